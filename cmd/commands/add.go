@@ -5,9 +5,12 @@ package commands
 
 import (
 	"bufio"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"github.com/open-cmsis-pack/cpackget/cmd/installer"
@@ -37,8 +40,60 @@ var addCmdFlags struct {
 
 	// Reports encoded progress for files and download when used by other tools
 	encodedProgress bool
+
+	// junitReport is the file to write a JUnit report of the added packs to
+	junitReport string
+
+	// watch keeps refreshing added PDSC files when they or the files next to them change
+	watch bool
+
+	// link installs packs by linking their source folder instead of extracting them
+	link bool
+
+	// slim skips the documentation and examples of packs
+	slim bool
+
+	// includeComponents and excludeComponents select the components of packs to install
+	includeComponents []string
+	excludeComponents []string
+
+	// toolchain skips the files of packs only other toolchains use
+	toolchain string
+
+	// preferCache installs exact pack versions found in .Download/ from there
+	preferCache bool
+
+	// forceDownload downloads packs again even if they are in .Download/
+	forceDownload bool
+
+	// refresh updates the public index before resolving pack versions
+	refresh bool
+
+	// keepGoing attempts every pack whatever fails and summarizes the outcome
+	keepGoing bool
+
+	// failFast stops at the first pack that fails
+	failFast bool
+
+	// rollback removes the packs added before the failure with failFast
+	rollback bool
+
+	// idempotent succeeds quietly for packs already installed
+	idempotent bool
+
+	// reinstall replaces installed packs with a fresh copy of the same version
+	reinstall bool
+
+	// signedURL treats the credentials and query of pack URLs as secrets
+	signedURL bool
+
+	// pipelineDepth is how many packs are downloaded ahead of the one being installed
+	pipelineDepth uint
 }
 
+// watchInterval is how often --watch looks for changes
+var watchInterval = time.Second
+
 var AddCmd = &cobra.Command{
 	Use:   "add [<pack> | -f <packs list>]",
 	Short: "Add Open-CMSIS-Pack packages",
@@ -60,6 +115,89 @@ Add a pack using the following "<pack>" specification or using packs provided by
 
   Use this syntax if you are installing a pack that has not
   been released yet. This will add a reference in ".Local/local_repository.pidx".
+  With --watch, cpackget keeps running and refreshes that reference, and touches
+  pack.idx, whenever the PDSC file or the files next to it change.
+
+  $ cpackget add --link path/to/Vendor.Pack.pdsc
+
+  Use this syntax while developing a pack. Instead of extracting files, it links
+  the folder of the PDSC file, or the given folder holding it, to
+  "CMSIS_PACK_ROOT/<vendor>/<packName>/<x.y.z>/", x.y.z being the latest release
+  in the PDSC file. Changes to the pack's files are seen right away, and removing
+  the pack only removes the link.
+
+  $ cpackget add --slim Vendor::Pack@1.2.3
+
+  Use this syntax to skip the documentation and examples of packs, i.e. component
+  files of category "doc", board books and example folders listed in their PDSC file.
+  Skipped files are listed in ".cpackget-skipped" next to the PDSC file, and
+  "cpackget update" keeps skipping them for newer versions of the pack.
+  Use "cpackget materialize" to extract them when they are needed.
+
+  $ cpackget add --include-component CMSIS:CORE --exclude-component "Device:*" Vendor::Pack@1.2.3
+
+  Use this syntax to only install the files of some components of packs, given as
+  Cclass[:Cgroup[:Csub]] glob patterns. Skipped files are listed like the ones of
+  --slim installs, and "cpackget update" applies the same filters to newer versions.
+
+  $ cpackget add --toolchain GCC Vendor::Pack@1.2.3
+
+  Use this syntax to skip the components and files that, by their PDSC conditions,
+  only apply to other toolchains, e.g. startup files for AC6 or IAR on a GCC-only
+  CI image. Conditions depending on devices or other components are not evaluated,
+  their files are kept. Skipped files are listed like the ones of --slim installs.
+
+  $ cpackget add --prefer-cache Vendor::Pack@1.2.3
+
+  Use this syntax to install a pack version already in "CMSIS_PACK_ROOT/.Download/"
+  from there, without looking up its URL, e.g. for repeat provisioning. It is still
+  verified, see --pack-hash-urls. Use --force-download to download packs again instead.
+
+  $ cpackget add --refresh Vendor::Pack
+
+  Use this syntax to update the public index, like "cpackget update-index", before
+  resolving pack versions. Otherwise a warning tells when the index was last refreshed
+  longer ago than --stale-index-days.
+
+  $ cpackget add --keep-going -f packs.txt
+
+  Use this syntax to attempt every pack of a batch even if refreshing the index or
+  adding some of them fails, and to finish with a summary of the added, skipped and
+  failed packs along with the reasons. With "--errors json", the summary is also
+  written to stderr as a JSON object.
+
+  $ cpackget add --fail-fast --rollback -f packs.txt
+
+  Use this syntax to stop at the first pack that fails, and with --rollback to
+  remove the packs, dependencies and PDSC files added before it, leaving the pack
+  root as it was before the command. Packs replaced by --force-reinstall and
+  downloaded pack files are kept.
+
+  $ cpackget add --idempotent Vendor::Pack@1.2.3
+
+  Use this syntax in scripts provisioning the same packs over and over. Packs
+  already installed are reported as such rather than as an error, and with
+  "--errors json" as {"pack": "...", "status": "PACK_ALREADY_INSTALLED"} on stderr.
+
+  $ cpackget add --reinstall Vendor::Pack@1.2.3
+
+  Use this syntax to replace the files of an installed pack with a fresh copy of
+  the same version, e.g. after they were modified. If installing fails, the files
+  installed before are restored. The license is not presented again, and packs
+  added with --slim or component filters keep them.
+
+  $ cpackget add --pipeline-depth 2 -f packs.txt
+
+  Use this syntax to download the next packs of a batch while the current one is
+  extracted, at most the given number of packs ahead. Packs given by URL or pack ID
+  are downloaded ahead, local files and installed packs are not.
+
+  $ cpackget add --signed-url "https://bucket.s3.amazonaws.com/Vendor.Pack.1.2.3.pack?X-Amz-Signature=..."
+
+  Use this syntax to download packs from pre-signed URLs, e.g. of S3 or with Azure
+  SAS tokens. The user info and query of URLs are sent as given but never shown:
+  messages, reports and errors only mention them redacted. --url-rewrites does not
+  apply to such URLs, as rewriting them would invalidate their signature.
 
   To install a specific version use: Vendor::Pack@x.y.z
   To install the newest version of the major version if a version greater equal x.y.z is not already installed use: Vendor::Pack@^x.y.z
@@ -73,8 +211,22 @@ Add a pack using the following "<pack>" specification or using packs provided by
 	PersistentPreRunE: configureInstaller,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		utils.SetEncodedProgress(addCmdFlags.encodedProgress)
+		utils.SetEncodedProgress(addCmdFlags.encodedProgress || ciMode)
 		utils.SetSkipTouch(addCmdFlags.skipTouch)
+		installer.SetSlim(addCmdFlags.slim)
+		installer.SetComponentFilters(addCmdFlags.includeComponents, addCmdFlags.excludeComponents)
+		if err := installer.SetToolchain(addCmdFlags.toolchain); err != nil {
+			return err
+		}
+
+		if addCmdFlags.preferCache && addCmdFlags.forceDownload {
+			log.Error("--prefer-cache and --force-download cannot be used together")
+			return errs.ErrIncorrectCmdArgs
+		}
+		installer.SetPreferCache(addCmdFlags.preferCache)
+		installer.SetForceDownload(addCmdFlags.forceDownload)
+		installer.SetIdempotent(addCmdFlags.idempotent)
+		utils.SetSignedURLs(addCmdFlags.signedURL)
 
 		if addCmdFlags.packsListFileName != "" {
 			log.Infof("Parsing packs urls via file %v", addCmdFlags.packsListFileName)
@@ -109,28 +261,336 @@ Add a pack using the following "<pack>" specification or using packs provided by
 			return errs.ErrIncorrectCmdArgs
 		}
 
-		log.Debugf("Specified packs %v", args)
-		var lastErr error
+		if addCmdFlags.link {
+			if addCmdFlags.watch {
+				log.Error("--link and --watch cannot be used together")
+				return errs.ErrIncorrectCmdArgs
+			}
+			for _, packPath := range args {
+				if filepath.Ext(packPath) != ".pdsc" && !utils.DirExists(packPath) {
+					log.Errorf("Only PDSC files or folders holding one can be linked, not \"%s\"", packPath)
+					return errs.ErrIncorrectCmdArgs
+				}
+			}
+		}
+
+		if addCmdFlags.reinstall {
+			if addCmdFlags.link || addCmdFlags.watch {
+				log.Error("--reinstall cannot be used with --link or --watch")
+				return errs.ErrIncorrectCmdArgs
+			}
+			for _, packPath := range args {
+				if filepath.Ext(packPath) == ".pdsc" {
+					log.Errorf("Only packs can be reinstalled, not \"%s\"", packPath)
+					return errs.ErrIncorrectCmdArgs
+				}
+			}
+		}
+
+		if addCmdFlags.watch {
+			for _, packPath := range args {
+				if filepath.Ext(packPath) != ".pdsc" {
+					log.Errorf("Only PDSC files can be watched, not \"%s\"", packPath)
+					return errs.ErrIncorrectCmdArgs
+				}
+			}
+		}
+
+		if addCmdFlags.failFast && addCmdFlags.keepGoing {
+			log.Error("--fail-fast and --keep-going cannot be used together")
+			return errs.ErrIncorrectCmdArgs
+		}
+		if addCmdFlags.rollback && !addCmdFlags.failFast {
+			log.Error("--rollback requires --fail-fast")
+			return errs.ErrIncorrectCmdArgs
+		}
+
+		shownArgs := make([]string, len(args))
+		for i, packPath := range args {
+			shownArgs[i] = utils.RedactURL(packPath)
+		}
+		log.Debugf("Specified packs %v", shownArgs)
 		installer.UnlockPackRoot()
-		for _, packPath := range args {
+		if addsPackIDs(args) {
+			if err := refreshOrWarnStaleIndex(addCmdFlags.refresh); err != nil {
+				if !addCmdFlags.keepGoing {
+					installer.LockPackRoot()
+					return err
+				}
+				log.Warnf("Going on with the current public index: %s", err)
+			}
+		}
+		var installedBefore map[string]installer.InstalledPackInfo
+		if addCmdFlags.rollback {
 			var err error
-			if filepath.Ext(packPath) == ".pdsc" {
-				err = installer.AddPdsc(packPath)
-			} else {
-				err = installer.AddPack(packPath, !addCmdFlags.skipEula, addCmdFlags.extractEula, addCmdFlags.forceReinstall, addCmdFlags.noRequirements, viper.GetInt("timeout"))
+			if installedBefore, err = installedPacksByID(); err != nil {
+				installer.LockPackRoot()
+				return err
 			}
-			if err != nil {
-				lastErr = err
-				if !errs.AlreadyLogged(err) {
-					log.Error(err)
-				}
+		}
+		results, err := addPacks(args, !addCmdFlags.skipEula, addCmdFlags.extractEula, addCmdFlags.forceReinstall, addCmdFlags.noRequirements, addCmdFlags.link)
+		if err != nil && addCmdFlags.rollback {
+			if rollbackErr := rollbackAddedPacks(installedBefore); rollbackErr != nil {
+				log.Errorf("Could not roll back the added packs: %s", rollbackErr)
 			}
 		}
 		installer.LockPackRoot()
-		return lastErr
+
+		if addCmdFlags.junitReport != "" {
+			if reportErr := writeJUnitReport(addCmdFlags.junitReport, "cpackget add", results); reportErr != nil {
+				log.Errorf("Could not write the JUnit report: %s", reportErr)
+				if err == nil {
+					err = reportErr
+				}
+			}
+		}
+
+		if err == nil && addCmdFlags.watch {
+			return installer.WatchPdscs(args, watchInterval)
+		}
+		return err
 	},
 }
 
+// addsPackIDs tells whether any of packPaths is a pack ID, whose version
+// is resolved from the public index, rather than a file or URL
+func addsPackIDs(packPaths []string) bool {
+	for _, packPath := range packPaths {
+		ext := filepath.Ext(packPath)
+		if ext != ".pack" && ext != ".zip" && ext != ".pdsc" && !strings.Contains(packPath, "://") && !utils.DirExists(packPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshOrWarnStaleIndex updates the public index of the unlocked pack root
+// with refresh, or warns if it is outdated otherwise
+func refreshOrWarnStaleIndex(refresh bool) error {
+	if !refresh {
+		installer.WarnIfIndexStale()
+		return nil
+	}
+	log.Info("Refreshing the public index")
+	return installer.UpdatePublicIndex("", true, false, false, false, viper.GetInt("concurrent-downloads"), viper.GetInt("timeout"))
+}
+
+// addPackResult is the outcome of adding one pack or PDSC file
+type addPackResult struct {
+	packPath string
+	duration time.Duration
+	err      error
+
+	// alreadyInstalled tells that the pack was installed before, with --idempotent
+	alreadyInstalled bool
+}
+
+// addPacks adds packs and PDSC files to the unlocked pack root, or links
+// them if link is set, going on after failures unless --fail-fast is given.
+// It returns the outcome of each and the last error.
+func addPacks(packPaths []string, checkEula, extractEula, forceReinstall, noRequirements, link bool) ([]addPackResult, error) {
+	var lastErr error
+	results := []addPackResult{}
+	if addCmdFlags.pipelineDepth > 0 && !link && !addCmdFlags.reinstall {
+		stopPrefetch := installer.PrefetchPacks(packPaths, int(addCmdFlags.pipelineDepth), viper.GetInt("timeout"))
+		defer stopPrefetch()
+	}
+	for _, packPath := range packPaths {
+		start := time.Now()
+		var err error
+		if link {
+			err = installer.LinkPack(packPath, forceReinstall)
+		} else if addCmdFlags.reinstall {
+			err = installer.ReinstallPack(packPath, viper.GetInt("timeout"))
+		} else if filepath.Ext(packPath) == ".pdsc" {
+			err = installer.AddPdsc(packPath)
+		} else {
+			err = installer.AddPack(packPath, checkEula, extractEula, forceReinstall, noRequirements, viper.GetInt("timeout"))
+		}
+		shownPath := utils.RedactURL(packPath)
+		result := addPackResult{packPath: shownPath, duration: time.Since(start), err: err}
+		if errs.Is(err, errs.ErrPackAlreadyInstalled) {
+			result.err, result.alreadyInstalled = nil, true
+			reportAlreadyInstalled(shownPath)
+			err = nil
+		}
+		results = append(results, result)
+		if err != nil {
+			lastErr = err
+			reportError(err, shownPath)
+			if !errs.AlreadyLogged(err) {
+				log.Error(err)
+			}
+			if addCmdFlags.failFast {
+				break
+			}
+		}
+	}
+
+	summarizeAddedPacks(results)
+	return results, lastErr
+}
+
+// reportAlreadyInstalled writes that packPath was already installed to the
+// errors output, for tools running "add --idempotent" to tell
+func reportAlreadyInstalled(packPath string) {
+	if errorsOutput == nil {
+		return
+	}
+	b, err := json.Marshal(map[string]string{"pack": packPath, "status": errs.Code(errs.ErrPackAlreadyInstalled)})
+	if err != nil {
+		log.Debugf("Could not encode the status: %s", err)
+		return
+	}
+	fmt.Fprintln(errorsOutput, string(b))
+}
+
+// installedPacksByID returns the packs in the unlocked pack root by pack ID,
+// or by PDSC file for the ones installed via PDSC file
+func installedPacksByID() (map[string]installer.InstalledPackInfo, error) {
+	packs, err := installer.GetInstalledPacks()
+	if err != nil {
+		return nil, err
+	}
+
+	packsByID := make(map[string]installer.InstalledPackInfo, len(packs))
+	for _, pack := range packs {
+		if pack.IsPdscInstalled {
+			packsByID[pack.PdscPath] = pack
+		} else {
+			packsByID[pack.Vendor+"::"+pack.Name+"@"+pack.Version] = pack
+		}
+	}
+	return packsByID, nil
+}
+
+// rollbackAddedPacks removes the packs and PDSC files added to the unlocked
+// pack root since installedBefore was taken by installedPacksByID
+func rollbackAddedPacks(installedBefore map[string]installer.InstalledPackInfo) error {
+	installedAfter, err := installedPacksByID()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for packID, pack := range installedAfter {
+		if _, found := installedBefore[packID]; found {
+			continue
+		}
+		log.Infof("Rolling back \"%s\"", packID)
+		if pack.IsPdscInstalled {
+			err = installer.RemovePdsc(pack.PdscPath)
+		} else {
+			err = installer.RemovePack(packID, false, viper.GetInt("timeout"))
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// licenseDeclined tells whether err comes from a pack's license not being agreed
+func licenseDeclined(err error) bool {
+	return errs.Is(err, errs.ErrEula) || errs.Is(err, errs.ErrEulaNotAgreed)
+}
+
+// summarizeAddedPacks reports the outcome of addPacks in CI mode, with
+// --keep-going and in GitHub Actions
+func summarizeAddedPacks(results []addPackResult) {
+	failed := []addPackResult{}
+	declined := []string{}
+	alreadyInstalled := 0
+	summary := []string{"### cpackget add", "", "| Pack | Result |", "| --- | --- |"}
+	for _, result := range results {
+		switch {
+		case result.alreadyInstalled:
+			alreadyInstalled++
+			summary = append(summary, fmt.Sprintf("| %s | already installed |", result.packPath))
+		case licenseDeclined(result.err):
+			declined = append(declined, result.packPath)
+			summary = append(summary, fmt.Sprintf("| %s | license declined |", result.packPath))
+		case result.err != nil:
+			failed = append(failed, result)
+			summary = append(summary, fmt.Sprintf("| %s | failed: %s |", result.packPath, result.err))
+		default:
+			summary = append(summary, fmt.Sprintf("| %s | added |", result.packPath))
+		}
+	}
+	appendGithubStepSummary(summary...)
+
+	if ciMode || addCmdFlags.keepGoing {
+		added := len(results) - len(failed) - len(declined)
+		if len(declined) > 0 {
+			log.Infof("Summary: %d of %d pack(s) added, %d license(s) declined", added, len(results), len(declined))
+		} else {
+			log.Infof("Summary: %d of %d pack(s) added", added, len(results))
+		}
+		if alreadyInstalled > 0 {
+			log.Infof("%d of these pack(s) were already installed", alreadyInstalled)
+		}
+		for _, packPath := range declined {
+			log.Warnf("Not added %s, its license was not agreed", packPath)
+		}
+		for _, result := range failed {
+			log.Errorf("Failed adding %s: %s", result.packPath, result.err)
+		}
+	}
+
+	if addCmdFlags.keepGoing {
+		emitAddSummary(results)
+	}
+}
+
+// addSummaryEntry is a pack that was not added, along with the reason
+type addSummaryEntry struct {
+	Pack   string          `json:"pack"`
+	Reason json.RawMessage `json:"reason"`
+}
+
+// emitAddSummary writes the outcome of addPacks to the errors output as
+// {"summary": {"added": [...], "skipped": [...], "failed": [...]}}
+func emitAddSummary(results []addPackResult) {
+	if errorsOutput == nil {
+		return
+	}
+
+	summary := struct {
+		Added            []string          `json:"added"`
+		Skipped          []addSummaryEntry `json:"skipped"`
+		Failed           []addSummaryEntry `json:"failed"`
+		AlreadyInstalled []string          `json:"alreadyInstalled"`
+	}{[]string{}, []addSummaryEntry{}, []addSummaryEntry{}, []string{}}
+	for _, result := range results {
+		if result.alreadyInstalled {
+			summary.AlreadyInstalled = append(summary.AlreadyInstalled, result.packPath)
+			continue
+		}
+		if result.err == nil {
+			summary.Added = append(summary.Added, result.packPath)
+			continue
+		}
+		reason, err := errs.JSON(result.err)
+		if err != nil {
+			log.Debugf("Could not encode the error: %s", err)
+			continue
+		}
+		entry := addSummaryEntry{result.packPath, reason}
+		if licenseDeclined(result.err) {
+			summary.Skipped = append(summary.Skipped, entry)
+		} else {
+			summary.Failed = append(summary.Failed, entry)
+		}
+	}
+
+	b, err := json.Marshal(map[string]any{"summary": summary})
+	if err != nil {
+		log.Debugf("Could not encode the summary: %s", err)
+		return
+	}
+	fmt.Fprintln(errorsOutput, string(b))
+}
+
 func init() {
 	AddCmd.Flags().BoolVarP(&addCmdFlags.skipEula, "agree-embedded-license", "a", false, "agrees with the embedded license of the pack")
 	AddCmd.Flags().BoolVarP(&addCmdFlags.extractEula, "extract-embedded-license", "x", false, "extracts the embedded license of the pack and aborts the installation")
@@ -139,6 +599,23 @@ func init() {
 	AddCmd.Flags().StringVarP(&addCmdFlags.packsListFileName, "packs-list-filename", "f", "", "specifies a file listing packs urls, one per line")
 	AddCmd.Flags().BoolVar(&addCmdFlags.skipTouch, "skip-touch", false, "do not touch pack.idx")
 	AddCmd.Flags().BoolVarP(&addCmdFlags.encodedProgress, "encoded-progress", "E", false, "Reports encoded progress for files and download when used by other tools")
+	AddCmd.Flags().StringVar(&addCmdFlags.junitReport, "junit-report", "", "writes a JUnit XML report with one test case per pack to the given file")
+	AddCmd.Flags().BoolVar(&addCmdFlags.watch, "watch", false, "keeps refreshing added PDSC files when they or the files next to them change")
+	AddCmd.Flags().BoolVar(&addCmdFlags.link, "link", false, "installs packs from PDSC files by linking their folder instead of extracting them, for pack development")
+	AddCmd.Flags().BoolVar(&addCmdFlags.slim, "slim", false, "skips the documentation and examples of packs")
+	AddCmd.Flags().StringSliceVar(&addCmdFlags.includeComponents, "include-component", nil, "only installs the files of components matching Cclass[:Cgroup[:Csub]] glob patterns")
+	AddCmd.Flags().StringSliceVar(&addCmdFlags.excludeComponents, "exclude-component", nil, "skips the files of components matching Cclass[:Cgroup[:Csub]] glob patterns")
+	AddCmd.Flags().StringVar(&addCmdFlags.toolchain, "toolchain", "", "skips the components and files whose PDSC conditions only hold with other toolchains than AC6, GCC or IAR")
+	AddCmd.Flags().BoolVar(&addCmdFlags.preferCache, "prefer-cache", false, "installs exact pack versions found in .Download/ from there, without looking up their URL")
+	AddCmd.Flags().BoolVar(&addCmdFlags.forceDownload, "force-download", false, "downloads packs again even if they are in .Download/")
+	AddCmd.Flags().BoolVar(&addCmdFlags.refresh, "refresh", false, "updates the public index before resolving pack versions")
+	AddCmd.Flags().BoolVar(&addCmdFlags.reinstall, "reinstall", false, "replaces installed packs with a fresh copy of the same version, restoring them if that fails")
+	AddCmd.Flags().UintVar(&addCmdFlags.pipelineDepth, "pipeline-depth", 0, "downloads up to the given number of packs ahead of the one being installed. Disabled by default")
+	AddCmd.Flags().BoolVar(&addCmdFlags.signedURL, "signed-url", false, "treats the user info and query of pack URLs as credentials, e.g. of pre-signed URLs, never rewriting nor showing them")
+	AddCmd.Flags().BoolVar(&addCmdFlags.idempotent, "idempotent", false, "succeeds quietly for packs already installed, reporting them as such")
+	AddCmd.Flags().BoolVar(&addCmdFlags.failFast, "fail-fast", false, "stops at the first pack that fails")
+	AddCmd.Flags().BoolVar(&addCmdFlags.rollback, "rollback", false, "removes the packs added before the failure with --fail-fast")
+	AddCmd.Flags().BoolVar(&addCmdFlags.keepGoing, "keep-going", false, "attempts every pack whatever fails and prints a summary of the added, skipped and failed packs")
 
 	AddCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
 		// Small workaround to keep the linter happy, not
@@ -9,6 +9,9 @@ import (
 	"testing"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -16,6 +19,8 @@ var (
 	fileWithPacksListed   = "file_with_listed_packs.txt"
 	fileWithNoPacksListed = "file_with_no_listed_packs.txt"
 	pdscFilePath          = filepath.Join(testingDir, "1.2.3", "TheVendor.PackName.pdsc")
+	packWithLicensePath   = filepath.Join(testingDir, "TheVendor.PackWithLicense.1.2.3.pack")
+	junitReport           = "junit_report.xml"
 )
 
 var addCmdTests = []TestCase{
@@ -52,18 +57,229 @@ var addCmdTests = []TestCase{
 		expectedStdout: []string{"File", "DoesNotExist.Pack.1.2.3.pack", "doesn't exist"},
 		expectedErr:    errs.ErrFileNotFound,
 	},
+	{
+		name:           "test adding pack missing file with json errors",
+		args:           []string{"add", "DoesNotExist.Pack.1.2.3.pack"},
+		createPackRoot: true,
+		env:            map[string]string{"CPACKGET_ERRORS": "json"},
+		expectedStderr: []string{`{"code":"FILE_NOT_FOUND"`, `"pack":"DoesNotExist.Pack.1.2.3.pack"`, `"hint":"Check the path of the file"}`},
+		expectedErr:    errs.ErrFileNotFound,
+	},
+	{
+		name:           "test adding pack with unknown errors format",
+		args:           []string{"add", packFilePath},
+		createPackRoot: true,
+		env:            map[string]string{"CPACKGET_ERRORS": "xml"},
+		expectedStdout: []string{"--errors must be either \"text\" or \"json\""},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
 	{
 		name:           "test adding pack file",
 		args:           []string{"add", packFilePath},
 		createPackRoot: true,
 		expectedStdout: []string{"Adding pack", filepath.Base(packFilePath)},
 	},
+	{
+		name:           "test adding pack with license in ci mode",
+		args:           []string{"add", packWithLicensePath},
+		createPackRoot: true,
+		env:            map[string]string{"CI": "true"},
+		expectedStdout: []string{"embedded license must be agreed", "Summary: 0 of 1 pack(s) added"},
+		expectedErr:    errs.ErrEulaNotAgreed,
+	},
+	{
+		name:           "test adding pack with agreed license in ci mode",
+		args:           []string{"add", "-a", packWithLicensePath},
+		createPackRoot: true,
+		env:            map[string]string{"GITHUB_ACTIONS": "true"},
+		expectedStdout: []string{"Summary: 1 of 1 pack(s) added"},
+	},
+	{
+		name:           "test adding pack file in a detected ci environment",
+		args:           []string{"add", packFilePath},
+		createPackRoot: true,
+		env:            map[string]string{"GITLAB_CI": "true"},
+		expectedStdout: []string{"Summary: 1 of 1 pack(s) added"},
+	},
+	{
+		name:           "test adding pack file in ci mode without colors",
+		args:           []string{"add", "DoesNotExist.Pack.1.2.3.pack"},
+		createPackRoot: true,
+		env:            map[string]string{"CI": "true"},
+		expectedStdout: []string{"level=error"},
+		expectedErr:    errs.ErrFileNotFound,
+		setUpFunc: func(t *TestCase) {
+			log.SetFormatter(&log.TextFormatter{ForceColors: true, DisableTimestamp: true})
+		},
+		tearDownFunc: func() {
+			log.SetFormatter(new(LogFormatter))
+		},
+	},
+	{
+		name:           "test adding pack file outside of ci",
+		args:           []string{"add", packFilePath},
+		createPackRoot: true,
+		env:            map[string]string{"CI": "false"},
+		expectedStdout: []string{"Adding pack", filepath.Base(packFilePath)},
+	},
+	{
+		name:           "test adding packs with a junit report",
+		args:           []string{"add", "--junit-report", junitReport, packFilePath, "DoesNotExist.Pack.1.2.3.pack"},
+		createPackRoot: true,
+		expectedErr:    errs.ErrFileNotFound,
+		tearDownFunc: func() {
+			os.Remove(junitReport)
+		},
+		validationFunc: func(t *testing.T) {
+			report, err := os.ReadFile(junitReport)
+			assert.Nil(t, err)
+			assert.Contains(t, string(report), `<testsuite name="cpackget add" tests="2" failures="1"`)
+			assert.Contains(t, string(report), `<testcase name="`+packFilePath+`"`)
+			assert.Contains(t, string(report), `<failure message="file not found">Failed adding DoesNotExist.Pack.1.2.3.pack`)
+		},
+	},
+	{
+		name:           "test adding packs with a declined license and a junit report",
+		args:           []string{"add", "--junit-report", junitReport, packFilePath, packWithLicensePath},
+		createPackRoot: true,
+		env:            map[string]string{"CI": "true"},
+		expectedStdout: []string{"Summary: 1 of 2 pack(s) added, 1 license(s) declined", "Not added " + packWithLicensePath},
+		expectedErr:    errs.ErrEulaNotAgreed,
+		tearDownFunc: func() {
+			os.Remove(junitReport)
+		},
+		validationFunc: func(t *testing.T) {
+			report, err := os.ReadFile(junitReport)
+			assert.Nil(t, err)
+			assert.Contains(t, string(report), `<testsuite name="cpackget add" tests="2" failures="0" skipped="1"`)
+			assert.Contains(t, string(report), `<skipped message="embedded license must be agreed`)
+		},
+	},
+	{
+		name:           "test adding packs keeping going",
+		args:           []string{"add", "--keep-going", "DoesNotExist.Pack.1.2.3.pack", packFilePath},
+		createPackRoot: true,
+		env:            map[string]string{"CPACKGET_ERRORS": "json"},
+		expectedStdout: []string{"Adding pack", "Summary: 1 of 2 pack(s) added", "Failed adding DoesNotExist.Pack.1.2.3.pack: "},
+		expectedStderr: []string{`{"summary":{"added":["` + packFilePath + `"],"skipped":[],"failed":[{"pack":"DoesNotExist.Pack.1.2.3.pack","reason":{"code":"FILE_NOT_FOUND"`},
+		expectedErr:    errs.ErrFileNotFound,
+	},
+	{
+		name:           "test adding packs failing fast",
+		args:           []string{"add", "--fail-fast", "DoesNotExist.Pack.1.2.3.pack", packFilePath},
+		createPackRoot: true,
+		expectedErr:    errs.ErrFileNotFound,
+		validationFunc: func(t *testing.T) {
+			assert.NoDirExists(t, filepath.Join("test_adding_packs_failing_fast", "TheVendor", "PublicLocalPack"))
+		},
+	},
+	{
+		name:           "test adding packs rolling back",
+		args:           []string{"add", "--fail-fast", "--rollback", packFilePath, pdscFilePath, "DoesNotExist.Pack.1.2.3.pack"},
+		createPackRoot: true,
+		expectedStdout: []string{"Rolling back \"TheVendor::PublicLocalPack@1.2.3\"", "Rolling back"},
+		expectedErr:    errs.ErrFileNotFound,
+		validationFunc: func(t *testing.T) {
+			assert.NoDirExists(t, filepath.Join("test_adding_packs_rolling_back", "TheVendor", "PublicLocalPack", "1.2.3"))
+			localPidx, err := os.ReadFile(filepath.Join("test_adding_packs_rolling_back", ".Local", "local_repository.pidx"))
+			assert.Nil(t, err)
+			assert.NotContains(t, string(localPidx), "PackName")
+		},
+	},
+	{
+		name:           "test rolling back without failing fast",
+		args:           []string{"add", "--rollback", packFilePath},
+		createPackRoot: true,
+		expectedStdout: []string{"--rollback requires --fail-fast"},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test adding packs failing fast and keeping going",
+		args:           []string{"add", "--fail-fast", "--keep-going", packFilePath},
+		createPackRoot: true,
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test adding an installed pack idempotently",
+		args:           []string{"add", "--idempotent", packFilePath, packFilePath},
+		createPackRoot: true,
+		env:            map[string]string{"CPACKGET_ERRORS": "json"},
+		expectedStdout: []string{"is already installed here"},
+		expectedStderr: []string{`{"pack":"` + packFilePath + `","status":"PACK_ALREADY_INSTALLED"}`},
+	},
+	{
+		name:           "test reinstalling a pack not installed",
+		args:           []string{"add", "--reinstall", packFilePath},
+		createPackRoot: true,
+		expectedStdout: []string{"it cannot be reinstalled"},
+		expectedErr:    errs.ErrPackNotInstalled,
+	},
+	{
+		name:           "test reinstalling a pdsc file",
+		args:           []string{"add", "--reinstall", pdscFilePath},
+		createPackRoot: true,
+		expectedStdout: []string{"Only packs can be reinstalled"},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test adding pack with an invalid license allowlist",
+		args:           []string{"add", packWithLicensePath},
+		createPackRoot: true,
+		env:            map[string]string{"CPACKGET_EULA_ALLOWLIST": fileWithPacksListed},
+		expectedStdout: []string{"is not a SHA-256 hash"},
+		expectedErr:    errs.ErrInvalidEulaAllowlist,
+		setUpFunc: func(t *TestCase) {
+			t.assert.Nil(os.WriteFile(fileWithPacksListed, []byte(packWithLicensePath), 0600))
+		},
+		tearDownFunc: func() {
+			os.Remove(fileWithPacksListed)
+		},
+	},
 	{
 		name:           "test adding pack via pdsc file",
 		args:           []string{"add", pdscFilePath},
 		createPackRoot: true,
 		expectedStdout: []string{"Adding pdsc", filepath.Base(pdscFilePath)},
 	},
+	{
+		name:           "test watching a pdsc file",
+		args:           []string{"add", "--watch", pdscFilePath},
+		createPackRoot: true,
+		expectedStdout: []string{"Adding pdsc", "for changes, press Ctrl+C to stop", "Stopping watching"},
+		setUpFunc: func(t *TestCase) {
+			utils.ShouldAbortFunction = func() bool {
+				return true
+			}
+		},
+		tearDownFunc: func() {
+			utils.ShouldAbortFunction = nil
+		},
+	},
+	{
+		name:           "test watching a pack file",
+		args:           []string{"add", "--watch", packFilePath},
+		createPackRoot: true,
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test linking a pack file",
+		args:           []string{"add", "--link", packFilePath},
+		createPackRoot: true,
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test adding a pack preferring the cache and forcing its download",
+		args:           []string{"add", "--prefer-cache", "--force-download", packFilePath},
+		createPackRoot: true,
+		expectedStdout: []string{"--prefer-cache and --force-download cannot be used together"},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test adding a pack preferring the cache",
+		args:           []string{"add", "--prefer-cache", packFilePath},
+		createPackRoot: true,
+		expectedStdout: []string{"Adding pack", filepath.Base(packFilePath)},
+	},
 	{
 		name:           "test adding packs listed in file",
 		args:           []string{"add", "-f", fileWithPacksListed},
@@ -114,3 +330,34 @@ var addCmdTests = []TestCase{
 func TestAddCmd(t *testing.T) {
 	runTests(t, addCmdTests)
 }
+
+func TestAddCmdInGithubActions(t *testing.T) {
+	githubStepSummary := filepath.Join(t.TempDir(), "github_step_summary.md")
+	runTests(t, []TestCase{
+		{
+			name:           "test adding pack missing file in github actions",
+			args:           []string{"add", "DoesNotExist.Pack.1.2.3.pack"},
+			createPackRoot: true,
+			env:            map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_STEP_SUMMARY": githubStepSummary},
+			expectedStdout: []string{"::error::File", "DoesNotExist.Pack.1.2.3.pack"},
+			expectedErr:    errs.ErrFileNotFound,
+			validationFunc: func(t *testing.T) {
+				summary, err := os.ReadFile(githubStepSummary)
+				assert.Nil(t, err)
+				assert.Contains(t, string(summary), "| DoesNotExist.Pack.1.2.3.pack | failed: ")
+			},
+		},
+		{
+			name:           "test adding pack with a declined license in github actions",
+			args:           []string{"add", packWithLicensePath},
+			createPackRoot: true,
+			env:            map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_STEP_SUMMARY": githubStepSummary},
+			expectedErr:    errs.ErrEulaNotAgreed,
+			validationFunc: func(t *testing.T) {
+				summary, err := os.ReadFile(githubStepSummary)
+				assert.Nil(t, err)
+				assert.Contains(t, string(summary), "| "+packWithLicensePath+" | license declined |")
+			},
+		},
+	})
+}
@@ -0,0 +1,141 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var cachePruneCmdFlags struct {
+	// olderThan is the minimum age of the cached files to remove
+	olderThan string
+
+	// unused keeps cached files of installed pack versions
+	unused bool
+}
+
+var cacheStatsCmdFlags struct {
+	// json prints the statistics as a JSON document
+	json bool
+}
+
+var CacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage cached pack files",
+	Long:  "Manage the pack files cached in the .Download folder of the pack root",
+	Args:  cobra.MaximumNArgs(0),
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune [--older-than <age>] [--unused]",
+	Short: "Remove old or unused cached pack files",
+	Long: `
+Remove cached pack and PDSC files from .Download/:
+
+  $ cpackget cache prune --older-than 90d --unused
+
+--older-than only removes files last modified before the given age, e.g. "90d",
+"2w" or "36h". --unused keeps the files of installed pack versions. At least one
+of them is required.`,
+	Args:              cobra.MaximumNArgs(0),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var olderThan time.Duration
+		if cachePruneCmdFlags.olderThan != "" {
+			var err error
+			if olderThan, err = parseAge(cachePruneCmdFlags.olderThan); err != nil {
+				log.Errorf("Invalid age \"%s\", use e.g. 90d, 2w or 36h", cachePruneCmdFlags.olderThan)
+				return errs.ErrIncorrectCmdArgs
+			}
+		} else if !cachePruneCmdFlags.unused {
+			log.Error("Specify which files to prune with --older-than and/or --unused")
+			return errs.ErrIncorrectCmdArgs
+		}
+
+		installer.UnlockPackRoot()
+		err := installer.PruneCache(olderThan, cachePruneCmdFlags.unused)
+		installer.LockPackRoot()
+		return err
+	},
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats [--json]",
+	Short: "Print how often packs were installed from the cache",
+	Long: `
+Print how often packs were installed from .Download/ rather than downloaded,
+the bytes this saved, and the files it holds, helping to size shared caches:
+
+  $ cpackget cache stats
+  I: Hits: 12 of 16 install(s), 75.0%
+  I: Saved: 1.2 GiB, downloaded: 310.0 MiB
+  ...
+
+Installs are counted in .Download/, so pack roots sharing a cache through
+--cache-dir count in the same statistics. --json prints them as a JSON document.`,
+	Args:              cobra.MaximumNArgs(0),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stats, err := installer.GetCacheStats()
+		if err != nil {
+			return err
+		}
+
+		if cacheStatsCmdFlags.json {
+			return printJSON(cmd, stats)
+		}
+
+		log.Infof("Hits: %d of %d install(s), %.1f%%", stats.Hits, stats.Hits+stats.Misses, stats.HitRate())
+		log.Infof("Saved: %s, downloaded: %s", utils.FormatBytes(uint64(stats.BytesSaved)), utils.FormatBytes(uint64(stats.BytesDownloaded)))
+		if stats.Since != nil {
+			log.Infof("Counting since: %s", stats.Since.Format(time.RFC3339))
+		}
+		log.Infof("Entries: %d, %s", stats.Entries, utils.FormatBytes(uint64(stats.Size)))
+		if stats.Oldest != nil {
+			log.Infof("Oldest: %s (%s)", stats.Oldest.Name, stats.Oldest.Modified.Format(time.RFC3339))
+			log.Infof("Newest: %s (%s)", stats.Newest.Name, stats.Newest.Modified.Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+// parseAge parses durations like time.ParseDuration does,
+// also accepting days ("90d") and weeks ("2w")
+func parseAge(age string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if count, found := strings.CutSuffix(age, suffix); found {
+			value, err := strconv.ParseUint(count, 10, 32)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(value) * unit, nil
+		}
+	}
+	return time.ParseDuration(age)
+}
+
+func init() {
+	cachePruneCmd.Flags().StringVar(&cachePruneCmdFlags.olderThan, "older-than", "", "only prunes files last modified before this age, e.g. 90d")
+	cachePruneCmd.Flags().BoolVar(&cachePruneCmdFlags.unused, "unused", false, "only prunes files of pack versions that are not installed")
+	cacheStatsCmd.Flags().BoolVar(&cacheStatsCmdFlags.json, "json", false, "print the statistics as JSON")
+	CacheCmd.AddCommand(cachePruneCmd, cacheStatsCmd)
+
+	cachePruneCmd.SetHelpFunc(CacheCmd.HelpFunc())
+	cacheStatsCmd.SetHelpFunc(CacheCmd.HelpFunc())
+	CacheCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
+		err := command.Flags().MarkHidden("concurrent-downloads")
+		_ = command.Flags().MarkHidden("timeout")
+		log.Debug(err)
+		command.Parent().HelpFunc()(command, strings)
+	})
+}
@@ -0,0 +1,63 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+)
+
+var cacheCmdTests = []TestCase{
+	{
+		name:        "test help command",
+		args:        []string{"help", "cache", "prune"},
+		expectedErr: nil,
+	},
+	{
+		name:           "test pruning cache without criteria",
+		args:           []string{"cache", "prune"},
+		createPackRoot: true,
+		expectedStdout: []string{"Specify which files to prune"},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test pruning cache with an invalid age",
+		args:           []string{"cache", "prune", "--older-than", "ninety days"},
+		createPackRoot: true,
+		expectedStdout: []string{"Invalid age \"ninety days\""},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test pruning cache",
+		args:           []string{"cache", "prune", "--older-than", "90d", "--unused"},
+		createPackRoot: true,
+		expectedStdout: []string{"Pruned 0 cached file(s)"},
+	},
+	{
+		name:           "test printing cache statistics",
+		args:           []string{"cache", "stats"},
+		createPackRoot: true,
+		expectedStdout: []string{"Hits: 2 of 3 install(s), 66.7%", "Saved: 3.0 KiB, downloaded: 1.0 KiB", "Entries: 1, 10.0 B", "Oldest: Vendor.Pack.1.2.3.pack"},
+		setUpFunc: func(t *TestCase) {
+			downloadDir := filepath.Join(os.Getenv("CMSIS_PACK_ROOT"), ".Download")
+			stats := `{"hits": 2, "misses": 1, "bytesSaved": 3072, "bytesDownloaded": 1024}`
+			t.assert.Nil(os.WriteFile(filepath.Join(downloadDir, installer.CacheStatsName), []byte(stats), 0600))
+			t.assert.Nil(os.WriteFile(filepath.Join(downloadDir, "Vendor.Pack.1.2.3.pack"), []byte("0123456789"), 0600))
+		},
+	},
+	{
+		name:           "test printing cache statistics as json",
+		args:           []string{"cache", "stats", "--json"},
+		createPackRoot: true,
+		expectedStdout: []string{`"hits": 0`, `"misses": 0`, `"entries": 0`},
+	},
+}
+
+func TestCacheCmd(t *testing.T) {
+	runTests(t, cacheCmdTests)
+}
@@ -0,0 +1,38 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/spf13/cobra"
+)
+
+var CatCmd = &cobra.Command{
+	Use:   "cat <pack> [<file>]",
+	Short: "Print a file of a pack",
+	Long: `
+Print a file of a pack archive or of an installed pack to stdout, without extracting
+anything else, e.g. its PDSC file or a linker script:
+
+  $ cpackget cat Vendor.Pack.1.2.3.pack Device/Source/gcc_linker_script.ld
+  $ cpackget cat Vendor::Pack@1.2.3 Device/Source/gcc_linker_script.ld
+
+The file is relative to the folder of the pack's PDSC file, and defaults to the
+PDSC file itself. Installed packs default to their latest installed version.`,
+	Args: cobra.RangeArgs(1, 2),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Pack archives do not need a pack root
+		if installer.IsPackFile(args[0]) {
+			return configureInstallerGlobalCmd(cmd, args)
+		}
+		return configureInstaller(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fileName := ""
+		if len(args) > 1 {
+			fileName = args[1]
+		}
+		return installer.CatPackFile(args[0], fileName, cmd.OutOrStdout())
+	},
+}
@@ -0,0 +1,48 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+)
+
+// catTestPack is a pack the cat command tests print files of
+var catTestPack = filepath.Join("..", "..", "testdata", "integration", "1.2.3", "TheVendor.NonPublicLocalPack.1.2.3.pack")
+
+var catCmdTests = []TestCase{
+	{
+		name:        "test different number of parameters",
+		args:        []string{"cat"},
+		expectedErr: errors.New("accepts between 1 and 2 arg(s), received 0"),
+	},
+	{
+		name:        "test help command",
+		args:        []string{"help", "cat"},
+		expectedErr: nil,
+	},
+	{
+		name:           "test printing the pdsc file of a pack archive",
+		args:           []string{"cat", catTestPack},
+		expectedStdout: []string{"<name>NonPublicLocalPack</name>"},
+	},
+	{
+		name:        "test printing a file missing from a pack archive",
+		args:        []string{"cat", catTestPack, "missing_file"},
+		expectedErr: errs.WithPath(errs.ErrFileNotFound, "missing_file"),
+	},
+	{
+		name:           "test printing a file of a pack not installed",
+		args:           []string{"cat", "TheVendor::PackName"},
+		createPackRoot: true,
+		expectedErr:    errs.ErrPackNotInstalled,
+	},
+}
+
+func TestCatCmd(t *testing.T) {
+	runTests(t, catCmdTests)
+}
@@ -4,7 +4,11 @@
 package commands
 
 import (
+	"path/filepath"
+
 	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -15,21 +19,48 @@ var checksumCreateCmdFlags struct {
 
 	// outputDir is the target directory where the checksum file is written to
 	outputDir string
+
+	// embed writes the checksum file into the pack instead
+	embed bool
+
+	// root writes a manifest of the whole pack root instead
+	root bool
+
+	// keyPath is the PGP private key signing the manifest of the pack root
+	keyPath string
+
+	// passphraseFile holds the passphrase of an encrypted private key
+	passphraseFile string
 }
 
 var checksumVerifyCmdFlags struct {
 	// checksumPath is the path of the checksum file
 	checksumPath string
+
+	// sarifReport is the file to write a SARIF log of the findings to
+	sarifReport string
+
+	// root verifies the whole pack root against a manifest instead
+	root bool
+
+	// pubKeyPath is the PGP public key the manifest must be signed with
+	pubKeyPath string
 }
 
 func init() {
 	ChecksumCreateCmd.Flags().StringVarP(&checksumCreateCmdFlags.hashAlgorithm, "hash-function", "a", cryptography.Hashes[0], "specifies the hash function to be used")
 	ChecksumCreateCmd.Flags().StringVarP(&checksumCreateCmdFlags.outputDir, "output-dir", "o", "", "specifies output directory for the checksum file")
+	ChecksumCreateCmd.Flags().BoolVarP(&checksumCreateCmdFlags.embed, "embed", "e", false, "embeds the checksum file into the pack, written to the output directory if given")
+	ChecksumCreateCmd.Flags().BoolVar(&checksumCreateCmdFlags.root, "root", false, "writes a manifest of all the packs installed in the pack root instead, to the output directory if given")
+	ChecksumCreateCmd.Flags().StringVarP(&checksumCreateCmdFlags.keyPath, "private-key", "k", "", "signs the manifest of the pack root with the given PGP private key")
+	ChecksumCreateCmd.Flags().StringVar(&checksumCreateCmdFlags.passphraseFile, "passphrase-file", "", "read the passphrase of the encrypted private key from the first line of a file, defaults to CPACKGET_PASSPHRASE environment variable, then to asking for it")
 	ChecksumVerifyCmd.Flags().StringVarP(&checksumVerifyCmdFlags.checksumPath, "path", "p", "", "path of the checksum file")
+	ChecksumVerifyCmd.Flags().BoolVar(&checksumVerifyCmdFlags.root, "root", false, "verifies all the packs installed in the pack root against a manifest instead")
+	ChecksumVerifyCmd.Flags().StringVarP(&checksumVerifyCmdFlags.pubKeyPath, "pub-key", "k", "", "requires the manifest of the pack root to be signed with the given PGP public key")
+	ChecksumVerifyCmd.Flags().StringVar(&checksumVerifyCmdFlags.sarifReport, "sarif", "", "writes a SARIF log of the findings to the given file, for code scanning dashboards")
 
 	ChecksumCreateCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
-		err := command.Flags().MarkHidden("pack-root")
-		_ = command.Flags().MarkHidden("concurrent-downloads")
+		err := command.Flags().MarkHidden("concurrent-downloads")
 		_ = command.Flags().MarkHidden("timeout")
 		log.Debug(err)
 		command.Parent().HelpFunc()(command, strings)
@@ -54,10 +85,58 @@ of the contents of the pack. Example <Vendor.Pack.1.2.3.sha256.checksum> file:
 The default Cryptographic Hash Function used is "` + cryptography.Hashes[0] + `". In the future other hash functions
 might be supported. The used function will be prefixed to the ".checksum" extension.
 
-By default the checksum file will be created in the same directory as the provided pack.`,
-	Args:              cobra.ExactArgs(1),
+By default the checksum file will be created in the same directory as the provided pack.
+
+With "--embed", the checksum file is embedded into the pack instead, as
+".checksum/sha256.checksum" next to its PDSC file, and "cpackget add" verifies the
+extracted files against it. The pack is changed in place unless "--output-dir" is given.
+Embed checksums before signing packs, or use "signature-create --embed-checksum".
+
+With "--root", a manifest of all the packs installed in the pack root is written
+instead, "` + cryptography.RootManifestName + `" in the output directory, by default the
+current one. It lists the packs and the digests of their files, for a copy of the
+pack root, e.g. in a cloned machine image, to be verified with "checksum-verify --root".
+Given a PGP private key with "--private-key", the manifest is also signed, in a
+detached ".sig" file next to it:
+
+  $ cpackget checksum-create --root --pack-root /opt/packs --private-key signer.asc`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if checksumCreateCmdFlags.root {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	PersistentPreRunE: configureInstallerGlobalCmd,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if checksumCreateCmdFlags.root {
+			if checksumCreateCmdFlags.embed {
+				log.Error("--root and --embed cannot be used together")
+				return errs.ErrIncorrectCmdArgs
+			}
+			if checksumCreateCmdFlags.outputDir != "" && !utils.DirExists(checksumCreateCmdFlags.outputDir) {
+				return errs.WithPath(errs.ErrDirectoryNotFound, checksumCreateCmdFlags.outputDir)
+			}
+			passphrase, err := cryptography.ReadPassphrase(checksumCreateCmdFlags.passphraseFile)
+			if err != nil {
+				return err
+			}
+			manifestPath := filepath.Join(checksumCreateCmdFlags.outputDir, cryptography.RootManifestName)
+			return cryptography.GenerateRootManifest(firstPackRoot(), manifestPath, checksumCreateCmdFlags.hashAlgorithm, Version, checksumCreateCmdFlags.keyPath, passphrase)
+		}
+		if checksumCreateCmdFlags.keyPath != "" {
+			log.Error("--private-key only signs the manifest of the pack root, use it with --root")
+			return errs.ErrIncorrectCmdArgs
+		}
+		if checksumCreateCmdFlags.embed {
+			destinationPack := args[0]
+			if checksumCreateCmdFlags.outputDir != "" {
+				if !utils.DirExists(checksumCreateCmdFlags.outputDir) {
+					return errs.WithPath(errs.ErrDirectoryNotFound, checksumCreateCmdFlags.outputDir)
+				}
+				destinationPack = filepath.Join(checksumCreateCmdFlags.outputDir, filepath.Base(args[0]))
+			}
+			return cryptography.EmbedChecksum(args[0], destinationPack, checksumCreateCmdFlags.hashAlgorithm)
+		}
 		return cryptography.GenerateChecksum(args[0], checksumCreateCmdFlags.outputDir, checksumCreateCmdFlags.hashAlgorithm)
 	},
 }
@@ -73,10 +152,36 @@ with "checksum-create"), present in the same directory:
 
 The used hash function is inferred from the checksum filename, and if any of the digests
 computed doesn't match the one provided in the checksum file an error will be thrown.
-If the .checksum file is in another directory, specify it with the -p/--path flag`,
-	Args:              cobra.ExactArgs(1),
+If the .checksum file is in another directory, specify it with the -p/--path flag.
+
+With "--sarif", a failed verification is also written to a SARIF log, read by
+code scanning dashboards like GitHub code scanning or SonarQube.
+
+With "--root", the packs installed in the pack root are verified instead against
+the manifest written by "checksum-create --root", "` + cryptography.RootManifestName + `"
+in the current directory unless given with -p/--path. No file may be missing,
+changed or added. With "--pub-key", the manifest must be signed with the given
+PGP public key:
+
+  $ cpackget checksum-verify --root -p pack-root.checksum.json --pub-key signer.pub`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if checksumVerifyCmdFlags.root {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	PersistentPreRunE: configureInstallerGlobalCmd,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return cryptography.VerifyChecksum(args[0], checksumVerifyCmdFlags.checksumPath)
+		if checksumVerifyCmdFlags.root {
+			manifestPath := checksumVerifyCmdFlags.checksumPath
+			if manifestPath == "" {
+				manifestPath = cryptography.RootManifestName
+			}
+			packRoot := firstPackRoot()
+			err := cryptography.VerifyRootManifest(packRoot, manifestPath, checksumVerifyCmdFlags.pubKeyPath)
+			return reportFindings(checksumVerifyCmdFlags.sarifReport, cmd, []string{"--root", "--path", manifestPath}, verifyFindings(packRoot, err), err)
+		}
+		err := cryptography.VerifyChecksum(args[0], checksumVerifyCmdFlags.checksumPath)
+		return reportFindings(checksumVerifyCmdFlags.sarifReport, cmd, args, verifyFindings(args[0], err), err)
 	},
 }
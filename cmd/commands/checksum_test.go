@@ -4,13 +4,45 @@
 package commands_test
 
 import (
+	"encoding/json"
 	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 
+	gopgp "github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/stretchr/testify/assert"
 )
 
+// installFakePack writes a file of Vendor::Pack@1.2.3 to the pack root
+func installFakePack(t *TestCase) {
+	packDir := filepath.Join(os.Getenv("CMSIS_PACK_ROOT"), "Vendor", "Pack", "1.2.3")
+	t.assert.Nil(os.MkdirAll(packDir, 0700))
+	t.assert.Nil(os.WriteFile(filepath.Join(packDir, "Vendor.Pack.pdsc"), []byte("<package/>"), 0600))
+}
+
+// writeManifestKeys writes a PGP private key to manifest-key.asc and its
+// public key to manifest-key.pub
+func writeManifestKeys(t *TestCase) {
+	key, err := gopgp.GenerateKey("Manifest Signer", "signer@example.com", "x25519", 0)
+	t.assert.Nil(err)
+	privateKey, err := key.Armor()
+	t.assert.Nil(err)
+	publicKey, err := key.GetArmoredPublicKey()
+	t.assert.Nil(err)
+	t.assert.Nil(os.WriteFile("manifest-key.asc", []byte(privateKey), 0600))
+	t.assert.Nil(os.WriteFile("manifest-key.pub", []byte(publicKey), 0600))
+}
+
+// removeManifestFiles removes the files of the pack root manifest tests
+func removeManifestFiles() {
+	for _, name := range []string{"manifest-key.asc", "manifest-key.pub", cryptography.RootManifestName, cryptography.RootManifestName + ".sig"} {
+		os.Remove(name)
+	}
+}
+
 // TODO: Compare actual ErrFileNotFound output
 var checksumCreateCmdTests = []TestCase{
 	{
@@ -28,6 +60,11 @@ var checksumCreateCmdTests = []TestCase{
 		args:        []string{"checksum-create", "DoesNotExist.Pack.1.2.3.pack"},
 		expectedErr: errs.ErrFileNotFound,
 	},
+	{
+		name:        "test embedding checksum into nonexisting pack",
+		args:        []string{"checksum-create", "--embed", "DoesNotExist.Pack.1.2.3.pack"},
+		expectedErr: errs.ErrFileNotFound,
+	},
 	{
 		name:        "test using nonexisting hash function",
 		args:        []string{"checksum-create", "Vendor.Pack.1.2.3.pack", "-a", "sha1"},
@@ -40,6 +77,33 @@ var checksumCreateCmdTests = []TestCase{
 			os.Remove("Vendor.Pack.1.2.3.pack.sha256.checksum")
 		},
 	},
+	{
+		name:        "test creating a manifest of the pack root with a pack",
+		args:        []string{"checksum-create", "--root", "Vendor.Pack.1.2.3.pack"},
+		expectedErr: errors.New("unknown command \"Vendor.Pack.1.2.3.pack\" for \"cpackget checksum-create\""),
+	},
+	{
+		name:           "test creating a signed manifest of the pack root",
+		args:           []string{"checksum-create", "--root", "--private-key", "manifest-key.asc"},
+		createPackRoot: true,
+		expectedStdout: []string{"Wrote the digests of 1 pack(s), 1 file(s)", "Signed \"" + cryptography.RootManifestName + "\""},
+		setUpFunc: func(t *TestCase) {
+			installFakePack(t)
+			writeManifestKeys(t)
+		},
+		tearDownFunc: removeManifestFiles,
+		validationFunc: func(t *testing.T) {
+			b, err := os.ReadFile(cryptography.RootManifestName)
+			assert.Nil(t, err)
+			var manifest cryptography.RootManifest
+			assert.Nil(t, json.Unmarshal(b, &manifest))
+			assert.Equal(t, cryptography.RootManifestVersion, manifest.Version)
+			assert.Equal(t, []string{"Vendor::Pack@1.2.3"}, manifest.Packs)
+			assert.Len(t, manifest.Files, 1)
+			assert.Contains(t, manifest.Files, "Vendor/Pack/1.2.3/Vendor.Pack.pdsc")
+			assert.FileExists(t, cryptography.RootManifestName+".sig")
+		},
+	},
 }
 
 var checksumVerifyCmdTests = []TestCase{
@@ -65,6 +129,20 @@ var checksumVerifyCmdTests = []TestCase{
 			os.Remove("DoesNotExist.Pack.1.2.3.pack.sha256.checksum")
 		},
 	},
+	{
+		name:        "test writing a failed verification to a SARIF log",
+		args:        []string{"checksum-verify", "DoesNotExist.Pack.1.2.3.pack", "--sarif", "checksum.sarif"},
+		expectedErr: errs.ErrFileNotFound,
+		tearDownFunc: func() {
+			os.Remove("checksum.sarif")
+		},
+		validationFunc: func(t *testing.T) {
+			report, err := os.ReadFile("checksum.sarif")
+			assert.Nil(t, err)
+			assert.Contains(t, string(report), `"ruleId": "FILE_NOT_FOUND"`)
+			assert.Contains(t, string(report), `"uri": "DoesNotExist.Pack.1.2.3.pack"`)
+		},
+	},
 	{
 		name:        "test verifying checksum of nonexisting checksum file",
 		args:        []string{"checksum-verify", "Vendor.Pack.1.2.3.pack"},
@@ -73,6 +151,51 @@ var checksumVerifyCmdTests = []TestCase{
 			os.Remove("Vendor.Pack.1.2.3.pack.sha256.checksum")
 		},
 	},
+	{
+		name:           "test verifying the pack root against a signed manifest",
+		args:           []string{"checksum-verify", "--root", "--pub-key", "manifest-key.pub"},
+		createPackRoot: true,
+		expectedStdout: []string{"pack root integrity verified, all 1 pack(s) and 1 file(s) match."},
+		setUpFunc: func(t *TestCase) {
+			installFakePack(t)
+			writeManifestKeys(t)
+			t.assert.Nil(cryptography.GenerateRootManifest(os.Getenv("CMSIS_PACK_ROOT"), cryptography.RootManifestName, "sha256", "", "manifest-key.asc", nil))
+		},
+		tearDownFunc: removeManifestFiles,
+	},
+	{
+		name:           "test verifying the pack root against a tampered manifest",
+		args:           []string{"checksum-verify", "--root", "--pub-key", "manifest-key.pub"},
+		createPackRoot: true,
+		expectedErr:    errs.ErrBadManifestSignature,
+		setUpFunc: func(t *TestCase) {
+			installFakePack(t)
+			writeManifestKeys(t)
+			t.assert.Nil(cryptography.GenerateRootManifest(os.Getenv("CMSIS_PACK_ROOT"), cryptography.RootManifestName, "sha256", "", "manifest-key.asc", nil))
+			f, err := os.OpenFile(cryptography.RootManifestName, os.O_APPEND|os.O_WRONLY, 0600)
+			t.assert.Nil(err)
+			_, err = f.WriteString("\n")
+			t.assert.Nil(err)
+			t.assert.Nil(f.Close())
+		},
+		tearDownFunc: removeManifestFiles,
+	},
+	{
+		name:           "test verifying a changed pack root",
+		args:           []string{"checksum-verify", "--root"},
+		createPackRoot: true,
+		expectedStdout: []string{"Vendor/Pack/1.2.3/Vendor.Pack.pdsc: computed checksum did NOT match", "\"Vendor/Pack/1.2.3/extra.h\" is not listed in the manifest"},
+		expectedErr:    errs.ErrIntegrityCheckFailed,
+		setUpFunc: func(t *TestCase) {
+			installFakePack(t)
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			t.assert.Nil(cryptography.GenerateRootManifest(packRoot, cryptography.RootManifestName, "sha256", "", "", nil))
+			packDir := filepath.Join(packRoot, "Vendor", "Pack", "1.2.3")
+			t.assert.Nil(os.WriteFile(filepath.Join(packDir, "Vendor.Pack.pdsc"), []byte("<package></package>"), 0600))
+			t.assert.Nil(os.WriteFile(filepath.Join(packDir, "extra.h"), nil, 0600))
+		},
+		tearDownFunc: removeManifestFiles,
+	},
 }
 
 func TestChecksumCreateCmd(t *testing.T) {
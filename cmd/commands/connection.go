@@ -4,8 +4,10 @@
 package commands
 
 import (
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"github.com/open-cmsis-pack/cpackget/cmd/installer"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -21,18 +23,39 @@ var connectionCmdFlags struct {
 
 	// check connection status
 	checkConnection bool
+
+	// vendors checks all vendor URLs of the public index instead
+	vendors bool
 }
 
 var ConnectionCmd = &cobra.Command{
 	Use:   "connection [<url>]",
 	Short: "Check online connection to default or given URL",
 	Long: `Checks if the given or default url is accessible
-The url is optional. Ex "cpackget connection https://www.keil.com/pack"`,
-	Args: cobra.MinimumNArgs(0),
+The url is optional. Ex "cpackget connection https://www.keil.com/pack"
+
+With --vendors, every distinct vendor URL of the public index is checked
+instead, listing their latency and throughput in a table and flagging the
+ones likely to slow down or fail installing packs.`,
+	Args:              cobra.MinimumNArgs(0),
+	PersistentPreRunE: configureInstallerGlobalCmd,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		utils.SetEncodedProgress(connectionCmdFlags.encodedProgress)
+		utils.SetEncodedProgress(connectionCmdFlags.encodedProgress || ciMode)
 		utils.SetSkipTouch(connectionCmdFlags.skipTouch)
 
+		if connectionCmdFlags.vendors {
+			if len(args) > 0 {
+				log.Error("--vendors checks the vendor URLs of the public index, no URL can be given")
+				return errs.ErrIncorrectCmdArgs
+			}
+			createPackRoot = false
+			if err := configureInstaller(cmd, args); err != nil {
+				return err
+			}
+			_, err := installer.CheckVendorConnections(viper.GetInt("concurrent-downloads"), viper.GetInt("timeout"))
+			return err
+		}
+
 		var indexPath string
 		if len(args) > 0 {
 			indexPath = args[0]
@@ -59,4 +82,5 @@ The url is optional. Ex "cpackget connection https://www.keil.com/pack"`,
 
 func init() {
 	ConnectionCmd.Flags().BoolVarP(&connectionCmdFlags.encodedProgress, "encoded-progress", "E", false, "Reports encoded progress for files and download when used by other tools")
+	ConnectionCmd.Flags().BoolVar(&connectionCmdFlags.vendors, "vendors", false, "Checks the latency and throughput of every vendor URL of the public index")
 }
@@ -6,6 +6,8 @@ package commands_test
 import (
 	"errors"
 	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 )
 
 var (
@@ -53,6 +55,17 @@ var connectionCmdTests = []TestCase{
 		args:        []string{"connection"},
 		expectedErr: nil,
 	},
+	{
+		name:           "test checking the vendor URLs of an empty index",
+		args:           []string{"connection", "--vendors"},
+		createPackRoot: true,
+		expectedStdout: []string{"Checking 0 vendor URLs of the public index", "LATENCY"},
+	},
+	{
+		name:        "test checking the vendor URLs with a URL",
+		args:        []string{"connection", "--vendors", urlPath},
+		expectedErr: errs.ErrIncorrectCmdArgs,
+	},
 }
 
 func TestConnectionCmd(t *testing.T) {
@@ -0,0 +1,76 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"path"
+	"strconv"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var ContentsCmd = &cobra.Command{
+	Use:   "contents <pack> [<glob>]",
+	Short: "List the files of a pack",
+	Long: `
+List the files of a pack archive, or of a pack by its ID, with their compressed and
+uncompressed sizes in bytes:
+
+  $ cpackget contents Vendor.Pack.1.2.3.pack
+  $ cpackget contents Vendor::Pack@1.2.3 "*.ld"
+
+Packs given by ID are listed from their archive in .Download/ if cached, from the pack
+root otherwise, where compressed sizes are unknown. They default to their latest
+installed version.
+
+Files are relative to the folder of the pack's PDSC file. A glob without a slash
+matches their base name, e.g. "*.ld", one with a slash their whole path, e.g.
+"Device/*/*.h".`,
+	Args: cobra.RangeArgs(1, 2),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Pack archives do not need a pack root
+		if installer.IsPackFile(args[0]) {
+			return configureInstallerGlobalCmd(cmd, args)
+		}
+		return configureInstaller(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pattern := ""
+		if len(args) > 1 {
+			pattern = args[1]
+			if _, err := path.Match(pattern, ""); err != nil {
+				log.Errorf("Invalid glob: %s", err)
+				return errs.ErrIncorrectCmdArgs
+			}
+		}
+
+		entries, source, err := installer.PackContents(args[0], pattern)
+		if err != nil {
+			return err
+		}
+
+		// Compressed sizes are only known for archives
+		fromArchive := installer.IsPackFile(source)
+		compressed := func(size uint64) string {
+			if !fromArchive {
+				return "-"
+			}
+			return strconv.FormatUint(size, 10)
+		}
+
+		log.Infof("Listing files of %s", source)
+		log.Infof("%12s %12s  %s", "COMPRESSED", "SIZE", "NAME")
+		var compressedSize, size uint64
+		for _, entry := range entries {
+			log.Infof("%12s %12d  %s", compressed(entry.CompressedSize), entry.Size, entry.Name)
+			compressedSize += entry.CompressedSize
+			size += entry.Size
+		}
+		log.Infof("%12s %12d  %d file(s)", compressed(compressedSize), size, len(entries))
+		return nil
+	},
+}
@@ -0,0 +1,58 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+)
+
+// contentsTestPack is a pack the contents command tests list
+var contentsTestPack = filepath.Join("..", "..", "testdata", "integration", "1.2.3", "TheVendor.NonPublicLocalPack.1.2.3.pack")
+
+var contentsCmdTests = []TestCase{
+	{
+		name:        "test different number of parameters",
+		args:        []string{"contents"},
+		expectedErr: errors.New("accepts between 1 and 2 arg(s), received 0"),
+	},
+	{
+		name:        "test help command",
+		args:        []string{"help", "contents"},
+		expectedErr: nil,
+	},
+	{
+		name: "test listing the contents of a pack archive",
+		args: []string{"contents", contentsTestPack},
+		expectedStdout: []string{
+			"  COMPRESSED         SIZE  NAME",
+			"         281          475  TheVendor.NonPublicLocalPack.pdsc",
+			"           0            0  sample_file",
+			"         281          475  2 file(s)",
+		},
+	},
+	{
+		name:           "test listing the contents of a pack archive matching a glob",
+		args:           []string{"contents", contentsTestPack, "*.pdsc"},
+		expectedStdout: []string{"1 file(s)"},
+	},
+	{
+		name:        "test listing with an invalid glob",
+		args:        []string{"contents", contentsTestPack, "["},
+		expectedErr: errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test listing the contents of a pack not installed",
+		args:           []string{"contents", "TheVendor::PackName"},
+		createPackRoot: true,
+		expectedErr:    errs.ErrPackNotInstalled,
+	},
+}
+
+func TestContentsCmd(t *testing.T) {
+	runTests(t, contentsCmdTests)
+}
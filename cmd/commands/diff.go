@@ -0,0 +1,52 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var DiffCmd = &cobra.Command{
+	Use:   "diff <old pack> <new pack>",
+	Short: "Compare the files of two packs",
+	Long: `
+Compare the files of two packs, e.g. two versions of a pack before upgrading, by name
+and SHA-256 hash, listing the added, removed and changed files:
+
+  $ cpackget diff ARM.CMSIS@5.8.0 ARM.CMSIS@5.9.0
+  $ cpackget diff Vendor.Pack.1.2.3.pack Vendor.Pack.1.3.0.pack
+
+Packs given by ID are taken from .Download/ if cached, from the pack root if
+installed, and downloaded otherwise. Without a version, they default to their
+latest installed version.`,
+	Args: cobra.ExactArgs(2),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Pack archives do not need a pack root
+		if installer.IsPackFile(args[0]) && installer.IsPackFile(args[1]) {
+			return configureInstallerGlobalCmd(cmd, args)
+		}
+		return configureInstaller(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		diffs, err := installer.DiffPacks(args[0], args[1], viper.GetInt("timeout"))
+		if err != nil {
+			return err
+		}
+
+		if len(diffs) == 0 {
+			log.Info("(no differences found)")
+			return nil
+		}
+
+		changes := map[string]int{}
+		for _, diff := range diffs {
+			changes[diff.Change]++
+			log.Infof("%-8s %s", diff.Change, diff.Name)
+		}
+		log.Infof("%d added, %d removed and %d changed file(s)", changes[installer.PackFileAdded], changes[installer.PackFileRemoved], changes[installer.PackFileChanged])
+		return nil
+	},
+}
@@ -0,0 +1,59 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+)
+
+// diffTestPacks are two versions of a pack the diff command tests compare
+var diffTestPacks = []string{
+	filepath.Join("..", "..", "testdata", "integration", "1.2.3", "TheVendor.PublicLocalPack.1.2.3.pack"),
+	filepath.Join("..", "..", "testdata", "integration", "1.2.4", "TheVendor.PublicLocalPack.1.2.4.pack"),
+}
+
+var diffCmdTests = []TestCase{
+	{
+		name:        "test different number of parameters",
+		args:        []string{"diff", diffTestPacks[0]},
+		expectedErr: errors.New("accepts 2 arg(s), received 1"),
+	},
+	{
+		name:        "test help command",
+		args:        []string{"help", "diff"},
+		expectedErr: nil,
+	},
+	{
+		name: "test comparing two pack archives",
+		args: []string{"diff", diffTestPacks[0], diffTestPacks[1]},
+		expectedStdout: []string{
+			"changed  TheVendor.PublicLocalPack.pdsc",
+			"0 added, 0 removed and 1 changed file(s)",
+		},
+	},
+	{
+		name:           "test comparing a pack archive with itself",
+		args:           []string{"diff", diffTestPacks[0], diffTestPacks[0]},
+		expectedStdout: []string{"(no differences found)"},
+	},
+	{
+		name:        "test comparing a missing pack archive",
+		args:        []string{"diff", diffTestPacks[0], "DoesNotExist.Pack.1.2.3.pack"},
+		expectedErr: errs.ErrFileNotFound,
+	},
+	{
+		name:           "test comparing a pack not installed",
+		args:           []string{"diff", "TheVendor::PackName", diffTestPacks[0]},
+		createPackRoot: true,
+		expectedErr:    errs.ErrPackNotInstalled,
+	},
+}
+
+func TestDiffCmd(t *testing.T) {
+	runTests(t, diffCmdTests)
+}
@@ -0,0 +1,87 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var doctorEnvCmdFlags struct {
+	// sarifReport is the file to write a SARIF log of the problems found to
+	sarifReport string
+}
+
+var DoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose problems running cpackget",
+	Long:  "Diagnose problems running cpackget, e.g. in its environment",
+	Args:  cobra.MaximumNArgs(0),
+}
+
+var doctorEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Check the environment cpackget runs in",
+	Long: `
+Check the environment cpackget runs in and tell how to fix the problems found:
+
+  $ cpackget doctor env
+
+It checks that the pack root exists and is writable, that the PDSC files of
+packs added via PDSC file still exist, that the proxy settings are URLs, that
+the host of the public index can be reached over TLS, that the clock is not
+off, which would fail certificates and signatures, and that there is disk
+space left. It fails if any problem was found.
+
+With "--sarif", the problems found are also written to a SARIF log, read by
+code scanning dashboards like GitHub code scanning or SonarQube.`,
+	Args:              cobra.MaximumNArgs(0),
+	PersistentPreRunE: configureInstallerGlobalCmd,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		packRoot := viper.GetString("pack-root")
+		// Problems are reported on the pack root, the first one of a search path
+		location := "."
+		if roots := filepath.SplitList(packRoot); len(roots) > 0 && roots[0] != "" {
+			location = roots[0]
+		}
+		findings := []sarifFinding{}
+		for _, check := range installer.CheckEnvironment(packRoot, viper.GetInt("timeout")) {
+			if check.Problem == "" {
+				log.Infof("[ok] %s: %s", check.Name, check.Detail)
+				continue
+			}
+			log.Errorf("[!!] %s: %s", check.Name, check.Problem)
+			log.Infof("     %s", check.Hint)
+			findings = append(findings, sarifFinding{
+				rule:    "ENVIRONMENT_" + strings.ToUpper(strings.ReplaceAll(check.Name, " ", "_")),
+				message: check.Problem,
+				hint:    check.Hint,
+				path:    location,
+			})
+		}
+
+		var err error
+		if len(findings) > 0 {
+			err = errs.ErrEnvironmentProblems
+		}
+		return reportFindings(doctorEnvCmdFlags.sarifReport, cmd, args, findings, err)
+	},
+}
+
+func init() {
+	DoctorCmd.AddCommand(doctorEnvCmd)
+	doctorEnvCmd.Flags().StringVar(&doctorEnvCmdFlags.sarifReport, "sarif", "", "writes a SARIF log of the problems found to the given file, for code scanning dashboards")
+
+	doctorEnvCmd.SetHelpFunc(DoctorCmd.HelpFunc())
+	DoctorCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
+		err := command.Flags().MarkHidden("concurrent-downloads")
+		log.Debug(err)
+		command.Parent().HelpFunc()(command, strings)
+	})
+}
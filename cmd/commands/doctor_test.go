@@ -0,0 +1,62 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var doctorCmdTests = []TestCase{
+	{
+		name:        "test help command",
+		args:        []string{"help", "doctor"},
+		expectedErr: nil,
+	},
+	{
+		name:           "test checking the environment with a missing pack root",
+		args:           []string{"doctor", "env", "--timeout", "1"},
+		expectedErr:    errs.ErrEnvironmentProblems,
+		expectedStdout: []string{"[!!] pack root: \"test_checking_the_environment_with_a_missing_pack_root\" does not exist", "cpackget init --pack-root"},
+	},
+	{
+		name:        "test writing the problems found to a SARIF log",
+		args:        []string{"doctor", "env", "--timeout", "1", "--sarif", "doctor.sarif"},
+		expectedErr: errs.ErrEnvironmentProblems,
+		tearDownFunc: func() {
+			os.Remove("doctor.sarif")
+		},
+		validationFunc: func(t *testing.T) {
+			report, err := os.ReadFile("doctor.sarif")
+			assert.Nil(t, err)
+			var sarif struct {
+				Version string
+				Runs    []struct {
+					Results []struct {
+						RuleID    string
+						Locations []struct {
+							PhysicalLocation struct {
+								ArtifactLocation struct {
+									URI string
+								}
+							}
+						}
+					}
+				}
+			}
+			assert.Nil(t, json.Unmarshal(report, &sarif))
+			assert.Equal(t, "2.1.0", sarif.Version)
+			assert.Equal(t, "ENVIRONMENT_PACK_ROOT", sarif.Runs[0].Results[0].RuleID)
+			assert.Equal(t, "test_writing_the_problems_found_to_a_SARIF_log", sarif.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		},
+	},
+}
+
+func TestDoctorCmd(t *testing.T) {
+	runTests(t, doctorCmdTests)
+}
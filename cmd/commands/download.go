@@ -0,0 +1,51 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var DownloadCmd = &cobra.Command{
+	Use:   "download <pack> [<pack>...]",
+	Short: "Download and verify Open-CMSIS-Pack packages without installing them",
+	Long: `
+Download packs into the ".Download" folder of the pack root and verify them, without extracting them:
+
+  $ cpackget download Vendor::Pack@1.2.3
+  $ cpackget download Vendor.Pack.1.2.3
+  $ cpackget download https://vendor.com/example/Vendor.Pack.1.2.3.pack
+  $ cpackget download path/to/Vendor.Pack.1.2.3.pack
+
+  Packs are verified like "cpackget add" does before extracting them:
+  against the hash published by their vendor (see --pack-hash-urls) and
+  the keys pinned for their vendor (see --pinned-signers), if any, their
+  files against their checksums in the archive and their PDSC file against
+  their version. The SHA-256 hash of each pack is recorded next to it.
+  Packs failing verification are removed.
+
+  This pre-stages packs for later, offline, installs, e.g. with
+  "cpackget add --offline", or populates mirrors of vendor servers.`,
+	Args:              cobra.MinimumNArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var lastErr error
+		installer.UnlockPackRoot()
+		defer installer.LockPackRoot()
+		for _, packPath := range args {
+			if _, err := installer.DownloadPack(packPath, viper.GetInt("timeout")); err != nil {
+				reportError(err, packPath)
+				if !errs.Is(err, errs.ErrAlreadyLogged) {
+					log.Error(err)
+					err = errs.ErrAlreadyLogged
+				}
+				lastErr = err
+			}
+		}
+		return lastErr
+	},
+}
@@ -0,0 +1,50 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var downloadCmdTests = []TestCase{
+	{
+		name:        "test help command",
+		args:        []string{"help", "download"},
+		expectedErr: nil,
+	},
+	{
+		name:           "test downloading no pack",
+		args:           []string{"download"},
+		createPackRoot: true,
+		expectedErr:    errors.New("requires at least 1 arg(s), only received 0"),
+	},
+	{
+		name:           "test downloading pack that does not exist",
+		args:           []string{"download", "DoesNotExist.Pack.1.2.3.pack"},
+		createPackRoot: true,
+		expectedStdout: []string{"File", "DoesNotExist.Pack.1.2.3.pack", "doesn't exist"},
+		expectedErr:    errs.ErrAlreadyLogged,
+	},
+	{
+		name:           "test downloading a pack",
+		args:           []string{"download", packFilePath},
+		createPackRoot: true,
+		expectedStdout: []string{"Downloaded and verified TheVendor.PublicLocalPack.1.2.3"},
+		validationFunc: func(t *testing.T) {
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			assert.FileExists(t, filepath.Join(packRoot, ".Download", "TheVendor.PublicLocalPack.1.2.3.pack"))
+			assert.NoDirExists(t, filepath.Join(packRoot, "TheVendor", "PublicLocalPack"))
+		},
+	},
+}
+
+func TestDownloadCmd(t *testing.T) {
+	runTests(t, downloadCmdTests)
+}
@@ -0,0 +1,65 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// errorsOutput receives every failure as a JSON object on its own line
+// with --errors json, and is nil otherwise
+var errorsOutput io.Writer
+
+// lastReportedError keeps the error a command returns from being
+// reported again after the failure it comes from
+var lastReportedError error
+
+// configureErrorsOutput makes reportError write failures to output in
+// format, "text" leaving them to the log only
+func configureErrorsOutput(format string, output io.Writer) error {
+	errorsOutput = nil
+	lastReportedError = nil
+	switch format {
+	case "text":
+	case "json":
+		errorsOutput = output
+	default:
+		log.Errorf("--errors must be either \"text\" or \"json\", not \"%s\"", format)
+		return errs.ErrIncorrectCmdArgs
+	}
+	return nil
+}
+
+// reportError writes err, which happened on packPath if not empty, as a
+// JSON object with its code, message, pack and remediation hint
+func reportError(err error, packPath string) {
+	lastReportedError = err
+	if errorsOutput == nil {
+		return
+	}
+
+	var e *errs.Error
+	if packPath != "" && !errs.As(err, &e) {
+		err = errs.WithPackID(err, packPath)
+	}
+	jsonErr, jsonErrErr := errs.JSON(err)
+	if jsonErrErr != nil {
+		log.Debugf("Could not encode the error: %s", jsonErrErr)
+		return
+	}
+	fmt.Fprintln(errorsOutput, string(jsonErr))
+}
+
+// ReportError reports the error a command failed with, unless it was
+// already reported along with the pack it happened on
+func ReportError(err error) {
+	if err == lastReportedError || (errs.Is(err, errs.ErrAlreadyLogged) && lastReportedError != nil) {
+		return
+	}
+	reportError(err, "")
+}
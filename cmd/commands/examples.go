@@ -0,0 +1,90 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var examplesCopyCmdFlags struct {
+	// pack selects the pack providing the example to copy
+	pack string
+}
+
+var ExamplesCmd = &cobra.Command{
+	Use:   "examples",
+	Short: "List and copy example projects of installed packs",
+	Long:  "List the example projects shipped with installed packs and copy them out of the pack root",
+	Args:  cobra.MaximumNArgs(0),
+}
+
+var examplesListCmd = &cobra.Command{
+	Use:   "list [<pack>]",
+	Short: "List example projects of installed packs",
+	Long: `
+List the examples of the latest installed version of each pack, or only of the given pack:
+
+  $ cpackget examples list Vendor.Pack`,
+	Args:              cobra.MaximumNArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		packID := ""
+		if len(args) > 0 {
+			packID = args[0]
+		}
+
+		examples, err := installer.FindExamples(packID)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("Listing examples")
+		if len(examples) == 0 {
+			log.Info("(no examples found)")
+			return nil
+		}
+
+		for _, example := range examples {
+			logMessage := example.Name + " - " + example.Pack
+			if example.Description != "" {
+				logMessage += ": " + example.Description
+			}
+			log.Info(logMessage)
+		}
+		return nil
+	},
+}
+
+var examplesCopyCmd = &cobra.Command{
+	Use:   "copy <name> <dir>",
+	Short: "Copy an example project out of the pack root",
+	Long: `
+Copy the files of an example into a workspace folder, which must not exist or be empty:
+
+  $ cpackget examples copy Blinky path/to/workspace/Blinky
+
+Copied files are writable. If several packs provide an example with that name,
+select one of them with --pack, e.g. --pack Vendor.Pack.`,
+	Args:              cobra.ExactArgs(2),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return installer.CopyExample(examplesCopyCmdFlags.pack, args[0], args[1])
+	},
+}
+
+func init() {
+	examplesCopyCmd.Flags().StringVar(&examplesCopyCmdFlags.pack, "pack", "", "pack providing the example, e.g. Vendor.Pack")
+	ExamplesCmd.AddCommand(examplesListCmd, examplesCopyCmd)
+
+	examplesListCmd.SetHelpFunc(ExamplesCmd.HelpFunc())
+	examplesCopyCmd.SetHelpFunc(ExamplesCmd.HelpFunc())
+	ExamplesCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
+		err := command.Flags().MarkHidden("concurrent-downloads")
+		_ = command.Flags().MarkHidden("timeout")
+		log.Debug(err)
+		command.Parent().HelpFunc()(command, strings)
+	})
+}
@@ -0,0 +1,65 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+)
+
+// setUpExamplePack installs a pack providing a "Blinky" example
+func setUpExamplePack(t *TestCase) {
+	packRoot := os.Getenv("CMSIS_PACK_ROOT")
+	packFolder := filepath.Join(packRoot, "Vendor", "Pack", "1.2.3")
+	t.assert.Nil(os.MkdirAll(filepath.Join(packFolder, "Examples", "Blinky"), 0700))
+	pdsc := `<package><vendor>Vendor</vendor><name>Pack</name><examples>
+		<example name="Blinky" folder="Examples/Blinky"><description>Blinks the LEDs</description></example>
+	</examples></package>`
+	t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor.Pack.pdsc"), []byte(pdsc), 0600))
+	t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Examples", "Blinky", "main.c"), []byte(""), 0600))
+}
+
+var examplesCmdTests = []TestCase{
+	{
+		name:        "test help command",
+		args:        []string{"help", "examples"},
+		expectedErr: nil,
+	},
+	{
+		name:           "test listing examples",
+		args:           []string{"examples", "list", "Vendor.Pack"},
+		createPackRoot: true,
+		expectedStdout: []string{"Blinky - Vendor::Pack@1.2.3: Blinks the LEDs"},
+		setUpFunc:      setUpExamplePack,
+	},
+	{
+		name:           "test copying an example",
+		args:           []string{"examples", "copy", "Blinky", "test-copying-an-example-workspace"},
+		createPackRoot: true,
+		setUpFunc:      setUpExamplePack,
+		tearDownFunc: func() {
+			os.RemoveAll("test-copying-an-example-workspace")
+		},
+		validationFunc: func(t *testing.T) {
+			_, err := os.Stat(filepath.Join("test-copying-an-example-workspace", "main.c"))
+			if err != nil {
+				t.Error(err)
+			}
+		},
+	},
+	{
+		name:           "test copying a missing example",
+		args:           []string{"examples", "copy", "Missing", "test-copying-a-missing-example-workspace"},
+		createPackRoot: true,
+		expectedErr:    errs.ErrExampleNotFound,
+		setUpFunc:      setUpExamplePack,
+	},
+}
+
+func TestExamplesCmd(t *testing.T) {
+	runTests(t, examplesCmdTests)
+}
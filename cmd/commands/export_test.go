@@ -0,0 +1,7 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+// CIEnvVars lets tests clear the variables that turn on CI mode
+var CIEnvVars = ciEnvVars
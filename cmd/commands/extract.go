@@ -0,0 +1,80 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"os"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var extractCmdFlags struct {
+	// outputDir is the directory the pack is extracted to
+	outputDir string
+
+	// checkEula displays the pack's license for acceptance before extracting it
+	checkEula bool
+
+	// verifySignature verifies the pack's signature before extracting it
+	verifySignature bool
+
+	// pubKey is the publisher's PGP public key to verify the signature with
+	pubKey string
+
+	// tofu trusts the first signer of each vendor and refuses other ones
+	tofu bool
+}
+
+var ExtractCmd = &cobra.Command{
+	Use:   "extract <local .pack file>",
+	Short: "Extract a pack to a directory outside the pack root",
+	Long: `
+Extract a local pack to a directory, e.g. to inspect or repackage it, checking and
+extracting it the way "cpackget add" does but without touching the pack root:
+
+  $ cpackget extract Vendor.Pack.1.2.3.pack --output ./inspect
+
+The output directory defaults to "Vendor.Pack.1.2.3" in the current directory, and must
+be empty. Use --eula to display the pack's license for acceptance first, and
+--verify-signature to verify a signed pack first, see "cpackget help signature-verify".`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstallerGlobalCmd,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if extractCmdFlags.pubKey != "" && !extractCmdFlags.verifySignature {
+			log.Error("-k/--pub-key needs --verify-signature")
+			return errs.ErrIncorrectCmdArgs
+		}
+		if extractCmdFlags.tofu && !extractCmdFlags.verifySignature {
+			log.Error("--tofu needs --verify-signature")
+			return errs.ErrIncorrectCmdArgs
+		}
+		if extractCmdFlags.verifySignature {
+			configureKnownSigners(extractCmdFlags.tofu, os.Getenv("CPACKGET_KNOWN_SIGNERS"))
+			if err := cryptography.VerifyPackSignature(args[0], extractCmdFlags.pubKey, Version, false, false, true); err != nil {
+				return err
+			}
+		}
+		return installer.ExtractPack(args[0], extractCmdFlags.outputDir, extractCmdFlags.checkEula)
+	},
+}
+
+func init() {
+	ExtractCmd.Flags().StringVarP(&extractCmdFlags.outputDir, "output", "o", "", "directory to extract the pack to, Vendor.Pack.x.y.z by default")
+	ExtractCmd.Flags().BoolVar(&extractCmdFlags.checkEula, "eula", false, "display the pack's license for acceptance before extracting it")
+	ExtractCmd.Flags().BoolVar(&extractCmdFlags.verifySignature, "verify-signature", false, "verify the pack's signature before extracting it")
+	ExtractCmd.Flags().StringVarP(&extractCmdFlags.pubKey, "pub-key", "k", "", "path of the publisher's PGP public key, with --verify-signature")
+	ExtractCmd.Flags().BoolVar(&extractCmdFlags.tofu, "tofu", false, "trust the signer of the first verified pack of each vendor, with --verify-signature, see \"cpackget help signature-verify\"")
+
+	ExtractCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
+		err := command.Flags().MarkHidden("pack-root")
+		_ = command.Flags().MarkHidden("concurrent-downloads")
+		_ = command.Flags().MarkHidden("timeout")
+		log.Debug(err)
+		command.Parent().HelpFunc()(command, strings)
+	})
+}
@@ -0,0 +1,78 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// extractTestPack is a pack the extract command tests extract
+var extractTestPack = filepath.Join("..", "..", "testdata", "integration", "1.2.3", "TheVendor.NonPublicLocalPack.1.2.3.pack")
+
+var extractCmdTests = []TestCase{
+	{
+		name:        "test different number of parameters",
+		args:        []string{"extract"},
+		expectedErr: errors.New("accepts 1 arg(s), received 0"),
+	},
+	{
+		name:        "test help command",
+		args:        []string{"help", "extract"},
+		expectedErr: nil,
+	},
+	{
+		name:        "test extracting a nonexisting pack",
+		args:        []string{"extract", "DoesNotExist.Pack.1.2.3.pack"},
+		expectedErr: errs.ErrFileNotFound,
+	},
+	{
+		name:        "test extracting a pack id",
+		args:        []string{"extract", "TheVendor.NonPublicLocalPack.1.2.3"},
+		expectedErr: errs.ErrBadPackName,
+	},
+	{
+		name:        "test extracting a pack to a directory not empty",
+		args:        []string{"extract", extractTestPack, "--output", "test-extracting-a-pack-to-a-directory-not-empty"},
+		expectedErr: errs.WithPath(errs.ErrPathAlreadyExists, "test-extracting-a-pack-to-a-directory-not-empty"),
+		setUpFunc: func(t *TestCase) {
+			t.assert.Nil(os.MkdirAll("test-extracting-a-pack-to-a-directory-not-empty", 0700))
+			t.assert.Nil(os.WriteFile(filepath.Join("test-extracting-a-pack-to-a-directory-not-empty", "file"), nil, 0600))
+		},
+		tearDownFunc: func() {
+			os.RemoveAll("test-extracting-a-pack-to-a-directory-not-empty")
+		},
+	},
+	{
+		name: "test extracting a pack",
+		args: []string{"extract", extractTestPack, "--output", "test-extracting-a-pack"},
+		validationFunc: func(t *testing.T) {
+			assert.FileExists(t, filepath.Join("test-extracting-a-pack", "TheVendor.NonPublicLocalPack.pdsc"))
+			assert.FileExists(t, filepath.Join("test-extracting-a-pack", "sample_file"))
+			assert.NoDirExists(t, filepath.Join("test-extracting-a-pack", ".Download"))
+		},
+		tearDownFunc: func() {
+			os.RemoveAll("test-extracting-a-pack")
+		},
+	},
+	{
+		name:        "test using a public key without verifying the signature",
+		args:        []string{"extract", extractTestPack, "--pub-key", "key.asc"},
+		expectedErr: errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:        "test trusting the first signer without verifying the signature",
+		args:        []string{"extract", extractTestPack, "--tofu"},
+		expectedErr: errs.ErrIncorrectCmdArgs,
+	},
+}
+
+func TestExtractCmd(t *testing.T) {
+	runTests(t, extractCmdTests)
+}
@@ -0,0 +1,77 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+)
+
+// githubActions turns errors and warnings into GitHub Actions annotations
+// and writes step summaries, see --github-actions
+var githubActions bool
+
+// githubFormatter prints errors and warnings as GitHub Actions workflow
+// commands and leaves all other messages to the formatter it wraps
+type githubFormatter struct {
+	next log.Formatter
+}
+
+func (f *githubFormatter) Format(entry *log.Entry) ([]byte, error) {
+	switch entry.Level {
+	case log.ErrorLevel, log.FatalLevel, log.PanicLevel:
+		return []byte("::error::" + escapeGithubData(entry.Message) + "\n"), nil
+	case log.WarnLevel:
+		return []byte("::warning::" + escapeGithubData(entry.Message) + "\n"), nil
+	}
+	return f.next.Format(entry)
+}
+
+// escapeGithubData escapes message so it fits in a single workflow command
+func escapeGithubData(message string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(message)
+}
+
+// configureGithubActions switches the log output and the installer
+// reporting to GitHub Actions, or back
+func configureGithubActions(enabled bool) {
+	githubActions = enabled
+
+	if formatter, ok := log.StandardLogger().Formatter.(*githubFormatter); ok {
+		log.SetFormatter(formatter.next)
+	}
+	installer.OutdatedPackFound = nil
+
+	if enabled {
+		log.SetFormatter(&githubFormatter{next: log.StandardLogger().Formatter})
+		installer.OutdatedPackFound = func(packID, version, latestVersion string) {
+			log.Warnf("%s can be updated from \"%s\" to \"%s\"", packID, version, latestVersion)
+			appendGithubStepSummary(fmt.Sprintf("- %s can be updated from %s to %s", packID, version, latestVersion))
+		}
+	}
+}
+
+// appendGithubStepSummary adds markdown lines to the summary of the current
+// workflow step. Nothing is written outside of GitHub Actions.
+func appendGithubStepSummary(lines ...string) {
+	summaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
+	if !githubActions || summaryPath == "" {
+		return
+	}
+
+	file, err := os.OpenFile(summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Debugf("Could not write the step summary: %s", err)
+		return
+	}
+	defer file.Close()
+
+	for _, line := range lines {
+		fmt.Fprintln(file, line)
+	}
+}
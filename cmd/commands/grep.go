@@ -0,0 +1,89 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"path/filepath"
+	"regexp"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var grepCmdFlags struct {
+	// cached also searches the PDSC files in .Web/ of packs not installed
+	cached bool
+
+	// ignoreCase matches regardless of case
+	ignoreCase bool
+
+	// refresh updates the public index before searching
+	refresh bool
+}
+
+var GrepCmd = &cobra.Command{
+	Use:   "grep <regex>",
+	Short: "Search the PDSC files of installed packs",
+	Long: `
+Search the attributes and texts of the PDSC files of installed packs for a regular expression,
+printing the matching packs and elements:
+
+  $ cpackget grep -i "cortex-m55"
+
+Use --cached to also search the PDSC files in .Web/ of packs not installed, and
+--refresh to update the public index and these PDSC files first. Otherwise a warning
+tells when the index was last refreshed longer ago than --stale-index-days.`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pattern := args[0]
+		if grepCmdFlags.ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Errorf("Invalid regular expression: %s", err)
+			return errs.ErrIncorrectCmdArgs
+		}
+
+		if grepCmdFlags.refresh && !grepCmdFlags.cached {
+			log.Error("--refresh needs --cached")
+			return errs.ErrIncorrectCmdArgs
+		}
+		if grepCmdFlags.cached {
+			installer.UnlockPackRoot()
+			err = refreshOrWarnStaleIndex(grepCmdFlags.refresh)
+			installer.LockPackRoot()
+			if err != nil {
+				return err
+			}
+		}
+
+		matches, err := installer.GrepPdscs(expression, grepCmdFlags.cached)
+		if err != nil {
+			return err
+		}
+
+		if len(matches) == 0 {
+			log.Info("(no matches found)")
+			return nil
+		}
+
+		packs := map[string]bool{}
+		for _, match := range matches {
+			packs[match.Pack] = true
+			log.Infof("%s %s:%d %s: %s", match.Pack, filepath.Base(match.File), match.Line, match.Element, match.Match)
+		}
+		log.Infof("Found %d match(es) in %d pack(s)", len(matches), len(packs))
+		return nil
+	},
+}
+
+func init() {
+	GrepCmd.Flags().BoolVarP(&grepCmdFlags.cached, "cached", "c", false, "also search the PDSC files in .Web/ of packs not installed")
+	GrepCmd.Flags().BoolVarP(&grepCmdFlags.ignoreCase, "ignore-case", "i", false, "match regardless of case")
+	GrepCmd.Flags().BoolVar(&grepCmdFlags.refresh, "refresh", false, "update the public index before searching, with --cached")
+}
@@ -0,0 +1,68 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+)
+
+// setUpGrepPack installs a pack describing a Cortex-M55 device
+func setUpGrepPack(t *TestCase) {
+	packRoot := os.Getenv("CMSIS_PACK_ROOT")
+	packFolder := filepath.Join(packRoot, "Vendor", "Pack", "1.2.3")
+	t.assert.Nil(os.MkdirAll(packFolder, 0700))
+	pdsc := `<package><vendor>Vendor</vendor><name>Pack</name><devices>
+		<family Dfamily="Chip Series"><processor Dcore="Cortex-M55"/></family>
+	</devices></package>`
+	t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor.Pack.pdsc"), []byte(pdsc), 0600))
+}
+
+var grepCmdTests = []TestCase{
+	{
+		name:        "test help command",
+		args:        []string{"help", "grep"},
+		expectedErr: nil,
+	},
+	{
+		name:           "test searching pdsc files",
+		args:           []string{"grep", "-i", "cortex-m5[0-9]"},
+		createPackRoot: true,
+		expectedStdout: []string{
+			"Vendor::Pack@1.2.3 Vendor.Pack.pdsc:2 package/devices/family/processor: Dcore=\"Cortex-M55\"",
+			"Found 1 match(es) in 1 pack(s)",
+		},
+		setUpFunc: setUpGrepPack,
+	},
+	{
+		name:           "test searching with an invalid expression",
+		args:           []string{"grep", "cortex-m5["},
+		createPackRoot: true,
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test refreshing the index without searching it",
+		args:           []string{"grep", "--refresh", "cortex"},
+		createPackRoot: true,
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test searching with a stale index",
+		args:           []string{"grep", "--cached", "cortex"},
+		createPackRoot: true,
+		expectedStdout: []string{"The public index was last refreshed 40 day(s) ago"},
+		setUpFunc: func(t *TestCase) {
+			refreshed := time.Now().Add(-40 * 24 * time.Hour).UTC().Format(time.RFC3339)
+			t.assert.Nil(os.WriteFile(filepath.Join(os.Getenv("CMSIS_PACK_ROOT"), ".Local", "index_refreshed"), []byte(refreshed), 0600))
+		},
+	},
+}
+
+func TestGrepCmd(t *testing.T) {
+	runTests(t, grepCmdTests)
+}
@@ -0,0 +1,49 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var importCmdFlags struct {
+	// mdkPackDir is the pack folder of a Keil MDK installation
+	mdkPackDir string
+}
+
+var ImportCmd = &cobra.Command{
+	Use:   "import --from-mdk <path>",
+	Short: "Import packs from a Keil MDK installation",
+	Long: `
+Import the packs of a Keil MDK pack folder into the pack root:
+
+  $ cpackget import --from-mdk C:\Keil_v5\ARM\PACK
+
+Extracted packs and cached pack files are copied into the pack root,
+unless already there. Packs MDK added via PDSC files are added to
+".Local/local_repository.pidx" as long as their PDSC files still exist.
+The MDK pack folder is left untouched.`,
+	Args:              cobra.MaximumNArgs(0),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		installer.UnlockPackRoot()
+		err := installer.ImportMDKPacks(importCmdFlags.mdkPackDir)
+		installer.LockPackRoot()
+		return err
+	},
+}
+
+func init() {
+	ImportCmd.Flags().StringVar(&importCmdFlags.mdkPackDir, "from-mdk", "", "specifies the pack folder of a Keil MDK installation")
+	_ = ImportCmd.MarkFlagRequired("from-mdk")
+
+	ImportCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
+		err := command.Flags().MarkHidden("concurrent-downloads")
+		_ = command.Flags().MarkHidden("timeout")
+		log.Debug(err)
+		command.Parent().HelpFunc()(command, strings)
+	})
+}
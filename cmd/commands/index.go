@@ -0,0 +1,183 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"fmt"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var indexCmdFlags struct {
+	// pin is the hash the index must match, recorded for later updates
+	pin string
+
+	// strictPin makes later updates fail when the index does not match the pin
+	strictPin bool
+
+	// json tells whether printing the index sources as JSON
+	json bool
+}
+
+var IndexCmd = &cobra.Command{
+	Use:   "index <index-url> | list | add | remove | enable | disable",
+	Short: "Replaces the public index",
+	Long: `
+Replaces the public index in .Web/index.pidx with the one at <index-url>, a
+URL or a local file:
+
+  $ cpackget index https://vendor.com/index.pidx
+
+With --pin, the index must have the given SHA-256 hash, e.g. as published by its
+vendor, and the pin is recorded in .Local/` + installer.IndexPinName + `. Later updates,
+see "cpackget help update-index", then warn when the index they retrieve does not
+have this hash, or fail with --strict-pin:
+
+  $ cpackget index https://vendor.com/index.pidx --pin sha256:9f86d08...
+
+Replacing the index without --pin removes the recorded pin.
+
+Other indexes, e.g. of vendors not in the public index, can be subscribed to with
+"cpackget index add". Their packs are merged into the public index, now and on
+every update of it, see "cpackget help index add".`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if indexCmdFlags.strictPin && indexCmdFlags.pin == "" {
+			log.Error("--strict-pin needs --pin")
+			return errs.ErrIncorrectCmdArgs
+		}
+
+		log.Infof("Replacing public index with \"%s\"", args[0])
+		installer.UnlockPackRoot()
+		err := installer.PinPublicIndex(args[0], indexCmdFlags.pin, indexCmdFlags.strictPin, viper.GetInt("timeout"))
+		installer.LockPackRoot()
+		return err
+	},
+}
+
+var indexListCmd = &cobra.Command{
+	Use:               "list",
+	Short:             "List the index sources",
+	Long:              "List the public index and the index sources subscribed to, along with the number of packs merged from each",
+	Args:              cobra.NoArgs,
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sources, err := installer.ReadIndexSources()
+		if err != nil {
+			return err
+		}
+		origins, err := installer.ReadIndexOrigins()
+		if err != nil {
+			return err
+		}
+
+		type indexSource struct {
+			installer.IndexSource
+			Packs int `json:"packs"`
+		}
+		packs := map[string]int{}
+		for _, pdscTag := range installer.Installation.PublicIndexXML.ListPdscTags() {
+			origin, found := origins[pdscTag.Vendor+"."+pdscTag.Name]
+			if !found {
+				origin = installer.DefaultIndexSource
+			}
+			packs[origin]++
+		}
+
+		listed := []indexSource{{installer.IndexSource{Name: installer.DefaultIndexSource, URL: installer.Installation.PublicIndexXML.URL}, packs[installer.DefaultIndexSource]}}
+		for _, source := range sources {
+			listed = append(listed, indexSource{source, packs[source.Name]})
+		}
+
+		if indexCmdFlags.json {
+			return printJSON(cmd, listed)
+		}
+
+		log.Info("Listing index sources")
+		for _, source := range listed {
+			logMessage := fmt.Sprintf("%s: %s (%d pack(s))", source.Name, source.URL, source.Packs)
+			if source.Disabled {
+				logMessage = fmt.Sprintf("%s: %s (disabled)", source.Name, source.URL)
+			}
+			log.Info(logMessage)
+		}
+		return nil
+	},
+}
+
+var indexAddCmd = &cobra.Command{
+	Use:   "add <name> <index-url>",
+	Short: "Subscribe to an index",
+	Long: `
+Subscribes to the index at <index-url>, a URL, a local file or a folder of PDSC and
+pack files, under <name>. Its packs are merged into the public index right away and on
+every later update of it, unless the public index or a source added before has them:
+
+  $ cpackget index add vendor https://vendor.com/index.pidx
+
+The index sources are recorded in .Local/` + installer.IndexSourcesName + `, and "cpackget list --public"
+tells the source packs come from.`,
+	Args:              cobra.ExactArgs(2),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		installer.UnlockPackRoot()
+		err := installer.AddIndexSource(args[0], args[1], viper.GetInt("timeout"))
+		installer.LockPackRoot()
+		return err
+	},
+}
+
+var indexRemoveCmd = &cobra.Command{
+	Use:               "remove <name>",
+	Short:             "Unsubscribe from an index",
+	Long:              "Unsubscribes from the index source <name>, removing its packs from the public index",
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		installer.UnlockPackRoot()
+		err := installer.RemoveIndexSource(args[0])
+		installer.LockPackRoot()
+		return err
+	},
+}
+
+var indexEnableCmd = &cobra.Command{
+	Use:               "enable <name>",
+	Short:             "Enable an index source",
+	Long:              "Merges the packs of the disabled index source <name> into the public index again",
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		installer.UnlockPackRoot()
+		err := installer.EnableIndexSource(args[0], true, viper.GetInt("timeout"))
+		installer.LockPackRoot()
+		return err
+	},
+}
+
+var indexDisableCmd = &cobra.Command{
+	Use:               "disable <name>",
+	Short:             "Disable an index source",
+	Long:              "Removes the packs of the index source <name> from the public index, keeping the subscription for \"cpackget index enable\"",
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		installer.UnlockPackRoot()
+		err := installer.EnableIndexSource(args[0], false, viper.GetInt("timeout"))
+		installer.LockPackRoot()
+		return err
+	},
+}
+
+func init() {
+	IndexCmd.Flags().StringVar(&indexCmdFlags.pin, "pin", "", "SHA-256 hash the index must have, as sha256:<hex digest>, recorded for later updates to check")
+	IndexCmd.Flags().BoolVar(&indexCmdFlags.strictPin, "strict-pin", false, "make later updates fail instead of warning when the index does not match the pin")
+
+	indexListCmd.Flags().BoolVar(&indexCmdFlags.json, "json", false, "print the index sources as JSON")
+	IndexCmd.AddCommand(indexListCmd, indexAddCmd, indexRemoveCmd, indexEnableCmd, indexDisableCmd)
+}
@@ -0,0 +1,59 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+)
+
+var indexCmdTests = []TestCase{
+	{
+		name:        "test help command",
+		args:        []string{"help", "index"},
+		expectedErr: nil,
+	},
+	{
+		name:        "test missing the index url",
+		args:        []string{"index"},
+		expectedErr: errors.New("accepts 1 arg(s), received 0"),
+	},
+	{
+		name:           "test strict pin without a pin",
+		args:           []string{"index", "index.pidx", "--strict-pin"},
+		createPackRoot: true,
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test an invalid pin",
+		args:           []string{"index", "index.pidx", "--pin", "sha256:1234"},
+		createPackRoot: true,
+		expectedStdout: []string{"\"sha256:1234\" is not an index pin"},
+		expectedErr:    errs.ErrInvalidIndexPin,
+	},
+	{
+		name:           "test listing the index sources",
+		args:           []string{"index", "list"},
+		createPackRoot: true,
+		expectedStdout: []string{"default: "},
+	},
+	{
+		name:        "test adding an index source without url",
+		args:        []string{"index", "add", "vendor"},
+		expectedErr: errors.New("accepts 2 arg(s), received 1"),
+	},
+	{
+		name:           "test removing an unknown index source",
+		args:           []string{"index", "remove", "vendor"},
+		createPackRoot: true,
+		expectedStdout: []string{"There is no index source named \"vendor\""},
+		expectedErr:    errs.ErrIndexSourceNotFound,
+	},
+}
+
+func TestIndexCmd(t *testing.T) {
+	runTests(t, indexCmdTests)
+}
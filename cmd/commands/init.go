@@ -4,10 +4,15 @@
 package commands
 
 import (
+	"path/filepath"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"github.com/open-cmsis-pack/cpackget/cmd/installer"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	viperType "github.com/spf13/viper"
 )
 
 var initCmdFlags struct {
@@ -19,10 +24,52 @@ var initCmdFlags struct {
 
 	// skipTouch does not touch pack.idx after adding
 	skipTouch bool
+
+	// bootstrapFileName is a file with the index url and the packs to add
+	bootstrapFileName string
+
+	// localFiles sets up the pack root from local files only
+	localFiles bool
+
+	// contentStore stores identical files of packs only once
+	contentStore bool
+}
+
+// bootstrapFile lists what "init --bootstrap" sets a pack root up with
+type bootstrapFile struct {
+	// index is the index url, unless given as argument
+	index string
+
+	// packs are added once the index is in place
+	packs []string
+
+	// agreeEmbeddedLicenses agrees with the embedded licenses of all packs
+	agreeEmbeddedLicenses bool
+}
+
+// readBootstrapFile reads a bootstrap file in any format viper supports, e.g.:
+//
+//	index: https://www.keil.com/pack/index.pidx
+//	agree-embedded-licenses: true
+//	packs:
+//	  - ARM::CMSIS@5.9.0
+//	  - path/to/Vendor.Pack.pdsc
+func readBootstrapFile(fileName string) (bootstrapFile, error) {
+	config := viperType.New()
+	config.SetConfigFile(fileName)
+	if err := config.ReadInConfig(); err != nil {
+		return bootstrapFile{}, err
+	}
+
+	return bootstrapFile{
+		index:                 config.GetString("index"),
+		packs:                 config.GetStringSlice("packs"),
+		agreeEmbeddedLicenses: config.GetBool("agree-embedded-licenses"),
+	}, nil
 }
 
 var InitCmd = &cobra.Command{
-	Use:   "init [--pack-root <pack root>] <index-url>",
+	Use:   "init [--pack-root <pack root>] [--bootstrap <bootstrap file>] <index-url>",
 	Short: "Initializes a pack root folder",
 	Long: `Initializes a pack root folder specified by -R/--pack-root command line
 or via the CMSIS_PACK_ROOT environment variable with the following contents:
@@ -30,14 +77,60 @@ or via the CMSIS_PACK_ROOT environment variable with the following contents:
   - .Local/
   - .Web/
   - .Web/index.pidx (downloaded from <index-url>)
-The index-url is mandatory. Ex "cpackget init --pack-root path/to/mypackroot https://www.keil.com/pack/index.pidx"`,
-	Args: cobra.ExactArgs(1),
+The index-url is mandatory. Ex "cpackget init --pack-root path/to/mypackroot https://www.keil.com/pack/index.pidx"
+
+The index-url can also be a folder of pdsc and pack files, which is scanned
+to synthesize an index of the packs in it.
+
+A bootstrap file sets up a pack root with packs in one step. It can also
+give the index-url, which then is optional:
+
+  index: https://www.keil.com/pack/index.pidx
+  agree-embedded-licenses: true
+  packs:
+    - ARM::CMSIS@5.9.0
+    - path/to/Vendor.Pack.pdsc
+
+  $ cpackget init --bootstrap setup.yml
+
+With --local-files, no network access is made. The index-url must be a local
+file and the pdsc and pack files next to it are used instead of downloading
+them, e.g. for air-gapped installations:
+
+  $ cpackget init --local-files path/to/offline-files/index.pidx
+
+With --content-store, files that are the same in several packs, e.g. in
+closely related device family packs, are stored only once in the pack
+root's ".Store" folder and hard linked into the packs.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		packRoot := viper.GetString("pack-root")
-		utils.SetEncodedProgress(initCmdFlags.encodedProgress)
+		utils.SetEncodedProgress(initCmdFlags.encodedProgress || ciMode)
 		utils.SetSkipTouch(initCmdFlags.skipTouch)
 
-		indexPath := args[0]
+		var bootstrap bootstrapFile
+		if initCmdFlags.bootstrapFileName != "" {
+			var err error
+			if bootstrap, err = readBootstrapFile(initCmdFlags.bootstrapFileName); err != nil {
+				return err
+			}
+		}
+
+		indexPath := bootstrap.index
+		if len(args) > 0 {
+			indexPath = args[0]
+		}
+		if indexPath == "" {
+			log.Error("Missing an index-url, either as argument or in the bootstrap file")
+			return errs.ErrIncorrectCmdArgs
+		}
+
+		if initCmdFlags.localFiles {
+			if strings.HasPrefix(indexPath, "http://") || strings.HasPrefix(indexPath, "https://") || initCmdFlags.downloadPdscFiles {
+				log.Error("--local-files requires a local index file and cannot download all pdsc files")
+				return errs.ErrIncorrectCmdArgs
+			}
+		}
 
 		log.Debugf("Initializing a new pack root in \"%v\" using index url \"%v\"", packRoot, indexPath)
 
@@ -46,9 +139,35 @@ The index-url is mandatory. Ex "cpackget init --pack-root path/to/mypackroot htt
 		if err != nil {
 			return err
 		}
+		if initCmdFlags.localFiles && !utils.GetOffline() {
+			utils.SetOffline(true)
+			defer utils.SetOffline(false)
+		}
 
 		installer.UnlockPackRoot()
+		if initCmdFlags.contentStore {
+			if err := utils.EnsureDir(installer.Installation.StoreDir); err != nil {
+				installer.LockPackRoot()
+				return err
+			}
+		}
 		err = installer.UpdatePublicIndex(indexPath, true, true, initCmdFlags.downloadPdscFiles, false, viper.GetInt("concurrent-downloads"), viper.GetInt("timeout"))
+		if err == nil && initCmdFlags.localFiles {
+			err = installer.AddOfflineFiles(filepath.Dir(indexPath))
+		}
+		installer.LockPackRoot()
+		if err != nil || len(bootstrap.packs) == 0 {
+			return err
+		}
+
+		// Reload the pack root to pick up the new index
+		if err := installer.SetPackRoot(packRoot, false); err != nil {
+			return err
+		}
+
+		log.Infof("Adding %v", bootstrap.packs)
+		installer.UnlockPackRoot()
+		_, err = addPacks(bootstrap.packs, !bootstrap.agreeEmbeddedLicenses, false, false, false, false)
 		installer.LockPackRoot()
 		return err
 	},
@@ -58,4 +177,7 @@ func init() {
 	InitCmd.Flags().BoolVarP(&initCmdFlags.downloadPdscFiles, "all-pdsc-files", "a", false, "downloads all the latest .pdsc files from the public index")
 	InitCmd.Flags().BoolVarP(&initCmdFlags.encodedProgress, "encoded-progress", "E", false, "Reports encoded progress for files and download when used by other tools")
 	InitCmd.Flags().BoolVar(&initCmdFlags.skipTouch, "skip-touch", false, "do not touch pack.idx")
+	InitCmd.Flags().BoolVar(&initCmdFlags.localFiles, "local-files", false, "sets up the pack root from the local index file and the pdsc and pack files next to it, without network access")
+	InitCmd.Flags().BoolVar(&initCmdFlags.contentStore, "content-store", false, "stores files that are the same in several packs only once, hard linking them into the packs")
+	InitCmd.Flags().StringVarP(&initCmdFlags.bootstrapFileName, "bootstrap", "b", "", "specifies a file with the index url and packs to add")
 }
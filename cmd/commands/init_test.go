@@ -5,11 +5,16 @@ package commands_test
 
 import (
 	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/stretchr/testify/assert"
 )
 
 // Tests for init command are placed here because there was something wrong
@@ -18,13 +23,20 @@ import (
 var (
 	pidxFilePath         = filepath.Join(testingDir, "SamplePublicIndex.pidx")
 	notFoundPidxFilePath = filepath.Join("path", "to", "index.pidx")
+	bootstrapFilePath    = "bootstrap.yml"
+	offlineFilesDir      = "offline-files"
 )
 
 var initCmdTests = []TestCase{
 	{
 		name:        "test no parameter given",
 		args:        []string{"init"},
-		expectedErr: errors.New("accepts 1 arg(s), received 0"),
+		expectedErr: errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:        "test too many parameters given",
+		args:        []string{"init", pidxFilePath, pidxFilePath},
+		expectedErr: errors.New("accepts at most 1 arg(s), received 2"),
 	},
 	{
 		name:        "test help command",
@@ -63,7 +75,7 @@ var initCmdTests = []TestCase{
 		name:           "test create using directory as path",
 		args:           []string{"init", "foo/"},
 		createPackRoot: true,
-		expectedErr:    errs.ErrInvalidPublicIndexReference,
+		expectedStdout: []string{"Found 0 pack(s)"},
 		setUpFunc: func(t *TestCase) {
 			t.assert.Nil(os.Mkdir("foo/", 0777))
 		},
@@ -71,6 +83,71 @@ var initCmdTests = []TestCase{
 			os.Remove("foo/")
 		},
 	},
+	{
+		name:           "test create using a bootstrap file",
+		args:           []string{"init", "--bootstrap", bootstrapFilePath},
+		createPackRoot: true,
+		expectedStdout: []string{"Adding [" + pdscFilePath + "]"},
+		setUpFunc: func(t *TestCase) {
+			bootstrap := "index: " + pidxFilePath + "\npacks:\n  - " + pdscFilePath + "\n"
+			t.assert.Nil(os.WriteFile(bootstrapFilePath, []byte(bootstrap), 0600))
+		},
+		tearDownFunc: func() {
+			os.Remove(bootstrapFilePath)
+		},
+		validationFunc: func(t *testing.T) {
+			localRepository := xml.NewPidxXML(filepath.Join("test_create_using_a_bootstrap_file", ".Local", "local_repository.pidx"))
+			assert.Nil(t, localRepository.Read())
+			assert.Len(t, localRepository.ListPdscTags(), 1)
+		},
+	},
+	{
+		name:           "test create offline",
+		args:           []string{"init", "--local-files", filepath.Join(offlineFilesDir, "index.pidx")},
+		createPackRoot: true,
+		expectedStdout: []string{"Added 1 pdsc and 1 pack files"},
+		setUpFunc: func(t *TestCase) {
+			t.assert.Nil(os.MkdirAll(offlineFilesDir, 0755))
+			t.assert.Nil(utils.CopyFile(pidxFilePath, filepath.Join(offlineFilesDir, "index.pidx")))
+			t.assert.Nil(utils.CopyFile(pdscFilePath, filepath.Join(offlineFilesDir, filepath.Base(pdscFilePath))))
+			t.assert.Nil(utils.CopyFile(packFilePath, filepath.Join(offlineFilesDir, filepath.Base(packFilePath))))
+		},
+		tearDownFunc: func() {
+			os.RemoveAll(offlineFilesDir)
+		},
+		validationFunc: func(t *testing.T) {
+			packRoot := "test_create_offline"
+			assert.True(t, utils.FileExists(filepath.Join(packRoot, ".Web", filepath.Base(pdscFilePath))))
+			assert.True(t, utils.FileExists(filepath.Join(packRoot, ".Download", filepath.Base(packFilePath))))
+		},
+	},
+	{
+		name:           "test create with a content store",
+		args:           []string{"init", "--local-files", "--content-store", filepath.Join(offlineFilesDir, "index.pidx")},
+		createPackRoot: true,
+		setUpFunc: func(t *TestCase) {
+			t.assert.Nil(os.MkdirAll(offlineFilesDir, 0755))
+			t.assert.Nil(utils.CopyFile(pidxFilePath, filepath.Join(offlineFilesDir, "index.pidx")))
+		},
+		tearDownFunc: func() {
+			os.RemoveAll(offlineFilesDir)
+		},
+		validationFunc: func(t *testing.T) {
+			assert.True(t, utils.DirExists(filepath.Join("test_create_with_a_content_store", ".Store")))
+		},
+	},
+	{
+		name:           "test create offline using a remote index",
+		args:           []string{"init", "--local-files", "https://www.keil.com/pack/index.pidx"},
+		createPackRoot: true,
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test create using a bootstrap file that does not exist",
+		args:           []string{"init", "--bootstrap", bootstrapFilePath},
+		createPackRoot: true,
+		expectedErr:    &fs.PathError{Op: "open", Path: bootstrapFilePath, Err: syscall.ENOENT},
+	},
 }
 
 func TestInitCmd(t *testing.T) {
@@ -0,0 +1,84 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// junitTestSuites is the root of a JUnit XML report, as read by most CI systems
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// junitSeconds formats duration the way JUnit reports expect
+func junitSeconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
+
+// writeJUnitReport writes a JUnit report to path with one test case per added pack.
+// Packs whose license was declined are skipped rather than failed.
+func writeJUnitReport(path, suiteName string, results []addPackResult) error {
+	suite := junitTestSuite{
+		Name:      suiteName,
+		Tests:     len(results),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var total time.Duration
+	for _, result := range results {
+		testCase := junitTestCase{
+			Name:      result.packPath,
+			ClassName: suiteName,
+			Time:      junitSeconds(result.duration),
+		}
+		if licenseDeclined(result.err) {
+			suite.Skipped++
+			testCase.Skipped = &junitSkipped{Message: result.err.Error()}
+		} else if result.err != nil {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: result.err.Error(),
+				Text:    fmt.Sprintf("Failed adding %s: %s", result.packPath, result.err),
+			}
+		}
+		total += result.duration
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Time = junitSeconds(total)
+
+	return utils.WriteXML(path, junitTestSuites{TestSuites: []junitTestSuite{suite}})
+}
@@ -4,7 +4,14 @@
 package commands
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -21,6 +28,54 @@ var listCmdFlags struct {
 
 	// listFilter is a set of words by which to filter listed packs
 	listFilter string
+
+	// listVendor is a pattern by which to filter the vendor of listed devices and boards
+	listVendor string
+
+	// listClass is a pattern by which to filter the Cclass of listed components
+	listClass string
+
+	// listGroup is a pattern by which to filter the Cgroup of listed components
+	listGroup string
+
+	// listJSON tells whether printing listed devices, boards and components as JSON
+	listJSON bool
+
+	// listTree tells whether printing the device hierarchy as a JSON document
+	listTree bool
+}
+
+// printJSON prints value as indented JSON to the command's output
+func printJSON(cmd *cobra.Command, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	return err
+}
+
+// printJSONErrors makes run print the error it fails with as a JSON document,
+// {"error": {"code": ..., "message": ...}}, when listing as JSON. Tools
+// can then tell errors apart by their stable code, see errs.Code.
+func printJSONErrors(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		err := run(cmd, args)
+		if err == nil || (!listCmdFlags.listJSON && !listCmdFlags.listTree) {
+			return err
+		}
+
+		data, jsonErr := errs.JSON(err)
+		if jsonErr != nil {
+			return err
+		}
+		if printErr := printJSON(cmd, struct {
+			Error json.RawMessage `json:"error"`
+		}{data}); printErr != nil {
+			log.Debug(printErr)
+		}
+		return err
+	}
 }
 
 var ListCmd = &cobra.Command{
@@ -45,6 +100,229 @@ var listRequiredCmd = &cobra.Command{
 	},
 }
 
+var listDevicesCmd = &cobra.Command{
+	Use:   "devices [<pattern>]",
+	Short: "List devices supported by installed packs",
+	Long: `List devices supported by installed packs and the pack providing each of them.
+The optional pattern filters device names, e.g. "STM32F4*", ignoring case.
+Use --cached to include packs not installed whose PDSC files are in .Web/.
+
+--tree prints the whole family, sub family, device and variant hierarchy of each
+pack as a single JSON document instead, with the processor attributes of each level.`,
+	Args:              cobra.MaximumNArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: printJSONErrors(func(cmd *cobra.Command, args []string) error {
+		namePattern := ""
+		if len(args) > 0 {
+			namePattern = args[0]
+		}
+
+		if listCmdFlags.listTree {
+			if namePattern != "" || listCmdFlags.listVendor != "" {
+				log.Error("Devices cannot be filtered with --tree")
+				return errs.ErrIncorrectCmdArgs
+			}
+
+			packs, err := installer.FindDeviceTree(listCmdFlags.listCached)
+			if err != nil {
+				return err
+			}
+			return printJSON(cmd, struct {
+				Packs []installer.DeviceTreePack `json:"packs"`
+			}{packs})
+		}
+
+		devices, err := installer.FindDevices(listCmdFlags.listCached, listCmdFlags.listVendor, namePattern)
+		if err != nil {
+			return err
+		}
+
+		if listCmdFlags.listJSON {
+			return printJSON(cmd, devices)
+		}
+
+		log.Infof("Listing devices")
+		if len(devices) == 0 {
+			log.Info("(no devices found)")
+			return nil
+		}
+
+		for _, device := range devices {
+			logMessage := device.Name + " (" + device.Vendor + " " + device.Family + ") - " + device.Pack
+			if !device.Installed {
+				logMessage += " (cached)"
+			}
+			log.Info(logMessage)
+		}
+		return nil
+	}),
+}
+
+var listBoardsCmd = &cobra.Command{
+	Use:   "boards [<pattern>]",
+	Short: "List boards described by installed packs",
+	Long: `List boards described by installed packs, their mounted devices and the pack providing each of them.
+The optional pattern filters board names, e.g. "MCB*", ignoring case.
+Use --cached to include packs not installed whose PDSC files are in .Web/.`,
+	Args:              cobra.MaximumNArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: printJSONErrors(func(cmd *cobra.Command, args []string) error {
+		namePattern := ""
+		if len(args) > 0 {
+			namePattern = args[0]
+		}
+
+		boards, err := installer.FindBoards(listCmdFlags.listCached, listCmdFlags.listVendor, namePattern)
+		if err != nil {
+			return err
+		}
+
+		if listCmdFlags.listJSON {
+			return printJSON(cmd, boards)
+		}
+
+		log.Infof("Listing boards")
+		if len(boards) == 0 {
+			log.Info("(no boards found)")
+			return nil
+		}
+
+		for _, board := range boards {
+			logMessage := board.Name + " (" + board.Vendor + ")"
+			if len(board.MountedDevices) > 0 {
+				logMessage += " with " + strings.Join(board.MountedDevices, ", ")
+			}
+			logMessage += " - " + board.Pack
+			if !board.Installed {
+				logMessage += " (cached)"
+			}
+			log.Info(logMessage)
+		}
+		return nil
+	}),
+}
+
+var listComponentsCmd = &cobra.Command{
+	Use:   "components",
+	Short: "List software components provided by installed packs",
+	Long: `List software components provided by installed packs and the pack providing each of them.
+Components can be filtered by Cclass and Cgroup patterns, e.g. --class CMSIS --group "RTOS*", ignoring case.
+Use --cached to include packs not installed whose PDSC files are in .Web/.`,
+	Args:              cobra.MaximumNArgs(0),
+	PersistentPreRunE: configureInstaller,
+	RunE: printJSONErrors(func(cmd *cobra.Command, args []string) error {
+		components, err := installer.FindComponents(listCmdFlags.listCached, listCmdFlags.listClass, listCmdFlags.listGroup)
+		if err != nil {
+			return err
+		}
+
+		if listCmdFlags.listJSON {
+			return printJSON(cmd, components)
+		}
+
+		log.Infof("Listing components")
+		if len(components) == 0 {
+			log.Info("(no components found)")
+			return nil
+		}
+
+		for _, component := range components {
+			logMessage := component.ID + " - " + component.Pack
+			if !component.Installed {
+				logMessage += " (cached)"
+			}
+			log.Info(logMessage)
+		}
+		return nil
+	}),
+}
+
+var listUpdatesCmd = &cobra.Command{
+	Use:   "updates [<pattern>]",
+	Short: "List installed packs with newer public versions",
+	Long: `List installed public packs for which newer versions are in the public index,
+with the release notes of the latest version and the size of its download.
+The optional pattern filters packs in Vendor::Name format, e.g. "ARM::*", ignoring case.
+--json prints every pack with the releases since the installed version, for IDEs to
+show the updates available.`,
+	Args:              cobra.MaximumNArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: printJSONErrors(func(cmd *cobra.Command, args []string) error {
+		pattern := ""
+		if len(args) > 0 {
+			pattern = args[0]
+		}
+
+		updates, err := installer.FindPackUpdates(pattern, viper.GetInt("timeout"))
+		if err != nil {
+			return err
+		}
+
+		if listCmdFlags.listJSON {
+			return printJSON(cmd, updates)
+		}
+
+		log.Infof("Listing installed packs with available update")
+		if len(updates) == 0 {
+			log.Info("(no updates found)")
+			return nil
+		}
+
+		for _, update := range updates {
+			logMessage := fmt.Sprintf("%s can be updated from \"%s\" to \"%s\"", update.Pack, update.Version, update.LatestVersion)
+			if update.DownloadSize >= 0 {
+				logMessage += " (" + utils.FormatBytes(uint64(update.DownloadSize))
+				if update.Cached {
+					logMessage += ", cached"
+				}
+				logMessage += ")"
+			}
+			if update.ReleaseNotes != "" {
+				logMessage += ": " + update.ReleaseNotes
+			}
+			log.Info(logMessage)
+		}
+		return nil
+	}),
+}
+
+var listLicensesCmd = &cobra.Command{
+	Use:   "licenses",
+	Short: "List the decisions taken on embedded licenses",
+	Long: `List which embedded licenses of packs were accepted or declined in the pack root,
+when, by whom, how and under which SHA-256 hash of the license text, oldest first.
+Licenses accepted once are not asked for again for other versions of the same pack,
+as long as their text does not change.`,
+	Args:              cobra.MaximumNArgs(0),
+	PersistentPreRunE: configureInstaller,
+	RunE: printJSONErrors(func(cmd *cobra.Command, args []string) error {
+		decisions, err := installer.ReadEulaDecisions()
+		if err != nil {
+			return err
+		}
+
+		if listCmdFlags.listJSON {
+			return printJSON(cmd, decisions)
+		}
+
+		log.Infof("Listing license decisions")
+		if len(decisions) == 0 {
+			log.Info("(no license decisions found)")
+			return nil
+		}
+
+		for _, decision := range decisions {
+			result := "declined"
+			if decision.Accepted {
+				result = "accepted"
+			}
+			log.Infof("%s: %s %s by %s on %s (%s), sha256:%s", decision.Pack, decision.License, result,
+				decision.User, decision.Time.Format(time.RFC3339), decision.Source, decision.SHA256)
+		}
+		return nil
+	}),
+}
+
 func init() {
 	ListCmd.Flags().BoolVarP(&listCmdFlags.listCached, "cached", "c", false, "list only cached packs")
 	ListCmd.Flags().BoolVarP(&listCmdFlags.listPublic, "public", "p", false, "list packs in the public index")
@@ -52,7 +330,35 @@ func init() {
 	ListCmd.Flags().StringVarP(&listCmdFlags.listFilter, "filter", "f", "", "filter results (case sensitive, accepts several expressions)")
 	ListCmd.AddCommand(listRequiredCmd)
 
+	listDevicesCmd.Flags().BoolVarP(&listCmdFlags.listCached, "cached", "c", false, "include packs not installed whose PDSC files are in .Web/")
+	listDevicesCmd.Flags().StringVar(&listCmdFlags.listVendor, "vendor", "", "filter devices by vendor pattern, e.g. \"ST*\"")
+	listDevicesCmd.Flags().BoolVar(&listCmdFlags.listJSON, "json", false, "print devices as JSON")
+	listDevicesCmd.Flags().BoolVar(&listCmdFlags.listTree, "tree", false, "print the device hierarchy of each pack as a JSON document")
+	ListCmd.AddCommand(listDevicesCmd)
+
+	listBoardsCmd.Flags().BoolVarP(&listCmdFlags.listCached, "cached", "c", false, "include packs not installed whose PDSC files are in .Web/")
+	listBoardsCmd.Flags().StringVar(&listCmdFlags.listVendor, "vendor", "", "filter boards by vendor pattern, e.g. \"Keil\"")
+	listBoardsCmd.Flags().BoolVar(&listCmdFlags.listJSON, "json", false, "print boards as JSON")
+	ListCmd.AddCommand(listBoardsCmd)
+
+	listComponentsCmd.Flags().BoolVarP(&listCmdFlags.listCached, "cached", "c", false, "include packs not installed whose PDSC files are in .Web/")
+	listComponentsCmd.Flags().StringVar(&listCmdFlags.listClass, "class", "", "filter components by Cclass pattern, e.g. \"CMSIS\"")
+	listComponentsCmd.Flags().StringVar(&listCmdFlags.listGroup, "group", "", "filter components by Cgroup pattern, e.g. \"RTOS*\"")
+	listComponentsCmd.Flags().BoolVar(&listCmdFlags.listJSON, "json", false, "print components as JSON")
+	ListCmd.AddCommand(listComponentsCmd)
+
+	listUpdatesCmd.Flags().BoolVar(&listCmdFlags.listJSON, "json", false, "print updates as JSON")
+	ListCmd.AddCommand(listUpdatesCmd)
+
+	listLicensesCmd.Flags().BoolVar(&listCmdFlags.listJSON, "json", false, "print license decisions as JSON")
+	ListCmd.AddCommand(listLicensesCmd)
+
 	listRequiredCmd.SetHelpFunc(ListCmd.HelpFunc())
+	listDevicesCmd.SetHelpFunc(ListCmd.HelpFunc())
+	listBoardsCmd.SetHelpFunc(ListCmd.HelpFunc())
+	listComponentsCmd.SetHelpFunc(ListCmd.HelpFunc())
+	listUpdatesCmd.SetHelpFunc(ListCmd.HelpFunc())
+	listLicensesCmd.SetHelpFunc(ListCmd.HelpFunc())
 	ListCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
 		err := command.Flags().MarkHidden("concurrent-downloads")
 		_ = command.Flags().MarkHidden("timeout")
@@ -4,14 +4,21 @@
 package commands_test
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
 	"github.com/open-cmsis-pack/cpackget/cmd/xml"
 )
 
+// listCmdWorkingDir is where to come back to after running list in a project
+var listCmdWorkingDir string
+
 var listCmdTests = []TestCase{
 	{
 		name:        "test help command",
@@ -51,6 +58,179 @@ var listCmdTests = []TestCase{
 			t.assert.Nil(localRepository.Write())
 		},
 	},
+	{
+		name:           "test listing installed packs of the project pack root",
+		args:           []string{"list"},
+		createPackRoot: true,
+		env:            map[string]string{"CMSIS_PACK_ROOT": ""},
+		expectedStdout: []string{"Vendor::Pack@1.2.3"},
+		setUpFunc: func(t *TestCase) {
+			// CMSIS_PACK_ROOT is unset by now, the pack root is named after the test
+			packRoot, err := filepath.Abs("test_listing_installed_packs_of_the_project_pack_root")
+			t.assert.Nil(err)
+			packFolder := filepath.Join(packRoot, "Vendor", "Pack", "1.2.3")
+			t.assert.Nil(os.MkdirAll(packFolder, 0700))
+			t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor.Pack.pdsc"), []byte(""), 0600))
+
+			// Run from a sub folder of a project using the pack root
+			projectDir, err := os.MkdirTemp("", "project")
+			t.assert.Nil(err)
+			t.assert.Nil(os.MkdirAll(filepath.Join(projectDir, ".cpackget"), 0700))
+			t.assert.Nil(os.WriteFile(filepath.Join(projectDir, ".cpackget", "root"), []byte(packRoot+"\n"), 0600))
+			t.assert.Nil(os.MkdirAll(filepath.Join(projectDir, "src"), 0700))
+			listCmdWorkingDir, err = os.Getwd()
+			t.assert.Nil(err)
+			t.assert.Nil(os.Chdir(filepath.Join(projectDir, "src")))
+		},
+		tearDownFunc: func() {
+			projectDir, _ := os.Getwd()
+			_ = os.Chdir(listCmdWorkingDir)
+			os.RemoveAll(filepath.Dir(projectDir))
+
+			// runTests cleaned up from within the project folder, missing the pack root
+			utils.UnsetReadOnlyR("test_listing_installed_packs_of_the_project_pack_root")
+			os.RemoveAll("test_listing_installed_packs_of_the_project_pack_root")
+		},
+	},
+	{
+		name:           "test listing devices",
+		args:           []string{"list", "devices", "CHIP*"},
+		createPackRoot: true,
+		expectedStdout: []string{"CHIP100 (ChipVendor Chip Series) - Vendor::Pack@1.2.3"},
+		setUpFunc: func(t *TestCase) {
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			packFolder := filepath.Join(packRoot, "Vendor", "Pack", "1.2.3")
+			t.assert.Nil(os.MkdirAll(packFolder, 0700))
+			pdsc := `<package><vendor>Vendor</vendor><name>Pack</name><devices>
+				<family Dfamily="Chip Series" Dvendor="ChipVendor:1"><device Dname="CHIP100"/></family>
+			</devices></package>`
+			t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor.Pack.pdsc"), []byte(pdsc), 0600))
+		},
+	},
+	{
+		name:           "test listing components",
+		args:           []string{"list", "components", "--class", "CMSIS", "--group", "RTOS*"},
+		createPackRoot: true,
+		expectedStdout: []string{"Vendor::CMSIS:RTOS2@1.0.0 - Vendor::Pack@1.2.3"},
+		setUpFunc: func(t *TestCase) {
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			packFolder := filepath.Join(packRoot, "Vendor", "Pack", "1.2.3")
+			t.assert.Nil(os.MkdirAll(packFolder, 0700))
+			pdsc := `<package><vendor>Vendor</vendor><name>Pack</name><components>
+				<component Cclass="CMSIS" Cgroup="RTOS2" Cversion="1.0.0"/>
+			</components></package>`
+			t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor.Pack.pdsc"), []byte(pdsc), 0600))
+		},
+	},
+	{
+		name:           "test listing license decisions",
+		args:           []string{"list", "licenses"},
+		createPackRoot: true,
+		expectedStdout: []string{"TheVendor.PackWithLicense.1.2.3: LICENSE.txt accepted by jdoe on 2026-01-02T03:04:05Z (prompt), sha256:"},
+		setUpFunc: func(t *TestCase) {
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			decisions := `[{"pack": "TheVendor.PackWithLicense.1.2.3", "license": "LICENSE.txt", "sha256": "` + strings.Repeat("0", 64) + `",
+				"accepted": true, "source": "prompt", "user": "jdoe", "time": "2026-01-02T03:04:05Z"}]`
+			t.assert.Nil(os.WriteFile(filepath.Join(packRoot, ".Local", installer.EulaDecisionsName), []byte(decisions), 0600))
+		},
+	},
+	{
+		name:           "test listing boards as json",
+		args:           []string{"list", "boards", "--json"},
+		createPackRoot: true,
+		expectedStdout: []string{`"name": "Eval Board"`, `"mountedDevices": [`, `"pack": "Vendor::Pack@1.2.3"`},
+		setUpFunc: func(t *TestCase) {
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			packFolder := filepath.Join(packRoot, "Vendor", "Pack", "1.2.3")
+			t.assert.Nil(os.MkdirAll(packFolder, 0700))
+			pdsc := `<package><vendor>Vendor</vendor><name>Pack</name><boards>
+				<board vendor="BoardVendor" name="Eval Board"><mountedDevice Dname="CHIP100"/></board>
+			</boards></package>`
+			t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor.Pack.pdsc"), []byte(pdsc), 0600))
+		},
+	},
+	{
+		name:           "test listing the device tree",
+		args:           []string{"list", "devices", "--tree"},
+		createPackRoot: true,
+		expectedStdout: []string{`"packs": [`, `"family": "Chip Series"`, `"dcore": "Cortex-M4"`, `"name": "CHIP100"`},
+		setUpFunc: func(t *TestCase) {
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			packFolder := filepath.Join(packRoot, "Vendor", "Pack", "1.2.3")
+			t.assert.Nil(os.MkdirAll(packFolder, 0700))
+			pdsc := `<package><vendor>Vendor</vendor><name>Pack</name><devices>
+				<family Dfamily="Chip Series" Dvendor="ChipVendor:1"><processor Dcore="Cortex-M4"/><device Dname="CHIP100"/></family>
+			</devices></package>`
+			t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor.Pack.pdsc"), []byte(pdsc), 0600))
+		},
+	},
+	{
+		name:           "test listing the device tree filtered",
+		args:           []string{"list", "devices", "--tree", "CHIP*"},
+		createPackRoot: true,
+		expectedStdout: []string{`"error": {`, `"code": "INCORRECT_COMMAND_ARGUMENTS"`},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test listing no license decisions as json",
+		args:           []string{"list", "licenses", "--json"},
+		createPackRoot: true,
+		expectedStdout: []string{"[]"},
+	},
+	{
+		name:           "test listing no updates as json",
+		args:           []string{"list", "updates", "--json"},
+		createPackRoot: true,
+		expectedStdout: []string{"[]"},
+	},
+	{
+		name:           "test listing updates with a malformed pattern",
+		args:           []string{"list", "updates", "--json", "["},
+		createPackRoot: true,
+		expectedStdout: []string{`"error": {`},
+		expectedErr:    errors.New("syntax error in pattern"),
+		setUpFunc: func(t *TestCase) {
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			packFolder := filepath.Join(packRoot, "Vendor", "Pack", "1.2.3")
+			t.assert.Nil(os.MkdirAll(packFolder, 0700))
+			t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor.Pack.pdsc"), []byte(""), 0600))
+		},
+	},
+	{
+		name:           "test listing with an unknown scope",
+		args:           []string{"list"},
+		createPackRoot: true,
+		env:            map[string]string{"CPACKGET_SCOPE": "machine"},
+		expectedStdout: []string{"--scope must be \"user\" or \"system\", not \"machine\""},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test listing the system scope without system pack root",
+		args:           []string{"list"},
+		createPackRoot: true,
+		env:            map[string]string{"CPACKGET_SCOPE": "system"},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test listing packs of the system pack root",
+		args:           []string{"list"},
+		createPackRoot: true,
+		env:            map[string]string{"CPACKGET_SYSTEM_PACK_ROOT": "test_listing_packs_of_the_system_pack_root_system"},
+		expectedStdout: []string{"Vendor::SystemPack@1.0.0 (system)", "Vendor::Pack@1.2.3\n"},
+		setUpFunc: func(t *TestCase) {
+			for packRoot, pack := range map[string]string{
+				os.Getenv("CMSIS_PACK_ROOT"):                        "Pack/1.2.3",
+				"test_listing_packs_of_the_system_pack_root_system": "SystemPack/1.0.0",
+			} {
+				packFolder := filepath.Join(packRoot, "Vendor", filepath.FromSlash(pack))
+				t.assert.Nil(os.MkdirAll(packFolder, 0700))
+				t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor."+filepath.Base(filepath.Dir(packFolder))+".pdsc"), []byte(""), 0600))
+			}
+		},
+		tearDownFunc: func() {
+			os.RemoveAll("test_listing_packs_of_the_system_pack_root_system")
+		},
+	},
 	/*  TODO
 	{
 		name:           "test listing required packs",
@@ -0,0 +1,50 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"path"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var MaterializeCmd = &cobra.Command{
+	Use:   "materialize <pack> [<glob>...]",
+	Short: "Extract the files a slim or filtered install of a pack skipped",
+	Long: `
+Extract the documentation, examples and component files "cpackget add --slim",
+"--include-component" or "--exclude-component" skipped from the pack's archive
+in .Download/, which keeps them compressed until they are needed:
+
+  $ cpackget materialize Vendor::Pack@1.2.3
+  $ cpackget materialize Vendor::Pack "Examples/Blinky/*"
+
+Only skipped files matching one of the globs are extracted, all of them without globs.
+Globs without a slash match the file name, e.g. "*.pdf". The pack defaults to its latest
+installed version.`,
+	Args:              cobra.MinimumNArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, pattern := range args[1:] {
+			if _, err := path.Match(pattern, ""); err != nil {
+				log.Errorf("Invalid glob: %s", err)
+				return errs.ErrIncorrectCmdArgs
+			}
+		}
+
+		installer.UnlockPackRoot()
+		defer installer.LockPackRoot()
+		extracted, err := installer.MaterializePack(args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		if extracted > 0 {
+			log.Infof("Extracted %d skipped file(s) of %s", extracted, args[0])
+		}
+		return nil
+	},
+}
@@ -0,0 +1,40 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+)
+
+var materializeCmdTests = []TestCase{
+	{
+		name:        "test no parameter given",
+		args:        []string{"materialize"},
+		expectedErr: errors.New("requires at least 1 arg(s), only received 0"),
+	},
+	{
+		name:        "test help command",
+		args:        []string{"help", "materialize"},
+		expectedErr: nil,
+	},
+	{
+		name:           "test materializing with an invalid glob",
+		args:           []string{"materialize", "TheVendor::PackName", "["},
+		createPackRoot: true,
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test materializing a pack not installed",
+		args:           []string{"materialize", "TheVendor::PackName"},
+		createPackRoot: true,
+		expectedErr:    errs.ErrPackNotInstalled,
+	},
+}
+
+func TestMaterializeCmd(t *testing.T) {
+	runTests(t, materializeCmdTests)
+}
@@ -0,0 +1,90 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// commandStart is when the running command started, reported by --metrics
+var commandStart time.Time
+
+// metricsReport is the JSON written by --metrics-file
+type metricsReport struct {
+	Command string              `json:"command"`
+	Seconds float64             `json:"seconds"`
+	Packs   []packMetricsReport `json:"packs"`
+}
+
+type packMetricsReport struct {
+	Pack                string  `json:"pack"`
+	DownloadSeconds     float64 `json:"downloadSeconds"`
+	DownloadBytes       int64   `json:"downloadBytes"`
+	VerificationSeconds float64 `json:"verificationSeconds"`
+	ExtractionSeconds   float64 `json:"extractionSeconds"`
+	ExtractionBytes     uint64  `json:"extractionBytes"`
+}
+
+// configureMetrics starts measuring the running command if requested
+func configureMetrics() {
+	commandStart = time.Now()
+	installer.SetCollectMetrics(viper.GetBool("metrics") || viper.GetString("metrics-file") != "")
+}
+
+// reportMetrics prints and/or writes the metrics gathered while running cmd
+func reportMetrics(cmd *cobra.Command, args []string) error {
+	printMetrics := viper.GetBool("metrics")
+	metricsFile := viper.GetString("metrics-file")
+	if !printMetrics && metricsFile == "" {
+		return nil
+	}
+
+	report := metricsReport{
+		Command: cmd.Name(),
+		Seconds: time.Since(commandStart).Seconds(),
+		Packs:   []packMetricsReport{},
+	}
+	for _, metrics := range installer.GetMetrics() {
+		report.Packs = append(report.Packs, packMetricsReport{
+			Pack:                metrics.PackID,
+			DownloadSeconds:     metrics.DownloadTime.Seconds(),
+			DownloadBytes:       metrics.DownloadBytes,
+			VerificationSeconds: metrics.VerificationTime.Seconds(),
+			ExtractionSeconds:   metrics.ExtractionTime.Seconds(),
+			ExtractionBytes:     metrics.ExtractionBytes,
+		})
+	}
+
+	if printMetrics {
+		log.Infof("Metrics for \"%s\", took %.2fs:", report.Command, report.Seconds)
+		for _, pack := range report.Packs {
+			download := "cached"
+			if pack.DownloadBytes > 0 {
+				download = utils.FormatBytes(uint64(pack.DownloadBytes)) + " in " + formatSeconds(pack.DownloadSeconds)
+			}
+			log.Infof("  %s: download %s, verification %s, extraction %s in %s", pack.Pack, download,
+				formatSeconds(pack.VerificationSeconds), utils.FormatBytes(pack.ExtractionBytes), formatSeconds(pack.ExtractionSeconds))
+		}
+	}
+
+	if metricsFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		return utils.WriteFileAtomic(metricsFile, append(data, '\n'), utils.FileModeRW)
+	}
+
+	return nil
+}
+
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond).String()
+}
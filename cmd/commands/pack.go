@@ -0,0 +1,105 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"path/filepath"
+	"time"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var packBumpCmdFlags struct {
+	// major, minor and patch tell which part of the version to increment
+	major bool
+	minor bool
+	patch bool
+
+	// date is the date of the new release, YYYY-MM-DD or "today"
+	date string
+
+	// pdscPath is the PDSC file to edit
+	pdscPath string
+}
+
+var PackCmd = &cobra.Command{
+	Use:   "pack",
+	Short: "Help authoring packs",
+	Long:  "Help authoring packs, e.g. by editing their PDSC file",
+	Args:  cobra.MaximumNArgs(0),
+}
+
+var packBumpCmd = &cobra.Command{
+	Use:   "bump [--major | --minor | --patch] [--date <date>] [--pdsc <file>] <release notes>",
+	Short: "Add a release to a PDSC file",
+	Long: `
+Add a release to the <releases> section of a PDSC file:
+
+  $ cpackget pack bump --minor --date today "Added support for new devices"
+
+The version of the new release is the latest one with its major, minor or, by
+default, patch part incremented. The new release goes first, as the newest one,
+and the rest of the file is left untouched. --date takes "today" or a date like
+2024-01-31, and --pdsc the PDSC file to edit, by default the only one in the
+current directory.`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstallerGlobalCmd,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		part := "patch"
+		parts := 0
+		for name, set := range map[string]bool{"major": packBumpCmdFlags.major, "minor": packBumpCmdFlags.minor, "patch": packBumpCmdFlags.patch} {
+			if set {
+				part = name
+				parts++
+			}
+		}
+		if parts > 1 {
+			log.Error("Only one of --major, --minor and --patch can be used")
+			return errs.ErrIncorrectCmdArgs
+		}
+
+		date := packBumpCmdFlags.date
+		if date == "today" {
+			date = time.Now().Format("2006-01-02")
+		}
+
+		pdscPath := packBumpCmdFlags.pdscPath
+		if pdscPath == "" {
+			matches, err := filepath.Glob("*.pdsc")
+			if err != nil {
+				return err
+			}
+			if len(matches) != 1 {
+				log.Errorf("Found %d PDSC files in the current directory, specify one with --pdsc", len(matches))
+				return errs.ErrIncorrectCmdArgs
+			}
+			pdscPath = matches[0]
+		}
+
+		_, err := installer.BumpRelease(pdscPath, part, date, args[0])
+		return err
+	},
+}
+
+func init() {
+	packBumpCmd.Flags().BoolVar(&packBumpCmdFlags.major, "major", false, "increments the major version, e.g. 1.2.3 to 2.0.0")
+	packBumpCmd.Flags().BoolVar(&packBumpCmdFlags.minor, "minor", false, "increments the minor version, e.g. 1.2.3 to 1.3.0")
+	packBumpCmd.Flags().BoolVar(&packBumpCmdFlags.patch, "patch", false, "increments the patch version, e.g. 1.2.3 to 1.2.4 (default)")
+	packBumpCmd.Flags().StringVar(&packBumpCmdFlags.date, "date", "today", "date of the new release, YYYY-MM-DD or \"today\"")
+	packBumpCmd.Flags().StringVar(&packBumpCmdFlags.pdscPath, "pdsc", "", "PDSC file to edit, by default the only one in the current directory")
+
+	PackCmd.AddCommand(packBumpCmd)
+
+	packBumpCmd.SetHelpFunc(PackCmd.HelpFunc())
+	PackCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
+		err := command.Flags().MarkHidden("pack-root")
+		_ = command.Flags().MarkHidden("concurrent-downloads")
+		_ = command.Flags().MarkHidden("timeout")
+		log.Debug(err)
+		command.Parent().HelpFunc()(command, strings)
+	})
+}
@@ -0,0 +1,86 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var PackRootCmd = &cobra.Command{
+	Use:   "root",
+	Short: "Manage the pack root folder",
+	Long:  "Manage the pack root folder specified by -R/--pack-root or the CMSIS_PACK_ROOT environment variable",
+	Args:  cobra.MaximumNArgs(0),
+}
+
+var packRootMoveCmd = &cobra.Command{
+	Use:   "move <new pack root>",
+	Short: "Move the pack root to another folder",
+	Long: `
+Move the pack root to a new folder, which must either not exist or be empty.
+
+  $ cpackget root move --pack-root path/to/packroot path/to/newpackroot
+
+Packs added via PDSC files stored inside the pack root keep working from
+the new location and read-only flags are kept. If the move gets interrupted,
+running the same command again resumes it.
+
+Remember to update CMSIS_PACK_ROOT once the pack root is moved.`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstallerGlobalCmd,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return installer.MovePackRoot(viper.GetString("pack-root"), args[0])
+	},
+}
+
+var packRootExportCmd = &cobra.Command{
+	Use:   "export <archive>",
+	Short: "Export the pack root to an archive",
+	Long: `
+Export the whole pack root, i.e. packs, index and cached files, to a .tar,
+.tar.gz or .tgz archive:
+
+  $ cpackget root export --pack-root path/to/packroot packroot.tar.gz
+
+The archive can be imported on other machines with "cpackget root import".`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstallerGlobalCmd,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return installer.ExportPackRoot(viper.GetString("pack-root"), args[0])
+	},
+}
+
+var packRootImportCmd = &cobra.Command{
+	Use:   "import <archive>",
+	Short: "Import the pack root from an archive",
+	Long: `
+Import a pack root exported by "cpackget root export" into a folder,
+which must either not exist or be empty:
+
+  $ cpackget root import --pack-root path/to/packroot packroot.tar.gz
+
+Packs added via PDSC files stored inside the exported pack root keep
+working from the new location.`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstallerGlobalCmd,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return installer.ImportPackRoot(args[0], viper.GetString("pack-root"))
+	},
+}
+
+func init() {
+	PackRootCmd.AddCommand(packRootMoveCmd, packRootExportCmd, packRootImportCmd)
+
+	packRootMoveCmd.SetHelpFunc(PackRootCmd.HelpFunc())
+	packRootExportCmd.SetHelpFunc(PackRootCmd.HelpFunc())
+	packRootImportCmd.SetHelpFunc(PackRootCmd.HelpFunc())
+	PackRootCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
+		err := command.Flags().MarkHidden("concurrent-downloads")
+		_ = command.Flags().MarkHidden("timeout")
+		log.Debug(err)
+		command.Parent().HelpFunc()(command, strings)
+	})
+}
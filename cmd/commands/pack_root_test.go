@@ -0,0 +1,88 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var movedPackRoot = "test_moved_pack_root"
+var exportedPackRootArchive = "test_exported_pack_root.tar.gz"
+
+var packRootCmdTests = []TestCase{
+	{
+		name:           "test moving pack root no args",
+		args:           []string{"root", "move"},
+		createPackRoot: true,
+		expectedErr:    errors.New("accepts 1 arg(s), received 0"),
+	},
+	{
+		name:        "test help command",
+		args:        []string{"help", "root", "move"},
+		expectedErr: nil,
+	},
+	{
+		name:           "test moving pack root",
+		args:           []string{"root", "move", movedPackRoot},
+		createPackRoot: true,
+		expectedStdout: []string{"Pack root moved to"},
+		tearDownFunc: func() {
+			utils.UnsetReadOnlyR(movedPackRoot)
+			os.RemoveAll(movedPackRoot)
+		},
+		validationFunc: func(t *testing.T) {
+			assert.False(t, utils.DirExists("test_moving_pack_root"))
+			assert.True(t, utils.FileExists(filepath.Join(movedPackRoot, ".Web", "index.pidx")))
+		},
+	},
+	{
+		name:           "test exporting pack root to an unsupported archive",
+		args:           []string{"root", "export", "packroot.tar.zst"},
+		createPackRoot: true,
+		expectedStdout: []string{"Zstandard compressed archives are not supported"},
+		expectedErr:    errs.ErrUnsupportedArchiveFormat,
+	},
+	{
+		name:           "test exporting pack root",
+		args:           []string{"root", "export", exportedPackRootArchive},
+		createPackRoot: true,
+		expectedStdout: []string{"Pack root exported to"},
+		tearDownFunc: func() {
+			os.Remove(exportedPackRootArchive)
+		},
+		validationFunc: func(t *testing.T) {
+			assert.True(t, utils.FileExists(exportedPackRootArchive))
+		},
+	},
+	{
+		name: "test importing pack root",
+		args: []string{"root", "import", exportedPackRootArchive},
+		setUpFunc: func(t *TestCase) {
+			packRoot := "test_importing_pack_root_exported"
+			t.assert.Nil(installer.SetPackRoot(packRoot, true))
+			t.assert.Nil(installer.ExportPackRoot(packRoot, exportedPackRootArchive))
+			utils.UnsetReadOnlyR(packRoot)
+			os.RemoveAll(packRoot)
+		},
+		expectedStdout: []string{"Pack root imported into"},
+		tearDownFunc: func() {
+			os.Remove(exportedPackRootArchive)
+		},
+		validationFunc: func(t *testing.T) {
+			assert.True(t, utils.FileExists(filepath.Join("test_importing_pack_root", ".Web", "index.pidx")))
+		},
+	},
+}
+
+func TestPackRootCmd(t *testing.T) {
+	runTests(t, packRootCmdTests)
+}
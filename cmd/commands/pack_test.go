@@ -0,0 +1,52 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"os"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+)
+
+var bumpPdscPath = "Vendor.BumpPack.pdsc"
+
+var packCmdTests = []TestCase{
+	{
+		name:        "test help command",
+		args:        []string{"help", "pack", "bump"},
+		expectedErr: nil,
+	},
+	{
+		name:           "test bumping the minor version",
+		args:           []string{"pack", "bump", "--pdsc", bumpPdscPath, "--minor", "--date", "2024-05-01", "New devices"},
+		expectedStdout: []string{"Added release 1.3.0 dated 2024-05-01"},
+		setUpFunc: func(t *TestCase) {
+			pdsc := "<package>\n  <releases>\n    <release version=\"1.2.3\">First release</release>\n  </releases>\n</package>\n"
+			t.assert.Nil(os.WriteFile(bumpPdscPath, []byte(pdsc), 0600))
+		},
+		validationFunc: func(t *testing.T) {
+			data, err := os.ReadFile(bumpPdscPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := "<package>\n  <releases>\n    <release version=\"1.3.0\" date=\"2024-05-01\">New devices</release>\n    <release version=\"1.2.3\">First release</release>\n  </releases>\n</package>\n"
+			if string(data) != expected {
+				t.Errorf("unexpected pdsc file:\n%s", data)
+			}
+		},
+		tearDownFunc: func() {
+			os.Remove(bumpPdscPath)
+		},
+	},
+	{
+		name:        "test bumping several parts",
+		args:        []string{"pack", "bump", "--pdsc", bumpPdscPath, "--major", "--minor", "Notes"},
+		expectedErr: errs.ErrIncorrectCmdArgs,
+	},
+}
+
+func TestPackCmd(t *testing.T) {
+	runTests(t, packCmdTests)
+}
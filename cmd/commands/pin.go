@@ -0,0 +1,73 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var PinCmd = &cobra.Command{
+	Use:   "pin [<pack>]",
+	Short: "Pin installed packs to their version",
+	Long: `
+Pin an installed pack to one of its versions, or to its latest installed version if none is given:
+
+  $ cpackget pin Vendor::Pack@1.2.3
+  $ cpackget pin Vendor.Pack@1.2.3
+  $ cpackget pin Vendor.Pack
+
+  Pinned packs are skipped by "cpackget update" and "cpackget rm --all",
+  and updating or removing them fails unless "--force" is given, protecting
+  qualified toolchain configurations from casual modification. Pins are
+  recorded in ".Local/pinned_packs.json".
+
+  $ cpackget pin
+
+  Use this to list the pinned packs.`,
+	Args:              cobra.MaximumNArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			return installer.PinPack(args[0])
+		}
+
+		pins, err := installer.ReadPinnedPacks()
+		if err != nil {
+			return err
+		}
+		log.Info("Listing pinned packs")
+		if len(pins) == 0 {
+			log.Info("(no packs pinned)")
+			return nil
+		}
+		keys := make([]string, 0, len(pins))
+		for key := range pins {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			log.Info(strings.Replace(key, ".", "::", 1) + "@" + pins[key])
+		}
+		return nil
+	},
+}
+
+var UnpinCmd = &cobra.Command{
+	Use:   "unpin <pack>",
+	Short: "Unpin packs pinned with \"cpackget pin\"",
+	Long: `
+Remove the pin of a pack, letting "cpackget update" and "cpackget rm" change it again:
+
+  $ cpackget unpin Vendor::Pack`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: configureInstaller,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return installer.UnpinPack(args[0])
+	},
+}
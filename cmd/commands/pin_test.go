@@ -0,0 +1,95 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+// installFakePackFolder creates the folder of Vendor.Pack.1.2.3 with an empty PDSC file
+func installFakePackFolder(t *TestCase) {
+	packFolder := filepath.Join(os.Getenv("CMSIS_PACK_ROOT"), "Vendor", "Pack", "1.2.3")
+	t.assert.Nil(os.MkdirAll(packFolder, 0700))
+	t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor.Pack.pdsc"), []byte(""), 0600))
+}
+
+var pinCmdTests = []TestCase{
+	{
+		name:        "test help command",
+		args:        []string{"help", "pin"},
+		expectedErr: nil,
+	},
+	{
+		name:           "test pinning too many packs",
+		args:           []string{"pin", "Vendor.Pack", "Vendor.OtherPack"},
+		createPackRoot: true,
+		expectedErr:    errors.New("accepts at most 1 arg(s), received 2"),
+	},
+	{
+		name:           "test listing no pinned packs",
+		args:           []string{"pin"},
+		createPackRoot: true,
+		expectedStdout: []string{"(no packs pinned)"},
+	},
+	{
+		name:           "test pinning a pack that is not installed",
+		args:           []string{"pin", "Vendor::Pack@1.2.3"},
+		createPackRoot: true,
+		expectedErr:    errs.ErrPackNotInstalled,
+	},
+	{
+		name:           "test pinning a pack",
+		args:           []string{"pin", "Vendor.Pack@1.2.3"},
+		createPackRoot: true,
+		expectedStdout: []string{"Pinned Vendor::Pack@1.2.3"},
+		setUpFunc:      installFakePackFolder,
+		validationFunc: func(t *testing.T) {
+			pins, err := installer.ReadPinnedPacks()
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]string{"Vendor.Pack": "1.2.3"}, pins)
+		},
+	},
+	{
+		name:           "test listing pinned packs",
+		args:           []string{"pin"},
+		createPackRoot: true,
+		expectedStdout: []string{"Vendor::Pack@1.2.3"},
+		setUpFunc: func(t *TestCase) {
+			t.assert.Nil(os.WriteFile(filepath.Join(os.Getenv("CMSIS_PACK_ROOT"), ".Local", installer.PinnedPacksName), []byte(`{"Vendor.Pack": "1.2.3"}`), 0600))
+		},
+	},
+}
+
+var unpinCmdTests = []TestCase{
+	{
+		name:           "test unpinning no pack",
+		args:           []string{"unpin"},
+		createPackRoot: true,
+		expectedErr:    errors.New("accepts 1 arg(s), received 0"),
+	},
+	{
+		name:           "test unpinning a pack",
+		args:           []string{"unpin", "Vendor::Pack"},
+		createPackRoot: true,
+		expectedStdout: []string{"Unpinned Vendor::Pack@1.2.3"},
+		setUpFunc: func(t *TestCase) {
+			t.assert.Nil(os.WriteFile(filepath.Join(os.Getenv("CMSIS_PACK_ROOT"), ".Local", installer.PinnedPacksName), []byte(`{"Vendor.Pack": "1.2.3"}`), 0600))
+		},
+	},
+}
+
+func TestPinCmd(t *testing.T) {
+	runTests(t, pinCmdTests)
+}
+
+func TestUnpinCmd(t *testing.T) {
+	runTests(t, unpinCmdTests)
+}
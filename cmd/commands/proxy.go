@@ -0,0 +1,118 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var proxyCmdFlags struct {
+	// addr is the address the proxy listens on
+	addr string
+
+	// cacheDir is where the proxy keeps the files it fetched
+	cacheDir string
+
+	// allowedHosts are the hosts the proxy fetches files from
+	allowedHosts []string
+}
+
+var ProxyCmd = &cobra.Command{
+	Use:   "proxy [--addr <address>] [--proxy-cache <path>] [--allow-host <host>]...",
+	Short: "Serve and cache pack downloads for other machines",
+	Long: `
+Run a caching proxy for pack, PDSC and index files, so that a build farm
+downloads each pack from the Internet only once:
+
+  $ cpackget proxy --addr :9000
+
+Clients request files with the upstream URL as path, e.g.
+"http://proxy:9000/https/www.keil.com/pack/index.pidx", or use the proxy as
+HTTP proxy. Pack files are kept for good, PDSC and index files are fetched
+again on every request and served from the cache when upstream is unreachable.
+
+Files are only fetched from the host of the public index, the vendors listed
+in the indexes the proxy served and the hosts given with --allow-host:
+
+  $ cpackget proxy --allow-host packs.example.com --allow-host mirror.example.com:8080`,
+	Args:              cobra.MaximumNArgs(0),
+	PersistentPreRunE: configureInstallerGlobalCmd,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cacheDir := proxyCmdFlags.cacheDir
+		if cacheDir == "" {
+			userCacheDir, err := os.UserCacheDir()
+			if err != nil {
+				return err
+			}
+			cacheDir = filepath.Join(userCacheDir, "cpackget", "proxy")
+		}
+
+		listener, err := net.Listen("tcp", proxyCmdFlags.addr)
+		if err != nil {
+			return err
+		}
+
+		server := &http.Server{
+			Handler:           installer.NewProxy(cacheDir, proxyAllowedHosts()),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		served := make(chan error, 1)
+		go func() { served <- server.Serve(listener) }()
+		log.Infof("Proxying downloads on %s, caching them in \"%s\"", listener.Addr(), cacheDir)
+
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case err := <-served:
+				return err
+			case <-ticker.C:
+				if utils.ShouldAbortFunction != nil && utils.ShouldAbortFunction() {
+					log.Info("Stopping the proxy")
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					if err := server.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+						return err
+					}
+					return nil
+				}
+			}
+		}
+	},
+}
+
+// proxyAllowedHosts returns the hosts given with --allow-host and the host of the public index
+func proxyAllowedHosts() []string {
+	allowedHosts := append([]string{}, proxyCmdFlags.allowedHosts...)
+	if publicIndex, err := url.Parse(defaultPublicIndex); err == nil {
+		allowedHosts = append(allowedHosts, publicIndex.Host)
+	}
+	return allowedHosts
+}
+
+func init() {
+	ProxyCmd.Flags().StringVar(&proxyCmdFlags.addr, "addr", ":9000", "address the proxy listens on")
+	ProxyCmd.Flags().StringVar(&proxyCmdFlags.cacheDir, "proxy-cache", "", "folder keeping the fetched files, defaults to the user's cache folder")
+	ProxyCmd.Flags().StringSliceVar(&proxyCmdFlags.allowedHosts, "allow-host", nil, "host, optionally with port, to fetch files from on top of the public index and its vendors; \"*\" allows any")
+
+	ProxyCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
+		err := command.Flags().MarkHidden("concurrent-downloads")
+		_ = command.Flags().MarkHidden("timeout")
+		log.Debug(err)
+		command.Parent().HelpFunc()(command, strings)
+	})
+}
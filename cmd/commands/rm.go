@@ -19,6 +19,15 @@ var rmCmdFlags struct {
 
 	// skipTouch does not touch pack.idx after adding
 	skipTouch bool
+
+	// stale stores the value of "--stale" flag for the "pack rm" command
+	stale bool
+
+	// all stores the value of "--all" flag for the "pack rm" command
+	all bool
+
+	// force lets pinned packs be removed
+	force bool
 }
 
 var RmCmd = &cobra.Command{
@@ -47,14 +56,46 @@ Remove a pack using the reference "Vendor.Pack[.x.y.z]", "Vendor::Pack[@x.y.z]"
   wish to remove a specific one by specifying a more complete
   PDSC file path, as shown in the second example.
 
+  $ cpackget rm --stale
+
+  With "--stale", the references in ".Local/local_repository.pidx"
+  to PDSC files that no longer exist, e.g. of deleted development
+  trees, are removed instead. "cpackget doctor env" reports them.
+
+  $ cpackget rm --all
+
+  With "--all", all the packs installed in the pack root are removed,
+  except the ones pinned with "cpackget pin". Removing pinned packs
+  fails unless "--force" is given.
+
 The version "x.y.z" is optional.
 Cache files (i.e. under CMSIS_PACK_ROOT/.Download/)
 are *NOT* removed. If cache files need to be actually removed,
 please use "--purge".`,
-	Args:              cobra.MinimumNArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if rmCmdFlags.stale || rmCmdFlags.all {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	PersistentPreRunE: configureInstaller,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		utils.SetSkipTouch(rmCmdFlags.skipTouch)
+		installer.SetForcePinned(rmCmdFlags.force)
+		if rmCmdFlags.stale {
+			installer.UnlockPackRoot()
+			defer installer.LockPackRoot()
+			pruned, err := installer.PruneLocalPdscs()
+			if err == nil {
+				log.Infof("Removed %d stale PDSC reference(s)", len(pruned))
+			}
+			return err
+		}
+		if rmCmdFlags.all {
+			installer.UnlockPackRoot()
+			defer installer.LockPackRoot()
+			return installer.RemoveAllPacks(rmCmdFlags.purge, viper.GetInt("timeout"))
+		}
 		log.Infof("Removing %v", args)
 		var lastErr error
 		installer.UnlockPackRoot()
@@ -62,14 +103,15 @@ please use "--purge".`,
 			var err error
 			if filepath.Ext(packPath) == ".pdsc" {
 				err = installer.RemovePdsc(packPath)
-				if err == errs.ErrPdscEntryNotFound {
+				if errs.Is(err, errs.ErrPdscEntryNotFound) {
 					err = errs.ErrPackNotInstalled
 				}
 			} else {
 				err = installer.RemovePack(packPath, rmCmdFlags.purge, viper.GetInt("timeout"))
 			}
 			if err != nil {
-				if err != errs.ErrAlreadyLogged {
+				reportError(err, packPath)
+				if !errs.Is(err, errs.ErrAlreadyLogged) {
 					log.Error(err)
 					err = errs.ErrAlreadyLogged
 				}
@@ -86,6 +128,9 @@ please use "--purge".`,
 func init() {
 	RmCmd.Flags().BoolVarP(&rmCmdFlags.purge, "purge", "p", false, "forces deletion of cached pack files")
 	RmCmd.Flags().BoolVar(&rmCmdFlags.skipTouch, "skip-touch", false, "do not touch pack.idx")
+	RmCmd.Flags().BoolVar(&rmCmdFlags.stale, "stale", false, "removes the references to PDSC files that no longer exist")
+	RmCmd.Flags().BoolVar(&rmCmdFlags.all, "all", false, "removes all installed packs but the pinned ones")
+	RmCmd.Flags().BoolVar(&rmCmdFlags.force, "force", false, "removes pinned packs too")
 
 	RmCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
 		err := command.Flags().MarkHidden("concurrent-downloads")
@@ -12,6 +12,7 @@ import (
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"github.com/open-cmsis-pack/cpackget/cmd/installer"
 	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/stretchr/testify/assert"
 )
 
 var rmCmdTests = []TestCase{
@@ -68,6 +69,65 @@ var rmCmdTests = []TestCase{
 			t.assert.Nil(localRepository.Write())
 		},
 	},
+	{
+		name:           "test removing stale pdsc references with a pack",
+		args:           []string{"rm", "--stale", "Vendor.Pack.1.2.3"},
+		createPackRoot: true,
+		expectedErr:    errors.New("unknown command \"Vendor.Pack.1.2.3\" for \"cpackget rm\""),
+	},
+	{
+		name:           "test removing stale pdsc references",
+		args:           []string{"rm", "--stale"},
+		createPackRoot: true,
+		expectedStdout: []string{"Removing \"Vendor.PackInstalledViaPdsc.1.2.3\"", "Removed 1 stale PDSC reference(s)"},
+		setUpFunc: func(t *TestCase) {
+			localRepository := installer.Installation.LocalPidx
+			t.assert.Nil(localRepository.Read())
+			t.assert.Nil(localRepository.AddPdsc(xml.PdscTag{Vendor: "Vendor", Name: "PackInstalledViaPdsc", Version: "1.2.3", URL: "file://localhost/deleted/dev/tree/"}))
+			t.assert.Nil(localRepository.Write())
+		},
+	},
+	{
+		name:           "test removing all packs with a pack",
+		args:           []string{"rm", "--all", "Vendor.Pack.1.2.3"},
+		createPackRoot: true,
+		expectedErr:    errors.New("unknown command \"Vendor.Pack.1.2.3\" for \"cpackget rm\""),
+	},
+	{
+		name:           "test removing all packs but the pinned ones",
+		args:           []string{"rm", "--all"},
+		createPackRoot: true,
+		expectedStdout: []string{"Keeping Vendor::Pack@1.2.3, it is pinned", "Removed 1 pack(s), kept 1 pinned pack(s)"},
+		setUpFunc: func(t *TestCase) {
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			for _, pack := range []string{"Pack", "OtherPack"} {
+				packFolder := filepath.Join(packRoot, "Vendor", pack, "1.2.3")
+				t.assert.Nil(os.MkdirAll(packFolder, 0700))
+				t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor."+pack+".pdsc"), []byte(""), 0600))
+				t.assert.Nil(os.WriteFile(filepath.Join(packRoot, ".Local", "Vendor."+pack+".pdsc"), []byte(""), 0600))
+			}
+			t.assert.Nil(os.WriteFile(filepath.Join(packRoot, ".Local", installer.PinnedPacksName), []byte(`{"Vendor.Pack": "1.2.3"}`), 0600))
+		},
+		validationFunc: func(t *testing.T) {
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			assert.DirExists(t, filepath.Join(packRoot, "Vendor", "Pack", "1.2.3"))
+			assert.NoDirExists(t, filepath.Join(packRoot, "Vendor", "OtherPack", "1.2.3"))
+		},
+	},
+	{
+		name:           "test removing a pinned pack",
+		args:           []string{"rm", "Vendor.Pack.1.2.3"},
+		createPackRoot: true,
+		expectedStdout: []string{"Vendor::Pack is pinned to 1.2.3"},
+		expectedErr:    errs.ErrAlreadyLogged,
+		setUpFunc: func(t *TestCase) {
+			packRoot := os.Getenv("CMSIS_PACK_ROOT")
+			packFolder := filepath.Join(packRoot, "Vendor", "Pack", "1.2.3")
+			t.assert.Nil(os.MkdirAll(packFolder, 0700))
+			t.assert.Nil(os.WriteFile(filepath.Join(packFolder, "Vendor.Pack.pdsc"), []byte(""), 0600))
+			t.assert.Nil(os.WriteFile(filepath.Join(packRoot, ".Local", installer.PinnedPacksName), []byte(`{"Vendor.Pack": "1.2.3"}`), 0600))
+		},
+	},
 }
 
 func TestRmCmd(t *testing.T) {
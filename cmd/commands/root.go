@@ -9,9 +9,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/ui"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -22,8 +27,12 @@ import (
 var AllCommands = []*cobra.Command{
 	InitCmd,
 	AddCmd,
+	DownloadCmd,
 	RmCmd,
+	PinCmd,
+	UnpinCmd,
 	ListCmd,
+	IndexCmd,
 	UpdateIndexCmd,
 	UpdateCmd,
 	ChecksumCreateCmd,
@@ -31,6 +40,20 @@ var AllCommands = []*cobra.Command{
 	SignatureCreateCmd,
 	SignatureVerifyCmd,
 	ConnectionCmd,
+	PackRootCmd,
+	ImportCmd,
+	CacheCmd,
+	ProxyCmd,
+	ExamplesCmd,
+	GrepCmd,
+	PackCmd,
+	DoctorCmd,
+	ExtractCmd,
+	CatCmd,
+	ContentsCmd,
+	DiffCmd,
+	MaterializeCmd,
+	SelfUpdateCmd,
 }
 
 // createPackRoot is a flag that determines if the pack root should be created or not
@@ -41,6 +64,31 @@ const defaultPublicIndex = "https://www.keil.com/pack/index.pidx"
 
 var viper *viperType.Viper
 
+// ciMode makes cpackget suitable for unattended builds, see --ci
+var ciMode bool
+
+// ciDownloadRetries is how many times downloads are retried in CI mode
+const ciDownloadRetries = 3
+
+// ciEnvVars are set by common CI systems, any of them turns on CI mode by default
+var ciEnvVars = []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI", "JENKINS_URL", "TF_BUILD", "BUILDKITE", "TEAMCITY_VERSION"}
+
+// detectCI tells whether cpackget runs in a CI environment
+func detectCI() bool {
+	for _, envVar := range ciEnvVars {
+		if value, ok := os.LookupEnv(envVar); ok && value != "" && value != "0" && strings.ToLower(value) != "false" {
+			return true
+		}
+	}
+	return false
+}
+
+// declineLicenseInCI is the license prompt in CI mode, where nobody can answer it
+func declineLicenseInCI(licenseTitle, licenseContents string) (bool, error) {
+	log.Debugf("Not prompting for license \"%s\" in CI mode", licenseTitle)
+	return false, errs.ErrEulaNotAgreed
+}
+
 func configureInstallerGlobalCmd(cmd *cobra.Command, args []string) error {
 	verbosiness := viper.GetBool("verbose")
 	quiet := viper.GetBool("quiet")
@@ -59,9 +107,83 @@ func configureInstallerGlobalCmd(cmd *cobra.Command, args []string) error {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	ui.PromptTimeout = time.Duration(viper.GetUint("license-prompt-timeout")) * time.Minute
+
+	ciMode = viper.GetBool("ci")
+	if ciMode {
+		log.Debug("Running in CI mode")
+		// CI logs are no terminal, keep color escape codes out of them
+		if formatter, ok := log.StandardLogger().Formatter.(*log.TextFormatter); ok {
+			formatter.DisableColors = true
+		}
+		ui.LicensePrompt = declineLicenseInCI
+		utils.SetDownloadRetries(ciDownloadRetries)
+	} else {
+		ui.LicensePrompt = nil
+		utils.SetDownloadRetries(0)
+	}
+
+	configureGithubActions(viper.GetBool("github-actions"))
+	if err := configureErrorsOutput(viper.GetString("errors"), cmd.ErrOrStderr()); err != nil {
+		return err
+	}
+	configureMetrics()
+
+	// Commands run in the same process, e.g. by tests, must not inherit it
+	utils.SetOffline(viper.GetBool("offline"))
+	utils.SetVerifyCache(!viper.GetBool("no-verify-cache"))
+
+	ip4, ip6 := viper.GetBool("ip4"), viper.GetBool("ip6")
+	if ip4 && ip6 {
+		log.Error("--ip4 and --ip6 cannot be used together")
+		return errs.ErrIncorrectCmdArgs
+	}
+	switch {
+	case ip4:
+		utils.SetIPVersion(4)
+	case ip6:
+		utils.SetIPVersion(6)
+	default:
+		utils.SetIPVersion(0)
+	}
+
+	utils.SetMaxRedirects(viper.GetInt("max-redirects"))
+	utils.SetMaxHostDownloads(viper.GetInt("max-host-downloads"))
+	if err := utils.SetRedirectAuth(viper.GetString("redirect-auth")); err != nil {
+		return err
+	}
+
+	utils.SetURLRewrites(nil)
+	if rewritesFile := viper.GetString("url-rewrites"); rewritesFile != "" {
+		rewrites, err := utils.ReadURLRewrites(rewritesFile)
+		if err != nil {
+			return err
+		}
+		utils.SetURLRewrites(rewrites)
+	}
+
+	cryptography.SetPinnedSigners(nil)
+	if pinnedSignersFile := viper.GetString("pinned-signers"); pinnedSignersFile != "" {
+		pinned, err := cryptography.ReadPinnedSigners(pinnedSignersFile)
+		if err != nil {
+			return err
+		}
+		cryptography.SetPinnedSigners(pinned)
+	}
+
 	return nil
 }
 
+// firstPackRoot returns the pack root given with --pack-root, the first one
+// of a search path
+func firstPackRoot() string {
+	roots := filepath.SplitList(viper.GetString("pack-root"))
+	if len(roots) == 0 {
+		return ""
+	}
+	return roots[0]
+}
+
 // configureInstaller configures cpackget installer for adding or removing pack/pdsc
 func configureInstaller(cmd *cobra.Command, args []string) error {
 	err := configureInstallerGlobalCmd(cmd, args)
@@ -70,11 +192,52 @@ func configureInstaller(cmd *cobra.Command, args []string) error {
 	}
 
 	targetPackRoot := viper.GetString("pack-root")
+	installer.SetSystemPackRoot(viper.GetString("system-pack-root"))
+	switch viper.GetString("scope") {
+	case installer.ScopeUser:
+	case installer.ScopeSystem:
+		if installer.GetSystemPackRoot() == "" {
+			log.Error("--scope system requires the machine-wide pack root, see --system-pack-root")
+			return errs.ErrIncorrectCmdArgs
+		}
+		targetPackRoot = installer.GetSystemPackRoot()
+	default:
+		log.Errorf("--scope must be \"%s\" or \"%s\", not \"%s\"", installer.ScopeUser, installer.ScopeSystem, viper.GetString("scope"))
+		return errs.ErrIncorrectCmdArgs
+	}
+	installer.SetCacheDir(viper.GetString("cache-dir"))
+	installer.SetMaxPackRootSize(viper.GetUint64("max-pack-root-size") * 1024 * 1024)
+	installer.SetWebhook(viper.GetString("webhook"))
+	installer.SetPostInstallCommands(strings.Split(viper.GetString("post-install-commands"), ","))
+	installer.SetVerifyCachedPacks(viper.GetBool("verify-cached-packs"))
+	installer.SetStaleIndexAge(time.Duration(viper.GetUint("stale-index-days")) * 24 * time.Hour)
+	if viper.GetBool("strict-index") && viper.GetString("index-key") == "" {
+		log.Error("--strict-index requires the public key to verify the index with, see --index-key")
+		return errs.ErrIncorrectCmdArgs
+	}
+	installer.SetIndexVerification(viper.GetString("index-key"), viper.GetBool("strict-index"))
+
+	installer.SetPackHashURLs(nil)
+	if hashURLsFile := viper.GetString("pack-hash-urls"); hashURLsFile != "" {
+		hashURLs, err := installer.ReadPackHashURLs(hashURLsFile)
+		if err != nil {
+			return err
+		}
+		installer.SetPackHashURLs(hashURLs)
+	}
+	installer.SetEulaAllowlist(nil)
+	if allowlistFile := viper.GetString("eula-allowlist"); allowlistFile != "" {
+		allowlist, err := installer.ReadEulaAllowlist(allowlistFile)
+		if err != nil {
+			return err
+		}
+		installer.SetEulaAllowlist(allowlist)
+	}
 	checkConnection := viper.GetBool("check-connection")
 
-	if targetPackRoot == installer.GetDefaultCmsisPackRoot() {
-		// If using the default pack root path and the public index is not found,
-		// initialize it
+	if targetPackRoot == installer.GetDefaultCmsisPackRoot() || (projectPackRoot != "" && targetPackRoot == projectPackRoot) {
+		// If using the default or the project pack root path and the public index
+		// is not found, initialize it
 		if !checkConnection && !utils.FileExists(filepath.Join(targetPackRoot, ".Web", "index.pidx")) {
 			err := installer.SetPackRoot(targetPackRoot, true)
 			if err != nil {
@@ -113,6 +276,10 @@ var flags struct {
 	version bool
 }
 
+// projectPackRoot is the pack root found in .cpackget/root of the project in the
+// working directory, used when CMSIS_PACK_ROOT is not set
+var projectPackRoot string
+
 var Version string
 var Copyright string
 
@@ -160,11 +327,19 @@ func NewCli() *cobra.Command {
 
 			return cmd.Help()
 		},
+		PersistentPostRunE: reportMetrics,
 	}
 
 	rootCmd.SetUsageTemplate(usageTemplate)
 
 	defaultPackRoot := os.Getenv("CMSIS_PACK_ROOT")
+	projectPackRoot = ""
+	if defaultPackRoot == "" {
+		if workingDir, err := os.Getwd(); err == nil {
+			projectPackRoot, _ = installer.FindProjectPackRoot(workingDir)
+		}
+		defaultPackRoot = projectPackRoot
+	}
 	if defaultPackRoot == "" {
 		defaultPackRoot = installer.GetDefaultCmsisPackRoot()
 	}
@@ -174,14 +349,84 @@ func NewCli() *cobra.Command {
 	rootCmd.Flags().BoolVarP(&flags.version, "version", "V", false, "Prints the version number of cpackget and exit")
 	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Run cpackget silently, printing only error messages")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Sets verboseness level: None (Errors + Info + Warnings), -v (all + Debugging). Specify \"-q\" for no messages")
-	rootCmd.PersistentFlags().StringP("pack-root", "R", defaultPackRoot, "Specifies pack root folder. Defaults to CMSIS_PACK_ROOT environment variable")
+	rootCmd.PersistentFlags().StringP("pack-root", "R", defaultPackRoot, "Specifies pack root folder. Defaults to CMSIS_PACK_ROOT environment variable, then to the one set in .cpackget/root of the project")
+	defaultScope := os.Getenv("CPACKGET_SCOPE")
+	if defaultScope == "" {
+		defaultScope = installer.ScopeUser
+	}
+	rootCmd.PersistentFlags().String("scope", defaultScope, "Installs into the pack root of the user (\"user\") or into the machine-wide one (\"system\"), whose packs also count as installed for the user. Defaults to CPACKGET_SCOPE environment variable, then to \"user\"")
+	rootCmd.PersistentFlags().String("system-pack-root", os.Getenv("CPACKGET_SYSTEM_PACK_ROOT"), "Specifies the machine-wide pack root, managed by administrators and read-only for users. Defaults to CPACKGET_SYSTEM_PACK_ROOT environment variable")
+	rootCmd.PersistentFlags().String("cache-dir", os.Getenv("CPACKGET_CACHE_DIR"), "Specifies where downloaded packs are kept instead of the .Download folder of the pack root. Defaults to CPACKGET_CACHE_DIR environment variable")
+	rootCmd.PersistentFlags().Uint64("max-pack-root-size", 0, "Fails adding packs that would grow the pack root beyond the given megabytes. Disabled by default")
+	rootCmd.PersistentFlags().String("webhook", os.Getenv("CPACKGET_WEBHOOK"), "Posts a JSON notification to the given URL after each pack is added, removed or updated. Defaults to CPACKGET_WEBHOOK environment variable")
+	rootCmd.PersistentFlags().String("url-rewrites", os.Getenv("CPACKGET_URL_REWRITES"), "Reads rules like \"https://www.keil.com/pack/ => https://mirror/packs/\" from the given file, applied to all downloaded URLs. Defaults to CPACKGET_URL_REWRITES environment variable")
+	rootCmd.PersistentFlags().Bool("offline", false, "Forbids network access: packs are only added from .Download/ and local files, and commands needing the network fail right away")
+	rootCmd.PersistentFlags().BoolP("ip4", "4", false, "Connects over IPv4 only, e.g. when broken IPv6 routes make downloads hang")
+	rootCmd.PersistentFlags().BoolP("ip6", "6", false, "Connects over IPv6 only")
+	rootCmd.PersistentFlags().Uint("max-redirects", utils.DefaultMaxRedirects, "Fails downloads redirected more than the given times. Set to 0 to follow no redirects")
+	redirectAuth := os.Getenv("CPACKGET_REDIRECT_AUTH")
+	if redirectAuth == "" {
+		redirectAuth = utils.RedirectAuthSameDomain
+	}
+	rootCmd.PersistentFlags().String("redirect-auth", redirectAuth, "Forwards the credentials of redirected downloads to the \"same-host\", the \"same-domain\" including its subdomains, or \"any-host\", e.g. a CDN. Defaults to CPACKGET_REDIRECT_AUTH environment variable, then to \"same-domain\"")
+	rootCmd.PersistentFlags().String("index-key", os.Getenv("CPACKGET_INDEX_KEY"), "Verifies the index against its detached signature, index.pidx.sig, with the given PGP public key. Defaults to CPACKGET_INDEX_KEY environment variable")
+	rootCmd.PersistentFlags().Bool("strict-index", false, "Refuses indexes that are not signed, requires --index-key")
+	rootCmd.PersistentFlags().String("pack-hash-urls", os.Getenv("CPACKGET_PACK_HASH_URLS"), "Reads lines like \"Vendor => https://vendor.com/hashes/{file}.sha256\" from the given file, added packs of these vendors are verified against the published SHA-256 hashes. Defaults to CPACKGET_PACK_HASH_URLS environment variable")
+	rootCmd.PersistentFlags().String("pinned-signers", os.Getenv("CPACKGET_PINNED_SIGNERS"), "Reads lines like \"Vendor => certificate.pem\" or \"Vendor => x509:<fingerprint>\" from the given file, packs of these vendors must be signed with a pinned key to be added or verified. Defaults to CPACKGET_PINNED_SIGNERS environment variable")
 	rootCmd.PersistentFlags().UintP("concurrent-downloads", "C", 20, "Number of concurrent batch downloads. Set to 0 to disable concurrency")
+	maxHostDownloads := uint64(utils.DefaultMaxHostDownloads)
+	if value, err := strconv.ParseUint(os.Getenv("CPACKGET_MAX_HOST_DOWNLOADS"), 10, 0); err == nil {
+		maxHostDownloads = value
+	}
+	rootCmd.PersistentFlags().Uint("max-host-downloads", uint(maxHostDownloads), "Number of concurrent downloads from the same host, the downloads from other hosts proceeding meanwhile. Set to 0 for no limit. Defaults to CPACKGET_MAX_HOST_DOWNLOADS environment variable, then to 6")
 	rootCmd.PersistentFlags().UintP("timeout", "T", 0, "Set maximum duration (in seconds) of a download. Disabled by default")
+	rootCmd.PersistentFlags().String("eula-allowlist", os.Getenv("CPACKGET_EULA_ALLOWLIST"), "Reads SHA-256 hashes of pre-approved license texts, one per line, from the given file. Embedded licenses matching them are accepted without prompting. Defaults to CPACKGET_EULA_ALLOWLIST environment variable")
+	rootCmd.PersistentFlags().String("post-install-commands", os.Getenv("CPACKGET_POST_INSTALL_COMMANDS"), "Runs the post-install steps packs declare if they only call the given comma-separated commands, sandboxed without network access. Disabled by default. Defaults to CPACKGET_POST_INSTALL_COMMANDS environment variable")
+	rootCmd.PersistentFlags().Uint("license-prompt-timeout", 0, "Declines embedded licenses whose prompt is not answered within the given minutes. Waits forever by default")
+	rootCmd.PersistentFlags().Bool("ci", detectCI(), "Runs unattended: no prompts, encoded progress, download retries and a summary. Defaults to on when a CI environment is detected")
+	rootCmd.PersistentFlags().Bool("github-actions", os.Getenv("GITHUB_ACTIONS") == "true", "Reports errors and warnings as GitHub Actions annotations and writes a step summary. Defaults to on within GitHub Actions")
+	rootCmd.PersistentFlags().Uint("stale-index-days", 30, "Warns that packs resolved from the public index may be outdated when it was last refreshed more days ago. Set to 0 to disable")
+	rootCmd.PersistentFlags().Bool("no-verify-cache", false, "Computes the hashes of packs on every verification instead of reusing the ones of earlier successful verifications")
+	rootCmd.PersistentFlags().Bool("verify-cached-packs", false, "Verifies packs installed from .Download/ against the SHA-256 hash recorded when they were downloaded, downloading them again if they changed")
+	errorsFormat := os.Getenv("CPACKGET_ERRORS")
+	if errorsFormat == "" {
+		errorsFormat = "text"
+	}
+	rootCmd.PersistentFlags().String("errors", errorsFormat, "Prints errors as \"text\" in the log only, or also as \"json\" objects with their code, message, pack and hint on stderr, one per line. Defaults to CPACKGET_ERRORS environment variable, then to \"text\"")
+	rootCmd.PersistentFlags().Bool("metrics", false, "Prints how long downloading, verifying and extracting each pack took at the end of the command")
+	rootCmd.PersistentFlags().String("metrics-file", "", "Writes the metrics printed by --metrics as JSON to the given file")
 	_ = viper.BindPFlag("concurrent-downloads", rootCmd.PersistentFlags().Lookup("concurrent-downloads"))
+	_ = viper.BindPFlag("max-host-downloads", rootCmd.PersistentFlags().Lookup("max-host-downloads"))
 	_ = viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
 	_ = viper.BindPFlag("pack-root", rootCmd.PersistentFlags().Lookup("pack-root"))
+	_ = viper.BindPFlag("scope", rootCmd.PersistentFlags().Lookup("scope"))
+	_ = viper.BindPFlag("system-pack-root", rootCmd.PersistentFlags().Lookup("system-pack-root"))
+	_ = viper.BindPFlag("cache-dir", rootCmd.PersistentFlags().Lookup("cache-dir"))
+	_ = viper.BindPFlag("max-pack-root-size", rootCmd.PersistentFlags().Lookup("max-pack-root-size"))
+	_ = viper.BindPFlag("webhook", rootCmd.PersistentFlags().Lookup("webhook"))
+	_ = viper.BindPFlag("url-rewrites", rootCmd.PersistentFlags().Lookup("url-rewrites"))
+	_ = viper.BindPFlag("offline", rootCmd.PersistentFlags().Lookup("offline"))
+	_ = viper.BindPFlag("ip4", rootCmd.PersistentFlags().Lookup("ip4"))
+	_ = viper.BindPFlag("ip6", rootCmd.PersistentFlags().Lookup("ip6"))
+	_ = viper.BindPFlag("max-redirects", rootCmd.PersistentFlags().Lookup("max-redirects"))
+	_ = viper.BindPFlag("redirect-auth", rootCmd.PersistentFlags().Lookup("redirect-auth"))
+	_ = viper.BindPFlag("index-key", rootCmd.PersistentFlags().Lookup("index-key"))
+	_ = viper.BindPFlag("strict-index", rootCmd.PersistentFlags().Lookup("strict-index"))
+	_ = viper.BindPFlag("pack-hash-urls", rootCmd.PersistentFlags().Lookup("pack-hash-urls"))
+	_ = viper.BindPFlag("pinned-signers", rootCmd.PersistentFlags().Lookup("pinned-signers"))
 	_ = viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 	_ = viper.BindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
+	_ = viper.BindPFlag("eula-allowlist", rootCmd.PersistentFlags().Lookup("eula-allowlist"))
+	_ = viper.BindPFlag("post-install-commands", rootCmd.PersistentFlags().Lookup("post-install-commands"))
+	_ = viper.BindPFlag("license-prompt-timeout", rootCmd.PersistentFlags().Lookup("license-prompt-timeout"))
+	_ = viper.BindPFlag("ci", rootCmd.PersistentFlags().Lookup("ci"))
+	_ = viper.BindPFlag("github-actions", rootCmd.PersistentFlags().Lookup("github-actions"))
+	_ = viper.BindPFlag("stale-index-days", rootCmd.PersistentFlags().Lookup("stale-index-days"))
+	_ = viper.BindPFlag("no-verify-cache", rootCmd.PersistentFlags().Lookup("no-verify-cache"))
+	_ = viper.BindPFlag("verify-cached-packs", rootCmd.PersistentFlags().Lookup("verify-cached-packs"))
+	_ = viper.BindPFlag("errors", rootCmd.PersistentFlags().Lookup("errors"))
+	_ = viper.BindPFlag("metrics", rootCmd.PersistentFlags().Lookup("metrics"))
+	_ = viper.BindPFlag("metrics-file", rootCmd.PersistentFlags().Lookup("metrics-file"))
 
 	for _, cmd := range AllCommands {
 		rootCmd.AddCommand(cmd)
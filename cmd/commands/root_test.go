@@ -147,10 +147,13 @@ func runTests(t *testing.T, tests []TestCase) {
 				installer.UnlockPackRoot()
 			}
 
-			if test.env != nil {
-				for envVar := range test.env {
-					os.Setenv(envVar, test.env[envVar])
-				}
+			// CI mode is only on when a test turns it on, and never
+			// writes to the step summary of the job running the tests
+			for _, envVar := range append(commands.CIEnvVars, "GITHUB_STEP_SUMMARY") {
+				t.Setenv(envVar, "")
+			}
+			for envVar, value := range test.env {
+				t.Setenv(envVar, value)
 			}
 
 			if test.setUpFunc != nil {
@@ -228,3 +231,18 @@ func init() {
 	log.SetLevel(logLevel)
 	log.SetFormatter(new(LogFormatter))
 }
+
+// TestMain keeps parsed metadata of the many throwaway pack roots
+// out of the user's cache directory
+func TestMain(m *testing.M) {
+	metadataCacheDir, err := os.MkdirTemp("", "cpackget-metadata")
+	if err != nil {
+		panic(err)
+	}
+	utils.SetMetadataCacheDir(metadataCacheDir)
+
+	code := m.Run()
+
+	os.RemoveAll(metadataCacheDir)
+	os.Exit(code)
+}
@@ -0,0 +1,183 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// sarifSchema is the JSON schema of SARIF 2.1.0 logs, as read by code
+// scanning dashboards like GitHub code scanning or SonarQube
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// sarifFinding is a problem reported in a SARIF log
+type sarifFinding struct {
+	// rule identifies the kind of problem, e.g. the code of an error
+	rule string
+
+	// message describes the problem
+	message string
+
+	// hint tells how to fix the problem, if known
+	hint string
+
+	// path is the file or folder the problem was found in
+	path string
+}
+
+// errorFinding is the finding of a command failing with err on path
+func errorFinding(err error, path string) sarifFinding {
+	return sarifFinding{
+		rule:    errs.Code(err),
+		message: err.Error(),
+		hint:    errs.Hint(err),
+		path:    path,
+	}
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription sarifMessage  `json:"shortDescription"`
+	Help             *sarifMessage `json:"help,omitempty"`
+}
+
+type sarifInvocation struct {
+	CommandLine         string `json:"commandLine"`
+	ExecutionSuccessful bool   `json:"executionSuccessful"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+// sarifURI turns path into the URI of a SARIF artifact, relative paths
+// being kept relative to the folder cpackget runs in
+func sarifURI(path string) string {
+	uri := filepath.ToSlash(path)
+	if filepath.IsAbs(path) {
+		if !strings.HasPrefix(uri, "/") {
+			uri = "/" + uri
+		}
+		return "file://" + uri
+	}
+	return uri
+}
+
+// writeSARIFReport writes a SARIF log to path with the findings of
+// commandLine, one rule per kind of finding. No findings clears the
+// alerts of earlier reports in code scanning dashboards.
+func writeSARIFReport(path, commandLine string, findings []sarifFinding) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "cpackget",
+			Version:        Version,
+			InformationURI: "https://github.com/Open-CMSIS-Pack/cpackget",
+			Rules:          []sarifRule{},
+		}},
+		Invocations: []sarifInvocation{{CommandLine: commandLine, ExecutionSuccessful: true}},
+		Results:     []sarifResult{},
+	}
+
+	ruleIndexes := map[string]int{}
+	for _, finding := range findings {
+		index, ok := ruleIndexes[finding.rule]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndexes[finding.rule] = index
+			rule := sarifRule{ID: finding.rule, ShortDescription: sarifMessage{Text: finding.message}}
+			if finding.hint != "" {
+				rule.Help = &sarifMessage{Text: finding.hint}
+			}
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		}
+
+		result := sarifResult{
+			RuleID:    finding.rule,
+			RuleIndex: index,
+			Level:     "error",
+			Message:   sarifMessage{Text: finding.message},
+		}
+		var location sarifLocation
+		location.PhysicalLocation.ArtifactLocation.URI = sarifURI(finding.path)
+		result.Locations = append(result.Locations, location)
+		run.Results = append(run.Results, result)
+	}
+
+	b, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, append(b, '\n'), utils.FileModeRW)
+}
+
+// reportFindings writes the findings of cmd run with args to the SARIF log
+// reportPath, if given, and returns err, the outcome of cmd, unless the log
+// could not be written
+func reportFindings(reportPath string, cmd *cobra.Command, args []string, findings []sarifFinding, err error) error {
+	if reportPath == "" {
+		return err
+	}
+	commandLine := strings.Join(append([]string{cmd.CommandPath()}, args...), " ")
+	if reportErr := writeSARIFReport(reportPath, commandLine, findings); reportErr != nil {
+		log.Errorf("Could not write the SARIF report: %s", reportErr)
+		if err == nil {
+			err = reportErr
+		}
+	}
+	return err
+}
+
+// verifyFindings are the findings of a command verifying path, failing with err
+func verifyFindings(path string, err error) []sarifFinding {
+	if err == nil {
+		return nil
+	}
+	return []sarifFinding{errorFinding(err, path)}
+}
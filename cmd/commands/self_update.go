@@ -0,0 +1,92 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var selfUpdateCmdFlags struct {
+	// checkOnly only tells whether a newer release is available
+	checkOnly bool
+
+	// feed is where the latest release is looked up
+	feed string
+
+	// releaseKey is the PGP public key releases are verified with
+	releaseKey string
+}
+
+var SelfUpdateCmd = &cobra.Command{
+	Use:   "self-update [--check-only]",
+	Short: "Update cpackget to its latest release",
+	Long: `
+Update cpackget to its latest release, replacing the running binary:
+
+  $ cpackget self-update
+
+The latest release is looked up in the GitHub releases of cpackget, or in the
+--feed given, in the same format. The archive of the release for this platform
+is verified against the checksums file of the release before its binary
+replaces the running one. With --release-key, the detached PGP signature of
+the checksums file, ".sig" next to it, is verified too, and unsigned releases
+are refused. The binary is replaced atomically, the old one is kept if
+anything fails.
+
+With --check-only, cpackget only tells whether a newer release is available,
+for installations managed centrally.`,
+	Args:              cobra.ExactArgs(0),
+	PersistentPreRunE: configureInstallerGlobalCmd,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		release, err := installer.LatestRelease(selfUpdateCmdFlags.feed, viper.GetInt("timeout"))
+		if err != nil {
+			return err
+		}
+
+		current := strings.TrimPrefix(Version, "v")
+		if !release.IsNewerThan(Version) {
+			log.Infof("cpackget %s is up to date", current)
+			return nil
+		}
+		if current == "" {
+			current = "a development build"
+		}
+		log.Infof("cpackget %s is available, this is %s", strings.TrimPrefix(release.Version, "v"), current)
+		if selfUpdateCmdFlags.checkOnly {
+			return nil
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		if executable, err = filepath.EvalSymlinks(executable); err != nil {
+			return err
+		}
+		return installer.SelfUpdate(release, executable, selfUpdateCmdFlags.releaseKey, viper.GetInt("timeout"))
+	},
+}
+
+func init() {
+	defaultFeed := os.Getenv("CPACKGET_RELEASE_FEED")
+	if defaultFeed == "" {
+		defaultFeed = installer.DefaultReleaseFeed
+	}
+	SelfUpdateCmd.Flags().BoolVar(&selfUpdateCmdFlags.checkOnly, "check-only", false, "only tells whether a newer release is available")
+	SelfUpdateCmd.Flags().StringVar(&selfUpdateCmdFlags.feed, "feed", defaultFeed, "URL the latest release is looked up at, in the format of the GitHub releases API. Defaults to CPACKGET_RELEASE_FEED environment variable, then to the GitHub releases of cpackget")
+	SelfUpdateCmd.Flags().StringVar(&selfUpdateCmdFlags.releaseKey, "release-key", os.Getenv("CPACKGET_RELEASE_KEY"), "verifies the signature of the checksums of the release with the given PGP public key. Defaults to CPACKGET_RELEASE_KEY environment variable")
+
+	SelfUpdateCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
+		err := command.Flags().MarkHidden("pack-root")
+		_ = command.Flags().MarkHidden("concurrent-downloads")
+		log.Debug(err)
+		command.Parent().HelpFunc()(command, strings)
+	})
+}
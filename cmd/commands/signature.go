@@ -4,6 +4,8 @@
 package commands
 
 import (
+	"os"
+
 	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	log "github.com/sirupsen/logrus"
@@ -17,12 +19,18 @@ var signatureCreateflags struct {
 	// certPath points to the signer's certificate
 	certPath string
 
+	// embedChecksum embeds a checksum file into the pack before signing it
+	embedChecksum bool
+
 	// keyPath points to the signer's private key
 	keyPath string
 
 	// outputDir saves the signed pack to a specific path
 	outputDir string
 
+	// passphraseFile holds the passphrase of an encrypted private key
+	passphraseFile string
+
 	// pgp mode embeds a PGP signature instead
 	pgp bool
 
@@ -37,20 +45,31 @@ var signatureVerifyflags struct {
 	// export doesn't sign but only exports the embedded certificate
 	export bool
 
+	// knownSigners is the file trust on first use records signers in
+	knownSigners string
+
 	// pgpKey loads a PGP public key to verify against the signature
 	pgpKey string
 
+	// sarifReport is the file to write a SARIF log of the findings to
+	sarifReport string
+
 	// skipCertValidation skips sanity/safety checks on the provided certificate
 	skipCertValidation bool
 
 	// skipInfo skips displaying certificate info
 	skipInfo bool
+
+	// tofu trusts the first signer of each vendor and refuses other ones
+	tofu bool
 }
 
 func init() {
 	SignatureCreateCmd.Flags().BoolVar(&signatureCreateflags.certOnly, "cert-only", false, "certificate-only signature mode")
 	SignatureCreateCmd.Flags().StringVarP(&signatureCreateflags.certPath, "certificate", "c", "", "path of the signer's certificate")
+	SignatureCreateCmd.Flags().BoolVar(&signatureCreateflags.embedChecksum, "embed-checksum", false, "embed a checksum file into the pack before signing it")
 	SignatureCreateCmd.Flags().StringVarP(&signatureCreateflags.keyPath, "private-key", "k", "", "path of the signer's private key")
+	SignatureCreateCmd.Flags().StringVar(&signatureCreateflags.passphraseFile, "passphrase-file", "", "read the passphrase of the encrypted private key from the first line of a file, defaults to CPACKGET_PASSPHRASE environment variable, then to asking for it")
 	SignatureCreateCmd.Flags().StringVarP(&signatureCreateflags.outputDir, "output-dir", "o", "", "save the signed pack to a specific path")
 	SignatureCreateCmd.Flags().BoolVar(&signatureCreateflags.pgp, "pgp", false, "PGP signature mode")
 	SignatureCreateCmd.Flags().BoolVar(&signatureCreateflags.skipCertValidation, "skip-validation", false, "do not validate certificate")
@@ -59,7 +78,10 @@ func init() {
 	SignatureVerifyCmd.Flags().BoolVarP(&signatureVerifyflags.export, "export", "e", false, "only export embed certificate")
 	SignatureVerifyCmd.Flags().StringVarP(&signatureVerifyflags.pgpKey, "pub-key", "k", "", "path of the PGP public key")
 	SignatureVerifyCmd.Flags().BoolVar(&signatureVerifyflags.skipCertValidation, "skip-validation", false, "do not validate certificate")
+	SignatureVerifyCmd.Flags().StringVar(&signatureVerifyflags.sarifReport, "sarif", "", "writes a SARIF log of the findings to the given file, for code scanning dashboards")
 	SignatureVerifyCmd.Flags().BoolVar(&signatureVerifyflags.skipInfo, "skip-info", false, "do not display certificate information")
+	SignatureVerifyCmd.Flags().BoolVar(&signatureVerifyflags.tofu, "tofu", false, "trust the signer of the first verified pack of each vendor and refuse packs signed by others")
+	SignatureVerifyCmd.Flags().StringVar(&signatureVerifyflags.knownSigners, "known-signers", os.Getenv("CPACKGET_KNOWN_SIGNERS"), "file --tofu records signers in, defaults to CPACKGET_KNOWN_SIGNERS environment variable, then to known_signers.json in the cpackget folder of the user's configuration directory")
 
 	SignatureCreateCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
 		err := command.Flags().MarkHidden("pack-root")
@@ -72,6 +94,19 @@ func init() {
 	SignatureVerifyCmd.SetHelpFunc(SignatureCreateCmd.HelpFunc())
 }
 
+// configureKnownSigners turns trust on first use on or off for the
+// signature verifications of the command
+func configureKnownSigners(tofu bool, knownSigners string) {
+	if !tofu {
+		cryptography.SetKnownSigners("")
+		return
+	}
+	if knownSigners == "" {
+		knownSigners = cryptography.DefaultKnownSigners()
+	}
+	cryptography.SetKnownSigners(knownSigners)
+}
+
 var SignatureCreateCmd = &cobra.Command{
 	Use:   "signature-create [<local .path pack>]",
 	Short: "Digitally signs a pack with a X.509 certificate or PGP key",
@@ -81,6 +116,10 @@ Signs a pack using X.509 Public Key Infrastructure or PGP signatures.
 Three modes are available. "full" is the default, and takes a X.509 public key
 certificate and its private key (currently only RSA supported), which is used
 to sign the hashed (SHA256) contents of a pack.
+Certificates and keys are read as PEM, DER or PKCS#12, whose format is detected. Keys
+can be PKCS#1 or PKCS#8, possibly encrypted. The same PKCS#12 bundle can be given
+as both certificate and key.
+
 If "--cert-only" is specified, only a X.509 certificate will be embed in the pack. This
 offers a lesser degree of security guarantees.
 Both these options perform some basic validations on the X.509 certificate, which can
@@ -89,13 +128,20 @@ be skipped.
 If "--pgp" is specified, the user must provide a PGP private key (Curve25519 or RSA 2048,
 3072 and 4096 bits are supported).
 
+The passphrase of encrypted X.509 and PGP private keys is read from the first line
+of the "--passphrase-file", else from the CPACKGET_PASSPHRASE environment variable,
+else asked for on the terminal. Keep the passphrase file readable only by its owner.
+
 The signature follows a simple scheme which includes the cpackget version used to sign,
 the mode, and the outputs, base64 encoded - saved to the pack's Zip comment field.
 These can be viewed with any text/hex editor or dedicated zip tools like "zipinfo".
 
 The referenced pack must be in its original/compressed form (.pack), and be present locally:
 
-  $ cpackget signature-create Vendor.Pack.1.2.3.pack -k private.key -c certificate.pem`,
+  $ cpackget signature-create Vendor.Pack.1.2.3.pack -k private.key -c certificate.pem
+
+With "--embed-checksum", a checksum file is embedded into the signed pack first, see
+"cpackget help checksum-create".`,
 	Args:              cobra.ExactArgs(1),
 	PersistentPreRunE: configureInstallerGlobalCmd,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -112,7 +158,7 @@ The referenced pack must be in its original/compressed form (.pack), and be pres
 		}
 		if signatureCreateflags.certPath == "" {
 			if !signatureCreateflags.pgp {
-				log.Error("Specify PEM certificate with the -c/--certificate flag")
+				log.Error("Specify certificate with the -c/--certificate flag")
 				return errs.ErrIncorrectCmdArgs
 			}
 		}
@@ -134,7 +180,11 @@ The referenced pack must be in its original/compressed form (.pack), and be pres
 				return errs.ErrIncorrectCmdArgs
 			}
 		}
-		return cryptography.SignPack(args[0], signatureCreateflags.certPath, signatureCreateflags.keyPath, signatureCreateflags.outputDir, Version, signatureCreateflags.certOnly, signatureCreateflags.skipCertValidation, signatureCreateflags.skipInfo)
+		passphrase, err := cryptography.ReadPassphrase(signatureCreateflags.passphraseFile)
+		if err != nil {
+			return err
+		}
+		return cryptography.SignPack(args[0], signatureCreateflags.certPath, signatureCreateflags.keyPath, signatureCreateflags.outputDir, Version, signatureCreateflags.certOnly, signatureCreateflags.embedChecksum, signatureCreateflags.skipCertValidation, signatureCreateflags.skipInfo, passphrase)
 	},
 }
 
@@ -152,7 +202,14 @@ the publisher's public PGP key.
 
 The referenced pack must be in its original/compressed form (.pack), and be present locally:
 
-  $ cpackget signature-verify Vendor.Pack.1.2.3.pack.signed`,
+  $ cpackget signature-verify Vendor.Pack.1.2.3.pack.signed
+
+With "--tofu", the key the first verified pack of a vendor was signed with is
+recorded, and later packs of this vendor signed with another key are refused
+with a loud warning, until its entry is removed from the --known-signers file.
+
+With "--sarif", a failed verification is also written to a SARIF log, read by
+code scanning dashboards like GitHub code scanning or SonarQube.`,
 	Args:              cobra.ExactArgs(1),
 	PersistentPreRunE: configureInstallerGlobalCmd,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -170,6 +227,12 @@ The referenced pack must be in its original/compressed form (.pack), and be pres
 				return errs.ErrIncorrectCmdArgs
 			}
 		}
-		return cryptography.VerifyPackSignature(args[0], signatureVerifyflags.pgpKey, Version, signatureVerifyflags.export, signatureVerifyflags.skipCertValidation, signatureVerifyflags.skipInfo)
+		if signatureVerifyflags.knownSigners != "" && !signatureVerifyflags.tofu {
+			log.Error("--known-signers needs --tofu")
+			return errs.ErrIncorrectCmdArgs
+		}
+		configureKnownSigners(signatureVerifyflags.tofu, signatureVerifyflags.knownSigners)
+		err := cryptography.VerifyPackSignature(args[0], signatureVerifyflags.pgpKey, Version, signatureVerifyflags.export, signatureVerifyflags.skipCertValidation, signatureVerifyflags.skipInfo)
+		return reportFindings(signatureVerifyflags.sarifReport, cmd, args, verifyFindings(args[0], err), err)
 	},
 }
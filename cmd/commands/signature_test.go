@@ -5,9 +5,18 @@ package commands_test
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	signatureTestPack = filepath.Join("..", "..", "testdata", "integration", "1.2.3", "TheVendor.PublicLocalPack.1.2.3.pack")
+	signatureTestCert = filepath.Join("..", "..", "testdata", "keys", "TheVendor.pem")
+	signatureTestKey  = filepath.Join("..", "..", "testdata", "keys", "TheVendor.encrypted.key")
 )
 
 var signatureCreateCmdTests = []TestCase{
@@ -56,6 +65,29 @@ var signatureCreateCmdTests = []TestCase{
 		args:        []string{"signature-create", "Vendor.Pack.1.2.3.pack", "--pgp", "--private-key", "foo", "--skip-info"},
 		expectedErr: errs.ErrIncorrectCmdArgs,
 	},
+	{
+		name:           "test reading the passphrase from the environment",
+		args:           []string{"signature-create", signatureTestPack, "-c", signatureTestCert, "-k", signatureTestKey, "--skip-validation", "-o", "test_reading_the_passphrase_from_the_environment"},
+		createPackRoot: true,
+		env:            map[string]string{"CPACKGET_PASSPHRASE": "secret"},
+		validationFunc: func(t *testing.T) {
+			assert.FileExists(t, filepath.Join("test_reading_the_passphrase_from_the_environment", "TheVendor.PublicLocalPack.1.2.3.pack.signed"))
+		},
+	},
+	{
+		name:           "test reading the passphrase from a file",
+		args:           []string{"signature-create", signatureTestPack, "-c", signatureTestCert, "-k", signatureTestKey, "--skip-validation", "-o", "test_reading_the_passphrase_from_a_file", "--passphrase-file", filepath.Join("test_reading_the_passphrase_from_a_file", "passphrase.txt")},
+		createPackRoot: true,
+		env:            map[string]string{"CPACKGET_PASSPHRASE": "wrong"},
+		setUpFunc: func(t *TestCase) {
+			t.assert.Nil(os.WriteFile(filepath.Join("test_reading_the_passphrase_from_a_file", "passphrase.txt"), []byte("secret\n"), 0600))
+		},
+	},
+	{
+		name:        "test missing passphrase file",
+		args:        []string{"signature-create", signatureTestPack, "-c", signatureTestCert, "-k", signatureTestKey, "--passphrase-file", "DoesNotExist.txt"},
+		expectedErr: errs.ErrFileNotFound,
+	},
 }
 
 var signatureVerifyCmdTests = []TestCase{
@@ -94,6 +126,23 @@ var signatureVerifyCmdTests = []TestCase{
 		args:        []string{"signature-verify", "Vendor.Pack.1.2.3.pack", "--pub-key", "foo", "--skip-info"},
 		expectedErr: errs.ErrIncorrectCmdArgs,
 	},
+	{
+		name:        "test reading invalid pinned signers",
+		args:        []string{"signature-verify", "Vendor.Pack.1.2.3.pack"},
+		env:         map[string]string{"CPACKGET_PINNED_SIGNERS": "pinned_signers.txt"},
+		expectedErr: errs.ErrInvalidPinnedSigner,
+		setUpFunc: func(t *TestCase) {
+			_ = os.WriteFile("pinned_signers.txt", []byte("Vendor =>\n"), 0600)
+		},
+		tearDownFunc: func() {
+			os.Remove("pinned_signers.txt")
+		},
+	},
+	{
+		name:        "test passing known-signers without tofu",
+		args:        []string{"signature-verify", "Vendor.Pack.1.2.3.pack", "--known-signers", "known_signers.json"},
+		expectedErr: errs.ErrIncorrectCmdArgs,
+	},
 }
 
 func TestSignatureCreateCmd(t *testing.T) {
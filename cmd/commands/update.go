@@ -30,6 +30,16 @@ var updateCmdFlags struct {
 
 	// Reports encoded progress for files and download when used by other tools
 	encodedProgress bool
+
+	// includeComponents and excludeComponents replace the component filters packs were added with
+	includeComponents []string
+	excludeComponents []string
+
+	// toolchain replaces the toolchain packs were added for
+	toolchain string
+
+	// force lets pinned packs be updated
+	force bool
 }
 
 var UpdateCmd = &cobra.Command{
@@ -44,7 +54,12 @@ Update a pack using the following "<pack>" specification or using packs provided
 
   $ cpackget update
 
-  Use this to update all installed packs to the latest version
+  Use this to update all installed packs to the latest version, except the ones
+  pinned with "cpackget pin". Updating pinned packs fails unless "--force" is given.
+
+  Packs added with "cpackget add --slim", "--include-component", "--exclude-component" or "--toolchain"
+  are updated leaving out the same files. Give other component filters or another toolchain to replace
+  the ones of the packs to update.
 
   The pack can be local file or hosted somewhere else on the Internet.
   If it's hosted somewhere, cpackget will first download it then extract all pack files into "CMSIS_PACK_ROOT/<vendor>/<packName>/<x.y.z>/"
@@ -53,8 +68,13 @@ Update a pack using the following "<pack>" specification or using packs provided
 	PersistentPreRunE: configureInstaller,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		utils.SetEncodedProgress(updateCmdFlags.encodedProgress)
+		utils.SetEncodedProgress(updateCmdFlags.encodedProgress || ciMode)
 		utils.SetSkipTouch(updateCmdFlags.skipTouch)
+		installer.SetForcePinned(updateCmdFlags.force)
+		installer.SetComponentFilters(updateCmdFlags.includeComponents, updateCmdFlags.excludeComponents)
+		if err := installer.SetToolchain(updateCmdFlags.toolchain); err != nil {
+			return err
+		}
 
 		if updateCmdFlags.packsListFileName != "" {
 			log.Infof("Parsing packs urls via file %v", updateCmdFlags.packsListFileName)
@@ -85,6 +105,10 @@ Update a pack using the following "<pack>" specification or using packs provided
 			if updateCmdFlags.packsListFileName != "" {
 				return nil // nothing to do
 			}
+			if len(updateCmdFlags.includeComponents) > 0 || len(updateCmdFlags.excludeComponents) > 0 {
+				log.Error("--include-component and --exclude-component need the packs to update")
+				return errs.ErrIncorrectCmdArgs
+			}
 			installer.UnlockPackRoot()
 			err := installer.UpdatePack("", !updateCmdFlags.skipEula, updateCmdFlags.noRequirements, viper.GetInt("timeout"))
 			if err != nil {
@@ -103,6 +127,7 @@ Update a pack using the following "<pack>" specification or using packs provided
 			err := installer.UpdatePack(packPath, !updateCmdFlags.skipEula, updateCmdFlags.noRequirements, viper.GetInt("timeout"))
 			if err != nil {
 				lastErr = err
+				reportError(err, packPath)
 				if !errs.AlreadyLogged(err) {
 					log.Error(err)
 				}
@@ -119,6 +144,10 @@ func init() {
 	UpdateCmd.Flags().StringVarP(&updateCmdFlags.packsListFileName, "packs-list-filename", "f", "", "specifies a file listing packs urls, one per line")
 	UpdateCmd.Flags().BoolVar(&updateCmdFlags.skipTouch, "skip-touch", false, "do not touch pack.idx")
 	UpdateCmd.Flags().BoolVarP(&updateCmdFlags.encodedProgress, "encoded-progress", "E", false, "Reports encoded progress for files and download when used by other tools")
+	UpdateCmd.Flags().StringSliceVar(&updateCmdFlags.includeComponents, "include-component", nil, "only installs the files of components matching Cclass[:Cgroup[:Csub]] glob patterns, replacing the ones packs were added with")
+	UpdateCmd.Flags().StringSliceVar(&updateCmdFlags.excludeComponents, "exclude-component", nil, "skips the files of components matching Cclass[:Cgroup[:Csub]] glob patterns, replacing the ones packs were added with")
+	UpdateCmd.Flags().BoolVar(&updateCmdFlags.force, "force", false, "updates pinned packs too")
+	UpdateCmd.Flags().StringVar(&updateCmdFlags.toolchain, "toolchain", "", "skips the files only other toolchains than AC6, GCC or IAR use, replacing the toolchain packs were added for")
 
 	UpdateCmd.SetHelpFunc(func(command *cobra.Command, strings []string) {
 		// Small workaround to keep the linter happy, not
@@ -23,6 +23,9 @@ var updateIndexCmdFlags struct {
 
 	// skipTouch does not touch pack.idx after adding
 	skipTouch bool
+
+	// retryFailed only downloads again the pdsc files the last update could not download
+	retryFailed bool
 }
 
 var UpdateIndexCmd = &cobra.Command{
@@ -32,19 +35,23 @@ var UpdateIndexCmd = &cobra.Command{
 	PersistentPreRunE: configureInstaller,
 	Args:              cobra.ExactArgs(0),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		utils.SetEncodedProgress(updateIndexCmdFlags.encodedProgress)
+		utils.SetEncodedProgress(updateIndexCmdFlags.encodedProgress || ciMode)
 		utils.SetSkipTouch(updateIndexCmdFlags.skipTouch)
-		log.Infof("Updating public index")
 		installer.UnlockPackRoot()
-		err := installer.UpdatePublicIndex("", true, updateIndexCmdFlags.sparse, false, updateIndexCmdFlags.downloadUpdatePdscFiles, viper.GetInt("concurrent-downloads"), viper.GetInt("timeout"))
-		installer.LockPackRoot()
-		return err
+		defer installer.LockPackRoot()
+		if updateIndexCmdFlags.retryFailed {
+			return installer.RetryFailedPdscs(viper.GetInt("concurrent-downloads"), viper.GetInt("timeout"))
+		}
+		log.Infof("Updating public index")
+		return installer.UpdatePublicIndex("", true, updateIndexCmdFlags.sparse, false, updateIndexCmdFlags.downloadUpdatePdscFiles, viper.GetInt("concurrent-downloads"), viper.GetInt("timeout"))
 	},
 }
 
 func getLongUpdateDescription() string {
 	return `Updates the public index in ` + os.Getenv("CMSIS_PACK_ROOT") + `/.Web/index.pidx using the URL in <url> tag inside index.pidx.
-By default it will also check if all PDSC files under .Web/ need update as well. This can be disabled via the "--sparse" flag.`
+By default it will also check if all PDSC files under .Web/ need update as well. This can be disabled via the "--sparse" flag.
+If the index is pinned, see "cpackget help index", the retrieved index is checked against the pinned hash.
+PDSC files that could not be downloaded are recorded in .Local/failed_pdscs.json, "--retry-failed" only downloads these again.`
 }
 
 func init() {
@@ -52,4 +59,5 @@ func init() {
 	UpdateIndexCmd.Flags().BoolVarP(&updateIndexCmdFlags.downloadUpdatePdscFiles, "all-pdsc-files", "a", false, "updates/downloads all the latest .pdsc files from the public index")
 	UpdateIndexCmd.Flags().BoolVarP(&updateIndexCmdFlags.encodedProgress, "encoded-progress", "E", false, "Reports encoded progress for files and download when used by other tools")
 	UpdateIndexCmd.Flags().BoolVar(&updateIndexCmdFlags.skipTouch, "skip-touch", false, "do not touch pack.idx")
+	UpdateIndexCmd.Flags().BoolVar(&updateIndexCmdFlags.retryFailed, "retry-failed", false, "only download again the pdsc files the last update could not download")
 }
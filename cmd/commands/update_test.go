@@ -5,6 +5,8 @@ package commands_test
 
 import (
 	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 )
 
 var (
@@ -98,6 +100,20 @@ var updateCmdTests = []TestCase{
 				os.Remove(fileWithNoPacksListed)
 			},
 		},*/
+	{
+		name:           "test updating all packs with component filters",
+		args:           []string{"update", "--include-component", "CMSIS:CORE"},
+		createPackRoot: true,
+		expectedStdout: []string{"--include-component and --exclude-component need the packs to update"},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
+	{
+		name:           "test updating packs for an unknown toolchain",
+		args:           []string{"update", "--toolchain", "Tasking", "Vendor.Pack"},
+		createPackRoot: true,
+		expectedStdout: []string{"Unknown toolchain \"Tasking\", use one of AC6, GCC, IAR"},
+		expectedErr:    errs.ErrIncorrectCmdArgs,
+	},
 }
 
 func TestUpdateCmd(t *testing.T) {
@@ -1,19 +1,59 @@
 package cryptography
 
 import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
-	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 )
 
 // Hashes is the list of supported Cryptographic Hash Functions used for the checksum feature.
 var Hashes = [1]string{"sha256"}
 
+// EmbeddedChecksumDir is the folder next to the PDSC file of a pack
+// holding the checksum file embedded with EmbedChecksum
+const EmbeddedChecksumDir = ".checksum"
+
+// SkippedFilesName is the file next to the PDSC file of an extracted pack
+// listing the files left out on purpose, one per line, e.g. by slim installs
+const SkippedFilesName = ".cpackget-skipped"
+
+// RenamedFilesName is the file next to the PDSC file of an extracted pack
+// mapping the names of its files Windows refuses to the names they were
+// extracted to, escaped with utils.WindowsSafeName, as a JSON object
+const RenamedFilesName = ".cpackget-renamed"
+
+// ReadRenamedFiles returns the names of the files of the pack extracted to
+// packDir which were escaped, by name in the pack, if any
+func ReadRenamedFiles(packDir string) (map[string]string, error) {
+	renamed := map[string]string{}
+	renamedPath := filepath.Join(packDir, RenamedFilesName)
+	if !utils.FileExists(renamedPath) {
+		return renamed, nil
+	}
+	b, err := afero.ReadFile(utils.GetFileSystem(), renamedPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &renamed); err != nil {
+		log.Errorf("Can't parse \"%s\": %s", renamedPath, err)
+		return nil, err
+	}
+	return renamed, nil
+}
+
 // isValidHash returns whether a hash function is
 // supported or not.
 func isValidHash(hashFunction string) bool {
@@ -109,10 +149,14 @@ func VerifyChecksum(packPath, checksumPath string) error {
 		return errors.New("not a valid .checksum file (correct format is [<pack>].[<hash-algorithm>].checksum). Please confirm if the hash is supported")
 	}
 
-	// Compute pack's digests
-	digests, err := getDigestList(packPath, hashFunction)
-	if err != nil {
-		return err
+	// Compute pack's digests, unless an earlier verification did
+	var digests map[string]string
+	if !utils.LoadVerification("checksum-"+hashFunction, packPath, &digests) {
+		var err error
+		digests, err = getDigestList(packPath, hashFunction)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Check if pack and checksum file have the same number of files listed
@@ -147,6 +191,235 @@ func VerifyChecksum(packPath, checksumPath string) error {
 		return errors.New("bad pack integrity")
 	}
 
+	utils.StoreVerification("checksum-"+hashFunction, packPath, digests)
 	log.Info("pack integrity verified, all checksums match.")
 	return nil
 }
+
+// embeddedChecksumName returns the path of the checksum file embedded
+// with hashFunction, relative to the pack's PDSC file
+func embeddedChecksumName(hashFunction string) string {
+	return EmbeddedChecksumDir + "/" + hashFunction + ".checksum"
+}
+
+// packContentsFolder returns the folder of a pack's zip file holding its
+// PDSC file, e.g. "Vendor.Pack.1.2.3/", or "" if it is at the root
+func packContentsFolder(z *zip.ReadCloser) (string, error) {
+	folder := ""
+	found := false
+	for _, file := range z.File {
+		depth := strings.Count(file.Name, "/")
+		if filepath.Ext(file.Name) != ".pdsc" || depth > 1 {
+			continue
+		}
+		if !found || depth == 0 {
+			folder = file.Name[:strings.LastIndex(file.Name, "/")+1]
+			found = true
+		}
+	}
+	if !found {
+		return "", errs.ErrPdscFileNotFound
+	}
+	return folder, nil
+}
+
+// EmbedChecksum writes a copy of the pack sourcePack to destinationPack,
+// which may be sourcePack itself, with a checksum file of the pack's files
+// embedded in EmbeddedChecksumDir. "cpackget add" verifies extracted files
+// against it. Signatures cover all files of a pack, so checksums must be
+// embedded before signing.
+func EmbedChecksum(sourcePack, destinationPack, hashFunction string) error {
+	if !isValidHash(hashFunction) {
+		return errors.New("provided hash function is not supported")
+	}
+	if !utils.FileExists(sourcePack) {
+		log.Errorf("\"%s\" does not exist", sourcePack)
+		return errs.ErrFileNotFound
+	}
+
+	z, err := zip.OpenReader(sourcePack)
+	if err != nil {
+		log.Errorf("can't decompress \"%s\": %s", sourcePack, err)
+		return errs.ErrFailedDecompressingFile
+	}
+	defer z.Close()
+
+	if strings.HasPrefix(z.Comment, sigVersionPrefix) {
+		log.Error("Embed the checksum before signing the pack")
+		return errs.ErrAlreadySigned
+	}
+
+	folder, err := packContentsFolder(z)
+	if err != nil {
+		return errs.WithPath(err, sourcePack)
+	}
+	checksumName := folder + embeddedChecksumName(hashFunction)
+
+	// Previously embedded checksum files are replaced
+	digests := []string{}
+	files := []*zip.File{}
+	for _, file := range z.File {
+		if strings.HasPrefix(file.Name, folder+EmbeddedChecksumDir+"/") {
+			continue
+		}
+		files = append(files, file)
+		if strings.HasSuffix(file.Name, "/") || !strings.HasPrefix(file.Name, folder) {
+			continue
+		}
+		h := sha256.New()
+		if err := hashZipFile(h, file); err != nil {
+			return err
+		}
+		digests = append(digests, fmt.Sprintf("%x %s\n", h.Sum(nil), strings.TrimPrefix(file.Name, folder)))
+	}
+	sort.Strings(digests)
+
+	tmpPack := destinationPack + ".tmp"
+	out, err := os.Create(tmpPack)
+	if err != nil {
+		log.Error(err)
+		return errs.ErrFailedCreatingFile
+	}
+	defer os.Remove(tmpPack)
+
+	w := zip.NewWriter(out)
+	for _, file := range files {
+		if err := w.Copy(file); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	writer, err := w.CreateHeader(&zip.FileHeader{Name: checksumName, Method: zip.Deflate, Modified: time.Now()})
+	if err == nil {
+		_, err = writer.Write([]byte(strings.Join(digests, "")))
+	}
+	if err == nil {
+		err = w.SetComment(z.Comment)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	z.Close()
+	if err := os.Rename(tmpPack, destinationPack); err != nil {
+		return err
+	}
+
+	log.Infof("Embedded \"%s\" with the digests of %d file(s) into \"%s\"", checksumName, len(digests), destinationPack)
+	return nil
+}
+
+// VerifyEmbeddedChecksum checks the files of a pack extracted to packDir
+// against the checksum file embedded with EmbedChecksum. Files listed in
+// SkippedFilesName are neither required nor checked, files listed in
+// RenamedFilesName are looked up by their escaped names. It tells whether
+// there was an embedded checksum file to check against.
+func VerifyEmbeddedChecksum(packDir string) (bool, error) {
+	checksumPath := ""
+	for _, hash := range Hashes {
+		path := filepath.Join(packDir, filepath.FromSlash(embeddedChecksumName(hash)))
+		if utils.FileExists(path) {
+			checksumPath = path
+			break
+		}
+	}
+	if checksumPath == "" {
+		return false, nil
+	}
+
+	fsys := utils.GetFileSystem()
+	b, err := afero.ReadFile(fsys, checksumPath)
+	if err != nil {
+		return true, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+
+	skipped := map[string]bool{}
+	skippedPath := filepath.Join(packDir, SkippedFilesName)
+	if utils.FileExists(skippedPath) {
+		b, err := afero.ReadFile(fsys, skippedPath)
+		if err != nil {
+			return true, err
+		}
+		for _, name := range strings.Split(string(b), "\n") {
+			if name != "" {
+				skipped[name] = true
+			}
+		}
+	}
+
+	renamed, err := ReadRenamedFiles(packDir)
+	if err != nil {
+		return true, err
+	}
+	renamedPath := filepath.Join(packDir, RenamedFilesName)
+
+	failure := false
+	listed := 0
+	for _, line := range lines {
+		digest, name, found := strings.Cut(line, " ")
+		if !found {
+			log.Errorf("Invalid line in \"%s\": %s", checksumPath, line)
+			return true, errs.ErrIntegrityCheckFailed
+		}
+		if skipped[name] {
+			continue
+		}
+		listed++
+
+		extractedName := name
+		if escaped, found := renamed[name]; found {
+			extractedName = escaped
+		}
+		file, err := fsys.Open(filepath.Join(packDir, filepath.FromSlash(extractedName)))
+		if err != nil {
+			log.Errorf("\"%s\" does not exist in the pack but is listed in its embedded checksum file", name)
+			return true, errs.ErrIntegrityCheckFailed
+		}
+		h := sha256.New()
+		_, err = utils.SecureCopy(h, file)
+		file.Close()
+		if err != nil {
+			return true, err
+		}
+
+		if fmt.Sprintf("%x", h.Sum(nil)) != digest {
+			log.Errorf("%s: computed checksum did NOT match", name)
+			failure = true
+		}
+	}
+	if failure {
+		return true, errs.ErrIntegrityCheckFailed
+	}
+
+	// Files not listed in the checksum file must not have sneaked in
+	count := 0
+	err = afero.Walk(fsys, packDir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == EmbeddedChecksumDir && filepath.Dir(path) == filepath.Clean(packDir) {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && path != skippedPath && path != renamedPath {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return true, err
+	}
+	if count != listed {
+		log.Errorf("The embedded checksum file lists %d file(s), but the pack contains %d file(s)", listed, count)
+		return true, errs.ErrIntegrityCheckFailed
+	}
+
+	log.Debugf("Verified the files of \"%s\" against \"%s\"", packDir, checksumPath)
+	return true, nil
+}
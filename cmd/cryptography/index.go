@@ -0,0 +1,59 @@
+package cryptography
+
+import (
+	"os"
+
+	gopgp "github.com/ProtonMail/gopenpgp/v2/crypto"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+)
+
+// VerifyIndexSignature checks the detached PGP signature in signaturePath,
+// armored or binary, of the index in indexPath against the public key in keyPath.
+func VerifyIndexSignature(indexPath, signaturePath, keyPath string) error {
+	return verifyDetachedSignature(indexPath, signaturePath, keyPath, errs.ErrBadIndexSignature)
+}
+
+// VerifyReleaseSignature checks the detached PGP signature in signaturePath,
+// armored or binary, of the checksums file of a cpackget release in
+// checksumsPath against the public key in keyPath.
+func VerifyReleaseSignature(checksumsPath, signaturePath, keyPath string) error {
+	return verifyDetachedSignature(checksumsPath, signaturePath, keyPath, errs.ErrBadReleaseSignature)
+}
+
+// verifyDetachedSignature checks the detached PGP signature in signaturePath
+// of the file in dataPath against the public key in keyPath, failing with
+// errBadSignature if it does not match
+func verifyDetachedSignature(dataPath, signaturePath, keyPath string, errBadSignature error) error {
+	k, err := os.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(dataPath)
+	if err != nil {
+		return err
+	}
+	s, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return err
+	}
+
+	pgpSignature, err := gopgp.NewPGPSignatureFromArmored(string(s))
+	if err != nil {
+		pgpSignature = gopgp.NewPGPSignature(s)
+	}
+	publicKeyObj, err := gopgp.NewKeyFromArmored(string(k))
+	if err != nil {
+		return err
+	}
+	signingKeyRing, err := gopgp.NewKeyRing(publicKeyObj)
+	if err != nil {
+		return err
+	}
+
+	if err := signingKeyRing.VerifyDetached(gopgp.NewPlainMessage(data), pgpSignature, gopgp.GetUnixTime()); err != nil {
+		log.Debugf("Signature verification of \"%s\" failed: %s", dataPath, err)
+		return errBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package cryptography
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"runtime"
+	"syscall"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/pkcs12"
+	"golang.org/x/term"
+)
+
+// Formats key material is read in, see keyFormat
+const (
+	formatPEM    = "PEM"
+	formatDER    = "DER"
+	formatPKCS12 = "PKCS#12"
+)
+
+// Object identifiers of the encryption of PKCS#8 private keys
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// encryptedPrivateKeyInfo maps an encrypted PKCS#8 private key
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// pfxHeader is the beginning of a PKCS#12 bundle, enough to tell one
+type pfxHeader struct {
+	Version  int
+	AuthSafe asn1.RawValue
+	MacData  asn1.RawValue `asn1:"optional"`
+}
+
+// PassphraseEnv is the environment variable the passphrase of encrypted
+// signing keys is read from when no passphrase file is given
+const PassphraseEnv = "CPACKGET_PASSPHRASE"
+
+// ReadPassphrase returns the passphrase of encrypted signing keys, read from
+// the first line of passphraseFile or else from the CPACKGET_PASSPHRASE
+// environment variable. It returns nil if neither is given, so that the
+// passphrase is asked for on the terminal when needed.
+func ReadPassphrase(passphraseFile string) ([]byte, error) {
+	if passphraseFile == "" {
+		if passphrase, ok := os.LookupEnv(PassphraseEnv); ok {
+			log.Debugf("Reading passphrase from the %s environment variable", PassphraseEnv)
+			return []byte(passphrase), nil
+		}
+		return nil, nil
+	}
+
+	info, err := os.Stat(passphraseFile)
+	if err != nil {
+		log.Errorf("Can't read passphrase file \"%s\": %s", passphraseFile, err)
+		return nil, errs.ErrFileNotFound
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		log.Warnf("Passphrase file \"%s\" can be read by other users, restrict it with \"chmod 600\"", passphraseFile)
+	}
+	data, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, _, _ := bytes.Cut(data, []byte("\n"))
+	return bytes.TrimSuffix(passphrase, []byte("\r")), nil
+}
+
+// passphraseSource hands out the passphrase of encrypted keys, asking for
+// it on the terminal the first time if it was not given
+type passphraseSource struct {
+	passphrase []byte
+}
+
+func (s *passphraseSource) get() ([]byte, error) {
+	if s.passphrase == nil {
+		stdin := int(syscall.Stdin)
+		if !term.IsTerminal(stdin) {
+			log.Error("The private key is encrypted and there is no terminal to ask for its passphrase")
+			return nil, errs.ErrPassphraseRequired
+		}
+		fmt.Fprint(os.Stderr, "Enter key passphrase: ")
+		passphrase, err := term.ReadPassword(stdin)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		s.passphrase = passphrase
+	}
+	return s.passphrase, nil
+}
+
+// keyFormat tells the format of the key material in data: PEM, DER, or
+// a PKCS#12 bundle
+func keyFormat(data []byte) string {
+	if block, _ := pem.Decode(data); block != nil {
+		return formatPEM
+	}
+	var pfx pfxHeader
+	if rest, err := asn1.Unmarshal(data, &pfx); err == nil && len(rest) == 0 && pfx.Version == 3 {
+		return formatPKCS12
+	}
+	return formatDER
+}
+
+// readCertificate reads the X.509 certificate in certPath, PEM or DER
+// encoded or in a PKCS#12 bundle, returning it PEM encoded as signatures
+// embed it
+func readCertificate(certPath string, passphrase *passphraseSource) ([]byte, error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	format := keyFormat(data)
+	log.Debugf("Reading certificate \"%s\" as %s", certPath, format)
+	switch format {
+	case formatPEM:
+		return data, nil
+	case formatPKCS12:
+		blocks, err := decodePKCS12(data, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		if cert := pkcs12Certificate(blocks); cert != nil {
+			return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), nil
+		}
+		log.Errorf("\"%s\" does not hold a certificate", certPath)
+		return nil, errs.ErrCannotVerifySignature
+	}
+
+	if _, err := x509.ParseCertificate(data); err != nil {
+		log.Errorf("\"%s\" is neither a PEM or DER encoded certificate nor a PKCS#12 bundle: %s", certPath, err)
+		return nil, errs.ErrCannotVerifySignature
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: data}), nil
+}
+
+// readPrivateKey reads the RSA private key in keyPath: PKCS#1 or PKCS#8,
+// PEM or DER encoded and possibly encrypted, or in a PKCS#12 bundle
+func readPrivateKey(keyPath string, passphrase *passphraseSource) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	format := keyFormat(data)
+	log.Debugf("Reading private key \"%s\" as %s", keyPath, format)
+	switch format {
+	case formatPEM:
+		block, rest := pem.Decode(data)
+		if len(rest) > 0 {
+			log.Debug("The provided key included other PEM objects, only the first was read")
+		}
+		der := block.Bytes
+		switch {
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			if der, err = decryptPKCS8(der, passphrase); err != nil {
+				return nil, err
+			}
+		case x509.IsEncryptedPEMBlock(block):
+			password, err := passphrase.get()
+			if err != nil {
+				return nil, err
+			}
+			if der, err = x509.DecryptPEMBlock(block, password); err != nil {
+				log.Errorf("Could not decrypt \"%s\", please check the passphrase: %s", keyPath, err)
+				return nil, errs.ErrBadPrivateKey
+			}
+		case block.Type != "RSA PRIVATE KEY" && block.Type != "PRIVATE KEY":
+			log.Errorf("\"%s\" holds a \"%s\" instead of a private key", keyPath, block.Type)
+			return nil, errs.ErrBadPrivateKey
+		}
+		return parseRSAPrivateKey(der)
+	case formatPKCS12:
+		blocks, err := decodePKCS12(data, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range blocks {
+			if block.Type == "PRIVATE KEY" {
+				return parseRSAPrivateKey(block.Bytes)
+			}
+		}
+		log.Errorf("\"%s\" does not hold a private key", keyPath)
+		return nil, errs.ErrBadPrivateKey
+	}
+
+	// An encrypted DER key is told by its encryption algorithm
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(data, &info); err == nil && info.Algorithm.Algorithm.Equal(oidPBES2) {
+		if data, err = decryptPKCS8(data, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	return parseRSAPrivateKey(data)
+}
+
+// parseRSAPrivateKey parses a DER encoded PKCS#1 or PKCS#8 RSA private key
+func parseRSAPrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		log.Errorf("Could not parse private key, please check for corruption: %s", err)
+		return nil, errs.ErrBadPrivateKey
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		log.Errorf("Only RSA private keys are supported, not %T", key)
+		return nil, errs.ErrUnsupportedKeyAlgo
+	}
+	return rsaKey, nil
+}
+
+// decodePKCS12 returns the certificates and private keys of a PKCS#12
+// bundle as PEM blocks. Bundles without a password are read right away,
+// the passphrase is asked for otherwise.
+func decodePKCS12(data []byte, passphrase *passphraseSource) ([]*pem.Block, error) {
+	blocks, err := pkcs12.ToPEM(data, "")
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		var password []byte
+		if password, err = passphrase.get(); err != nil {
+			return nil, err
+		}
+		blocks, err = pkcs12.ToPEM(data, string(password))
+	}
+
+	var notImplemented pkcs12.NotImplementedError
+	switch {
+	case errors.As(err, &notImplemented):
+		log.Errorf("The encryption of the PKCS#12 bundle is not supported (%s), export it again with \"openssl pkcs12 -export -legacy\"", err)
+		return nil, errs.ErrUnsupportedKeyAlgo
+	case err != nil:
+		log.Errorf("Could not read the PKCS#12 bundle, please check the passphrase: %s", err)
+		return nil, errs.ErrBadPrivateKey
+	}
+	return blocks, nil
+}
+
+// pkcs12Certificate returns the certificate of the key of a PKCS#12 bundle,
+// telling it from the ones of certificate authorities in the bundle
+func pkcs12Certificate(blocks []*pem.Block) *x509.Certificate {
+	var first *x509.Certificate
+	for _, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if !cert.IsCA {
+			return cert
+		}
+		if first == nil {
+			first = cert
+		}
+	}
+	return first
+}
+
+// decryptPKCS8 decrypts a DER encoded PKCS#8 private key encrypted with
+// PBES2, using PBKDF2 and AES-CBC as OpenSSL does by default
+func decryptPKCS8(der []byte, passphrase *passphraseSource) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		log.Errorf("Only PBES2 encrypted PKCS#8 private keys are supported, not %s", info.Algorithm.Algorithm)
+		return nil, errs.ErrUnsupportedKeyAlgo
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		log.Errorf("Only PBKDF2 derived keys are supported, not %s", params.KeyDerivationFunc.Algorithm)
+		return nil, errs.ErrUnsupportedKeyAlgo
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, err
+	}
+
+	var prf func() hash.Hash
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	default:
+		log.Errorf("Unsupported key derivation function %s", kdfParams.PRF.Algorithm)
+		return nil, errs.ErrUnsupportedKeyAlgo
+	}
+
+	var keyLength int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLength = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLength = 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLength = 32
+	default:
+		log.Errorf("Unsupported private key encryption %s", params.EncryptionScheme.Algorithm)
+		return nil, errs.ErrUnsupportedKeyAlgo
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize || len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		log.Error("Could not decode the encrypted private key, please check for corruption")
+		return nil, errs.ErrBadPrivateKey
+	}
+
+	password, err := passphrase.get()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(pbkdf2.Key(password, kdfParams.Salt, kdfParams.IterationCount, keyLength, prf))
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, info.EncryptedData)
+
+	// A wrong passphrase shows as bad padding most of the time
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		log.Error("Could not decrypt the private key, please check the passphrase")
+		return nil, errs.ErrBadPrivateKey
+	}
+	return plain[:len(plain)-padding], nil
+}
@@ -0,0 +1,152 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package cryptography
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gopgp "github.com/ProtonMail/gopenpgp/v2/crypto"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+)
+
+// PinnedSigner is a key the packs of a vendor must be signed with
+type PinnedSigner struct {
+	// Fingerprint is "x509:" followed by the SHA-256 hash of the public key
+	// of a certificate, or "pgp:" followed by the fingerprint of a PGP key,
+	// see KnownSigner
+	Fingerprint string
+
+	// KeyPath is the PGP public key file the pin was read from, if any,
+	// which packs signed with PGP are verified with
+	KeyPath string
+}
+
+// gPinnedSigners are the keys the packs of each vendor must be signed with
+var gPinnedSigners map[string][]PinnedSigner
+
+// SetPinnedSigners makes signature verifications, and adding packs, refuse
+// packs of the vendors in pinned that are unsigned or signed with another
+// key than the pinned ones, whether or not their certificate is valid
+func SetPinnedSigners(pinned map[string][]PinnedSigner) {
+	gPinnedSigners = pinned
+}
+
+func GetPinnedSigners() map[string][]PinnedSigner {
+	return gPinnedSigners
+}
+
+// ReadPinnedSigners reads the pinned signers in fileName, one per line. The
+// key is given by its fingerprint, or by a X.509 certificate or armored PGP
+// public key file relative to fileName:
+//
+//	# Comments and empty lines are ignored
+//	TheVendor => x509:3f2a...
+//	TheVendor => certificates/TheVendor.pem
+//	OtherVendor => keys/OtherVendor.asc
+func ReadPinnedSigners(fileName string) (map[string][]PinnedSigner, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pinned := make(map[string][]PinnedSigner)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		vendor, key, found := strings.Cut(line, "=>")
+		vendor, key = strings.TrimSpace(vendor), strings.TrimSpace(key)
+		if !found || vendor == "" || key == "" {
+			log.Errorf("%s:%d: \"%s\" is not a pinned signer", fileName, lineNumber, line)
+			return nil, errs.ErrInvalidPinnedSigner
+		}
+
+		if fingerprint := strings.ToLower(key); strings.HasPrefix(fingerprint, "x509:") || strings.HasPrefix(fingerprint, "pgp:") {
+			pinned[vendor] = append(pinned[vendor], PinnedSigner{Fingerprint: fingerprint})
+			continue
+		}
+
+		if !filepath.IsAbs(key) {
+			key = filepath.Join(filepath.Dir(fileName), key)
+		}
+		pin, err := readPinnedKey(key)
+		if err != nil {
+			log.Errorf("%s:%d: \"%s\" is neither a X.509 certificate nor a PGP public key: %s", fileName, lineNumber, key, err)
+			return nil, errs.ErrInvalidPinnedSigner
+		}
+		pinned[vendor] = append(pinned[vendor], pin)
+	}
+	return pinned, scanner.Err()
+}
+
+// readPinnedKey reads the X.509 certificate, PEM or DER encoded, or armored
+// PGP public key in keyPath
+func readPinnedKey(keyPath string) (PinnedSigner, error) {
+	rawKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		return PinnedSigner{}, err
+	}
+
+	if certPEM, _ := pem.Decode(rawKey); certPEM != nil && certPEM.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(certPEM.Bytes)
+		if err != nil {
+			return PinnedSigner{}, err
+		}
+		return PinnedSigner{Fingerprint: certificateFingerprint(cert)}, nil
+	}
+	if keyFormat(rawKey) == formatDER {
+		if cert, err := x509.ParseCertificate(rawKey); err == nil {
+			return PinnedSigner{Fingerprint: certificateFingerprint(cert)}, nil
+		}
+	}
+
+	key, err := gopgp.NewKeyFromArmored(string(rawKey))
+	if err != nil {
+		return PinnedSigner{}, err
+	}
+	return PinnedSigner{Fingerprint: pgpFingerprint(key), KeyPath: keyPath}, nil
+}
+
+// VerifyPinnedSignature verifies the signature of the pack of vendor in
+// packPath if keys are pinned for vendor, see SetPinnedSigners. Certificates
+// are not validated, the pinned key is what the pack is trusted for.
+func VerifyPinnedSignature(packPath, vendor string) error {
+	pins, pinned := gPinnedSigners[vendor]
+	if !pinned {
+		return nil
+	}
+
+	log.Debugf("Verifying the signature of \"%s\" against the keys pinned for %s", packPath, vendor)
+	keyPaths := []string{}
+	for _, pin := range pins {
+		if pin.KeyPath != "" {
+			keyPaths = append(keyPaths, pin.KeyPath)
+		}
+	}
+	if len(keyPaths) == 0 {
+		keyPaths = append(keyPaths, "")
+	}
+
+	var err error
+	for _, keyPath := range keyPaths {
+		if err = VerifyPackSignature(packPath, keyPath, "", false, true, true); err == nil {
+			return nil
+		}
+	}
+	if errs.Is(err, errs.ErrBadSignatureScheme) {
+		log.Errorf("Packs of %s must be signed by a pinned key, \"%s\" is not signed", vendor, filepath.Base(packPath))
+		return errs.ErrSignerNotPinned
+	}
+	return err
+}
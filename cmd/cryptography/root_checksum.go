@@ -0,0 +1,275 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package cryptography
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gopgp "github.com/ProtonMail/gopenpgp/v2/crypto"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// RootManifestName is the file GenerateRootManifest writes by default
+const RootManifestName = "pack-root.checksum.json"
+
+// RootManifestVersion is the format version of the manifests written by
+// GenerateRootManifest, raised on changes older cpackget can't read
+const RootManifestVersion = 1
+
+// RootManifest lists the digests of the files of all the packs installed
+// in a pack root, for a copy of it, e.g. in a cloned machine image, to be
+// verified against
+type RootManifest struct {
+	// Version is the format version of the manifest
+	Version int `json:"version"`
+
+	// Created is when the manifest was created, in RFC 3339 format
+	Created string `json:"created"`
+
+	// CreatedBy is the version of cpackget which created the manifest
+	CreatedBy string `json:"createdBy,omitempty"`
+
+	// HashFunction is the function the digests were computed with
+	HashFunction string `json:"hashFunction"`
+
+	// Packs are the installed packs, as Vendor::Name@Version
+	Packs []string `json:"packs"`
+
+	// Files are the digests of the files of the packs, by path relative
+	// to the pack root with forward slashes
+	Files map[string]string `json:"files"`
+}
+
+// rootDigests computes the digests of the files of the packs installed in
+// packRoot, leaving out the .Download, .Local and .Web folders and the files
+// at its top, like pack.idx, which change without packs being added
+func rootDigests(packRoot string) ([]string, map[string]string, error) {
+	fsys := utils.GetFileSystem()
+	packs := []string{}
+	files := map[string]string{}
+	err := afero.Walk(fsys, packRoot, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(packRoot, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		depth := strings.Count(relPath, "/") + 1
+
+		if info.IsDir() {
+			if depth == 1 && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if depth == 3 {
+				parts := strings.Split(relPath, "/")
+				packs = append(packs, parts[0]+"::"+parts[1]+"@"+parts[2])
+			}
+			return nil
+		}
+		if depth == 1 {
+			return nil
+		}
+
+		file, err := fsys.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		h := sha256.New()
+		if _, err := utils.SecureCopy(h, file); err != nil {
+			return err
+		}
+		files[relPath] = fmt.Sprintf("%x", h.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Strings(packs)
+	return packs, files, nil
+}
+
+// GenerateRootManifest writes the RootManifest of the pack root in packRoot
+// to manifestPath. Given the PGP private key in keyPath, it also signs it
+// with a detached signature in manifestPath + ".sig". version is the version
+// of cpackget recorded in the manifest.
+func GenerateRootManifest(packRoot, manifestPath, hashFunction, version, keyPath string, passphrase []byte) error {
+	if !isValidHash(hashFunction) {
+		return errors.New("provided hash function is not supported")
+	}
+	if !utils.DirExists(packRoot) {
+		log.Errorf("\"%s\" does not exist", packRoot)
+		return errs.ErrPackRootDoesNotExist
+	}
+	if utils.FileExists(manifestPath) {
+		log.Errorf("\"%s\" already exists, choose a diferent path", manifestPath)
+		return errs.ErrPathAlreadyExists
+	}
+
+	var keyRing *gopgp.KeyRing
+	if keyPath != "" {
+		key, err := os.ReadFile(keyPath)
+		if err != nil {
+			log.Errorf("Can't read private key \"%s\": %s", keyPath, err)
+			return errs.ErrFileNotFound
+		}
+		if keyRing, err = getUnlockedKeyring(string(key), &passphraseSource{passphrase: passphrase}); err != nil {
+			return err
+		}
+	}
+
+	packs, files, err := rootDigests(packRoot)
+	if err != nil {
+		return err
+	}
+	manifest := RootManifest{
+		Version:      RootManifestVersion,
+		Created:      time.Now().UTC().Format(time.RFC3339),
+		CreatedBy:    version,
+		HashFunction: hashFunction,
+		Packs:        packs,
+		Files:        files,
+	}
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if err := utils.WriteFileAtomic(manifestPath, b, utils.FileModeRW); err != nil {
+		return err
+	}
+	log.Infof("Wrote the digests of %d pack(s), %d file(s), to \"%s\"", len(packs), len(files), manifestPath)
+
+	if keyRing == nil {
+		return nil
+	}
+	signature, err := keyRing.SignDetached(gopgp.NewPlainMessage(b))
+	if err != nil {
+		return err
+	}
+	armored, err := signature.GetArmored()
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(manifestPath+".sig", []byte(armored), utils.FileModeRW); err != nil {
+		return err
+	}
+	log.Infof("Signed \"%s\" to \"%s.sig\"", manifestPath, manifestPath)
+	return nil
+}
+
+// VerifyRootManifest checks the packs installed in packRoot against the
+// RootManifest in manifestPath: no file may be missing, changed or added.
+// Given the PGP public key in keyPath, the manifest must be signed by it.
+func VerifyRootManifest(packRoot, manifestPath, keyPath string) error {
+	if !utils.FileExists(manifestPath) {
+		log.Errorf("\"%s\" does not exist", manifestPath)
+		return errs.ErrFileNotFound
+	}
+	if !utils.DirExists(packRoot) {
+		log.Errorf("\"%s\" does not exist", packRoot)
+		return errs.ErrPackRootDoesNotExist
+	}
+
+	if keyPath != "" {
+		signaturePath := manifestPath + ".sig"
+		if !utils.FileExists(signaturePath) {
+			log.Errorf("\"%s\" has no signature \"%s\"", manifestPath, signaturePath)
+			return errs.ErrUnsignedManifest
+		}
+		if err := verifyDetachedSignature(manifestPath, signaturePath, keyPath, errs.ErrBadManifestSignature); err != nil {
+			return err
+		}
+		log.Debugf("Verified the signature of \"%s\"", manifestPath)
+	}
+
+	b, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	var manifest RootManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		log.Errorf("Can't parse \"%s\": %s", manifestPath, err)
+		return errors.New("not a valid pack root manifest")
+	}
+	if manifest.Version < 1 || manifest.Version > RootManifestVersion {
+		log.Errorf("\"%s\" has format version %d, this cpackget reads up to version %d", manifestPath, manifest.Version, RootManifestVersion)
+		return errors.New("not a valid pack root manifest")
+	}
+	if !isValidHash(manifest.HashFunction) {
+		return errors.New("not a valid pack root manifest. Please confirm if the hash is supported")
+	}
+
+	packs, files, err := rootDigests(packRoot)
+	if err != nil {
+		return err
+	}
+
+	failure := false
+	installed := map[string]bool{}
+	for _, pack := range packs {
+		installed[pack] = true
+	}
+	for _, pack := range manifest.Packs {
+		if !installed[pack] {
+			log.Errorf("%s is listed in the manifest but not installed", pack)
+			failure = true
+		}
+		delete(installed, pack)
+	}
+	for _, pack := range packs {
+		if installed[pack] {
+			log.Errorf("%s is installed but not listed in the manifest", pack)
+			failure = true
+		}
+	}
+
+	names := make([]string, 0, len(manifest.Files))
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		digest, found := files[name]
+		switch {
+		case !found:
+			log.Errorf("\"%s\" is listed in the manifest but does not exist", name)
+			failure = true
+		case digest != manifest.Files[name]:
+			log.Errorf("%s: computed checksum did NOT match", name)
+			failure = true
+		}
+		delete(files, name)
+	}
+	added := make([]string, 0, len(files))
+	for name := range files {
+		added = append(added, name)
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		log.Errorf("\"%s\" is not listed in the manifest", name)
+		failure = true
+	}
+
+	if failure {
+		return errs.ErrIntegrityCheckFailed
+	}
+	log.Infof("pack root integrity verified, all %d pack(s) and %d file(s) match.", len(packs), len(names))
+	return nil
+}
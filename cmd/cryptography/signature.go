@@ -13,19 +13,22 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
 
 	gopgp "github.com/ProtonMail/gopenpgp/v2/crypto"
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
-	log "github.com/sirupsen/logrus"
 	"golang.org/x/mod/semver"
-	"golang.org/x/term"
 )
 
 const sigVersionPrefix = "cpackget-"
 
+// versionCore returns the x.y.z part of a version like vx.y.z[-n-ghash]
+func versionCore(version string) string {
+	return strings.TrimPrefix(strings.Split(version, "-")[0], "v")
+}
+
 // validateSignatureScheme parses and identifies a packs
 // signature scheme (stored in the Zip comment field).
 func validateSignatureScheme(zip *zip.ReadCloser, version string, signing bool) string {
@@ -45,7 +48,7 @@ func validateSignatureScheme(zip *zip.ReadCloser, version string, signing bool)
 		return "invalid"
 	}
 	// Warn the user if the tag was made by an older cpackget version
-	if utils.SemverCompare(strings.Split(sv, "-")[1][1:], strings.Split(version, "-")[0][1:]) == -1 {
+	if utils.SemverCompare(versionCore(sv), versionCore(version)) == -1 {
 		log.Warnf("This pack was signed with an older version of cpackget (%s)", sv)
 	}
 	if s[1] == "f" && len(s) == 4 {
@@ -205,54 +208,20 @@ func exportCertificate(b64Cert, path string) error {
 	return nil
 }
 
-// signPackHash takes a private RSA key and PKCS1v15 signs
+// signPackHashX509 takes a private RSA key and PKCS1v15 signs
 // the hashed zip contents of a pack.
-func signPackHashX509(keyPath string, cert *x509.Certificate, hash []byte) ([]byte, error) {
-	k, err := os.ReadFile(keyPath)
+func signPackHashX509(keyPath string, cert *x509.Certificate, hash []byte, passphrase *passphraseSource) ([]byte, error) {
+	rsaPrivateKey, err := readPrivateKey(keyPath, passphrase)
 	if err != nil {
 		return nil, err
 	}
-	block, rest := pem.Decode([]byte(k))
-	if block == nil {
-		log.Error("Could not decode key as PEM, please check for corruption")
-		log.Debugf("rest: %s", string(rest))
+	if !rsaPrivateKey.PublicKey.Equal(cert.PublicKey) {
+		log.Error("Private key does not derive from provided x509 certificate")
 		return nil, errs.ErrBadPrivateKey
 	}
 
-	keyType, err := detectKeyType(string(k))
-	if err != nil {
-		return nil, err
-	}
-	var rsaPrivateKey *rsa.PrivateKey
-	var signedHash []byte
-	rng := rand.Reader
 	hashed := sha256.Sum256(hash)
-	// written as a switch to future proof
-	// for more key types (i.e PKCS8 encrypted)
-	switch keyType {
-	case "PKCS1":
-		b, err := isPrivateKeyFromCertificate(cert, block.Bytes, "PKCS1")
-		if !b {
-			log.Error("Private key does not derive from provided x509 certificate")
-			return nil, err
-		}
-		rsaPrivateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
-		if err != nil {
-			return nil, err
-		}
-	case "PKCS8":
-		b, err := isPrivateKeyFromCertificate(cert, block.Bytes, "PKCS8")
-		if !b {
-			log.Error("Private key does not derive from provided x509 certificate")
-			return nil, err
-		}
-		pk, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-		if err != nil {
-			return nil, err
-		}
-		rsaPrivateKey = pk.(*rsa.PrivateKey)
-	}
-	signedHash, err = rsa.SignPKCS1v15(rng, rsaPrivateKey, crypto.SHA256, hashed[:])
+	signedHash, err := rsa.SignPKCS1v15(rand.Reader, rsaPrivateKey, crypto.SHA256, hashed[:])
 	if err != nil {
 		return nil, err
 	}
@@ -318,8 +287,11 @@ func embedPack(packFilename, version string, z *zip.ReadCloser, rawCert, signedH
 }
 
 // SignPack is the command entrypoint to the signature
-// specific creation functions.
-func SignPack(packPath, certPath, keyPath, outputDir, version string, certOnly, skipCertValidation, skipInfo bool) error {
+// specific creation functions. With embedChecksum, a checksum file
+// is embedded into the signed pack first, see EmbedChecksum. PGP keys,
+// encrypted private keys and PKCS#12 bundles are unlocked with passphrase,
+// which is asked for on the terminal if nil.
+func SignPack(packPath, certPath, keyPath, outputDir, version string, certOnly, embedChecksum, skipCertValidation, skipInfo bool, passphrase []byte) error {
 	if !utils.FileExists(packPath) {
 		log.Errorf("\"%s\" does not exist", packPath)
 		return errs.ErrFileNotFound
@@ -357,11 +329,22 @@ func SignPack(packPath, certPath, keyPath, outputDir, version string, certOnly,
 		}
 	}
 
+	if embedChecksum {
+		// The signature covers the embedded checksum file as well
+		checksumPack := packFilenameSigned + ".checksum"
+		if err := EmbedChecksum(packPath, checksumPack, Hashes[0]); err != nil {
+			return err
+		}
+		defer os.Remove(checksumPack)
+		packPath = checksumPack
+	}
+
 	zip, err := zip.OpenReader(packPath)
 	if err != nil {
 		log.Errorf("Can't decompress \"%s\": %s", packPath, err)
 		return errs.ErrFailedDecompressingFile
 	}
+	defer zip.Close()
 	switch validateSignatureScheme(zip, version, true) {
 	case "full":
 		log.Error("\"Full\" signature found in provided pack")
@@ -381,23 +364,19 @@ func SignPack(packPath, certPath, keyPath, outputDir, version string, certOnly,
 	var keyring *gopgp.KeyRing
 	var rawCert []byte
 	var cert *x509.Certificate
+	passphraseSource := &passphraseSource{passphrase: passphrase}
 	if pgp {
 		key, err := os.ReadFile(keyPath)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("Enter key passphrase: \n")
-		passphrase, err := term.ReadPassword(int(syscall.Stdin))
-		if err != nil {
-			return err
-		}
-		keyring, err = getUnlockedKeyring(string(key), passphrase)
+		keyring, err = getUnlockedKeyring(string(key), passphraseSource)
 		if err != nil {
 			return err
 		}
 	} else {
-		// Load & analyze certificate
-		rawCert, err = os.ReadFile(certPath)
+		// Load & analyze certificate, embedded PEM encoded whatever its format
+		rawCert, err = readCertificate(certPath, passphraseSource)
 		if err != nil {
 			return err
 		}
@@ -419,7 +398,7 @@ func SignPack(packPath, certPath, keyPath, outputDir, version string, certOnly,
 			s, err = signPackHashPGP(keyring, hash)
 			signedHash = []byte(s)
 		} else {
-			signedHash, err = signPackHashX509(keyPath, cert, hash)
+			signedHash, err = signPackHashX509(keyPath, cert, hash, passphraseSource)
 		}
 		if err != nil {
 			return err
@@ -429,14 +408,14 @@ func SignPack(packPath, certPath, keyPath, outputDir, version string, certOnly,
 	if err = embedPack(packFilenameSigned, version, zip, rawCert, signedHash); err != nil {
 		return err
 	}
-	log.Infof("Successfully written signed pack %s to %s", filepath.Base(packPath), filepath.Join(outputDir, packFilenameSigned))
+	log.Infof("Successfully written signed pack %s to %s", packFilenameBase, filepath.Join(outputDir, packFilenameSigned))
 	return nil
 }
 
 // verifyPackFullSignature validates the integrity of a pack
 // by computing its digest and verifying the embedded PKCS1v15
 // signature.
-func verifyPackFullSignature(zip *zip.ReadCloser, vendor, b64Cert, b64Hash string, skipCertValidation, skipInfo bool) error {
+func verifyPackFullSignature(packPath string, zip *zip.ReadCloser, vendor, b64Cert, b64Hash string, skipCertValidation, skipInfo bool) error {
 	rawCert, err := base64.StdEncoding.DecodeString(b64Cert)
 	if err != nil {
 		return err
@@ -449,12 +428,16 @@ func verifyPackFullSignature(zip *zip.ReadCloser, vendor, b64Cert, b64Hash strin
 	if err != nil {
 		return err
 	}
-	hashPack, err := calculatePackHash(zip)
+	hashPack, err := verifiedPackHash(packPath, zip)
 	if err != nil {
 		return err
 	}
 	hashPack256 := sha256.Sum256(hashPack)
-	return rsa.VerifyPKCS1v15(certificate.PublicKey.(*rsa.PublicKey), crypto.SHA256, hashPack256[:], hashSig)
+	if err := rsa.VerifyPKCS1v15(certificate.PublicKey.(*rsa.PublicKey), crypto.SHA256, hashPack256[:], hashSig); err != nil {
+		return err
+	}
+	utils.StoreVerification("signature", packPath, hashPack)
+	return nil
 }
 
 // verifyPackCertOnlySignature validates the integrity of a pack
@@ -473,7 +456,7 @@ func verifyPackCertOnlySignature(zip *zip.ReadCloser, vendor, b64Cert string, sk
 
 // verifyPackCertOnlySignature validates the integrity of a pack
 // by verifying a PGP detached signature against a public key.
-func verifyPackPGPSignature(zip *zip.ReadCloser, keyPath, b64Signature string) error {
+func verifyPackPGPSignature(packPath string, zip *zip.ReadCloser, keyPath, b64Signature string) error {
 	if keyPath == "" {
 		log.Error("Please provide the public key to use for verification")
 		return errs.ErrCannotVerifySignature
@@ -486,7 +469,7 @@ func verifyPackPGPSignature(zip *zip.ReadCloser, keyPath, b64Signature string) e
 	if err != nil {
 		return err
 	}
-	packHash, err := calculatePackHash(zip)
+	packHash, err := verifiedPackHash(packPath, zip)
 	if err != nil {
 		return err
 	}
@@ -503,7 +486,11 @@ func verifyPackPGPSignature(zip *zip.ReadCloser, keyPath, b64Signature string) e
 	if err != nil {
 		return err
 	}
-	return signingKeyRing.VerifyDetached(message, pgpSignature, gopgp.GetUnixTime())
+	if err := signingKeyRing.VerifyDetached(message, pgpSignature, gopgp.GetUnixTime()); err != nil {
+		return err
+	}
+	utils.StoreVerification("signature", packPath, packHash)
+	return nil
 }
 
 // VerifyPackSignature is the command entrypoint to the signature
@@ -525,7 +512,8 @@ func VerifyPackSignature(packPath, pubPath, version string, export, skipCertVali
 
 	vendor := strings.Split(filepath.Base(packPath), ".")[0]
 	certPath := filepath.Base(packPath) + ".pem"
-	switch validateSignatureScheme(zip, version, false) {
+	scheme := validateSignatureScheme(zip, version, false)
+	switch scheme {
 	case "full":
 		if export {
 			err := exportCertificate(getSignField(zip.Comment, "certificate"), certPath)
@@ -534,7 +522,7 @@ func VerifyPackSignature(packPath, pubPath, version string, export, skipCertVali
 			}
 			return nil
 		}
-		err := verifyPackFullSignature(zip, vendor, getSignField(zip.Comment, "certificate"), getSignField(zip.Comment, "hash"), skipCertValidation, skipInfo)
+		err := verifyPackFullSignature(packPath, zip, vendor, getSignField(zip.Comment, "certificate"), getSignField(zip.Comment, "hash"), skipCertValidation, skipInfo)
 		if err != nil {
 			return errs.ErrPossibleMaliciousPack
 		}
@@ -551,7 +539,7 @@ func VerifyPackSignature(packPath, pubPath, version string, export, skipCertVali
 			return errs.ErrPossibleMaliciousPack
 		}
 	case "pgp":
-		if err = verifyPackPGPSignature(zip, pubPath, getSignField(zip.Comment, "pubsig")); err != nil {
+		if err = verifyPackPGPSignature(packPath, zip, pubPath, getSignField(zip.Comment, "pubsig")); err != nil {
 			return err
 		}
 	case "empty":
@@ -561,6 +549,10 @@ func VerifyPackSignature(packPath, pubPath, version string, export, skipCertVali
 		return errs.ErrBadSignatureScheme
 	}
 
+	if err := checkSigner(vendor, packPath, scheme, zip.Comment, pubPath); err != nil {
+		return err
+	}
+
 	log.Info("Pack signature verification success - pack is authentic")
 	return nil
 }
@@ -0,0 +1,208 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package cryptography
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	gopgp "github.com/ProtonMail/gopenpgp/v2/crypto"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// gKnownSigners is the file the signers of each vendor are recorded in,
+// see SetKnownSigners
+var gKnownSigners string
+
+// SetKnownSigners makes signature verifications trust the signer of the
+// first pack of a vendor they verify, recording its key in the JSON file
+// in path, and refuse packs of this vendor signed with another key later
+// on. An empty path disables trust on first use.
+func SetKnownSigners(path string) {
+	gKnownSigners = path
+}
+
+func GetKnownSigners() string {
+	return gKnownSigners
+}
+
+// DefaultKnownSigners returns where signers are recorded unless told
+// otherwise, in the cpackget folder of the user's configuration directory
+func DefaultKnownSigners() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "known_signers.json"
+	}
+	return filepath.Join(dir, "cpackget", "known_signers.json")
+}
+
+// KnownSigner is the key a vendor signed the first verified pack with
+type KnownSigner struct {
+	// Fingerprint is "x509:" followed by the SHA-256 hash of the public key
+	// of the certificate, so that renewed certificates of the same key are
+	// still trusted, or "pgp:" followed by the fingerprint of the PGP key
+	Fingerprint string `json:"fingerprint"`
+
+	// Subject describes the signer, e.g. the subject of the certificate
+	Subject string `json:"subject,omitempty"`
+
+	// Pack is the file name of the first verified pack
+	Pack string `json:"pack"`
+
+	// FirstSeen is when the signer was recorded
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// ReadKnownSigners returns the signers recorded in path by vendor
+func ReadKnownSigners(path string) (map[string]KnownSigner, error) {
+	signers := make(map[string]KnownSigner)
+	if !utils.FileExists(path) {
+		return signers, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &signers); err != nil {
+		log.Errorf("Could not read the known signers \"%s\": %s", path, err)
+		return nil, err
+	}
+	return signers, nil
+}
+
+// writeKnownSigners saves signers to path
+func writeKnownSigners(path string, signers map[string]KnownSigner) error {
+	data, err := json.MarshalIndent(signers, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, data, 0600)
+}
+
+// packSigner returns the fingerprint and subject of the key the pack was
+// signed with in scheme, read from its zip comment or the PGP key in pubPath
+func packSigner(scheme, comment, pubPath string) (string, string, error) {
+	if scheme == "pgp" {
+		rawKey, err := os.ReadFile(pubPath)
+		if err != nil {
+			return "", "", err
+		}
+		key, err := gopgp.NewKeyFromArmored(string(rawKey))
+		if err != nil {
+			return "", "", err
+		}
+		subject := ""
+		if identity := key.GetEntity().PrimaryIdentity(); identity != nil {
+			subject = identity.Name
+		}
+		return pgpFingerprint(key), subject, nil
+	}
+
+	rawCert, err := base64.StdEncoding.DecodeString(getSignField(comment, "certificate"))
+	if err != nil {
+		return "", "", err
+	}
+	certPEM, _ := pem.Decode(rawCert)
+	if certPEM == nil {
+		return "", "", errs.ErrCannotVerifySignature
+	}
+	cert, err := x509.ParseCertificate(certPEM.Bytes)
+	if err != nil {
+		return "", "", err
+	}
+	return certificateFingerprint(cert), cert.Subject.String(), nil
+}
+
+// certificateFingerprint identifies the public key of cert
+func certificateFingerprint(cert *x509.Certificate) string {
+	return fmt.Sprintf("x509:%x", sha256.Sum256(cert.RawSubjectPublicKeyInfo))
+}
+
+// pgpFingerprint identifies the PGP key
+func pgpFingerprint(key *gopgp.Key) string {
+	return "pgp:" + key.GetFingerprint()
+}
+
+// checkSigner checks that the verified pack in packPath is signed by a key
+// pinned for vendor, if any, and trusts its signer on first use otherwise
+func checkSigner(vendor, packPath, scheme, comment, pubPath string) error {
+	pins, pinned := gPinnedSigners[vendor]
+	if !pinned {
+		return trustSigner(vendor, packPath, scheme, comment, pubPath)
+	}
+
+	if scheme == "cert-only" {
+		log.Errorf("Packs of %s must be signed by a pinned key, \"%s\" only embeds a certificate", vendor, filepath.Base(packPath))
+		return errs.ErrSignerNotPinned
+	}
+	fingerprint, subject, err := packSigner(scheme, comment, pubPath)
+	if err != nil {
+		return err
+	}
+	for _, pin := range pins {
+		if pin.Fingerprint == fingerprint {
+			log.Debugf("%s is a pinned signer of %s packs", fingerprint, vendor)
+			return nil
+		}
+	}
+	log.Errorf("\"%s\" is signed by \"%s\" with %s, which is not pinned for %s", filepath.Base(packPath), subject, fingerprint, vendor)
+	return errs.ErrSignerNotPinned
+}
+
+// trustSigner records the signer of the verified pack in packPath if it
+// is the first one of vendor, or checks that it is the recorded one
+func trustSigner(vendor, packPath, scheme, comment, pubPath string) error {
+	if gKnownSigners == "" {
+		return nil
+	}
+
+	fingerprint, subject, err := packSigner(scheme, comment, pubPath)
+	if err != nil {
+		return err
+	}
+
+	signers, err := ReadKnownSigners(gKnownSigners)
+	if err != nil {
+		return err
+	}
+
+	known, ok := signers[vendor]
+	if !ok {
+		log.Infof("Trusting %s as the signer of %s packs from now on", fingerprint, vendor)
+		signers[vendor] = KnownSigner{
+			Fingerprint: fingerprint,
+			Subject:     subject,
+			Pack:        filepath.Base(packPath),
+			FirstSeen:   time.Now().UTC(),
+		}
+		return writeKnownSigners(gKnownSigners, signers)
+	}
+
+	if known.Fingerprint != fingerprint {
+		log.Error("@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@")
+		log.Errorf("@  WARNING: THE SIGNER OF %s PACKS HAS CHANGED!", vendor)
+		log.Error("@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@")
+		log.Errorf("%s packs were first signed by \"%s\" with %s, verifying %s on %s",
+			vendor, known.Subject, known.Fingerprint, known.Pack, known.FirstSeen.Format(time.RFC3339))
+		log.Errorf("\"%s\" is signed by \"%s\" with %s", filepath.Base(packPath), subject, fingerprint)
+		log.Errorf("If %s really changed its key, remove its entry from \"%s\"", vendor, gKnownSigners)
+		return errs.ErrSignerChanged
+	}
+
+	log.Debugf("%s is the known signer of %s packs", fingerprint, vendor)
+	return nil
+}
@@ -7,12 +7,11 @@ import (
 	"crypto/x509"
 	"fmt"
 	"hash"
-	"strings"
 
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
-	log "github.com/sirupsen/logrus"
 )
 
 // calculatePackHash hashes the contents of a zip file using the
@@ -21,13 +20,7 @@ func calculatePackHash(zip *zip.ReadCloser) ([]byte, error) {
 	hashes := make([]byte, 0)
 	h := sha256.New()
 	for _, file := range zip.File {
-		reader, err := file.Open()
-		if err != nil {
-			return nil, err
-		}
-		defer reader.Close()
-		_, err = utils.SecureCopy(h, reader)
-		if err != nil {
+		if err := hashZipFile(h, file); err != nil {
 			return nil, err
 		}
 		hashes = h.Sum(hashes)
@@ -36,22 +29,16 @@ func calculatePackHash(zip *zip.ReadCloser) ([]byte, error) {
 	return hashes, nil
 }
 
-// detectKeyType identifies a PEM encoded RSA private key. It can be
-// either PKCS1 or PKCS8, the latter not password-protected (std crypto
-// does not support it currently).
-func detectKeyType(key string) (string, error) {
-	switch strings.Split(key, "-----")[1] {
-	case "BEGIN RSA PRIVATE KEY":
-		return "PKCS1", nil
-	case "BEGIN PRIVATE KEY":
-		return "PKCS8", nil
-	case "BEGIN ENCRYPTED PRIVATE KEY":
-		log.Error("encrypted PKCSC8 private keys aren't currently supported")
-		return "", errs.ErrUnsupportedKeyAlgo
-	default:
-		log.Error("could not decode private key as PEM, please check for corruption")
-		return "", errs.ErrBadPrivateKey
+// verifiedPackHash returns the hash of the pack in packPath an earlier
+// successful signature verification computed, calculating it otherwise.
+// The signature itself is always checked again.
+func verifiedPackHash(packPath string, zip *zip.ReadCloser) ([]byte, error) {
+	var hash []byte
+	if utils.LoadVerification("signature", packPath, &hash) {
+		log.Debugf("Reusing the hash of \"%s\" from an earlier verification", packPath)
+		return hash, nil
 	}
+	return calculatePackHash(zip)
 }
 
 // displayCertInfo prints the relevant fields of a certificate.
@@ -100,16 +87,11 @@ func getDigestList(sourcePack, hashFunction string) (map[string]string, error) {
 		log.Errorf("can't decompress \"%s\": %s", sourcePack, err)
 		return nil, errs.ErrFailedDecompressingFile
 	}
+	defer zipReader.Close()
 
 	digests := make(map[string]string)
 	for _, file := range zipReader.File {
-		reader, err := file.Open()
-		if err != nil {
-			return nil, err
-		}
-		defer reader.Close()
-		_, err = utils.SecureCopy(h, reader)
-		if err != nil {
+		if err := hashZipFile(h, file); err != nil {
 			return nil, err
 		}
 		digests[file.Name] = fmt.Sprintf("%x", h.Sum(nil))
@@ -117,6 +99,20 @@ func getDigestList(sourcePack, hashFunction string) (map[string]string, error) {
 	return digests, nil
 }
 
+// hashZipFile streams the uncompressed contents of file into h. The
+// entry is closed right away, so the memory held by its decompressor
+// does not pile up over the entries of a pack.
+func hashZipFile(h hash.Hash, file *zip.File) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = utils.SecureCopy(h, reader)
+	return err
+}
+
 // getKeyUsage prints the RFC/human friendly version
 // of possible X.509 key usages (https://www.rfc-editor.org/rfc/rfc5280#section-4.2.1.3).
 func getKeyUsage(k x509.KeyUsage) []string {
@@ -155,13 +151,25 @@ func getKeyUsage(k x509.KeyUsage) []string {
 }
 
 // getUnlockedKeyring returns a ready to use
-// KeyRing based on a private key.
-func getUnlockedKeyring(key string, passphrase []byte) (*crypto.KeyRing, error) {
+// KeyRing based on a private key. Locked keys are unlocked with
+// the passphrase of passphrase.
+func getUnlockedKeyring(key string, passphrase *passphraseSource) (*crypto.KeyRing, error) {
 	privateKeyObj, err := crypto.NewKeyFromArmored(key)
 	if err != nil {
 		return nil, err
 	}
-	unlockedKeyObj, err := privateKeyObj.Unlock(passphrase)
+	locked, err := privateKeyObj.IsLocked()
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return crypto.NewKeyRing(privateKeyObj)
+	}
+	password, err := passphrase.get()
+	if err != nil {
+		return nil, err
+	}
+	unlockedKeyObj, err := privateKeyObj.Unlock(password)
 	if err != nil {
 		return nil, err
 	}
@@ -172,29 +180,6 @@ func getUnlockedKeyring(key string, passphrase []byte) (*crypto.KeyRing, error)
 	return signingKeyRing, nil
 }
 
-// isPrivateKeyFromCertificate tells whether a DER encoded key
-// is the private counterpart to a X.509 certificate.
-func isPrivateKeyFromCertificate(cert *x509.Certificate, keyDER []byte, keyType string) (bool, error) {
-	if keyType == "PKCS1" {
-		pv, err := x509.ParsePKCS1PrivateKey(keyDER)
-		if err != nil {
-			return false, err
-		}
-		pubCert := cert.PublicKey
-		return pv.PublicKey.Equal(pubCert), nil
-	} else {
-		if keyType == "PKCS8" {
-			pv, err := x509.ParsePKCS8PrivateKey(keyDER)
-			if err != nil {
-				return false, err
-			}
-			pubCert := cert.PublicKey
-			return pv.(*rsa.PrivateKey).PublicKey.Equal(pubCert), nil
-		}
-	}
-	return false, errs.ErrBadPrivateKey
-}
-
 // sanitizeVersionForSignature cleans the version string
 // for the sig scheme as it may not be the same in builds
 // from source vs an automated release.
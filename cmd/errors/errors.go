@@ -4,14 +4,21 @@
 package errors
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 )
 
 var lastLoggedMessage string
 
-// Is returns true if err is equals to target
+// Is returns true if err is target or wraps it
 func Is(err, target error) bool {
-	return err == target
+	return errors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target, see errors.As
+func As(err error, target any) bool {
+	return errors.As(err, target)
 }
 
 // AlreadyLogged returns true if the error log has already been logged
@@ -33,19 +40,43 @@ var (
 	// Errors related to package content
 	ErrPdscFileNotFound      = errors.New("pdsc not found")
 	ErrPackNotInstalled      = errors.New("pack not installed")
+	ErrPackAlreadyInstalled  = errors.New("pack already installed")
 	ErrPackNotPurgeable      = errors.New("pack not purgeable")
 	ErrPdscEntryExists       = errors.New("pdsc already in index")
 	ErrPdscEntryNotFound     = errors.New("pdsc not found in index")
 	ErrEula                  = errors.New("user does not agree with the pack's license")
+	ErrEulaTimeout           = fmt.Errorf("license prompt was not answered in time: %w", ErrEula)
 	ErrExtractEula           = errors.New("user wants to extract embedded license only")
+	ErrEulaNotAgreed         = errors.New("embedded license must be agreed with -a/--agree-embedded-license")
 	ErrLicenseNotFound       = errors.New("embedded license not found")
 	ErrPackRootNotFound      = errors.New("no CMSIS Pack Root directory specified. Either the environment CMSIS_PACK_ROOT needs to be set or the path specified using the command line option -R/--pack-root string")
 	ErrPackRootDoesNotExist  = errors.New("the specified CMSIS Pack Root directory does NOT exist! Please take a moment to review if the value is correct or create a new one via `cpackget init` command")
+	ErrNoWritablePackRoot    = errors.New("none of the CMSIS Pack Root directories specified is writable")
 	ErrPdscFileTooDeepInPack = errors.New("pdsc file is too deep in pack file")
+	ErrExampleNotFound       = errors.New("example not found in installed packs")
+	ErrAmbiguousExample      = errors.New("example is provided by several packs, select one with --pack")
+	ErrPackPinned            = errors.New("pack is pinned, changing it requires --force")
+
+	// Errors related to moving the pack root
+	ErrMovingPackRootIntoItself    = errors.New("cannot move a pack root into itself")
+	ErrPackRootDestinationNotEmpty = errors.New("the pack root can only be moved to an empty directory")
+
+	// Errors related to importing packs
+	ErrNotMDKPackFolder = errors.New("not an MDK pack folder")
+
+	// Errors related to updating cpackget itself
+	ErrReleaseNotFound = errors.New("the release has no cpackget binary for this platform")
+
+	// Errors related to post-install steps of packs
+	ErrPostInstallNotAllowed = errors.New("pack declares a post-install step running a command that is not allowed")
+	ErrPostInstallFailed     = errors.New("post-install step of the pack failed")
+	ErrPostInstallNoSandbox  = errors.New("post-install steps cannot be sandboxed on this system")
 
 	// Errors related to network
 	ErrBadRequest            = errors.New("bad request")
 	ErrFailedDownloadingFile = errors.New("failed to download file")
+	ErrOffline               = errors.New("cannot access the network while offline")
+	ErrTooManyRedirects      = errors.New("too many redirects")
 
 	// Errors related to file system
 	ErrFailedCreatingFile        = errors.New("failed to create a local file")
@@ -58,21 +89,38 @@ var (
 	ErrPathAlreadyExists         = errors.New("path already exists")
 	ErrCopyingEqualPaths         = errors.New("failed copying files: source is the same as destination")
 	ErrMovingEqualPaths          = errors.New("failed moving files: source is the same as destination")
+	ErrNotEnoughDiskSpace        = errors.New("not enough disk space")
+	ErrPackRootQuotaExceeded     = errors.New("pack root would exceed its maximum size")
+	ErrUnsupportedArchiveFormat  = errors.New("unsupported archive format, use .tar, .tar.gz or .tgz")
+	ErrArchiveInsidePackRoot     = errors.New("the pack root cannot be exported into itself")
 
 	// Cryptography errors
 	ErrIntegrityCheckFailed  = errors.New("checksum verification failed")
 	ErrAlreadySigned         = errors.New("pack is already signed, not overwriting")
 	ErrBadPrivateKey         = errors.New("private key can't be processed")
+	ErrPassphraseRequired    = errors.New("passphrase of the encrypted private key is required")
 	ErrBadSignatureScheme    = errors.New("pack has an invalid/corrupt signature scheme")
 	ErrUnsafeCertificate     = errors.New("certificate does not meet minimum security standards")
 	ErrUnsupportedKeyAlgo    = errors.New("unsupported key algorithm")
 	ErrCannotVerifySignature = errors.New("cannot verify pack signature")
 	ErrPossibleMaliciousPack = errors.New("bad pack integrity! signature does not match pack contents - might have been tampered")
+	ErrSignerNotPinned       = errors.New("pack is not signed by a key pinned for its vendor")
+	ErrSignerChanged         = errors.New("pack is signed by another key than the earlier packs of its vendor - might have been tampered")
+	ErrUnsignedIndex         = errors.New("index is not signed, a detached .sig signature is required")
+	ErrBadIndexSignature     = errors.New("bad index integrity! signature does not match index contents - might have been tampered")
+	ErrBadReleaseSignature   = errors.New("bad release integrity! signature does not match the checksums of the release - might have been tampered")
+	ErrUnsignedRelease       = errors.New("release is not signed, a detached .sig signature of its checksums is required")
+	ErrBadManifestSignature  = errors.New("bad pack root integrity! signature does not match the manifest - might have been tampered")
+	ErrUnsignedManifest      = errors.New("manifest is not signed, a detached .sig signature is required")
+	ErrIndexPinMismatch      = errors.New("index does not match its pinned hash")
+	ErrInvalidIndexPin       = errors.New("index pins must look like \"sha256:<hex digest>\"")
 
 	// Security errors
-	ErrInsecureZipFileName = errors.New("zip file contains insecure characters: ../")
-	ErrFileTooBig          = errors.New("files cannot be over 20G")
-	ErrIndexPathNotSafe    = errors.New("index url path does not start with HTTPS")
+	ErrInsecureZipFileName     = errors.New("zip file contains insecure characters: ../")
+	ErrInsecureArchiveFileName = errors.New("archive contains files outside of its root")
+	ErrCaseCollision           = errors.New("pack contains files whose names only differ by case, they would overwrite each other")
+	ErrFileTooBig              = errors.New("files cannot be over 20G")
+	ErrIndexPathNotSafe        = errors.New("index url path does not start with HTTPS")
 
 	// Errors that can't be be predicted
 	ErrUnknownBehavior = errors.New("unknown behavior")
@@ -83,11 +131,22 @@ var (
 	// Errors on installation strucuture
 	ErrCannotOverwritePublicIndex      = errors.New("cannot replace \"index.pidx\", use the flag \"-f/--force\" to force overwritting it")
 	ErrInvalidPublicIndexReference     = errors.New("the specified index path can only either empty, a local file or an HTTP(S) URL - not a directory")
+	ErrInvalidURLRewrite               = errors.New("URL rewrite rules must look like \"<prefix> => <replacement>\"")
+	ErrInvalidPackHashURL              = errors.New("pack hash URLs must look like \"<vendor> => <URL pattern>\"")
+	ErrInvalidPinnedSigner             = errors.New("pinned signers must look like \"<vendor> => <x509:|pgp:fingerprint, certificate or PGP public key file>\"")
+	ErrInvalidEulaAllowlist            = errors.New("license allowlists must list the SHA-256 hashes of license texts, one per line")
+	ErrEnvironmentProblems             = errors.New("found problems in the environment, see the hints above")
 	ErrPackPdscCannotBeFound           = errors.New("the URL is invalid or does not return the file")
 	ErrPackVersionNotFoundInPdsc       = errors.New("pack version not found in the pdsc file")
 	ErrPackVersionNotLatestReleasePdsc = errors.New("pack version is not the latest in the pdsc file")
 	ErrPackVersionNotAvailable         = errors.New("target pack version is not available")
+	ErrInvalidReleaseVersion           = errors.New("release version is not a valid semantic version")
 	ErrPackURLCannotBeFound            = errors.New("URL for the pack cannot be determined. Please consider updating the public index. Ex: cpackget update-index")
+	ErrIndexSourceNotFound             = errors.New("index source not found")
+	ErrIndexSourceExists               = errors.New("index source already exists")
+
+	// Errors of the Go API
+	ErrUnknownVersion = errors.New("cpackget version cannot be determined, please specify it")
 
 	// Hack to allow multiple error logs while still avoiding duplicating the last error log
 	ErrAlreadyLogged = errors.New("already logged")
@@ -95,3 +154,249 @@ var (
 	// Error/Flag to detect when a user has requested early termination
 	ErrTerminatedByUser = errors.New("terminated by user request")
 )
+
+// CodeUnknown is the code of errors that are not listed in codes
+const CodeUnknown = "UNKNOWN"
+
+// codes maps errors to stable codes for tools that process cpackget's
+// errors. Codes must never change once released, only new ones be added.
+// Errors wrapping others of this package come before them.
+var codes = []struct {
+	err  error
+	code string
+}{
+	{ErrBadPackName, "BAD_PACK_NAME"},
+	{ErrBadPackURL, "BAD_PACK_URL"},
+	{ErrPdscFileNotFound, "PDSC_FILE_NOT_FOUND"},
+	{ErrPackNotInstalled, "PACK_NOT_INSTALLED"},
+	{ErrPackAlreadyInstalled, "PACK_ALREADY_INSTALLED"},
+	{ErrPackNotPurgeable, "PACK_NOT_PURGEABLE"},
+	{ErrPdscEntryExists, "PDSC_ENTRY_EXISTS"},
+	{ErrPdscEntryNotFound, "PDSC_ENTRY_NOT_FOUND"},
+	{ErrEulaTimeout, "EULA_TIMEOUT"},
+	{ErrEula, "EULA_DECLINED"},
+	{ErrExtractEula, "EULA_EXTRACTED"},
+	{ErrEulaNotAgreed, "EULA_NOT_AGREED"},
+	{ErrLicenseNotFound, "LICENSE_NOT_FOUND"},
+	{ErrPackRootNotFound, "PACK_ROOT_NOT_FOUND"},
+	{ErrPackRootDoesNotExist, "PACK_ROOT_DOES_NOT_EXIST"},
+	{ErrNoWritablePackRoot, "NO_WRITABLE_PACK_ROOT"},
+	{ErrPdscFileTooDeepInPack, "PDSC_FILE_TOO_DEEP_IN_PACK"},
+	{ErrExampleNotFound, "EXAMPLE_NOT_FOUND"},
+	{ErrAmbiguousExample, "AMBIGUOUS_EXAMPLE"},
+	{ErrPackPinned, "PACK_PINNED"},
+	{ErrMovingPackRootIntoItself, "MOVE_PACK_ROOT_INTO_ITSELF"},
+	{ErrPackRootDestinationNotEmpty, "PACK_ROOT_DESTINATION_NOT_EMPTY"},
+	{ErrNotMDKPackFolder, "NOT_MDK_PACK_FOLDER"},
+	{ErrBadRequest, "BAD_REQUEST"},
+	{ErrFailedDownloadingFile, "DOWNLOAD_FAILED"},
+	{ErrOffline, "OFFLINE"},
+	{ErrTooManyRedirects, "TOO_MANY_REDIRECTS"},
+	{ErrFailedCreatingFile, "CREATE_FILE_FAILED"},
+	{ErrFailedWrittingToLocalFile, "WRITE_FILE_FAILED"},
+	{ErrFailedDecompressingFile, "DECOMPRESS_FAILED"},
+	{ErrFailedInflatingFile, "INFLATE_FAILED"},
+	{ErrFailedCreatingDirectory, "CREATE_DIRECTORY_FAILED"},
+	{ErrFileNotFound, "FILE_NOT_FOUND"},
+	{ErrDirectoryNotFound, "DIRECTORY_NOT_FOUND"},
+	{ErrPathAlreadyExists, "PATH_ALREADY_EXISTS"},
+	{ErrCopyingEqualPaths, "COPY_EQUAL_PATHS"},
+	{ErrMovingEqualPaths, "MOVE_EQUAL_PATHS"},
+	{ErrNotEnoughDiskSpace, "NOT_ENOUGH_DISK_SPACE"},
+	{ErrPackRootQuotaExceeded, "PACK_ROOT_QUOTA_EXCEEDED"},
+	{ErrUnsupportedArchiveFormat, "UNSUPPORTED_ARCHIVE_FORMAT"},
+	{ErrArchiveInsidePackRoot, "ARCHIVE_INSIDE_PACK_ROOT"},
+	{ErrPostInstallNotAllowed, "POST_INSTALL_NOT_ALLOWED"},
+	{ErrPostInstallFailed, "POST_INSTALL_FAILED"},
+	{ErrPostInstallNoSandbox, "POST_INSTALL_NO_SANDBOX"},
+	{ErrIntegrityCheckFailed, "INTEGRITY_CHECK_FAILED"},
+	{ErrAlreadySigned, "ALREADY_SIGNED"},
+	{ErrBadPrivateKey, "BAD_PRIVATE_KEY"},
+	{ErrPostInstallNotAllowed, "Check the steps in the pack's PDSC file, then allow their commands with --post-install-commands"},
+	{ErrPostInstallNoSandbox, "Run post-install steps on Linux with unprivileged user namespaces enabled"},
+	{ErrPassphraseRequired, "PASSPHRASE_REQUIRED"},
+	{ErrBadSignatureScheme, "BAD_SIGNATURE_SCHEME"},
+	{ErrUnsafeCertificate, "UNSAFE_CERTIFICATE"},
+	{ErrUnsupportedKeyAlgo, "UNSUPPORTED_KEY_ALGORITHM"},
+	{ErrCannotVerifySignature, "CANNOT_VERIFY_SIGNATURE"},
+	{ErrPossibleMaliciousPack, "POSSIBLE_MALICIOUS_PACK"},
+	{ErrSignerNotPinned, "SIGNER_NOT_PINNED"},
+	{ErrSignerChanged, "SIGNER_CHANGED"},
+	{ErrUnsignedIndex, "UNSIGNED_INDEX"},
+	{ErrBadIndexSignature, "BAD_INDEX_SIGNATURE"},
+	{ErrBadReleaseSignature, "BAD_RELEASE_SIGNATURE"},
+	{ErrUnsignedRelease, "UNSIGNED_RELEASE"},
+	{ErrReleaseNotFound, "RELEASE_NOT_FOUND"},
+	{ErrBadManifestSignature, "BAD_MANIFEST_SIGNATURE"},
+	{ErrUnsignedManifest, "UNSIGNED_MANIFEST"},
+	{ErrIndexPinMismatch, "INDEX_PIN_MISMATCH"},
+	{ErrInvalidIndexPin, "INVALID_INDEX_PIN"},
+	{ErrInsecureZipFileName, "INSECURE_ZIP_FILE_NAME"},
+	{ErrInsecureArchiveFileName, "INSECURE_ARCHIVE_FILE_NAME"},
+	{ErrCaseCollision, "CASE_COLLISION"},
+	{ErrFileTooBig, "FILE_TOO_BIG"},
+	{ErrIndexPathNotSafe, "INDEX_PATH_NOT_SAFE"},
+	{ErrUnknownBehavior, "UNKNOWN_BEHAVIOR"},
+	{ErrIncorrectCmdArgs, "INCORRECT_COMMAND_ARGUMENTS"},
+	{ErrCannotOverwritePublicIndex, "CANNOT_OVERWRITE_PUBLIC_INDEX"},
+	{ErrInvalidPublicIndexReference, "INVALID_PUBLIC_INDEX_REFERENCE"},
+	{ErrInvalidURLRewrite, "INVALID_URL_REWRITE"},
+	{ErrInvalidPackHashURL, "INVALID_PACK_HASH_URL"},
+	{ErrInvalidPinnedSigner, "INVALID_PINNED_SIGNER"},
+	{ErrInvalidEulaAllowlist, "INVALID_EULA_ALLOWLIST"},
+	{ErrEnvironmentProblems, "ENVIRONMENT_PROBLEMS"},
+	{ErrPackPdscCannotBeFound, "PACK_PDSC_NOT_FOUND"},
+	{ErrPackVersionNotFoundInPdsc, "PACK_VERSION_NOT_IN_PDSC"},
+	{ErrPackVersionNotLatestReleasePdsc, "PACK_VERSION_NOT_LATEST_IN_PDSC"},
+	{ErrPackVersionNotAvailable, "PACK_VERSION_NOT_AVAILABLE"},
+	{ErrInvalidReleaseVersion, "INVALID_RELEASE_VERSION"},
+	{ErrPackURLCannotBeFound, "PACK_URL_NOT_FOUND"},
+	{ErrIndexSourceNotFound, "INDEX_SOURCE_NOT_FOUND"},
+	{ErrIndexSourceExists, "INDEX_SOURCE_EXISTS"},
+	{ErrUnknownVersion, "UNKNOWN_VERSION"},
+	{ErrAlreadyLogged, "ALREADY_LOGGED"},
+	{ErrTerminatedByUser, "TERMINATED_BY_USER"},
+}
+
+// Code returns the stable code of err, or CodeUnknown if err
+// does not wrap any of the errors of this package
+func Code(err error) string {
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return CodeUnknown
+}
+
+// hints tells how to get past errors, for tools presenting them to users.
+// Errors wrapping others of this package come before them.
+var hints = []struct {
+	err  error
+	hint string
+}{
+	{ErrBadPackName, "Use a pack ID like Vendor::Pack@1.2.3, or the path of a .pack or .pdsc file"},
+	{ErrPackNotInstalled, "Run \"cpackget list\" to see the installed packs"},
+	{ErrPackPinned, "Unpin the pack with \"cpackget unpin\" first, or pass --force"},
+	{ErrEula, "Run with --extract-embedded-license to read the license before agreeing with it"},
+	{ErrEulaNotAgreed, "Run with -a/--agree-embedded-license after reading the license"},
+	{ErrPackRootNotFound, "Set CMSIS_PACK_ROOT or pass -R/--pack-root"},
+	{ErrPackRootDoesNotExist, "Run \"cpackget init\" to create the pack root"},
+	{ErrFailedDownloadingFile, "Check the network with \"cpackget connection\", or retry with -T/--timeout"},
+	{ErrBadRequest, "Check the network with \"cpackget connection\""},
+	{ErrOffline, "Run without --offline, or place the pack in .Download/ first"},
+	{ErrTooManyRedirects, "Raise --max-redirects if the server really redirects that often"},
+	{ErrFileNotFound, "Check the path of the file"},
+	{ErrNotEnoughDiskSpace, "Free up disk space, or move the pack root with \"cpackget root move\""},
+	{ErrPackRootQuotaExceeded, "Remove packs, or raise --max-pack-root-size"},
+	{ErrIntegrityCheckFailed, "Download the pack again with --force-download"},
+	{ErrCaseCollision, "Install the pack on a case-sensitive file system, and ask its vendor to rename the files"},
+	{ErrPassphraseRequired, "Give the passphrase with --passphrase-file or the CPACKGET_PASSPHRASE environment variable"},
+	{ErrSignerNotPinned, "Check the key the vendor signs its packs with against --pinned-signers"},
+	{ErrSignerChanged, "If the vendor really changed its key, remove its entry from the known signers"},
+	{ErrIndexPinMismatch, "Pin the new index with \"cpackget index <index-url> --pin\" once verified"},
+	{ErrPackURLCannotBeFound, "Run \"cpackget update-index\" to refresh the public index"},
+	{ErrPackVersionNotAvailable, "Run \"cpackget update-index\" to refresh the public index"},
+	{ErrIndexSourceNotFound, "Run \"cpackget index list\" to see the index sources"},
+	{ErrIncorrectCmdArgs, "Run \"cpackget help\" with the command to see its usage"},
+}
+
+// Hint returns how to get past err, or an empty string if there is no hint
+func Hint(err error) string {
+	for _, h := range hints {
+		if errors.Is(err, h.err) {
+			return h.hint
+		}
+	}
+	return ""
+}
+
+// Error is an error of this package along with the pack, URL
+// or path it happened on
+type Error struct {
+	// Err is the error of this package that happened
+	Err error
+
+	// PackID is the pack the error happened on, if known
+	PackID string
+
+	// URL is the URL the error happened on, if known
+	URL string
+
+	// Path is the local path the error happened on, if known
+	Path string
+}
+
+// WithPackID returns err along with the pack it happened on
+func WithPackID(err error, packID string) error {
+	return &Error{Err: err, PackID: packID}
+}
+
+// WithURL returns err along with the URL it happened on
+func WithURL(err error, url string) error {
+	return &Error{Err: err, URL: url}
+}
+
+// WithPath returns err along with the local path it happened on
+func WithPath(err error, path string) error {
+	return &Error{Err: err, Path: path}
+}
+
+// Error prefixes the message of the wrapped error with the pack, URL or path it happened on
+func (e *Error) Error() string {
+	for _, context := range []string{e.PackID, e.URL, e.Path} {
+		if context != "" {
+			return "\"" + context + "\": " + e.Err.Error()
+		}
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error, for errors.Is and errors.As
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Code returns the stable code of the wrapped error
+func (e *Error) Code() string {
+	return Code(e.Err)
+}
+
+// jsonError is the JSON representation of an error
+type jsonError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	PackID  string `json:"pack,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// MarshalJSON encodes the error as an object with its code, message and context
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonError{
+		Code:    e.Code(),
+		Message: e.Error(),
+		PackID:  e.PackID,
+		URL:     e.URL,
+		Path:    e.Path,
+		Hint:    Hint(e.Err),
+	})
+}
+
+// JSON encodes any error like Error.MarshalJSON does, with the
+// context of the first Error found in its chain, if any
+func JSON(err error) ([]byte, error) {
+	var e *Error
+	if errors.As(err, &e) {
+		return json.Marshal(jsonError{
+			Code:    Code(err),
+			Message: err.Error(),
+			PackID:  e.PackID,
+			URL:     e.URL,
+			Path:    e.Path,
+			Hint:    Hint(err),
+		})
+	}
+	return json.Marshal(jsonError{Code: Code(err), Message: err.Error(), Hint: Hint(err)})
+}
@@ -0,0 +1,59 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package errors_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("test error keeps the message format and unwraps to the sentinel", func(t *testing.T) {
+		err := errs.WithURL(errs.ErrBadRequest, "https://vendor.com/index.pidx")
+		assert.Equal("\"https://vendor.com/index.pidx\": bad request", err.Error())
+		assert.Equal(errs.ErrBadRequest, errors.Unwrap(err))
+		assert.True(errs.Is(err, errs.ErrBadRequest))
+		assert.False(errs.Is(err, errs.ErrFailedDownloadingFile))
+
+		var e *errs.Error
+		assert.True(errors.As(fmt.Errorf("wrapped: %w", err), &e))
+		assert.Equal("https://vendor.com/index.pidx", e.URL)
+	})
+
+	t.Run("test error without context", func(t *testing.T) {
+		err := &errs.Error{Err: errs.ErrFileNotFound}
+		assert.Equal(errs.ErrFileNotFound.Error(), err.Error())
+	})
+
+	t.Run("test error codes", func(t *testing.T) {
+		assert.Equal("PACK_NOT_INSTALLED", errs.Code(errs.ErrPackNotInstalled))
+		assert.Equal("FILE_NOT_FOUND", errs.Code(errs.WithPath(errs.ErrFileNotFound, "foo")))
+		assert.Equal("BAD_PACK_NAME", errs.WithPackID(errs.ErrBadPackName, "foo").(*errs.Error).Code())
+		assert.Equal("EULA_TIMEOUT", errs.Code(errs.ErrEulaTimeout))
+		assert.Equal(errs.CodeUnknown, errs.Code(errors.New("not from cpackget")))
+		assert.Equal(errs.CodeUnknown, errs.Code(nil))
+	})
+
+	t.Run("test errors encoded as json", func(t *testing.T) {
+		jsonErr, err := errs.JSON(errs.WithPackID(errs.ErrPackNotInstalled, "Vendor::Pack"))
+		assert.Nil(err)
+		assert.Equal(`{"code":"PACK_NOT_INSTALLED","message":"\"Vendor::Pack\": pack not installed","pack":"Vendor::Pack","hint":"Run \"cpackget list\" to see the installed packs"}`, string(jsonErr))
+
+		jsonErr, err = errs.JSON(errs.ErrTerminatedByUser)
+		assert.Nil(err)
+		assert.Equal(`{"code":"TERMINATED_BY_USER","message":"terminated by user request"}`, string(jsonErr))
+	})
+
+	t.Run("test error hints", func(t *testing.T) {
+		assert.Equal("Run \"cpackget init\" to create the pack root", errs.Hint(errs.WithPath(errs.ErrPackRootDoesNotExist, "foo")))
+		assert.Empty(errs.Hint(errs.ErrTerminatedByUser))
+		assert.Empty(errs.Hint(errors.New("not from cpackget")))
+	})
+}
@@ -0,0 +1,399 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// exportMarkerFile is the first entry of a pack root archive. It holds the
+// path the pack root was exported from, so that PDSC files added from within
+// the pack root can be relocated when importing it somewhere else.
+const exportMarkerFile = ".cpackget-export"
+
+// maxExportMarkerSize bounds the path held by exportMarkerFile
+const maxExportMarkerSize = 64 * 1024
+
+// isGzipArchive tells whether archivePath is a .tar.gz/.tgz or a plain .tar archive
+func isGzipArchive(archivePath string) (bool, error) {
+	name := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return true, nil
+	case strings.HasSuffix(name, ".tar"):
+		return false, nil
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		// Go's standard library has no Zstandard support and cpackget
+		// does not depend on any other implementation
+		log.Errorf("Zstandard compressed archives are not supported, use .tar.gz instead of \"%s\"", filepath.Base(archivePath))
+	}
+	return false, errs.ErrUnsupportedArchiveFormat
+}
+
+// ExportPackRoot snapshots the whole pack root in packRoot, i.e. packs, index
+// and cached files, into the tar archive in archivePath. Paths are stored
+// relative to the pack root with forward slashes.
+func ExportPackRoot(packRoot, archivePath string) error {
+	var err error
+	if packRoot, err = filepath.Abs(packRoot); err != nil {
+		return err
+	}
+	if archivePath, err = filepath.Abs(archivePath); err != nil {
+		return err
+	}
+
+	compress, err := isGzipArchive(archivePath)
+	if err != nil {
+		return err
+	}
+	if !utils.DirExists(packRoot) {
+		return errs.ErrPackRootDoesNotExist
+	}
+	if isWithin(packRoot, archivePath) {
+		return errs.ErrArchiveInsidePackRoot
+	}
+
+	log.Infof("Exporting pack root \"%s\" to \"%s\"", packRoot, archivePath)
+	err = utils.WriteAtomic(archivePath, utils.FileModeRW, func(writer io.Writer) error {
+		if compress {
+			gzipWriter := gzip.NewWriter(writer)
+			if err := writePackRootArchive(packRoot, gzipWriter); err != nil {
+				return err
+			}
+			return gzipWriter.Close()
+		}
+		return writePackRootArchive(packRoot, writer)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Pack root exported to \"%s\"", archivePath)
+	return nil
+}
+
+// writePackRootArchive writes every file and directory of packRoot as a tar stream
+func writePackRootArchive(packRoot string, writer io.Writer) error {
+	fsys := utils.GetFileSystem()
+	tarWriter := tar.NewWriter(writer)
+
+	marker := []byte(packRoot)
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Name:     exportMarkerFile,
+		Mode:     int64(utils.FileModeRW),
+		Size:     int64(len(marker)),
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	if _, err := tarWriter.Write(marker); err != nil {
+		return err
+	}
+
+	// Files hard linked to each other, e.g. through the content store, are
+	// written once and recorded as links to that entry after
+	canHardLink := utils.CanHardLink()
+	exportedFiles := map[utils.FileID]string{}
+
+	err := afero.Walk(fsys, packRoot, func(filePath string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if utils.ShouldAbortFunction != nil && utils.ShouldAbortFunction() {
+			return errs.ErrTerminatedByUser
+		}
+
+		rel, err := filepath.Rel(packRoot, filePath)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		// Packs added with --link are recorded as links to their folder
+		linkTarget := ""
+		if utils.IsLink(filePath) {
+			if linkTarget, err = utils.ReadLink(filePath); err != nil {
+				return err
+			}
+			log.Warnf("Exporting \"%s\" as link to \"%s\", which has to exist wherever the pack root is imported", filePath, linkTarget)
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			log.Warnf("Not exporting \"%s\", it is not a regular file", filePath)
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, linkTarget)
+		if err != nil {
+			return err
+		}
+		if linkTarget != "" {
+			// Windows junctions are no symbolic links to tar
+			header.Typeflag = tar.TypeSymlink
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+
+		if canHardLink && info.Mode().IsRegular() {
+			if id, links, err := utils.StatFileID(filePath); err == nil && links > 1 {
+				if exportedFile, ok := exportedFiles[id]; ok {
+					header.Typeflag = tar.TypeLink
+					header.Linkname = exportedFile
+					header.Size = 0
+					return tarWriter.WriteHeader(header)
+				}
+				exportedFiles[id] = header.Name
+			}
+		}
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() || linkTarget != "" {
+			return nil
+		}
+
+		log.Debugf("Exporting \"%s\"", filePath)
+		file, err := fsys.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tarWriter.Close()
+}
+
+// ImportPackRoot restores the pack root archive in archivePath, made by
+// ExportPackRoot, into packRoot, which must either not exist or be empty.
+// PDSC files added from within the exported pack root are referenced
+// from packRoot in .Local/local_repository.pidx.
+func ImportPackRoot(archivePath, packRoot string) error {
+	var err error
+	if packRoot, err = filepath.Abs(packRoot); err != nil {
+		return err
+	}
+
+	compress, err := isGzipArchive(archivePath)
+	if err != nil {
+		return err
+	}
+	if !utils.FileExists(archivePath) {
+		return errs.WithPath(errs.ErrFileNotFound, archivePath)
+	}
+	if utils.DirExists(packRoot) {
+		if !utils.IsEmpty(packRoot) {
+			return errs.WithPath(errs.ErrPackRootDestinationNotEmpty, packRoot)
+		}
+	} else if utils.FileExists(packRoot) {
+		return errs.WithPath(errs.ErrPathAlreadyExists, packRoot)
+	}
+
+	fsys := utils.GetFileSystem()
+	archive, err := fsys.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	var reader io.Reader = archive
+	if compress {
+		gzipReader, err := gzip.NewReader(archive)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	log.Infof("Importing pack root \"%s\" into \"%s\"", archivePath, packRoot)
+	if err := utils.EnsureDir(packRoot); err != nil {
+		return err
+	}
+
+	exportedPackRoot, err := readPackRootArchive(packRoot, reader)
+	if err != nil {
+		return err
+	}
+
+	if exportedPackRoot != "" {
+		if err := relocateLocalPdscs(exportedPackRoot, packRoot); err != nil {
+			return err
+		}
+	}
+
+	for _, dir := range []string{".Web", ".Local", ".Download", ""} {
+		utils.SetReadOnly(filepath.Join(packRoot, dir))
+	}
+
+	log.Infof("Pack root imported into \"%s\"", packRoot)
+	return nil
+}
+
+// readPackRootArchive extracts the tar stream in reader into packRoot and
+// returns the path the archive was exported from, if recorded
+func readPackRootArchive(packRoot string, reader io.Reader) (string, error) {
+	fsys := utils.GetFileSystem()
+	tarReader := tar.NewReader(reader)
+
+	// Directories stay writable until all files are extracted
+	type dirMode struct {
+		path string
+		mode fs.FileMode
+	}
+	dirModes := []dirMode{}
+	exportedPackRoot := ""
+
+	// Links of packs added with --link are made once everything else is
+	// extracted, so that no entry can be written through them
+	type link struct {
+		path   string
+		target string
+	}
+	links := []link{}
+
+	for {
+		if utils.ShouldAbortFunction != nil && utils.ShouldAbortFunction() {
+			return "", errs.ErrTerminatedByUser
+		}
+
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		name := path.Clean(strings.ReplaceAll(header.Name, "\\", "/"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || filepath.VolumeName(name) != "" {
+			return "", errs.WithPath(errs.ErrInsecureArchiveFileName, header.Name)
+		}
+
+		if name == exportMarkerFile {
+			if header.Size > maxExportMarkerSize {
+				return "", errs.WithPath(errs.ErrFileTooBig, header.Name)
+			}
+			marker, err := io.ReadAll(tarReader)
+			if err != nil {
+				return "", err
+			}
+			exportedPackRoot = string(marker)
+			continue
+		}
+
+		target := filepath.Join(packRoot, filepath.FromSlash(name))
+		mode := fs.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			dirModes = append(dirModes, dirMode{target, mode})
+			if err := fsys.MkdirAll(target, 0755); err != nil {
+				return "", err
+			}
+			if err := fsys.Chmod(target, 0755); err != nil {
+				return "", err
+			}
+		case tar.TypeReg:
+			log.Debugf("Importing \"%s\"", name)
+			if err := fsys.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return "", err
+			}
+			if err := extractArchiveFile(tarReader, target, mode); err != nil {
+				return "", err
+			}
+			if err := fsys.Chtimes(target, header.ModTime, header.ModTime); err != nil {
+				return "", err
+			}
+		case tar.TypeLink:
+			linkName := path.Clean(strings.ReplaceAll(header.Linkname, "\\", "/"))
+			if path.IsAbs(linkName) || linkName == ".." || strings.HasPrefix(linkName, "../") || filepath.VolumeName(linkName) != "" {
+				return "", errs.WithPath(errs.ErrInsecureArchiveFileName, header.Linkname)
+			}
+			source := filepath.Join(packRoot, filepath.FromSlash(linkName))
+			if !utils.FileExists(source) {
+				log.Warnf("Not importing \"%s\", it links to \"%s\", which is not in the archive", name, linkName)
+				continue
+			}
+			log.Debugf("Importing \"%s\" as link to \"%s\"", name, linkName)
+			if err := fsys.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return "", err
+			}
+			if err := utils.HardLink(source, target); err != nil {
+				return "", err
+			}
+		case tar.TypeSymlink:
+			// Only pack folders, <vendor>/<pack>/<version>, are linked
+			if strings.Count(name, "/") != 2 || strings.HasPrefix(name, ".") {
+				log.Warnf("Not importing \"%s\", only linked packs are", name)
+				continue
+			}
+			links = append(links, link{target, header.Linkname})
+		default:
+			log.Warnf("Not importing \"%s\", it is not a regular file", name)
+		}
+	}
+
+	for _, link := range links {
+		if !utils.DirExists(link.target) {
+			log.Warnf("\"%s\" links to \"%s\", which does not exist here", link.path, link.target)
+		}
+		log.Debugf("Linking \"%s\" to \"%s\"", link.path, link.target)
+		if err := fsys.MkdirAll(filepath.Dir(link.path), 0755); err != nil {
+			return "", err
+		}
+		if err := utils.LinkDir(link.target, link.path); err != nil {
+			return "", err
+		}
+	}
+
+	// Subdirectories first, so that parents are still writable
+	slices.Reverse(dirModes)
+	for _, dir := range dirModes {
+		if err := fsys.Chmod(dir.path, dir.mode); err != nil {
+			return "", err
+		}
+	}
+
+	return exportedPackRoot, nil
+}
+
+// extractArchiveFile writes the current archive entry in reader to target
+func extractArchiveFile(reader io.Reader, target string, mode fs.FileMode) error {
+	fsys := utils.GetFileSystem()
+
+	file, err := fsys.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, utils.FileModeRW)
+	if err != nil {
+		return err
+	}
+
+	_, err = utils.SecureCopy(file, reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return fsys.Chmod(target, mode)
+}
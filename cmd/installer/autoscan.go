@@ -0,0 +1,170 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"io"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"golang.org/x/mod/semver"
+)
+
+// scannedIndexFileName is where the index synthesized from a directory is
+// written to in .Download/ before replacing .Web/index.pidx
+const scannedIndexFileName = ".scanned-index.pidx"
+
+// localDirURL returns the file:// URL of dir, like the one of local PDSC files
+func localDirURL(dir string) string {
+	return "file://localhost/" + filepath.ToSlash(dir) + "/"
+}
+
+// fileURLPath returns the local path fileURL points to
+func fileURLPath(fileURL *url.URL) string {
+	filePath := fileURL.Path
+	if runtime.GOOS == "windows" && strings.HasPrefix(filePath, "/") {
+		filePath = filePath[1:]
+	}
+	return filepath.FromSlash(filePath)
+}
+
+// scanIndexDir synthesizes an index out of the PDSC and pack files in dir, so
+// that a shared folder can serve as public index. PDSC files are referenced
+// from dir. Packs without a PDSC file next to them have the PDSC file of
+// their latest version extracted into .Web/ and referenced from there.
+// It returns the path of the synthesized index.
+func scanIndexDir(dir string) (string, error) {
+	var err error
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", err
+	}
+	log.Infof("Scanning \"%s\" for pdsc and pack files", dir)
+
+	pidxXML := xml.NewPidxXML(filepath.Join(Installation.DownloadDir, scannedIndexFileName))
+	pidxXML.SchemaVersion = "1.1.0"
+	pidxXML.Vendor = filepath.Base(dir)
+	pidxXML.URL = localDirURL(dir)
+
+	pdscFiles, err := utils.ListDir(dir, `\.pdsc$`)
+	if err != nil {
+		return "", err
+	}
+
+	scanned := map[string]bool{}
+	for _, pdscFile := range pdscFiles {
+		pdscXML := xml.NewPdscXML(pdscFile)
+		if err := pdscXML.Read(); err != nil {
+			log.Warnf("Skipping \"%s\": %s", pdscFile, err)
+			continue
+		}
+		pidxXML.Pindex.Pdscs = append(pidxXML.Pindex.Pdscs, xml.PdscTag{
+			Vendor:  pdscXML.Vendor,
+			Name:    pdscXML.Name,
+			Version: pdscXML.LatestVersion(),
+			URL:     localDirURL(dir),
+		})
+		scanned[pdscXML.Vendor+"."+pdscXML.Name] = true
+	}
+
+	packFiles, err := utils.ListDir(dir, `\.pack$`)
+	if err != nil {
+		return "", err
+	}
+
+	// Only the latest version of each pack has its PDSC file extracted
+	type scannedPack struct {
+		utils.PackInfo
+		path string
+	}
+	latestPacks := map[string]scannedPack{}
+	for _, packFile := range packFiles {
+		info, err := utils.ExtractPackInfo(packFile)
+		if err != nil {
+			log.Warnf("Skipping \"%s\": %s", packFile, err)
+			continue
+		}
+		packID := info.Vendor + "." + info.Pack
+		if scanned[packID] {
+			continue
+		}
+		if latest, found := latestPacks[packID]; !found || semver.Compare("v"+info.Version, "v"+latest.Version) > 0 {
+			latestPacks[packID] = scannedPack{info, packFile}
+		}
+	}
+
+	packIDs := make([]string, 0, len(latestPacks))
+	for packID := range latestPacks {
+		packIDs = append(packIDs, packID)
+	}
+	sort.Strings(packIDs)
+
+	for _, packID := range packIDs {
+		pack := latestPacks[packID]
+		pdscFile := filepath.Join(Installation.WebDir, packID+".pdsc")
+		if err := extractPackPdsc(pack.path, pdscFile); err != nil {
+			log.Warnf("Skipping \"%s\": %s", pack.path, err)
+			continue
+		}
+		pidxXML.Pindex.Pdscs = append(pidxXML.Pindex.Pdscs, xml.PdscTag{
+			Vendor:  pack.Vendor,
+			Name:    pack.Pack,
+			Version: pack.Version,
+			URL:     localDirURL(Installation.WebDir),
+		})
+	}
+
+	log.Infof("Found %d pack(s) in \"%s\"", len(pidxXML.Pindex.Pdscs), dir)
+	if err := pidxXML.Write(); err != nil {
+		return "", err
+	}
+	return filepath.Join(Installation.DownloadDir, scannedIndexFileName), nil
+}
+
+// extractPackPdsc copies the PDSC file in packFile to pdscFile
+func extractPackPdsc(packFile, pdscFile string) error {
+	zipReader, err := utils.OpenZip(packFile)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	for _, file := range zipReader.File {
+		if filepath.Base(file.Name) != filepath.Base(pdscFile) {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		utils.UnsetReadOnly(pdscFile)
+		defer utils.SetReadOnly(pdscFile)
+		return utils.WriteAtomic(pdscFile, utils.FileModeRW, func(writer io.Writer) error {
+			_, err := utils.SecureCopy(writer, reader)
+			return err
+		})
+	}
+	return errs.ErrPdscFileNotFound
+}
+
+// scannedIndexPackPath returns the pack file in the directory the public
+// index was synthesized from, if any
+func scannedIndexPackPath(pack *PackType) (string, bool) {
+	indexURL, err := url.Parse(Installation.PublicIndexXML.URL)
+	if err != nil || indexURL.Scheme != "file" {
+		return "", false
+	}
+
+	packPath := filepath.Join(fileURLPath(indexURL), pack.Vendor+"."+pack.Name+"."+utils.SemverStripMeta(pack.targetVersion)+".pack")
+	return packPath, utils.FileExists(packPath)
+}
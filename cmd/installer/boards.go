@@ -0,0 +1,54 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// Board is a board described by an installed or cached pack
+type Board struct {
+	xml.Board
+
+	// Pack is the ID of the pack providing the board, e.g. Vendor::Name@1.2.3
+	Pack string `json:"pack"`
+
+	// Installed tells whether the providing pack is installed
+	Installed bool `json:"installed"`
+}
+
+// FindBoards returns the boards described by installed packs, and optionally
+// by packs whose PDSC file is cached in .Web/, whose vendor and name match
+// vendorPattern and namePattern, like FindDevices
+func FindBoards(includeCached bool, vendorPattern, namePattern string) ([]Board, error) {
+	pdscs, err := findPackPdscs(includeCached)
+	if err != nil {
+		return nil, err
+	}
+
+	boards := []Board{}
+	for _, pdsc := range pdscs {
+		for _, board := range pdsc.pdscXML.ListBoards() {
+			vendorMatches, err := matchPattern(vendorPattern, board.Vendor)
+			if err != nil {
+				return nil, err
+			}
+			nameMatches, err := matchPattern(namePattern, board.Name)
+			if err != nil {
+				return nil, err
+			}
+			if vendorMatches && nameMatches {
+				boards = append(boards, Board{board, pdsc.packID, pdsc.installed})
+			}
+		}
+	}
+
+	sort.SliceStable(boards, func(i, j int) bool {
+		return strings.ToLower(boards[i].Name) < strings.ToLower(boards[j].Name)
+	})
+	return boards, nil
+}
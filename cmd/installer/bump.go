@@ -0,0 +1,102 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/spf13/afero"
+)
+
+var releasesTagRegex = regexp.MustCompile(`<releases\b[^>]*>`)
+var releaseTagRegex = regexp.MustCompile(`<release\b`)
+
+// BumpRelease adds a release to the <releases> section of the PDSC file
+// pdscPath. Its version is the latest release's one with the "major",
+// "minor" or "patch" part incremented, its date is date (YYYY-MM-DD) and
+// description describes it. Releases are kept newest first, and the rest
+// of the file is left untouched. It returns the new version.
+func BumpRelease(pdscPath, part, date, description string) (string, error) {
+	log.Debugf("Bumping the %s version of \"%s\"", part, pdscPath)
+
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		log.Errorf("Invalid date \"%s\", use YYYY-MM-DD", date)
+		return "", errs.ErrIncorrectCmdArgs
+	}
+
+	if !utils.FileExists(pdscPath) {
+		return "", errs.WithPath(errs.ErrPdscFileNotFound, pdscPath)
+	}
+
+	pdscXML := xml.NewPdscXML(pdscPath)
+	if err := pdscXML.Read(); err != nil {
+		return "", err
+	}
+
+	// Do not rely on releases being ordered already
+	latest := ""
+	for _, release := range pdscXML.AllReleases() {
+		if latest == "" || utils.SemverCompare(release, latest) > 0 {
+			latest = release
+		}
+	}
+	if latest == "" {
+		return "", errs.WithPath(errs.ErrPackVersionNotFoundInPdsc, pdscPath)
+	}
+
+	version, err := utils.SemverBump(latest, part)
+	if err != nil {
+		return "", errs.WithPath(err, pdscPath)
+	}
+
+	fs := utils.GetFileSystem()
+	data, err := afero.ReadFile(fs, pdscPath)
+	if err != nil {
+		return "", err
+	}
+	content := string(data)
+
+	releases := releasesTagRegex.FindStringIndex(content)
+	if releases == nil {
+		return "", errs.WithPath(errs.ErrPackVersionNotFoundInPdsc, pdscPath)
+	}
+	first := releaseTagRegex.FindStringIndex(content[releases[1]:])
+	if first == nil {
+		return "", errs.WithPath(errs.ErrPackVersionNotFoundInPdsc, pdscPath)
+	}
+	position := releases[1] + first[0]
+
+	// The new release goes on its own line, indented like the one before it
+	separator := ""
+	indent := content[strings.LastIndex(content[:position], "\n")+1 : position]
+	if strings.TrimSpace(indent) == "" {
+		newline := "\n"
+		if strings.Contains(content, "\r\n") {
+			newline = "\r\n"
+		}
+		separator = newline + indent
+	}
+
+	escaper := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	release := fmt.Sprintf(`<release version="%s" date="%s">%s</release>`, version, date, escaper.Replace(description))
+	content = content[:position] + release + separator + content[position:]
+
+	info, err := fs.Stat(pdscPath)
+	if err != nil {
+		return "", err
+	}
+	if err := utils.WriteFileAtomic(pdscPath, []byte(content), info.Mode().Perm()); err != nil {
+		return "", err
+	}
+
+	log.Infof("Added release %s dated %s to \"%s\"", version, date, pdscPath)
+	return version, nil
+}
@@ -0,0 +1,161 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// CacheStatsName is the file in .Download/ counting how often packs were
+// served from it rather than downloaded. It is kept next to the cached
+// files, so that pack roots sharing a cache, see SetCacheDir, share it too.
+const CacheStatsName = "cache_stats.json"
+
+// CacheStats tells how well .Download/ serves the packs installed
+type CacheStats struct {
+	// Hits counts the packs installed from .Download/
+	Hits int `json:"hits"`
+
+	// Misses counts the packs downloaded to be installed
+	Misses int `json:"misses"`
+
+	// BytesSaved is the size of the packs installed from .Download/
+	BytesSaved int64 `json:"bytesSaved"`
+
+	// BytesDownloaded is the size of the packs downloaded to be installed
+	BytesDownloaded int64 `json:"bytesDownloaded"`
+
+	// Since is when the first hit or miss was counted
+	Since *time.Time `json:"since,omitempty"`
+
+	// Entries counts the pack and PDSC files in .Download/
+	Entries int `json:"entries"`
+
+	// Size is the size of the files in .Download/
+	Size int64 `json:"size"`
+
+	// Oldest and Newest are the files in .Download/ modified first and last
+	Oldest *CacheEntry `json:"oldest,omitempty"`
+	Newest *CacheEntry `json:"newest,omitempty"`
+}
+
+// CacheEntry is a file in .Download/
+type CacheEntry struct {
+	Name     string    `json:"name"`
+	Modified time.Time `json:"modified"`
+}
+
+// HitRate returns the share of installs served from .Download/, in percent
+func (s *CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) * 100 / float64(s.Hits+s.Misses)
+}
+
+// cacheStatsLock serializes the updates of CacheStatsName by concurrent installs
+var cacheStatsLock sync.Mutex
+
+// readCacheCounters reads the hits and misses counted in CacheStatsName so far
+func readCacheCounters() (*CacheStats, error) {
+	stats := &CacheStats{}
+	statsPath := filepath.Join(Installation.DownloadDir, CacheStatsName)
+	if !utils.FileExists(statsPath) {
+		return stats, nil
+	}
+	b, err := afero.ReadFile(utils.GetFileSystem(), statsPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, stats); err != nil {
+		log.Errorf("Can't parse \"%s\": %s", statsPath, err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// recordCacheUse counts a pack of size bytes that was served from
+// .Download/, if hit, or downloaded otherwise. Failing to count it
+// does not fail the install.
+func recordCacheUse(hit bool, size int64) {
+	cacheStatsLock.Lock()
+	defer cacheStatsLock.Unlock()
+
+	stats, err := readCacheCounters()
+	if err != nil {
+		log.Debugf("Not counting the cache use: %s", err)
+		return
+	}
+	if stats.Since == nil {
+		now := time.Now().UTC().Truncate(time.Second)
+		stats.Since = &now
+	}
+	if hit {
+		stats.Hits++
+		stats.BytesSaved += size
+	} else {
+		stats.Misses++
+		stats.BytesDownloaded += size
+	}
+
+	counters := CacheStats{
+		Hits:            stats.Hits,
+		Misses:          stats.Misses,
+		BytesSaved:      stats.BytesSaved,
+		BytesDownloaded: stats.BytesDownloaded,
+		Since:           stats.Since,
+	}
+	b, err := json.MarshalIndent(counters, "", "  ")
+	if err != nil {
+		log.Debugf("Not counting the cache use: %s", err)
+		return
+	}
+	statsPath := filepath.Join(Installation.DownloadDir, CacheStatsName)
+	if err := utils.WriteFileAtomic(statsPath, append(b, '\n'), utils.FileModeRW); err != nil {
+		log.Debugf("Not counting the cache use: %s", err)
+	}
+}
+
+// GetCacheStats returns how often packs were installed from .Download/
+// rather than downloaded, along with the files it currently holds
+func GetCacheStats() (*CacheStats, error) {
+	stats, err := readCacheCounters()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := utils.ListDir(Installation.DownloadDir, "")
+	if err != nil {
+		return nil, err
+	}
+	fsys := utils.GetFileSystem()
+	for _, file := range files {
+		if _, found := cachedFilePackID(filepath.Base(file)); !found || strings.HasSuffix(file, cachedDigestExtension) {
+			continue
+		}
+		info, err := fsys.Stat(file)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		stats.Entries++
+		stats.Size += info.Size()
+		entry := &CacheEntry{Name: filepath.Base(file), Modified: info.ModTime().UTC()}
+		if stats.Oldest == nil || entry.Modified.Before(stats.Oldest.Modified) {
+			stats.Oldest = entry
+		}
+		if stats.Newest == nil || entry.Modified.After(stats.Newest.Modified) {
+			stats.Newest = entry
+		}
+	}
+	return stats, nil
+}
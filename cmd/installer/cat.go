@@ -0,0 +1,116 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"archive/zip"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// IsPackFile tells whether packPath is a pack archive rather than a pack ID
+func IsPackFile(packPath string) bool {
+	ext := strings.ToLower(filepath.Ext(packPath))
+	return ext == ".pack" || ext == ".zip"
+}
+
+// CatPackFile writes fileName, a path relative to the pack's root folder, to
+// w without extracting anything else. pack is either a pack archive or the ID
+// of an installed pack, e.g. "Vendor.Pack" or "Vendor::Pack@1.2.3", its
+// latest installed version if none is given. An empty fileName is the PDSC file.
+func CatPackFile(pack, fileName string, w io.Writer) error {
+	info, err := utils.ExtractPackInfo(pack)
+	if err != nil {
+		return err
+	}
+	if fileName == "" {
+		fileName = info.Vendor + "." + info.Pack + ".pdsc"
+	}
+	fileName = path.Clean(strings.ReplaceAll(fileName, "\\", "/"))
+	if path.IsAbs(fileName) || fileName == ".." || strings.HasPrefix(fileName, "../") {
+		log.Errorf("\"%s\" is outside of the pack", fileName)
+		return errs.ErrInsecureZipFileName
+	}
+
+	if IsPackFile(pack) {
+		if !utils.FileExists(pack) {
+			log.Errorf("File \"%s\" doesn't exist", pack)
+			return errs.ErrFileNotFound
+		}
+		return catArchiveFile(pack, info.Vendor+"."+info.Pack+".pdsc", fileName, w)
+	}
+
+	installedPacks, err := findInstalledPacks(true, info.Version == "")
+	if err != nil {
+		return err
+	}
+	for _, installed := range installedPacks {
+		if installed.err != nil || installed.Vendor != info.Vendor || installed.Name != info.Pack || (info.Version != "" && installed.Version != info.Version) {
+			continue
+		}
+
+		filePath := filepath.Join(filepath.Dir(installed.pdscPath), filepath.FromSlash(fileName))
+		file, err := utils.GetFileSystem().Open(filePath)
+		if err != nil {
+			log.Errorf("\"%s\" not found in %s", fileName, installed.YamlPackID())
+			return errs.WithPath(errs.ErrFileNotFound, fileName)
+		}
+		defer file.Close()
+		_, err = utils.SecureCopy(w, file)
+		return err
+	}
+
+	log.Errorf("Pack \"%s\" is not installed", pack)
+	return errs.ErrPackNotInstalled
+}
+
+// archiveSubfolder returns the folder of a pack archive its files are
+// compressed in, the one of its PDSC file, or "." if there is none
+func archiveSubfolder(zipReader *zip.Reader, pdscFileName string) string {
+	for _, file := range zipReader.File {
+		name := strings.ReplaceAll(file.Name, "\\", "/")
+		if path.Base(name) == pdscFileName && strings.Count(name, "/") <= 1 {
+			return path.Dir(name)
+		}
+	}
+	return "."
+}
+
+// catArchiveFile writes fileName of the pack archive in packPath to w. Names
+// are relative to the folder of the pack's PDSC file, which packs may be
+// compressed in.
+func catArchiveFile(packPath, pdscFileName, fileName string, w io.Writer) error {
+	zipReader, err := utils.OpenZip(packPath)
+	if err != nil {
+		log.Errorf("Can't decompress \"%s\": %s", packPath, err)
+		return errs.ErrFailedDecompressingFile
+	}
+	defer zipReader.Close()
+
+	subfolder := archiveSubfolder(zipReader.Reader, pdscFileName)
+	for _, file := range zipReader.File {
+		name := strings.ReplaceAll(file.Name, "\\", "/")
+		if path.Join(subfolder, fileName) != name {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			log.Errorf("Entry \"%s\" of \"%s\" is corrupt: %s", file.Name, packPath, err)
+			return errs.ErrFailedDecompressingFile
+		}
+		defer reader.Close()
+		_, err = utils.SecureCopy(w, reader)
+		return err
+	}
+
+	log.Errorf("\"%s\" not found in \"%s\"", fileName, packPath)
+	return errs.WithPath(errs.ErrFileNotFound, fileName)
+}
@@ -0,0 +1,57 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// Component is a software component provided by an installed or cached pack
+type Component struct {
+	xml.Component
+
+	// ID is the component in Vendor::Class&Bundle:Group:Sub&Variant@Version format
+	ID string `json:"id"`
+
+	// Pack is the ID of the pack providing the component, e.g. Vendor::Name@1.2.3
+	Pack string `json:"pack"`
+
+	// Installed tells whether the providing pack is installed
+	Installed bool `json:"installed"`
+}
+
+// FindComponents returns the components provided by installed packs, and
+// optionally by packs whose PDSC file is cached in .Web/, whose Cclass and
+// Cgroup match classPattern and groupPattern, e.g. "CMSIS" and "RTOS*"
+func FindComponents(includeCached bool, classPattern, groupPattern string) ([]Component, error) {
+	pdscs, err := findPackPdscs(includeCached)
+	if err != nil {
+		return nil, err
+	}
+
+	components := []Component{}
+	for _, pdsc := range pdscs {
+		for _, component := range pdsc.pdscXML.ListComponents() {
+			classMatches, err := matchPattern(classPattern, component.Class)
+			if err != nil {
+				return nil, err
+			}
+			groupMatches, err := matchPattern(groupPattern, component.Group)
+			if err != nil {
+				return nil, err
+			}
+			if classMatches && groupMatches {
+				components = append(components, Component{component, component.ID(), pdsc.packID, pdsc.installed})
+			}
+		}
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		return strings.ToLower(components[i].ID) < strings.ToLower(components[j].ID)
+	})
+	return components, nil
+}
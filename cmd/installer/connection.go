@@ -0,0 +1,206 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"golang.org/x/sync/semaphore"
+)
+
+// VendorConnection is how the server of one of the vendor URLs of the index answered
+type VendorConnection struct {
+	// URL is the vendor URL, as in the index
+	URL string
+
+	// Vendors are the vendors whose packs are at URL
+	Vendors []string
+
+	// Err tells why URL could not be reached, nil if it could
+	Err error
+
+	// Latency is how long the server took to answer
+	Latency time.Duration
+
+	// Throughput is how many bytes per second the PDSC file was downloaded at
+	Throughput float64
+
+	// Slow tells whether the server will likely slow down installing packs
+	Slow bool
+}
+
+// SlowLatency is the latency above which a vendor server counts as slow
+var SlowLatency = 2 * time.Second
+
+// SlowThroughput is the throughput, in bytes per second, below which a vendor server counts as slow
+var SlowThroughput float64 = 50 * 1024
+
+// CheckVendorConnections downloads a PDSC file from every distinct vendor URL
+// of the public index, to tell which servers cannot be reached or are slow,
+// and lists them in a table
+func CheckVendorConnections(concurrency, timeout int) ([]VendorConnection, error) {
+	// Probe each URL with the PDSC file of the pack found there first by name,
+	// so that the same file is probed every time
+	probes := map[string]xml.PdscTag{}
+	vendors := map[string]map[string]bool{}
+	for _, pdscTag := range Installation.PublicIndexXML.ListPdscTags() {
+		probe, ok := probes[pdscTag.URL]
+		if !ok {
+			vendors[pdscTag.URL] = map[string]bool{}
+		}
+		if !ok || pdscTag.Vendor+"."+pdscTag.Name < probe.Vendor+"."+probe.Name {
+			probes[pdscTag.URL] = pdscTag
+		}
+		vendors[pdscTag.URL][pdscTag.Vendor] = true
+	}
+
+	connections := make([]VendorConnection, 0, len(probes))
+	for vendorURL := range probes {
+		connection := VendorConnection{URL: vendorURL}
+		for vendor := range vendors[vendorURL] {
+			connection.Vendors = append(connection.Vendors, vendor)
+		}
+		sort.Strings(connection.Vendors)
+		connections = append(connections, connection)
+	}
+	sort.Slice(connections, func(i, j int) bool { return connections[i].URL < connections[j].URL })
+
+	log.Infof("Checking %d vendor URLs of the public index", len(connections))
+
+	ctx := operationContext
+	concurrency = CheckConcurrency(concurrency)
+	sem := semaphore.NewWeighted(int64(concurrency))
+
+	for i := range connections {
+		if ctx.Err() != nil {
+			break
+		}
+		if concurrency == 0 {
+			connections[i].check(probes[connections[i].URL], timeout)
+		} else {
+			if err := sem.Acquire(ctx, 1); err != nil {
+				log.Errorf("Failed to acquire semaphore: %v", err)
+				break
+			}
+
+			go func(connection *VendorConnection) {
+				defer sem.Release(1)
+				connection.check(probes[connection.URL], timeout)
+			}(&connections[i])
+		}
+	}
+	// Wait for the running checks
+	if concurrency > 0 {
+		if err := sem.Acquire(context.Background(), int64(concurrency)); err != nil {
+			log.Errorf("Failed to acquire semaphore: %v", err)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil, utils.ContextError(ctx)
+	}
+
+	printVendorConnections(connections)
+	return connections, nil
+}
+
+// check downloads the PDSC file of pdscTag from the vendor URL
+func (c *VendorConnection) check(pdscTag xml.PdscTag, timeout int) {
+	pdscURL, err := url.Parse(c.URL)
+	if err != nil {
+		c.Err = err
+		return
+	}
+	if pdscURL.Scheme != "http" && pdscURL.Scheme != "https" {
+		c.Err = fmt.Errorf("not an HTTP(S) URL")
+		return
+	}
+	pdscURL.Path = path.Join(pdscURL.Path, pdscTag.Vendor+"."+pdscTag.Name+".pdsc")
+
+	ctx := operationContext
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, utils.RewriteURL(pdscURL.String()), nil)
+	if err != nil {
+		c.Err = err
+		return
+	}
+	req.Header.Set("User-Agent", utils.GetUserAgent())
+
+	client := http.Client{Transport: utils.DownloadTransport(req.URL.String())}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		c.Err = err
+		return
+	}
+	defer resp.Body.Close()
+	c.Latency = time.Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		c.Err = fmt.Errorf("%s answered %s", pdscURL, resp.Status)
+		return
+	}
+
+	size, err := io.Copy(io.Discard, resp.Body)
+	if err != nil {
+		c.Err = err
+		return
+	}
+	if elapsed := time.Since(start); elapsed > 0 {
+		c.Throughput = float64(size) / elapsed.Seconds()
+	}
+	c.Slow = c.Latency > SlowLatency || c.Throughput < SlowThroughput
+	log.Debugf("%s: %d bytes in %s, latency %s", pdscURL, size, time.Since(start), c.Latency)
+}
+
+// printVendorConnections lists connections in a table, slow or unreachable ones flagged
+func printVendorConnections(connections []VendorConnection) {
+	urlWidth := len("URL")
+	for _, connection := range connections {
+		if len(connection.URL) > urlWidth {
+			urlWidth = len(connection.URL)
+		}
+	}
+
+	log.Infof("%-*s  %10s  %12s  %-11s  %s", urlWidth, "URL", "LATENCY", "THROUGHPUT", "STATUS", "VENDORS")
+	slow, unreachable := 0, 0
+	for _, connection := range connections {
+		latency, throughput, status := "-", "-", "ok"
+		switch {
+		case connection.Err != nil:
+			status = "unreachable"
+			unreachable++
+		case connection.Slow:
+			status = "slow"
+			slow++
+		}
+		if connection.Err == nil {
+			latency = connection.Latency.Round(time.Millisecond).String()
+			throughput = utils.FormatBytes(uint64(connection.Throughput)) + "/s"
+		}
+		log.Infof("%-*s  %10s  %12s  %-11s  %s", urlWidth, connection.URL, latency, throughput, status, strings.Join(connection.Vendors, ", "))
+		if connection.Err != nil {
+			log.Debugf("%s: %s", connection.URL, connection.Err)
+		}
+	}
+
+	if slow > 0 || unreachable > 0 {
+		log.Warnf("%d vendor URLs are slow and %d cannot be reached, installing their packs will be slow or fail", slow, unreachable)
+	}
+}
@@ -0,0 +1,150 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// PackEntry is a file of a pack
+type PackEntry struct {
+	// Name is the path of the file relative to the folder of the pack's PDSC file, with forward slashes
+	Name string
+
+	// CompressedSize is the size of the file in the pack archive, 0 if listed from the pack root
+	CompressedSize uint64
+
+	// Size is the uncompressed size of the file
+	Size uint64
+}
+
+// MatchPackEntry tells whether the entry called name matches the glob
+// pattern. Patterns without a slash match the base name of entries, and an
+// empty pattern matches all of them.
+func MatchPackEntry(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
+
+// PackContents lists the files of pack matching the glob pattern, sorted by
+// name, and where they were listed from. pack is either a pack archive or the
+// ID of a pack, e.g. "Vendor.Pack" or "Vendor::Pack@1.2.3", listed from its
+// archive in .Download/ if cached, from the pack root otherwise. Packs without
+// a version default to their latest installed one.
+func PackContents(pack, pattern string) ([]PackEntry, string, error) {
+	if IsPackFile(pack) {
+		if !utils.FileExists(pack) {
+			log.Errorf("File \"%s\" doesn't exist", pack)
+			return nil, "", errs.ErrFileNotFound
+		}
+		entries, err := archiveContents(pack, pattern)
+		return entries, pack, err
+	}
+
+	info, err := utils.ExtractPackInfo(pack)
+	if err != nil {
+		return nil, "", err
+	}
+
+	installedDir := ""
+	installedPacks, err := findInstalledPacks(true, info.Version == "")
+	if err != nil {
+		return nil, "", err
+	}
+	for _, installed := range installedPacks {
+		if installed.err == nil && installed.Vendor == info.Vendor && installed.Name == info.Pack && (info.Version == "" || installed.Version == info.Version) {
+			installedDir = filepath.Dir(installed.pdscPath)
+			info.Version = installed.Version
+			break
+		}
+	}
+
+	if info.Version != "" {
+		archivePath := filepath.Join(Installation.DownloadDir, info.Vendor+"."+info.Pack+"."+info.Version+".pack")
+		if utils.FileExists(archivePath) {
+			entries, err := archiveContents(archivePath, pattern)
+			return entries, archivePath, err
+		}
+	}
+
+	if installedDir == "" {
+		log.Errorf("Pack \"%s\" is neither installed nor cached", pack)
+		return nil, "", errs.ErrPackNotInstalled
+	}
+	entries, err := treeContents(installedDir, pattern)
+	return entries, installedDir, err
+}
+
+// archiveContents lists the files of the pack archive in packPath matching pattern
+func archiveContents(packPath, pattern string) ([]PackEntry, error) {
+	info, err := utils.ExtractPackInfo(packPath)
+	if err != nil {
+		return nil, err
+	}
+	zipReader, err := utils.OpenZip(packPath)
+	if err != nil {
+		log.Errorf("Can't decompress \"%s\": %s", packPath, err)
+		return nil, errs.ErrFailedDecompressingFile
+	}
+	defer zipReader.Close()
+
+	subfolder := archiveSubfolder(zipReader.Reader, info.Vendor+"."+info.Pack+".pdsc")
+	entries := []PackEntry{}
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		name := strings.ReplaceAll(file.Name, "\\", "/")
+		if subfolder != "." {
+			name = strings.TrimPrefix(name, subfolder+"/")
+		}
+		if MatchPackEntry(pattern, name) {
+			entries = append(entries, PackEntry{Name: name, CompressedSize: file.CompressedSize64, Size: file.UncompressedSize64})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// treeContents lists the files of the pack folder in dir matching pattern
+func treeContents(dir, pattern string) ([]PackEntry, error) {
+	entries := []PackEntry{}
+	err := afero.Walk(utils.GetFileSystem(), dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); MatchPackEntry(pattern, name) {
+			entries = append(entries, PackEntry{Name: name, Size: uint64(info.Size())})
+		}
+		return nil
+	})
+	if err != nil && !errs.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
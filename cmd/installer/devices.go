@@ -0,0 +1,152 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// Device is a device supported by an installed or cached pack
+type Device struct {
+	xml.Device
+
+	// Pack is the ID of the pack providing the device, e.g. Vendor::Name@1.2.3
+	Pack string `json:"pack"`
+
+	// Installed tells whether the providing pack is installed
+	Installed bool `json:"installed"`
+}
+
+// packPdsc is the PDSC file of an installed or cached pack
+type packPdsc struct {
+	pdscXML   *xml.PdscXML
+	packID    string
+	installed bool
+}
+
+// findPackPdscs reads the PDSC files of installed packs, and optionally the
+// ones cached in .Web/ of packs not installed
+func findPackPdscs(includeCached bool) ([]packPdsc, error) {
+	installedPacks, err := findInstalledPacks(true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	pdscs := []packPdsc{}
+	installed := map[string]bool{}
+	for _, pack := range installedPacks {
+		if pack.err != nil {
+			continue
+		}
+		pdscXML := xml.NewPdscXML(pack.pdscPath)
+		if err := pdscXML.Read(); err != nil {
+			log.Warnf("Skipping \"%s\": %s", pack.pdscPath, err)
+			continue
+		}
+		pdscs = append(pdscs, packPdsc{pdscXML, pack.YamlPackID(), true})
+		installed[pack.Vendor+"."+pack.Name] = true
+	}
+
+	if includeCached {
+		matches, err := utils.GlobIn(Installation.WebDir, "*.pdsc")
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			pdscXML := xml.NewPdscXML(match)
+			if err := pdscXML.Read(); err != nil {
+				log.Warnf("Skipping \"%s\": %s", match, err)
+				continue
+			}
+			if installed[pdscXML.Vendor+"."+pdscXML.Name] {
+				continue
+			}
+			tag := pdscXML.Tag()
+			pdscs = append(pdscs, packPdsc{pdscXML, tag.YamlPackID(), false})
+		}
+	}
+
+	return pdscs, nil
+}
+
+// matchPattern tells whether value matches the shell pattern, ignoring case.
+// An empty pattern matches everything.
+func matchPattern(pattern, value string) (bool, error) {
+	if pattern == "" {
+		return true, nil
+	}
+	return path.Match(strings.ToLower(pattern), strings.ToLower(value))
+}
+
+// FindDevices returns the devices supported by installed packs, and
+// optionally by packs whose PDSC file is cached in .Web/, whose vendor
+// and name match vendorPattern and namePattern, e.g. "STM32F4*"
+func FindDevices(includeCached bool, vendorPattern, namePattern string) ([]Device, error) {
+	pdscs, err := findPackPdscs(includeCached)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := []Device{}
+	for _, pdsc := range pdscs {
+		for _, device := range pdsc.pdscXML.ListDevices() {
+			vendorMatches, err := matchPattern(vendorPattern, device.Vendor)
+			if err != nil {
+				return nil, err
+			}
+			nameMatches, err := matchPattern(namePattern, device.Name)
+			if err != nil {
+				return nil, err
+			}
+			if vendorMatches && nameMatches {
+				devices = append(devices, Device{device, pdsc.packID, pdsc.installed})
+			}
+		}
+	}
+
+	sort.SliceStable(devices, func(i, j int) bool {
+		return strings.ToLower(devices[i].Name) < strings.ToLower(devices[j].Name)
+	})
+	return devices, nil
+}
+
+// DeviceTreePack is the device hierarchy described by a pack
+type DeviceTreePack struct {
+	// Pack is the ID of the pack, e.g. Vendor::Name@1.2.3
+	Pack string `json:"pack"`
+
+	// Installed tells whether the pack is installed
+	Installed bool `json:"installed"`
+
+	Families []xml.DeviceTreeFamily `json:"families"`
+}
+
+// FindDeviceTree returns the device hierarchy of installed packs, and
+// optionally of packs whose PDSC file is cached in .Web/. Packs describing
+// no devices are left out.
+func FindDeviceTree(includeCached bool) ([]DeviceTreePack, error) {
+	pdscs, err := findPackPdscs(includeCached)
+	if err != nil {
+		return nil, err
+	}
+
+	packs := []DeviceTreePack{}
+	for _, pdsc := range pdscs {
+		families := pdsc.pdscXML.DeviceTree()
+		if len(families) > 0 {
+			packs = append(packs, DeviceTreePack{pdsc.packID, pdsc.installed, families})
+		}
+	}
+
+	sort.SliceStable(packs, func(i, j int) bool {
+		return strings.ToLower(packs[i].Pack) < strings.ToLower(packs[j].Pack)
+	})
+	return packs, nil
+}
@@ -0,0 +1,216 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// The changes of a file between two packs
+const (
+	PackFileAdded   = "added"
+	PackFileRemoved = "removed"
+	PackFileChanged = "changed"
+)
+
+// PackDiff is a file differing between two packs
+type PackDiff struct {
+	// Name is the path of the file relative to the folder of the pack's PDSC file, with forward slashes
+	Name string
+
+	// Change is PackFileAdded, PackFileRemoved or PackFileChanged
+	Change string
+}
+
+// dottedVersionRegex matches pack IDs like Vendor.Pack@x.y.z
+var dottedVersionRegex = regexp.MustCompile(`^([^.:@]+)\.([^@]+@.+)$`)
+
+// DiffPacks compares the files of two packs by name and SHA-256 hash, sorted
+// by name. Packs are either pack archives or pack IDs, e.g. "Vendor.Pack@1.2.3"
+// or "Vendor::Pack@1.2.3", taken from .Download/ if cached, from the pack root
+// if installed, and downloaded otherwise. Pack IDs without a version default
+// to their latest installed version.
+func DiffPacks(oldPack, newPack string, timeout int) ([]PackDiff, error) {
+	oldDigests, err := packDigests(oldPack, timeout)
+	if err != nil {
+		return nil, err
+	}
+	newDigests, err := packDigests(newPack, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []PackDiff{}
+	for name, oldDigest := range oldDigests {
+		if newDigest, ok := newDigests[name]; !ok {
+			diffs = append(diffs, PackDiff{Name: name, Change: PackFileRemoved})
+		} else if newDigest != oldDigest {
+			diffs = append(diffs, PackDiff{Name: name, Change: PackFileChanged})
+		}
+	}
+	for name := range newDigests {
+		if _, ok := oldDigests[name]; !ok {
+			diffs = append(diffs, PackDiff{Name: name, Change: PackFileAdded})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs, nil
+}
+
+// packDigests returns the SHA-256 hashes of the files of pack by name
+func packDigests(pack string, timeout int) (map[string]string, error) {
+	source, err := findPackSource(pack, timeout)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Hashing the files of \"%s\"", source)
+	if IsPackFile(source) {
+		return archiveDigests(source)
+	}
+	return treeDigests(source)
+}
+
+// findPackSource returns the pack archive or the installed folder of pack,
+// downloading the pack if it is neither cached nor installed
+func findPackSource(pack string, timeout int) (string, error) {
+	if IsPackFile(pack) {
+		if !utils.FileExists(pack) {
+			log.Errorf("File \"%s\" doesn't exist", pack)
+			return "", errs.ErrFileNotFound
+		}
+		return pack, nil
+	}
+
+	if matches := dottedVersionRegex.FindStringSubmatch(pack); matches != nil && !strings.Contains(pack, "::") {
+		pack = matches[1] + "::" + matches[2]
+	}
+	info, err := utils.ExtractPackInfo(pack)
+	if err != nil {
+		return "", err
+	}
+
+	installedDir := ""
+	installedPacks, err := findInstalledPacks(true, info.Version == "")
+	if err != nil {
+		return "", err
+	}
+	for _, installed := range installedPacks {
+		if installed.err == nil && installed.Vendor == info.Vendor && installed.Name == info.Pack && (info.Version == "" || installed.Version == info.Version) {
+			installedDir = filepath.Dir(installed.pdscPath)
+			info.Version = installed.Version
+			break
+		}
+	}
+	if info.Version == "" {
+		log.Errorf("Pack \"%s\" is not installed, give its version", pack)
+		return "", errs.ErrPackNotInstalled
+	}
+
+	archivePath := filepath.Join(Installation.DownloadDir, info.Vendor+"."+info.Pack+"."+info.Version+".pack")
+	if utils.FileExists(archivePath) {
+		return archivePath, nil
+	}
+	if installedDir != "" {
+		return installedDir, nil
+	}
+
+	packType, err := preparePack(info.Vendor+"."+info.Pack+"."+info.Version, false, false, false, timeout)
+	if err != nil {
+		return "", err
+	}
+	if packType.path, err = FindPackURL(packType); err != nil {
+		return "", err
+	}
+	if err = packType.fetch(timeout); err != nil {
+		return "", err
+	}
+	if err = packType.verifyPublishedHash(timeout); err != nil {
+		return "", err
+	}
+	if err = packType.verifyPinnedSigner(); err != nil {
+		return "", err
+	}
+	// Keep it cached under the name adding the pack would give it
+	if packType.path != archivePath {
+		if err = utils.MoveFile(packType.path, archivePath); err != nil {
+			return "", err
+		}
+	}
+	return archivePath, nil
+}
+
+// archiveDigests hashes the files of the pack archive in packPath
+func archiveDigests(packPath string) (map[string]string, error) {
+	info, err := utils.ExtractPackInfo(packPath)
+	if err != nil {
+		return nil, err
+	}
+	zipReader, err := utils.OpenZip(packPath)
+	if err != nil {
+		log.Errorf("Can't decompress \"%s\": %s", packPath, err)
+		return nil, errs.ErrFailedDecompressingFile
+	}
+	defer zipReader.Close()
+
+	subfolder := archiveSubfolder(zipReader.Reader, info.Vendor+"."+info.Pack+".pdsc")
+	digests := map[string]string{}
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		name := strings.ReplaceAll(file.Name, "\\", "/")
+		if subfolder != "." {
+			name = strings.TrimPrefix(name, subfolder+"/")
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			log.Errorf("Entry \"%s\" of \"%s\" is corrupt: %s", file.Name, packPath, err)
+			return nil, errs.ErrFailedDecompressingFile
+		}
+		h := sha256.New()
+		_, err = utils.SecureCopy(h, reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		digests[name] = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	return digests, nil
+}
+
+// treeDigests hashes the files of the pack folder in dir
+func treeDigests(dir string) (map[string]string, error) {
+	digests := map[string]string{}
+	err := afero.Walk(utils.GetFileSystem(), dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		digest, err := utils.FileSHA256(filePath)
+		if err != nil {
+			return err
+		}
+		digests[filepath.ToSlash(rel)] = digest
+		return nil
+	})
+	return digests, err
+}
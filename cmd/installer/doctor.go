@@ -0,0 +1,267 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// EnvironmentCheck is the outcome of one of the checks of CheckEnvironment
+type EnvironmentCheck struct {
+	// Name tells what was checked, e.g. "pack root"
+	Name string
+
+	// Detail tells what was found
+	Detail string
+
+	// Problem describes what is wrong, empty if nothing is
+	Problem string
+
+	// Hint tells how to fix the problem
+	Hint string
+}
+
+// MaxClockSkew is how far the local clock can be off before
+// certificates and signatures might be wrongly rejected
+var MaxClockSkew = 5 * time.Minute
+
+// MinFreeDiskSpace is the free disk space below which adding packs will likely fail
+var MinFreeDiskSpace uint64 = 1024 * 1024 * 1024
+
+// environmentCheckTimeout is the timeout in seconds of CheckEnvironment when none is given
+const environmentCheckTimeout = 10
+
+// proxyEnvVars are the environment variables Go takes the proxy settings from
+var proxyEnvVars = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy"}
+
+// CheckEnvironment checks whether cpackget can work with packRoot, possibly a
+// search path, from this machine: whether it exists and is writable, the proxy
+// settings, the PDSC files packs were added from, the reachability of the
+// index host, the clock and the disk space.
+// A timeout of 0 waits for the index host for environmentCheckTimeout seconds.
+func CheckEnvironment(packRoot string, timeout int) []EnvironmentCheck {
+	checks := []EnvironmentCheck{}
+	if timeout == 0 {
+		timeout = environmentCheckTimeout
+	}
+
+	packRoots := []string{}
+	for _, root := range filepath.SplitList(packRoot) {
+		if root != "" {
+			packRoots = append(packRoots, filepath.Clean(root))
+		}
+	}
+
+	indexURL := KeilDefaultPackRoot + "index.pidx"
+	writableRoot := ""
+	if len(packRoots) == 0 {
+		checks = append(checks, EnvironmentCheck{
+			Name:    "pack root",
+			Problem: "no pack root is set",
+			Hint:    "Set CMSIS_PACK_ROOT or use --pack-root",
+		})
+	}
+	for _, root := range packRoots {
+		if indexPath := filepath.Join(root, ".Web", "index.pidx"); utils.FileExists(indexPath) {
+			pidx := xml.NewPidxXML(indexPath)
+			if err := pidx.Read(); err == nil && pidx.URL != "" {
+				indexURL = strings.TrimSuffix(pidx.URL, "/") + "/index.pidx"
+			}
+		}
+
+		check := checkPackRoot(root)
+		checks = append(checks, check)
+		if check.Problem != "" {
+			continue
+		}
+		checks = append(checks, checkLocalPdscs(root))
+
+		if writableRoot == "" {
+			permissions := checkPackRootPermissions(root)
+			checks = append(checks, permissions)
+			if permissions.Problem == "" {
+				writableRoot = root
+			}
+		}
+	}
+
+	checks = append(checks, checkProxy()...)
+	checks = append(checks, checkIndexHost(indexURL, timeout)...)
+
+	if writableRoot != "" {
+		checks = append(checks, checkDiskSpace(writableRoot))
+	}
+
+	return checks
+}
+
+// checkPackRoot checks that packRoot exists and has an index
+func checkPackRoot(packRoot string) EnvironmentCheck {
+	check := EnvironmentCheck{Name: "pack root", Detail: packRoot}
+	initHint := fmt.Sprintf("Run \"cpackget init --pack-root %s %sindex.pidx\"", packRoot, KeilDefaultPackRoot)
+	if !utils.DirExists(packRoot) {
+		check.Problem = fmt.Sprintf("\"%s\" does not exist", packRoot)
+		check.Hint = initHint
+		return check
+	}
+
+	for _, dir := range []string{".Download", ".Local", ".Web"} {
+		if !utils.DirExists(filepath.Join(packRoot, dir)) {
+			check.Problem = fmt.Sprintf("\"%s\" is missing its %s folder", packRoot, dir)
+			check.Hint = initHint
+			return check
+		}
+	}
+
+	if !utils.FileExists(filepath.Join(packRoot, ".Web", "index.pidx")) {
+		check.Problem = fmt.Sprintf("\"%s\" has no public index", packRoot)
+		check.Hint = "Run \"cpackget update-index\""
+	}
+	return check
+}
+
+// checkLocalPdscs checks that the PDSC files of the packs added via PDSC
+// file to packRoot still exist
+func checkLocalPdscs(packRoot string) EnvironmentCheck {
+	check := EnvironmentCheck{Name: "local packs"}
+	localPidxPath := filepath.Join(packRoot, ".Local", "local_repository.pidx")
+	if !utils.FileExists(localPidxPath) {
+		check.Detail = "no pack is added via PDSC file"
+		return check
+	}
+	localPidx := xml.NewPidxXML(localPidxPath)
+	if err := localPidx.Read(); err != nil {
+		check.Problem = fmt.Sprintf("cannot read \"%s\": %s", localPidxPath, err)
+		check.Hint = fmt.Sprintf("Remove \"%s\" and add the PDSC files again", localPidxPath)
+		return check
+	}
+
+	check.Detail = fmt.Sprintf("%d pack(s) added via PDSC file", len(localPidx.ListPdscTags()))
+	stale := staleLocalPdscs(localPidx)
+	if len(stale) > 0 {
+		missing := []string{}
+		for _, pdsc := range stale {
+			missing = append(missing, pdsc.PdscPath)
+		}
+		check.Problem = fmt.Sprintf("%d PDSC file(s) added to \"%s\" no longer exist: %s", len(stale), packRoot, strings.Join(missing, ", "))
+		check.Hint = "Run \"cpackget rm --stale\""
+	}
+	return check
+}
+
+// checkPackRootPermissions checks that packs can be added to packRoot
+func checkPackRootPermissions(packRoot string) EnvironmentCheck {
+	check := EnvironmentCheck{Name: "permissions", Detail: packRoot + " is writable"}
+	for _, dir := range []string{packRoot, filepath.Join(packRoot, ".Download"), filepath.Join(packRoot, ".Local"), filepath.Join(packRoot, ".Web")} {
+		if !utils.IsWritableDir(dir) {
+			check.Detail = ""
+			check.Problem = fmt.Sprintf("\"%s\" is not writable", dir)
+			check.Hint = fmt.Sprintf("Make the current user own \"%s\" and everything in it", packRoot)
+			break
+		}
+	}
+	return check
+}
+
+// checkProxy checks that the proxy settings are URLs
+func checkProxy() []EnvironmentCheck {
+	checks := []EnvironmentCheck{}
+	for _, envVar := range proxyEnvVars {
+		value, ok := os.LookupEnv(envVar)
+		if !ok || value == "" {
+			continue
+		}
+
+		check := EnvironmentCheck{Name: "proxy", Detail: envVar + "=" + value}
+		if !strings.HasPrefix(strings.ToUpper(envVar), "NO_") {
+			proxyURL, err := url.Parse(value)
+			if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+				check.Problem = fmt.Sprintf("%s is not a URL", envVar)
+				check.Hint = fmt.Sprintf("Set %s to a URL like http://proxy.example.com:8080", envVar)
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	if len(checks) == 0 {
+		checks = append(checks, EnvironmentCheck{Name: "proxy", Detail: "no proxy is set"})
+	}
+	return checks
+}
+
+// checkIndexHost checks that the host of indexURL can be reached, and the
+// local clock against the time it answers with
+func checkIndexHost(indexURL string, timeout int) []EnvironmentCheck {
+	indexURL = utils.RewriteURL(indexURL)
+	parsedURL, err := url.Parse(indexURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return []EnvironmentCheck{{Name: "index host", Detail: indexURL + " is not downloaded"}}
+	}
+
+	check := EnvironmentCheck{Name: "index host", Detail: parsedURL.Host}
+	client := http.Client{
+		Transport: utils.DownloadTransport(indexURL),
+		Timeout:   time.Duration(timeout) * time.Second,
+	}
+	start := time.Now()
+	resp, err := client.Head(indexURL)
+	if err != nil {
+		check.Problem = fmt.Sprintf("cannot reach %s: %s", parsedURL.Host, err)
+		check.Hint = "Check the network connection and the proxy settings"
+		var unknownAuthority x509.UnknownAuthorityError
+		var certificateInvalid x509.CertificateInvalidError
+		var hostname x509.HostnameError
+		if errors.As(err, &unknownAuthority) || errors.As(err, &certificateInvalid) || errors.As(err, &hostname) {
+			check.Hint = "Add the certificate of the server, or of the proxy intercepting HTTPS, to the trusted ones, e.g. with SSL_CERT_FILE"
+		}
+		return []EnvironmentCheck{check}
+	}
+	resp.Body.Close()
+	check.Detail = fmt.Sprintf("%s answered in %s", parsedURL.Host, time.Since(start).Round(time.Millisecond))
+	if parsedURL.Scheme != "https" {
+		check.Detail += ", without HTTPS"
+	}
+
+	clock := EnvironmentCheck{Name: "clock", Detail: "no time to compare with"}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		skew := time.Since(date).Round(time.Second)
+		if skew < 0 {
+			skew = -skew
+		}
+		clock.Detail = fmt.Sprintf("%s off from %s", skew, parsedURL.Host)
+		if skew > MaxClockSkew {
+			clock.Problem = fmt.Sprintf("the clock is %s off from %s", skew, parsedURL.Host)
+			clock.Hint = "Synchronize the clock, certificates and signatures are checked against it"
+		}
+	}
+	return []EnvironmentCheck{check, clock}
+}
+
+// checkDiskSpace checks that packs still fit on the volume of packRoot
+func checkDiskSpace(packRoot string) EnvironmentCheck {
+	check := EnvironmentCheck{Name: "disk space"}
+	free, err := utils.FreeDiskSpace(packRoot)
+	if err != nil {
+		check.Detail = fmt.Sprintf("cannot tell the free disk space: %s", err)
+		return check
+	}
+
+	check.Detail = utils.FormatBytes(free) + " free"
+	if free < MinFreeDiskSpace {
+		check.Problem = fmt.Sprintf("only %s are free in \"%s\"", utils.FormatBytes(free), packRoot)
+		check.Hint = "Free some space, e.g. with \"cpackget cache prune\""
+	}
+	return check
+}
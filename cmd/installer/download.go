@@ -0,0 +1,91 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path/filepath"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// DownloadPack fetches the pack file packPath refers to into .Download/
+// and verifies it like AddPack does, without extracting it: against the
+// hash published by its vendor and the keys pinned for its vendor, if any,
+// its files against their checksums in the archive and its PDSC file
+// against its version. Downloaded packs failing verification are removed.
+// It returns the path of the pack file in .Download/.
+func DownloadPack(packPath string, timeout int) (string, error) {
+	log.Debugf("Downloading pack \"%s\"", packPath)
+
+	if filepath.Ext(packPath) == ".pdsc" {
+		log.Errorf("\"%s\" is a PDSC file, only packs can be downloaded", packPath)
+		return "", errs.ErrBadPackName
+	}
+
+	pack, err := preparePack(packPath, false, false, false, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	if cachedPath, cached := pack.cachedPack(); cached {
+		log.Infof("Using \"%s\" from the cache", cachedPath)
+		pack.path = cachedPath
+		pack.targetVersion = pack.Version
+		pack.isDownloaded = true
+	} else if pack.isPackID {
+		if pack.path, err = FindPackURL(pack); err != nil {
+			return "", err
+		}
+	}
+
+	if err = pack.fetch(timeout); err != nil {
+		return "", err
+	}
+	if err = pack.verifyPublishedHash(timeout); err != nil {
+		return "", err
+	}
+	if err = pack.verifyPinnedSigner(); err != nil {
+		return "", err
+	}
+	if err = pack.verifyArchive(); err != nil {
+		if pack.isDownloaded {
+			_ = utils.GetFileSystem().Remove(pack.path)
+		}
+		return "", err
+	}
+
+	// Local pack files are staged into .Download/ once verified
+	downloadPath := pack.path
+	if !pack.isDownloaded {
+		downloadPath = filepath.Join(Installation.DownloadDir, pack.PackFileName())
+		if err = utils.CopyFile(pack.path, downloadPath); err != nil {
+			return "", err
+		}
+		recordCachedDigest(downloadPath)
+	}
+
+	log.Infof("Downloaded and verified %s to \"%s\"", pack.PackIDWithVersion(), downloadPath)
+	return downloadPath, nil
+}
+
+// verifyArchive checks the fetched pack file can be installed: all its files
+// match their checksums in the archive, and it holds the PDSC file of its
+// version
+func (p *PackType) verifyArchive() error {
+	var err error
+	p.zipReader, err = utils.OpenZip(p.path)
+	if err != nil {
+		log.Errorf("Can't decompress \"%s\": %s", p.path, err)
+		return errs.ErrFailedDecompressingFile
+	}
+	defer p.zipReader.Close()
+
+	if entry, entryErr := utils.CorruptZipEntry(p.zipReader.Reader); entry != "" {
+		log.Errorf("Entry \"%s\" of \"%s\" is corrupt: %s", entry, p.path, entryErr)
+		return errs.ErrFailedDecompressingFile
+	}
+	return p.validate()
+}
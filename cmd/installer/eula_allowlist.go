@@ -0,0 +1,59 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"bufio"
+	"encoding/hex"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// EulaAllowlisted is a license accepted because its hash is in the
+// allowlist, see SetEulaAllowlist
+const EulaAllowlisted = "allowlist"
+
+// eulaAllowlist holds the SHA-256 hashes of the pre-approved licenses
+var eulaAllowlist map[string]bool
+
+// SetEulaAllowlist makes the licenses whose text has one of the hex SHA-256
+// hashes accepted without prompting. Other licenses are still prompted for.
+func SetEulaAllowlist(hashes map[string]bool) {
+	eulaAllowlist = hashes
+}
+
+func GetEulaAllowlist() map[string]bool {
+	return eulaAllowlist
+}
+
+// ReadEulaAllowlist reads the SHA-256 hashes of pre-approved licenses, one
+// per line and "#" starting comments, from fileName. Whatever follows a hash
+// on its line is ignored, so that the output of sha256sum can be used as is.
+func ReadEulaAllowlist(fileName string) (map[string]bool, error) {
+	file, err := utils.GetFileSystem().Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hashes := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		hash := strings.ToLower(strings.TrimPrefix(fields[0], "sha256:"))
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 64 {
+			log.Errorf("%s:%d: \"%s\" is not a SHA-256 hash", fileName, lineNumber, fields[0])
+			return nil, errs.ErrInvalidEulaAllowlist
+		}
+		hashes[hash] = true
+	}
+	return hashes, scanner.Err()
+}
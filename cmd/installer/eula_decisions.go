@@ -0,0 +1,136 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// EulaDecisionsName is the file in .Local/ holding the EulaDecision of
+// every embedded license presented or agreed with in the pack root
+const EulaDecisionsName = "eula_decisions.json"
+
+// Ways an EulaDecision was taken
+const (
+	// EulaPrompted is a decision of the user, or of the CI mode, when prompted
+	EulaPrompted = "prompt"
+
+	// EulaAgreedByFlag is an agreement given on the command line, e.g. -a
+	EulaAgreedByFlag = "agreed"
+)
+
+// EulaDecision records whether the embedded license of a pack was accepted
+type EulaDecision struct {
+	// Pack is the pack the license is embedded in, as Vendor.Pack.x.y.z
+	Pack string `json:"pack"`
+
+	// License is the path of the license in the pack
+	License string `json:"license"`
+
+	// SHA256 is the hex digest of the license text
+	SHA256 string `json:"sha256"`
+
+	// Accepted tells whether the license was accepted or declined
+	Accepted bool `json:"accepted"`
+
+	// Source is how the decision was taken, e.g. EulaPrompted
+	Source string `json:"source"`
+
+	// User is the name of the user who ran cpackget
+	User string `json:"user"`
+
+	// Time is when the decision was taken
+	Time time.Time `json:"time"`
+}
+
+// ReadEulaDecisions returns the decisions on licenses taken in the pack root, oldest first
+func ReadEulaDecisions() ([]EulaDecision, error) {
+	decisions := []EulaDecision{}
+	decisionsPath := filepath.Join(Installation.LocalDir, EulaDecisionsName)
+	if !utils.FileExists(decisionsPath) {
+		return decisions, nil
+	}
+	b, err := afero.ReadFile(utils.GetFileSystem(), decisionsPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &decisions); err != nil {
+		log.Errorf("Can't parse \"%s\": %s", decisionsPath, err)
+		return nil, err
+	}
+	return decisions, nil
+}
+
+// eulaHash returns the hex SHA-256 digest of a license text
+func eulaHash(eula []byte) string {
+	sum := sha256.Sum256(eula)
+	return hex.EncodeToString(sum[:])
+}
+
+// currentUserName returns who runs cpackget, for the record
+func currentUserName() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// recordEulaDecision adds the decision on the license of the pack, whose
+// text is eula, to the decisions of the pack root
+func (p *PackType) recordEulaDecision(eula []byte, accepted bool, source string) error {
+	decisions, err := ReadEulaDecisions()
+	if err != nil {
+		return err
+	}
+	decisions = append(decisions, EulaDecision{
+		Pack:     p.PackIDWithVersion(),
+		License:  p.Pdsc.License,
+		SHA256:   eulaHash(eula),
+		Accepted: accepted,
+		Source:   source,
+		User:     currentUserName(),
+		Time:     time.Now().UTC(),
+	})
+
+	b, err := json.MarshalIndent(decisions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(filepath.Join(Installation.LocalDir, EulaDecisionsName), append(b, '\n'), utils.FileModeRW)
+}
+
+// acceptedEula returns the last decision taken on the license of the pack,
+// whose text is eula, in any of its versions, if it accepted the same text
+func (p *PackType) acceptedEula(eula []byte) (*EulaDecision, error) {
+	decisions, err := ReadEulaDecisions()
+	if err != nil {
+		return nil, err
+	}
+	hash := eulaHash(eula)
+	for i := len(decisions) - 1; i >= 0; i-- {
+		decision := decisions[i]
+		if decision.SHA256 != hash || !strings.HasPrefix(decision.Pack, p.PackID()+".") {
+			continue
+		}
+		if decision.Accepted {
+			return &decision, nil
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
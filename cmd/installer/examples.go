@@ -0,0 +1,137 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/spf13/afero"
+)
+
+// Example is an example project shipped with an installed pack
+type Example struct {
+	xml.ExampleTag
+
+	// Pack is the ID of the pack providing the example, e.g. Vendor::Name@1.2.3
+	Pack string
+
+	// Path is the folder of the example in the pack root
+	Path string
+}
+
+// FindExamples returns the examples of the latest installed version of each
+// pack, or only of packID if not empty, e.g. "Vendor.Pack" or "Vendor::Pack@1.2.3"
+func FindExamples(packID string) ([]Example, error) {
+	var info utils.PackInfo
+	if packID != "" {
+		var err error
+		if info, err = utils.ExtractPackInfo(packID); err != nil {
+			return nil, err
+		}
+	}
+
+	// A given version may not be the latest installed one
+	installedPacks, err := findInstalledPacks(true, info.Version == "")
+	if err != nil {
+		return nil, err
+	}
+
+	examples := []Example{}
+	packFound := false
+	for _, pack := range installedPacks {
+		if pack.err != nil {
+			continue
+		}
+		if packID != "" && (pack.Vendor != info.Vendor || pack.Name != info.Pack || (info.Version != "" && pack.Version != info.Version)) {
+			continue
+		}
+		packFound = true
+
+		pdscXML := xml.NewPdscXML(pack.pdscPath)
+		if err := pdscXML.Read(); err != nil {
+			log.Warnf("Skipping \"%s\": %s", pack.pdscPath, err)
+			continue
+		}
+
+		packDir := filepath.Dir(pack.pdscPath)
+		for _, exampleTag := range pdscXML.ExamplesTag.Examples {
+			examplePath := filepath.Join(packDir, filepath.FromSlash(exampleTag.Folder))
+			if rel, err := filepath.Rel(packDir, examplePath); err != nil || strings.HasPrefix(rel, "..") {
+				log.Warnf("Skipping example \"%s\" of %s, its folder is outside of the pack", exampleTag.Name, pack.YamlPackID())
+				continue
+			}
+			examples = append(examples, Example{exampleTag, pack.YamlPackID(), examplePath})
+		}
+	}
+
+	if packID != "" && !packFound {
+		log.Errorf("Pack \"%s\" is not installed", packID)
+		return nil, errs.ErrPackNotInstalled
+	}
+
+	return examples, nil
+}
+
+// CopyExample copies the example called name, from packID if not empty,
+// into dir, which must not exist or be empty. Copied files are writable.
+func CopyExample(packID, name, dir string) error {
+	examples, err := FindExamples(packID)
+	if err != nil {
+		return err
+	}
+
+	var found []Example
+	for _, example := range examples {
+		if strings.EqualFold(example.Name, name) {
+			found = append(found, example)
+		}
+	}
+
+	if len(found) == 0 {
+		log.Errorf("Example \"%s\" not found", name)
+		return errs.ErrExampleNotFound
+	}
+	for _, example := range found[1:] {
+		if example.Pack != found[0].Pack {
+			log.Errorf("Example \"%s\" is provided by %s and %s", name, found[0].Pack, example.Pack)
+			return errs.ErrAmbiguousExample
+		}
+	}
+	example := found[0]
+
+	if utils.DirExists(dir) && !utils.IsEmpty(dir) {
+		return errs.WithPath(errs.ErrPathAlreadyExists, dir)
+	}
+	if !utils.DirExists(example.Path) {
+		return errs.WithPath(errs.ErrDirectoryNotFound, example.Path)
+	}
+
+	log.Infof("Copying example \"%s\" of %s to \"%s\"", example.Name, example.Pack, dir)
+	return afero.Walk(utils.GetFileSystem(), example.Path, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(example.Path, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+
+		if info.IsDir() {
+			return utils.EnsureDir(target)
+		}
+		if !info.Mode().IsRegular() {
+			log.Warnf("Not copying \"%s\", it is not a regular file", path)
+			return nil
+		}
+		return utils.CopyFile(path, target)
+	})
+}
@@ -0,0 +1,98 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path/filepath"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// ExtractPack extracts the local pack file in packPath to outputDir, checked
+// and extracted the way AddPack does but without a pack root. outputDir
+// defaults to Vendor.Pack.x.y.z in the current directory, and must be empty.
+// With checkEula, the pack's license is displayed for acceptance first.
+func ExtractPack(packPath, outputDir string, checkEula bool) error {
+	info, err := utils.ExtractPackInfo(packPath)
+	if err != nil {
+		return err
+	}
+	if info.IsPackID || (info.Extension != "pack" && info.Extension != "zip") {
+		log.Errorf("\"%s\" is not a pack file", packPath)
+		return errs.ErrBadPackName
+	}
+
+	pack := &PackType{path: filepath.Clean(packPath)}
+	pack.Vendor = info.Vendor
+	pack.Name = info.Pack
+	pack.Version = info.Version
+	if !utils.FileExists(pack.path) {
+		log.Errorf("File \"%s\" doesn't exist", pack.path)
+		return errs.ErrFileNotFound
+	}
+
+	if outputDir == "" {
+		outputDir = pack.PackIDWithVersion()
+	}
+	outputDir = filepath.Clean(outputDir)
+	if utils.DirExists(outputDir) && !utils.IsEmpty(outputDir) {
+		return errs.WithPath(errs.ErrPathAlreadyExists, outputDir)
+	} else if utils.FileExists(outputDir) {
+		return errs.WithPath(errs.ErrPathAlreadyExists, outputDir)
+	}
+
+	if pack.zipReader, err = utils.OpenZip(pack.path); err != nil {
+		log.Errorf("Can't decompress \"%s\": %s", pack.path, err)
+		return errs.ErrFailedDecompressingFile
+	}
+	defer pack.zipReader.Close()
+
+	if err = pack.validate(); err != nil {
+		return err
+	}
+
+	if checkEula && len(pack.Pdsc.License) > 0 {
+		ok, err := pack.checkEula()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			log.Info("User does not agree with the pack's license, not extracting it")
+			return nil
+		}
+	}
+
+	var uncompressedSize uint64
+	for _, file := range pack.zipReader.File {
+		uncompressedSize += file.UncompressedSize64
+	}
+	if err = utils.EnsureDir(outputDir); err != nil {
+		return err
+	}
+	if err = utils.CheckDiskSpace(outputDir, uncompressedSize); err != nil {
+		return err
+	}
+
+	log.Infof("Extracting files to %s...", outputDir)
+	for _, file := range pack.zipReader.File {
+		if err = utils.SecureInflateFileContext(operationContext, file, outputDir, pack.Subfolder); err != nil {
+			if entry, entryErr := utils.CorruptZipEntry(pack.zipReader.Reader); entry != "" {
+				log.Errorf("Entry \"%s\" of \"%s\" is corrupt: %s", entry, pack.path, entryErr)
+				return errs.ErrFailedDecompressingFile
+			}
+			return err
+		}
+	}
+
+	if verified, err := cryptography.VerifyEmbeddedChecksum(outputDir); err != nil {
+		log.Errorf("Files extracted to \"%s\" do not match the checksum file embedded in the pack", outputDir)
+		return err
+	} else if verified {
+		log.Info("Verified the extracted files against the checksum file embedded in the pack")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// PdscMatch is an element of a PDSC file matching GrepPdscs' expression
+type PdscMatch struct {
+	// Pack is the ID of the pack the PDSC file describes, e.g. Vendor::Name@1.2.3
+	Pack string
+
+	// Installed tells whether the pack is installed
+	Installed bool
+
+	// File and Line locate the matching element
+	File string
+	Line int
+
+	// Element is the path of the matching element, e.g. package/devices/family
+	Element string
+
+	// Match is the matching attribute, as in Dname="STM32F407VG", or text
+	Match string
+}
+
+// GrepPdscs searches the attributes and texts of the PDSC files of installed
+// packs, and optionally of the ones cached in .Web/, for expression
+func GrepPdscs(expression *regexp.Regexp, includeCached bool) ([]PdscMatch, error) {
+	pdscs, err := findPackPdscs(includeCached)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []PdscMatch{}
+	for _, pdsc := range pdscs {
+		fileMatches, err := grepPdscFile(pdsc.pdscXML.FileName, expression)
+		if err != nil {
+			log.Warnf("Skipping \"%s\": %s", pdsc.pdscXML.FileName, err)
+			continue
+		}
+		for i := range fileMatches {
+			fileMatches[i].Pack = pdsc.packID
+			fileMatches[i].Installed = pdsc.installed
+		}
+		matches = append(matches, fileMatches...)
+	}
+	return matches, nil
+}
+
+// grepPdscFile returns the elements of the XML file in path whose
+// attributes or text match expression
+func grepPdscFile(path string, expression *regexp.Regexp) ([]PdscMatch, error) {
+	file, err := utils.GetFileSystem().Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	matches := []PdscMatch{}
+	elements := []string{}
+	decoder := utils.NewXMLDecoder(file)
+	for {
+		// Tokens start where the previous one ended
+		line, _ := decoder.InputPos()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			elements = append(elements, token.Name.Local)
+			for _, attr := range token.Attr {
+				if expression.MatchString(attr.Value) {
+					matches = append(matches, PdscMatch{
+						File:    path,
+						Line:    line,
+						Element: strings.Join(elements, "/"),
+						Match:   attr.Name.Local + "=\"" + attr.Value + "\"",
+					})
+				}
+			}
+		case xml.EndElement:
+			if len(elements) > 0 {
+				elements = elements[:len(elements)-1]
+			}
+		case xml.CharData:
+			text := strings.TrimSpace(string(token))
+			if text != "" && expression.MatchString(text) {
+				// Multi-line texts start on the line of their first non-blank character
+				line += strings.Count(string(token)[:strings.Index(string(token), text)], "\n")
+				matches = append(matches, PdscMatch{
+					File:    path,
+					Line:    line,
+					Element: strings.Join(elements, "/"),
+					Match:   text,
+				})
+			}
+		}
+	}
+	return matches, nil
+}
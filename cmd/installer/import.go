@@ -0,0 +1,136 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// ImportMDKPacks adopts the packs of a Keil MDK pack folder, e.g. C:\Keil_v5\ARM\PACK,
+// into the pack root. Extracted packs and cached pack and PDSC files are copied over
+// unless already present, and PDSC files MDK got from its index or added locally are
+// registered in .Web and .Local/local_repository.pidx.
+func ImportMDKPacks(mdkPackDir string) error {
+	log.Debugf("Importing packs from \"%s\"", mdkPackDir)
+
+	pdscFiles, err := utils.GlobIn(mdkPackDir, "*/*/*/*.pdsc")
+	if err != nil {
+		return err
+	}
+	if len(pdscFiles) == 0 && !utils.DirExists(filepath.Join(mdkPackDir, ".Download")) {
+		log.Errorf("\"%s\" does not look like an MDK pack folder, it has neither packs nor a .Download folder", mdkPackDir)
+		return errs.WithPath(errs.ErrNotMDKPackFolder, mdkPackDir)
+	}
+
+	mdkWebDir := filepath.Join(mdkPackDir, ".Web")
+	imported := 0
+	for _, pdscFile := range pdscFiles {
+		versionDir := filepath.Dir(pdscFile)
+		name := filepath.Base(filepath.Dir(versionDir))
+		vendor := filepath.Base(filepath.Dir(filepath.Dir(versionDir)))
+		version := filepath.Base(versionDir)
+		pdscFileName := vendor + "." + name + ".pdsc"
+
+		// Skip hidden folders like .Web, and PDSC files of other packs
+		if strings.HasPrefix(vendor, ".") || filepath.Base(pdscFile) != pdscFileName {
+			continue
+		}
+
+		packHomeDir := filepath.Join(Installation.PackRoot, vendor, name, version)
+		if utils.DirExists(packHomeDir) {
+			log.Debugf("%s.%s.%s is already installed, not importing it", vendor, name, version)
+			continue
+		}
+
+		log.Infof("Importing %s.%s.%s", vendor, name, version)
+		if err := utils.EnsureDir(filepath.Dir(packHomeDir)); err != nil {
+			return err
+		}
+		if err := copyPackRoot(versionDir, packHomeDir); err != nil {
+			return err
+		}
+		utils.SetReadOnlyR(packHomeDir)
+
+		// Same copies of the PDSC file as installing the pack makes
+		_ = utils.CopyFile(pdscFile, filepath.Join(Installation.DownloadDir, vendor+"."+name+"."+version+".pdsc"))
+		webPdscFile := filepath.Join(Installation.WebDir, pdscFileName)
+		if !utils.FileExists(webPdscFile) {
+			if mdkWebPdscFile := filepath.Join(mdkWebDir, pdscFileName); utils.FileExists(mdkWebPdscFile) {
+				if err := utils.CopyFile(mdkWebPdscFile, webPdscFile); err != nil {
+					return err
+				}
+			} else {
+				_ = utils.CopyFile(pdscFile, filepath.Join(Installation.LocalDir, pdscFileName))
+			}
+		}
+		imported++
+	}
+
+	cachedFiles, err := utils.ListDir(filepath.Join(mdkPackDir, ".Download"), `\.(pack|pdsc)$`)
+	if err != nil && utils.DirExists(filepath.Join(mdkPackDir, ".Download")) {
+		return err
+	}
+	for _, cachedFile := range cachedFiles {
+		target := filepath.Join(Installation.DownloadDir, filepath.Base(cachedFile))
+		if utils.FileExists(target) {
+			continue
+		}
+		if err := utils.CopyFile(cachedFile, target); err != nil {
+			return err
+		}
+	}
+
+	if err := importMDKLocalPdscs(filepath.Join(mdkPackDir, ".Local", "local_repository.pidx")); err != nil {
+		return err
+	}
+
+	log.Infof("Imported %d pack(s) and %d cached file(s) from \"%s\"", imported, len(cachedFiles), mdkPackDir)
+	return Installation.touchPackIdx()
+}
+
+// importMDKLocalPdscs adds the PDSC files MDK installed locally to
+// local_repository.pidx, as long as they can still be found
+func importMDKLocalPdscs(mdkLocalPidxPath string) error {
+	if !utils.FileExists(mdkLocalPidxPath) {
+		return nil
+	}
+
+	mdkLocalPidx := xml.NewPidxXML(mdkLocalPidxPath)
+	if err := mdkLocalPidx.Read(); err != nil {
+		return err
+	}
+
+	if err := Installation.LocalPidx.Read(); err != nil {
+		return err
+	}
+
+	added := false
+	for _, pdscTag := range mdkLocalPidx.ListPdscTags() {
+		parsedURL, err := url.ParseRequestURI(pdscTag.URL)
+		if err != nil || !utils.FileExists(filepath.Join(utils.CleanPath(parsedURL.Path), pdscTag.Vendor+"."+pdscTag.Name+".pdsc")) {
+			log.Warnf("Not importing %s.%s, its pdsc file is not found at \"%s\"", pdscTag.Vendor, pdscTag.Name, pdscTag.URL)
+			continue
+		}
+
+		if err := Installation.LocalPidx.AddPdsc(pdscTag); err != nil {
+			if errs.Is(err, errs.ErrPdscEntryExists) {
+				continue
+			}
+			return err
+		}
+		added = true
+	}
+
+	if !added {
+		return nil
+	}
+	return Installation.LocalPidx.Write()
+}
@@ -0,0 +1,80 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// IndexRefreshedName is the file in .Local/ holding when the public index
+// was last refreshed
+const IndexRefreshedName = "index_refreshed"
+
+// staleIndexAge is how old the public index has to be for WarnIfIndexStale to warn
+var staleIndexAge = 30 * 24 * time.Hour
+
+// SetStaleIndexAge sets how long after its last refresh the public index is
+// considered outdated by WarnIfIndexStale. Zero disables the warning.
+func SetStaleIndexAge(age time.Duration) {
+	staleIndexAge = age
+}
+
+func GetStaleIndexAge() time.Duration {
+	return staleIndexAge
+}
+
+// IndexRefreshed returns when the public index was last refreshed. Pack roots
+// where this was never recorded fall back to when index.pidx was written.
+func IndexRefreshed() (time.Time, error) {
+	refreshedPath := filepath.Join(Installation.LocalDir, IndexRefreshedName)
+	if utils.FileExists(refreshedPath) {
+		b, err := afero.ReadFile(utils.GetFileSystem(), refreshedPath)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if refreshed, err := time.Parse(time.RFC3339, strings.TrimSpace(string(b))); err == nil {
+			return refreshed, nil
+		}
+		log.Debugf("Can't parse \"%s\", falling back to the modification time of the index", refreshedPath)
+	}
+
+	fileInfo, err := utils.GetFileSystem().Stat(Installation.PublicIndex)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fileInfo.ModTime(), nil
+}
+
+// recordIndexRefreshed records that the public index was just refreshed
+func recordIndexRefreshed() error {
+	refreshed := time.Now().UTC().Format(time.RFC3339) + "\n"
+	return utils.WriteFileAtomic(filepath.Join(Installation.LocalDir, IndexRefreshedName), []byte(refreshed), utils.FileModeRW)
+}
+
+// WarnIfIndexStale warns that versions resolved from the public index may be
+// outdated if it was last refreshed longer ago than SetStaleIndexAge allows,
+// telling whether it did
+func WarnIfIndexStale() bool {
+	if staleIndexAge == 0 {
+		return false
+	}
+	refreshed, err := IndexRefreshed()
+	if err != nil {
+		log.Debugf("Can't tell when the public index was last refreshed: %s", err)
+		return false
+	}
+
+	age := time.Since(refreshed)
+	if age < staleIndexAge {
+		return false
+	}
+	log.Warnf("The public index was last refreshed %d day(s) ago, results may be outdated. Run \"cpackget update-index\" or pass --refresh", int(age.Hours()/24))
+	return true
+}
@@ -0,0 +1,193 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/spf13/afero"
+	"golang.org/x/sync/semaphore"
+)
+
+// FailedPdscsName is the file in .Local/ holding the PDSC files the last
+// index update could not download, for RetryFailedPdscs to try them again
+const FailedPdscsName = "failed_pdscs.json"
+
+// FailedPdsc is a PDSC file an index update could not download
+type FailedPdsc struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+
+	// Version is the latest version of the pack in the index, if known
+	Version string `json:"version,omitempty"`
+
+	// URL is where the PDSC file is downloaded from
+	URL string `json:"url"`
+
+	// Local tells whether the PDSC file goes to .Local/ rather than .Web/
+	Local bool `json:"local,omitempty"`
+}
+
+// gFailedPdscs collects the PDSC downloads failing during an index update
+var gFailedPdscs struct {
+	sync.Mutex
+
+	// pdscs is nil outside of index updates, nothing is collected then
+	pdscs []FailedPdsc
+}
+
+// ReadFailedPdscs returns the PDSC files the last index update could not download
+func ReadFailedPdscs() ([]FailedPdsc, error) {
+	failed := []FailedPdsc{}
+	failedPath := filepath.Join(Installation.LocalDir, FailedPdscsName)
+	if !utils.FileExists(failedPath) {
+		return failed, nil
+	}
+	b, err := afero.ReadFile(utils.GetFileSystem(), failedPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &failed); err != nil {
+		log.Errorf("Can't parse \"%s\": %s", failedPath, err)
+		return nil, err
+	}
+	return failed, nil
+}
+
+// startFailedPdscs starts collecting the PDSC downloads failing from now on
+func startFailedPdscs() {
+	gFailedPdscs.Lock()
+	defer gFailedPdscs.Unlock()
+	gFailedPdscs.pdscs = []FailedPdsc{}
+}
+
+// recordFailedPdsc records that the PDSC file of pdscTag could not be downloaded
+func recordFailedPdsc(pdscTag xml.PdscTag, local bool) {
+	gFailedPdscs.Lock()
+	defer gFailedPdscs.Unlock()
+	if gFailedPdscs.pdscs == nil {
+		return
+	}
+	gFailedPdscs.pdscs = append(gFailedPdscs.pdscs, FailedPdsc{
+		Vendor:  pdscTag.Vendor,
+		Name:    pdscTag.Name,
+		Version: pdscTag.Version,
+		URL:     pdscTag.URL,
+		Local:   local,
+	})
+}
+
+// saveFailedPdscs stops collecting failing PDSC downloads and writes the
+// ones collected to .Local/, or removes the file if all of them succeeded
+func saveFailedPdscs() error {
+	gFailedPdscs.Lock()
+	failed := gFailedPdscs.pdscs
+	gFailedPdscs.pdscs = nil
+	gFailedPdscs.Unlock()
+
+	failedPath := filepath.Join(Installation.LocalDir, FailedPdscsName)
+	if len(failed) == 0 {
+		if utils.FileExists(failedPath) {
+			return utils.GetFileSystem().Remove(failedPath)
+		}
+		return nil
+	}
+
+	log.Warnf("%d PDSC file(s) could not be downloaded, run \"cpackget update-index --retry-failed\" to try them again", len(failed))
+	b, err := json.MarshalIndent(failed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(failedPath, append(b, '\n'), utils.FileModeRW)
+}
+
+// RetryFailedPdscs downloads again only the PDSC files the last index update
+// could not download, keeping the ones failing again for another retry
+func RetryFailedPdscs(concurrency int, timeout int) error {
+	failed, err := ReadFailedPdscs()
+	if err != nil {
+		return err
+	}
+	if len(failed) == 0 {
+		log.Info("No failed PDSC downloads to retry")
+		return nil
+	}
+
+	log.Infof("Retrying %d failed PDSC download(s)", len(failed))
+	if utils.GetEncodedProgress() {
+		log.Infof("[J%d:F\"%s\"]", len(failed), filepath.Join(Installation.LocalDir, FailedPdscsName))
+	}
+
+	startFailedPdscs()
+
+	ctx := operationContext
+	concurrency = CheckConcurrency(concurrency)
+	sem := semaphore.NewWeighted(int64(concurrency))
+
+	for i, failedPdsc := range failed {
+		if ctx.Err() != nil {
+			// The ones not tried yet are kept for the next retry
+			for _, notTried := range failed[i:] {
+				recordFailedPdsc(notTried.pdscTag(), notTried.Local)
+			}
+			break
+		}
+		if concurrency == 0 {
+			retryFailedPdsc(failedPdsc, timeout)
+		} else {
+			if err := sem.Acquire(ctx, 1); err != nil {
+				log.Errorf("Failed to acquire semaphore: %v", err)
+				for _, notTried := range failed[i:] {
+					recordFailedPdsc(notTried.pdscTag(), notTried.Local)
+				}
+				break
+			}
+
+			go func(failedPdsc FailedPdsc) {
+				defer sem.Release(1)
+				retryFailedPdsc(failedPdsc, timeout)
+			}(failedPdsc)
+		}
+	}
+	// Wait for the running downloads, which stop early once ctx is done
+	if concurrency > 0 {
+		if err := sem.Acquire(context.Background(), int64(concurrency)); err != nil {
+			log.Errorf("Failed to acquire semaphore: %v", err)
+		}
+	}
+
+	if err := saveFailedPdscs(); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return utils.ContextError(ctx)
+	}
+
+	return Installation.touchPackIdx()
+}
+
+// retryFailedPdsc downloads the PDSC file of failedPdsc again
+func retryFailedPdsc(failedPdsc FailedPdsc, timeout int) {
+	var err error
+	if failedPdsc.Local {
+		err = Installation.loadPdscFile(failedPdsc.pdscTag(), timeout)
+	} else {
+		err = Installation.downloadPdscFile(failedPdsc.pdscTag(), false, timeout)
+	}
+	if err != nil {
+		log.Error(err)
+		recordFailedPdsc(failedPdsc.pdscTag(), failedPdsc.Local)
+	}
+}
+
+// pdscTag returns the tag the PDSC file of f is downloaded with
+func (f FailedPdsc) pdscTag() xml.PdscTag {
+	return xml.PdscTag{Vendor: f.Vendor, Name: f.Name, Version: f.Version, URL: f.URL}
+}
@@ -0,0 +1,144 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// IndexPinName is the file in .Local/ holding the IndexPin of the public index
+const IndexPinName = "index_pin.json"
+
+// IndexPin is the hash the public index is expected to have, recorded by
+// PinPublicIndex and checked by every update of the public index
+type IndexPin struct {
+	// URL is where the pinned index was retrieved from
+	URL string `json:"url"`
+
+	// SHA256 is the hex digest of the pinned index
+	SHA256 string `json:"sha256"`
+
+	// Strict makes updates retrieving another index fail instead of warning
+	Strict bool `json:"strict,omitempty"`
+}
+
+// ParseIndexPin returns the hex digest of a pin like "sha256:<hex digest>"
+func ParseIndexPin(pin string) (string, error) {
+	algorithm, digest, found := strings.Cut(pin, ":")
+	digest = strings.ToLower(digest)
+	if !found || !strings.EqualFold(algorithm, "sha256") || len(digest) != 64 {
+		log.Errorf("\"%s\" is not an index pin", pin)
+		return "", errs.ErrInvalidIndexPin
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		log.Errorf("\"%s\" is not an index pin", pin)
+		return "", errs.ErrInvalidIndexPin
+	}
+	return digest, nil
+}
+
+// ReadIndexPin returns the pin of the public index, or nil if it is not pinned
+func ReadIndexPin() (*IndexPin, error) {
+	pinPath := filepath.Join(Installation.LocalDir, IndexPinName)
+	if !utils.FileExists(pinPath) {
+		return nil, nil
+	}
+	b, err := afero.ReadFile(utils.GetFileSystem(), pinPath)
+	if err != nil {
+		return nil, err
+	}
+	pin := &IndexPin{}
+	if err = json.Unmarshal(b, pin); err != nil {
+		log.Errorf("Can't parse \"%s\": %s", pinPath, err)
+		return nil, err
+	}
+	return pin, nil
+}
+
+// writeIndexPin records pin, a nil pin removes the recorded one
+func writeIndexPin(pin *IndexPin) error {
+	pinPath := filepath.Join(Installation.LocalDir, IndexPinName)
+	if pin == nil {
+		if !utils.FileExists(pinPath) {
+			return nil
+		}
+		return utils.GetFileSystem().Remove(pinPath)
+	}
+
+	b, err := json.MarshalIndent(pin, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(pinPath, append(b, '\n'), utils.FileModeRW)
+}
+
+// PinPublicIndex replaces the public index with the one in indexPath, which
+// must match pin, "sha256:<hex digest>", and records the pin for later
+// updates to check the index they retrieve against. With strict, these
+// updates fail instead of warning when it does not match. An empty pin
+// replaces the public index and removes the recorded pin.
+func PinPublicIndex(indexPath, pin string, strict bool, timeout int) error {
+	if pin == "" {
+		if err := writeIndexPin(nil); err != nil {
+			return err
+		}
+		return UpdatePublicIndex(indexPath, true, true, false, false, 0, timeout)
+	}
+
+	digest, err := ParseIndexPin(pin)
+	if err != nil {
+		return err
+	}
+	previousPin, err := ReadIndexPin()
+	if err != nil {
+		return err
+	}
+
+	// The index given along with the pin has to match it
+	newPin := &IndexPin{URL: indexPath, SHA256: digest, Strict: true}
+	if err := writeIndexPin(newPin); err != nil {
+		return err
+	}
+	if err := UpdatePublicIndex(indexPath, true, true, false, false, 0, timeout); err != nil {
+		_ = writeIndexPin(previousPin)
+		return err
+	}
+
+	log.Infof("Pinned the public index to sha256:%s", digest)
+	newPin.Strict = strict
+	return writeIndexPin(newPin)
+}
+
+// checkIndexPin checks the index retrieved into indexPath against the pin
+// of the public index, if any
+func checkIndexPin(indexPath string) error {
+	pin, err := ReadIndexPin()
+	if err != nil || pin == nil {
+		return err
+	}
+
+	digest, err := utils.FileSHA256(indexPath)
+	if err != nil {
+		return err
+	}
+	if digest == pin.SHA256 {
+		log.Debugf("The index matches its pin sha256:%s", pin.SHA256)
+		return nil
+	}
+
+	if pin.Strict {
+		log.Errorf("The retrieved index has the SHA-256 hash %s, but is pinned to %s", digest, pin.SHA256)
+		return errs.ErrIndexPinMismatch
+	}
+	log.Warnf("The retrieved index has the SHA-256 hash %s, but is pinned to %s, see \"cpackget help index\"", digest, pin.SHA256)
+	return nil
+}
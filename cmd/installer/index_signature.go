@@ -0,0 +1,63 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// indexKeyPath is the PGP public key index signatures are verified with
+var indexKeyPath string
+
+// strictIndex refuses indexes that are not signed
+var strictIndex bool
+
+// SetIndexVerification makes updating the public index verify its detached
+// signature, "index.pidx.sig" next to it, with the PGP public key in keyPath.
+// With strict, indexes without a signature are refused. An empty keyPath
+// disables the verification.
+func SetIndexVerification(keyPath string, strict bool) {
+	indexKeyPath = keyPath
+	strictIndex = strict
+}
+
+// verifyIndexSignature verifies the index read from indexRef, already in the
+// local indexPath, against the signature next to indexRef
+func verifyIndexSignature(indexRef, indexPath string, timeout int) error {
+	if indexKeyPath == "" {
+		return nil
+	}
+
+	signatureRef := indexRef + ".sig"
+	signaturePath := signatureRef
+	var err error
+	if strings.HasPrefix(signatureRef, "http://") || strings.HasPrefix(signatureRef, "https://") {
+		signaturePath, err = utils.DownloadFileContext(operationContext, signatureRef, timeout)
+		if err == nil {
+			defer utils.GetFileSystem().Remove(signaturePath)
+		}
+	} else if !utils.FileExists(signaturePath) {
+		err = errs.WithPath(errs.ErrFileNotFound, signaturePath)
+	}
+
+	if err != nil {
+		if strictIndex {
+			log.Errorf("Could not get the signature \"%s\" of the index: %s", signatureRef, err)
+			return errs.ErrUnsignedIndex
+		}
+		log.Warnf("Index \"%s\" is not signed, it cannot be verified", indexRef)
+		return nil
+	}
+
+	if err := cryptography.VerifyIndexSignature(indexPath, signaturePath, indexKeyPath); err != nil {
+		return err
+	}
+	log.Info("Index signature verification success - index is authentic")
+	return nil
+}
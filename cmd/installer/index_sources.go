@@ -0,0 +1,340 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/spf13/afero"
+)
+
+// IndexSourcesName is the file in .Local/ listing the IndexSources the
+// public index is merged with
+const IndexSourcesName = "index_sources.json"
+
+// IndexOriginsName is the file in .Web/ telling which IndexSource each
+// pack merged into the public index comes from
+const IndexOriginsName = "index_origins.json"
+
+// DefaultIndexSource names the index .Web/index.pidx is retrieved from
+const DefaultIndexSource = "default"
+
+// IndexSource is an index, e.g. of a vendor, subscribed to on top of the
+// public index. Its packs are merged into .Web/index.pidx, unless the public
+// index or a source added earlier has them already.
+type IndexSource struct {
+	// Name identifies the source in "cpackget index" commands
+	Name string `json:"name"`
+
+	// URL is where the index of the source is retrieved from, a URL, a
+	// local file or a folder of PDSC and pack files
+	URL string `json:"url"`
+
+	// Disabled sources are kept but not merged
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// ReadIndexSources returns the index sources subscribed to, in the order
+// they were added
+func ReadIndexSources() ([]IndexSource, error) {
+	sourcesPath := filepath.Join(Installation.LocalDir, IndexSourcesName)
+	sources := []IndexSource{}
+	if !utils.FileExists(sourcesPath) {
+		return sources, nil
+	}
+	b, err := afero.ReadFile(utils.GetFileSystem(), sourcesPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &sources); err != nil {
+		log.Errorf("Can't parse \"%s\": %s", sourcesPath, err)
+		return nil, err
+	}
+	return sources, nil
+}
+
+// writeIndexSources records sources, removing the file if there are none
+func writeIndexSources(sources []IndexSource) error {
+	sourcesPath := filepath.Join(Installation.LocalDir, IndexSourcesName)
+	if len(sources) == 0 {
+		if !utils.FileExists(sourcesPath) {
+			return nil
+		}
+		return utils.GetFileSystem().Remove(sourcesPath)
+	}
+
+	b, err := json.MarshalIndent(sources, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(sourcesPath, append(b, '\n'), utils.FileModeRW)
+}
+
+// ReadIndexOrigins returns the index source of each pack, as Vendor.Name,
+// merged into the public index. Packs of the public index itself are not
+// listed.
+func ReadIndexOrigins() (map[string]string, error) {
+	originsPath := filepath.Join(Installation.WebDir, IndexOriginsName)
+	origins := map[string]string{}
+	if !utils.FileExists(originsPath) {
+		return origins, nil
+	}
+	b, err := afero.ReadFile(utils.GetFileSystem(), originsPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &origins); err != nil {
+		log.Errorf("Can't parse \"%s\": %s", originsPath, err)
+		return nil, err
+	}
+	return origins, nil
+}
+
+// writeIndexOrigins records origins, removing the file if there are none
+func writeIndexOrigins(origins map[string]string) error {
+	originsPath := filepath.Join(Installation.WebDir, IndexOriginsName)
+	if len(origins) == 0 {
+		if !utils.FileExists(originsPath) {
+			return nil
+		}
+		return utils.GetFileSystem().Remove(originsPath)
+	}
+
+	b, err := json.MarshalIndent(origins, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(originsPath, append(b, '\n'), utils.FileModeRW)
+}
+
+// findIndexSource returns the position of the source named name in
+// sources, or -1 if there is none
+func findIndexSource(sources []IndexSource, name string) int {
+	for i, source := range sources {
+		if strings.EqualFold(source.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findExistingIndexSource returns the position of the source named name in
+// sources, failing if there is none
+func findExistingIndexSource(sources []IndexSource, name string) (int, error) {
+	i := findIndexSource(sources, name)
+	if i < 0 {
+		log.Errorf("There is no index source named \"%s\"", name)
+		return -1, errs.ErrIndexSourceNotFound
+	}
+	return i, nil
+}
+
+// AddIndexSource subscribes to the index at indexURL under name, and merges
+// its packs into the public index right away
+func AddIndexSource(name, indexURL string, timeout int) error {
+	if name == "" || strings.EqualFold(name, DefaultIndexSource) || strings.ContainsAny(name, " \t/\\") {
+		log.Errorf("Invalid index source name \"%s\"", name)
+		return errs.ErrIncorrectCmdArgs
+	}
+
+	sources, err := ReadIndexSources()
+	if err != nil {
+		return err
+	}
+	if findIndexSource(sources, name) >= 0 {
+		log.Errorf("There is an index source named \"%s\" already", name)
+		return errs.ErrIndexSourceExists
+	}
+
+	source := IndexSource{Name: name, URL: indexURL}
+	if err := mergeIndexSources([]IndexSource{source}, true, timeout); err != nil {
+		return err
+	}
+	return writeIndexSources(append(sources, source))
+}
+
+// RemoveIndexSource unsubscribes from the index source named name, and
+// removes its packs from the public index
+func RemoveIndexSource(name string) error {
+	sources, err := ReadIndexSources()
+	if err != nil {
+		return err
+	}
+	i, err := findExistingIndexSource(sources, name)
+	if err != nil {
+		return err
+	}
+
+	if err := unmergeIndexSource(sources[i].Name); err != nil {
+		return err
+	}
+	return writeIndexSources(append(sources[:i], sources[i+1:]...))
+}
+
+// EnableIndexSource merges the packs of the index source named name into
+// the public index again, or removes them from it if not enabled, keeping
+// the subscription
+func EnableIndexSource(name string, enabled bool, timeout int) error {
+	sources, err := ReadIndexSources()
+	if err != nil {
+		return err
+	}
+	i, err := findExistingIndexSource(sources, name)
+	if err != nil {
+		return err
+	}
+	if sources[i].Disabled == !enabled {
+		return nil
+	}
+
+	sources[i].Disabled = !enabled
+	if enabled {
+		err = mergeIndexSources(sources[i:i+1], true, timeout)
+	} else {
+		err = unmergeIndexSource(sources[i].Name)
+	}
+	if err != nil {
+		return err
+	}
+	return writeIndexSources(sources)
+}
+
+// readIndexSource retrieves the index of source and returns it
+func readIndexSource(source IndexSource, timeout int) (*xml.PidxXML, error) {
+	indexPath := source.URL
+	if strings.HasPrefix(indexPath, "http://") || strings.HasPrefix(indexPath, "https://") {
+		indexPath = filepath.Join(Installation.DownloadDir, ".index-source-"+source.Name+".pidx")
+		if err := utils.DownloadFileToContext(operationContext, source.URL, indexPath, timeout); err != nil {
+			return nil, err
+		}
+		defer utils.GetFileSystem().Remove(indexPath)
+		if err := verifyIndexSignature(source.URL, indexPath, timeout); err != nil {
+			return nil, err
+		}
+	} else if utils.DirExists(indexPath) {
+		var err error
+		if indexPath, err = scanIndexDir(indexPath); err != nil {
+			return nil, err
+		}
+		defer utils.GetFileSystem().Remove(indexPath)
+	} else if !utils.FileExists(indexPath) {
+		return nil, errs.WithPath(errs.ErrFileNotFound, indexPath)
+	} else if err := verifyIndexSignature(indexPath, indexPath, timeout); err != nil {
+		return nil, err
+	}
+
+	pidxXML := xml.NewPidxXML(indexPath)
+	if err := pidxXML.Read(); err != nil {
+		return nil, err
+	}
+	return pidxXML, nil
+}
+
+// mergeIndexSources adds the packs of sources that the public index has
+// not got yet to it, recording where they come from. Sources that cannot
+// be retrieved are only warned about, unless strict.
+func mergeIndexSources(sources []IndexSource, strict bool, timeout int) error {
+	origins, err := ReadIndexOrigins()
+	if err != nil {
+		return err
+	}
+
+	packs := map[string]bool{}
+	for _, pdscTag := range Installation.PublicIndexXML.ListPdscTags() {
+		packs[pdscTag.Vendor+"."+pdscTag.Name] = true
+	}
+
+	merged := 0
+	for _, source := range sources {
+		if source.Disabled {
+			continue
+		}
+
+		pidxXML, err := readIndexSource(source, timeout)
+		if err != nil {
+			if strict {
+				return err
+			}
+			log.Warnf("Could not retrieve the index source \"%s\": %s", source.Name, err)
+			continue
+		}
+
+		pdscTags := pidxXML.ListPdscTags()
+		sort.Slice(pdscTags, func(i, j int) bool {
+			return pdscTags[i].Key() < pdscTags[j].Key()
+		})
+		count := 0
+		for _, pdscTag := range pdscTags {
+			packID := pdscTag.Vendor + "." + pdscTag.Name
+			if packs[packID] {
+				log.Debugf("Not merging %s of \"%s\", the public index has it already", packID, source.Name)
+				continue
+			}
+			if err := Installation.PublicIndexXML.AddPdsc(pdscTag); err != nil {
+				return err
+			}
+			packs[packID] = true
+			origins[packID] = source.Name
+			count++
+		}
+		log.Infof("Merged %d pack(s) of the index source \"%s\"", count, source.Name)
+		merged += count
+	}
+
+	if merged == 0 {
+		return nil
+	}
+	if err := writePublicIndex(); err != nil {
+		return err
+	}
+	return writeIndexOrigins(origins)
+}
+
+// unmergeIndexSource removes the packs merged from the index source named
+// name from the public index
+func unmergeIndexSource(name string) error {
+	origins, err := ReadIndexOrigins()
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, pdscTag := range Installation.PublicIndexXML.ListPdscTags() {
+		packID := pdscTag.Vendor + "." + pdscTag.Name
+		if origins[packID] != name {
+			continue
+		}
+		if err := Installation.PublicIndexXML.RemovePdsc(pdscTag); err != nil {
+			return err
+		}
+		removed++
+	}
+	for packID, origin := range origins {
+		if origin == name {
+			delete(origins, packID)
+		}
+	}
+
+	log.Infof("Removed %d pack(s) of the index source \"%s\"", removed, name)
+	if removed > 0 {
+		if err := writePublicIndex(); err != nil {
+			return err
+		}
+	}
+	return writeIndexOrigins(origins)
+}
+
+// writePublicIndex writes the public index back to .Web/index.pidx
+func writePublicIndex() error {
+	utils.UnsetReadOnly(Installation.PublicIndex)
+	defer utils.SetReadOnly(Installation.PublicIndex)
+	return Installation.PublicIndexXML.Write()
+}
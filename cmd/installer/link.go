@@ -0,0 +1,91 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path/filepath"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// LinkPack installs the pack described by the PDSC file pdscPath, or by the
+// only PDSC file in the folder pdscPath, by linking its folder to
+// "CMSIS_PACK_ROOT/<vendor>/<packName>/<x.y.z>/", x.y.z being the latest
+// release in the PDSC file. Changes to the pack's files are seen right away
+// by anything using the pack, and removing it only removes the link.
+func LinkPack(pdscPath string, forceReinstall bool) error {
+	log.Infof("Linking pack \"%v\"", pdscPath)
+
+	if utils.DirExists(pdscPath) {
+		matches, err := utils.GlobIn(pdscPath, "*.pdsc")
+		if err != nil {
+			return err
+		}
+		if len(matches) != 1 {
+			log.Errorf("Expected exactly one PDSC file in \"%s\", found %d", pdscPath, len(matches))
+			return errs.WithPath(errs.ErrPdscFileNotFound, pdscPath)
+		}
+		pdscPath = matches[0]
+	}
+
+	pdscPath, err := filepath.Abs(pdscPath)
+	if err != nil {
+		return err
+	}
+	if !utils.FileExists(pdscPath) {
+		return errs.WithPath(errs.ErrPdscFileNotFound, pdscPath)
+	}
+
+	pdscXML := xml.NewPdscXML(pdscPath)
+	if err := pdscXML.Read(); err != nil {
+		return err
+	}
+	tag := pdscXML.Tag()
+	if tag.Version == "" {
+		return errs.WithPath(errs.ErrPackVersionNotFoundInPdsc, pdscPath)
+	}
+
+	pdscFileName := tag.Vendor + "." + tag.Name + ".pdsc"
+	if filepath.Base(pdscPath) != pdscFileName {
+		log.Errorf("The PDSC file of %s must be called \"%s\"", tag.YamlPackID(), pdscFileName)
+		return errs.WithPath(errs.ErrPdscFileNotFound, pdscPath)
+	}
+
+	packHomeDir := filepath.Join(Installation.PackRoot, tag.Vendor, tag.Name, utils.SemverStripMeta(tag.Version))
+	if utils.DirExists(packHomeDir) || utils.IsLink(packHomeDir) {
+		if !forceReinstall {
+			log.Errorf("Pack \"%s\" is already installed here: \"%s\", use the --force-reinstall (-F) flag to force installation", tag.YamlPackID(), packHomeDir)
+			return errs.WithPath(errs.ErrPathAlreadyExists, packHomeDir)
+		}
+
+		log.Debugf("Removing \"%s\"", packHomeDir)
+		utils.UnsetReadOnlyR(packHomeDir)
+		if err := utils.GetFileSystem().RemoveAll(packHomeDir); err != nil {
+			return err
+		}
+	}
+
+	if err := utils.EnsureDir(filepath.Dir(packHomeDir)); err != nil {
+		return err
+	}
+	if err := utils.LinkDir(filepath.Dir(pdscPath), packHomeDir); err != nil {
+		return err
+	}
+
+	// Like for installed packs, removing the pack expects the PDSC file of
+	// packs not in the public index under .Local/
+	isPublic := utils.FileExists(filepath.Join(Installation.WebDir, pdscFileName)) ||
+		len(Installation.PublicIndexXML.FindPdscTags(xml.PdscTag{Vendor: tag.Vendor, Name: tag.Name})) > 0
+	if !isPublic {
+		if err := utils.CopyFile(pdscPath, filepath.Join(Installation.LocalDir, pdscFileName)); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Linked \"%s\" to \"%s\"", packHomeDir, filepath.Dir(pdscPath))
+	return Installation.touchPackIdx()
+}
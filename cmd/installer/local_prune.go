@@ -0,0 +1,89 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"net/url"
+	"path/filepath"
+	"sort"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// StalePdsc is a registration in .Local/local_repository.pidx whose PDSC
+// file no longer exists, e.g. because its development tree was deleted
+type StalePdsc struct {
+	xml.PdscTag
+
+	// PdscPath is the path of the missing PDSC file
+	PdscPath string
+}
+
+// localPdscPath returns the path of the PDSC file pdscTag of
+// .Local/local_repository.pidx was added from
+func localPdscPath(pdscTag xml.PdscTag) (string, error) {
+	parsedURL, err := url.ParseRequestURI(pdscTag.URL)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(utils.CleanPath(parsedURL.Path), pdscTag.Vendor+"."+pdscTag.Name+".pdsc"), nil
+}
+
+// staleLocalPdscs returns the registrations of localPidx whose PDSC files
+// no longer exist, sorted by pack. Registrations whose URL can't be parsed
+// are left alone.
+func staleLocalPdscs(localPidx *xml.PidxXML) []StalePdsc {
+	stale := []StalePdsc{}
+	for _, pdscTag := range localPidx.ListPdscTags() {
+		pdscPath, err := localPdscPath(pdscTag)
+		if err != nil {
+			log.Debugf("Can't tell where \"%s\" was added from: %s", pdscTag.URL, err)
+			continue
+		}
+		if !utils.FileExists(pdscPath) {
+			stale = append(stale, StalePdsc{PdscTag: pdscTag, PdscPath: pdscPath})
+		}
+	}
+
+	sort.Slice(stale, func(i, j int) bool {
+		if stale[i].Key() != stale[j].Key() {
+			return stale[i].Key() < stale[j].Key()
+		}
+		return stale[i].PdscPath < stale[j].PdscPath
+	})
+	return stale
+}
+
+// FindStaleLocalPdscs returns the registrations of packs added via PDSC
+// file whose PDSC files no longer exist
+func FindStaleLocalPdscs() ([]StalePdsc, error) {
+	if err := Installation.LocalPidx.Read(); err != nil {
+		return nil, err
+	}
+	return staleLocalPdscs(Installation.LocalPidx), nil
+}
+
+// PruneLocalPdscs removes the registrations of packs added via PDSC file
+// whose PDSC files no longer exist from .Local/local_repository.pidx, and
+// returns them
+func PruneLocalPdscs() ([]StalePdsc, error) {
+	stale, err := FindStaleLocalPdscs()
+	if err != nil || len(stale) == 0 {
+		return stale, err
+	}
+
+	for _, pdsc := range stale {
+		log.Infof("Removing \"%s\", \"%s\" no longer exists", pdsc.Key(), pdsc.PdscPath)
+		if err := Installation.LocalPidx.RemovePdsc(pdsc.PdscTag); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := Installation.LocalPidx.Write(); err != nil {
+		return nil, err
+	}
+	return stale, Installation.touchPackIdx()
+}
@@ -0,0 +1,134 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// MaterializePack extracts the files a sparse install of pack skipped, see
+// SetSlim and SetComponentFilters, from its archive in .Download/. pack is
+// the ID of an installed pack, e.g. "Vendor.Pack" or "Vendor::Pack@1.2.3",
+// its latest installed version if none is given. Only files matching one of the glob patterns
+// are extracted, see MatchPackEntry, all of them without patterns. It
+// returns the number of extracted files.
+func MaterializePack(pack string, patterns []string) (int, error) {
+	info, err := utils.ExtractPackInfo(pack)
+	if err != nil {
+		return 0, err
+	}
+
+	installedPacks, err := findInstalledPacks(false, info.Version == "")
+	if err != nil {
+		return 0, err
+	}
+	var installed *installedPack
+	for i := range installedPacks {
+		candidate := &installedPacks[i]
+		if candidate.err == nil && candidate.Vendor == info.Vendor && candidate.Name == info.Pack && (info.Version == "" || candidate.Version == info.Version) {
+			installed = candidate
+			break
+		}
+	}
+	if installed == nil {
+		log.Errorf("Pack \"%s\" is not installed", pack)
+		return 0, errs.ErrPackNotInstalled
+	}
+
+	packHomeDir := filepath.Dir(installed.pdscPath)
+	skippedPath := filepath.Join(packHomeDir, cryptography.SkippedFilesName)
+	if !utils.FileExists(skippedPath) {
+		log.Infof("%s has no skipped files", installed.YamlPackID())
+		return 0, nil
+	}
+	fsys := utils.GetFileSystem()
+	b, err := afero.ReadFile(fsys, skippedPath)
+	if err != nil {
+		return 0, err
+	}
+	toExtract := map[string]bool{}
+	remaining := []string{}
+	for _, name := range strings.Split(string(b), "\n") {
+		if name == "" {
+			continue
+		}
+		matched := len(patterns) == 0
+		for _, pattern := range patterns {
+			matched = matched || MatchPackEntry(pattern, name)
+		}
+		if matched {
+			toExtract[name] = true
+		} else {
+			remaining = append(remaining, name)
+		}
+	}
+	if len(toExtract) == 0 {
+		log.Infof("No skipped file of %s matches", installed.YamlPackID())
+		return 0, nil
+	}
+
+	packPath := filepath.Join(Installation.DownloadDir, installed.Vendor+"."+installed.Name+"."+installed.Version+".pack")
+	if !utils.FileExists(packPath) {
+		log.Errorf("\"%s\" is needed to extract the skipped files, add the pack again with --force-reinstall", packPath)
+		return 0, errs.WithPath(errs.ErrFileNotFound, packPath)
+	}
+	zipReader, err := utils.OpenZip(packPath)
+	if err != nil {
+		log.Errorf("Can't decompress \"%s\": %s", packPath, err)
+		return 0, errs.ErrFailedDecompressingFile
+	}
+	defer zipReader.Close()
+
+	packType := &PackType{PdscTag: installed.PdscTag}
+	packType.Unlock()
+	defer packType.Lock()
+
+	subfolder := archiveSubfolder(zipReader.Reader, installed.Vendor+"."+installed.Name+".pdsc")
+	stripPrefix := ""
+	if subfolder != "." {
+		stripPrefix = subfolder
+	}
+	extracted := 0
+	for _, file := range zipReader.File {
+		name := strings.ReplaceAll(file.Name, "\\", "/")
+		if stripPrefix != "" {
+			name = strings.TrimPrefix(name, stripPrefix+"/")
+		}
+		if !toExtract[name] {
+			continue
+		}
+		if err = utils.SecureInflateFileContext(operationContext, file, packHomeDir, stripPrefix); err != nil {
+			return extracted, err
+		}
+		delete(toExtract, name)
+		extracted++
+	}
+	// Files the archive lacks stay skipped
+	for name := range toExtract {
+		log.Warnf("\"%s\" not found in \"%s\"", name, packPath)
+		remaining = append(remaining, name)
+	}
+
+	if len(remaining) == 0 {
+		err = fsys.Remove(skippedPath)
+	} else {
+		err = writeSkippedFiles(packHomeDir, remaining)
+	}
+	if err != nil {
+		return extracted, err
+	}
+
+	if _, err = cryptography.VerifyEmbeddedChecksum(packHomeDir); err != nil {
+		log.Errorf("Files extracted to \"%s\" do not match the checksum file embedded in the pack", packHomeDir)
+		return extracted, err
+	}
+	return extracted, storeFiles(packHomeDir)
+}
@@ -0,0 +1,49 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import "time"
+
+// PackMetrics holds how long each phase of installing a pack took and how
+// many bytes it handled. Phases that did not happen, e.g. downloading a
+// pack that was already cached, are left zeroed.
+type PackMetrics struct {
+	PackID string
+
+	DownloadTime  time.Duration
+	DownloadBytes int64
+
+	VerificationTime time.Duration
+
+	ExtractionTime  time.Duration
+	ExtractionBytes uint64
+}
+
+// collectMetrics tells whether installed packs should have their metrics recorded
+var collectMetrics bool
+
+// gMetrics holds the metrics of the packs installed so far
+var gMetrics []PackMetrics
+
+// SetCollectMetrics enables recording metrics of the packs installed from
+// now on, discarding the ones recorded before
+func SetCollectMetrics(enabled bool) {
+	collectMetrics = enabled
+	gMetrics = nil
+}
+
+// GetMetrics returns the metrics of the packs installed since SetCollectMetrics
+func GetMetrics() []PackMetrics {
+	return gMetrics
+}
+
+// recordMetrics keeps the metrics measured while installing pack
+func recordMetrics(pack *PackType) {
+	if !collectMetrics || pack == nil {
+		return
+	}
+
+	pack.metrics.PackID = pack.PackIDWithVersion()
+	gMetrics = append(gMetrics, pack.metrics)
+}
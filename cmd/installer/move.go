@@ -0,0 +1,348 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/spf13/afero"
+)
+
+// moveMarkerFile is kept in the destination of a pack root move until
+// it is complete. It holds the path of the pack root being moved, so
+// that running the same move again resumes it.
+const moveMarkerFile = ".cpackget-move"
+
+// MovePackRoot relocates the pack root in oldPackRoot to newPackRoot.
+// PDSC files added from within the pack root are referenced from their new
+// location in .Local/local_repository.pidx and read-only flags are kept.
+// If a move gets interrupted, calling MovePackRoot again resumes it.
+func MovePackRoot(oldPackRoot, newPackRoot string) error {
+	var err error
+	if oldPackRoot, err = filepath.Abs(oldPackRoot); err != nil {
+		return err
+	}
+	if newPackRoot, err = filepath.Abs(newPackRoot); err != nil {
+		return err
+	}
+
+	if oldPackRoot == newPackRoot {
+		return errs.ErrMovingEqualPaths
+	}
+	if isWithin(oldPackRoot, newPackRoot) {
+		return errs.ErrMovingPackRootIntoItself
+	}
+
+	fsys := utils.GetFileSystem()
+	markerPath := filepath.Join(newPackRoot, moveMarkerFile)
+	marker, err := afero.ReadFile(fsys, markerPath)
+	resuming := err == nil && string(marker) == oldPackRoot
+
+	if resuming {
+		log.Infof("Resuming move of pack root \"%s\" to \"%s\"", oldPackRoot, newPackRoot)
+	} else {
+		if !utils.DirExists(oldPackRoot) {
+			return errs.ErrPackRootDoesNotExist
+		}
+		if utils.DirExists(newPackRoot) {
+			if !utils.IsEmpty(newPackRoot) {
+				return errs.WithPath(errs.ErrPackRootDestinationNotEmpty, newPackRoot)
+			}
+		} else if utils.FileExists(newPackRoot) {
+			return errs.WithPath(errs.ErrPathAlreadyExists, newPackRoot)
+		}
+
+		log.Infof("Moving pack root \"%s\" to \"%s\"", oldPackRoot, newPackRoot)
+		if !renamePackRoot(oldPackRoot, newPackRoot) {
+			if err := utils.EnsureDir(newPackRoot); err != nil {
+				return err
+			}
+			if err := afero.WriteFile(fsys, markerPath, []byte(oldPackRoot), utils.FileModeRW); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Whatever is left of the old pack root still needs to be copied
+	if utils.DirExists(oldPackRoot) {
+		if err := copyPackRoot(oldPackRoot, newPackRoot); err != nil {
+			return err
+		}
+
+		log.Debugf("Removing \"%s\"", oldPackRoot)
+		utils.UnsetReadOnlyR(oldPackRoot)
+		if err := fsys.RemoveAll(oldPackRoot); err != nil {
+			return err
+		}
+	}
+
+	if err := relocateLocalPdscs(oldPackRoot, newPackRoot); err != nil {
+		return err
+	}
+
+	utils.UnsetReadOnly(newPackRoot)
+	if utils.FileExists(markerPath) {
+		if err := fsys.Remove(markerPath); err != nil {
+			return err
+		}
+	}
+
+	for _, dir := range []string{".Web", ".Local", ".Download", ""} {
+		utils.SetReadOnly(filepath.Join(newPackRoot, dir))
+	}
+
+	log.Infof("Pack root moved to \"%s\"", newPackRoot)
+	return nil
+}
+
+// renamePackRoot moves oldPackRoot to newPackRoot in one go, which only
+// works within the same file system. It tells whether it did.
+func renamePackRoot(oldPackRoot, newPackRoot string) bool {
+	fsys := utils.GetFileSystem()
+
+	// An empty destination makes way for the pack root, whose parent needs
+	// to exist already
+	if utils.DirExists(newPackRoot) {
+		if err := fsys.Remove(newPackRoot); err != nil {
+			return false
+		}
+	}
+	if err := utils.EnsureDir(filepath.Dir(newPackRoot)); err != nil {
+		return false
+	}
+
+	// Moving a directory to another parent updates its ".." entry
+	utils.UnsetReadOnly(oldPackRoot)
+	if err := fsys.Rename(oldPackRoot, newPackRoot); err != nil {
+		log.Debugf("Could not rename \"%s\", copying it instead: %s", oldPackRoot, err)
+		utils.SetReadOnly(oldPackRoot)
+		return false
+	}
+	return true
+}
+
+// copyPackRoot copies every file of oldPackRoot not yet in newPackRoot,
+// keeping modes, modification times and hard links between its files
+func copyPackRoot(oldPackRoot, newPackRoot string) error {
+	fsys := utils.GetFileSystem()
+
+	// Copies of files with several hard links, e.g. in the content store
+	canHardLink := utils.CanHardLink()
+	linkedCopies := map[utils.FileID]string{}
+
+	// Directories stay writable until all files are copied
+	type dirMode struct {
+		path string
+		mode fs.FileMode
+	}
+	dirModes := []dirMode{}
+
+	err := afero.Walk(fsys, oldPackRoot, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if utils.ShouldAbortFunction != nil && utils.ShouldAbortFunction() {
+			return errs.ErrTerminatedByUser
+		}
+
+		rel, err := filepath.Rel(oldPackRoot, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(newPackRoot, rel)
+
+		if info.IsDir() {
+			dirModes = append(dirModes, dirMode{target, info.Mode().Perm()})
+			if err := fsys.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			return fsys.Chmod(target, 0755)
+		}
+
+		// Packs added with --link point to folders outside the pack root
+		if utils.IsLink(path) {
+			return copyPackRootLink(path, target)
+		}
+
+		if !info.Mode().IsRegular() {
+			log.Warnf("Not moving \"%s\", it is not a regular file", path)
+			return nil
+		}
+
+		if !canHardLink {
+			return copyPackRootFile(path, target, info)
+		}
+		id, links, err := utils.StatFileID(path)
+		if err != nil || links < 2 {
+			return copyPackRootFile(path, target, info)
+		}
+		if linkedCopy, ok := linkedCopies[id]; ok {
+			return linkPackRootFile(linkedCopy, target)
+		}
+		linkedCopies[id] = target
+		return copyPackRootFile(path, target, info)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Subdirectories first, so that parents are still writable
+	slices.Reverse(dirModes)
+	for _, dir := range dirModes {
+		if err := fsys.Chmod(dir.path, dir.mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyPackRootFile copies the file in path described by info to target,
+// unless a previous attempt already did
+func copyPackRootFile(path, target string, info fs.FileInfo) error {
+	fsys := utils.GetFileSystem()
+
+	if targetInfo, err := fsys.Stat(target); err == nil {
+		if targetInfo.Size() == info.Size() && targetInfo.ModTime().Equal(info.ModTime()) {
+			return nil
+		}
+		if err := fsys.Chmod(target, utils.FileModeRW); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Copying \"%s\"", path)
+	source, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := fsys.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, utils.FileModeRW)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(destination, source)
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := fsys.Chmod(target, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	// The modification time tells a resumed move the file is already copied
+	return fsys.Chtimes(target, info.ModTime(), info.ModTime())
+}
+
+// copyPackRootLink makes target a link to the same folder as the link
+// in path, unless a previous attempt already did
+func copyPackRootLink(path, target string) error {
+	linkTarget, err := utils.ReadLink(path)
+	if err != nil {
+		return err
+	}
+	if utils.IsLink(target) {
+		if existingTarget, err := utils.ReadLink(target); err == nil && existingTarget == linkTarget {
+			return nil
+		}
+		if err := utils.GetFileSystem().Remove(target); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Linking \"%s\" to \"%s\"", target, linkTarget)
+	return utils.LinkDir(linkTarget, target)
+}
+
+// linkPackRootFile makes target a hard link to the copy of a file made
+// in linkedCopy, unless a previous attempt already did
+func linkPackRootFile(linkedCopy, target string) error {
+	fsys := utils.GetFileSystem()
+
+	if utils.FileExists(target) {
+		copyID, _, err := utils.StatFileID(linkedCopy)
+		if err != nil {
+			return err
+		}
+		if targetID, _, err := utils.StatFileID(target); err == nil && targetID == copyID {
+			return nil
+		}
+		if err := fsys.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Linking \"%s\" to \"%s\"", target, linkedCopy)
+	return utils.HardLink(linkedCopy, target)
+}
+
+// relocateLocalPdscs points the entries of the moved local_repository.pidx
+// that referenced PDSC files inside oldPackRoot to newPackRoot
+func relocateLocalPdscs(oldPackRoot, newPackRoot string) error {
+	localDir := filepath.Join(newPackRoot, ".Local")
+	localPidxPath := filepath.Join(localDir, "local_repository.pidx")
+	if !utils.FileExists(localPidxPath) {
+		return nil
+	}
+
+	localPidx := xml.NewPidxXML(localPidxPath)
+	if err := localPidx.Read(); err != nil {
+		return err
+	}
+
+	relocated := false
+	for _, pdsc := range localPidx.ListPdscTags() {
+		parsedURL, err := url.ParseRequestURI(pdsc.URL)
+		if err != nil || parsedURL.Scheme != "file" {
+			continue
+		}
+
+		pdscDir := utils.CleanPath(parsedURL.Path)
+		if !isWithin(oldPackRoot, pdscDir) {
+			continue
+		}
+		rel, _ := filepath.Rel(oldPackRoot, pdscDir)
+
+		newTag := pdsc
+		location := filepath.Join(newPackRoot, rel)
+		newTag.URL = strings.ReplaceAll("file://localhost/"+location+string(os.PathSeparator), "\\", "/")
+		log.Debugf("Relocating \"%s\" to \"%s\"", pdsc.URL, newTag.URL)
+
+		if err := localPidx.RemovePdsc(pdsc); err != nil {
+			return err
+		}
+		if err := localPidx.AddPdsc(newTag); err != nil {
+			return err
+		}
+		relocated = true
+	}
+
+	if !relocated {
+		return nil
+	}
+
+	utils.UnsetReadOnly(localDir)
+	return localPidx.Write()
+}
+
+// isWithin tells whether path is dir or one of its subdirectories
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -4,21 +4,23 @@
 package installer
 
 import (
-	"archive/zip"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/url"
-	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/lu4p/cat"
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 	"github.com/open-cmsis-pack/cpackget/cmd/ui"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
 	"github.com/open-cmsis-pack/cpackget/cmd/xml"
 	"github.com/schollz/progressbar/v3"
-	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 )
 
 // PackType is the struct that represents the installation of a
@@ -38,6 +40,9 @@ type PackType struct {
 	// isDownloaded tells whether the file needed to be downloaded from a server
 	isDownloaded bool
 
+	// downloadURL is where the pack file was downloaded from, if it was
+	downloadURL string
+
 	// isPackID tells whether the path is in packID format: Vendor.PackName[.x.y.z]
 	isPackID bool
 
@@ -57,11 +62,20 @@ type PackType struct {
 	// Subfolder stores the subfolder this pack is in the compressed file.
 	Subfolder string
 
+	// sparse tells which files of the pack installations skip, see SparseSelection
+	sparse SparseSelection
+
 	// Pdsc holds a pointer to the PDSC file already parsed as XML
 	Pdsc *xml.PdscXML
 
 	// zipReader holds a pointer to the uncompressed pack file
-	zipReader *zip.ReadCloser
+	zipReader *utils.ZipReadCloser
+
+	// metrics holds what installing the pack took, see SetCollectMetrics
+	metrics PackMetrics
+
+	// releaseNotes are the releases since the installed version an update brings
+	releaseNotes []PackRelease
 
 	// Requirements represents a packs' dependencies
 	Requirements struct {
@@ -82,7 +96,8 @@ func preparePack(packPath string, toBeRemoved, forceLatest, noLocal bool, timeou
 	}
 
 	// Clean out any possible query or user auth in the URL
-	// to help finding the correct path info
+	// to help finding the correct path info. The pack is still
+	// downloaded from the full URL, which pre-signed URLs need.
 	if strings.HasPrefix(packPath, "http") {
 		url, err := url.Parse(packPath)
 		if err != nil {
@@ -130,15 +145,43 @@ func preparePack(packPath string, toBeRemoved, forceLatest, noLocal bool, timeou
 // will use the one in .Download/ if previously downloaded.
 // If the path is not a URL, it will make sure the file exists in the local file system
 func (p *PackType) fetch(timeout int) error {
-	log.Debugf("Fetching pack file \"%s\" (or just making sure it exists locally)", p.path)
+	log.Debugf("Fetching pack file \"%s\" (or just making sure it exists locally)", utils.RedactURL(p.path))
 	var err error
 	if strings.HasPrefix(p.path, "http") {
-		p.path, err = utils.DownloadFile(p.path, timeout)
-		if err == errs.ErrTerminatedByUser {
+		// A pack downloaded ahead, see PrefetchPacks, is served from .Download/
+		prefetched, prefetchedFromCache := awaitPrefetch(prefetchFileName(p.path))
+		if forceDownload && !prefetched {
+			if err = dropCachedDownload(p.path); err != nil {
+				return err
+			}
+		}
+
+		cachedPath := filepath.Join(Installation.DownloadDir, prefetchFileName(p.path))
+		cached := prefetchedFromCache
+		if !prefetched {
+			cached = utils.FileExists(cachedPath)
+		}
+		if cached && !verifyCachedDigest(cachedPath) {
+			cached = false
+		}
+		start := time.Now()
+		p.downloadURL = p.path
+		p.path, err = utils.DownloadFileContext(operationContext, p.path, timeout)
+		if errs.Is(err, errs.ErrTerminatedByUser) {
 			log.Infof("Aborting pack download. Removing \"%s\"", p.path)
 		}
 
 		p.isDownloaded = true
+		if err == nil {
+			p.metrics.DownloadTime = time.Since(start)
+			if info, statErr := utils.GetFileSystem().Stat(p.path); statErr == nil {
+				p.metrics.DownloadBytes = info.Size()
+				recordCacheUse(cached, info.Size())
+			}
+			if !cached {
+				recordCachedDigest(p.path)
+			}
+		}
 		return err
 	}
 
@@ -147,6 +190,13 @@ func (p *PackType) fetch(timeout int) error {
 		return errs.ErrFileNotFound
 	}
 
+	// Packs AddPack takes from .Download/ without looking up their URL
+	if p.isDownloaded {
+		if info, statErr := utils.GetFileSystem().Stat(p.path); statErr == nil {
+			recordCacheUse(true, info.Size())
+		}
+	}
+
 	return nil
 }
 
@@ -166,16 +216,16 @@ func (p *PackType) validate() error {
 				p.Subfolder = filepath.Dir(file.Name)
 			}
 
-			// Read pack's pdsc
-			tmpPdscFileName := filepath.Join(os.TempDir(), utils.RandStringBytes(10))
-			defer os.RemoveAll(tmpPdscFileName)
-
-			if err := utils.SecureInflateFile(file, tmpPdscFileName, ""); err != nil {
-				return err
+			// Read pack's pdsc straight out of the pack
+			reader, err := file.Open()
+			if err != nil {
+				log.Errorf("Entry \"%s\" of \"%s\" is corrupt: %s", file.Name, p.path, err)
+				return errs.ErrFailedDecompressingFile
 			}
+			defer reader.Close()
 
-			p.Pdsc = xml.NewPdscXML(filepath.Join(tmpPdscFileName, file.Name)) // #nosec
-			if err := p.Pdsc.Read(); err != nil {
+			p.Pdsc = xml.NewPdscXML(file.Name)
+			if err := p.Pdsc.Decode(reader); err != nil {
 				return err
 			}
 
@@ -205,6 +255,62 @@ func (p *PackType) validate() error {
 	return errs.ErrPdscFileNotFound
 }
 
+// checkCaseCollisions fails if files of the pack only differ by case and the
+// pack root is on a case-insensitive file system, where extracting them
+// would silently keep only one of them. They are only warned about on
+// case-sensitive file systems, as copies of the pack root to Windows or
+// macOS would lose them.
+func (p *PackType) checkCaseCollisions() error {
+	names := []string{}
+	for _, file := range p.zipReader.File {
+		if !file.FileInfo().IsDir() {
+			names = append(names, p.entryName(file.Name))
+		}
+	}
+	collisions := utils.CaseCollisions(names)
+	if len(collisions) == 0 {
+		return nil
+	}
+
+	caseInsensitive := utils.IsCaseInsensitiveDir(Installation.PackRoot)
+	for _, collision := range collisions {
+		files := "\"" + strings.Join(collision, "\", \"") + "\""
+		if caseInsensitive {
+			log.Errorf("Files %s of %s only differ by case and would overwrite each other in \"%s\"", files, p.PackID(), Installation.PackRoot)
+		} else {
+			log.Warnf("Files %s of %s only differ by case, copies of the pack root to Windows or macOS would lose all but one", files, p.PackID())
+		}
+	}
+	if caseInsensitive {
+		return errs.WithPackID(errs.ErrCaseCollision, p.PackID())
+	}
+	return nil
+}
+
+// cachedFileExtensions are the extensions of the files purge removes from .Download/
+var cachedFileExtensions = []string{".pack", ".zip", ".pdsc"}
+
+// cachedFileVersion returns the version of this pack a file in .Download/
+// was cached for, telling whether fileName is one of these files at all
+func (p *PackType) cachedFileVersion(fileName string) (string, bool) {
+	rest, found := strings.CutPrefix(fileName, p.PackID()+".")
+	if !found {
+		return "", false
+	}
+	for i := 1; i < len(rest); i++ {
+		if rest[i] != '.' {
+			continue
+		}
+		for _, extension := range cachedFileExtensions {
+			end := i + len(extension)
+			if strings.HasPrefix(rest[i:], extension) && (end == len(rest) || rest[end] == '.') {
+				return rest[:i], true
+			}
+		}
+	}
+	return "", false
+}
+
 // purge Removes cached files when
 // - It
 //   - Removes "CMSIS_PACK_ROOT/.Download/p.Vendor.p.Name.p.Version.pdsc"
@@ -212,26 +318,29 @@ func (p *PackType) validate() error {
 func (p *PackType) purge() error {
 	log.Debugf("Purging \"%v\"", p.path)
 
-	fileNamePattern := p.Vendor + "\\." + p.Name
-	if len(p.Version) > 0 {
-		fileNamePattern += "\\." + p.GetVersionNoMeta() + ".*"
-	} else {
-		fileNamePattern += "\\..*?"
-	}
-	fileNamePattern += "\\.(?:pack|zip|pdsc)"
-
-	files, err := utils.ListDir(Installation.DownloadDir, fileNamePattern)
+	// Cached files are named Vendor.Pack.x.y.z.ext, and extracted licenses
+	// Vendor.Pack.x.y.z.pack.LICENSE.txt, so globbing beats walking .Download/.
+	// Pack IDs hold no glob metacharacters, see utils.ExtractPackInfo.
+	matches, err := utils.GlobIn(Installation.DownloadDir, p.PackID()+".*")
 	if err != nil {
 		return err
 	}
 
+	files := []string{}
+	for _, match := range matches {
+		version, found := p.cachedFileVersion(filepath.Base(match))
+		if found && (len(p.Version) == 0 || version == p.GetVersionNoMeta()) {
+			files = append(files, match)
+		}
+	}
+
 	log.Debugf("Files to be purged \"%v\"", files)
 	if len(files) == 0 {
 		return errs.ErrPackNotPurgeable
 	}
 
 	for _, file := range files {
-		if err := os.Remove(file); err != nil {
+		if err := utils.GetFileSystem().Remove(file); err != nil {
 			return err
 		}
 	}
@@ -255,15 +364,22 @@ func (p *PackType) install(installation *PacksInstallationType, checkEula bool)
 	log.Debugf("Installing \"%s\"", p.path)
 
 	var err error
-	p.zipReader, err = zip.OpenReader(p.path)
+	verificationStart := time.Now()
+	p.zipReader, err = utils.OpenZip(p.path)
 	if err != nil {
 		log.Errorf("Can't decompress \"%s\": %s", p.path, err)
 		return errs.ErrFailedDecompressingFile
 	}
 
 	if err = p.validate(); err != nil {
+		p.zipReader.Close()
 		return err
 	}
+	if err = p.checkCaseCollisions(); err != nil {
+		p.zipReader.Close()
+		return err
+	}
+	p.metrics.VerificationTime = time.Since(verificationStart)
 
 	packHomeDir := filepath.Join(Installation.PackRoot, p.Vendor, p.Name, p.GetVersionNoMeta())
 	packBackupPath := filepath.Join(Installation.DownloadDir, p.PackFileName())
@@ -277,7 +393,7 @@ func (p *PackType) install(installation *PacksInstallationType, checkEula bool)
 		if checkEula {
 			ok, err := p.checkEula()
 			if err != nil {
-				if err == errs.ErrExtractEula {
+				if errs.Is(err, errs.ErrExtractEula) {
 					return p.extractEula(packBackupPath)
 				}
 				return err
@@ -291,6 +407,11 @@ func (p *PackType) install(installation *PacksInstallationType, checkEula bool)
 			// Explicitly inform the user that license has been agreed
 			fmt.Printf("Agreed to embedded license: %v", filepath.Join(packHomeDir, p.Pdsc.License))
 			fmt.Println()
+			if eula, err := p.readEula(); err == nil {
+				if err := p.recordEulaDecision(eula, true, EulaAgreedByFlag); err != nil {
+					log.Warnf("Could not record the agreement with the license: %s", err)
+				}
+			}
 		}
 	} else if ui.Extract {
 		if utils.GetEncodedProgress() {
@@ -299,6 +420,30 @@ func (p *PackType) install(installation *PacksInstallationType, checkEula bool)
 		return errs.ErrLicenseNotFound
 	}
 
+	var skipped map[string]bool
+	if !p.sparse.IsFull() {
+		if skipped, err = p.sparseSkippedPaths(); err != nil {
+			p.zipReader.Close()
+			return err
+		}
+	}
+
+	// Fail before extracting anything if the pack does not fit
+	var uncompressedSize uint64
+	for _, file := range p.zipReader.File {
+		if !isSkipped(skipped, p.entryName(file.Name)) {
+			uncompressedSize += file.UncompressedSize64
+		}
+	}
+	if err = utils.CheckDiskSpace(Installation.PackRoot, uncompressedSize); err != nil {
+		p.zipReader.Close()
+		return err
+	}
+	if err = checkPackRootQuota(uncompressedSize); err != nil {
+		p.zipReader.Close()
+		return err
+	}
+
 	// Inflate all files
 	err = utils.EnsureDir(packHomeDir)
 	if err != nil {
@@ -315,26 +460,46 @@ func (p *PackType) install(installation *PacksInstallationType, checkEula bool)
 	var encodedProgress *utils.EncodedProgress
 
 	if utils.GetEncodedProgress() {
-		encodedProgress = utils.NewEncodedProgress(int64(len(p.zipReader.File)), 0, p.path)
+		encodedProgress = utils.NewEncodedProgress(int64(len(p.zipReader.File)), utils.ProgressInstance(filepath.Base(p.path)), p.path)
 	} else if interactiveTerminal && log.GetLevel() != log.ErrorLevel {
 		progress = progressbar.Default(int64(len(p.zipReader.File)), "I:")
 	}
 
+	extractionStart := time.Now()
+	skippedFiles := []string{}
+	renamedFiles := map[string]string{}
 	for _, file := range p.zipReader.File {
 		if utils.GetEncodedProgress() {
 			_ = encodedProgress.Add(1)
 		} else if interactiveTerminal && log.GetLevel() != log.ErrorLevel {
 			_ = progress.Add64(1)
 		}
-		err = utils.SecureInflateFile(file, packHomeDir, p.Subfolder)
+		if name := p.entryName(file.Name); isSkipped(skipped, name) {
+			if !file.FileInfo().IsDir() {
+				skippedFiles = append(skippedFiles, name)
+			}
+			continue
+		} else if utils.GetEscapeWindowsNames() && !file.FileInfo().IsDir() {
+			if escaped := utils.WindowsSafeName(name); escaped != name {
+				log.Debugf("Extracting \"%s\" as \"%s\"", name, escaped)
+				renamedFiles[name] = escaped
+			}
+		}
+		err = utils.SecureInflateFileContext(operationContext, file, packHomeDir, p.Subfolder)
 		if err != nil {
 			defer p.zipReader.Close()
 
-			if err == errs.ErrTerminatedByUser {
+			if errs.Is(err, errs.ErrTerminatedByUser) {
 				log.Infof("Aborting pack extraction. Removing \"%s\"", packHomeDir)
 				if newErr := p.uninstall(installation); newErr != nil {
 					log.Error(err)
 				}
+			} else if entry, entryErr := utils.CorruptZipEntry(p.zipReader.Reader); entry != "" {
+				log.Errorf("Entry \"%s\" of \"%s\" is corrupt: %s. Removing \"%s\"", entry, p.path, entryErr, packHomeDir)
+				if newErr := p.uninstall(installation); newErr != nil {
+					log.Debug(newErr)
+				}
+				return errs.ErrFailedDecompressingFile
 			}
 			return err
 		}
@@ -342,6 +507,47 @@ func (p *PackType) install(installation *PacksInstallationType, checkEula bool)
 
 	// Close zip file so Windows can't complain if we rename it
 	p.zipReader.Close()
+	p.metrics.ExtractionTime = time.Since(extractionStart)
+	p.metrics.ExtractionBytes = uncompressedSize
+
+	if len(skippedFiles) > 0 {
+		log.Infof("Skipped %d file(s) left out of the sparse install", len(skippedFiles))
+		if err = writeSkippedFiles(packHomeDir, skippedFiles); err != nil {
+			return err
+		}
+	}
+
+	if len(renamedFiles) > 0 {
+		log.Warnf("Escaped the names of %d file(s) invalid on Windows, they are listed in \"%s\"", len(renamedFiles), filepath.Join(packHomeDir, cryptography.RenamedFilesName))
+		if err = writeRenamedFiles(packHomeDir, renamedFiles); err != nil {
+			return err
+		}
+	}
+
+	if verified, err := cryptography.VerifyEmbeddedChecksum(packHomeDir); err != nil {
+		log.Errorf("Files extracted to \"%s\" do not match the checksum file embedded in the pack, removing them", packHomeDir)
+		if newErr := p.uninstall(installation); newErr != nil {
+			log.Debug(newErr)
+		}
+		return err
+	} else if verified {
+		log.Info("Verified the extracted files against the checksum file embedded in the pack")
+	}
+
+	if err = p.runPostInstall(packHomeDir); err != nil {
+		log.Errorf("Removing \"%s\" as its post-install steps failed", packHomeDir)
+		if newErr := p.uninstall(installation); newErr != nil {
+			log.Debug(newErr)
+		}
+		return err
+	}
+
+	if err = storeFiles(packHomeDir); err != nil {
+		if newErr := p.uninstall(installation); newErr != nil {
+			log.Debug(newErr)
+		}
+		return err
+	}
 
 	pdscFileName := p.PdscFileName()
 	pdscFilePath := filepath.Join(packHomeDir, pdscFileName)
@@ -380,14 +586,21 @@ func (p *PackType) uninstall(installation *PacksInstallationType) error {
 
 	// Remove Vendor/Pack/x.y.z
 	packPath := filepath.Join(installation.PackRoot, p.Vendor, p.Name, p.GetVersionNoMeta())
-	if err := os.RemoveAll(packPath); err != nil {
+	storePaths, err := storedFiles(packPath)
+	if err != nil {
+		return err
+	}
+	if err := utils.GetFileSystem().RemoveAll(packPath); err != nil {
+		return err
+	}
+	if err := releaseStoredFiles(storePaths); err != nil {
 		return err
 	}
 
 	// Remove Vendor/Pack/ if empty
 	packPath = filepath.Join(installation.PackRoot, p.Vendor, p.Name)
 	if utils.IsEmpty(packPath) {
-		if err := os.Remove(packPath); err != nil {
+		if err := utils.GetFileSystem().Remove(packPath); err != nil {
 			return err
 		}
 
@@ -395,7 +608,7 @@ func (p *PackType) uninstall(installation *PacksInstallationType) error {
 		if !p.IsPublic {
 			localPdscFileName := p.PdscFileName()
 			filePath := filepath.Join(installation.LocalDir, localPdscFileName)
-			if err := os.Remove(filePath); err != nil {
+			if err := utils.GetFileSystem().Remove(filePath); err != nil {
 				return err
 			}
 		}
@@ -404,7 +617,7 @@ func (p *PackType) uninstall(installation *PacksInstallationType) error {
 	// Remove Vendor/ if empty
 	vendorPath := filepath.Join(installation.PackRoot, p.Vendor)
 	if utils.IsEmpty(vendorPath) {
-		if err := os.Remove(vendorPath); err != nil {
+		if err := utils.GetFileSystem().Remove(vendorPath); err != nil {
 			return err
 		}
 	}
@@ -451,13 +664,38 @@ func (p *PackType) checkEula() (bool, error) {
 		return false, err
 	}
 
+	// Licenses are not asked for again once accepted, nor if pre-approved, unless extracted
+	if !ui.Extract {
+		decision, err := p.acceptedEula(bytes)
+		if err != nil {
+			return false, err
+		}
+		if decision != nil {
+			log.Infof("The license of %s was accepted by %s on %s for %s, not asking again", p.PackID(), decision.User, decision.Time.Format(time.RFC3339), decision.Pack)
+			return true, nil
+		}
+		if eulaAllowlist[eulaHash(bytes)] {
+			log.Infof("The license of %s is pre-approved, accepting it", p.PackID())
+			if err := p.recordEulaDecision(bytes, true, EulaAllowlisted); err != nil {
+				log.Warnf("Could not record the decision on the license: %s", err)
+			}
+			return true, nil
+		}
+	}
+
 	eulaContents, err := cat.FromBytes(bytes)
 	if err != nil {
 		log.Error(err)
 		return false, err
 	}
 
-	return ui.DisplayAndWaitForEULA(p.Pdsc.License, eulaContents)
+	agreed, err := ui.DisplayAndWaitForEULA(p.Pdsc.License, eulaContents)
+	if err == nil {
+		if err := p.recordEulaDecision(bytes, agreed, EulaPrompted); err != nil {
+			log.Warnf("Could not record the decision on the license: %s", err)
+		}
+	}
+	return agreed, err
 }
 
 // extractEula extracts the pack's License to a file next to the pack's location
@@ -479,14 +717,14 @@ func (p *PackType) extractEula(packPath string) error {
 
 	if utils.FileExists(eulaFileName) {
 		utils.UnsetReadOnly(eulaFileName)
-		os.Remove(eulaFileName)
+		utils.GetFileSystem().Remove(eulaFileName)
 	}
 	if utils.FileExists(eulaFileName) {
 		log.Errorf("Cannot remove previous copy of license file: \"%s\"", eulaFileName)
 		return errs.ErrFailedCreatingFile
 	}
 
-	return os.WriteFile(eulaFileName, eulaContents, utils.FileModeRO)
+	return afero.WriteFile(utils.GetFileSystem(), eulaFileName, eulaContents, utils.FileModeRO)
 }
 
 // resolveVersionModifier takes into account eventual versionModifiers (@, @^, @~ and @>=) to determine
@@ -712,3 +950,13 @@ func (p *PackType) Lock() {
 func (p *PackType) Unlock() {
 	p.toggleReadOnly(false)
 }
+
+// writeRenamedFiles records the files whose names were escaped as they are
+// invalid on Windows in packHomeDir, by name in the pack
+func writeRenamedFiles(packHomeDir string, renamed map[string]string) error {
+	b, err := json.MarshalIndent(renamed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(utils.GetFileSystem(), filepath.Join(packHomeDir, cryptography.RenamedFilesName), append(b, '\n'), utils.FileModeRW)
+}
@@ -0,0 +1,154 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// preferCache makes AddPack install packs found in .Download/ without looking up their URL
+var preferCache bool
+
+// forceDownload makes AddPack download packs again even if they are in .Download/
+var forceDownload bool
+
+// verifyCachedPacks makes AddPack hash packs in .Download/ again before
+// installing them, downloading them again if they changed
+var verifyCachedPacks bool
+
+// cachedDigestExtension is appended to the name of a pack file in .Download/
+// for the file recording its SHA-256 hash, in the format of sha256sum
+const cachedDigestExtension = ".sha256"
+
+// SetPreferCache makes the following additions of an exact pack version
+// already in .Download/ install it from there, even if its PDSC file no
+// longer lists it. The cached file is still verified against the hash its
+// vendor publishes, see SetPackHashURLs.
+func SetPreferCache(prefer bool) {
+	preferCache = prefer
+}
+
+func GetPreferCache() bool {
+	return preferCache
+}
+
+// SetForceDownload makes the following additions of packs download them
+// again, replacing the ones in .Download/
+func SetForceDownload(force bool) {
+	forceDownload = force
+}
+
+func GetForceDownload() bool {
+	return forceDownload
+}
+
+// SetVerifyCachedPacks makes the following additions of packs found in
+// .Download/ verify them against the SHA-256 hash recorded when they were
+// downloaded, and download them again if they do not match, e.g. after
+// the disk holding the cache silently corrupted them
+func SetVerifyCachedPacks(verify bool) {
+	verifyCachedPacks = verify
+}
+
+func GetVerifyCachedPacks() bool {
+	return verifyCachedPacks
+}
+
+// cachedPack returns the path of the pack file in .Download/ AddPack
+// installs instead of looking up its URL, telling whether there is one
+func (p *PackType) cachedPack() (string, bool) {
+	if !preferCache || forceDownload || p.versionModifier != utils.ExactVersion || p.Version == "" {
+		return "", false
+	}
+	if !p.isPackID && !strings.HasPrefix(p.path, "http") {
+		return "", false
+	}
+
+	awaitPrefetch(p.PackFileName())
+	cachedPath := filepath.Join(Installation.DownloadDir, p.PackFileName())
+	if !utils.FileExists(cachedPath) || !verifyCachedDigest(cachedPath) {
+		return "", false
+	}
+	return cachedPath, true
+}
+
+// recordCachedDigest records the SHA-256 hash of the pack file in
+// .Download/ cachedPath, just downloaded, for verifyCachedDigest
+func recordCachedDigest(cachedPath string) {
+	digest, err := utils.FileSHA256(cachedPath)
+	if err != nil {
+		log.Debugf("Not recording the hash of \"%s\": %s", cachedPath, err)
+		return
+	}
+	content := digest + "  " + filepath.Base(cachedPath) + "\n"
+	if err := utils.WriteFileAtomic(cachedPath+cachedDigestExtension, []byte(content), utils.FileModeRW); err != nil {
+		log.Debugf("Not recording the hash of \"%s\": %s", cachedPath, err)
+	}
+}
+
+// verifyCachedDigest tells whether the pack file in .Download/ cachedPath
+// can be installed, verifying it against the SHA-256 hash recorded when it
+// was downloaded if SetVerifyCachedPacks asks to. A file that does not
+// match is removed, for the caller to download it again.
+func verifyCachedDigest(cachedPath string) bool {
+	if !verifyCachedPacks {
+		return true
+	}
+
+	digestPath := cachedPath + cachedDigestExtension
+	if !utils.FileExists(digestPath) {
+		log.Warnf("No hash was recorded when \"%s\" was downloaded, recording it now", cachedPath)
+		recordCachedDigest(cachedPath)
+		return true
+	}
+	b, err := afero.ReadFile(utils.GetFileSystem(), digestPath)
+	if err != nil {
+		log.Warnf("Can't read the recorded hash of \"%s\": %s", cachedPath, err)
+		return true
+	}
+	fields := strings.Fields(string(b))
+	digest, err := utils.FileSHA256(cachedPath)
+	if err != nil {
+		log.Warnf("Can't hash \"%s\": %s", cachedPath, err)
+		return true
+	}
+	if len(fields) > 0 && strings.EqualFold(fields[0], digest) {
+		log.Debugf("\"%s\" matches its recorded hash", cachedPath)
+		return true
+	}
+
+	log.Warnf("\"%s\" changed since it was downloaded, its SHA-256 hash is %s, downloading it again", cachedPath, digest)
+	utils.UnsetReadOnly(cachedPath)
+	if err := utils.GetFileSystem().Remove(cachedPath); err != nil {
+		log.Errorf("Can't remove \"%s\": %s", cachedPath, err)
+	}
+	_ = utils.GetFileSystem().Remove(digestPath)
+	return false
+}
+
+// dropCachedDownload removes the file in .Download/ a download of
+// packURL would otherwise be served from
+func dropCachedDownload(packURL string) error {
+	parsedURL, err := url.Parse(packURL)
+	if err != nil {
+		return err
+	}
+
+	cachedPath := filepath.Join(Installation.DownloadDir, path.Base(parsedURL.Path))
+	if !utils.FileExists(cachedPath) {
+		return nil
+	}
+
+	log.Debugf("Removing \"%s\" to download it again", cachedPath)
+	utils.UnsetReadOnly(cachedPath)
+	_ = utils.GetFileSystem().Remove(cachedPath + cachedDigestExtension)
+	return utils.GetFileSystem().Remove(cachedPath)
+}
@@ -0,0 +1,182 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"bufio"
+	"path/filepath"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// PackHashURL is where a vendor publishes the SHA-256 hashes of its packs
+type PackHashURL struct {
+	// Vendor is the vendor of the packs, or "*" for any vendor
+	Vendor string
+
+	// Pattern is the URL of the hash file of a pack, where {vendor}, {name},
+	// {version} and {file} are replaced with the pack's vendor, name,
+	// version and file name, e.g. https://vendor.com/hashes/{file}.sha256
+	Pattern string
+}
+
+// packHashURLs are the URLs adding packs verifies them against
+var packHashURLs []PackHashURL
+
+// SetPackHashURLs makes adding packs verify them against the hashes their
+// vendor publishes, the first matching URL wins
+func SetPackHashURLs(hashURLs []PackHashURL) {
+	packHashURLs = hashURLs
+}
+
+func GetPackHashURLs() []PackHashURL {
+	return packHashURLs
+}
+
+// ReadPackHashURLs reads the pack hash URLs in fileName, one per line:
+//
+//	# Comments and empty lines are ignored
+//	TheVendor => https://vendor.com/hashes/{vendor}.{name}.{version}.pack.sha256
+//	* => https://mirror.corp/hashes/{file}.sha256
+func ReadPackHashURLs(fileName string) ([]PackHashURL, error) {
+	file, err := utils.GetFileSystem().Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hashURLs := []PackHashURL{}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		vendor, pattern, found := strings.Cut(line, "=>")
+		vendor, pattern = strings.TrimSpace(vendor), strings.TrimSpace(pattern)
+		if !found || vendor == "" || pattern == "" {
+			log.Errorf("%s:%d: \"%s\" is not a pack hash URL", fileName, lineNumber, line)
+			return nil, errs.ErrInvalidPackHashURL
+		}
+		hashURLs = append(hashURLs, PackHashURL{Vendor: vendor, Pattern: pattern})
+	}
+	return hashURLs, scanner.Err()
+}
+
+// publishedHashURL returns the URL of the hash published for the pack,
+// or "" if its vendor does not publish any
+func (p *PackType) publishedHashURL() string {
+	for _, hashURL := range packHashURLs {
+		if hashURL.Vendor != "*" && hashURL.Vendor != p.Vendor {
+			continue
+		}
+		return strings.NewReplacer(
+			"{vendor}", p.Vendor,
+			"{name}", p.Name,
+			"{version}", p.GetVersionNoMeta(),
+			"{file}", p.PackFileName(),
+		).Replace(hashURL.Pattern)
+	}
+	return ""
+}
+
+// verifyPublishedHash checks the fetched pack file against the hash its
+// vendor publishes, if any. Downloaded packs not matching it are removed.
+func (p *PackType) verifyPublishedHash(timeout int) error {
+	hashURL := p.publishedHashURL()
+	if hashURL == "" {
+		return nil
+	}
+
+	// Hash files of different packs may share their name, e.g. SHA256SUMS,
+	// so they never go to the download cache
+	fsys := utils.GetFileSystem()
+	hashFile, err := afero.TempFile(fsys, "", "cpackget-*.sha256")
+	if err != nil {
+		return err
+	}
+	hashPath := hashFile.Name()
+	hashFile.Close()
+	defer fsys.Remove(hashPath) //nolint:errcheck
+
+	log.Debugf("Verifying \"%s\" against \"%s\"", p.path, hashURL)
+	if err := utils.DownloadFileToContext(operationContext, hashURL, hashPath, timeout); err != nil {
+		log.Errorf("Could not get the published hash \"%s\" of %s: %s", hashURL, p.PackIDWithVersion(), err)
+		return err
+	}
+
+	publishedDigest, err := readPublishedHash(hashPath, p.PackFileName())
+	if err != nil {
+		return err
+	}
+	if publishedDigest == "" {
+		log.Errorf("The published hash \"%s\" holds no hash of \"%s\"", hashURL, p.PackFileName())
+		return errs.WithURL(errs.ErrIntegrityCheckFailed, hashURL)
+	}
+
+	var digest string
+	if !utils.LoadVerification("sha256", p.path, &digest) {
+		digest, err = utils.FileSHA256(p.path)
+		if err != nil {
+			return err
+		}
+	}
+	if digest != publishedDigest {
+		log.Errorf("The SHA-256 hash of \"%s\", %s, does not match the published one, %s", filepath.Base(p.path), digest, publishedDigest)
+		if p.isDownloaded {
+			_ = fsys.Remove(p.path)
+		}
+		return errs.WithURL(errs.ErrIntegrityCheckFailed, hashURL)
+	}
+
+	utils.StoreVerification("sha256", p.path, digest)
+	log.Infof("Verified %s against its published hash", p.PackIDWithVersion())
+	return nil
+}
+
+// readPublishedHash returns the lower case hash of packFileName in the hash
+// file in hashPath, or "" if it holds none. The file either holds just the
+// hash, or lines of hashes and file names like sha256sum writes them:
+//
+//	<hash>  Vendor.Pack.1.2.3.pack
+//	<hash> *Vendor.Other.1.0.0.pack
+func readPublishedHash(hashPath, packFileName string) (string, error) {
+	file, err := utils.GetFileSystem().Open(hashPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1:
+			return strings.ToLower(fields[0]), nil
+		case len(fields) >= 2 && strings.TrimPrefix(fields[1], "*") == packFileName:
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// verifyPinnedSigner checks that the fetched pack file is signed by a key
+// pinned for its vendor, if any, see cryptography.SetPinnedSigners.
+// Downloaded packs that are not are removed.
+func (p *PackType) verifyPinnedSigner() error {
+	if err := cryptography.VerifyPinnedSignature(p.path, p.Vendor); err != nil {
+		log.Errorf("%s is not signed by a key pinned for %s", p.PackIDWithVersion(), p.Vendor)
+		if p.isDownloaded {
+			_ = utils.GetFileSystem().Remove(p.path)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// PinnedPacksName is the file in .Local/ holding the versions packs are
+// pinned to, by Vendor.Pack
+const PinnedPacksName = "pinned_packs.json"
+
+// forcePinned lets pinned packs be updated and removed
+var forcePinned bool
+
+// SetForcePinned lets the following updates and removals change pinned packs
+func SetForcePinned(force bool) {
+	forcePinned = force
+}
+
+func GetForcePinned() bool {
+	return forcePinned
+}
+
+// ReadPinnedPacks returns the versions packs are pinned to, by Vendor.Pack
+func ReadPinnedPacks() (map[string]string, error) {
+	pins := map[string]string{}
+	pinsPath := filepath.Join(Installation.LocalDir, PinnedPacksName)
+	if !utils.FileExists(pinsPath) {
+		return pins, nil
+	}
+	b, err := afero.ReadFile(utils.GetFileSystem(), pinsPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &pins); err != nil {
+		log.Errorf("Can't parse \"%s\": %s", pinsPath, err)
+		return nil, err
+	}
+	return pins, nil
+}
+
+// writePinnedPacks records pins, no pins removing the recorded ones
+func writePinnedPacks(pins map[string]string) error {
+	pinsPath := filepath.Join(Installation.LocalDir, PinnedPacksName)
+	if len(pins) == 0 {
+		if !utils.FileExists(pinsPath) {
+			return nil
+		}
+		return utils.GetFileSystem().Remove(pinsPath)
+	}
+
+	b, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(pinsPath, append(b, '\n'), utils.FileModeRW)
+}
+
+// pinnedPackInfo returns the vendor, name and exact version, if any, of the
+// pack packPath refers to, which also may be written Vendor.Pack@x.y.z
+func pinnedPackInfo(packPath string) (utils.PackInfo, error) {
+	if !strings.Contains(packPath, "::") {
+		packPath = strings.Replace(packPath, "@", ".", 1)
+	}
+	info, err := utils.ExtractPackInfo(packPath)
+	if err != nil {
+		return info, err
+	}
+	if !info.IsPackID || (info.Version != "" && info.VersionModifier != utils.ExactVersion) {
+		log.Errorf("\"%s\" is not a pack reference like Vendor::Pack[@x.y.z]", packPath)
+		return info, errs.ErrBadPackName
+	}
+	return info, nil
+}
+
+// PinPack pins the pack packPath refers to to its installed version given
+// in packPath, or else to its latest installed version. Pinned packs are
+// skipped by updates of all packs and removals of all packs, and updating
+// or removing them fails unless forced with SetForcePinned.
+func PinPack(packPath string) error {
+	info, err := pinnedPackInfo(packPath)
+	if err != nil {
+		return err
+	}
+
+	installedPacks, err := findInstalledPacks(false, false)
+	if err != nil {
+		return err
+	}
+	version := ""
+	for _, installedPack := range installedPacks {
+		if installedPack.Vendor != info.Vendor || installedPack.Name != info.Pack {
+			continue
+		}
+		if info.Version != "" {
+			if installedPack.Version == info.Version {
+				version = info.Version
+			}
+		} else if version == "" || utils.SemverCompare(installedPack.Version, version) > 0 {
+			version = installedPack.Version
+		}
+	}
+	if version == "" {
+		log.Errorf("Pack \"%s\" is not installed", packPath)
+		return errs.ErrPackNotInstalled
+	}
+
+	pins, err := ReadPinnedPacks()
+	if err != nil {
+		return err
+	}
+	pins[info.Vendor+"."+info.Pack] = version
+	if err = writePinnedPacks(pins); err != nil {
+		return err
+	}
+	log.Infof("Pinned %s::%s@%s", info.Vendor, info.Pack, version)
+	return nil
+}
+
+// UnpinPack removes the pin of the pack packPath refers to
+func UnpinPack(packPath string) error {
+	info, err := pinnedPackInfo(packPath)
+	if err != nil {
+		return err
+	}
+
+	pins, err := ReadPinnedPacks()
+	if err != nil {
+		return err
+	}
+	key := info.Vendor + "." + info.Pack
+	version, found := pins[key]
+	if !found || (info.Version != "" && info.Version != version) {
+		log.Infof("Pack \"%s\" is not pinned", packPath)
+		return nil
+	}
+	delete(pins, key)
+	if err = writePinnedPacks(pins); err != nil {
+		return err
+	}
+	log.Infof("Unpinned %s::%s@%s", info.Vendor, info.Pack, version)
+	return nil
+}
+
+// checkPinned returns errs.ErrPackPinned if the pack vendor.name is pinned
+// to version, or to any version if version is empty, unless forced with
+// SetForcePinned
+func checkPinned(vendor, name, version string) error {
+	pins, err := ReadPinnedPacks()
+	if err != nil {
+		return err
+	}
+	pinnedVersion, found := pins[vendor+"."+name]
+	if !found || (version != "" && version != pinnedVersion) {
+		return nil
+	}
+	if forcePinned {
+		log.Warnf("Changing %s::%s, pinned to %s, as forced", vendor, name, pinnedVersion)
+		return nil
+	}
+	log.Errorf("%s::%s is pinned to %s, unpin it with \"cpackget unpin %s::%s\" or pass --force", vendor, name, pinnedVersion, vendor, name)
+	return errs.ErrPackPinned
+}
+
+// releasePin removes the pin of the pack vendor.name if it is pinned to
+// version, or to any version if version is empty, once it was removed
+func releasePin(vendor, name, version string) error {
+	pins, err := ReadPinnedPacks()
+	if err != nil {
+		return err
+	}
+	key := vendor + "." + name
+	if pinnedVersion, found := pins[key]; !found || (version != "" && version != pinnedVersion) {
+		return nil
+	}
+	delete(pins, key)
+	return writePinnedPacks(pins)
+}
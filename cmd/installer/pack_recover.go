@@ -0,0 +1,56 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"errors"
+	"io/fs"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// installOrRecover installs the pack. If its downloaded file, possibly an old
+// one in .Download/, turns out corrupt, the pack alone is downloaded again and
+// installed once more, unless the file matches the hash its vendor publishes.
+func (p *PackType) installOrRecover(installation *PacksInstallationType, checkEula bool, timeout int) error {
+	err := p.install(installation, checkEula)
+	if !errs.Is(err, errs.ErrFailedDecompressingFile) || p.downloadURL == "" {
+		return err
+	}
+
+	if digest, hashErr := utils.FileSHA256(p.path); hashErr == nil {
+		log.Warnf("\"%s\" is corrupt, its SHA-256 hash is %s", p.path, digest)
+	}
+	if p.publishedHashURL() != "" {
+		if hashErr := p.verifyPublishedHash(timeout); hashErr == nil {
+			log.Errorf("%s matches the hash its vendor publishes, the pack itself is corrupt", p.PackIDWithVersion())
+			return err
+		}
+	}
+
+	// verifyPublishedHash already removed a file not matching the published hash
+	if removeErr := utils.GetFileSystem().Remove(p.path); removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+		log.Errorf("Can't remove the corrupt \"%s\": %s", p.path, removeErr)
+		return err
+	}
+
+	log.Infof("Downloading %s again from \"%s\"", p.PackIDWithVersion(), utils.RedactURL(p.downloadURL))
+	p.path = p.downloadURL
+	if err = p.fetch(timeout); err != nil {
+		return err
+	}
+	if err = p.verifyPublishedHash(timeout); err != nil {
+		return err
+	}
+	if err = p.verifyPinnedSigner(); err != nil {
+		return err
+	}
+	if err = p.install(installation, checkEula); errs.Is(err, errs.ErrFailedDecompressingFile) {
+		log.Errorf("%s is corrupt again, the server likely has a corrupt copy", p.PackIDWithVersion())
+		_ = utils.GetFileSystem().Remove(p.path)
+	}
+	return err
+}
@@ -9,9 +9,9 @@ import (
 	"strings"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
 	"github.com/open-cmsis-pack/cpackget/cmd/xml"
-	log "github.com/sirupsen/logrus"
 )
 
 // PdscType is the struct that represents the installation of a
@@ -43,11 +43,8 @@ func preparePdsc(pdscPath string) (*PdscType, error) {
 	pdsc.Vendor = info.Vendor
 	pdsc.Version = info.Version
 
-	if !Installation.localIsLoaded {
-		if err := Installation.LocalPidx.Read(); err != nil {
-			return pdsc, err
-		}
-		Installation.localIsLoaded = true
+	if err := Installation.loadLocalPidx(); err != nil {
+		return pdsc, err
 	}
 
 	return pdsc, err
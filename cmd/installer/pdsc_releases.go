@@ -0,0 +1,51 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"sync"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// gPdscReleases keeps the PDSC files read to resolve pack versions, by path
+// and SHA-256 hash, so that resolving many packs of the same vendor, e.g. of
+// a packs list, parses their PDSC file once. The hash keeps PDSC files
+// downloaded again, whose modification time changes, cached as long as
+// their contents do not.
+var gPdscReleases = struct {
+	sync.Mutex
+	pdscs map[string]*xml.PdscXML
+}{pdscs: make(map[string]*xml.PdscXML)}
+
+// readPdscReleases returns the PDSC file in pdscPath, parsed once per
+// contents. It must not be modified.
+func readPdscReleases(pdscPath string) (*xml.PdscXML, error) {
+	digest, err := utils.FileSHA256(pdscPath)
+	if err != nil {
+		pdscXML := xml.NewPdscXML(pdscPath)
+		return pdscXML, pdscXML.Read()
+	}
+
+	key := pdscPath + "\x00" + digest
+	gPdscReleases.Lock()
+	pdscXML, found := gPdscReleases.pdscs[key]
+	gPdscReleases.Unlock()
+	if found {
+		log.Debugf("Using the releases of \"%s\" read before", pdscPath)
+		return pdscXML, nil
+	}
+
+	pdscXML = xml.NewPdscXML(pdscPath)
+	if err := pdscXML.Read(); err != nil {
+		return pdscXML, err
+	}
+
+	gPdscReleases.Lock()
+	gPdscReleases.pdscs[key] = pdscXML
+	gPdscReleases.Unlock()
+	return pdscXML, nil
+}
@@ -0,0 +1,180 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// postInstallCommands are the commands the post-install steps of packs may
+// run. Post-install steps are disabled while it is empty.
+var postInstallCommands map[string]bool
+
+// PostInstallTimeout bounds how long each post-install step may run
+var PostInstallTimeout = 5 * time.Minute
+
+// postInstallEnv are the environment variables post-install steps inherit,
+// others like credentials are left out
+var postInstallEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TMPDIR", "TEMP", "TMP", "SYSTEMROOT"}
+
+// SetPostInstallCommands makes installing packs run the post-install steps
+// declared in their PDSC file, if all of them run one of commands. Steps run
+// sandboxed, without network access, see runPostInstall. No commands
+// disables post-install steps.
+func SetPostInstallCommands(commands []string) {
+	postInstallCommands = nil
+	for _, command := range commands {
+		if command = strings.TrimSpace(command); command != "" {
+			if postInstallCommands == nil {
+				postInstallCommands = map[string]bool{}
+			}
+			postInstallCommands[command] = true
+		}
+	}
+}
+
+// runPostInstall runs the post-install steps of the pack installed in
+// packHomeDir, one after the other, logging all of their output. All
+// steps are checked before running any: they must run an allowed command,
+// found in PATH, from within the pack's folder. Steps only get a few
+// environment variables and no network access.
+func (p *PackType) runPostInstall(packHomeDir string) error {
+	steps := p.Pdsc.PostInstallTag.Steps
+	if len(steps) == 0 {
+		return nil
+	}
+
+	packID := p.Vendor + "::" + p.Name + "@" + p.GetVersionNoMeta()
+	if len(postInstallCommands) == 0 {
+		log.Warnf("Pack \"%s\" declares %d post-install step(s), not running them. Allow their commands with --post-install-commands", packID, len(steps))
+		return nil
+	}
+
+	packHomeDir, err := filepath.Abs(packHomeDir)
+	if err != nil {
+		return err
+	}
+	commands := make([]string, len(steps))
+	dirs := make([]string, len(steps))
+	for i, step := range steps {
+		if !postInstallCommands[step.Command] || strings.ContainsAny(step.Command, `/\`) {
+			log.Errorf("Post-install step %d of \"%s\" runs \"%s\", which is not among the allowed commands", i+1, packID, step.Command)
+			return errs.ErrPostInstallNotAllowed
+		}
+		command, err := exec.LookPath(step.Command)
+		if err != nil {
+			log.Errorf("Post-install step %d of \"%s\" runs \"%s\", which is not found: %s", i+1, packID, step.Command, err)
+			return errs.ErrPostInstallFailed
+		}
+		dir := filepath.Join(packHomeDir, filepath.FromSlash(step.Dir))
+		if rel, err := filepath.Rel(packHomeDir, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			log.Errorf("Post-install step %d of \"%s\" runs in \"%s\", outside of the pack's folder", i+1, packID, step.Dir)
+			return errs.ErrPostInstallNotAllowed
+		}
+		commands[i] = command
+		dirs[i] = dir
+	}
+
+	env := []string{}
+	for _, name := range postInstallEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	env = append(env,
+		"CMSIS_PACK_ROOT="+Installation.PackRoot,
+		"CPACKGET_PACK_DIR="+packHomeDir,
+		"CPACKGET_PACK_ID="+packID,
+	)
+
+	for i, step := range steps {
+		log.Infof("Running post-install step %d/%d of \"%s\": %s %s (in \"%s\")", i+1, len(steps), packID, step.Command, strings.Join(step.Args, " "), dirs[i])
+
+		ctx, cancel := context.WithTimeout(operationContext, PostInstallTimeout)
+		cmd := exec.CommandContext(ctx, commands[i], step.Args...) // #nosec
+		cmd.Dir = dirs[i]
+		cmd.Env = env
+		output := &postInstallOutput{prefix: packID + ": "}
+		cmd.Stdout = output
+		cmd.Stderr = output
+		if err := sandbox(cmd); err != nil {
+			cancel()
+			log.Errorf("Can't sandbox the post-install steps of \"%s\": %s", packID, err)
+			return errs.ErrPostInstallNoSandbox
+		}
+
+		start := time.Now()
+		err := cmd.Run()
+		output.flush()
+		cancel()
+		if ctx.Err() != nil && operationContext.Err() != nil {
+			return utils.ContextError(operationContext)
+		}
+
+		var exitErr *exec.ExitError
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			log.Errorf("Post-install step %d of \"%s\" did not finish within %s", i+1, packID, PostInstallTimeout)
+			return errs.ErrPostInstallFailed
+		case errors.As(err, &exitErr):
+			log.Errorf("Post-install step %d of \"%s\" failed with exit code %d", i+1, packID, exitErr.ExitCode())
+			return errs.ErrPostInstallFailed
+		case err != nil:
+			if isSandboxError(err) {
+				log.Errorf("Can't sandbox the post-install steps of \"%s\": %s", packID, err)
+				return errs.ErrPostInstallNoSandbox
+			}
+			log.Errorf("Post-install step %d of \"%s\" could not run: %s", i+1, packID, err)
+			return errs.ErrPostInstallFailed
+		}
+		log.Infof("Post-install step %d/%d of \"%s\" succeeded in %s", i+1, len(steps), packID, time.Since(start).Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+// postInstallOutput logs the output of a post-install step line by line
+type postInstallOutput struct {
+	mu     sync.Mutex
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (o *postInstallOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buf.Write(b)
+	for {
+		line, err := o.buf.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write
+			o.buf.WriteString(line)
+			break
+		}
+		log.Info(o.prefix + strings.TrimRight(line, "\r\n"))
+	}
+	return len(b), nil
+}
+
+// flush logs the last line of output, if it did not end with a new line
+func (o *postInstallOutput) flush() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.buf.Len() > 0 {
+		log.Info(o.prefix + strings.TrimRight(o.buf.String(), "\r\n"))
+		o.buf.Reset()
+	}
+}
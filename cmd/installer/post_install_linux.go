@@ -0,0 +1,31 @@
+//go:build linux
+// +build linux
+
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// sandbox runs cmd in network and user namespaces of its own, so that it
+// only sees a loopback interface and cannot reach the network
+func sandbox(cmd *exec.Cmd) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+	}
+	return nil
+}
+
+// isSandboxError tells whether err comes from the system refusing to create
+// the namespaces, e.g. with unprivileged user namespaces disabled
+func isSandboxError(err error) bool {
+	return errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOSPC)
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// sandbox refuses to run cmd, as its network access cannot be cut off here
+func sandbox(cmd *exec.Cmd) error {
+	return errors.New("cutting off the network of post-install steps is only supported on Linux")
+}
+
+// isSandboxError tells whether err comes from sandboxing a command
+func isSandboxError(err error) bool {
+	return false
+}
@@ -0,0 +1,196 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// prefetchedPack is a pack file PrefetchPacks downloads to .Download/
+type prefetchedPack struct {
+	// index is the position of the pack in the batch
+	index int
+
+	// done is closed once the download is over, err telling how it went
+	done chan struct{}
+	err  error
+
+	// cached tells whether the pack file was in .Download/ already
+	cached bool
+}
+
+// gPrefetch holds the pack files being downloaded ahead of AddPack
+var gPrefetch struct {
+	sync.Mutex
+
+	// packs are the pack files being downloaded, by file name in .Download/
+	packs map[string]*prefetchedPack
+
+	// claimed is how many packs of the batch AddPack has got to, the packs
+	// after them are only downloaded up to the depth given to PrefetchPacks
+	claimed int
+	moved   *sync.Cond
+}
+
+// PrefetchPacks downloads the pack files of packPaths, the ones on the
+// Internet and the ones resolved from the index, to .Download/ in the
+// background, while AddPack installs the previous ones. At most depth pack
+// files are downloaded ahead of the pack AddPack is fetching. Packs whose
+// file cannot be told in advance, e.g. installed or local ones, are left to
+// AddPack. The returned function stops the downloads left.
+func PrefetchPacks(packPaths []string, depth, timeout int) func() {
+	if depth <= 0 {
+		return func() {}
+	}
+
+	packURLs := []string{}
+	for _, packPath := range packPaths {
+		if packURL := prefetchURL(packPath, timeout); packURL != "" {
+			packURLs = append(packURLs, packURL)
+		}
+	}
+	if len(packURLs) < 2 {
+		return func() {}
+	}
+
+	gPrefetch.Lock()
+	gPrefetch.packs = make(map[string]*prefetchedPack, len(packURLs))
+	gPrefetch.claimed = 0
+	gPrefetch.moved = sync.NewCond(&gPrefetch.Mutex)
+	for index, packURL := range packURLs {
+		gPrefetch.packs[prefetchFileName(packURL)] = &prefetchedPack{index: index, done: make(chan struct{})}
+	}
+	gPrefetch.Unlock()
+
+	log.Infof("Downloading %d pack(s) while installing, up to %d ahead", len(packURLs), depth)
+	ctx, cancel := context.WithCancel(utils.WithQuietDownloads(operationContext))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		downloaded := 0
+		for index, packURL := range packURLs {
+			prefetched := waitForPrefetchTurn(ctx, index, depth, packURL)
+			if prefetched == nil {
+				continue
+			}
+			if ctx.Err() == nil {
+				prefetched.cached, prefetched.err = prefetchPack(ctx, packURL, timeout)
+			} else {
+				prefetched.err = utils.ContextError(ctx)
+			}
+			if prefetched.err == nil {
+				downloaded++
+				log.Infof("Downloaded %d of %d pack(s) ahead", downloaded, len(packURLs))
+			}
+			close(prefetched.done)
+		}
+	}()
+
+	return func() {
+		cancel()
+		gPrefetch.Lock()
+		gPrefetch.moved.Broadcast()
+		gPrefetch.Unlock()
+		wg.Wait()
+
+		gPrefetch.Lock()
+		gPrefetch.packs = nil
+		gPrefetch.Unlock()
+	}
+}
+
+// prefetchURL returns where the file of the pack in packPath is downloaded
+// from, or "" if this cannot be told before adding it
+func prefetchURL(packPath string, timeout int) string {
+	if strings.HasPrefix(packPath, "http") {
+		return packPath
+	}
+	if ext := filepath.Ext(packPath); ext == ".pack" || ext == ".zip" || ext == ".pdsc" || utils.DirExists(packPath) {
+		return ""
+	}
+
+	pack, err := preparePack(packPath, false, false, false, timeout)
+	if err != nil || pack.isInstalled || !pack.isPackID {
+		return ""
+	}
+	packURL, err := FindPackURL(pack)
+	if err != nil || !strings.HasPrefix(packURL, "http") {
+		return ""
+	}
+	return packURL
+}
+
+// prefetchFileName returns the name packURL is downloaded to in .Download/
+func prefetchFileName(packURL string) string {
+	parsedURL, err := url.Parse(packURL)
+	if err != nil {
+		return ""
+	}
+	return path.Base(parsedURL.Path)
+}
+
+// waitForPrefetchTurn waits until the index-th pack of the batch is at most
+// depth packs ahead of the one AddPack is fetching, returning it, or nil if
+// AddPack already got to it
+func waitForPrefetchTurn(ctx context.Context, index, depth int, packURL string) *prefetchedPack {
+	gPrefetch.Lock()
+	defer gPrefetch.Unlock()
+
+	prefetched := gPrefetch.packs[prefetchFileName(packURL)]
+	if prefetched == nil || prefetched.index != index {
+		return nil
+	}
+	for index >= gPrefetch.claimed+depth && ctx.Err() == nil {
+		gPrefetch.moved.Wait()
+	}
+	return prefetched
+}
+
+// prefetchPack downloads packURL to .Download/, telling whether it was there already
+func prefetchPack(ctx context.Context, packURL string, timeout int) (bool, error) {
+	if forceDownload {
+		if err := dropCachedDownload(packURL); err != nil {
+			return false, err
+		}
+	}
+	cached := utils.FileExists(filepath.Join(Installation.DownloadDir, prefetchFileName(packURL)))
+	_, err := utils.DownloadFileContext(ctx, packURL, timeout)
+	if err != nil {
+		log.Debugf("Could not download \"%s\" ahead: %s", utils.RedactURL(packURL), err)
+	}
+	return cached, err
+}
+
+// awaitPrefetch waits for the download of fileName to .Download/ started by
+// PrefetchPacks, if any, telling whether it succeeded and whether the file
+// was in .Download/ already. AddPack downloads the file itself otherwise.
+func awaitPrefetch(fileName string) (bool, bool) {
+	gPrefetch.Lock()
+	prefetched := gPrefetch.packs[fileName]
+	if prefetched == nil {
+		gPrefetch.Unlock()
+		return false, false
+	}
+	if prefetched.index+1 > gPrefetch.claimed {
+		gPrefetch.claimed = prefetched.index + 1
+		gPrefetch.moved.Broadcast()
+	}
+	gPrefetch.Unlock()
+
+	<-prefetched.done
+
+	gPrefetch.Lock()
+	delete(gPrefetch.packs, fileName)
+	gPrefetch.Unlock()
+	return prefetched.err == nil, prefetched.cached
+}
@@ -0,0 +1,60 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// ProjectPackRootMarker is the file telling which pack root the project
+// it is in uses, relative to the project folder
+const ProjectPackRootMarker = ".cpackget/root"
+
+// projectPackRootDefault is the pack root of projects with an empty marker
+const projectPackRootDefault = ".cpackget/packs"
+
+// FindProjectPackRoot looks for ProjectPackRootMarker in dir and its parents
+// and returns the pack root it holds, or "" if dir is not in a project.
+// The marker holds the path of the pack root on its first line, relative to
+// the folder containing .cpackget/, or nothing for .cpackget/packs.
+func FindProjectPackRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		markerPath := filepath.Join(dir, filepath.FromSlash(ProjectPackRootMarker))
+		if utils.FileExists(markerPath) {
+			content, err := afero.ReadFile(utils.GetFileSystem(), markerPath)
+			if err != nil {
+				return "", err
+			}
+
+			packRoot, _, _ := strings.Cut(string(content), "\n")
+			packRoot = strings.TrimSpace(packRoot)
+			if packRoot == "" {
+				packRoot = projectPackRootDefault
+			}
+			packRoot = filepath.FromSlash(packRoot)
+			if !filepath.IsAbs(packRoot) {
+				packRoot = filepath.Join(dir, packRoot)
+			}
+
+			log.Debugf("Using pack root \"%s\" of the project in \"%s\"", packRoot, dir)
+			return filepath.Clean(packRoot), nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
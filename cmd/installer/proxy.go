@@ -0,0 +1,231 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// proxyImmutableExtensions are the files the proxy never fetches again once
+// cached, as a published pack version does not change. PDSC and index files
+// are fetched on every request and only served from the cache when the
+// upstream server cannot be reached.
+var proxyImmutableExtensions = []string{".pack", ".zip"}
+
+// ProxyType serves pack, PDSC and index files from upstream servers to other
+// cpackget instances, caching them so that each pack is downloaded only once.
+//
+// Files are requested with their upstream URL as path, e.g.
+// "http://proxy:9000/https/www.keil.com/pack/index.pidx", or with
+// the URL itself when the proxy is configured as HTTP proxy.
+//
+// Only hosts the proxy was told to allow are fetched from, so that it
+// cannot be used to reach arbitrary servers. The hosts of the vendors
+// listed in an index it serves are allowed from then on.
+type ProxyType struct {
+	// CacheDir is where the files fetched from upstream are kept
+	CacheDir string
+
+	client *http.Client
+
+	// allowedHosts holds the hosts, with or without port, files are fetched from.
+	// "*" allows any host.
+	allowedHosts sync.Map
+
+	// locks holds a mutex per cached file, so that concurrent requests
+	// for the same file fetch it only once
+	locks sync.Map
+}
+
+// NewProxy returns a proxy caching upstream files in cacheDir,
+// fetched from allowedHosts and the vendors of indexes it serves
+func NewProxy(cacheDir string, allowedHosts []string) *ProxyType {
+	proxy := &ProxyType{
+		CacheDir: cacheDir,
+		client:   &http.Client{Transport: utils.DownloadTransport("")},
+	}
+	for _, host := range allowedHosts {
+		proxy.allowedHosts.Store(strings.ToLower(host), true)
+	}
+	return proxy
+}
+
+// allowed tells whether files may be fetched from host
+func (p *ProxyType) allowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, candidate := range []string{"*", host, strings.Split(host, ":")[0]} {
+		if _, ok := p.allowedHosts.Load(candidate); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowIndexedVendors allows the hosts of the PDSC files listed in the index in cachePath
+func (p *ProxyType) allowIndexedVendors(cachePath string) {
+	index := xml.NewPidxXML(cachePath)
+	if err := index.Read(); err != nil {
+		log.Debugf("Could not read the vendors of \"%s\": %s", cachePath, err)
+		return
+	}
+	for _, pdsc := range index.ListPdscTags() {
+		if parsedURL, err := url.Parse(pdsc.URL); err == nil && parsedURL.Host != "" {
+			p.allowedHosts.LoadOrStore(strings.ToLower(parsedURL.Host), true)
+		}
+	}
+}
+
+// upstreamURL returns the upstream URL requested by r and where it is cached
+func (p *ProxyType) upstreamURL(r *http.Request) (string, string, bool) {
+	scheme, host, filePath := r.URL.Scheme, r.URL.Host, r.URL.Path
+	if !r.URL.IsAbs() {
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+		if len(parts) < 3 {
+			return "", "", false
+		}
+		scheme, host, filePath = parts[0], parts[1], parts[2]
+	}
+
+	filePath = path.Clean("/" + filePath)
+	if scheme != "http" && scheme != "https" || host == "" || host == "." || host == ".." ||
+		strings.ContainsAny(host, "\\") || filePath == "/" {
+		return "", "", false
+	}
+
+	upstream := scheme + "://" + host + filePath
+	cacheDir := p.CacheDir
+	if r.URL.RawQuery != "" {
+		upstream += "?" + r.URL.RawQuery
+
+		// Files differing in their query only are kept apart
+		sum := sha256.Sum256([]byte(r.URL.RawQuery))
+		cacheDir = filepath.Join(cacheDir, "queries", hex.EncodeToString(sum[:16]))
+	}
+	cachePath := filepath.Join(cacheDir, scheme, strings.ReplaceAll(host, ":", "_"), filepath.FromSlash(filePath))
+	return upstream, cachePath, true
+}
+
+func (p *ProxyType) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	upstream, cachePath, ok := p.upstreamURL(r)
+	if !ok {
+		http.Error(w, "request files as /<scheme>/<host>/<path>", http.StatusBadRequest)
+		return
+	}
+
+	upstreamURL, _ := url.Parse(upstream)
+	if !p.allowed(upstreamURL.Host) {
+		log.Warnf("Not fetching \"%s\", its host is not allowed", upstream)
+		http.Error(w, "host not allowed, see --allow-host", http.StatusForbidden)
+		return
+	}
+
+	lock, _ := p.locks.LoadOrStore(cachePath, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	status := p.fetch(r.Context(), upstream, cachePath)
+	lock.(*sync.Mutex).Unlock()
+
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	if strings.EqualFold(filepath.Ext(cachePath), ".pidx") {
+		p.allowIndexedVendors(cachePath)
+	}
+
+	fsys := utils.GetFileSystem()
+	file, err := fsys.Open(cachePath)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, filepath.Base(cachePath), info.ModTime(), file)
+}
+
+// fetch makes sure cachePath holds the file in upstream and returns the
+// HTTP status the proxy should answer with
+func (p *ProxyType) fetch(ctx context.Context, upstream, cachePath string) int {
+	cached := utils.FileExists(cachePath)
+	if cached && slices.Contains(proxyImmutableExtensions, strings.ToLower(filepath.Ext(cachePath))) {
+		log.Debugf("Serving \"%s\" from the cache", upstream)
+		return http.StatusOK
+	}
+
+	status, err := p.download(ctx, upstream, cachePath)
+	if err == nil {
+		return http.StatusOK
+	}
+
+	if cached {
+		log.Warnf("Serving cached \"%s\", it could not be fetched again: %s", upstream, err)
+		return http.StatusOK
+	}
+	log.Errorf("Could not fetch \"%s\": %s", upstream, err)
+	return status
+}
+
+// download saves the file in upstream to cachePath
+func (p *ProxyType) download(ctx context.Context, upstream, cachePath string) (int, error) {
+	log.Infof("Fetching \"%s\"", upstream)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream, nil)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	req.Header.Add("User-Agent", utils.GetUserAgent())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return http.StatusBadGateway, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Debugf("bad status: %s", resp.Status)
+		status := resp.StatusCode
+		if status < http.StatusBadRequest || status >= http.StatusInternalServerError {
+			status = http.StatusBadGateway
+		}
+		return status, errs.WithURL(errs.ErrBadRequest, upstream)
+	}
+
+	if err := utils.EnsureDir(filepath.Dir(cachePath)); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	err = utils.WriteAtomic(cachePath, utils.FileModeRW, func(writer io.Writer) error {
+		_, err := utils.SecureCopyContext(ctx, writer, resp.Body)
+		return err
+	})
+	if err != nil {
+		return http.StatusBadGateway, err
+	}
+	return http.StatusOK, nil
+}
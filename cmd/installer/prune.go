@@ -0,0 +1,102 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// cachedFilePackID returns the "Vendor.Pack.x.y.z" a file in .Download/ was
+// cached for, telling whether fileName is one of these files at all
+func cachedFilePackID(fileName string) (string, bool) {
+	for i := 1; i < len(fileName); i++ {
+		if fileName[i] != '.' {
+			continue
+		}
+		for _, extension := range cachedFileExtensions {
+			end := i + len(extension)
+			if strings.HasPrefix(fileName[i:], extension) && (end == len(fileName) || fileName[end] == '.') {
+				return fileName[:i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// hasSkippedFiles tells whether the installed pack Vendor.Pack.x.y.z has
+// files a slim install skipped, which MaterializePack extracts from its archive
+func hasSkippedFiles(packID string) bool {
+	packInfo, err := utils.ExtractPackInfo(packID)
+	if err != nil {
+		return false
+	}
+	return utils.FileExists(filepath.Join(Installation.PackRoot, packInfo.Vendor, packInfo.Pack, packInfo.Version, cryptography.SkippedFilesName))
+}
+
+// PruneCache removes the pack files cached in .Download/ that were last
+// modified more than olderThan ago. With unusedOnly, files of pack versions
+// that are installed are kept. Archives of slim packs with skipped files
+// are always kept.
+func PruneCache(olderThan time.Duration, unusedOnly bool) error {
+	log.Debugf("Pruning cached files older than %v", olderThan)
+
+	files, err := utils.ListDir(Installation.DownloadDir, "")
+	if err != nil {
+		return err
+	}
+
+	fsys := utils.GetFileSystem()
+	cutoff := time.Now().Add(-olderThan)
+	pruned := 0
+	var freed uint64
+	for _, file := range files {
+		packID, found := cachedFilePackID(filepath.Base(file))
+		if !found {
+			continue
+		}
+
+		info, err := fsys.Stat(file)
+		if err != nil || info.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		if IsPackFile(file) && hasSkippedFiles(packID) {
+			log.Debugf("Keeping \"%s\", it holds the skipped files of a slim pack", file)
+			continue
+		}
+		if unusedOnly {
+			packInfo, err := utils.ExtractPackInfo(packID)
+			if err != nil {
+				log.Debugf("Keeping \"%s\", its name is not a pack's", file)
+				continue
+			}
+
+			pdscTag := xml.PdscTag{
+				Vendor:  packInfo.Vendor,
+				Name:    packInfo.Pack,
+				Version: packInfo.Version,
+			}
+			if Installation.PackIsInstalled(&PackType{PdscTag: pdscTag}, false) {
+				continue
+			}
+		}
+
+		log.Debugf("Removing \"%s\"", file)
+		if err := fsys.Remove(file); err != nil {
+			return err
+		}
+		pruned++
+		freed += uint64(info.Size())
+	}
+
+	log.Infof("Pruned %d cached file(s), freeing %s", pruned, utils.FormatBytes(freed))
+	return nil
+}
@@ -0,0 +1,89 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path/filepath"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// ReinstallPack replaces the installed pack in packPath, a pack ID with
+// its exact version or a pack file, with a fresh copy of the same version.
+// It is all or nothing: the installed files are restored if installing
+// fails. The pack's license was agreed with when it was first installed
+// and is not presented again, and so is its sparse selection kept.
+func ReinstallPack(packPath string, timeout int) (err error) {
+	pack, err := preparePack(packPath, false, false, false, timeout)
+	if err != nil {
+		return err
+	}
+
+	fullPackPath := filepath.Join(Installation.PackRoot, pack.Vendor, pack.Name, pack.GetVersionNoMeta())
+	if !pack.isInstalled || !utils.DirExists(fullPackPath) {
+		log.Errorf("Pack \"%s\" is not installed in \"%s\", it cannot be reinstalled", packPath, Installation.PackRoot)
+		return errs.ErrPackNotInstalled
+	}
+
+	log.Infof("Reinstalling pack \"%s\"", packPath)
+	defer func() {
+		notifyWebhook("add", pack, err)
+		if err == nil {
+			recordMetrics(pack)
+		}
+	}()
+
+	cachedPath, cached := pack.cachedPack()
+	if cached {
+		log.Infof("Using \"%s\" from the cache", cachedPath)
+		pack.path = cachedPath
+		pack.targetVersion = pack.Version
+		pack.isDownloaded = true
+	} else if pack.isPackID {
+		pack.path, err = FindPackURL(pack)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = pack.fetch(timeout); err != nil {
+		return err
+	}
+	if err = pack.verifyPublishedHash(timeout); err != nil {
+		return err
+	}
+	if err = pack.verifyPinnedSigner(); err != nil {
+		return err
+	}
+
+	sparse, err := sparsePacks()
+	if err != nil {
+		return err
+	}
+	pack.sparse = sparse[pack.Vendor+"."+pack.Name]
+
+	backupPackPath := fullPackPath + "_tmp"
+	log.Debugf("Making temporary backup of pack \"%s\"", packPath)
+	if err = utils.MoveFile(fullPackPath, backupPackPath); err != nil {
+		return err
+	}
+
+	pack.Unlock()
+	defer pack.Lock()
+
+	if err = pack.installOrRecover(Installation, false, timeout); err != nil {
+		log.Error("Error reinstalling pack, restoring the installed one")
+		if restoreErr := restorePackBackup(backupPackPath, fullPackPath); restoreErr != nil {
+			log.Errorf("Could not restore \"%s\": %s", fullPackPath, restoreErr)
+		}
+		return err
+	}
+
+	if err = removePackBackup(backupPackPath); err != nil {
+		return err
+	}
+	return Installation.touchPackIdx()
+}
@@ -13,12 +13,13 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 	"github.com/open-cmsis-pack/cpackget/cmd/ui"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
 	"github.com/open-cmsis-pack/cpackget/cmd/xml"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"golang.org/x/mod/semver"
 	"golang.org/x/sync/semaphore"
@@ -65,7 +66,7 @@ func GetDefaultCmsisPackRoot() string {
 }
 
 // AddPack adds a pack to the pack installation directory structure
-func AddPack(packPath string, checkEula, extractEula, forceReinstall, noRequirements bool, timeout int) error {
+func AddPack(packPath string, checkEula, extractEula, forceReinstall, noRequirements bool, timeout int) (err error) {
 
 	isDep := false
 	// tag dependency packs with $ for correct logging output
@@ -78,8 +79,9 @@ func AddPack(packPath string, checkEula, extractEula, forceReinstall, noRequirem
 		return err
 	}
 
+	shownPath := utils.RedactURL(packPath)
 	if !isDep {
-		log.Infof("Adding pack \"%s\"", packPath)
+		log.Infof("Adding pack \"%s\"", shownPath)
 	}
 
 	dropPreInstalled := false
@@ -88,25 +90,50 @@ func AddPack(packPath string, checkEula, extractEula, forceReinstall, noRequirem
 	if !extractEula && pack.isInstalled {
 		if forceReinstall {
 
-			log.Debugf("Making temporary backup of pack \"%s\"", packPath)
-
-			// Get target pack's full path and move it to a temporary "_tmp" directory
+			// Get target pack's full path and move it to a temporary "_tmp" directory.
+			// Packs installed in other pack roots of the search path are left alone.
 			fullPackPath = filepath.Join(Installation.PackRoot, pack.Vendor, pack.Name, pack.GetVersionNoMeta())
-			backupPackPath = fullPackPath + "_tmp"
+			if utils.DirExists(fullPackPath) {
+				log.Debugf("Making temporary backup of pack \"%s\"", shownPath)
 
-			if err := utils.MoveFile(fullPackPath, backupPackPath); err != nil {
-				return err
-			}
+				backupPackPath = fullPackPath + "_tmp"
+				if err := utils.MoveFile(fullPackPath, backupPackPath); err != nil {
+					return err
+				}
 
-			log.Debugf("Moved pack to temporary path \"%s\"", backupPackPath)
-			dropPreInstalled = true
+				log.Debugf("Moved pack to temporary path \"%s\"", backupPackPath)
+				dropPreInstalled = true
+			}
 		} else {
-			log.Errorf("Pack \"%s\" is already installed here: \"%s\", use the --force-reinstall (-F) flag to force installation", packPath, filepath.Join(Installation.PackRoot, pack.Vendor, pack.Name, pack.GetVersionNoMeta()))
+			installedPackRoot := Installation.packRootOf(pack.Vendor, pack.Name, pack.GetVersionNoMeta())
+			if idempotent {
+				log.Infof("Pack \"%s\" is already installed here: \"%s\"", shownPath, filepath.Join(installedPackRoot, pack.Vendor, pack.Name, pack.GetVersionNoMeta()))
+				return errs.ErrPackAlreadyInstalled
+			}
+			log.Errorf("Pack \"%s\" is already installed here: \"%s\", use the --force-reinstall (-F) flag to force installation", shownPath, filepath.Join(installedPackRoot, pack.Vendor, pack.Name, pack.GetVersionNoMeta()))
 			return nil
 		}
 	}
 
-	if pack.isPackID {
+	declined := false
+	defer func() {
+		if declined {
+			notifyWebhook("add", pack, errs.ErrEula)
+			return
+		}
+		notifyWebhook("add", pack, err)
+		if err == nil {
+			recordMetrics(pack)
+		}
+	}()
+
+	cachedPath, cached := pack.cachedPack()
+	if cached {
+		log.Infof("Using \"%s\" from the cache", cachedPath)
+		pack.path = cachedPath
+		pack.targetVersion = pack.Version
+		pack.isDownloaded = true
+	} else if pack.isPackID {
 		pack.path, err = FindPackURL(pack)
 		if err != nil {
 			return err
@@ -117,6 +144,13 @@ func AddPack(packPath string, checkEula, extractEula, forceReinstall, noRequirem
 		return err
 	}
 
+	if err = pack.verifyPublishedHash(timeout); err != nil {
+		return err
+	}
+	if err = pack.verifyPinnedSigner(); err != nil {
+		return err
+	}
+
 	// Since we only get the target version here, can only
 	// print the message now for dependencies
 	if isDep {
@@ -129,32 +163,33 @@ func AddPack(packPath string, checkEula, extractEula, forceReinstall, noRequirem
 	pack.Unlock()
 	defer pack.Lock()
 
-	if err = pack.install(Installation, checkEula || extractEula); err != nil {
+	pack.sparse = sparseSelection()
+	if err = pack.installOrRecover(Installation, checkEula || extractEula, timeout); err != nil {
 		// Just for internal purposes, is not presented as an error to the user
-		if err == errs.ErrEula {
+		if errs.Is(err, errs.ErrEula) {
+			declined = true
 			return nil
 		}
 		if dropPreInstalled {
 			log.Error("Error installing pack, reverting temporary pack to original state")
-			// Make sure the original directory doesn't exist to avoid moving errors
-			if err := os.RemoveAll(fullPackPath); err != nil {
-				return err
-			}
-			if err := utils.MoveFile(backupPackPath, fullPackPath); err != nil {
+			if err := restorePackBackup(backupPackPath, fullPackPath); err != nil {
 				return err
 			}
 		}
 		return err
 	}
 
+	if !extractEula {
+		if err = pack.rememberSparse(); err != nil {
+			return err
+		}
+	}
+
 	// Remove the original "temporary" pack
-	// Manual removal via os.RemoveAll as "_tmp" is an invalid packPath for RemovePack
 	if dropPreInstalled {
-		utils.UnsetReadOnlyR(backupPackPath)
-		if err := os.RemoveAll(backupPackPath); err != nil {
+		if err := removePackBackup(backupPackPath); err != nil {
 			return err
 		}
-		log.Debugf("Successfully deleted temporary pack \"%s\"", backupPackPath)
 	}
 
 	if !noRequirements {
@@ -201,8 +236,33 @@ func AddPack(packPath string, checkEula, extractEula, forceReinstall, noRequirem
 	return Installation.touchPackIdx()
 }
 
+// restorePackBackup moves the backup of an installed pack made before
+// reinstalling it back to fullPackPath, replacing whatever was installed
+func restorePackBackup(backupPackPath, fullPackPath string) error {
+	// Make sure the original directory doesn't exist to avoid moving errors
+	if err := utils.GetFileSystem().RemoveAll(fullPackPath); err != nil {
+		return err
+	}
+	return utils.MoveFile(backupPackPath, fullPackPath)
+}
+
+// removePackBackup removes the backup of a reinstalled pack, manually via
+// RemoveAll as "_tmp" is an invalid packPath for RemovePack
+func removePackBackup(backupPackPath string) error {
+	utils.UnsetReadOnlyR(backupPackPath)
+	storePaths, err := storedFiles(backupPackPath)
+	if err != nil {
+		return err
+	}
+	if err := utils.GetFileSystem().RemoveAll(backupPackPath); err != nil {
+		return err
+	}
+	log.Debugf("Successfully deleted temporary pack \"%s\"", backupPackPath)
+	return releaseStoredFiles(storePaths)
+}
+
 // RemovePack removes a pack given a pack path
-func RemovePack(packPath string, purge bool, timeout int) error {
+func RemovePack(packPath string, purge bool, timeout int) (err error) {
 	log.Debugf("Removing pack \"%v\"", packPath)
 
 	// TODO: by default, remove latest version first
@@ -214,12 +274,24 @@ func RemovePack(packPath string, purge bool, timeout int) error {
 	}
 
 	if pack.isInstalled {
+		if packRoot := Installation.packRootOf(pack.Vendor, pack.Name, pack.GetVersionNoMeta()); len(Installation.ExtraPackRoots) > 0 && packRoot != Installation.PackRoot {
+			log.Errorf("Pack \"%v\" is installed in \"%s\", which packs are not removed from", packPath, packRoot)
+			return errs.ErrPackNotInstalled
+		}
+		if err = checkPinned(pack.Vendor, pack.Name, pack.GetVersionNoMeta()); err != nil {
+			return err
+		}
+
+		defer func() { notifyWebhook("remove", pack, err) }()
 		// TODO: If removing-all is enabled, get rid of the version
 		// pack.Version = ""
 		pack.Unlock()
 		if err = pack.uninstall(Installation); err != nil {
 			return err
 		}
+		if err = releasePin(pack.Vendor, pack.Name, pack.GetVersionNoMeta()); err != nil {
+			return err
+		}
 
 		if purge {
 			if err = pack.purge(); err != nil {
@@ -237,6 +309,38 @@ func RemovePack(packPath string, purge bool, timeout int) error {
 	return errs.ErrPackNotInstalled
 }
 
+// RemoveAllPacks removes all the packs installed in the pack root but the
+// pinned ones, unless forced with SetForcePinned. Packs added via PDSC file
+// are left alone.
+func RemoveAllPacks(purge bool, timeout int) error {
+	installedPacks, err := findInstalledPacks(false, false)
+	if err != nil {
+		return err
+	}
+	pins, err := ReadPinnedPacks()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	removed, kept := 0, 0
+	for _, installedPack := range installedPacks {
+		if pins[installedPack.Vendor+"."+installedPack.Name] == installedPack.Version && !forcePinned {
+			log.Infof("Keeping %s::%s@%s, it is pinned", installedPack.Vendor, installedPack.Name, installedPack.Version)
+			kept++
+			continue
+		}
+		if err := RemovePack(installedPack.Vendor+"."+installedPack.Name+"."+installedPack.Version, purge, timeout); err != nil {
+			log.Error(err)
+			lastErr = err
+			continue
+		}
+		removed++
+	}
+	log.Infof("Removed %d pack(s), kept %d pinned pack(s)", removed, kept)
+	return lastErr
+}
+
 // AddPdsc adds a pack via PDSC file
 func AddPdsc(pdscPath string) error {
 	log.Infof("Adding pdsc \"%v\"", pdscPath)
@@ -247,7 +351,7 @@ func AddPdsc(pdscPath string) error {
 	}
 
 	if err := pdsc.install(Installation); err != nil {
-		if err == errs.ErrPdscEntryExists {
+		if errs.Is(err, errs.ErrPdscEntryExists) {
 			log.Info(err)
 			return nil
 		}
@@ -289,18 +393,27 @@ func RemovePdsc(pdscPath string) error {
 func massDownloadPdscFiles(pdscTag xml.PdscTag, skipInstalledPdscFiles bool, timeout int) {
 	if err := Installation.downloadPdscFile(pdscTag, skipInstalledPdscFiles, timeout); err != nil {
 		log.Error(err)
+		recordFailedPdsc(pdscTag, false)
 	}
 }
 
 // UpdatePack updates an installed pack to the latest version
-func UpdatePack(packPath string, checkEula, noRequirements bool, timeout int) error {
+func UpdatePack(packPath string, checkEula, noRequirements bool, timeout int) (err error) {
 
 	if packPath == "" {
 		installedPacks, err := findInstalledPacks(false, true)
 		if err != nil {
 			return err
 		}
+		pins, err := ReadPinnedPacks()
+		if err != nil {
+			return err
+		}
 		for _, installedPack := range installedPacks {
+			if version, pinned := pins[installedPack.Vendor+"."+installedPack.Name]; pinned && !forcePinned {
+				log.Infof("Skipping %s::%s, it is pinned to %s", installedPack.Vendor, installedPack.Name, version)
+				continue
+			}
 			err = UpdatePack(installedPack.Vendor+"."+installedPack.Name, checkEula, noRequirements, timeout)
 			if err != nil {
 				log.Error(err)
@@ -316,32 +429,75 @@ func UpdatePack(packPath string, checkEula, noRequirements bool, timeout int) er
 	if !pack.IsPublic || pack.isInstalled {
 		return nil
 	}
+	if err = checkPinned(pack.Vendor, pack.Name, ""); err != nil {
+		return err
+	}
 
 	log.Infof("Updating pack \"%s\"", packPath)
 
+	declined := false
+	defer func() {
+		if declined {
+			notifyWebhook("update", pack, errs.ErrEula)
+			return
+		}
+		notifyWebhook("update", pack, err)
+		if err == nil {
+			recordMetrics(pack)
+		}
+	}()
+
 	if pack.isPackID {
 		pack.path, err = FindPackURL(pack)
 		if err != nil {
 			return err
 		}
 	}
+	pack.showReleaseNotes()
 
 	if err = pack.fetch(timeout); err != nil {
 		return err
 	}
 
+	if err = pack.verifyPublishedHash(timeout); err != nil {
+		return err
+	}
+	if err = pack.verifyPinnedSigner(); err != nil {
+		return err
+	}
+
 	// Unlock the pack (to enable reinstalling) and lock it afterwards
 	pack.Unlock()
 	defer pack.Lock()
 
-	if err = pack.install(Installation, checkEula); err != nil {
+	// Packs added sparse stay sparse, unless given other component filters
+	sparse, err := sparsePacks()
+	if err != nil {
+		return err
+	}
+	pack.sparse = sparse[pack.Vendor+"."+pack.Name]
+	pack.sparse.Slim = pack.sparse.Slim || slimInstall
+	if selection := sparseSelection(); selection.filtersComponents() {
+		pack.sparse.IncludeComponents = selection.IncludeComponents
+		pack.sparse.ExcludeComponents = selection.ExcludeComponents
+	}
+	if installToolchain != "" {
+		pack.sparse.Toolchain = installToolchain
+	}
+
+	if err = pack.installOrRecover(Installation, checkEula, timeout); err != nil {
 		// Just for internal purposes, is not presented as an error to the user
-		if err == errs.ErrEula {
+		if errs.Is(err, errs.ErrEula) {
+			declined = true
 			return nil
 		}
 		return err
 	}
 
+	if err = pack.rememberSparse(); err != nil {
+		return err
+	}
+
 	if !noRequirements {
 		log.Debug("installing package requirements")
 		err := pack.loadDependencies()
@@ -400,13 +556,28 @@ func CheckConcurrency(concurrency int) int {
 	return concurrency
 }
 
+// interleavePdscTags orders pdscTags round-robin by the host of their URL,
+// for the downloads of each vendor server to proceed while the ones of
+// another wait for the per-host limit
+func interleavePdscTags(pdscTags []xml.PdscTag) []xml.PdscTag {
+	urls := make([]string, len(pdscTags))
+	for i, pdscTag := range pdscTags {
+		urls[i] = pdscTag.URL
+	}
+	interleaved := make([]xml.PdscTag, 0, len(pdscTags))
+	for _, i := range utils.InterleaveByHost(urls) {
+		interleaved = append(interleaved, pdscTags[i])
+	}
+	return interleaved
+}
+
 func DownloadPDSCFiles(skipInstalledPdscFiles bool, concurrency int, timeout int) error {
 	log.Info("Downloading all PDSC files available on the public index")
 	if err := Installation.PublicIndexXML.Read(); err != nil {
 		return err
 	}
 
-	pdscTags := Installation.PublicIndexXML.ListPdscTags()
+	pdscTags := interleavePdscTags(Installation.PublicIndexXML.ListPdscTags())
 	numPdsc := len(pdscTags)
 	if numPdsc == 0 {
 		log.Info("(no packs in public index)")
@@ -417,11 +588,14 @@ func DownloadPDSCFiles(skipInstalledPdscFiles bool, concurrency int, timeout int
 		log.Infof("[J%d:F\"%s\"]", numPdsc, Installation.PublicIndex)
 	}
 
-	ctx := context.TODO()
+	ctx := operationContext
 	concurrency = CheckConcurrency(concurrency)
 	sem := semaphore.NewWeighted(int64(concurrency))
 
 	for _, pdscTag := range pdscTags {
+		if ctx.Err() != nil {
+			break
+		}
 		if concurrency == 0 {
 			massDownloadPdscFiles(pdscTag, skipInstalledPdscFiles, timeout)
 		} else {
@@ -436,12 +610,17 @@ func DownloadPDSCFiles(skipInstalledPdscFiles bool, concurrency int, timeout int
 			}(pdscTag)
 		}
 	}
-	if concurrency > 1 {
-		if err := sem.Acquire(ctx, int64(concurrency)); err != nil {
+	// Wait for the running downloads, which stop early once ctx is done
+	if concurrency > 0 {
+		if err := sem.Acquire(context.Background(), int64(concurrency)); err != nil {
 			log.Errorf("Failed to acquire semaphore: %v", err)
 		}
 	}
 
+	if ctx.Err() != nil {
+		return utils.ContextError(ctx)
+	}
+
 	return nil
 }
 
@@ -457,18 +636,21 @@ func UpdateInstalledPDSCFiles(pidxXML *xml.PidxXML, concurrency int, timeout int
 		log.Infof("[J%d:F\"%s\"]", numPdsc, Installation.PublicIndex)
 	}
 
-	ctx := context.TODO()
+	ctx := operationContext
 	concurrency = CheckConcurrency(concurrency)
 	sem := semaphore.NewWeighted(int64(concurrency))
 
 	for _, pdscFile := range pdscFiles {
+		if ctx.Err() != nil {
+			break
+		}
 		log.Debugf("Checking if \"%s\" needs updating", pdscFile)
 		pdscXML := xml.NewPdscXML(pdscFile)
 		err := pdscXML.Read()
 		if err != nil {
 			log.Errorf("%s: %v", pdscFile, err)
 			utils.UnsetReadOnly(pdscFile)
-			os.Remove(pdscFile)
+			utils.GetFileSystem().Remove(pdscFile)
 			continue
 		}
 
@@ -482,7 +664,7 @@ func UpdateInstalledPDSCFiles(pidxXML *xml.PidxXML, concurrency int, timeout int
 		if len(tags) == 0 {
 			log.Warnf("The pack %s::%s is no longer present in the updated index.pidx, deleting PDSC file \"%v\"", pdscXML.Vendor, pdscXML.Name, pdscFile)
 			utils.UnsetReadOnly(pdscFile)
-			os.Remove(pdscFile)
+			utils.GetFileSystem().Remove(pdscFile)
 			continue
 		}
 
@@ -508,12 +690,17 @@ func UpdateInstalledPDSCFiles(pidxXML *xml.PidxXML, concurrency int, timeout int
 		}
 	}
 
-	if concurrency > 1 {
-		if err := sem.Acquire(ctx, int64(concurrency)); err != nil {
+	// Wait for the running downloads, which stop early once ctx is done
+	if concurrency > 0 {
+		if err := sem.Acquire(context.Background(), int64(concurrency)); err != nil {
 			log.Errorf("Failed to acquire semaphore: %v", err)
 		}
 	}
 
+	if ctx.Err() != nil {
+		return utils.ContextError(ctx)
+	}
+
 	pdscFiles, err = utils.ListDir(Installation.LocalDir, ".pdsc$")
 	if err != nil {
 		return err
@@ -531,7 +718,7 @@ func UpdateInstalledPDSCFiles(pidxXML *xml.PidxXML, concurrency int, timeout int
 		if err != nil {
 			log.Errorf("%s: %v", pdscFile, err)
 			utils.UnsetReadOnly(pdscFile)
-			os.Remove(pdscFile)
+			utils.GetFileSystem().Remove(pdscFile)
 			continue
 		}
 		if pdscXML.URL == "" {
@@ -545,6 +732,7 @@ func UpdateInstalledPDSCFiles(pidxXML *xml.PidxXML, concurrency int, timeout int
 		pdscTag.URL = pdscXML.URL
 		if err := Installation.loadPdscFile(pdscTag, timeout); err != nil {
 			log.Error(err)
+			recordFailedPdsc(pdscTag, true)
 		}
 
 		pdscXML = xml.NewPdscXML(pdscFile)
@@ -552,7 +740,7 @@ func UpdateInstalledPDSCFiles(pidxXML *xml.PidxXML, concurrency int, timeout int
 		if err != nil {
 			log.Errorf("%s: %v", pdscFile, err)
 			utils.UnsetReadOnly(pdscFile)
-			os.Remove(pdscFile)
+			utils.GetFileSystem().Remove(pdscFile)
 			continue
 		}
 		latestVersion := pdscXML.LatestVersion()
@@ -594,6 +782,11 @@ func UpdatePublicIndex(indexPath string, overwrite bool, sparse bool, downloadPd
 	// For backwards compatibility, allow indexPath to be a file, but ideally it should be empty
 	if indexPath == "" {
 		indexPath = fmt.Sprintf("%s/index.pidx", strings.TrimSuffix(Installation.PublicIndexXML.URL, "/"))
+
+		// Indexes synthesized from a directory are scanned again
+		if indexURL, err := url.Parse(Installation.PublicIndexXML.URL); err == nil && indexURL.Scheme == "file" && utils.DirExists(fileURLPath(indexURL)) {
+			indexPath = fileURLPath(indexURL)
+		}
 	}
 
 	log.Debugf("Updating public index with \"%v\"", indexPath)
@@ -605,22 +798,37 @@ func UpdatePublicIndex(indexPath string, overwrite bool, sparse bool, downloadPd
 			log.Warnf("Non-HTTPS url: \"%s\"", indexPath)
 		}
 
-		indexPath, err = utils.DownloadFile(indexPath, timeout)
+		indexURL := indexPath
+		indexPath, err = utils.DownloadFileContext(operationContext, indexPath, timeout)
 		if err != nil {
 			return err
 		}
-		defer os.Remove(indexPath)
+		defer utils.GetFileSystem().Remove(indexPath)
+
+		if err := verifyIndexSignature(indexURL, indexPath, timeout); err != nil {
+			return err
+		}
+		if err := checkIndexPin(indexPath); err != nil {
+			return err
+		}
 	} else {
 		if indexPath != "" {
 			if !utils.FileExists(indexPath) && !utils.DirExists(indexPath) {
 				return errs.ErrFileNotFound
 			}
-			fileInfo, err := os.Stat(indexPath)
+			fileInfo, err := utils.GetFileSystem().Stat(indexPath)
 			if err != nil {
 				return err
 			}
 			if fileInfo.IsDir() {
-				return errs.ErrInvalidPublicIndexReference
+				if indexPath, err = scanIndexDir(indexPath); err != nil {
+					return err
+				}
+				defer utils.GetFileSystem().Remove(indexPath)
+			} else if err := verifyIndexSignature(indexPath, indexPath, timeout); err != nil {
+				return err
+			} else if err := checkIndexPin(indexPath); err != nil {
+				return err
 			}
 		}
 	}
@@ -636,6 +844,36 @@ func UpdatePublicIndex(indexPath string, overwrite bool, sparse bool, downloadPd
 	}
 	utils.SetReadOnly(Installation.PublicIndex)
 
+	if err := Installation.PublicIndexXML.Read(); err != nil {
+		return err
+	}
+
+	// The index sources subscribed to are merged into the new index
+	if err := writeIndexOrigins(nil); err != nil {
+		return err
+	}
+	sources, err := ReadIndexSources()
+	if err != nil {
+		return err
+	}
+	if err := mergeIndexSources(sources, false, timeout); err != nil {
+		return err
+	}
+
+	if err := recordIndexRefreshed(); err != nil {
+		return err
+	}
+
+	// PDSC downloads failing are recorded for "update-index --retry-failed"
+	if downloadPdsc || !sparse || downloadRemainingPdscFiles {
+		startFailedPdscs()
+		defer func() {
+			if err := saveFailedPdscs(); err != nil {
+				log.Error(err)
+			}
+		}()
+	}
+
 	if downloadPdsc {
 		err = DownloadPDSCFiles(false, concurrency, timeout)
 		if err != nil {
@@ -660,6 +898,48 @@ func UpdatePublicIndex(indexPath string, overwrite bool, sparse bool, downloadPd
 	return Installation.touchPackIdx()
 }
 
+// AddOfflineFiles copies the PDSC files of the public index and the pack files
+// found in dir into .Web and the download cache, where packs are then looked up
+// instead of downloading them
+func AddOfflineFiles(dir string) error {
+	log.Debugf("Adding offline files from \"%s\"", dir)
+
+	if !utils.DirExists(dir) {
+		return errs.WithPath(errs.ErrDirectoryNotFound, dir)
+	}
+
+	pidxXML := xml.NewPidxXML(Installation.PublicIndex)
+	if err := pidxXML.Read(); err != nil {
+		return err
+	}
+
+	pdscCount := 0
+	for _, pdscTag := range pidxXML.ListPdscTags() {
+		pdscFileName := pdscTag.Vendor + "." + pdscTag.Name + ".pdsc"
+		pdscFilePath := filepath.Join(dir, pdscFileName)
+		if !utils.FileExists(pdscFilePath) {
+			continue
+		}
+		if err := utils.CopyFile(pdscFilePath, filepath.Join(Installation.WebDir, pdscFileName)); err != nil {
+			return err
+		}
+		pdscCount++
+	}
+
+	packFiles, err := utils.ListDir(dir, `\.pack$`)
+	if err != nil {
+		return err
+	}
+	for _, packFile := range packFiles {
+		if err := utils.CopyFile(packFile, filepath.Join(Installation.DownloadDir, filepath.Base(packFile))); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Added %d pdsc and %d pack files from \"%s\"", pdscCount, len(packFiles), dir)
+	return nil
+}
+
 type installedPack struct {
 	xml.PdscTag
 	pdscPath        string
@@ -670,56 +950,60 @@ type installedPack struct {
 func findInstalledPacks(addLocalPacks, removeDuplicates bool) ([]installedPack, error) {
 	installedPacks := []installedPack{}
 
-	// First, get installed packs from *.pack files
-	pattern := filepath.Join(Installation.PackRoot, "*", "*", "*", "*.pdsc")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, err
+	// The pack roots, .Local/ and .Web/ are scanned side by side, the latter
+	// for preparePack to tell public packs right away afterwards
+	var wg sync.WaitGroup
+	packRoots := Installation.packRoots()
+	packRootMatches := make([][]string, len(packRoots))
+	packRootErrs := make([]error, len(packRoots))
+	for i, packRoot := range packRoots {
+		wg.Add(1)
+		go func(i int, packRoot string) {
+			defer wg.Done()
+			packRootMatches[i], packRootErrs[i] = utils.GlobIn(packRoot, "*/*/*/*.pdsc")
+		}(i, packRoot)
+	}
+	localPacks := []installedPack{}
+	if addLocalPacks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			localPacks = findLocalPacks()
+		}()
 	}
-	for _, match := range matches {
-		pdscPath := strings.Replace(match, Installation.PackRoot, "", -1)
-		packName, _ := filepath.Split(pdscPath)
-		packName = strings.Replace(packName, "/", " ", -1)
-		packName = strings.Replace(packName, "\\", " ", -1)
-		packName = strings.Trim(packName, " ")
-		packName = strings.Replace(packName, " ", ".", -1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		Installation.scanWebDir()
+	}()
+	wg.Wait()
 
-		packNameBits := strings.SplitN(packName, ".", 3)
+	// First, get installed packs from *.pack files, in all pack roots
+	for i, packRoot := range packRoots {
+		if packRootErrs[i] != nil {
+			return nil, packRootErrs[i]
+		}
+		for _, match := range packRootMatches[i] {
+			pdscPath := strings.Replace(match, packRoot, "", -1)
+			packName, _ := filepath.Split(pdscPath)
+			packName = strings.Replace(packName, "/", " ", -1)
+			packName = strings.Replace(packName, "\\", " ", -1)
+			packName = strings.Trim(packName, " ")
+			packName = strings.Replace(packName, " ", ".", -1)
 
-		pack := installedPack{pdscPath: match}
-		pack.Vendor = packNameBits[0]
-		pack.Name = packNameBits[1]
-		pack.Version = packNameBits[2]
-		installedPacks = append(installedPacks, pack)
-	}
+			packNameBits := strings.SplitN(packName, ".", 3)
 
-	if addLocalPacks {
-		// Add packs listed in .Local/local_repository.pidx to the list
-		if err := Installation.LocalPidx.Read(); err != nil {
-			log.Error(err)
-		} else {
-			installedPdscs := Installation.LocalPidx.ListPdscTags()
-			for _, pdsc := range installedPdscs {
-				pack := installedPack{PdscTag: pdsc, isPdscInstalled: true}
-				pack.pdscPath = pdsc.URL + pack.Vendor + "/" + pack.Name + ".pdsc"
-
-				parsedURL, err := url.ParseRequestURI(pdsc.URL)
-				pack.err = err
-				if pack.err != nil {
-					installedPacks = append(installedPacks, pack)
-					continue
-				}
-
-				pack.pdscPath = filepath.Join(utils.CleanPath(parsedURL.Path), pack.Vendor+"."+pack.Name+".pdsc")
-				pdscXML := xml.NewPdscXML(pack.pdscPath)
-				pack.err = pdscXML.Read()
-				if pack.err == nil {
-					pack.Version = pdscXML.LatestVersion()
-				}
-				installedPacks = append(installedPacks, pack)
-			}
+			pack := installedPack{pdscPath: match}
+			pack.Vendor = packNameBits[0]
+			pack.Name = packNameBits[1]
+			pack.Version = packNameBits[2]
+			installedPacks = append(installedPacks, pack)
 		}
 	}
+
+	// Then add packs listed in .Local/local_repository.pidx
+	installedPacks = append(installedPacks, localPacks...)
+
 	if removeDuplicates {
 		sort.Slice(installedPacks, func(i, j int) bool {
 			vi := strings.ToLower(installedPacks[i].Vendor)
@@ -752,6 +1036,76 @@ func findInstalledPacks(addLocalPacks, removeDuplicates bool) ([]installedPack,
 	return installedPacks, nil
 }
 
+// findLocalPacks returns the packs listed in .Local/local_repository.pidx,
+// reading their PDSC files concurrently
+func findLocalPacks() []installedPack {
+	if err := Installation.LocalPidx.Read(); err != nil {
+		log.Error(err)
+		return []installedPack{}
+	}
+
+	installedPdscs := append(Installation.LocalPidx.ListPdscTags(), Installation.extraLocalPdscTags()...)
+	localPacks := make([]installedPack, len(installedPdscs))
+	concurrency := runtime.GOMAXPROCS(0)
+	sem := semaphore.NewWeighted(int64(concurrency))
+	for i, pdsc := range installedPdscs {
+		_ = sem.Acquire(context.Background(), 1)
+		go func(i int, pdsc xml.PdscTag) {
+			defer sem.Release(1)
+			pack := installedPack{PdscTag: pdsc, isPdscInstalled: true}
+			pack.pdscPath = pdsc.URL + pack.Vendor + "/" + pack.Name + ".pdsc"
+
+			parsedURL, err := url.ParseRequestURI(pdsc.URL)
+			pack.err = err
+			if pack.err == nil {
+				pack.pdscPath = filepath.Join(utils.CleanPath(parsedURL.Path), pack.Vendor+"."+pack.Name+".pdsc")
+				pdscXML := xml.NewPdscXML(pack.pdscPath)
+				pack.err = pdscXML.Read()
+				if pack.err == nil {
+					pack.Version = pdscXML.LatestVersion()
+				}
+			}
+			localPacks[i] = pack
+		}(i, pdsc)
+	}
+	_ = sem.Acquire(context.Background(), int64(concurrency))
+	return localPacks
+}
+
+// InstalledPackInfo describes a pack present in the pack root folder
+type InstalledPackInfo struct {
+	xml.PdscTag
+
+	// PdscPath is the path of the pack's PDSC file
+	PdscPath string
+
+	// IsPdscInstalled tells whether the pack was installed via PDSC file
+	IsPdscInstalled bool
+
+	// Err holds the error found while reading the pack's PDSC file, if any
+	Err error
+}
+
+// GetInstalledPacks returns all packs present in the pack root folder,
+// including the ones installed via PDSC files
+func GetInstalledPacks() ([]InstalledPackInfo, error) {
+	installedPacks, err := findInstalledPacks(true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	packs := make([]InstalledPackInfo, 0, len(installedPacks))
+	for _, pack := range installedPacks {
+		packs = append(packs, InstalledPackInfo{
+			PdscTag:         pack.PdscTag,
+			PdscPath:        pack.pdscPath,
+			IsPdscInstalled: pack.isPdscInstalled,
+			Err:             pack.err,
+		})
+	}
+	return packs, nil
+}
+
 // ListInstalledPacks generates a list of all packs present in the pack root folder
 func ListInstalledPacks(listCached, listPublic, listUpdates, listRequirements bool, listFilter string) error {
 	log.Debugf("Listing packs")
@@ -772,6 +1126,10 @@ func ListInstalledPacks(listCached, listPublic, listUpdates, listRequirements bo
 		sort.Slice(pdscTags, func(i, j int) bool {
 			return strings.ToLower(pdscTags[i].Key()) < strings.ToLower(pdscTags[j].Key())
 		})
+		origins, err := ReadIndexOrigins()
+		if err != nil {
+			return err
+		}
 		// List all available packs from the index
 		for _, pdscTag := range pdscTags {
 			logMessage := pdscTag.YamlPackID()
@@ -782,6 +1140,9 @@ func ListInstalledPacks(listCached, listPublic, listUpdates, listRequirements bo
 			} else if utils.FileExists(packFilePath) {
 				logMessage += " (cached)"
 			}
+			if origin, found := origins[pdscTag.Vendor+"."+pdscTag.Name]; found {
+				logMessage += " (from " + origin + ")"
+			}
 
 			// To avoid showing empty log lines ("I: ")
 			if listFilter == "" || utils.FilterPackID(logMessage, listFilter) != "" {
@@ -794,8 +1155,7 @@ func ListInstalledPacks(listCached, listPublic, listUpdates, listRequirements bo
 		} else {
 			log.Infof("Listing cached packs")
 		}
-		pattern := filepath.Join(Installation.DownloadDir, "*.pack")
-		matches, err := filepath.Glob(pattern)
+		matches, err := utils.GlobIn(Installation.DownloadDir, "*.pack")
 		if err != nil {
 			return err
 		}
@@ -882,6 +1242,9 @@ func ListInstalledPacks(listCached, listPublic, listUpdates, listRequirements bo
 			if listUpdates {
 				logMessage = strings.Replace(logMessage, "@", " can be updated from \"", 1)
 				logMessage += "\" to \"" + p.targetVersion + "\""
+				if OutdatedPackFound != nil && (listFilter == "" || utils.FilterPackID(logMessage, listFilter) != "") {
+					OutdatedPackFound(pack.Vendor+"::"+pack.Name, pack.Version, p.targetVersion)
+				}
 			}
 			if listRequirements {
 				p.Pdsc = xml.NewPdscXML(pack.pdscPath)
@@ -924,6 +1287,8 @@ func ListInstalledPacks(listCached, listPublic, listUpdates, listRequirements bo
 			// Print the PDSC path on packs installed via PDSC file
 			if pack.isPdscInstalled && !listRequirements {
 				logMessage += fmt.Sprintf(" (installed via %s)", pack.pdscPath)
+			} else if isSystemPack(pack.pdscPath) {
+				logMessage += " (system)"
 			}
 
 			// Append errors to the message, if any
@@ -979,8 +1344,8 @@ func FindPackURL(pack *PackType) (string, error) {
 
 	if pack.IsPublic {
 		packPdscFileName := filepath.Join(Installation.WebDir, pack.PdscFileName())
-		packPdscXML := xml.NewPdscXML(packPdscFileName)
-		if err := packPdscXML.Read(); err != nil {
+		packPdscXML, err := readPdscReleases(packPdscFileName)
+		if err != nil {
 			return "", err
 		}
 
@@ -1009,6 +1374,9 @@ func FindPackURL(pack *PackType) (string, error) {
 		if releaseTag == nil {
 			return "", errs.ErrPackVersionNotFoundInPdsc
 		}
+		if packPath, found := scannedIndexPackPath(pack); found {
+			return packPath, nil
+		}
 		if releaseTag.URL != "" {
 			return releaseTag.URL, nil
 		}
@@ -1022,8 +1390,8 @@ func FindPackURL(pack *PackType) (string, error) {
 		return "", errs.ErrPackURLCannotBeFound
 	}
 
-	packPdscXML := xml.NewPdscXML(packPdscFileName)
-	if err := packPdscXML.Read(); err != nil {
+	packPdscXML, err := readPdscReleases(packPdscFileName)
+	if err != nil {
 		return "", err
 	}
 
@@ -1063,6 +1431,75 @@ func FindPackURL(pack *PackType) (string, error) {
 // to PacksInstallationType
 var Installation *PacksInstallationType
 
+// cacheDir replaces the pack root's .Download folder when set
+var cacheDir string
+
+// SetCacheDir makes the following pack roots keep downloaded files in dir
+// instead of their .Download folder, for instance on a larger volume.
+// An empty dir restores .Download.
+func SetCacheDir(dir string) {
+	cacheDir = dir
+}
+
+// idempotent makes adding installed packs report it, see SetIdempotent
+var idempotent bool
+
+// SetIdempotent makes the following additions of installed packs log it
+// and return errs.ErrPackAlreadyInstalled, for callers to tell them apart,
+// instead of logging an error and returning nil
+func SetIdempotent(enabled bool) {
+	idempotent = enabled
+}
+
+func GetIdempotent() bool {
+	return idempotent
+}
+
+// maxPackRootSize is how many bytes the pack root may hold, zero meaning no limit
+var maxPackRootSize uint64
+
+// SetMaxPackRootSize makes installing packs fail when the pack root would hold more
+// than size bytes afterwards. Zero removes the limit.
+func SetMaxPackRootSize(size uint64) {
+	maxPackRootSize = size
+}
+
+// checkPackRootQuota fails if adding size bytes to the pack root exceeds its maximum size
+func checkPackRootQuota(size uint64) error {
+	if maxPackRootSize == 0 {
+		return nil
+	}
+
+	// Backups of packs being reinstalled are about to go
+	used, err := utils.DirSize(Installation.PackRoot, "*_tmp")
+	if err != nil {
+		return err
+	}
+
+	if used+size > maxPackRootSize {
+		log.Errorf("The pack root holds %s, adding %s would exceed its maximum size of %s", utils.FormatBytes(used), utils.FormatBytes(size), utils.FormatBytes(maxPackRootSize))
+		log.Error("Remove unused packs and their cached files with \"cpackget rm --purge\" or raise the maximum size")
+		return errs.ErrPackRootQuotaExceeded
+	}
+
+	return nil
+}
+
+// OutdatedPackFound, if set, is told about every pack "list --updates" finds a newer version of
+var OutdatedPackFound func(packID, version, latestVersion string)
+
+// operationContext stops downloads and extractions once it is done
+var operationContext = context.Background()
+
+// SetContext makes the following operations stop downloading and
+// extracting files once ctx is done. A nil ctx never stops them.
+func SetContext(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	operationContext = ctx
+}
+
 // SetPackRoot sets the working directory of the packs installation
 // if create == true, cpackget will try to create needed resources
 func SetPackRoot(packRoot string, create bool) error {
@@ -1070,10 +1507,19 @@ func SetPackRoot(packRoot string, create bool) error {
 		return errs.ErrPackRootNotFound
 	}
 
+	extraPackRoots := []string{}
+	if packRoots := filepath.SplitList(packRoot); len(packRoots) > 1 {
+		var err error
+		if packRoot, extraPackRoots, err = selectPackRoot(packRoots, create); err != nil {
+			return err
+		}
+	}
+
 	packRoot = filepath.Clean(packRoot)
 	if !utils.DirExists(packRoot) && !create {
 		return errs.ErrPackRootDoesNotExist
 	}
+	extraPackRoots = withSystemPackRoot(packRoot, extraPackRoots)
 
 	checkConnection := viper.GetBool("check-connection")
 	if checkConnection && !utils.GetEncodedProgress() {
@@ -1085,10 +1531,19 @@ func SetPackRoot(packRoot string, create bool) error {
 	}
 
 	Installation = &PacksInstallationType{
-		PackRoot:    packRoot,
-		DownloadDir: filepath.Join(packRoot, ".Download"),
-		LocalDir:    filepath.Join(packRoot, ".Local"),
-		WebDir:      filepath.Join(packRoot, ".Web"),
+		PackRoot:       packRoot,
+		ExtraPackRoots: extraPackRoots,
+		DownloadDir:    filepath.Join(packRoot, ".Download"),
+		LocalDir:       filepath.Join(packRoot, ".Local"),
+		WebDir:         filepath.Join(packRoot, ".Web"),
+		StoreDir:       filepath.Join(packRoot, ContentStoreDirName),
+	}
+	if cacheDir != "" {
+		// A cache outside of the pack root is shared, so it is always created
+		Installation.DownloadDir = filepath.Clean(cacheDir)
+		if err := utils.EnsureDir(Installation.DownloadDir); err != nil {
+			return err
+		}
 	}
 	Installation.LocalPidx = xml.NewPidxXML(filepath.Join(Installation.LocalDir, "local_repository.pidx"))
 	Installation.PackIdx = filepath.Join(packRoot, "pack.idx")
@@ -1118,12 +1573,13 @@ func SetPackRoot(packRoot string, create bool) error {
 	// Make sure utils.DownloadFile always downloads files to .Download/
 	utils.CacheDir = Installation.DownloadDir
 
-	err := Installation.PublicIndexXML.Read()
+	// The pdsc tags of the indexes are only parsed once a command looks into them
+	err := Installation.PublicIndexXML.ReadLazily()
 	if err != nil {
 		return err
 	}
 
-	err = Installation.LocalPidx.Read()
+	err = Installation.LocalPidx.ReadLazily()
 	if err != nil {
 		return err
 	}
@@ -1133,16 +1589,58 @@ func SetPackRoot(packRoot string, create bool) error {
 	return nil
 }
 
+// selectPackRoot picks the pack root to install to out of a search path of
+// pack roots: the first writable one, or, with create, the first one that does
+// not exist yet. The other existing ones are returned to be only looked into.
+func selectPackRoot(packRoots []string, create bool) (string, []string, error) {
+	packRoot := ""
+	extraPackRoots := []string{}
+	anyExists := false
+	for _, root := range packRoots {
+		if root == "" {
+			continue
+		}
+		root = filepath.Clean(root)
+		exists := utils.DirExists(root)
+		anyExists = anyExists || exists
+		if packRoot == "" && ((exists && utils.IsWritableDir(root)) || (!exists && create)) {
+			packRoot = root
+			continue
+		}
+		if exists && root != packRoot {
+			extraPackRoots = append(extraPackRoots, root)
+		}
+	}
+
+	if packRoot == "" {
+		if !anyExists {
+			return "", nil, errs.ErrPackRootDoesNotExist
+		}
+		return "", nil, errs.ErrNoWritablePackRoot
+	}
+	log.Debugf("Installing to \"%s\", also looking into %v", packRoot, extraPackRoots)
+	return packRoot, extraPackRoots, nil
+}
+
 // PacksInstallationType is the struct that manages Open-CMSIS-Pack installation/deletion.
+// Its methods are safe for concurrent use: the directory layout is set by SetPackRoot
+// and only read afterwards, and both indexes guard their own PDSC tags. AddPack,
+// UpdatePack, RemovePack and the other operations of this package are not, as they
+// share Installation and the settings of the Set* functions: run one at a time.
 type PacksInstallationType struct {
 	// PackRoot is the working directory if the packs installation
 	PackRoot string
 
+	// ExtraPackRoots are the other pack roots of a search path in CMSIS_PACK_ROOT,
+	// then the machine-wide one of SetSystemPackRoot. Their packs count as
+	// installed, but nothing gets installed into them.
+	ExtraPackRoots []string
+
 	// packs installed
 	packs map[string]bool
 
 	// DownloadDir stores copies of all packs that were installed via pack files
-	// from external servers.
+	// from external servers. It is the pack root's .Download unless SetCacheDir was called.
 	DownloadDir string
 
 	// LocalDir stores "local_repository.pidx" containing a list of all packs
@@ -1153,6 +1651,9 @@ type PacksInstallationType struct {
 	// publicly available packs.
 	WebDir string
 
+	// StoreDir is the content store of the pack root, see ContentStoreDirName
+	StoreDir string
+
 	// PublicIndex stores the path PackRoot/WebDir/index.pidx
 	PublicIndex string
 
@@ -1166,11 +1667,29 @@ type PacksInstallationType struct {
 	// localIsLoaded is a flag that tells whether the local_repository.pidx has been loaded or not
 	localIsLoaded bool
 
+	// mu guards packs and localIsLoaded
+	mu sync.Mutex
+
 	// PackIdx is the "pack.idx" file used by other tools to be notified that
 	// the pack installation had changed.
 	PackIdx string
 }
 
+// loadLocalPidx reads local_repository.pidx, only the first time it is called
+func (p *PacksInstallationType) loadLocalPidx() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.localIsLoaded {
+		return nil
+	}
+	if err := p.LocalPidx.Read(); err != nil {
+		return err
+	}
+	p.localIsLoaded = true
+	return nil
+}
+
 // touchPackIdx changes the timestamp of pack.idx.
 func (p *PacksInstallationType) touchPackIdx() error {
 	if utils.GetSkipTouch() {
@@ -1183,13 +1702,50 @@ func (p *PacksInstallationType) touchPackIdx() error {
 	return err
 }
 
+// packRoots returns the pack root followed by the other pack roots of the search path
+func (p *PacksInstallationType) packRoots() []string {
+	return append([]string{p.PackRoot}, p.ExtraPackRoots...)
+}
+
+// packRootOf returns the first pack root where vendor/name/version, or any
+// version if version is empty, is installed, or "" if none
+func (p *PacksInstallationType) packRootOf(vendor, name, version string) string {
+	for _, packRoot := range p.packRoots() {
+		packDir := filepath.Join(packRoot, vendor, name, version)
+		log.Debugf("Checking if \"%s\" exists", packDir)
+		if utils.DirExists(packDir) {
+			return packRoot
+		}
+	}
+	return ""
+}
+
+// extraLocalPdscTags lists the packs installed via PDSC files in the other
+// pack roots of the search path
+func (p *PacksInstallationType) extraLocalPdscTags() []xml.PdscTag {
+	pdscTags := []xml.PdscTag{}
+	for _, packRoot := range p.ExtraPackRoots {
+		// Reading a missing index would create it, but these pack roots are read-only
+		localPidxPath := filepath.Join(packRoot, ".Local", "local_repository.pidx")
+		if !utils.FileExists(localPidxPath) {
+			continue
+		}
+		localPidx := xml.NewPidxXML(localPidxPath)
+		if err := localPidx.Read(); err != nil {
+			log.Warnf("Could not read the local index of \"%s\": %v", packRoot, err)
+			continue
+		}
+		pdscTags = append(pdscTags, localPidx.ListPdscTags()...)
+	}
+	return pdscTags
+}
+
 // PackIsInstalled checks whether a given pack is already installed or not
 func (p *PacksInstallationType) PackIsInstalled(pack *PackType, noLocal bool) bool {
 	log.Debugf("Checking if %s is installed", pack.PackIDWithVersion())
 
 	// First make sure there's at least one version of the pack installed
-	installationDir := filepath.Join(p.PackRoot, pack.Vendor, pack.Name)
-	if !utils.DirExists(installationDir) {
+	if p.packRootOf(pack.Vendor, pack.Name, "") == "" {
 		return false
 	}
 
@@ -1200,9 +1756,7 @@ func (p *PacksInstallationType) PackIsInstalled(pack *PackType, noLocal bool) bo
 
 	// Exact version is easy, just find a matching installation folder
 	if pack.versionModifier == utils.ExactVersion {
-		packDir := filepath.Join(installationDir, pack.GetVersionNoMeta())
-		log.Debugf("Checking if \"%s\" exists", packDir)
-		return utils.DirExists(packDir)
+		return p.packRootOf(pack.Vendor, pack.Name, pack.GetVersionNoMeta()) != ""
 	}
 	installedVersions := []string{}
 	if !noLocal {
@@ -1211,7 +1765,7 @@ func (p *PacksInstallationType) PackIsInstalled(pack *PackType, noLocal bool) bo
 			log.Warn("Could not read local index")
 			return false
 		}
-		for _, pdsc := range p.LocalPidx.ListPdscTags() {
+		for _, pdsc := range append(p.LocalPidx.ListPdscTags(), p.extraLocalPdscTags()...) {
 			if pack.Vendor == pdsc.Vendor && pack.Name == pdsc.Name {
 				installedVersions = append(installedVersions, pdsc.Version)
 			}
@@ -1219,15 +1773,21 @@ func (p *PacksInstallationType) PackIsInstalled(pack *PackType, noLocal bool) bo
 	}
 
 	// Get all remaining versions installed and check if it satisfies the versionModifier condition
-	installedDirs, err := utils.ListDir(installationDir, "")
-	if err != nil {
-		log.Warnf("Could not list installed packs in \"%s\": %v", installationDir, err)
-		return false
-	}
+	for _, packRoot := range p.packRoots() {
+		installationDir := filepath.Join(packRoot, pack.Vendor, pack.Name)
+		if !utils.DirExists(installationDir) {
+			continue
+		}
+		installedDirs, err := utils.ListDir(installationDir, "")
+		if err != nil {
+			log.Warnf("Could not list installed packs in \"%s\": %v", installationDir, err)
+			return false
+		}
 
-	for _, path := range installedDirs {
-		base := filepath.Base(path)
-		installedVersions = append(installedVersions, base)
+		for _, path := range installedDirs {
+			base := filepath.Base(path)
+			installedVersions = append(installedVersions, base)
+		}
 	}
 
 	// Check if greater version is specified
@@ -1297,7 +1857,15 @@ func (p *PacksInstallationType) PackIsInstalled(pack *PackType, noLocal bool) bo
 		pdscLookupDir = Installation.LocalDir
 	}
 
+	// Fall back to the PDSC files of the other pack roots of the search path
 	pdscFilePath = filepath.Join(pdscLookupDir, pack.PdscFileName())
+	for _, packRoot := range p.ExtraPackRoots {
+		if utils.FileExists(pdscFilePath) {
+			break
+		}
+		pdscFilePath = filepath.Join(packRoot, filepath.Base(pdscLookupDir), pack.PdscFileName())
+	}
+
 	pdscXML := xml.NewPdscXML(pdscFilePath)
 	if err := pdscXML.Read(); err != nil {
 		log.Debugf("Could not retrieve pack's PDSC file from \"%s\"", pdscFilePath)
@@ -1305,16 +1873,15 @@ func (p *PacksInstallationType) PackIsInstalled(pack *PackType, noLocal bool) bo
 	}
 
 	latestVersion := pdscXML.LatestVersion()
-	packDir := filepath.Join(installationDir, latestVersion)
-	found := utils.DirExists(packDir)
+	found := p.packRootOf(pack.Vendor, pack.Name, latestVersion) != ""
 	pack.targetVersion = latestVersion
 	return found
 }
 
-// packIsPublic checks whether the pack is public or not.
-// Being public means a PDSC file is present in ".Web/" folder
-func (p *PacksInstallationType) packIsPublic(pack *PackType, timeout int) (bool, error) {
-	// lazyly lists all pdsc files in the ".Web/" folder only once
+// scanWebDir lazyly lists all pdsc files in the ".Web/" folder only once
+func (p *PacksInstallationType) scanWebDir() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if p.packs == nil {
 		p.packs = make(map[string]bool)
 		files, _ := utils.ListDir(p.WebDir, `^.*\.pdsc$`)
@@ -1323,8 +1890,15 @@ func (p *PacksInstallationType) packIsPublic(pack *PackType, timeout int) (bool,
 			p.packs[baseFileName] = true
 		}
 	}
+}
 
+// packIsPublic checks whether the pack is public or not.
+// Being public means a PDSC file is present in ".Web/" folder
+func (p *PacksInstallationType) packIsPublic(pack *PackType, timeout int) (bool, error) {
+	p.scanWebDir()
+	p.mu.Lock()
 	_, ok := p.packs[pack.PdscFileName()]
+	p.mu.Unlock()
 	if ok {
 		log.Debugf("Found \"%s\" in \"%s\"", pack.PdscFileName(), p.WebDir)
 		return true, nil
@@ -1349,7 +1923,15 @@ func (p *PacksInstallationType) packIsPublic(pack *PackType, timeout int) (bool,
 	// Sometimes a pidx file might have multiple pdsc tags for same key
 	// which is not the case here, so we'll take only the first one
 	pdscTag := pdscTags[0]
-	return true, p.downloadPdscFile(pdscTag, false, timeout)
+	if err := p.downloadPdscFile(pdscTag, false, timeout); err != nil {
+		return true, err
+	}
+
+	// Further packs of the same vendor and name, e.g. of a packs list, find it right away
+	p.mu.Lock()
+	p.packs[pack.PdscFileName()] = true
+	p.mu.Unlock()
+	return true, nil
 }
 
 // downloadPdscFile takes in a xml.PdscTag containing URL, Vendor and Name of the pack
@@ -1383,17 +1965,29 @@ func (p *PacksInstallationType) downloadPdscFile(pdscTag xml.PdscTag, skipInstal
 	}
 
 	pdscFileURL.Path = path.Join(pdscFileURL.Path, basePdscFile)
+	if pdscFileURL.Scheme == "file" {
+		sourceFilePath := fileURLPath(pdscFileURL)
+		if utils.SameFile(sourceFilePath, pdscFilePath) {
+			return nil
+		}
+		utils.UnsetReadOnly(pdscFilePath)
+		defer utils.SetReadOnly(pdscFilePath)
+		return utils.CopyFile(sourceFilePath, pdscFilePath)
+	}
 
-	localFileName, err := utils.DownloadFile(pdscFileURL.String(), timeout)
-	defer os.Remove(localFileName)
+	localFileName, err := utils.DownloadChangedFileContext(operationContext, pdscFileURL.String(), pdscFilePath, timeout)
+	if err == nil && localFileName == pdscFilePath {
+		return nil
+	}
+	defer utils.GetFileSystem().Remove(localFileName)
 
 	if err != nil {
 		log.Errorf("Could not download \"%s\": %s", pdscFileURL, err)
-		return fmt.Errorf("\"%s\": %w", pdscFileURL, errs.ErrPackPdscCannotBeFound)
+		return errs.WithURL(errs.ErrPackPdscCannotBeFound, pdscFileURL.String())
 	}
 
 	utils.UnsetReadOnly(pdscFilePath)
-	os.Remove(pdscFilePath)
+	utils.GetFileSystem().Remove(pdscFilePath)
 	err = utils.MoveFile(localFileName, pdscFilePath)
 	utils.SetReadOnly(pdscFilePath)
 
@@ -1416,10 +2010,7 @@ func (p *PacksInstallationType) loadPdscFile(pdscTag xml.PdscTag, timeout int) e
 
 	pdscFileURL.Path = path.Join(pdscFileURL.Path, basePdscFile)
 	if pdscFileURL.Scheme == "file" {
-		sourceFilePath := pdscFileURL.Path
-		if runtime.GOOS == "windows" && strings.HasPrefix(sourceFilePath, "/") {
-			sourceFilePath = sourceFilePath[1:]
-		}
+		sourceFilePath := fileURLPath(pdscFileURL)
 		utils.UnsetReadOnly(pdscFilePath)
 		defer utils.SetReadOnly(pdscFilePath)
 		if err = utils.CopyFile(sourceFilePath, pdscFilePath); err != nil {
@@ -1429,16 +2020,16 @@ func (p *PacksInstallationType) loadPdscFile(pdscTag xml.PdscTag, timeout int) e
 		return nil
 	}
 
-	localFileName, err := utils.DownloadFile(pdscFileURL.String(), timeout)
-	defer os.Remove(localFileName)
+	localFileName, err := utils.DownloadFileContext(operationContext, pdscFileURL.String(), timeout)
+	defer utils.GetFileSystem().Remove(localFileName)
 
 	if err != nil {
 		log.Errorf("Could not download \"%s\": %s", pdscFileURL, err)
-		return fmt.Errorf("\"%s\": %w", pdscFileURL, errs.ErrPackPdscCannotBeFound)
+		return errs.WithURL(errs.ErrPackPdscCannotBeFound, pdscFileURL.String())
 	}
 
 	utils.UnsetReadOnly(pdscFilePath)
-	os.Remove(pdscFilePath)
+	utils.GetFileSystem().Remove(pdscFilePath)
 	err = utils.MoveFile(localFileName, pdscFilePath)
 	utils.SetReadOnly(pdscFilePath)
 
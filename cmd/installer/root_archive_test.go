@@ -0,0 +1,95 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImportPackRoot(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test exporting a pack root to an unsupported archive", func(t *testing.T) {
+		localTestingDir := "test-exporting-a-pack-root-to-an-unsupported-archive"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		defer removePackRoot(localTestingDir)
+
+		err := installer.ExportPackRoot(localTestingDir, localTestingDir+".tar.zst")
+		assert.True(errs.Is(err, errs.ErrUnsupportedArchiveFormat))
+	})
+
+	t.Run("test exporting a pack root into itself", func(t *testing.T) {
+		localTestingDir := "test-exporting-a-pack-root-into-itself"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		defer removePackRoot(localTestingDir)
+
+		err := installer.ExportPackRoot(localTestingDir, filepath.Join(localTestingDir, "packroot.tar"))
+		assert.Equal(errs.ErrArchiveInsidePackRoot, err)
+	})
+
+	t.Run("test importing a pack root into a non empty directory", func(t *testing.T) {
+		localTestingDir := "test-importing-a-pack-root-into-a-non-empty-directory"
+		archive := localTestingDir + ".tar"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.ExportPackRoot(localTestingDir, archive))
+		defer os.Remove(archive)
+
+		err := installer.ImportPackRoot(archive, localTestingDir)
+		assert.True(errs.Is(err, errs.ErrPackRootDestinationNotEmpty))
+	})
+
+	t.Run("test importing a pack root with a file too big", func(t *testing.T) {
+		localTestingDir := "test-importing-a-pack-root-with-a-file-too-big"
+		archive := localTestingDir + ".tar"
+		newPackRoot := localTestingDir + "-new"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		defer removePackRoot(localTestingDir)
+		defer removePackRoot(newPackRoot)
+
+		assert.Nil(installer.ExportPackRoot(localTestingDir, archive))
+		defer os.Remove(archive)
+
+		defer func(size int64) { utils.MaxDownloadSize = size }(utils.MaxDownloadSize)
+		utils.MaxDownloadSize = 5
+		err := installer.ImportPackRoot(archive, newPackRoot)
+		assert.True(errs.Is(err, errs.ErrFileTooBig))
+	})
+
+	for _, extension := range []string{".tar", ".tar.gz", ".tgz"} {
+		t.Run("test exporting and importing a pack root as "+extension, func(t *testing.T) {
+			localTestingDir := "test-exporting-and-importing-a-pack-root" + strings.ReplaceAll(extension, ".", "-")
+			archive := localTestingDir + extension
+			newPackRoot := localTestingDir + "-new"
+			setUpPackRootToMove(t, localTestingDir)
+			defer removePackRoot(localTestingDir)
+			defer removePackRoot(newPackRoot)
+
+			assert.Nil(installer.ExportPackRoot(localTestingDir, archive))
+			defer os.Remove(archive)
+
+			assert.Nil(installer.ImportPackRoot(archive, newPackRoot))
+			assert.True(utils.FileExists(filepath.Join(newPackRoot, ".Web", "index.pidx")))
+			assert.False(utils.FileExists(filepath.Join(newPackRoot, ".cpackget-export")))
+
+			// The pack installed via PDSC file is now referenced from the new pack root
+			assert.Nil(installer.SetPackRoot(newPackRoot, !CreatePackRoot))
+			assert.Nil(installer.Installation.LocalPidx.Read())
+			tags := installer.Installation.LocalPidx.ListPdscTags()
+			assert.Equal(1, len(tags))
+			absNewPackRoot, _ := filepath.Abs(newPackRoot)
+			assert.Contains(tags[0].URL, filepath.ToSlash(absNewPackRoot))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdatePublicIndexFromDirectory(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test synthesizing the index of a directory", func(t *testing.T) {
+		localTestingDir := "test-synthesizing-the-index-of-a-directory"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		sharedDir := localTestingDir + "-shared"
+		assert.Nil(os.MkdirAll(sharedDir, 0755))
+		defer os.RemoveAll(sharedDir)
+		assert.Nil(utils.CopyFile(pdscPack123, filepath.Join(sharedDir, "TheVendor.PackName.pdsc")))
+		assert.Nil(utils.CopyFile(publicLocalPack123, filepath.Join(sharedDir, "TheVendor.PublicLocalPack.1.2.3.pack")))
+		assert.Nil(utils.CopyFile(publicLocalPack124, filepath.Join(sharedDir, "TheVendor.PublicLocalPack.1.2.4.pack")))
+
+		assert.Nil(installer.UpdatePublicIndex(sharedDir, true, true, false, false, 0, 0))
+
+		pidxXML := xml.NewPidxXML(installer.Installation.PublicIndex)
+		assert.Nil(pidxXML.Read())
+		tags := pidxXML.ListPdscTags()
+		assert.Equal(2, len(tags))
+
+		packNameTags := pidxXML.FindPdscTags(xml.PdscTag{Vendor: "TheVendor", Name: "PackName"})
+		assert.Equal(1, len(packNameTags))
+		absSharedDir, _ := filepath.Abs(sharedDir)
+		assert.Equal("file://localhost/"+filepath.ToSlash(absSharedDir)+"/", packNameTags[0].URL)
+
+		// Packs without a pdsc file have the one of their latest version extracted
+		publicLocalPackTags := pidxXML.FindPdscTags(xml.PdscTag{Vendor: "TheVendor", Name: "PublicLocalPack"})
+		assert.Equal(1, len(publicLocalPackTags))
+		assert.Equal("1.2.4", publicLocalPackTags[0].Version)
+		assert.True(utils.FileExists(filepath.Join(installer.Installation.WebDir, "TheVendor.PublicLocalPack.pdsc")))
+
+		// The synthesized index is not left behind
+		assert.False(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, ".scanned-index.pidx")))
+	})
+
+	t.Run("test updating a directory index scans it again", func(t *testing.T) {
+		localTestingDir := "test-updating-a-directory-index-scans-it-again"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		sharedDir := localTestingDir + "-shared"
+		assert.Nil(os.MkdirAll(sharedDir, 0755))
+		defer os.RemoveAll(sharedDir)
+
+		assert.Nil(installer.UpdatePublicIndex(sharedDir, true, true, false, false, 0, 0))
+		assert.Equal(0, len(installer.Installation.PublicIndexXML.ListPdscTags()))
+
+		assert.Nil(utils.CopyFile(publicLocalPack123, filepath.Join(sharedDir, "TheVendor.PublicLocalPack.1.2.3.pack")))
+		assert.Nil(installer.UpdatePublicIndex("", true, true, false, false, 0, 0))
+		assert.Equal(1, len(installer.Installation.PublicIndexXML.ListPdscTags()))
+	})
+
+	t.Run("test adding packs from a directory index", func(t *testing.T) {
+		localTestingDir := "test-adding-packs-from-a-directory-index"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		sharedDir := localTestingDir + "-shared"
+		assert.Nil(os.MkdirAll(sharedDir, 0755))
+		defer os.RemoveAll(sharedDir)
+		assert.Nil(utils.CopyFile(publicLocalPack123, filepath.Join(sharedDir, "TheVendor.PublicLocalPack.1.2.3.pack")))
+
+		assert.Nil(installer.UpdatePublicIndex(sharedDir, true, true, false, false, 0, 0))
+		assert.Nil(installer.AddPack("TheVendor::PublicLocalPack@1.2.3", !CheckEula, !ExtractEula, !ForceReinstall, NoRequirements, Timeout))
+
+		assert.True(utils.DirExists(filepath.Join(installer.Installation.PackRoot, "TheVendor", "PublicLocalPack", "1.2.3")))
+		assert.True(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, "TheVendor.PublicLocalPack.1.2.3.pack")))
+	})
+}
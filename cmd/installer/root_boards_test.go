@@ -0,0 +1,83 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+var boardsPdsc = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<package schemaVersion="1.7.7">
+  <vendor>TheVendor</vendor>
+  <name>BoardSupportPack</name>
+  <url>http://the.url/</url>
+  <releases>
+    <release version="1.0.0"/>
+  </releases>
+  <boards>
+    <board vendor="BoardVendor" name="Eval Board" revision="Rev. 2">
+      <mountedDevice Dvendor="ChipVendor:1" Dname="CHIP200" Dvariant="CHIP200A"/>
+      <mountedDevice Dvendor="ChipVendor:1" Dname="CHIP100"/>
+    </board>
+    <board vendor="OtherVendor" name="Discovery Kit"/>
+  </boards>
+</package>
+`)
+
+func TestFindBoards(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test listing boards of installed packs", func(t *testing.T) {
+		localTestingDir := "test-listing-boards-of-installed-packs"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := filepath.Join(localTestingDir, "TheVendor", "BoardSupportPack", "1.0.0")
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		assert.Nil(os.WriteFile(filepath.Join(packDir, "TheVendor.BoardSupportPack.pdsc"), boardsPdsc, 0600))
+
+		boards, err := installer.FindBoards(false, "", "")
+		assert.Nil(err)
+		assert.Equal(2, len(boards))
+
+		assert.Equal("Discovery Kit", boards[0].Name)
+		assert.Equal(0, len(boards[0].MountedDevices))
+
+		assert.Equal("Eval Board", boards[1].Name)
+		assert.Equal("BoardVendor", boards[1].Vendor)
+		assert.Equal("Rev. 2", boards[1].Revision)
+		assert.Equal([]string{"CHIP200A", "CHIP100"}, boards[1].MountedDevices)
+		assert.Equal("TheVendor::BoardSupportPack@1.0.0", boards[1].Pack)
+		assert.True(boards[1].Installed)
+
+		boards, err = installer.FindBoards(false, "board*", "eval*")
+		assert.Nil(err)
+		assert.Equal(1, len(boards))
+
+		boards, err = installer.FindBoards(false, "NoVendor", "")
+		assert.Nil(err)
+		assert.Equal(0, len(boards))
+	})
+
+	t.Run("test listing boards of cached pdsc files", func(t *testing.T) {
+		localTestingDir := "test-listing-boards-of-cached-pdsc-files"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(os.WriteFile(filepath.Join(installer.Installation.WebDir, "TheVendor.BoardSupportPack.pdsc"), boardsPdsc, 0600))
+
+		boards, err := installer.FindBoards(true, "", "")
+		assert.Nil(err)
+		assert.Equal(2, len(boards))
+		assert.False(boards[0].Installed)
+	})
+}
@@ -0,0 +1,65 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBumpRelease(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test bumping unordered releases", func(t *testing.T) {
+		localTestingDir := "test-bumping-unordered-releases"
+		assert.Nil(os.MkdirAll(localTestingDir, 0700))
+		defer os.RemoveAll(localTestingDir)
+
+		pdscPath := filepath.Join(localTestingDir, "TheVendor.BumpPack.pdsc")
+		pdsc := "<package>\r\n\t<releases>\r\n\t\t<release version=\"1.0.0\"/>\r\n\t\t<release version=\"1.1.0\"/>\r\n\t</releases>\r\n</package>\r\n"
+		assert.Nil(os.WriteFile(pdscPath, []byte(pdsc), 0600))
+
+		version, err := installer.BumpRelease(pdscPath, "patch", "2024-05-01", "Fixed <bugs> & typos")
+		assert.Nil(err)
+		assert.Equal("1.1.1", version)
+
+		// Indentation and line endings of the file are kept
+		data, err := os.ReadFile(pdscPath)
+		assert.Nil(err)
+		assert.Equal("<package>\r\n\t<releases>\r\n\t\t<release version=\"1.1.1\" date=\"2024-05-01\">Fixed &lt;bugs&gt; &amp; typos</release>\r\n\t\t<release version=\"1.0.0\"/>\r\n\t\t<release version=\"1.1.0\"/>\r\n\t</releases>\r\n</package>\r\n", string(data))
+
+		pdscXML := xml.NewPdscXML(pdscPath)
+		assert.Nil(pdscXML.Read())
+		assert.Equal("1.1.1", pdscXML.LatestVersion())
+
+		version, err = installer.BumpRelease(pdscPath, "major", "2024-06-01", "Breaking changes")
+		assert.Nil(err)
+		assert.Equal("2.0.0", version)
+	})
+
+	t.Run("test bumping without releases", func(t *testing.T) {
+		localTestingDir := "test-bumping-without-releases"
+		assert.Nil(os.MkdirAll(localTestingDir, 0700))
+		defer os.RemoveAll(localTestingDir)
+
+		pdscPath := filepath.Join(localTestingDir, "TheVendor.BumpPack.pdsc")
+		assert.Nil(os.WriteFile(pdscPath, []byte("<package><releases></releases></package>"), 0600))
+
+		_, err := installer.BumpRelease(pdscPath, "minor", "2024-05-01", "Notes")
+		assert.True(errs.Is(err, errs.ErrPackVersionNotFoundInPdsc))
+
+		_, err = installer.BumpRelease(pdscPath, "minor", "May 1st", "Notes")
+		assert.True(errs.Is(err, errs.ErrIncorrectCmdArgs))
+
+		_, err = installer.BumpRelease(filepath.Join(localTestingDir, "Missing.pdsc"), "minor", "2024-05-01", "Notes")
+		assert.True(errs.Is(err, errs.ErrPdscFileNotFound))
+	})
+}
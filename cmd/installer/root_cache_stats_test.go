@@ -0,0 +1,69 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheStats(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test counting packs downloaded and served from the cache", func(t *testing.T) {
+		localTestingDir := "test-counting-packs-downloaded-and-served-from-the-cache"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		installer.Installation.WebDir = filepath.Join(testDir, "public_index")
+		defer removePackRoot(localTestingDir)
+
+		stats, err := installer.GetCacheStats()
+		assert.Nil(err)
+		assert.Equal(0, stats.Hits+stats.Misses)
+		assert.Equal(0, stats.Entries)
+		assert.Nil(stats.Since)
+		assert.Nil(stats.Oldest)
+
+		zipContent, err := os.ReadFile(publicRemotePack123)
+		assert.Nil(err)
+		packServer := NewServer()
+		packServer.AddRoute("*", zipContent)
+		packURL := packServer.URL() + filepath.Base(publicRemotePack123)
+
+		assert.Nil(installer.AddPack(packURL, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.Nil(installer.AddPack(packURL, !CheckEula, !ExtractEula, ForceReinstall, !NoRequirements, Timeout))
+
+		stats, err = installer.GetCacheStats()
+		assert.Nil(err)
+		assert.Equal(1, stats.Hits)
+		assert.Equal(1, stats.Misses)
+		assert.Equal(int64(len(zipContent)), stats.BytesSaved)
+		assert.Equal(int64(len(zipContent)), stats.BytesDownloaded)
+		assert.Equal(float64(50), stats.HitRate())
+		assert.NotNil(stats.Since)
+		// The pack and its PDSC file
+		assert.Equal(2, stats.Entries)
+		assert.Greater(stats.Size, int64(len(zipContent)))
+		assert.Equal(filepath.Base(publicRemotePack123), stats.Oldest.Name)
+		assert.False(stats.Newest.Modified.Before(stats.Oldest.Modified))
+	})
+
+	t.Run("test not counting local packs", func(t *testing.T) {
+		localTestingDir := "test-not-counting-local-packs"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		addPack(t, publicLocalPack123, ConfigType{})
+
+		stats, err := installer.GetCacheStats()
+		assert.Nil(err)
+		assert.Equal(0, stats.Hits+stats.Misses)
+	})
+}
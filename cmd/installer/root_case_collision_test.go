@@ -0,0 +1,110 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+var casePackPdsc = `<?xml version="1.0" encoding="UTF-8"?>
+<package>
+  <vendor>TheVendor</vendor>
+  <name>CasePack</name>
+  <url>http://vendor.com/packs/</url>
+  <releases>
+    <release version="1.2.3">Initial release.</release>
+  </releases>
+</package>
+`
+
+// caseInsensitiveFs finds files by name regardless of their case, like the
+// default file systems of Windows and macOS
+type caseInsensitiveFs struct {
+	afero.Fs
+}
+
+func (c caseInsensitiveFs) Stat(name string) (os.FileInfo, error) {
+	info, err := c.Fs.Stat(name)
+	if err == nil {
+		return info, nil
+	}
+	entries, readErr := afero.ReadDir(c.Fs, filepath.Dir(name))
+	if readErr != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), filepath.Base(name)) {
+			return entry, nil
+		}
+	}
+	return nil, err
+}
+
+// writeCasePack writes TheVendor.CasePack.1.2.3.pack, two files of which only
+// differ by case, to dir
+func writeCasePack(t *testing.T, dir string) string {
+	assert := assert.New(t)
+
+	packPath := filepath.Join(dir, "TheVendor.CasePack.1.2.3.pack")
+	out, err := os.Create(packPath)
+	assert.Nil(err)
+	w := zip.NewWriter(out)
+	for _, name := range []string{"TheVendor.CasePack.pdsc", "Include/core.h", "include/Core.h"} {
+		writer, err := w.Create(name)
+		assert.Nil(err)
+		_, err = writer.Write([]byte(casePackPdsc))
+		assert.Nil(err)
+	}
+	assert.Nil(w.Close())
+	assert.Nil(out.Close())
+	return packPath
+}
+
+func TestAddPackCaseCollisions(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test warning about case collisions on case-sensitive file systems", func(t *testing.T) {
+		localTestingDir := "test-warning-about-case-collisions-on-case-sensitive-file-systems"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		packPath := writeCasePack(t, localTestingDir)
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(io.Discard)
+
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.Contains(buf.String(), `Files "Include/core.h", "include/Core.h" of TheVendor.CasePack only differ by case`)
+	})
+
+	t.Run("test refusing case collisions on case-insensitive file systems", func(t *testing.T) {
+		localTestingDir := "test-refusing-case-collisions-on-case-insensitive-file-systems"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		packPath := writeCasePack(t, localTestingDir)
+
+		utils.SetFileSystem(caseInsensitiveFs{afero.NewOsFs()})
+		defer utils.SetFileSystem(nil)
+
+		err := installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.True(errs.Is(err, errs.ErrCaseCollision))
+		assert.NoDirExists(filepath.Join(localTestingDir, "TheVendor", "CasePack", "1.2.3"))
+	})
+}
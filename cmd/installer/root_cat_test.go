@@ -0,0 +1,65 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"bytes"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatPackFile(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test printing the pdsc file of a pack archive", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Nil(installer.CatPackFile(nonPublicLocalPack123, "", &buf))
+		assert.Contains(buf.String(), "<name>NonPublicLocalPack</name>")
+	})
+
+	t.Run("test printing a file of a pack archive within a subfolder", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Nil(installer.CatPackFile(packWithSubFolder, "TheVendor.PackWithSubFolder.pdsc", &buf))
+		assert.Contains(buf.String(), "<name>PackWithSubFolder</name>")
+	})
+
+	t.Run("test printing a file missing from a pack archive", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := installer.CatPackFile(nonPublicLocalPack123, "missing_file", &buf)
+		assert.Equal(errs.WithPath(errs.ErrFileNotFound, "missing_file"), err)
+	})
+
+	t.Run("test printing a file outside of the pack", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := installer.CatPackFile(nonPublicLocalPack123, "../outside", &buf)
+		assert.Equal(errs.ErrInsecureZipFileName, err)
+	})
+
+	t.Run("test printing a file of an installed pack", func(t *testing.T) {
+		localTestingDir := "test-printing-a-file-of-an-installed-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.AddPack(nonPublicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		var pdsc bytes.Buffer
+		assert.Nil(installer.CatPackFile(nonPublicLocalPack123, "", &pdsc))
+
+		var buf bytes.Buffer
+		assert.Nil(installer.CatPackFile("TheVendor::NonPublicLocalPack", "", &buf))
+		assert.Equal(pdsc.String(), buf.String())
+
+		buf.Reset()
+		assert.Nil(installer.CatPackFile("TheVendor::NonPublicLocalPack@1.2.3", "sample_file", &buf))
+		assert.Empty(buf.String())
+
+		err := installer.CatPackFile("TheVendor::PublicLocalPack", "", &buf)
+		assert.Equal(errs.ErrPackNotInstalled, err)
+	})
+}
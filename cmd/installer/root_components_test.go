@@ -0,0 +1,91 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+var componentsPdsc = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<package schemaVersion="1.7.7">
+  <vendor>TheVendor</vendor>
+  <name>ComponentPack</name>
+  <url>http://the.url/</url>
+  <releases>
+    <release version="1.0.0"/>
+  </releases>
+  <components>
+    <component Cclass="CMSIS" Cgroup="CORE" Cversion="5.6.0"/>
+    <component Cvendor="OtherVendor" Cclass="CMSIS" Cgroup="RTOS2" Csub="Keil RTX5" Cvariant="Library" Cversion="5.5.4"/>
+    <bundle Cbundle="Board Support" Cclass="Board Support" Cversion="1.1.0">
+      <component Cgroup="LED"/>
+      <component Cgroup="Buttons"/>
+    </bundle>
+  </components>
+</package>
+`)
+
+func TestFindComponents(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test listing components of installed packs", func(t *testing.T) {
+		localTestingDir := "test-listing-components-of-installed-packs"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := filepath.Join(localTestingDir, "TheVendor", "ComponentPack", "1.0.0")
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		assert.Nil(os.WriteFile(filepath.Join(packDir, "TheVendor.ComponentPack.pdsc"), componentsPdsc, 0600))
+
+		components, err := installer.FindComponents(false, "", "")
+		assert.Nil(err)
+		ids := []string{}
+		for _, component := range components {
+			ids = append(ids, component.ID)
+			assert.Equal("TheVendor::ComponentPack@1.0.0", component.Pack)
+			assert.True(component.Installed)
+		}
+		assert.Equal([]string{
+			"OtherVendor::CMSIS:RTOS2:Keil RTX5&Library@5.5.4",
+			"TheVendor::Board Support&Board Support:Buttons@1.1.0",
+			"TheVendor::Board Support&Board Support:LED@1.1.0",
+			"TheVendor::CMSIS:CORE@5.6.0",
+		}, ids)
+
+		components, err = installer.FindComponents(false, "cmsis", "")
+		assert.Nil(err)
+		assert.Equal(2, len(components))
+
+		components, err = installer.FindComponents(false, "CMSIS", "RTOS*")
+		assert.Nil(err)
+		assert.Equal(1, len(components))
+		assert.Equal("Keil RTX5", components[0].Sub)
+		assert.Equal("Library", components[0].Variant)
+	})
+
+	t.Run("test listing components of cached pdsc files", func(t *testing.T) {
+		localTestingDir := "test-listing-components-of-cached-pdsc-files"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(os.WriteFile(filepath.Join(installer.Installation.WebDir, "TheVendor.ComponentPack.pdsc"), componentsPdsc, 0600))
+
+		components, err := installer.FindComponents(false, "", "")
+		assert.Nil(err)
+		assert.Equal(0, len(components))
+
+		components, err = installer.FindComponents(true, "Board Support", "")
+		assert.Nil(err)
+		assert.Equal(2, len(components))
+		assert.False(components[0].Installed)
+	})
+}
@@ -0,0 +1,93 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckVendorConnections(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test checking the vendor URLs of the index", func(t *testing.T) {
+		localTestingDir := "test-checking-the-vendor-urls-of-the-index"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		// OtherVendor.PackName.pdsc is the file probed at server
+		server := NewServer()
+		server.AddRoute("OtherVendor.PackName.pdsc", []byte("<package/>"))
+		missingServer := NewServer()
+
+		for _, pdscTag := range []xml.PdscTag{
+			{URL: server.URL(), Vendor: "TheVendor", Name: "PackName", Version: "1.2.3"},
+			{URL: server.URL(), Vendor: "TheVendor", Name: "OtherPack", Version: "1.2.3"},
+			{URL: server.URL(), Vendor: "OtherVendor", Name: "PackName", Version: "1.2.3"},
+			{URL: missingServer.URL(), Vendor: "MissingVendor", Name: "PackName", Version: "1.2.3"},
+		} {
+			assert.Nil(installer.Installation.PublicIndexXML.AddPdsc(pdscTag))
+		}
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(io.Discard)
+
+		connections, err := installer.CheckVendorConnections(0, Timeout)
+		assert.Nil(err)
+		assert.Len(connections, 2)
+
+		for _, connection := range connections {
+			if connection.URL == server.URL() {
+				assert.Nil(connection.Err)
+				assert.Equal([]string{"OtherVendor", "TheVendor"}, connection.Vendors)
+				assert.Greater(connection.Latency, time.Duration(0))
+				assert.Greater(connection.Throughput, float64(0))
+			} else {
+				assert.Equal(missingServer.URL(), connection.URL)
+				assert.NotNil(connection.Err)
+				assert.Equal([]string{"MissingVendor"}, connection.Vendors)
+			}
+		}
+
+		stdout := buf.String()
+		assert.Contains(stdout, "Checking 2 vendor URLs of the public index")
+		assert.Contains(stdout, "LATENCY")
+		assert.Contains(stdout, "unreachable")
+		assert.Contains(stdout, "OtherVendor, TheVendor")
+	})
+
+	t.Run("test checking slow vendor URLs", func(t *testing.T) {
+		localTestingDir := "test-checking-slow-vendor-urls"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		defer func(slowLatency time.Duration) { installer.SlowLatency = slowLatency }(installer.SlowLatency)
+		installer.SlowLatency = 0
+
+		server := NewServer()
+		server.AddRoute("TheVendor.PackName.pdsc", []byte("<package/>"))
+		assert.Nil(installer.Installation.PublicIndexXML.AddPdsc(xml.PdscTag{URL: server.URL(), Vendor: "TheVendor", Name: "PackName", Version: "1.2.3"}))
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(io.Discard)
+
+		connections, err := installer.CheckVendorConnections(4, Timeout)
+		assert.Nil(err)
+		assert.Len(connections, 1)
+		assert.True(connections[0].Slow)
+		assert.Contains(buf.String(), "1 vendor URLs are slow and 0 cannot be reached")
+	})
+}
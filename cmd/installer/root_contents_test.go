@@ -0,0 +1,75 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+// entryNames returns the names of entries
+func entryNames(entries []installer.PackEntry) []string {
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+func TestPackContents(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test listing the contents of a pack archive", func(t *testing.T) {
+		entries, source, err := installer.PackContents(nonPublicLocalPack123, "")
+		assert.Nil(err)
+		assert.Equal(nonPublicLocalPack123, source)
+		assert.Equal([]string{"TheVendor.NonPublicLocalPack.pdsc", "sample_file"}, entryNames(entries))
+		assert.Equal(uint64(475), entries[0].Size)
+		assert.Equal(uint64(281), entries[0].CompressedSize)
+	})
+
+	t.Run("test listing the contents of a pack archive within a subfolder", func(t *testing.T) {
+		entries, _, err := installer.PackContents(packWithSubFolder, "*.pdsc")
+		assert.Nil(err)
+		assert.Equal([]string{"TheVendor.PackWithSubFolder.pdsc"}, entryNames(entries))
+	})
+
+	t.Run("test listing the contents of an installed pack", func(t *testing.T) {
+		localTestingDir := "test-listing-the-contents-of-an-installed-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.AddPack(nonPublicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		// The cached archive comes first
+		entries, source, err := installer.PackContents("TheVendor::NonPublicLocalPack", "sample_*")
+		assert.Nil(err)
+		assert.Equal(filepath.Join(installer.Installation.DownloadDir, "TheVendor.NonPublicLocalPack.1.2.3.pack"), source)
+		assert.Equal([]string{"sample_file"}, entryNames(entries))
+
+		assert.Nil(os.Remove(source))
+		entries, source, err = installer.PackContents("TheVendor::NonPublicLocalPack@1.2.3", "")
+		assert.Nil(err)
+		assert.Equal(filepath.Join(localTestingDir, "TheVendor", "NonPublicLocalPack", "1.2.3"), source)
+		assert.Equal([]string{"TheVendor.NonPublicLocalPack.pdsc", "sample_file"}, entryNames(entries))
+		assert.Equal(uint64(0), entries[0].CompressedSize)
+
+		_, _, err = installer.PackContents("TheVendor::NonPublicLocalPack@1.2.4", "")
+		assert.Equal(errs.ErrPackNotInstalled, err)
+	})
+
+	t.Run("test matching pack entries", func(t *testing.T) {
+		assert.True(installer.MatchPackEntry("", "Device/Include/device.h"))
+		assert.True(installer.MatchPackEntry("*.h", "Device/Include/device.h"))
+		assert.True(installer.MatchPackEntry("Device/*/*.h", "Device/Include/device.h"))
+		assert.False(installer.MatchPackEntry("Device/*.h", "Device/Include/device.h"))
+	})
+}
@@ -0,0 +1,158 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+var devicesPdsc = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<package schemaVersion="1.7.7">
+  <vendor>TheVendor</vendor>
+  <name>DeviceFamilyPack</name>
+  <url>http://the.url/</url>
+  <releases>
+    <release version="1.0.0"/>
+  </releases>
+  <devices>
+    <family Dfamily="Chip Series" Dvendor="ChipVendor:1">
+      <device Dname="CHIP100"/>
+      <subFamily DsubFamily="CHIP2">
+        <device Dname="CHIP200">
+          <variant Dvariant="CHIP200A"/>
+          <variant Dvariant="CHIP200B"/>
+        </device>
+      </subFamily>
+      <subFamily DsubFamily="Licensed" Dvendor="OtherVendor:2">
+        <device Dname="OTHER300"/>
+      </subFamily>
+    </family>
+  </devices>
+</package>
+`)
+
+func TestFindDevices(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test listing devices of installed packs", func(t *testing.T) {
+		localTestingDir := "test-listing-devices-of-installed-packs"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := filepath.Join(localTestingDir, "TheVendor", "DeviceFamilyPack", "1.0.0")
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		assert.Nil(os.WriteFile(filepath.Join(packDir, "TheVendor.DeviceFamilyPack.pdsc"), devicesPdsc, 0600))
+
+		devices, err := installer.FindDevices(false, "", "")
+		assert.Nil(err)
+		assert.Equal(4, len(devices))
+
+		// Devices with variants are listed by variant, sorted by name
+		names := []string{}
+		for _, device := range devices {
+			names = append(names, device.Name)
+			assert.Equal("TheVendor::DeviceFamilyPack@1.0.0", device.Pack)
+			assert.True(device.Installed)
+		}
+		assert.Equal([]string{"CHIP100", "CHIP200A", "CHIP200B", "OTHER300"}, names)
+		assert.Equal("ChipVendor", devices[1].Vendor)
+		assert.Equal("Chip Series", devices[1].Family)
+		assert.Equal("CHIP2", devices[1].SubFamily)
+		assert.Equal("OtherVendor", devices[3].Vendor)
+
+		devices, err = installer.FindDevices(false, "", "chip2*")
+		assert.Nil(err)
+		assert.Equal(2, len(devices))
+
+		devices, err = installer.FindDevices(false, "Other*", "")
+		assert.Nil(err)
+		assert.Equal(1, len(devices))
+		assert.Equal("OTHER300", devices[0].Name)
+
+		_, err = installer.FindDevices(false, "", "[")
+		assert.NotNil(err)
+	})
+
+	t.Run("test listing devices of cached pdsc files", func(t *testing.T) {
+		localTestingDir := "test-listing-devices-of-cached-pdsc-files"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(os.WriteFile(filepath.Join(installer.Installation.WebDir, "TheVendor.DeviceFamilyPack.pdsc"), devicesPdsc, 0600))
+
+		devices, err := installer.FindDevices(false, "", "")
+		assert.Nil(err)
+		assert.Equal(0, len(devices))
+
+		devices, err = installer.FindDevices(true, "", "CHIP100")
+		assert.Nil(err)
+		assert.Equal(1, len(devices))
+		assert.Equal("TheVendor::DeviceFamilyPack@1.0.0", devices[0].Pack)
+		assert.False(devices[0].Installed)
+	})
+
+	t.Run("test exporting the device tree", func(t *testing.T) {
+		localTestingDir := "test-exporting-the-device-tree"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		pdsc := `<package><vendor>TheVendor</vendor><name>DualCorePack</name>
+  <releases><release version="1.0.0"/></releases>
+  <devices>
+    <family Dfamily="Dual Series" Dvendor="ChipVendor:1">
+      <processor Dcore="Cortex-M33" Dfpu="SP_FPU" Dendian="Little-endian"/>
+      <subFamily DsubFamily="DUAL1">
+        <processor Dclock="64000000"/>
+        <device Dname="DUAL100">
+          <processor Pname="cm7" Dcore="Cortex-M7"/>
+          <variant Dvariant="DUAL100A">
+            <processor Pname="cm7" Dclock="480000000"/>
+          </variant>
+        </device>
+      </subFamily>
+    </family>
+  </devices>
+</package>`
+		packDir := filepath.Join(localTestingDir, "TheVendor", "DualCorePack", "1.0.0")
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		assert.Nil(os.WriteFile(filepath.Join(packDir, "TheVendor.DualCorePack.pdsc"), []byte(pdsc), 0600))
+
+		packs, err := installer.FindDeviceTree(false)
+		assert.Nil(err)
+		assert.Equal(1, len(packs))
+		assert.Equal("TheVendor::DualCorePack@1.0.0", packs[0].Pack)
+
+		family := packs[0].Families[0]
+		assert.Equal("Dual Series", family.Family)
+		assert.Equal("ChipVendor", family.Vendor)
+		assert.Equal(0, len(family.Devices))
+		assert.Equal(1, len(family.Processors))
+
+		// Processor attributes are inherited and overridden by Pname
+		subFamily := family.SubFamilies[0]
+		assert.Equal("64000000", subFamily.Processors[0].Dclock)
+		assert.Equal("Cortex-M33", subFamily.Processors[0].Dcore)
+
+		device := subFamily.Devices[0]
+		assert.Equal("DUAL100", device.Name)
+		assert.Equal(2, len(device.Processors))
+		assert.Equal("Cortex-M7", device.Processors[1].Dcore)
+
+		variant := device.Variants[0]
+		assert.Equal("DUAL100A", variant.Name)
+		assert.Equal("ChipVendor", variant.Vendor)
+		assert.Equal("64000000", variant.Processors[0].Dclock)
+		assert.Equal("Cortex-M7", variant.Processors[1].Dcore)
+		assert.Equal("480000000", variant.Processors[1].Dclock)
+	})
+}
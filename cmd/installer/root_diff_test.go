@@ -0,0 +1,107 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/stretchr/testify/assert"
+)
+
+// writePackWithNewFile writes a copy of packPath to newPackPath, with sample_file replaced by new_file
+func writePackWithNewFile(t *testing.T, packPath, newPackPath string) {
+	assert := assert.New(t)
+
+	z, err := zip.OpenReader(packPath)
+	assert.Nil(err)
+	defer z.Close()
+
+	out, err := os.Create(newPackPath)
+	assert.Nil(err)
+	defer out.Close()
+	w := zip.NewWriter(out)
+	for _, file := range z.File {
+		if file.Name != "sample_file" {
+			assert.Nil(w.Copy(file))
+		}
+	}
+	writer, err := w.Create("new_file")
+	assert.Nil(err)
+	_, err = writer.Write([]byte("new"))
+	assert.Nil(err)
+	assert.Nil(w.Close())
+}
+
+func TestDiffPacks(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test comparing two pack archives", func(t *testing.T) {
+		diffs, err := installer.DiffPacks(publicLocalPack123, publicLocalPack124, Timeout)
+		assert.Nil(err)
+		assert.Equal([]installer.PackDiff{{Name: "TheVendor.PublicLocalPack.pdsc", Change: installer.PackFileChanged}}, diffs)
+	})
+
+	t.Run("test comparing pack archives with added and removed files", func(t *testing.T) {
+		newPackPath := filepath.Join(t.TempDir(), "TheVendor.PublicLocalPack.1.2.5.pack")
+		writePackWithNewFile(t, publicLocalPack123, newPackPath)
+
+		diffs, err := installer.DiffPacks(publicLocalPack123, newPackPath, Timeout)
+		assert.Nil(err)
+		assert.Equal([]installer.PackDiff{
+			{Name: "new_file", Change: installer.PackFileAdded},
+			{Name: "sample_file", Change: installer.PackFileRemoved},
+		}, diffs)
+	})
+
+	t.Run("test comparing an installed pack with its archive", func(t *testing.T) {
+		localTestingDir := "test-comparing-an-installed-pack-with-its-archive"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.AddPack(nonPublicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		utils.UnsetReadOnlyR(installer.Installation.DownloadDir)
+		assert.Nil(os.Remove(filepath.Join(installer.Installation.DownloadDir, filepath.Base(nonPublicLocalPack123))))
+
+		diffs, err := installer.DiffPacks("TheVendor.NonPublicLocalPack@1.2.3", nonPublicLocalPack123, Timeout)
+		assert.Nil(err)
+		assert.Empty(diffs)
+
+		_, err = installer.DiffPacks("TheVendor::PublicLocalPack", nonPublicLocalPack123, Timeout)
+		assert.Equal(errs.ErrPackNotInstalled, err)
+	})
+
+	t.Run("test comparing a pack downloaded", func(t *testing.T) {
+		localTestingDir := "test-comparing-a-pack-downloaded"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packContent, err := os.ReadFile(publicRemotePack123)
+		assert.Nil(err)
+		server := NewServer()
+		server.AddRoute("pack.zip", packContent)
+
+		// The release of the pack in .Web/ points to the server
+		packPdscFilePath := filepath.Join(installer.Installation.WebDir, "TheVendor.PublicRemotePack.pdsc")
+		assert.Nil(utils.CopyFile(pdscPack123MissingVersion, packPdscFilePath))
+		pdscXML := xml.NewPdscXML(packPdscFilePath)
+		assert.Nil(pdscXML.Read())
+		pdscXML.ReleasesTag.Releases = append(pdscXML.ReleasesTag.Releases, xml.ReleaseTag{URL: server.URL() + "pack.zip", Version: "1.2.3"})
+		assert.Nil(utils.WriteXML(pdscXML.FileName, pdscXML))
+
+		diffs, err := installer.DiffPacks("TheVendor::PublicRemotePack@1.2.3", publicRemotePack123, Timeout)
+		assert.Nil(err)
+		assert.Empty(diffs)
+		assert.True(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, "TheVendor.PublicRemotePack.1.2.3.pack")))
+	})
+}
@@ -0,0 +1,127 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+// findCheck returns the first check named name
+func findCheck(checks []installer.EnvironmentCheck, name string) installer.EnvironmentCheck {
+	for _, check := range checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	return installer.EnvironmentCheck{Name: "missing " + name}
+}
+
+func TestCheckEnvironment(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// newIndexHost serves the index of a pack root, answering with a clock skewed by skew
+	newIndexHost := func(skew time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Date", time.Now().Add(skew).UTC().Format(http.TimeFormat))
+		}))
+	}
+
+	// setUpPackRoot creates a pack root with an index downloaded from indexHost
+	setUpPackRoot := func(localTestingDir, indexHost string) {
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		installer.Installation.PublicIndexXML.URL = indexHost + "/"
+		assert.Nil(installer.Installation.PublicIndexXML.Write())
+	}
+
+	t.Run("test checking a healthy environment", func(t *testing.T) {
+		for _, envVar := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy"} {
+			t.Setenv(envVar, "")
+		}
+		indexHost := newIndexHost(0)
+		defer indexHost.Close()
+
+		// Whatever disk space the test machine has left is fine
+		defer func(minFreeDiskSpace uint64) { installer.MinFreeDiskSpace = minFreeDiskSpace }(installer.MinFreeDiskSpace)
+		installer.MinFreeDiskSpace = 0
+
+		localTestingDir := "test-checking-a-healthy-environment"
+		setUpPackRoot(localTestingDir, indexHost.URL)
+		defer removePackRoot(localTestingDir)
+
+		checks := installer.CheckEnvironment(localTestingDir, Timeout)
+		for _, check := range checks {
+			assert.Empty(check.Problem, check.Name)
+		}
+		assert.Equal(localTestingDir, findCheck(checks, "pack root").Detail)
+		assert.Equal("no proxy is set", findCheck(checks, "proxy").Detail)
+		assert.Contains(findCheck(checks, "index host").Detail, "without HTTPS")
+		assert.Contains(findCheck(checks, "clock").Detail, "off from")
+		assert.Equal("disk space", findCheck(checks, "disk space").Name)
+	})
+
+	t.Run("test checking an environment with problems", func(t *testing.T) {
+		t.Setenv("HTTPS_PROXY", "proxy.example.com")
+		indexHost := newIndexHost(-time.Hour)
+		defer indexHost.Close()
+
+		localTestingDir := "test-checking-an-environment-with-problems"
+		setUpPackRoot(localTestingDir, indexHost.URL)
+		defer removePackRoot(localTestingDir)
+		assert.Nil(os.Remove(filepath.Join(localTestingDir, ".Local", "local_repository.pidx")))
+		assert.Nil(os.Remove(filepath.Join(localTestingDir, ".Local")))
+
+		missingPackRoot := "test-checking-a-missing-pack-root"
+		checks := installer.CheckEnvironment(missingPackRoot+string(os.PathListSeparator)+localTestingDir, Timeout)
+
+		packRoot := findCheck(checks, "pack root")
+		assert.Equal("\""+missingPackRoot+"\" does not exist", packRoot.Problem)
+		assert.Contains(packRoot.Hint, "cpackget init --pack-root "+missingPackRoot)
+
+		found := false
+		for _, check := range checks {
+			if check.Name == "pack root" && check.Detail == localTestingDir {
+				assert.Contains(check.Problem, "is missing its .Local folder")
+				found = true
+			}
+		}
+		assert.True(found)
+
+		proxy := findCheck(checks, "proxy")
+		assert.Equal("HTTPS_PROXY is not a URL", proxy.Problem)
+		assert.NotEmpty(proxy.Hint)
+
+		clock := findCheck(checks, "clock")
+		assert.Contains(clock.Problem, "the clock is 1h0m")
+		assert.NotEmpty(clock.Hint)
+	})
+
+	t.Run("test checking an unreachable index host", func(t *testing.T) {
+		indexHost := newIndexHost(0)
+		indexHost.Close()
+
+		localTestingDir := "test-checking-an-unreachable-index-host"
+		setUpPackRoot(localTestingDir, indexHost.URL)
+		defer removePackRoot(localTestingDir)
+
+		checks := installer.CheckEnvironment(localTestingDir, Timeout)
+		host := findCheck(checks, "index host")
+		assert.Contains(host.Problem, "cannot reach")
+		assert.Equal("missing clock", findCheck(checks, "clock").Name)
+	})
+
+	t.Run("test checking without a pack root", func(t *testing.T) {
+		checks := installer.CheckEnvironment("", 1)
+		assert.Equal("no pack root is set", findCheck(checks, "pack root").Problem)
+	})
+}
@@ -0,0 +1,83 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadPack(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test downloading a pack from a url", func(t *testing.T) {
+		localTestingDir := "test-downloading-a-pack-from-a-url"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packContent, err := os.ReadFile(publicLocalPack123)
+		assert.Nil(err)
+		server := NewServer()
+		server.AddRoute(filepath.Base(publicLocalPack123), packContent)
+
+		downloadPath, err := installer.DownloadPack(server.URL()+filepath.Base(publicLocalPack123), Timeout)
+		assert.Nil(err)
+		assert.Equal(filepath.Join(installer.Installation.DownloadDir, filepath.Base(publicLocalPack123)), downloadPath)
+		assert.FileExists(downloadPath)
+		assert.NoDirExists(filepath.Join(localTestingDir, "TheVendor", "PublicLocalPack"))
+	})
+
+	t.Run("test staging a local pack", func(t *testing.T) {
+		localTestingDir := "test-staging-a-local-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		downloadPath, err := installer.DownloadPack(publicLocalPack123, Timeout)
+		assert.Nil(err)
+		assert.Equal(filepath.Join(installer.Installation.DownloadDir, filepath.Base(publicLocalPack123)), downloadPath)
+		assert.FileExists(downloadPath)
+		assert.NoDirExists(filepath.Join(localTestingDir, "TheVendor", "PublicLocalPack"))
+	})
+
+	t.Run("test downloading a corrupt pack", func(t *testing.T) {
+		localTestingDir := "test-downloading-a-corrupt-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packPath := filepath.Join(localTestingDir, "TheVendor.CorruptPack.1.2.3.pack")
+		out, err := os.Create(packPath)
+		assert.Nil(err)
+		w := zip.NewWriter(out)
+		writer, err := w.CreateRaw(&zip.FileHeader{Name: "TheVendor.CorruptPack.pdsc", Method: zip.Store, CRC32: 1, CompressedSize64: 7, UncompressedSize64: 7})
+		assert.Nil(err)
+		_, err = writer.Write([]byte("corrupt"))
+		assert.Nil(err)
+		assert.Nil(w.Close())
+		assert.Nil(out.Close())
+
+		_, err = installer.DownloadPack(packPath, Timeout)
+		assert.Equal(errs.ErrFailedDecompressingFile, err)
+		assert.NoFileExists(filepath.Join(installer.Installation.DownloadDir, "TheVendor.CorruptPack.1.2.3.pack"))
+	})
+
+	t.Run("test downloading a pdsc file", func(t *testing.T) {
+		localTestingDir := "test-downloading-a-pdsc-file"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		_, err := installer.DownloadPack(pdscPack123, Timeout)
+		assert.Equal(errs.ErrBadPackName, err)
+	})
+}
@@ -0,0 +1,121 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// tamperEmbeddedChecksum rewrites the pack in packPath with the first
+// digest of its embedded checksum file changed
+func tamperEmbeddedChecksum(t *testing.T, packPath string) {
+	assert := assert.New(t)
+
+	z, err := zip.OpenReader(packPath)
+	assert.Nil(err)
+	defer z.Close()
+
+	tamperedPath := packPath + ".tampered"
+	out, err := os.Create(tamperedPath)
+	assert.Nil(err)
+	w := zip.NewWriter(out)
+	for _, file := range z.File {
+		if !strings.HasSuffix(file.Name, "/.checksum/sha256.checksum") {
+			assert.Nil(w.Copy(file))
+			continue
+		}
+		reader, err := file.Open()
+		assert.Nil(err)
+		content, err := io.ReadAll(reader)
+		assert.Nil(err)
+		reader.Close()
+
+		writer, err := w.Create(file.Name)
+		assert.Nil(err)
+		_, err = writer.Write(append([]byte("0000"), content[4:]...))
+		assert.Nil(err)
+	}
+	assert.Nil(w.Close())
+	assert.Nil(out.Close())
+	z.Close()
+	assert.Nil(os.Rename(tamperedPath, packPath))
+}
+
+func TestAddPackWithEmbeddedChecksum(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test installing a pack with an embedded checksum", func(t *testing.T) {
+		localTestingDir := "test-installing-a-pack-with-an-embedded-checksum"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := localTestingDir + "-packs"
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		defer os.RemoveAll(packDir)
+		packPath := filepath.Join(packDir, filepath.Base(packWithSubFolder))
+		assert.Nil(utils.CopyFile(packWithSubFolder, packPath))
+
+		assert.Nil(cryptography.EmbedChecksum(packPath, packPath, "sha256"))
+
+		// Embedding again replaces the embedded checksum file
+		assert.Nil(cryptography.EmbedChecksum(packPath, packPath, "sha256"))
+
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		packHomeDir := filepath.Join(localTestingDir, "TheVendor", "PackWithSubFolder", "1.2.3")
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, ".checksum", "sha256.checksum")))
+
+		// Files added after installing are noticed
+		assert.Nil(os.WriteFile(filepath.Join(packHomeDir, "extra_file"), []byte("extra"), 0600))
+		verified, err := cryptography.VerifyEmbeddedChecksum(packHomeDir)
+		assert.True(verified)
+		assert.True(errs.Is(err, errs.ErrIntegrityCheckFailed))
+	})
+
+	t.Run("test installing a pack with a tampered embedded checksum", func(t *testing.T) {
+		localTestingDir := "test-installing-a-pack-with-a-tampered-embedded-checksum"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := localTestingDir + "-packs"
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		defer os.RemoveAll(packDir)
+		packPath := filepath.Join(packDir, filepath.Base(packWithSubFolder))
+		assert.Nil(utils.CopyFile(packWithSubFolder, packPath))
+
+		assert.Nil(cryptography.EmbedChecksum(packPath, packPath, "sha256"))
+		tamperEmbeddedChecksum(t, packPath)
+
+		err := installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.True(errs.Is(err, errs.ErrIntegrityCheckFailed))
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, "TheVendor", "PackWithSubFolder", "1.2.3")))
+	})
+
+	t.Run("test installing a pack without an embedded checksum", func(t *testing.T) {
+		localTestingDir := "test-installing-a-pack-without-an-embedded-checksum"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.AddPack(packWithSubFolder, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		verified, err := cryptography.VerifyEmbeddedChecksum(filepath.Join(localTestingDir, "TheVendor", "PackWithSubFolder", "1.2.3"))
+		assert.False(verified)
+		assert.Nil(err)
+	})
+}
@@ -0,0 +1,77 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEulaAllowlist(t *testing.T) {
+
+	assert := assert.New(t)
+
+	defer func() { ui.LicenseAgreed = nil }()
+	defer installer.SetEulaAllowlist(nil)
+
+	// Learn the hash of the license of packWithLicense
+	localTestingDir := "test-eula-allowlist-hash"
+	assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+	installer.UnlockPackRoot()
+	assert.Nil(installer.AddPack(packWithLicense, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+	decisions, err := installer.ReadEulaDecisions()
+	assert.Nil(err)
+	assert.Len(decisions, 1)
+	licenseHash := decisions[0].SHA256
+	removePackRoot(localTestingDir)
+
+	allowlistFile := filepath.Join(t.TempDir(), "eula_allowlist.txt")
+
+	t.Run("test adding a pack with a pre-approved license", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-with-a-pre-approved-license"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(os.WriteFile(allowlistFile, []byte("# Approved by legal\n"+strings.ToUpper(licenseHash)+"  LICENSE.txt\n"), 0600))
+		allowlist, err := installer.ReadEulaAllowlist(allowlistFile)
+		assert.Nil(err)
+		installer.SetEulaAllowlist(allowlist)
+
+		ui.LicenseAgreed = &ui.Disagreed
+		assert.Nil(installer.AddPack(packWithLicense, CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.DirExists(filepath.Join(localTestingDir, "TheVendor", "PackWithLicense", "1.2.3"))
+
+		decisions, err := installer.ReadEulaDecisions()
+		assert.Nil(err)
+		assert.Len(decisions, 1)
+		assert.Equal(installer.EulaAllowlisted, decisions[0].Source)
+	})
+
+	t.Run("test adding a pack with a license not pre-approved", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-with-a-license-not-pre-approved"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		installer.SetEulaAllowlist(map[string]bool{strings.Repeat("0", 64): true})
+
+		ui.LicenseAgreed = &ui.Disagreed
+		assert.Nil(installer.AddPack(packWithLicense, CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.NoDirExists(filepath.Join(localTestingDir, "TheVendor", "PackWithLicense", "1.2.3"))
+	})
+
+	t.Run("test reading an invalid allowlist", func(t *testing.T) {
+		assert.Nil(os.WriteFile(allowlistFile, []byte("LICENSE.txt\n"), 0600))
+		_, err := installer.ReadEulaAllowlist(allowlistFile)
+		assert.Equal(errs.ErrInvalidEulaAllowlist, err)
+	})
+}
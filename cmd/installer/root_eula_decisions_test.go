@@ -0,0 +1,81 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEulaDecisions(t *testing.T) {
+
+	assert := assert.New(t)
+
+	defer func() { ui.LicenseAgreed = nil }()
+
+	t.Run("test accepted licenses are not asked for again", func(t *testing.T) {
+		localTestingDir := "test-accepted-licenses-are-not-asked-for-again"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		ui.LicenseAgreed = &ui.Agreed
+		assert.Nil(installer.AddPack(packWithLicense, CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		decisions, err := installer.ReadEulaDecisions()
+		assert.Nil(err)
+		assert.Len(decisions, 1)
+		assert.Equal("TheVendor.PackWithLicense.1.2.3", decisions[0].Pack)
+		assert.True(decisions[0].Accepted)
+		assert.Equal(installer.EulaPrompted, decisions[0].Source)
+		assert.Len(decisions[0].SHA256, 64)
+
+		// Declining now would not install it again, if asked
+		assert.Nil(installer.RemovePack(packWithLicense, false, Timeout))
+		ui.LicenseAgreed = &ui.Disagreed
+		assert.Nil(installer.AddPack(packWithLicense, CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.DirExists(filepath.Join(installer.Installation.PackRoot, "TheVendor", "PackWithLicense", "1.2.3"))
+
+		decisions, err = installer.ReadEulaDecisions()
+		assert.Nil(err)
+		assert.Len(decisions, 1)
+	})
+
+	t.Run("test declined licenses are asked for again", func(t *testing.T) {
+		localTestingDir := "test-declined-licenses-are-asked-for-again"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		ui.LicenseAgreed = &ui.Disagreed
+		assert.Nil(installer.AddPack(packWithLicense, CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		ui.LicenseAgreed = &ui.Agreed
+		assert.Nil(installer.AddPack(packWithLicense, CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		decisions, err := installer.ReadEulaDecisions()
+		assert.Nil(err)
+		assert.Len(decisions, 2)
+		assert.False(decisions[0].Accepted)
+		assert.True(decisions[1].Accepted)
+	})
+
+	t.Run("test licenses agreed on the command line are recorded", func(t *testing.T) {
+		localTestingDir := "test-licenses-agreed-on-the-command-line-are-recorded"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.AddPack(packWithLicense, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		decisions, err := installer.ReadEulaDecisions()
+		assert.Nil(err)
+		assert.Len(decisions, 1)
+		assert.Equal(installer.EulaAgreedByFlag, decisions[0].Source)
+		assert.NotEmpty(decisions[0].User)
+	})
+}
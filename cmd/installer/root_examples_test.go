@@ -0,0 +1,113 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// addExamplesPack installs a pack providing a "Blinky" example in packRoot
+func addExamplesPack(t *testing.T, packRoot, name string) {
+	assert := assert.New(t)
+
+	pdsc := `<package><vendor>TheVendor</vendor><name>` + name + `</name>
+  <releases><release version="1.0.0"/></releases>
+  <examples>
+    <example name="Blinky" folder="Examples/Blinky" doc="README.md">
+      <description>Blinks the LEDs</description>
+      <board vendor="BoardVendor" name="Eval Board"/>
+    </example>
+    <example name="Escape" folder="../../Escape"/>
+  </examples>
+</package>`
+
+	packDir := filepath.Join(packRoot, "TheVendor", name, "1.0.0")
+	assert.Nil(os.MkdirAll(filepath.Join(packDir, "Examples", "Blinky", "src"), 0700))
+	assert.Nil(os.WriteFile(filepath.Join(packDir, "TheVendor."+name+".pdsc"), []byte(pdsc), 0600))
+	assert.Nil(os.WriteFile(filepath.Join(packDir, "Examples", "Blinky", "README.md"), []byte("Blinky"), 0400))
+	assert.Nil(os.WriteFile(filepath.Join(packDir, "Examples", "Blinky", "src", "main.c"), []byte("int main() {}"), 0400))
+}
+
+func TestExamples(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test listing examples", func(t *testing.T) {
+		localTestingDir := "test-listing-examples"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		addExamplesPack(t, localTestingDir, "ExamplePack")
+
+		// Examples outside of the pack are left out
+		examples, err := installer.FindExamples("")
+		assert.Nil(err)
+		assert.Equal(1, len(examples))
+		assert.Equal("Blinky", examples[0].Name)
+		assert.Equal("Blinks the LEDs", examples[0].Description)
+		assert.Equal("Eval Board", examples[0].Boards[0].Name)
+		assert.Equal("TheVendor::ExamplePack@1.0.0", examples[0].Pack)
+
+		examples, err = installer.FindExamples("TheVendor.ExamplePack")
+		assert.Nil(err)
+		assert.Equal(1, len(examples))
+
+		_, err = installer.FindExamples("TheVendor.NotInstalled")
+		assert.Equal(errs.ErrPackNotInstalled, err)
+	})
+
+	t.Run("test copying an example", func(t *testing.T) {
+		localTestingDir := "test-copying-an-example"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		addExamplesPack(t, localTestingDir, "ExamplePack")
+
+		workspace := localTestingDir + "-workspace"
+		defer os.RemoveAll(workspace)
+
+		assert.Nil(installer.CopyExample("", "blinky", workspace))
+		assert.True(utils.FileExists(filepath.Join(workspace, "README.md")))
+		assert.True(utils.FileExists(filepath.Join(workspace, "src", "main.c")))
+
+		// Copied files can be edited
+		assert.Nil(os.WriteFile(filepath.Join(workspace, "src", "main.c"), []byte("int main() { return 0; }"), 0600))
+
+		// The workspace must be empty
+		err := installer.CopyExample("", "Blinky", workspace)
+		assert.True(errors.Is(err, errs.ErrPathAlreadyExists))
+
+		err = installer.CopyExample("", "Missing", workspace+"-missing")
+		assert.Equal(errs.ErrExampleNotFound, err)
+	})
+
+	t.Run("test copying an example provided by several packs", func(t *testing.T) {
+		localTestingDir := "test-copying-an-example-provided-by-several-packs"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		addExamplesPack(t, localTestingDir, "ExamplePack")
+		addExamplesPack(t, localTestingDir, "OtherExamplePack")
+
+		workspace := localTestingDir + "-workspace"
+		defer os.RemoveAll(workspace)
+
+		err := installer.CopyExample("", "Blinky", workspace)
+		assert.Equal(errs.ErrAmbiguousExample, err)
+
+		assert.Nil(installer.CopyExample("TheVendor::OtherExamplePack", "Blinky", workspace))
+		assert.True(utils.FileExists(filepath.Join(workspace, "README.md")))
+	})
+}
@@ -0,0 +1,50 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractPack(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test extracting a pack within a subfolder", func(t *testing.T) {
+		outputDir := "test-extracting-a-pack-within-a-subfolder"
+		defer os.RemoveAll(outputDir)
+
+		assert.Nil(installer.ExtractPack(packWithSubFolder, outputDir, !CheckEula))
+		assert.True(utils.FileExists(filepath.Join(outputDir, "TheVendor.PackWithSubFolder.pdsc")))
+	})
+
+	t.Run("test extracting a pack to the default directory", func(t *testing.T) {
+		outputDir := "TheVendor.PublicLocalPack.1.2.3"
+		defer os.RemoveAll(outputDir)
+
+		assert.Nil(installer.ExtractPack(publicLocalPack123, "", !CheckEula))
+		assert.True(utils.FileExists(filepath.Join(outputDir, "TheVendor.PublicLocalPack.pdsc")))
+	})
+
+	t.Run("test extracting a corrupt pack", func(t *testing.T) {
+		packContents, err := os.ReadFile(nonPublicLocalPack123)
+		assert.Nil(err)
+		packPath := filepath.Join(os.TempDir(), filepath.Base(nonPublicLocalPack123))
+		assert.Nil(os.WriteFile(packPath, corruptPackEntry(t, packContents), 0600))
+		defer os.Remove(packPath)
+
+		outputDir := "test-extracting-a-corrupt-pack"
+		defer os.RemoveAll(outputDir)
+
+		err = installer.ExtractPack(packPath, outputDir, !CheckEula)
+		assert.Equal(errs.ErrFailedDecompressingFile, err)
+	})
+}
@@ -0,0 +1,56 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrepPdscs(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test searching pdsc files", func(t *testing.T) {
+		localTestingDir := "test-searching-pdsc-files"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := filepath.Join(localTestingDir, "TheVendor", "DeviceFamilyPack", "1.0.0")
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		assert.Nil(os.WriteFile(filepath.Join(packDir, "TheVendor.DeviceFamilyPack.pdsc"), devicesPdsc, 0600))
+		assert.Nil(os.WriteFile(filepath.Join(installer.Installation.WebDir, "TheVendor.BoardSupportPack.pdsc"), boardsPdsc, 0600))
+
+		matches, err := installer.GrepPdscs(regexp.MustCompile(`^CHIP200`), false)
+		assert.Nil(err)
+		assert.Equal(3, len(matches))
+		assert.Equal("TheVendor::DeviceFamilyPack@1.0.0", matches[0].Pack)
+		assert.Equal("package/devices/family/subFamily/device", matches[0].Element)
+		assert.Equal(`Dname="CHIP200"`, matches[0].Match)
+		assert.Equal(13, matches[0].Line)
+		assert.Equal("package/devices/family/subFamily/device/variant", matches[1].Element)
+
+		// Texts match too
+		matches, err = installer.GrepPdscs(regexp.MustCompile(`DeviceFamily`), false)
+		assert.Nil(err)
+		assert.Equal(1, len(matches))
+		assert.Equal("package/name", matches[0].Element)
+		assert.Equal(4, matches[0].Line)
+
+		// Cached files are only searched on demand
+		matches, err = installer.GrepPdscs(regexp.MustCompile(`^CHIP200`), true)
+		assert.Nil(err)
+		assert.Equal(5, len(matches))
+		assert.Equal("TheVendor::BoardSupportPack@1.0.0", matches[3].Pack)
+		assert.False(matches[3].Installed)
+		assert.Equal("package/boards/board/mountedDevice", matches[3].Element)
+		assert.Equal(`Dvariant="CHIP200A"`, matches[4].Match)
+	})
+}
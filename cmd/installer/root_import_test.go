@@ -0,0 +1,64 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportMDKPacks(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test importing from a folder that is not an MDK pack folder", func(t *testing.T) {
+		localTestingDir := "test-importing-from-a-folder-that-is-not-an-mdk-pack-folder"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		defer removePackRoot(localTestingDir)
+
+		mdkPackDir := localTestingDir + "-mdk"
+		assert.Nil(os.MkdirAll(mdkPackDir, 0755))
+		defer os.RemoveAll(mdkPackDir)
+
+		err := installer.ImportMDKPacks(mdkPackDir)
+		assert.True(errs.Is(err, errs.ErrNotMDKPackFolder))
+	})
+
+	t.Run("test importing packs from an MDK pack folder", func(t *testing.T) {
+		localTestingDir := "test-importing-packs-from-an-mdk-pack-folder"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		mdkPackDir := localTestingDir + "-mdk"
+		mdkVersionDir := filepath.Join(mdkPackDir, "TheVendor", "PackName", "1.2.3")
+		mdkDownloadDir := filepath.Join(mdkPackDir, ".Download")
+		assert.Nil(os.MkdirAll(mdkVersionDir, 0755))
+		assert.Nil(os.MkdirAll(mdkDownloadDir, 0755))
+		defer os.RemoveAll(mdkPackDir)
+
+		assert.Nil(utils.CopyFile(pdscPack123, filepath.Join(mdkVersionDir, "TheVendor.PackName.pdsc")))
+		assert.Nil(utils.CopyFile(publicLocalPack123, filepath.Join(mdkDownloadDir, "TheVendor.PublicLocalPack.1.2.3.pack")))
+
+		assert.Nil(installer.ImportMDKPacks(mdkPackDir))
+
+		packHomeDir := filepath.Join(installer.Installation.PackRoot, "TheVendor", "PackName", "1.2.3")
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "TheVendor.PackName.pdsc")))
+		assert.True(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, "TheVendor.PackName.1.2.3.pdsc")))
+		assert.True(utils.FileExists(filepath.Join(installer.Installation.LocalDir, "TheVendor.PackName.pdsc")))
+		assert.True(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, "TheVendor.PublicLocalPack.1.2.3.pack")))
+
+		// The MDK pack folder is left untouched
+		assert.True(utils.FileExists(filepath.Join(mdkVersionDir, "TheVendor.PackName.pdsc")))
+
+		// Importing again skips what is already there
+		assert.Nil(installer.ImportMDKPacks(mdkPackDir))
+	})
+}
@@ -0,0 +1,62 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexAge(t *testing.T) {
+
+	assert := assert.New(t)
+
+	localTestingDir := "test-index-age"
+	assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+	installer.UnlockPackRoot()
+	defer removePackRoot(localTestingDir)
+
+	indexContent, err := os.ReadFile(samplePublicIndex)
+	assert.Nil(err)
+	indexServer := NewServer()
+	indexServer.AddRoute("index.pidx", indexContent)
+
+	refreshedPath := filepath.Join(installer.Installation.LocalDir, installer.IndexRefreshedName)
+	defer installer.SetStaleIndexAge(installer.GetStaleIndexAge())
+	installer.SetStaleIndexAge(30 * 24 * time.Hour)
+
+	t.Run("test recording when the index was refreshed", func(t *testing.T) {
+		assert.Nil(installer.UpdatePublicIndex(indexServer.URL()+"index.pidx", true, true, false, false, 0, Timeout))
+		assert.FileExists(refreshedPath)
+
+		refreshed, err := installer.IndexRefreshed()
+		assert.Nil(err)
+		assert.WithinDuration(time.Now(), refreshed, time.Minute)
+		assert.False(installer.WarnIfIndexStale())
+	})
+
+	t.Run("test warning about a stale index", func(t *testing.T) {
+		aMonthAgo := time.Now().Add(-31 * 24 * time.Hour).UTC().Format(time.RFC3339)
+		assert.Nil(os.WriteFile(refreshedPath, []byte(aMonthAgo+"\n"), 0600))
+		assert.True(installer.WarnIfIndexStale())
+
+		installer.SetStaleIndexAge(0)
+		assert.False(installer.WarnIfIndexStale())
+		installer.SetStaleIndexAge(30 * 24 * time.Hour)
+	})
+
+	t.Run("test pack roots that never recorded when the index was refreshed", func(t *testing.T) {
+		assert.Nil(os.Remove(refreshedPath))
+		assert.False(installer.WarnIfIndexStale())
+
+		aMonthAgo := time.Now().Add(-31 * 24 * time.Hour)
+		assert.Nil(os.Chtimes(installer.Installation.PublicIndex, aMonthAgo, aMonthAgo))
+		assert.True(installer.WarnIfIndexStale())
+	})
+}
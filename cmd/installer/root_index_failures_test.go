@@ -0,0 +1,115 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryFailedPdscs(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test retrying only the pdsc files an update could not download", func(t *testing.T) {
+		localTestingDir := "test-retrying-only-the-pdsc-files-an-update-could-not-download"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		pdscName := filepath.Base(publicLocalPack124Pdsc)
+		var pdscAvailable atomic.Bool
+		var indexRequests atomic.Int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch filepath.Base(r.URL.Path) {
+			case "index.pidx":
+				indexRequests.Add(1)
+				http.ServeFile(w, r, filepath.Join(localTestingDir, "index.pidx"))
+			case pdscName:
+				if !pdscAvailable.Load() {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				http.ServeFile(w, r, publicLocalPack124Pdsc)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+
+		// 1.2.3 is in .Web/, the index served has 1.2.4
+		pack124Info, err := utils.ExtractPackInfo(publicLocalPack124)
+		assert.Nil(err)
+		pdscFile := filepath.Join(installer.Installation.WebDir, pdscName)
+		assert.Nil(utils.CopyFile(publicLocalPack123Pdsc, pdscFile))
+
+		indexFile := filepath.Join(localTestingDir, "index.pidx")
+		assert.Nil(utils.CopyFile(samplePublicIndex, indexFile))
+		indexXML := xml.NewPidxXML(indexFile)
+		assert.Nil(indexXML.Read())
+		assert.Nil(indexXML.AddPdsc(xml.PdscTag{
+			URL:     server.URL + "/",
+			Vendor:  pack124Info.Vendor,
+			Name:    pack124Info.Pack,
+			Version: pack124Info.Version,
+		}))
+		assert.Nil(indexXML.Write())
+		installer.Installation.PublicIndexXML.URL = server.URL
+
+		// The pdsc file cannot be downloaded, so it is recorded
+		assert.Nil(installer.UpdatePublicIndex("", true, false, false, false, 0, Timeout))
+		failed, err := installer.ReadFailedPdscs()
+		assert.Nil(err)
+		assert.Equal([]installer.FailedPdsc{{
+			Vendor:  pack124Info.Vendor,
+			Name:    pack124Info.Pack,
+			Version: pack124Info.Version,
+			URL:     server.URL + "/",
+		}}, failed)
+
+		// Retrying downloads it without getting the index again
+		pdscAvailable.Store(true)
+		assert.Nil(installer.RetryFailedPdscs(0, Timeout))
+		assert.Equal(int32(1), indexRequests.Load())
+
+		pdscXML := xml.NewPdscXML(pdscFile)
+		assert.Nil(pdscXML.Read())
+		assert.Equal(pack124Info.Version, pdscXML.LatestVersion())
+
+		_, err = os.Stat(filepath.Join(installer.Installation.LocalDir, installer.FailedPdscsName))
+		assert.True(os.IsNotExist(err))
+
+		// Nothing is left to retry
+		assert.Nil(installer.RetryFailedPdscs(0, Timeout))
+	})
+
+	t.Run("test keeping the pdsc files failing again", func(t *testing.T) {
+		localTestingDir := "test-keeping-the-pdsc-files-failing-again"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		defer server.Close()
+
+		failed := []byte(`[{"vendor": "TheVendor", "name": "PublicLocalPack", "url": "` + server.URL + `/"}]`)
+		failedPath := filepath.Join(installer.Installation.LocalDir, installer.FailedPdscsName)
+		assert.Nil(os.WriteFile(failedPath, failed, 0600))
+
+		assert.Nil(installer.RetryFailedPdscs(0, Timeout))
+		stillFailed, err := installer.ReadFailedPdscs()
+		assert.Nil(err)
+		assert.Len(stillFailed, 1)
+		assert.Equal("PublicLocalPack", stillFailed[0].Name)
+	})
+}
@@ -0,0 +1,83 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexPin(t *testing.T) {
+
+	assert := assert.New(t)
+
+	indexContent, err := os.ReadFile(samplePublicIndex)
+	assert.Nil(err)
+	otherIndexContent := append([]byte("<!-- updated -->\n"), indexContent...)
+	pin := fmt.Sprintf("sha256:%x", sha256.Sum256(indexContent))
+
+	indexServer := NewServer()
+	indexServer.AddRoute("index.pidx", indexContent)
+	indexServer.AddRoute("other.pidx", otherIndexContent)
+	indexURL := indexServer.URL() + "index.pidx"
+	otherIndexURL := indexServer.URL() + "other.pidx"
+
+	t.Run("test pinning an index not matching the pin", func(t *testing.T) {
+		localTestingDir := "test-pinning-an-index-not-matching-the-pin"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		err := installer.PinPublicIndex(otherIndexURL, pin, false, Timeout)
+		assert.Equal(errs.ErrIndexPinMismatch, err)
+
+		indexPin, err := installer.ReadIndexPin()
+		assert.Nil(err)
+		assert.Nil(indexPin)
+	})
+
+	t.Run("test updating a pinned index", func(t *testing.T) {
+		localTestingDir := "test-updating-a-pinned-index"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.PinPublicIndex(indexURL, pin, false, Timeout))
+		indexPin, err := installer.ReadIndexPin()
+		assert.Nil(err)
+		assert.Equal(&installer.IndexPin{URL: indexURL, SHA256: pin[len("sha256:"):]}, indexPin)
+
+		// Other indexes are only warned about
+		assert.Nil(installer.UpdatePublicIndex(otherIndexURL, true, true, false, false, 0, Timeout))
+		assert.Nil(installer.UpdatePublicIndex(indexURL, true, true, false, false, 0, Timeout))
+
+		// Unless the pin is strict
+		assert.Nil(installer.PinPublicIndex(indexURL, pin, true, Timeout))
+		err = installer.UpdatePublicIndex(otherIndexURL, true, true, false, false, 0, Timeout)
+		assert.Equal(errs.ErrIndexPinMismatch, err)
+
+		// Replacing the index without a pin removes it
+		assert.Nil(installer.PinPublicIndex(otherIndexURL, "", false, Timeout))
+		indexPin, err = installer.ReadIndexPin()
+		assert.Nil(err)
+		assert.Nil(indexPin)
+	})
+
+	t.Run("test parsing index pins", func(t *testing.T) {
+		digest, err := installer.ParseIndexPin("SHA256:" + fmt.Sprintf("%X", sha256.Sum256(indexContent)))
+		assert.Nil(err)
+		assert.Equal(pin[len("sha256:"):], digest)
+
+		for _, invalidPin := range []string{"", "sha256", "md5:" + digest, "sha256:" + digest[1:], "sha256:" + digest[1:] + "x"} {
+			_, err = installer.ParseIndexPin(invalidPin)
+			assert.Equal(errs.ErrInvalidIndexPin, err, invalidPin)
+		}
+	})
+}
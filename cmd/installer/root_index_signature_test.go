@@ -0,0 +1,109 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"testing"
+
+	gopgp "github.com/ProtonMail/gopenpgp/v2/crypto"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+// signIndex returns an armored public key and the detached signature of index
+func signIndex(t *testing.T, index []byte) (string, []byte) {
+	assert := assert.New(t)
+
+	key, err := gopgp.GenerateKey("Index Signer", "signer@example.com", "x25519", 0)
+	assert.Nil(err)
+	keyRing, err := gopgp.NewKeyRing(key)
+	assert.Nil(err)
+	signature, err := keyRing.SignDetached(gopgp.NewPlainMessage(index))
+	assert.Nil(err)
+	armoredSignature, err := signature.GetArmored()
+	assert.Nil(err)
+	publicKey, err := key.GetArmoredPublicKey()
+	assert.Nil(err)
+
+	return publicKey, []byte(armoredSignature)
+}
+
+func TestIndexSignature(t *testing.T) {
+
+	assert := assert.New(t)
+
+	indexContent, err := os.ReadFile(samplePublicIndex)
+	assert.Nil(err)
+	publicKey, signature := signIndex(t, indexContent)
+
+	keyPath := "test-index-signature.pub"
+	assert.Nil(os.WriteFile(keyPath, []byte(publicKey), 0600))
+	defer os.Remove(keyPath)
+
+	defer installer.SetIndexVerification("", false)
+
+	t.Run("test verifying a signed remote index", func(t *testing.T) {
+		localTestingDir := "test-verifying-a-signed-remote-index"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		indexServer := NewServer()
+		indexServer.AddRoute("index.pidx", indexContent)
+		indexServer.AddRoute("index.pidx.sig", signature)
+
+		installer.SetIndexVerification(keyPath, true)
+		assert.Nil(installer.UpdatePublicIndex(indexServer.URL()+"index.pidx", true, true, false, false, 0, 0))
+	})
+
+	t.Run("test refusing a tampered index", func(t *testing.T) {
+		localTestingDir := "test-refusing-a-tampered-index"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		indexServer := NewServer()
+		indexServer.AddRoute("index.pidx", append([]byte("<!-- tampered -->\n"), indexContent...))
+		indexServer.AddRoute("index.pidx.sig", signature)
+
+		installer.SetIndexVerification(keyPath, false)
+		err := installer.UpdatePublicIndex(indexServer.URL()+"index.pidx", true, true, false, false, 0, 0)
+		assert.Equal(errs.ErrBadIndexSignature, err)
+	})
+
+	t.Run("test unsigned indexes are only refused in strict mode", func(t *testing.T) {
+		localTestingDir := "test-unsigned-indexes-are-only-refused-in-strict-mode"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		indexServer := NewServer()
+		indexServer.AddRoute("index.pidx", indexContent)
+
+		installer.SetIndexVerification(keyPath, false)
+		assert.Nil(installer.UpdatePublicIndex(indexServer.URL()+"index.pidx", true, true, false, false, 0, 0))
+
+		installer.SetIndexVerification(keyPath, true)
+		err := installer.UpdatePublicIndex(indexServer.URL()+"index.pidx", true, true, false, false, 0, 0)
+		assert.Equal(errs.ErrUnsignedIndex, err)
+	})
+
+	t.Run("test verifying a signed local index", func(t *testing.T) {
+		localTestingDir := "test-verifying-a-signed-local-index"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		indexPath := localTestingDir + "-index.pidx"
+		assert.Nil(os.WriteFile(indexPath, indexContent, 0600))
+		defer os.Remove(indexPath)
+		assert.Nil(os.WriteFile(indexPath+".sig", signature, 0600))
+		defer os.Remove(indexPath + ".sig")
+
+		installer.SetIndexVerification(keyPath, true)
+		assert.Nil(installer.UpdatePublicIndex(indexPath, true, true, false, false, 0, 0))
+	})
+}
@@ -0,0 +1,133 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeIndex writes an index listing pdscTags to indexPath
+func writeIndex(t *testing.T, indexPath string, pdscTags ...xml.PdscTag) {
+	assert := assert.New(t)
+
+	pidxXML := xml.NewPidxXML(indexPath)
+	assert.Nil(pidxXML.Read())
+	for _, pdscTag := range pdscTags {
+		assert.Nil(pidxXML.AddPdsc(pdscTag))
+	}
+	assert.Nil(pidxXML.Write())
+}
+
+// publicIndexPacks returns the packs of .Web/index.pidx as Vendor.Name
+func publicIndexPacks(t *testing.T) []string {
+	pidxXML := xml.NewPidxXML(installer.Installation.PublicIndex)
+	assert.Nil(t, pidxXML.Read())
+	packs := []string{}
+	for _, pdscTag := range pidxXML.ListPdscTags() {
+		packs = append(packs, pdscTag.Vendor+"."+pdscTag.Name)
+	}
+	return packs
+}
+
+func TestIndexSources(t *testing.T) {
+
+	assert := assert.New(t)
+
+	publicPack := xml.PdscTag{Vendor: "TheVendor", Name: "PublicLocalPack", Version: "1.2.3", URL: "https://vendor.com/"}
+	otherPack := xml.PdscTag{Vendor: "OtherVendor", Name: "OtherPack", Version: "1.0.0", URL: "https://other.com/"}
+
+	// setUp creates a pack root whose public index lists publicPack, and an
+	// index source listing publicPack too and otherPack
+	setUp := func(t *testing.T, localTestingDir string) string {
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+
+		primaryIndex := filepath.Join(localTestingDir, "primary.pidx")
+		writeIndex(t, primaryIndex, publicPack)
+		assert.Nil(installer.UpdatePublicIndex(primaryIndex, true, true, false, false, 0, Timeout))
+
+		sourceIndex := filepath.Join(localTestingDir, "source.pidx")
+		writeIndex(t, sourceIndex, publicPack, otherPack)
+		return sourceIndex
+	}
+
+	t.Run("test subscribing to an index", func(t *testing.T) {
+		localTestingDir := "test-subscribing-to-an-index"
+		defer removePackRoot(localTestingDir)
+		sourceIndex := setUp(t, localTestingDir)
+
+		assert.Nil(installer.AddIndexSource("other", sourceIndex, Timeout))
+		assert.ElementsMatch([]string{"TheVendor.PublicLocalPack", "OtherVendor.OtherPack"}, publicIndexPacks(t))
+
+		sources, err := installer.ReadIndexSources()
+		assert.Nil(err)
+		assert.Equal([]installer.IndexSource{{Name: "other", URL: sourceIndex}}, sources)
+		origins, err := installer.ReadIndexOrigins()
+		assert.Nil(err)
+		assert.Equal(map[string]string{"OtherVendor.OtherPack": "other"}, origins)
+
+		assert.Equal(errs.ErrIndexSourceExists, installer.AddIndexSource("Other", sourceIndex, Timeout))
+		assert.Equal(errs.ErrIncorrectCmdArgs, installer.AddIndexSource(installer.DefaultIndexSource, sourceIndex, Timeout))
+	})
+
+	t.Run("test refusing index sources that cannot be retrieved", func(t *testing.T) {
+		localTestingDir := "test-refusing-index-sources-that-cannot-be-retrieved"
+		defer removePackRoot(localTestingDir)
+		setUp(t, localTestingDir)
+
+		assert.True(errs.Is(installer.AddIndexSource("missing", filepath.Join(localTestingDir, "missing.pidx"), Timeout), errs.ErrFileNotFound))
+		sources, err := installer.ReadIndexSources()
+		assert.Nil(err)
+		assert.Empty(sources)
+	})
+
+	t.Run("test disabling, enabling and removing an index source", func(t *testing.T) {
+		localTestingDir := "test-disabling-enabling-and-removing-an-index-source"
+		defer removePackRoot(localTestingDir)
+		sourceIndex := setUp(t, localTestingDir)
+		assert.Nil(installer.AddIndexSource("other", sourceIndex, Timeout))
+
+		assert.Nil(installer.EnableIndexSource("other", false, Timeout))
+		assert.Equal([]string{"TheVendor.PublicLocalPack"}, publicIndexPacks(t))
+		sources, err := installer.ReadIndexSources()
+		assert.Nil(err)
+		assert.True(sources[0].Disabled)
+
+		assert.Nil(installer.EnableIndexSource("other", true, Timeout))
+		assert.ElementsMatch([]string{"TheVendor.PublicLocalPack", "OtherVendor.OtherPack"}, publicIndexPacks(t))
+
+		assert.Nil(installer.RemoveIndexSource("other"))
+		assert.Equal([]string{"TheVendor.PublicLocalPack"}, publicIndexPacks(t))
+		sources, err = installer.ReadIndexSources()
+		assert.Nil(err)
+		assert.Empty(sources)
+		origins, err := installer.ReadIndexOrigins()
+		assert.Nil(err)
+		assert.Empty(origins)
+
+		assert.Equal(errs.ErrIndexSourceNotFound, installer.RemoveIndexSource("other"))
+	})
+
+	t.Run("test merging index sources when updating the public index", func(t *testing.T) {
+		localTestingDir := "test-merging-index-sources-when-updating-the-public-index"
+		defer removePackRoot(localTestingDir)
+		sourceIndex := setUp(t, localTestingDir)
+		assert.Nil(installer.AddIndexSource("other", sourceIndex, Timeout))
+
+		primaryIndex := filepath.Join(localTestingDir, "primary.pidx")
+		assert.Nil(installer.UpdatePublicIndex(primaryIndex, true, true, false, false, 0, Timeout))
+		assert.ElementsMatch([]string{"TheVendor.PublicLocalPack", "OtherVendor.OtherPack"}, publicIndexPacks(t))
+
+		// Disabled sources are not merged
+		assert.Nil(installer.EnableIndexSource("other", false, Timeout))
+		assert.Nil(installer.UpdatePublicIndex(primaryIndex, true, true, false, false, 0, Timeout))
+		assert.Equal([]string{"TheVendor.PublicLocalPack"}, publicIndexPacks(t))
+	})
+}
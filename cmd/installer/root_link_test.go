@@ -0,0 +1,132 @@
+//go:build !windows
+// +build !windows
+
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkPack(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test linking and removing a pack", func(t *testing.T) {
+		localTestingDir := "test-linking-and-removing-a-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		sourceDir, err := filepath.Abs("test-linking-and-removing-a-pack-source")
+		assert.Nil(err)
+		assert.Nil(os.MkdirAll(sourceDir, 0700))
+		defer os.RemoveAll(sourceDir)
+		assert.Nil(os.WriteFile(filepath.Join(sourceDir, "TheVendor.DeviceFamilyPack.pdsc"), devicesPdsc, 0600))
+
+		assert.Nil(installer.LinkPack(sourceDir, false))
+
+		packDir := filepath.Join(localTestingDir, "TheVendor", "DeviceFamilyPack", "1.0.0")
+		assert.True(utils.IsLink(packDir))
+		target, err := os.Readlink(packDir)
+		assert.Nil(err)
+		assert.Equal(sourceDir, target)
+		assert.True(utils.FileExists(filepath.Join(installer.Installation.LocalDir, "TheVendor.DeviceFamilyPack.pdsc")))
+
+		// Linked packs are installed, changes show up right away
+		devices, err := installer.FindDevices(false, "", "CHIP100")
+		assert.Nil(err)
+		assert.Equal(1, len(devices))
+		assert.True(devices[0].Installed)
+
+		// Locking the pack root leaves the pack's source writable
+		installer.LockPackRoot()
+		installer.UnlockPackRoot()
+		assert.Nil(os.WriteFile(filepath.Join(sourceDir, "README.md"), []byte("notes"), 0600))
+
+		// Linking again needs forcing
+		err = installer.LinkPack(filepath.Join(sourceDir, "TheVendor.DeviceFamilyPack.pdsc"), false)
+		assert.True(errs.Is(err, errs.ErrPathAlreadyExists))
+		assert.Nil(installer.LinkPack(filepath.Join(sourceDir, "TheVendor.DeviceFamilyPack.pdsc"), true))
+
+		// Removing the pack only removes the link
+		assert.Nil(installer.RemovePack("TheVendor::DeviceFamilyPack@1.0.0", false, 0))
+		assert.False(utils.IsLink(packDir))
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, "TheVendor")))
+		assert.True(utils.FileExists(filepath.Join(sourceDir, "TheVendor.DeviceFamilyPack.pdsc")))
+		assert.True(utils.FileExists(filepath.Join(sourceDir, "README.md")))
+	})
+
+	t.Run("test linking a folder without a pdsc file", func(t *testing.T) {
+		localTestingDir := "test-linking-a-folder-without-a-pdsc-file"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		sourceDir := localTestingDir + "-source"
+		assert.Nil(os.MkdirAll(sourceDir, 0700))
+		defer os.RemoveAll(sourceDir)
+
+		err := installer.LinkPack(sourceDir, false)
+		assert.True(errs.Is(err, errs.ErrPdscFileNotFound))
+
+		// The PDSC file must be called after the pack
+		pdscPath := filepath.Join(sourceDir, "Other.pdsc")
+		assert.Nil(os.WriteFile(pdscPath, devicesPdsc, 0600))
+		err = installer.LinkPack(pdscPath, false)
+		assert.True(errs.Is(err, errs.ErrPdscFileNotFound))
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, "TheVendor")))
+	})
+	t.Run("test moving, exporting and importing a pack root with a linked pack", func(t *testing.T) {
+		localTestingDir := "test-moving-a-pack-root-with-a-linked-pack"
+		movedPackRoot := localTestingDir + "-moved"
+		importedPackRoot := localTestingDir + "-imported"
+		archive := localTestingDir + ".tar"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		defer removePackRoot(movedPackRoot)
+		defer removePackRoot(importedPackRoot)
+		defer os.Remove(archive)
+
+		sourceDir, err := filepath.Abs(localTestingDir + "-source")
+		assert.Nil(err)
+		assert.Nil(os.MkdirAll(sourceDir, 0700))
+		defer os.RemoveAll(sourceDir)
+		assert.Nil(os.WriteFile(filepath.Join(sourceDir, "TheVendor.DeviceFamilyPack.pdsc"), devicesPdsc, 0600))
+		assert.Nil(installer.LinkPack(sourceDir, false))
+		installer.LockPackRoot()
+
+		// Copying the pack root, as across file systems, keeps the link
+		absLocalTestingDir, _ := filepath.Abs(localTestingDir)
+		assert.Nil(os.MkdirAll(movedPackRoot, 0755))
+		assert.Nil(os.WriteFile(filepath.Join(movedPackRoot, ".cpackget-move"), []byte(absLocalTestingDir), 0600))
+		assert.Nil(installer.MovePackRoot(localTestingDir, movedPackRoot))
+
+		packDir := filepath.Join(movedPackRoot, "TheVendor", "DeviceFamilyPack", "1.0.0")
+		assert.True(utils.IsLink(packDir))
+		target, err := os.Readlink(packDir)
+		assert.Nil(err)
+		assert.Equal(sourceDir, target)
+
+		// And so do exporting and importing it
+		assert.Nil(installer.ExportPackRoot(movedPackRoot, archive))
+		assert.Nil(installer.ImportPackRoot(archive, importedPackRoot))
+
+		packDir = filepath.Join(importedPackRoot, "TheVendor", "DeviceFamilyPack", "1.0.0")
+		assert.True(utils.IsLink(packDir))
+		target, err = os.Readlink(packDir)
+		assert.Nil(err)
+		assert.Equal(sourceDir, target)
+		assert.True(utils.FileExists(filepath.Join(sourceDir, "TheVendor.DeviceFamilyPack.pdsc")))
+	})
+}
@@ -0,0 +1,81 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneLocalPdscs(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// setUp adds pdscPack123 and a copy of it in a development tree, which
+	// is returned
+	setUp := func(t *testing.T, localTestingDir string) string {
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+
+		devTree := localTestingDir + "-dev-tree"
+		assert.Nil(os.MkdirAll(devTree, 0700))
+		pdscContent, err := os.ReadFile(pdscPack123)
+		assert.Nil(err)
+		devPdsc := filepath.Join(devTree, filepath.Base(pdscPack123))
+		assert.Nil(os.WriteFile(devPdsc, pdscContent, 0600))
+
+		assert.Nil(installer.AddPdsc(pdscPack123))
+		assert.Nil(installer.AddPdsc(devPdsc))
+		return devTree
+	}
+
+	t.Run("test finding no stale pdsc registrations", func(t *testing.T) {
+		localTestingDir := "test-finding-no-stale-pdsc-registrations"
+		defer removePackRoot(localTestingDir)
+		devTree := setUp(t, localTestingDir)
+		defer os.RemoveAll(devTree)
+
+		stale, err := installer.FindStaleLocalPdscs()
+		assert.Nil(err)
+		assert.Empty(stale)
+
+		checks := installer.CheckEnvironment(localTestingDir, Timeout)
+		localPacks := findCheck(checks, "local packs")
+		assert.Empty(localPacks.Problem)
+		assert.Equal("2 pack(s) added via PDSC file", localPacks.Detail)
+	})
+
+	t.Run("test pruning pdsc registrations of deleted development trees", func(t *testing.T) {
+		localTestingDir := "test-pruning-pdsc-registrations-of-deleted-development-trees"
+		defer removePackRoot(localTestingDir)
+		devTree := setUp(t, localTestingDir)
+		assert.Nil(os.RemoveAll(devTree))
+
+		checks := installer.CheckEnvironment(localTestingDir, Timeout)
+		localPacks := findCheck(checks, "local packs")
+		assert.Contains(localPacks.Problem, "1 PDSC file(s)")
+		assert.Contains(localPacks.Problem, devTree)
+		assert.Contains(localPacks.Hint, "cpackget rm --stale")
+
+		pruned, err := installer.PruneLocalPdscs()
+		assert.Nil(err)
+		assert.Len(pruned, 1)
+		devPdsc, err := filepath.Abs(filepath.Join(devTree, filepath.Base(pdscPack123)))
+		assert.Nil(err)
+		assert.Equal(devPdsc, pruned[0].PdscPath)
+
+		assert.Nil(installer.Installation.LocalPidx.Read())
+		pdscTags := installer.Installation.LocalPidx.ListPdscTags()
+		assert.Len(pdscTags, 1)
+		assert.NotContains(pdscTags[0].URL, devTree)
+
+		pruned, err = installer.PruneLocalPdscs()
+		assert.Nil(err)
+		assert.Empty(pruned)
+	})
+}
@@ -0,0 +1,95 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// addSlimPack adds TheVendor.SlimPack.1.2.3 slim to the pack root in localTestingDir
+func addSlimPack(t *testing.T, localTestingDir string) string {
+	assert := assert.New(t)
+
+	packDir := localTestingDir + "-packs"
+	assert.Nil(os.MkdirAll(packDir, 0700))
+	defer os.RemoveAll(packDir)
+
+	installer.SetSlim(true)
+	defer installer.SetSlim(false)
+	assert.Nil(installer.AddPack(writeSlimPack(t, packDir, "1.2.3"), !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+	return filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.3")
+}
+
+func TestMaterializePack(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test materializing the skipped files of a pack", func(t *testing.T) {
+		localTestingDir := "test-materializing-the-skipped-files-of-a-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		packHomeDir := addSlimPack(t, localTestingDir)
+
+		// Pruning the cache keeps the archive holding the skipped files
+		assert.Nil(installer.PruneCache(0, false))
+		assert.True(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, "TheVendor.SlimPack.1.2.3.pack")))
+
+		extracted, err := installer.MaterializePack("TheVendor::SlimPack@1.2.3", []string{"*.pdf"})
+		assert.Nil(err)
+		assert.Equal(1, extracted)
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Documents", "board.pdf")))
+		assert.False(utils.FileExists(filepath.Join(packHomeDir, "Examples", "Blinky", "main.c")))
+		skipped, err := os.ReadFile(filepath.Join(packHomeDir, cryptography.SkippedFilesName))
+		assert.Nil(err)
+		assert.Equal("Documents/startup.html\nExamples/Blinky/Abstract.txt\nExamples/Blinky/main.c\n", string(skipped))
+
+		extracted, err = installer.MaterializePack("TheVendor.SlimPack", nil)
+		assert.Nil(err)
+		assert.Equal(3, extracted)
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Examples", "Blinky", "main.c")))
+		assert.False(utils.FileExists(filepath.Join(packHomeDir, cryptography.SkippedFilesName)))
+
+		verified, err := cryptography.VerifyEmbeddedChecksum(packHomeDir)
+		assert.True(verified)
+		assert.Nil(err)
+
+		// Nothing is left to extract
+		extracted, err = installer.MaterializePack("TheVendor.SlimPack", nil)
+		assert.Nil(err)
+		assert.Equal(0, extracted)
+	})
+
+	t.Run("test materializing a pack without its archive", func(t *testing.T) {
+		localTestingDir := "test-materializing-a-pack-without-its-archive"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		addSlimPack(t, localTestingDir)
+
+		packPath := filepath.Join(installer.Installation.DownloadDir, "TheVendor.SlimPack.1.2.3.pack")
+		assert.Nil(os.Remove(packPath))
+
+		_, err := installer.MaterializePack("TheVendor.SlimPack", nil)
+		assert.Equal(errs.WithPath(errs.ErrFileNotFound, packPath), err)
+	})
+
+	t.Run("test materializing a pack not installed", func(t *testing.T) {
+		localTestingDir := "test-materializing-a-pack-not-installed"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		_, err := installer.MaterializePack("TheVendor.SlimPack", nil)
+		assert.Equal(errs.ErrPackNotInstalled, err)
+	})
+}
@@ -0,0 +1,74 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test collecting metrics of a local pack", func(t *testing.T) {
+		localTestingDir := "test-collecting-metrics-of-a-local-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		installer.SetCollectMetrics(true)
+		defer installer.SetCollectMetrics(false)
+
+		addPack(t, publicLocalPack123, ConfigType{})
+
+		metrics := installer.GetMetrics()
+		assert.Equal(1, len(metrics))
+		assert.Equal("TheVendor.PublicLocalPack.1.2.3", metrics[0].PackID)
+		assert.Equal(int64(0), metrics[0].DownloadBytes)
+		assert.Greater(metrics[0].ExtractionBytes, uint64(0))
+		assert.Greater(int64(metrics[0].VerificationTime), int64(0))
+		assert.Greater(int64(metrics[0].ExtractionTime), int64(0))
+	})
+
+	t.Run("test collecting metrics of a downloaded pack", func(t *testing.T) {
+		localTestingDir := "test-collecting-metrics-of-a-downloaded-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		installer.Installation.WebDir = filepath.Join(testDir, "public_index")
+		defer removePackRoot(localTestingDir)
+
+		installer.SetCollectMetrics(true)
+		defer installer.SetCollectMetrics(false)
+
+		zipContent, err := os.ReadFile(publicRemotePack123)
+		assert.Nil(err)
+		packServer := NewServer()
+		packServer.AddRoute("*", zipContent)
+
+		addPack(t, packServer.URL()+filepath.Base(publicRemotePack123), ConfigType{
+			IsPublic: true,
+		})
+
+		metrics := installer.GetMetrics()
+		assert.Equal(1, len(metrics))
+		assert.Equal(int64(len(zipContent)), metrics[0].DownloadBytes)
+		assert.Greater(int64(metrics[0].DownloadTime), int64(0))
+	})
+
+	t.Run("test not collecting metrics by default", func(t *testing.T) {
+		localTestingDir := "test-not-collecting-metrics-by-default"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		addPack(t, publicLocalPack123, ConfigType{})
+
+		assert.Equal(0, len(installer.GetMetrics()))
+	})
+}
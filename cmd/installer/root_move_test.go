@@ -0,0 +1,129 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/stretchr/testify/assert"
+)
+
+// setUpPackRootToMove creates a pack root with a pack installed
+// via a PDSC file that is stored inside the pack root
+func setUpPackRootToMove(t *testing.T, packRoot string) {
+	assert := assert.New(t)
+
+	assert.Nil(installer.SetPackRoot(packRoot, CreatePackRoot))
+	installer.UnlockPackRoot()
+
+	pdscDir := filepath.Join(packRoot, "local-packs")
+	assert.Nil(os.MkdirAll(pdscDir, 0755))
+	assert.Nil(utils.CopyFile(pdscPack123, filepath.Join(pdscDir, filepath.Base(pdscPack123))))
+	assert.Nil(installer.AddPdsc(filepath.Join(pdscDir, filepath.Base(pdscPack123))))
+
+	installer.LockPackRoot()
+}
+
+func TestMovePackRoot(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test moving a pack root into itself", func(t *testing.T) {
+		localTestingDir := "test-moving-a-pack-root-into-itself"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		defer removePackRoot(localTestingDir)
+
+		err := installer.MovePackRoot(localTestingDir, localTestingDir)
+		assert.Equal(errs.ErrMovingEqualPaths, err)
+
+		err = installer.MovePackRoot(localTestingDir, filepath.Join(localTestingDir, "sub"))
+		assert.Equal(errs.ErrMovingPackRootIntoItself, err)
+	})
+
+	t.Run("test moving a pack root to a non empty directory", func(t *testing.T) {
+		localTestingDir := "test-moving-a-pack-root-to-a-non-empty-directory"
+		newPackRoot := localTestingDir + "-new"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(os.MkdirAll(newPackRoot, 0755))
+		assert.Nil(os.WriteFile(filepath.Join(newPackRoot, "file.txt"), []byte("content"), 0600))
+		defer removePackRoot(newPackRoot)
+
+		err := installer.MovePackRoot(localTestingDir, newPackRoot)
+		assert.True(errs.Is(err, errs.ErrPackRootDestinationNotEmpty))
+		assert.True(utils.DirExists(localTestingDir))
+	})
+
+	t.Run("test moving a pack root", func(t *testing.T) {
+		localTestingDir := "test-moving-a-pack-root"
+		newPackRoot := localTestingDir + "-new"
+		setUpPackRootToMove(t, localTestingDir)
+		defer removePackRoot(localTestingDir)
+		defer removePackRoot(newPackRoot)
+
+		assert.Nil(os.MkdirAll(newPackRoot, 0755))
+		assert.Nil(installer.MovePackRoot(localTestingDir, newPackRoot))
+		assert.False(utils.DirExists(localTestingDir))
+		assert.False(utils.FileExists(filepath.Join(newPackRoot, ".cpackget-move")))
+
+		// The pack installed via PDSC file is now referenced from the new pack root
+		assert.Nil(installer.SetPackRoot(newPackRoot, !CreatePackRoot))
+		assert.Nil(installer.Installation.LocalPidx.Read())
+		tags := installer.Installation.LocalPidx.ListPdscTags()
+		assert.Len(tags, 1)
+		absNewPackRoot, _ := filepath.Abs(newPackRoot)
+		assert.True(strings.HasSuffix(tags[0].URL, filepath.ToSlash(filepath.Join(absNewPackRoot, "local-packs"))+"/"))
+
+		pdscXML := xml.NewPdscXML(filepath.Join(newPackRoot, "local-packs", filepath.Base(pdscPack123)))
+		assert.Nil(pdscXML.Read())
+	})
+
+	t.Run("test resuming an interrupted pack root move", func(t *testing.T) {
+		localTestingDir := "test-resuming-an-interrupted-pack-root-move"
+		newPackRoot := localTestingDir + "-new"
+		setUpPackRootToMove(t, localTestingDir)
+		defer removePackRoot(localTestingDir)
+		defer removePackRoot(newPackRoot)
+
+		// A pack file hard linked elsewhere in the pack root, like the content store does
+		pdscFile := filepath.Join(localTestingDir, "local-packs", filepath.Base(pdscPack123))
+		linkedFile := filepath.Join(localTestingDir, "local-packs", "linked.pdsc")
+		assert.Nil(os.Link(pdscFile, linkedFile))
+
+		// Pretend the pack root is on another file system, where it can't be renamed
+		absLocalTestingDir, _ := filepath.Abs(localTestingDir)
+		assert.Nil(os.MkdirAll(newPackRoot, 0755))
+		assert.Nil(os.WriteFile(filepath.Join(newPackRoot, ".cpackget-move"), []byte(absLocalTestingDir), 0600))
+
+		// Interrupt the move right after it started copying files
+		copied := 0
+		utils.ShouldAbortFunction = func() bool {
+			copied++
+			return copied > 3
+		}
+		err := installer.MovePackRoot(localTestingDir, newPackRoot)
+		utils.ShouldAbortFunction = nil
+		assert.Equal(errs.ErrTerminatedByUser, err)
+		assert.True(utils.DirExists(localTestingDir))
+		assert.True(utils.FileExists(filepath.Join(newPackRoot, ".cpackget-move")))
+
+		assert.Nil(installer.MovePackRoot(localTestingDir, newPackRoot))
+		assert.False(utils.DirExists(localTestingDir))
+		assert.False(utils.FileExists(filepath.Join(newPackRoot, ".cpackget-move")))
+		assert.True(utils.FileExists(filepath.Join(newPackRoot, ".Local", "local_repository.pidx")))
+		assert.True(utils.FileExists(filepath.Join(newPackRoot, ".Web", "index.pidx")))
+
+		links, err := utils.LinkCount(filepath.Join(newPackRoot, "local-packs", "linked.pdsc"))
+		assert.Nil(err)
+		assert.Equal(uint64(2), links)
+	})
+}
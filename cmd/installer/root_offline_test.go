@@ -0,0 +1,42 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddPackOffline(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test adding packs offline", func(t *testing.T) {
+		localTestingDir := "test-adding-packs-offline"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		utils.SetOffline(true)
+		defer utils.SetOffline(false)
+
+		packServer := NewServer()
+		packServer.AddRoute("*", []byte("not a pack"))
+		packPath := packServer.URL() + filepath.Base(publicRemotePack123)
+
+		// Packs not in .Download/ can't be added
+		err := installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.True(errs.Is(err, errs.ErrOffline))
+
+		// Packs in .Download/ are added from there
+		assert.Nil(utils.CopyFile(publicRemotePack123, filepath.Join(installer.Installation.DownloadDir, filepath.Base(publicRemotePack123))))
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.True(utils.DirExists(filepath.Join(localTestingDir, "TheVendor", "PublicRemotePack", "1.2.3")))
+	})
+}
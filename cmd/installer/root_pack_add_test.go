@@ -5,6 +5,7 @@ package installer_test
 
 import (
 	"errors"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	"github.com/open-cmsis-pack/cpackget/cmd/ui"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
 	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -53,6 +55,31 @@ func TestAddPack(t *testing.T) {
 		assert.False(utils.FileExists(installer.Installation.PackIdx))
 	})
 
+	t.Run("test installing a pack on an in-memory file system", func(t *testing.T) {
+		localTestingDir := "test-add-pack-in-memory"
+
+		packContents, err := os.ReadFile(publicLocalPack123)
+		assert.Nil(err)
+
+		memFs := afero.NewMemMapFs()
+		assert.Nil(afero.WriteFile(memFs, publicLocalPack123, packContents, 0644))
+		utils.SetFileSystem(memFs)
+		defer utils.SetFileSystem(nil)
+
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+
+		assert.Nil(installer.AddPack(publicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, NoRequirements, Timeout))
+
+		pdscPath := filepath.Join(localTestingDir, "TheVendor", "PublicLocalPack", "1.2.3", "TheVendor.PublicLocalPack.pdsc")
+		assert.True(utils.FileExists(pdscPath))
+		assert.True(utils.FileExists(installer.Installation.PackIdx))
+
+		// Nothing reached the disk
+		utils.SetFileSystem(nil)
+		assert.False(utils.DirExists(localTestingDir))
+	})
+
 	t.Run("test installing a pack previously installed", func(t *testing.T) {
 		localTestingDir := "test-add-pack-already-installed"
 		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
@@ -73,6 +100,11 @@ func TestAddPack(t *testing.T) {
 
 		// Make sure pack.idx did NOT get touched
 		assert.Equal(packIdxModTime, getPackIdxModTime(t, End))
+
+		// Idempotent additions tell that it was already installed
+		installer.SetIdempotent(true)
+		defer installer.SetIdempotent(false)
+		assert.Equal(errs.ErrPackAlreadyInstalled, installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
 	})
 
 	t.Run("test force-reinstalling a pack not yet installed", func(t *testing.T) {
@@ -229,6 +261,42 @@ func TestAddPack(t *testing.T) {
 		assert.False(utils.FileExists(installer.Installation.PackIdx))
 	})
 
+	t.Run("test installing a pack without enough disk space", func(t *testing.T) {
+		localTestingDir := "test-add-pack-without-enough-disk-space"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		defer func(margin uint64) { utils.DiskSpaceMargin = margin }(utils.DiskSpaceMargin)
+		utils.DiskSpaceMargin = math.MaxUint64
+
+		err := installer.AddPack(publicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.True(errs.Is(err, errs.ErrNotEnoughDiskSpace))
+
+		// Nothing got extracted
+		info, err := utils.ExtractPackInfo(publicLocalPack123)
+		assert.Nil(err)
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, info.Vendor, info.Pack)))
+		assert.False(utils.FileExists(installer.Installation.PackIdx))
+	})
+
+	t.Run("test installing a pack exceeding the pack root maximum size", func(t *testing.T) {
+		localTestingDir := "test-add-pack-exceeding-pack-root-maximum-size"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		defer installer.SetMaxPackRootSize(0)
+
+		installer.SetMaxPackRootSize(1)
+		err := installer.AddPack(publicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.Equal(errs.ErrPackRootQuotaExceeded, err)
+		assert.False(utils.FileExists(installer.Installation.PackIdx))
+
+		// A large enough maximum size does not get in the way
+		installer.SetMaxPackRootSize(1024 * 1024 * 1024)
+		assert.Nil(installer.AddPack(publicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+	})
+
 	t.Run("test installing a pack with bad URL format", func(t *testing.T) {
 		localTestingDir := "test-add-pack-with-malformed-url"
 		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
@@ -916,11 +984,11 @@ func TestAddPack(t *testing.T) {
 		installer.Installation.WebDir = filepath.Join(testDir, "public_index")
 		defer removePackRoot(localTestingDir)
 
-		// Fake a user termination request
-		skipAbortingOnPdscCopy := -1
+		// Fake a user termination request once the first file is extracted
+		skipAbortingOnFirstFile := -1
 		utils.ShouldAbortFunction = func() bool {
-			skipAbortingOnPdscCopy += 1
-			return skipAbortingOnPdscCopy > 1
+			skipAbortingOnFirstFile += 1
+			return skipAbortingOnFirstFile > 0
 		}
 
 		// Reset it at the end
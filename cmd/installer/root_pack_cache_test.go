@@ -0,0 +1,87 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddPackPreferCache(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test adding a pack from the cache", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-from-the-cache"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(utils.CopyFile(publicRemotePack123, filepath.Join(installer.Installation.DownloadDir, filepath.Base(publicRemotePack123))))
+
+		// Without --prefer-cache the URL of the pack has to be looked up
+		err := installer.AddPack(publicRemotePack123PackID, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.True(errs.Is(err, errs.ErrPackURLCannotBeFound))
+
+		installer.SetPreferCache(true)
+		defer installer.SetPreferCache(false)
+
+		assert.Nil(installer.AddPack(publicRemotePack123PackID, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.True(utils.DirExists(filepath.Join(localTestingDir, "TheVendor", "PublicRemotePack", "1.2.3")))
+	})
+
+	t.Run("test adding a pack from the cache not matching its published hash", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-from-the-cache-not-matching-its-published-hash"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		cachedPackPath := filepath.Join(installer.Installation.DownloadDir, filepath.Base(publicRemotePack123))
+		assert.Nil(utils.CopyFile(publicRemotePack123, cachedPackPath))
+
+		server := NewServer()
+		server.AddRoute("hashes/"+filepath.Base(publicRemotePack123)+".sha256", []byte(fmt.Sprintf("%x", sha256.Sum256(nil))))
+		installer.SetPackHashURLs([]installer.PackHashURL{{Vendor: "TheVendor", Pattern: server.URL() + "hashes/{file}.sha256"}})
+		defer installer.SetPackHashURLs(nil)
+
+		installer.SetPreferCache(true)
+		defer installer.SetPreferCache(false)
+
+		err := installer.AddPack(publicRemotePack123PackID, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.True(errs.Is(err, errs.ErrIntegrityCheckFailed))
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, "TheVendor", "PublicRemotePack", "1.2.3")))
+		assert.False(utils.FileExists(cachedPackPath))
+	})
+
+	t.Run("test forcing the download of a cached pack", func(t *testing.T) {
+		localTestingDir := "test-forcing-the-download-of-a-cached-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		// The server does not have the pack
+		packServer := NewServer()
+		packPath := packServer.URL() + filepath.Base(publicRemotePack123)
+		cachedPackPath := filepath.Join(installer.Installation.DownloadDir, filepath.Base(publicRemotePack123))
+		assert.Nil(utils.CopyFile(publicRemotePack123, cachedPackPath))
+
+		installer.SetForceDownload(true)
+		err := installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		installer.SetForceDownload(false)
+		assert.True(errs.Is(err, errs.ErrBadRequest))
+		assert.False(utils.FileExists(cachedPackPath))
+
+		// Otherwise the pack in .Download/ is used
+		assert.Nil(utils.CopyFile(publicRemotePack123, cachedPackPath))
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.True(utils.DirExists(filepath.Join(localTestingDir, "TheVendor", "PublicRemotePack", "1.2.3")))
+	})
+}
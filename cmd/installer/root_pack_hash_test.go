@@ -0,0 +1,130 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddPackWithPublishedHash(t *testing.T) {
+
+	assert := assert.New(t)
+
+	packContents, err := os.ReadFile(nonPublicLocalPack123)
+	assert.Nil(err)
+	packHash := fmt.Sprintf("%x", sha256.Sum256(packContents))
+	packFileName := filepath.Base(nonPublicLocalPack123)
+
+	t.Run("test adding a pack matching its published hash", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-matching-its-published-hash"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		server := NewServer()
+		server.AddRoute("hashes/"+packFileName+".sha256", []byte(packHash+"  "+packFileName+"\n"))
+		installer.SetPackHashURLs([]installer.PackHashURL{
+			{Vendor: "OtherVendor", Pattern: server.URL() + "other/{file}.sha256"},
+			{Vendor: "*", Pattern: server.URL() + "hashes/{vendor}.{name}.{version}.pack.sha256"},
+		})
+		defer installer.SetPackHashURLs(nil)
+
+		assert.Nil(installer.AddPack(nonPublicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.True(utils.DirExists(filepath.Join(localTestingDir, "TheVendor", "NonPublicLocalPack", "1.2.3")))
+	})
+
+	t.Run("test adding a pack not matching its published hash", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-not-matching-its-published-hash"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		server := NewServer()
+		server.AddRoute("hashes/"+packFileName+".sha256", []byte(fmt.Sprintf("%x", sha256.Sum256(nil))))
+		installer.SetPackHashURLs([]installer.PackHashURL{{Vendor: "TheVendor", Pattern: server.URL() + "hashes/{file}.sha256"}})
+		defer installer.SetPackHashURLs(nil)
+
+		err := installer.AddPack(nonPublicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.True(errs.Is(err, errs.ErrIntegrityCheckFailed))
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, "TheVendor", "NonPublicLocalPack", "1.2.3")))
+
+		// The original pack file is kept
+		assert.True(utils.FileExists(nonPublicLocalPack123))
+	})
+
+	t.Run("test adding a pack listed in a published list of hashes", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-listed-in-a-published-list-of-hashes"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		server := NewServer()
+		hashes := fmt.Sprintf("%x  TheVendor.Other.1.0.0.pack\n%s *%s\n", sha256.Sum256(nil), packHash, packFileName)
+		server.AddRoute("hashes/SHA256SUMS", []byte(hashes))
+		installer.SetPackHashURLs([]installer.PackHashURL{{Vendor: "TheVendor", Pattern: server.URL() + "hashes/SHA256SUMS"}})
+		defer installer.SetPackHashURLs(nil)
+
+		assert.Nil(installer.AddPack(nonPublicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.True(utils.DirExists(filepath.Join(localTestingDir, "TheVendor", "NonPublicLocalPack", "1.2.3")))
+
+		// The list is fetched again for the next pack rather than cached
+		assert.False(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, "SHA256SUMS")))
+	})
+
+	t.Run("test adding a pack missing from a published list of hashes", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-missing-from-a-published-list-of-hashes"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		server := NewServer()
+		server.AddRoute("hashes/SHA256SUMS", []byte(packHash+"  TheVendor.Other.1.0.0.pack\n"))
+		installer.SetPackHashURLs([]installer.PackHashURL{{Vendor: "TheVendor", Pattern: server.URL() + "hashes/SHA256SUMS"}})
+		defer installer.SetPackHashURLs(nil)
+
+		err := installer.AddPack(nonPublicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.True(errs.Is(err, errs.ErrIntegrityCheckFailed))
+	})
+
+	t.Run("test adding a pack without a published hash", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-without-a-published-hash"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		server := NewServer()
+		installer.SetPackHashURLs([]installer.PackHashURL{{Vendor: "TheVendor", Pattern: server.URL() + "hashes/{file}.sha256"}})
+		defer installer.SetPackHashURLs(nil)
+
+		err := installer.AddPack(nonPublicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.NotNil(err)
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, "TheVendor", "NonPublicLocalPack", "1.2.3")))
+	})
+
+	t.Run("test reading pack hash urls", func(t *testing.T) {
+		fileName := "test-reading-pack-hash-urls.txt"
+		defer os.Remove(fileName)
+
+		assert.Nil(os.WriteFile(fileName, []byte("# Vendors\n\nTheVendor => https://vendor.com/{file}.sha256\n* => https://mirror/{file}.sha256\n"), 0600))
+		hashURLs, err := installer.ReadPackHashURLs(fileName)
+		assert.Nil(err)
+		assert.Equal([]installer.PackHashURL{
+			{Vendor: "TheVendor", Pattern: "https://vendor.com/{file}.sha256"},
+			{Vendor: "*", Pattern: "https://mirror/{file}.sha256"},
+		}, hashURLs)
+
+		assert.Nil(os.WriteFile(fileName, []byte("TheVendor https://vendor.com/{file}.sha256\n"), 0600))
+		_, err = installer.ReadPackHashURLs(fileName)
+		assert.True(errs.Is(err, errs.ErrInvalidPackHashURL))
+	})
+}
@@ -0,0 +1,102 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinPack(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// setUp adds versions 1.2.3 and 1.2.4 of a pack
+	setUp := func(localTestingDir string) {
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		assert.Nil(installer.AddPack(nonPublicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.Nil(installer.AddPack(nonPublicLocalPack124, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+	}
+	packDir := func(localTestingDir, version string) string {
+		return filepath.Join(localTestingDir, "TheVendor", "NonPublicLocalPack", version)
+	}
+
+	t.Run("test pinning packs that are not installed", func(t *testing.T) {
+		localTestingDir := "test-pinning-packs-that-are-not-installed"
+		defer removePackRoot(localTestingDir)
+		setUp(localTestingDir)
+
+		assert.Equal(errs.ErrPackNotInstalled, installer.PinPack("TheVendor::NonPublicLocalPack@1.2.5"))
+		assert.Equal(errs.ErrBadPackName, installer.PinPack("TheVendor::NonPublicLocalPack@^1.2.3"))
+		pins, err := installer.ReadPinnedPacks()
+		assert.Nil(err)
+		assert.Empty(pins)
+	})
+
+	t.Run("test pinning the latest installed version", func(t *testing.T) {
+		localTestingDir := "test-pinning-the-latest-installed-version"
+		defer removePackRoot(localTestingDir)
+		setUp(localTestingDir)
+
+		assert.Nil(installer.PinPack("TheVendor.NonPublicLocalPack"))
+		pins, err := installer.ReadPinnedPacks()
+		assert.Nil(err)
+		assert.Equal(map[string]string{"TheVendor.NonPublicLocalPack": "1.2.4"}, pins)
+
+		assert.Nil(installer.UnpinPack("TheVendor::NonPublicLocalPack"))
+		pins, err = installer.ReadPinnedPacks()
+		assert.Nil(err)
+		assert.Empty(pins)
+		assert.NoFileExists(filepath.Join(localTestingDir, ".Local", installer.PinnedPacksName))
+	})
+
+	t.Run("test removing pinned packs", func(t *testing.T) {
+		localTestingDir := "test-removing-pinned-packs"
+		defer removePackRoot(localTestingDir)
+		setUp(localTestingDir)
+
+		assert.Nil(installer.PinPack("TheVendor.NonPublicLocalPack@1.2.3"))
+
+		err := installer.RemovePack(nonPublicLocalPack123PackID, false, Timeout)
+		assert.True(errs.Is(err, errs.ErrPackPinned))
+		err = installer.RemovePack(nonPublicLocalPackPackID, false, Timeout)
+		assert.True(errs.Is(err, errs.ErrPackPinned))
+		assert.DirExists(packDir(localTestingDir, "1.2.3"))
+
+		assert.Nil(installer.RemoveAllPacks(false, Timeout))
+		assert.DirExists(packDir(localTestingDir, "1.2.3"))
+		assert.NoDirExists(packDir(localTestingDir, "1.2.4"))
+
+		installer.SetForcePinned(true)
+		defer installer.SetForcePinned(false)
+		assert.Nil(installer.RemovePack(nonPublicLocalPack123PackID, false, Timeout))
+		assert.NoDirExists(packDir(localTestingDir, "1.2.3"))
+		pins, err := installer.ReadPinnedPacks()
+		assert.Nil(err)
+		assert.Empty(pins)
+	})
+
+	t.Run("test updating all packs but the pinned ones", func(t *testing.T) {
+		localTestingDir := "test-updating-all-packs-but-the-pinned-ones"
+		defer removePackRoot(localTestingDir)
+		setUp(localTestingDir)
+
+		assert.Nil(installer.PinPack("TheVendor::NonPublicLocalPack@1.2.3"))
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(io.Discard)
+
+		assert.Nil(installer.UpdatePack("", !CheckEula, !NoRequirements, Timeout))
+		assert.Contains(buf.String(), "Skipping TheVendor::NonPublicLocalPack, it is pinned to 1.2.3")
+	})
+}
@@ -0,0 +1,155 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// corruptPackEntry returns packContents with the content of its sample_file
+// entry no longer matching the entry's CRC-32
+func corruptPackEntry(t *testing.T, packContents []byte) []byte {
+	assert := assert.New(t)
+
+	z, err := zip.NewReader(bytes.NewReader(packContents), int64(len(packContents)))
+	assert.Nil(err)
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, file := range z.File {
+		if file.Name != "sample_file" {
+			assert.Nil(w.Copy(file))
+			continue
+		}
+		content := []byte("corrupted")
+		writer, err := w.CreateRaw(&zip.FileHeader{
+			Name:               file.Name,
+			Method:             zip.Store,
+			CRC32:              0xdeadbeef,
+			CompressedSize64:   uint64(len(content)),
+			UncompressedSize64: uint64(len(content)),
+		})
+		assert.Nil(err)
+		_, err = writer.Write(content)
+		assert.Nil(err)
+	}
+	assert.Nil(w.Close())
+	return buf.Bytes()
+}
+
+func TestAddCorruptPack(t *testing.T) {
+
+	assert := assert.New(t)
+
+	packContents, err := os.ReadFile(nonPublicLocalPack123)
+	assert.Nil(err)
+	corruptContents := corruptPackEntry(t, packContents)
+	packFileName := filepath.Base(nonPublicLocalPack123)
+	packDir := filepath.Join("TheVendor", "NonPublicLocalPack", "1.2.3")
+
+	t.Run("test adding a pack whose cached file is corrupt", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-whose-cached-file-is-corrupt"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		server := NewServer()
+		server.AddRoute(packFileName, packContents)
+		assert.Nil(os.WriteFile(filepath.Join(installer.Installation.DownloadDir, packFileName), corruptContents, 0600))
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(io.Discard)
+
+		assert.Nil(installer.AddPack(server.URL()+packFileName, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.True(utils.DirExists(filepath.Join(localTestingDir, packDir)))
+
+		stdout := buf.String()
+		assert.Contains(stdout, "Entry \"sample_file\" of")
+		assert.Contains(stdout, "its SHA-256 hash is "+fmt.Sprintf("%x", sha256.Sum256(corruptContents)))
+		assert.Contains(stdout, "Downloading TheVendor.NonPublicLocalPack.1.2.3 again")
+
+		cached, err := os.ReadFile(filepath.Join(installer.Installation.DownloadDir, packFileName))
+		assert.Nil(err)
+		assert.Equal(packContents, cached)
+	})
+
+	t.Run("test adding a pack downloaded corrupt", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-downloaded-corrupt"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		server := NewServer()
+		server.AddRoute(packFileName, corruptContents)
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(io.Discard)
+
+		err := installer.AddPack(server.URL()+packFileName, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.Equal(errs.ErrFailedDecompressingFile, err)
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, packDir)))
+
+		// The pack is downloaded again only once
+		assert.Equal(1, strings.Count(buf.String(), "Downloading TheVendor.NonPublicLocalPack.1.2.3 again"))
+		assert.False(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, packFileName)))
+	})
+
+	t.Run("test adding a corrupt pack matching its published hash", func(t *testing.T) {
+		localTestingDir := "test-adding-a-corrupt-pack-matching-its-published-hash"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		server := NewServer()
+		server.AddRoute(packFileName, corruptContents)
+		server.AddRoute("hashes/"+packFileName+".sha256", []byte(fmt.Sprintf("%x", sha256.Sum256(corruptContents))))
+		installer.SetPackHashURLs([]installer.PackHashURL{{Vendor: "*", Pattern: server.URL() + "hashes/{file}.sha256"}})
+		defer installer.SetPackHashURLs(nil)
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(io.Discard)
+
+		err := installer.AddPack(server.URL()+packFileName, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.Equal(errs.ErrFailedDecompressingFile, err)
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, packDir)))
+
+		stdout := buf.String()
+		assert.Contains(stdout, "the pack itself is corrupt")
+		assert.NotContains(stdout, "Downloading TheVendor.NonPublicLocalPack.1.2.3 again")
+	})
+
+	t.Run("test adding a local corrupt pack", func(t *testing.T) {
+		localTestingDir := "test-adding-a-local-corrupt-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packPath := filepath.Join(localTestingDir, packFileName)
+		assert.Nil(os.WriteFile(packPath, corruptContents, 0600))
+
+		err := installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.Equal(errs.ErrFailedDecompressingFile, err)
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, packDir)))
+
+		// Local pack files are left alone
+		assert.True(utils.FileExists(packPath))
+	})
+}
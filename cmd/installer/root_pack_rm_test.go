@@ -142,6 +142,35 @@ func TestRemovePack(t *testing.T) {
 		assert.Equal(errs.ErrPackNotPurgeable, err)
 	})
 
+	t.Run("test purge leaves files of other packs and versions", func(t *testing.T) {
+		localTestingDir := "test-purge-leaves-other-files"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		installer.Installation.WebDir = filepath.Join(testDir, "public_index")
+		defer removePackRoot(localTestingDir)
+
+		packPath := publicLocalPack123
+		addPack(t, packPath, ConfigType{
+			IsPublic: true,
+		})
+
+		otherFiles := []string{
+			"TheVendor.PublicLocalPack.1.2.30.pack",
+			"AnotherTheVendor.PublicLocalPack.1.2.3.pack",
+			"TheVendor.PublicLocalPackExtra.1.2.3.pack",
+			"TheVendor.PublicLocalPack.pdsc",
+		}
+		for _, file := range otherFiles {
+			assert.Nil(os.WriteFile(filepath.Join(installer.Installation.DownloadDir, file), []byte{}, 0600))
+		}
+
+		removePack(t, packPath, true, IsPublic, true) // withVersion=true, purge=true
+
+		for _, file := range otherFiles {
+			assert.True(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, file)))
+		}
+	})
+
 	t.Run("test purge a pack with license", func(t *testing.T) {
 		localTestingDir := "test-purge-pack-with-license"
 		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
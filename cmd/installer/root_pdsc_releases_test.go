@@ -0,0 +1,45 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvingPacksOfTheSameVendor(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test downloading the pdsc file once for packs of a batch", func(t *testing.T) {
+		localTestingDir := "test-downloading-the-pdsc-file-once-for-packs-of-a-batch"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		pdscRequests := 0
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pdscRequests++
+			http.ServeFile(w, r, pdscPack123MissingVersion)
+		}))
+		defer server.Close()
+
+		pdscTag := xml.PdscTag{Vendor: "TheVendor", Name: "PublicRemotePack", URL: server.URL + "/"}
+		assert.Nil(installer.Installation.PublicIndexXML.AddPdsc(pdscTag))
+
+		// The pdsc file has no such releases, it only needs to be read
+		for _, packID := range []string{"TheVendor::PublicRemotePack@1.2.3", "TheVendor.PublicRemotePack.1.2.3", "TheVendor::PublicRemotePack@1.2.3"} {
+			err := installer.AddPack(packID, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+			assert.NotNil(err)
+			assert.NotEqual(errs.ErrPackPdscCannotBeFound, err)
+		}
+		assert.Equal(1, pdscRequests)
+	})
+}
@@ -0,0 +1,133 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+// signPublicLocalPack signs publicLocalPack123 with a new certificate in dir,
+// returning the certificate and the signed pack, named like the original one
+func signPublicLocalPack(t *testing.T, dir string) (string, string) {
+	assert := assert.New(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "TheVendor"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	rawCert, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.Nil(err)
+
+	certPath := filepath.Join(dir, "TheVendor.pem")
+	keyPath := filepath.Join(dir, "TheVendor.key")
+	assert.Nil(os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rawCert}), 0600))
+	assert.Nil(os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+
+	assert.Nil(cryptography.SignPack(publicLocalPack123, certPath, keyPath, dir, "v2.0.0", false, false, true, true, nil))
+	signedPack := filepath.Join(dir, filepath.Base(publicLocalPack123))
+	assert.Nil(os.Rename(signedPack+".signed", signedPack))
+	return certPath, signedPack
+}
+
+func TestAddPackPinnedSigner(t *testing.T) {
+
+	assert := assert.New(t)
+
+	signingDir := "test-add-pack-pinned-signer-keys"
+	pinnedDir := filepath.Join(signingDir, "pinned")
+	otherDir := filepath.Join(signingDir, "other")
+	assert.Nil(os.MkdirAll(pinnedDir, 0700))
+	assert.Nil(os.MkdirAll(otherDir, 0700))
+	defer os.RemoveAll(signingDir)
+
+	pinnedCert, pinnedPack := signPublicLocalPack(t, pinnedDir)
+	_, otherPack := signPublicLocalPack(t, otherDir)
+
+	pinnedSignersFile := filepath.Join(signingDir, "pinned_signers.txt")
+	assert.Nil(os.WriteFile(pinnedSignersFile, []byte("# TheVendor rotates keys yearly\nTheVendor => pinned/TheVendor.pem\n"), 0600))
+	pinned, err := cryptography.ReadPinnedSigners(pinnedSignersFile)
+	assert.Nil(err)
+	assert.Len(pinned["TheVendor"], 1)
+
+	cryptography.SetPinnedSigners(pinned)
+	defer cryptography.SetPinnedSigners(nil)
+
+	t.Run("test adding a pack signed with the pinned key", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-signed-with-the-pinned-key"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.AddPack(pinnedPack, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+	})
+
+	t.Run("test adding a pack signed with another key", func(t *testing.T) {
+		localTestingDir := "test-adding-a-pack-signed-with-another-key"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		err := installer.AddPack(otherPack, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.Equal(errs.ErrSignerNotPinned, err)
+		assert.NoDirExists(filepath.Join(localTestingDir, "TheVendor", "PublicLocalPack"))
+	})
+
+	t.Run("test adding an unsigned pack", func(t *testing.T) {
+		localTestingDir := "test-adding-an-unsigned-pack-of-a-pinned-vendor"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		err := installer.AddPack(publicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.Equal(errs.ErrSignerNotPinned, err)
+	})
+
+	t.Run("test adding packs of vendors without pinned keys", func(t *testing.T) {
+		localTestingDir := "test-adding-packs-of-vendors-without-pinned-keys"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		cryptography.SetPinnedSigners(map[string][]cryptography.PinnedSigner{"OtherVendor": {{Fingerprint: "x509:00"}}})
+		defer cryptography.SetPinnedSigners(pinned)
+
+		assert.Nil(installer.AddPack(publicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+	})
+
+	t.Run("test reading invalid pinned signers", func(t *testing.T) {
+		assert.Nil(os.WriteFile(pinnedSignersFile, []byte("TheVendor\n"), 0600))
+		_, err := cryptography.ReadPinnedSigners(pinnedSignersFile)
+		assert.Equal(errs.ErrInvalidPinnedSigner, err)
+
+		assert.Nil(os.WriteFile(pinnedSignersFile, []byte("TheVendor => pinned/TheVendor.key\n"), 0600))
+		_, err = cryptography.ReadPinnedSigners(pinnedSignersFile)
+		assert.Equal(errs.ErrInvalidPinnedSigner, err)
+
+		absPinnedCert, err := filepath.Abs(pinnedCert)
+		assert.Nil(err)
+		assert.Nil(os.WriteFile(pinnedSignersFile, []byte("TheVendor => "+absPinnedCert+"\nTheVendor => X509:AB\n"), 0600))
+		pinned, err := cryptography.ReadPinnedSigners(pinnedSignersFile)
+		assert.Nil(err)
+		assert.Len(pinned["TheVendor"], 2)
+		assert.Equal("x509:ab", pinned["TheVendor"][1].Fingerprint)
+	})
+}
@@ -0,0 +1,136 @@
+//go:build linux
+// +build linux
+
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+var postInstallPackPdsc = `<?xml version="1.0" encoding="UTF-8"?>
+<package>
+  <vendor>TheVendor</vendor>
+  <name>PostInstallPack</name>
+  <url>http://vendor.com/packs/</url>
+  <releases>
+    <release version="1.2.3">Initial release.</release>
+  </releases>
+  <postInstall>
+    STEPS
+  </postInstall>
+</package>
+`
+
+// writePostInstallPack writes TheVendor.PostInstallPack.1.2.3.pack, whose
+// PDSC file declares steps, to dir
+func writePostInstallPack(t *testing.T, dir, steps string) string {
+	assert := assert.New(t)
+
+	packPath := filepath.Join(dir, "TheVendor.PostInstallPack.1.2.3.pack")
+	out, err := os.Create(packPath)
+	assert.Nil(err)
+	w := zip.NewWriter(out)
+	files := map[string]string{
+		"TheVendor.PostInstallPack.pdsc": strings.Replace(postInstallPackPdsc, "STEPS", steps, 1),
+		"Scripts/input.txt":              "input",
+	}
+	for name, content := range files {
+		writer, err := w.Create(name)
+		assert.Nil(err)
+		_, err = writer.Write([]byte(content))
+		assert.Nil(err)
+	}
+	assert.Nil(w.Close())
+	assert.Nil(out.Close())
+	return packPath
+}
+
+func TestAddPackPostInstall(t *testing.T) {
+
+	assert := assert.New(t)
+
+	generateStep := `<step command="sh" dir="Scripts"><arg>-c</arg><arg>cp input.txt generated.txt</arg></step>`
+
+	t.Run("test not running post-install steps by default", func(t *testing.T) {
+		localTestingDir := "test-not-running-post-install-steps-by-default"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packPath := writePostInstallPack(t, localTestingDir, generateStep)
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		scriptsDir := filepath.Join(localTestingDir, "TheVendor", "PostInstallPack", "1.2.3", "Scripts")
+		assert.FileExists(filepath.Join(scriptsDir, "input.txt"))
+		assert.NoFileExists(filepath.Join(scriptsDir, "generated.txt"))
+	})
+
+	t.Run("test running allowed post-install steps sandboxed", func(t *testing.T) {
+		localTestingDir := "test-running-allowed-post-install-steps-sandboxed"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		installer.SetPostInstallCommands([]string{"sh"})
+		defer installer.SetPostInstallCommands(nil)
+		t.Setenv("CPACKGET_TEST_SECRET", "secret")
+
+		// Only the loopback interface is there, and the environment is cleaned up
+		steps := generateStep +
+			`<step command="sh"><arg>-c</arg><arg>test "$(grep -c : /proc/net/dev)" -eq 1</arg></step>` +
+			`<step command="sh"><arg>-c</arg><arg>test -z "$CPACKGET_TEST_SECRET" -a -n "$CPACKGET_PACK_DIR"</arg></step>`
+		packPath := writePostInstallPack(t, localTestingDir, steps)
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		generated, err := os.ReadFile(filepath.Join(localTestingDir, "TheVendor", "PostInstallPack", "1.2.3", "Scripts", "generated.txt"))
+		assert.Nil(err)
+		assert.Equal("input", string(generated))
+	})
+
+	t.Run("test refusing post-install steps of commands not allowed", func(t *testing.T) {
+		localTestingDir := "test-refusing-post-install-steps-of-commands-not-allowed"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		installer.SetPostInstallCommands([]string{"python3"})
+		defer installer.SetPostInstallCommands(nil)
+
+		packPath := writePostInstallPack(t, localTestingDir, generateStep)
+		err := installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.Equal(errs.ErrPostInstallNotAllowed, err)
+		assert.NoDirExists(filepath.Join(localTestingDir, "TheVendor", "PostInstallPack", "1.2.3"))
+
+		// Nor may steps run outside of the pack's folder
+		installer.SetPostInstallCommands([]string{"sh"})
+		packPath = writePostInstallPack(t, localTestingDir, `<step command="sh" dir="../.."><arg>-c</arg><arg>true</arg></step>`)
+		err = installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.Equal(errs.ErrPostInstallNotAllowed, err)
+	})
+
+	t.Run("test removing packs whose post-install steps fail", func(t *testing.T) {
+		localTestingDir := "test-removing-packs-whose-post-install-steps-fail"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		installer.SetPostInstallCommands([]string{"sh"})
+		defer installer.SetPostInstallCommands(nil)
+
+		packPath := writePostInstallPack(t, localTestingDir, `<step command="sh"><arg>-c</arg><arg>echo failing; exit 3</arg></step>`)
+		err := installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout)
+		assert.Equal(errs.ErrPostInstallFailed, err)
+		assert.NoDirExists(filepath.Join(localTestingDir, "TheVendor", "PostInstallPack", "1.2.3"))
+	})
+}
@@ -0,0 +1,81 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefetchPacks(t *testing.T) {
+
+	assert := assert.New(t)
+
+	packs := []string{publicLocalPack123, nonPublicLocalPack123, nonPublicRemotePack123}
+	var mutex sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests[filepath.Base(r.URL.Path)]++
+		mutex.Unlock()
+		http.ServeFile(w, r, filepath.Join(testDir, "1.2.3", filepath.Base(r.URL.Path)))
+	}))
+	defer server.Close()
+
+	packURLs := []string{}
+	for _, pack := range packs {
+		packURLs = append(packURLs, server.URL+"/"+filepath.Base(pack))
+	}
+	requested := func(pack string) int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requests[filepath.Base(pack)]
+	}
+
+	t.Run("test adding packs downloaded ahead", func(t *testing.T) {
+		localTestingDir := "test-adding-packs-downloaded-ahead"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		stop := installer.PrefetchPacks(packURLs, 1, Timeout)
+		defer stop()
+
+		// Only the first pack is downloaded until it is being added
+		assert.Eventually(func() bool { return requested(packs[0]) == 1 }, 5*time.Second, 10*time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(0, requested(packs[1]))
+
+		for _, packURL := range packURLs {
+			assert.Nil(installer.AddPack(packURL, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		}
+		for _, pack := range packs {
+			assert.Equal(1, requested(pack))
+			assert.FileExists(filepath.Join(localTestingDir, ".Download", filepath.Base(pack)))
+		}
+	})
+
+	t.Run("test stopping downloads ahead", func(t *testing.T) {
+		localTestingDir := "test-stopping-downloads-ahead"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		stop := installer.PrefetchPacks(packURLs, 1, Timeout)
+		stop()
+
+		// Packs are downloaded by AddPack after that
+		assert.Nil(installer.AddPack(packURLs[2], !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		_, err := os.Stat(filepath.Join(localTestingDir, ".Download", filepath.Base(packs[2])))
+		assert.Nil(err)
+	})
+}
@@ -0,0 +1,51 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindProjectPackRoot(t *testing.T) {
+
+	assert := assert.New(t)
+
+	writeMarker := func(projectDir, content string) {
+		assert.Nil(os.MkdirAll(filepath.Join(projectDir, ".cpackget"), 0700))
+		assert.Nil(os.WriteFile(filepath.Join(projectDir, ".cpackget", "root"), []byte(content), 0600))
+	}
+
+	t.Run("test finding the pack root of a project", func(t *testing.T) {
+		projectDir := t.TempDir()
+		subDir := filepath.Join(projectDir, "src", "drivers")
+		assert.Nil(os.MkdirAll(subDir, 0700))
+
+		writeMarker(projectDir, "")
+		packRoot, err := installer.FindProjectPackRoot(subDir)
+		assert.Nil(err)
+		assert.Equal(filepath.Join(projectDir, ".cpackget", "packs"), packRoot)
+
+		writeMarker(projectDir, "../shared/packs\n# packs shared by all projects\n")
+		packRoot, err = installer.FindProjectPackRoot(subDir)
+		assert.Nil(err)
+		assert.Equal(filepath.Join(filepath.Dir(projectDir), "shared", "packs"), packRoot)
+
+		absolutePackRoot := filepath.Join(t.TempDir(), "packs")
+		writeMarker(projectDir, absolutePackRoot)
+		packRoot, err = installer.FindProjectPackRoot(projectDir)
+		assert.Nil(err)
+		assert.Equal(absolutePackRoot, packRoot)
+	})
+
+	t.Run("test finding the pack root outside of a project", func(t *testing.T) {
+		packRoot, err := installer.FindProjectPackRoot(t.TempDir())
+		assert.Nil(err)
+		assert.Equal("", packRoot)
+	})
+}
@@ -0,0 +1,133 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// proxyGet requests url from the proxy, returning the status and body
+func proxyGet(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url) // #nosec
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestProxy(t *testing.T) {
+
+	assert := assert.New(t)
+
+	requests := map[string]int{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if strings.HasSuffix(r.URL.Path, "missing.pack") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("content of " + r.URL.RequestURI()))
+	}))
+	upstreamHost := strings.TrimPrefix(upstream.URL, "http://")
+	upstreamPath := "/http/" + upstreamHost
+
+	// The vendor is only allowed once an index lists it
+	vendor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("content of vendor " + r.URL.Path))
+	}))
+	defer vendor.Close()
+	vendorPath := "/http/" + strings.TrimPrefix(vendor.URL, "http://")
+	index := `<index schemaVersion="1.1.0"><vendor>TheVendor</vendor><url>` + upstream.URL + `/</url>` +
+		`<pindex><pdsc url="` + vendor.URL + `/" vendor="TheVendor" name="PackName" version="1.2.3"/></pindex></index>`
+	indexUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(index))
+	}))
+	defer indexUpstream.Close()
+	indexUpstreamHost := strings.TrimPrefix(indexUpstream.URL, "http://")
+
+	cacheDir := "test-proxy-cache"
+	defer os.RemoveAll(cacheDir)
+	proxy := httptest.NewServer(installer.NewProxy(cacheDir, []string{upstreamHost, indexUpstreamHost}))
+	defer proxy.Close()
+
+	t.Run("test proxy fetching packs only once", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			status, body := proxyGet(t, proxy.URL+upstreamPath+"/pack/TheVendor.PackName.1.2.3.pack")
+			assert.Equal(http.StatusOK, status)
+			assert.Equal("content of /pack/TheVendor.PackName.1.2.3.pack", body)
+		}
+		assert.Equal(1, requests["/pack/TheVendor.PackName.1.2.3.pack"])
+	})
+
+	t.Run("test proxy fetching indexes on every request", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			status, body := proxyGet(t, proxy.URL+upstreamPath+"/pack/index.pidx")
+			assert.Equal(http.StatusOK, status)
+			assert.Equal("content of /pack/index.pidx", body)
+		}
+		assert.Equal(2, requests["/pack/index.pidx"])
+	})
+
+	t.Run("test proxy keeping files apart by their query", func(t *testing.T) {
+		for _, query := range []string{"?token=a", "?token=b"} {
+			status, body := proxyGet(t, proxy.URL+upstreamPath+"/pack/TheVendor.Queried.1.2.3.pack"+query)
+			assert.Equal(http.StatusOK, status)
+			assert.Equal("content of /pack/TheVendor.Queried.1.2.3.pack"+query, body)
+		}
+	})
+
+	t.Run("test proxy fetching from allowed hosts only", func(t *testing.T) {
+		status, _ := proxyGet(t, proxy.URL+vendorPath+"/TheVendor.PackName.pdsc")
+		assert.Equal(http.StatusForbidden, status)
+
+		status, _ = proxyGet(t, proxy.URL+"/http/"+indexUpstreamHost+"/index.pidx")
+		assert.Equal(http.StatusOK, status)
+
+		status, body := proxyGet(t, proxy.URL+vendorPath+"/TheVendor.PackName.pdsc")
+		assert.Equal(http.StatusOK, status)
+		assert.Equal("content of vendor /TheVendor.PackName.pdsc", body)
+	})
+
+	t.Run("test proxy bounding fetched files", func(t *testing.T) {
+		defer func(size int64) { utils.MaxDownloadSize = size }(utils.MaxDownloadSize)
+		utils.MaxDownloadSize = 5
+
+		status, _ := proxyGet(t, proxy.URL+upstreamPath+"/pack/TheVendor.Big.1.2.3.pack")
+		assert.Equal(http.StatusBadGateway, status)
+	})
+
+	t.Run("test proxy passing on missing files", func(t *testing.T) {
+		status, _ := proxyGet(t, proxy.URL+upstreamPath+"/pack/missing.pack")
+		assert.Equal(http.StatusNotFound, status)
+	})
+
+	t.Run("test proxy rejecting requests without an upstream URL", func(t *testing.T) {
+		status, _ := proxyGet(t, proxy.URL+"/ftp/example.com/index.pidx")
+		assert.Equal(http.StatusBadRequest, status)
+
+		status, _ = proxyGet(t, proxy.URL+"/index.pidx")
+		assert.Equal(http.StatusBadRequest, status)
+	})
+
+	t.Run("test proxy serving cached files when upstream is down", func(t *testing.T) {
+		upstream.Close()
+
+		status, body := proxyGet(t, proxy.URL+upstreamPath+"/pack/index.pidx")
+		assert.Equal(http.StatusOK, status)
+		assert.Equal("content of /pack/index.pidx", body)
+
+		status, _ = proxyGet(t, proxy.URL+upstreamPath+"/pack/TheVendor.Other.1.0.0.pack")
+		assert.Equal(http.StatusBadGateway, status)
+	})
+}
@@ -0,0 +1,78 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// setUpCacheToPrune installs a pack and caches files of a pack version
+// that is not installed, all of them last modified a year ago
+func setUpCacheToPrune(t *testing.T, packRoot string) []string {
+	assert := assert.New(t)
+
+	assert.Nil(installer.SetPackRoot(packRoot, CreatePackRoot))
+	installer.UnlockPackRoot()
+	addPack(t, publicLocalPack123, ConfigType{})
+
+	downloadDir := installer.Installation.DownloadDir
+	unusedFiles := []string{
+		filepath.Join(downloadDir, "TheVendor.PublicLocalPack.1.2.2.pack"),
+		filepath.Join(downloadDir, "TheVendor.PublicLocalPack.1.2.2.pdsc"),
+	}
+	for _, file := range unusedFiles {
+		assert.Nil(os.WriteFile(file, []byte("cached"), 0600))
+	}
+
+	yearAgo := time.Now().Add(-365 * 24 * time.Hour)
+	files, err := utils.ListDir(downloadDir, "")
+	assert.Nil(err)
+	for _, file := range files {
+		assert.Nil(os.Chtimes(file, yearAgo, yearAgo))
+	}
+
+	return unusedFiles
+}
+
+func TestPruneCache(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test pruning unused cached files", func(t *testing.T) {
+		localTestingDir := "test-pruning-unused-cached-files"
+		unusedFiles := setUpCacheToPrune(t, localTestingDir)
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.PruneCache(0, true))
+
+		for _, file := range unusedFiles {
+			assert.False(utils.FileExists(file))
+		}
+		assert.True(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, "TheVendor.PublicLocalPack.1.2.3.pack")))
+		assert.True(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, "TheVendor.PublicLocalPack.1.2.3.pdsc")))
+	})
+
+	t.Run("test pruning cached files by age", func(t *testing.T) {
+		localTestingDir := "test-pruning-cached-files-by-age"
+		unusedFiles := setUpCacheToPrune(t, localTestingDir)
+		defer removePackRoot(localTestingDir)
+
+		// Recently cached files are kept
+		now := time.Now()
+		assert.Nil(os.Chtimes(unusedFiles[0], now, now))
+
+		assert.Nil(installer.PruneCache(90*24*time.Hour, false))
+
+		assert.True(utils.FileExists(unusedFiles[0]))
+		assert.False(utils.FileExists(unusedFiles[1]))
+		assert.False(utils.FileExists(filepath.Join(installer.Installation.DownloadDir, "TheVendor.PublicLocalPack.1.2.3.pack")))
+	})
+}
@@ -0,0 +1,76 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/ui"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReinstallPack(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test reinstalling a pack not installed", func(t *testing.T) {
+		localTestingDir := "test-reinstalling-a-pack-not-installed"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Equal(errs.ErrPackNotInstalled, installer.ReinstallPack(publicLocalPack123, Timeout))
+	})
+
+	t.Run("test reinstalling a modified pack", func(t *testing.T) {
+		localTestingDir := "test-reinstalling-a-modified-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.AddPack(publicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		pdscPath := filepath.Join(localTestingDir, "TheVendor", "PublicLocalPack", "1.2.3", "TheVendor.PublicLocalPack.pdsc")
+		utils.UnsetReadOnly(pdscPath)
+		assert.Nil(os.Remove(pdscPath))
+
+		assert.Nil(installer.ReinstallPack(publicLocalPack123, Timeout))
+		assert.FileExists(pdscPath)
+		assert.NoDirExists(filepath.Join(localTestingDir, "TheVendor", "PublicLocalPack", "1.2.3_tmp"))
+	})
+
+	t.Run("test reinstalling a pack with an agreed license", func(t *testing.T) {
+		localTestingDir := "test-reinstalling-a-pack-with-an-agreed-license"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		ui.LicenseAgreed = &ui.Agreed
+		assert.Nil(installer.AddPack(packWithLicense, CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		// The license is not presented again
+		ui.LicenseAgreed = &ui.Disagreed
+		defer func() { ui.LicenseAgreed = nil }()
+		assert.Nil(installer.ReinstallPack(packWithLicense, Timeout))
+		assert.DirExists(filepath.Join(localTestingDir, "TheVendor", "PackWithLicense", "1.2.3"))
+	})
+
+	t.Run("test reinstalling a pack from a broken pack file", func(t *testing.T) {
+		localTestingDir := "test-reinstalling-a-pack-from-a-broken-pack-file"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.AddPack(publicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		brokenPack := filepath.Join(t.TempDir(), filepath.Base(publicLocalPack123))
+		assert.Nil(os.WriteFile(brokenPack, []byte("not a zip file"), 0600))
+
+		assert.NotNil(installer.ReinstallPack(brokenPack, Timeout))
+		assert.FileExists(filepath.Join(localTestingDir, "TheVendor", "PublicLocalPack", "1.2.3", "TheVendor.PublicLocalPack.pdsc"))
+	})
+}
@@ -0,0 +1,68 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemPackRoot(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test system and user pack roots", func(t *testing.T) {
+		systemPackRoot := "test-system-and-user-pack-roots-system"
+		userPackRoot := "test-system-and-user-pack-roots-user"
+		defer removePackRoot(systemPackRoot)
+		defer removePackRoot(userPackRoot)
+		installer.SetSystemPackRoot(systemPackRoot)
+		defer installer.SetSystemPackRoot("")
+
+		// Administrators install into the system pack root
+		assert.Nil(installer.SetPackRoot(systemPackRoot, CreatePackRoot))
+		assert.Empty(installer.Installation.ExtraPackRoots)
+		installer.UnlockPackRoot()
+		assert.Nil(installer.AddPack(packWithSubFolder, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		installer.LockPackRoot()
+
+		// Users install into their own pack root, seeing the system packs
+		assert.Nil(installer.SetPackRoot(userPackRoot, CreatePackRoot))
+		installer.UnlockPackRoot()
+		assert.Equal([]string{systemPackRoot}, installer.Installation.ExtraPackRoots)
+		assert.Nil(installer.AddPack(publicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(io.Discard)
+		assert.Nil(installer.ListInstalledPacks(!ListCached, !ListPublic, !ListUpdates, !ListRequirements, ListFilter))
+		assert.Contains(buf.String(), "I: TheVendor::PackWithSubFolder@1.2.3 (system)")
+		assert.Contains(buf.String(), "I: TheVendor::PublicLocalPack@1.2.3\n")
+
+		assert.Nil(installer.AddPack(packWithSubFolder, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.False(utils.DirExists(filepath.Join(userPackRoot, "TheVendor", "PackWithSubFolder", "1.2.3")))
+
+		err := installer.RemovePack("TheVendor.PackWithSubFolder.1.2.3", false, Timeout)
+		assert.Equal(errs.ErrPackNotInstalled, err)
+		assert.True(utils.DirExists(filepath.Join(systemPackRoot, "TheVendor", "PackWithSubFolder", "1.2.3")))
+	})
+
+	t.Run("test missing system pack root", func(t *testing.T) {
+		userPackRoot := "test-missing-system-pack-root-user"
+		defer removePackRoot(userPackRoot)
+		installer.SetSystemPackRoot("test-missing-system-pack-root-system")
+		defer installer.SetSystemPackRoot("")
+
+		assert.Nil(installer.SetPackRoot(userPackRoot, CreatePackRoot))
+		assert.Empty(installer.Installation.ExtraPackRoots)
+	})
+}
@@ -0,0 +1,87 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackRootSearchPath(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test pack root search path", func(t *testing.T) {
+		systemPackRoot := "test-pack-root-search-path-system"
+		userPackRoot := "test-pack-root-search-path-user"
+		defer removePackRoot(systemPackRoot)
+		defer removePackRoot(userPackRoot)
+
+		assert.Nil(installer.SetPackRoot(systemPackRoot, CreatePackRoot))
+		installer.UnlockPackRoot()
+		assert.Nil(installer.AddPack(packWithSubFolder, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		installer.LockPackRoot()
+
+		missingPackRoot := "test-pack-root-search-path-missing"
+		searchPath := strings.Join([]string{missingPackRoot, userPackRoot, systemPackRoot}, string(os.PathListSeparator))
+
+		// The user pack root does not exist yet, so packs go to the system one
+		assert.Nil(installer.SetPackRoot(searchPath, !CreatePackRoot))
+		assert.Equal(systemPackRoot, installer.Installation.PackRoot)
+		assert.Empty(installer.Installation.ExtraPackRoots)
+
+		assert.Nil(installer.SetPackRoot(userPackRoot, CreatePackRoot))
+		assert.Nil(installer.SetPackRoot(searchPath, !CreatePackRoot))
+		installer.UnlockPackRoot()
+		assert.Equal(userPackRoot, installer.Installation.PackRoot)
+		assert.Equal([]string{systemPackRoot}, installer.Installation.ExtraPackRoots)
+
+		// Packs of the system pack root count as installed
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(io.Discard)
+		assert.Nil(installer.ListInstalledPacks(!ListCached, !ListPublic, !ListUpdates, !ListRequirements, ListFilter))
+		assert.Contains(buf.String(), "I: TheVendor::PackWithSubFolder@1.2.3")
+
+		assert.Nil(installer.AddPack(packWithSubFolder, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.False(utils.DirExists(filepath.Join(userPackRoot, "TheVendor", "PackWithSubFolder", "1.2.3")))
+
+		// but are not removed from it
+		err := installer.RemovePack("TheVendor.PackWithSubFolder.1.2.3", false /*no purge*/, Timeout)
+		assert.Equal(errs.ErrPackNotInstalled, err)
+		assert.True(utils.DirExists(filepath.Join(systemPackRoot, "TheVendor", "PackWithSubFolder", "1.2.3")))
+
+		// Reinstalling adds the pack to the user pack root
+		assert.Nil(installer.AddPack(packWithSubFolder, !CheckEula, !ExtractEula, ForceReinstall, !NoRequirements, Timeout))
+		assert.True(utils.DirExists(filepath.Join(userPackRoot, "TheVendor", "PackWithSubFolder", "1.2.3")))
+		assert.True(utils.DirExists(filepath.Join(systemPackRoot, "TheVendor", "PackWithSubFolder", "1.2.3")))
+	})
+
+	t.Run("test pack root search path creating the first pack root", func(t *testing.T) {
+		firstPackRoot := "test-pack-root-search-path-first"
+		secondPackRoot := "test-pack-root-search-path-second"
+		defer removePackRoot(firstPackRoot)
+
+		searchPath := firstPackRoot + string(os.PathListSeparator) + secondPackRoot
+		assert.Nil(installer.SetPackRoot(searchPath, CreatePackRoot))
+		assert.Equal(firstPackRoot, installer.Installation.PackRoot)
+		assert.Empty(installer.Installation.ExtraPackRoots)
+		assert.False(utils.DirExists(secondPackRoot))
+	})
+
+	t.Run("test pack root search path without any pack root", func(t *testing.T) {
+		searchPath := "test-pack-root-search-path-none" + string(os.PathListSeparator) + "test-pack-root-search-path-nothing"
+		assert.Equal(errs.ErrPackRootDoesNotExist, installer.SetPackRoot(searchPath, !CreatePackRoot))
+	})
+}
@@ -0,0 +1,145 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+// releaseArchive returns the archive of a cpackget release for this
+// platform, as goreleaser creates it, with binary as cpackget binary
+func releaseArchive(t *testing.T, version string, binary []byte) (string, []byte) {
+	assert := assert.New(t)
+
+	dir := fmt.Sprintf("cpackget_%s_%s_%s", version, runtime.GOOS, runtime.GOARCH)
+	var b bytes.Buffer
+	if runtime.GOOS == "windows" {
+		w := zip.NewWriter(&b)
+		writer, err := w.Create(dir + "/cpackget.exe")
+		assert.Nil(err)
+		_, err = writer.Write(binary)
+		assert.Nil(err)
+		assert.Nil(w.Close())
+		return dir + ".zip", b.Bytes()
+	}
+
+	gz := gzip.NewWriter(&b)
+	w := tar.NewWriter(gz)
+	for name, content := range map[string][]byte{dir + "/README.md": []byte("readme"), dir + "/cpackget": binary} {
+		assert.Nil(w.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := w.Write(content)
+		assert.Nil(err)
+	}
+	assert.Nil(w.Close())
+	assert.Nil(gz.Close())
+	return dir + ".tar.gz", b.Bytes()
+}
+
+func TestSelfUpdate(t *testing.T) {
+
+	assert := assert.New(t)
+
+	version := "9.9.9"
+	archiveName, archive := releaseArchive(t, version, []byte("new binary"))
+	digest := sha256.Sum256(archive)
+	checksumsName := "cpackget_" + version + "_checksums.txt"
+	checksums := []byte(hex.EncodeToString(digest[:]) + "  " + archiveName + "\n")
+	publicKey, signature := signIndex(t, checksums)
+
+	keyPath := "test-self-update.pub"
+	assert.Nil(os.WriteFile(keyPath, []byte(publicKey), 0600))
+	defer os.Remove(keyPath)
+
+	server := NewServer()
+	server.AddRoute(archiveName, archive)
+	server.AddRoute(checksumsName, checksums)
+	server.AddRoute(checksumsName+".sig", signature)
+	server.AddRoute("tampered/"+archiveName, append([]byte{0}, archive...))
+
+	release := func(archiveURL string) []byte {
+		b, err := json.Marshal(installer.Release{
+			Version: "v" + version,
+			Assets: []installer.ReleaseAsset{
+				{Name: archiveName, URL: archiveURL},
+				{Name: checksumsName, URL: server.URL() + checksumsName},
+				{Name: checksumsName + ".sig", URL: server.URL() + checksumsName + ".sig"},
+			},
+		})
+		assert.Nil(err)
+		return b
+	}
+	server.AddRoute("latest", release(server.URL()+archiveName))
+	server.AddRoute("tampered/latest", release(server.URL()+"tampered/"+archiveName))
+
+	localTestingDir := "test-self-update"
+	assert.Nil(os.MkdirAll(localTestingDir, 0700))
+	defer os.RemoveAll(localTestingDir)
+	executable := filepath.Join(localTestingDir, "cpackget")
+
+	t.Run("test telling whether a release is newer", func(t *testing.T) {
+		latest, err := installer.LatestRelease(server.URL()+"latest", Timeout)
+		assert.Nil(err)
+		assert.Equal("v"+version, latest.Version)
+		assert.True(latest.IsNewerThan("v2.1.0"))
+		assert.True(latest.IsNewerThan(""))
+		assert.False(latest.IsNewerThan(version))
+		assert.False(latest.IsNewerThan("10.0.0"))
+
+		_, err = installer.LatestRelease(server.URL()+checksumsName, Timeout)
+		assert.Equal(errs.ErrReleaseNotFound, err)
+	})
+
+	t.Run("test replacing the binary with a verified release", func(t *testing.T) {
+		assert.Nil(os.WriteFile(executable, []byte("old binary"), 0700))
+
+		latest, err := installer.LatestRelease(server.URL()+"latest", Timeout)
+		assert.Nil(err)
+		assert.Nil(installer.SelfUpdate(latest, executable, keyPath, Timeout))
+
+		binary, err := os.ReadFile(executable)
+		assert.Nil(err)
+		assert.Equal("new binary", string(binary))
+		assert.NoFileExists(executable + ".new")
+	})
+
+	t.Run("test keeping the binary if the release does not match its checksum", func(t *testing.T) {
+		assert.Nil(os.WriteFile(executable, []byte("old binary"), 0700))
+
+		latest, err := installer.LatestRelease(server.URL()+"tampered/latest", Timeout)
+		assert.Nil(err)
+		assert.Equal(errs.ErrIntegrityCheckFailed, installer.SelfUpdate(latest, executable, "", Timeout))
+
+		binary, err := os.ReadFile(executable)
+		assert.Nil(err)
+		assert.Equal("old binary", string(binary))
+	})
+
+	t.Run("test refusing releases with a bad or no signature", func(t *testing.T) {
+		otherKey, _ := signIndex(t, checksums)
+		otherKeyPath := filepath.Join(localTestingDir, "other.pub")
+		assert.Nil(os.WriteFile(otherKeyPath, []byte(otherKey), 0600))
+
+		latest, err := installer.LatestRelease(server.URL()+"latest", Timeout)
+		assert.Nil(err)
+		assert.Equal(errs.ErrBadReleaseSignature, installer.SelfUpdate(latest, executable, otherKeyPath, Timeout))
+
+		latest.Assets = latest.Assets[:2]
+		assert.Equal(errs.ErrUnsignedRelease, installer.SelfUpdate(latest, executable, keyPath, Timeout))
+	})
+}
@@ -0,0 +1,258 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/zip"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var slimPackPdsc = `<?xml version="1.0" encoding="UTF-8"?>
+<package>
+  <vendor>TheVendor</vendor>
+  <name>SlimPack</name>
+  <url>http://vendor.com/packs/</url>
+  <releases>
+    <release version="1.2.4">Fixes.</release>
+    <release version="1.2.3">Initial release.</release>
+  </releases>
+  <boards>
+    <board vendor="TheVendor" name="Board">
+      <book category="manual" name="Documents\board.pdf"/>
+    </board>
+  </boards>
+  <components>
+    <component Cclass="Device" Cgroup="Startup">
+      <files>
+        <file category="doc" name="Documents/startup.html"/>
+        <file category="source" name="Source/startup.c"/>
+        <file category="header" name="Include/common.h"/>
+      </files>
+    </component>
+    <component Cclass="CMSIS" Cgroup="DSP">
+      <files>
+        <file category="source" name="Source/dsp.c"/>
+        <file category="header" name="Include/common.h"/>
+      </files>
+    </component>
+  </components>
+  <examples>
+    <example name="Blinky" folder="Examples/Blinky" doc="Abstract.txt"/>
+  </examples>
+</package>
+`
+
+// writeSlimPack writes TheVendor.SlimPack.<version>.pack, with documentation,
+// examples and an embedded checksum file, to dir
+func writeSlimPack(t *testing.T, dir, version string) string {
+	assert := assert.New(t)
+
+	pdsc := slimPackPdsc
+	if version == "1.2.3" {
+		pdsc = strings.Replace(pdsc, "    <release version=\"1.2.4\">Fixes.</release>\n", "", 1)
+	}
+
+	packPath := filepath.Join(dir, "TheVendor.SlimPack."+version+".pack")
+	out, err := os.Create(packPath)
+	assert.Nil(err)
+	w := zip.NewWriter(out)
+	files := map[string]string{
+		"TheVendor.SlimPack.pdsc":      pdsc,
+		"Documents/board.pdf":          "board",
+		"Documents/startup.html":       "startup",
+		"Source/startup.c":             "void Reset_Handler(void) {}",
+		"Source/dsp.c":                 "void arm_add_f32(void) {}",
+		"Include/common.h":             "#define COMMON 1",
+		"Examples/Blinky/Abstract.txt": "blinky",
+		"Examples/Blinky/main.c":       "int main(void) {}",
+	}
+	for name, content := range files {
+		writer, err := w.Create("TheVendor.SlimPack." + version + "/" + name)
+		assert.Nil(err)
+		_, err = writer.Write([]byte(content))
+		assert.Nil(err)
+	}
+	assert.Nil(w.Close())
+	assert.Nil(out.Close())
+
+	assert.Nil(cryptography.EmbedChecksum(packPath, packPath, "sha256"))
+	return packPath
+}
+
+func TestAddPackSlim(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test installing a slim pack", func(t *testing.T) {
+		localTestingDir := "test-installing-a-slim-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := localTestingDir + "-packs"
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		defer os.RemoveAll(packDir)
+		packPath := writeSlimPack(t, packDir, "1.2.3")
+
+		installer.SetSlim(true)
+		defer installer.SetSlim(false)
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		packHomeDir := filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.3")
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "TheVendor.SlimPack.pdsc")))
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Source", "startup.c")))
+		assert.False(utils.FileExists(filepath.Join(packHomeDir, "Documents", "board.pdf")))
+		assert.False(utils.FileExists(filepath.Join(packHomeDir, "Documents", "startup.html")))
+		assert.False(utils.DirExists(filepath.Join(packHomeDir, "Examples")))
+
+		skipped, err := os.ReadFile(filepath.Join(packHomeDir, cryptography.SkippedFilesName))
+		assert.Nil(err)
+		assert.Equal("Documents/board.pdf\nDocuments/startup.html\nExamples/Blinky/Abstract.txt\nExamples/Blinky/main.c\n", string(skipped))
+
+		// Skipped files are not missing
+		verified, err := cryptography.VerifyEmbeddedChecksum(packHomeDir)
+		assert.True(verified)
+		assert.Nil(err)
+
+		sparsePacks, err := os.ReadFile(filepath.Join(installer.Installation.LocalDir, installer.SparsePacksName))
+		assert.Nil(err)
+		assert.Contains(string(sparsePacks), `"TheVendor.SlimPack": {
+    "slim": true
+  }`)
+	})
+
+	t.Run("test updating a slim pack", func(t *testing.T) {
+		localTestingDir := "test-updating-a-slim-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := localTestingDir + "-packs"
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		defer os.RemoveAll(packDir)
+		packPath := writeSlimPack(t, packDir, "1.2.3")
+		packContent, err := os.ReadFile(writeSlimPack(t, packDir, "1.2.4"))
+		assert.Nil(err)
+
+		server := NewServer()
+		server.AddRoute("TheVendor.SlimPack.1.2.4.pack", packContent)
+		pdsc := strings.Replace(slimPackPdsc, "http://vendor.com/packs/", server.URL(), 1)
+		assert.Nil(os.WriteFile(filepath.Join(installer.Installation.WebDir, "TheVendor.SlimPack.pdsc"), []byte(pdsc), 0600))
+
+		installer.SetSlim(true)
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		installer.SetSlim(false)
+
+		// Updating without --slim keeps the pack slim
+		assert.Nil(installer.UpdatePack("TheVendor.SlimPack", !CheckEula, NoRequirements, Timeout))
+
+		packHomeDir := filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.4")
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Source", "startup.c")))
+		assert.False(utils.FileExists(filepath.Join(packHomeDir, "Documents", "startup.html")))
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, cryptography.SkippedFilesName)))
+
+		// Adding it in full forgets it
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, ForceReinstall, !NoRequirements, Timeout))
+		sparsePacks, err := os.ReadFile(filepath.Join(installer.Installation.LocalDir, installer.SparsePacksName))
+		assert.Nil(err)
+		assert.Equal("{}\n", string(sparsePacks))
+	})
+
+	t.Run("test installing some components of a pack", func(t *testing.T) {
+		localTestingDir := "test-installing-some-components-of-a-pack"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := localTestingDir + "-packs"
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		defer os.RemoveAll(packDir)
+		packPath := writeSlimPack(t, packDir, "1.2.3")
+
+		installer.SetComponentFilters(nil, []string{"cmsis:*"})
+		defer installer.SetComponentFilters(nil, nil)
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		packHomeDir := filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.3")
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Source", "startup.c")))
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Documents", "board.pdf")))
+		assert.False(utils.FileExists(filepath.Join(packHomeDir, "Source", "dsp.c")))
+		// Files used by kept components are kept
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Include", "common.h")))
+
+		skipped, err := os.ReadFile(filepath.Join(packHomeDir, cryptography.SkippedFilesName))
+		assert.Nil(err)
+		assert.Equal("Source/dsp.c\n", string(skipped))
+
+		// Bad patterns fail before extracting anything
+		installer.SetComponentFilters([]string{"CMSIS:["}, nil)
+		err = installer.AddPack(packPath, !CheckEula, !ExtractEula, ForceReinstall, !NoRequirements, Timeout)
+		assert.Equal(path.ErrBadPattern, err)
+	})
+
+	t.Run("test updating a pack with component filters", func(t *testing.T) {
+		localTestingDir := "test-updating-a-pack-with-component-filters"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := localTestingDir + "-packs"
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		defer os.RemoveAll(packDir)
+		packPath := writeSlimPack(t, packDir, "1.2.3")
+		packContent, err := os.ReadFile(writeSlimPack(t, packDir, "1.2.4"))
+		assert.Nil(err)
+
+		server := NewServer()
+		server.AddRoute("TheVendor.SlimPack.1.2.4.pack", packContent)
+		pdsc := strings.Replace(slimPackPdsc, "http://vendor.com/packs/", server.URL(), 1)
+		assert.Nil(os.WriteFile(filepath.Join(installer.Installation.WebDir, "TheVendor.SlimPack.pdsc"), []byte(pdsc), 0600))
+
+		installer.SetComponentFilters([]string{"CMSIS:DSP"}, nil)
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		installer.SetComponentFilters(nil, nil)
+
+		sparsePacks, err := os.ReadFile(filepath.Join(installer.Installation.LocalDir, installer.SparsePacksName))
+		assert.Nil(err)
+		assert.Contains(string(sparsePacks), `"includeComponents": [
+      "CMSIS:DSP"
+    ]`)
+
+		// Updating without filters applies the ones the pack was added with
+		assert.Nil(installer.UpdatePack("TheVendor.SlimPack", !CheckEula, NoRequirements, Timeout))
+
+		packHomeDir := filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.4")
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Source", "dsp.c")))
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Include", "common.h")))
+		assert.False(utils.FileExists(filepath.Join(packHomeDir, "Source", "startup.c")))
+	})
+
+	t.Run("test installing a pack in full", func(t *testing.T) {
+		localTestingDir := "test-installing-a-pack-in-full"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		packDir := localTestingDir + "-packs"
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		defer os.RemoveAll(packDir)
+		packPath := writeSlimPack(t, packDir, "1.2.3")
+
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		packHomeDir := filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.3")
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Documents", "board.pdf")))
+		assert.True(utils.FileExists(filepath.Join(packHomeDir, "Examples", "Blinky", "main.c")))
+		assert.False(utils.FileExists(filepath.Join(packHomeDir, cryptography.SkippedFilesName)))
+	})
+}
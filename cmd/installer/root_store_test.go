@@ -0,0 +1,166 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+// storeFileCount counts the files in the content store of the pack root
+func storeFileCount(t *testing.T) int {
+	count := 0
+	err := filepath.Walk(installer.Installation.StoreDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			count++
+		}
+		return err
+	})
+	assert.Nil(t, err)
+	return count
+}
+
+func TestAddPackWithContentStore(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test sharing files of packs in the content store", func(t *testing.T) {
+		localTestingDir := "test-sharing-files-of-packs-in-the-content-store"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		assert.Nil(os.Mkdir(installer.Installation.StoreDir, 0700))
+
+		packDir := localTestingDir + "-packs"
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		defer os.RemoveAll(packDir)
+
+		assert.Nil(installer.AddPack(writeSlimPack(t, packDir, "1.2.3"), !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.Nil(installer.AddPack(writeSlimPack(t, packDir, "1.2.4"), !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		// Both packs and the store link to the file they have in common
+		links, err := utils.LinkCount(filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.3", "Source", "startup.c"))
+		assert.Nil(err)
+		assert.Equal(uint64(3), links)
+
+		// Files only one pack has are stored as well
+		links, err = utils.LinkCount(filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.4", "TheVendor.SlimPack.pdsc"))
+		assert.Nil(err)
+		assert.Equal(uint64(2), links)
+		storedFiles := storeFileCount(t)
+
+		// Removing a pack only removes the files no other pack has
+		assert.Nil(installer.RemovePack("TheVendor.SlimPack.1.2.3", false, Timeout))
+		links, err = utils.LinkCount(filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.4", "Source", "startup.c"))
+		assert.Nil(err)
+		assert.Equal(uint64(2), links)
+		assert.Less(storeFileCount(t), storedFiles)
+
+		assert.Nil(installer.RemovePack("TheVendor.SlimPack.1.2.4", false, Timeout))
+		assert.Equal(0, storeFileCount(t))
+	})
+
+	t.Run("test keeping shared files read-only", func(t *testing.T) {
+		localTestingDir := "test-keeping-shared-files-read-only"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		assert.Nil(os.Mkdir(installer.Installation.StoreDir, 0700))
+
+		packDir := localTestingDir + "-packs"
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		defer os.RemoveAll(packDir)
+
+		assert.Nil(installer.AddPack(writeSlimPack(t, packDir, "1.2.3"), !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.Nil(installer.AddPack(writeSlimPack(t, packDir, "1.2.4"), !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		// Reinstalling or removing one pack does not make the files of the other writable
+		assert.Nil(installer.AddPack(writeSlimPack(t, packDir, "1.2.3"), !CheckEula, !ExtractEula, ForceReinstall, !NoRequirements, Timeout))
+		links, err := utils.LinkCount(filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.4", "Source", "startup.c"))
+		assert.Nil(err)
+		assert.Equal(uint64(3), links)
+		assert.Nil(installer.RemovePack("TheVendor.SlimPack.1.2.3", false, Timeout))
+		info, err := os.Stat(filepath.Join(localTestingDir, "TheVendor", "SlimPack", "1.2.4", "Source", "startup.c"))
+		assert.Nil(err)
+		assert.Equal(utils.FileModeRO, info.Mode().Perm())
+	})
+
+	t.Run("test moving, exporting and importing a pack root with a content store", func(t *testing.T) {
+		localTestingDir := "test-moving-a-pack-root-with-a-content-store"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		assert.Nil(os.Mkdir(installer.Installation.StoreDir, 0700))
+
+		packDir := localTestingDir + "-packs"
+		assert.Nil(os.MkdirAll(packDir, 0700))
+		defer os.RemoveAll(packDir)
+		assert.Nil(installer.AddPack(writeSlimPack(t, packDir, "1.2.3"), !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.Nil(installer.AddPack(writeSlimPack(t, packDir, "1.2.4"), !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		storedFiles := storeFileCount(t)
+
+		sharedFile := filepath.Join("TheVendor", "SlimPack", "1.2.3", "Source", "startup.c")
+		movedPackRoot := localTestingDir + "-moved"
+		defer removePackRoot(movedPackRoot)
+		assert.Nil(installer.MovePackRoot(localTestingDir, movedPackRoot))
+		links, err := utils.LinkCount(filepath.Join(movedPackRoot, sharedFile))
+		assert.Nil(err)
+		assert.Equal(uint64(3), links)
+
+		archivePath := filepath.Join(packDir, "pack-root.tar.gz")
+		assert.Nil(installer.ExportPackRoot(movedPackRoot, archivePath))
+		importedPackRoot := localTestingDir + "-imported"
+		defer removePackRoot(importedPackRoot)
+		assert.Nil(installer.ImportPackRoot(archivePath, importedPackRoot))
+		links, err = utils.LinkCount(filepath.Join(importedPackRoot, sharedFile))
+		assert.Nil(err)
+		assert.Equal(uint64(3), links)
+
+		assert.Nil(installer.SetPackRoot(importedPackRoot, !CreatePackRoot))
+		installer.UnlockPackRoot()
+		assert.Equal(storedFiles, storeFileCount(t))
+	})
+
+	t.Run("test adding packs with a content store on an in-memory file system", func(t *testing.T) {
+		localTestingDir := "test-adding-packs-with-a-content-store-in-memory"
+
+		packContents, err := os.ReadFile(publicLocalPack123)
+		assert.Nil(err)
+
+		memFs := afero.NewMemMapFs()
+		assert.Nil(afero.WriteFile(memFs, publicLocalPack123, packContents, 0644))
+		utils.SetFileSystem(memFs)
+		defer utils.SetFileSystem(nil)
+
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		assert.Nil(memFs.Mkdir(installer.Installation.StoreDir, 0700))
+
+		// The files are installed, just not shared
+		assert.Nil(installer.AddPack(publicLocalPack123, !CheckEula, !ExtractEula, !ForceReinstall, NoRequirements, Timeout))
+		assert.True(utils.FileExists(filepath.Join(localTestingDir, "TheVendor", "PublicLocalPack", "1.2.3", "TheVendor.PublicLocalPack.pdsc")))
+		assert.True(utils.IsEmpty(installer.Installation.StoreDir))
+		assert.Nil(installer.RemovePack("TheVendor.PublicLocalPack.1.2.3", false, Timeout))
+	})
+
+	t.Run("test adding packs without a content store", func(t *testing.T) {
+		localTestingDir := "test-adding-packs-without-a-content-store"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		assert.Nil(installer.AddPack(packWithSubFolder, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		links, err := utils.LinkCount(filepath.Join(localTestingDir, "TheVendor", "PackWithSubFolder", "1.2.3", "sample_file"))
+		assert.Nil(err)
+		assert.Equal(uint64(1), links)
+		assert.False(utils.DirExists(installer.Installation.StoreDir))
+	})
+}
@@ -852,6 +852,28 @@ func TestSetPackRoot(t *testing.T) {
 		assert.Nil(installer.SetPackRoot(localTestingDir, !CreatePackRoot))
 	})
 
+	t.Run("test initialize pack root with a separate cache directory", func(t *testing.T) {
+		localTestingDir := "valid-pack-root-with-cache-dir"
+		cacheDir := filepath.Join(t.TempDir(), "cache")
+		defer removePackRoot(localTestingDir)
+		defer installer.SetCacheDir("")
+
+		installer.SetCacheDir(cacheDir)
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+
+		checkPackRoot(t, localTestingDir)
+		assert.Equal(cacheDir, installer.Installation.DownloadDir)
+		assert.False(utils.DirExists(filepath.Join(localTestingDir, ".Download")))
+
+		// The cache directory is created even when the pack root is not
+		utils.UnsetReadOnly(cacheDir)
+		assert.Nil(os.RemoveAll(cacheDir))
+		assert.Nil(installer.SetPackRoot(localTestingDir, !CreatePackRoot))
+		assert.True(utils.DirExists(cacheDir))
+		installer.UnlockPackRoot()
+	})
+
 	// Define a few paths to try out per operating system
 	paths := generatePaths(t)
 	for description, path := range paths {
@@ -875,3 +897,18 @@ func init() {
 	log.SetLevel(logLevel)
 	log.SetFormatter(new(LogFormatter))
 }
+
+// TestMain keeps parsed metadata of the many throwaway pack roots
+// out of the user's cache directory
+func TestMain(m *testing.M) {
+	metadataCacheDir, err := os.MkdirTemp("", "cpackget-metadata")
+	if err != nil {
+		panic(err)
+	}
+	utils.SetMetadataCacheDir(metadataCacheDir)
+
+	code := m.Run()
+
+	os.RemoveAll(metadataCacheDir)
+	os.Exit(code)
+}
@@ -0,0 +1,162 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/stretchr/testify/assert"
+)
+
+var toolchainPackPdsc = `<?xml version="1.0" encoding="UTF-8"?>
+<package>
+  <vendor>TheVendor</vendor>
+  <name>ToolchainPack</name>
+  <url>http://vendor.com/packs/</url>
+  <releases>
+    <release version="1.2.3">Initial release.</release>
+  </releases>
+  <conditions>
+    <condition id="GCC">
+      <require Tcompiler="GCC"/>
+    </condition>
+    <condition id="AC6">
+      <require Tcompiler="ARMCC" Toptions="AC6"/>
+    </condition>
+    <condition id="AC5">
+      <require Tcompiler="ARMCC" Toptions="AC5"/>
+    </condition>
+    <condition id="IAR">
+      <require Tcompiler="IAR"/>
+    </condition>
+    <condition id="Device GCC">
+      <require Dvendor="ARM:82"/>
+      <require condition="GCC"/>
+    </condition>
+    <condition id="Device">
+      <require Dname="CHIP100"/>
+    </condition>
+    <condition id="Not IAR">
+      <deny Tcompiler="IAR"/>
+    </condition>
+    <condition id="Arm compilers">
+      <accept condition="AC6"/>
+      <accept condition="AC5"/>
+    </condition>
+  </conditions>
+  <components>
+    <component Cclass="Device" Cgroup="Startup">
+      <files>
+        <file category="source" name="Source/startup_gcc.S" condition="GCC"/>
+        <file category="source" name="Source/startup_ac6.s" condition="AC6"/>
+        <file category="source" name="Source/startup_ac5.s" condition="AC5"/>
+        <file category="source" name="Source/startup_iar.s" condition="IAR"/>
+        <file category="source" name="Source/system.c" condition="Device"/>
+      </files>
+    </component>
+    <component Cclass="Compiler" Cgroup="Retarget" condition="Device GCC">
+      <files>
+        <file category="source" name="Source/retarget.c"/>
+      </files>
+    </component>
+    <component Cclass="Compiler" Cgroup="Event Recorder" condition="Not IAR">
+      <files>
+        <file category="source" name="Source/recorder.c"/>
+      </files>
+    </component>
+    <component Cclass="Compiler" Cgroup="Scatter" condition="Arm compilers">
+      <files>
+        <file category="linkerScript" name="Source/scatter.sct"/>
+      </files>
+    </component>
+  </components>
+</package>
+`
+
+var toolchainPackFiles = []string{
+	"Source/recorder.c",
+	"Source/retarget.c",
+	"Source/scatter.sct",
+	"Source/startup_ac5.s",
+	"Source/startup_ac6.s",
+	"Source/startup_gcc.S",
+	"Source/startup_iar.s",
+	"Source/system.c",
+}
+
+// writeToolchainPack writes TheVendor.ToolchainPack.1.2.3.pack, whose files
+// apply to different toolchains, to dir
+func writeToolchainPack(t *testing.T, dir string) string {
+	assert := assert.New(t)
+
+	packPath := filepath.Join(dir, "TheVendor.ToolchainPack.1.2.3.pack")
+	out, err := os.Create(packPath)
+	assert.Nil(err)
+	w := zip.NewWriter(out)
+	files := map[string]string{"TheVendor.ToolchainPack.pdsc": toolchainPackPdsc}
+	for _, name := range toolchainPackFiles {
+		files[name] = name
+	}
+	for name, content := range files {
+		writer, err := w.Create(name)
+		assert.Nil(err)
+		_, err = writer.Write([]byte(content))
+		assert.Nil(err)
+	}
+	assert.Nil(w.Close())
+	assert.Nil(out.Close())
+	return packPath
+}
+
+func TestAddPackToolchain(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for toolchain, skipped := range map[string]string{
+		"GCC": "Source/scatter.sct\nSource/startup_ac5.s\nSource/startup_ac6.s\nSource/startup_iar.s\n",
+		"ac6": "Source/retarget.c\nSource/startup_ac5.s\nSource/startup_gcc.S\nSource/startup_iar.s\n",
+		"IAR": "Source/recorder.c\nSource/retarget.c\nSource/scatter.sct\nSource/startup_ac5.s\nSource/startup_ac6.s\nSource/startup_gcc.S\n",
+	} {
+		t.Run("test installing a pack for "+toolchain, func(t *testing.T) {
+			localTestingDir := "test-installing-a-pack-for-" + toolchain
+			assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+			installer.UnlockPackRoot()
+			defer removePackRoot(localTestingDir)
+
+			packDir := localTestingDir + "-packs"
+			assert.Nil(os.MkdirAll(packDir, 0700))
+			defer os.RemoveAll(packDir)
+			packPath := writeToolchainPack(t, packDir)
+
+			assert.Nil(installer.SetToolchain(toolchain))
+			defer func() { assert.Nil(installer.SetToolchain("")) }()
+			assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+			packHomeDir := filepath.Join(localTestingDir, "TheVendor", "ToolchainPack", "1.2.3")
+			skippedFiles, err := os.ReadFile(filepath.Join(packHomeDir, cryptography.SkippedFilesName))
+			assert.Nil(err)
+			assert.Equal(skipped, string(skippedFiles))
+			for _, name := range toolchainPackFiles {
+				_, err := os.Stat(filepath.Join(packHomeDir, name))
+				assert.Equal(err != nil, slices.Contains(strings.Split(string(skippedFiles), "\n"), name), name)
+			}
+
+			sparsePacks, err := os.ReadFile(filepath.Join(installer.Installation.LocalDir, installer.SparsePacksName))
+			assert.Nil(err)
+			assert.Contains(string(sparsePacks), `"toolchain": "`)
+		})
+	}
+
+	t.Run("test refusing unknown toolchains", func(t *testing.T) {
+		assert.Equal(errs.ErrIncorrectCmdArgs, installer.SetToolchain("Tasking"))
+	})
+}
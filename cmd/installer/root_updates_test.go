@@ -0,0 +1,138 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackUpdates(t *testing.T) {
+
+	assert := assert.New(t)
+
+	zipContent, err := os.ReadFile(publicRemotePack123)
+	assert.Nil(err)
+
+	// setUp installs version 1.2.3 of the remote pack, whose PDSC file in
+	// .Web/ lists 1.2.4 as the latest release, served by the returned server
+	setUp := func(t *testing.T, localTestingDir string) Server {
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+
+		packServer := NewServer()
+		packServer.AddRoute("*", zipContent)
+
+		pdscContent, err := os.ReadFile(filepath.Join(testDir, "public_index", publicRemotePackPackID+".pdsc"))
+		assert.Nil(err)
+		pdscContent = []byte(strings.Replace(string(pdscContent), "<url></url>", "<url>"+packServer.URL()+"</url>", 1))
+		assert.Nil(os.WriteFile(filepath.Join(installer.Installation.WebDir, publicRemotePackPackID+".pdsc"), pdscContent, 0600))
+
+		assert.Nil(installer.AddPack(packServer.URL()+filepath.Base(publicRemotePack123), !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		return packServer
+	}
+
+	t.Run("test finding updates of installed packs", func(t *testing.T) {
+		localTestingDir := "test-finding-updates-of-installed-packs"
+		defer removePackRoot(localTestingDir)
+		packServer := setUp(t, localTestingDir)
+
+		updates, err := installer.FindPackUpdates("", Timeout)
+		assert.Nil(err)
+		assert.Len(updates, 1)
+		update := updates[0]
+		assert.Equal("TheVendor::PublicRemotePack", update.Pack)
+		assert.Equal("1.2.3", update.Version)
+		assert.Equal("1.2.4", update.LatestVersion)
+		assert.Equal("New release 2.", update.ReleaseNotes)
+		assert.Equal([]installer.PackRelease{{Version: "1.2.4", Date: "2016-09-14", Description: "New release 2."}}, update.Releases)
+		assert.Equal(packServer.URL()+publicRemotePackPackID+".1.2.4.pack", update.URL)
+		assert.Equal(int64(len(zipContent)), update.DownloadSize)
+		assert.False(update.Cached)
+	})
+
+	t.Run("test finding updates of packs matching a pattern", func(t *testing.T) {
+		localTestingDir := "test-finding-updates-of-packs-matching-a-pattern"
+		defer removePackRoot(localTestingDir)
+		setUp(t, localTestingDir)
+
+		updates, err := installer.FindPackUpdates("thevendor::*", Timeout)
+		assert.Nil(err)
+		assert.Len(updates, 1)
+
+		updates, err = installer.FindPackUpdates("OtherVendor::*", Timeout)
+		assert.Nil(err)
+		assert.Empty(updates)
+	})
+
+	t.Run("test telling the size of cached updates", func(t *testing.T) {
+		localTestingDir := "test-telling-the-size-of-cached-updates"
+		defer removePackRoot(localTestingDir)
+		setUp(t, localTestingDir)
+
+		cachedContent := append(zipContent, zipContent...)
+		assert.Nil(os.WriteFile(filepath.Join(installer.Installation.DownloadDir, publicRemotePackPackID+".1.2.4.pack"), cachedContent, 0600))
+
+		updates, err := installer.FindPackUpdates("", Timeout)
+		assert.Nil(err)
+		assert.Len(updates, 1)
+		assert.True(updates[0].Cached)
+		assert.Equal(int64(len(cachedContent)), updates[0].DownloadSize)
+	})
+
+	t.Run("test showing release notes when updating", func(t *testing.T) {
+		localTestingDir := "test-showing-release-notes-when-updating"
+		defer removePackRoot(localTestingDir)
+		packServer := setUp(t, localTestingDir)
+
+		// Version 1.2.4 of the pack has the PDSC file of .Web/ in it
+		pdscContent, err := os.ReadFile(filepath.Join(installer.Installation.WebDir, publicRemotePackPackID+".pdsc"))
+		assert.Nil(err)
+		var pack124 bytes.Buffer
+		w := zip.NewWriter(&pack124)
+		for name, content := range map[string][]byte{publicRemotePackPackID + ".pdsc": pdscContent, "sample_file": nil} {
+			writer, err := w.Create(name)
+			assert.Nil(err)
+			_, err = writer.Write(content)
+			assert.Nil(err)
+		}
+		assert.Nil(w.Close())
+		packServer.AddRoute(publicRemotePackPackID+".1.2.4.pack", pack124.Bytes())
+
+		notifications := []map[string]interface{}{}
+		webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			notification := map[string]interface{}{}
+			assert.Nil(json.NewDecoder(r.Body).Decode(&notification))
+			notifications = append(notifications, notification)
+		}))
+		defer webhookServer.Close()
+		installer.SetWebhook(webhookServer.URL)
+		defer installer.SetWebhook("")
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(io.Discard)
+
+		assert.Nil(installer.UpdatePack(publicRemotePackPackID, !CheckEula, NoRequirements, Timeout))
+		assert.Contains(buf.String(), "Changes in TheVendor.PublicRemotePack since 1.2.3:")
+		assert.Contains(buf.String(), "1.2.4 (2016-09-14)")
+		assert.Contains(buf.String(), "New release 2.")
+
+		assert.Len(notifications, 1)
+		assert.Equal("success", notifications[0]["result"])
+		assert.Equal([]interface{}{map[string]interface{}{"version": "1.2.4", "date": "2016-09-14", "description": "New release 2."}}, notifications[0]["releases"])
+	})
+}
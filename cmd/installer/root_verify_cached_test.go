@@ -0,0 +1,79 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyCachedPacks(t *testing.T) {
+
+	assert := assert.New(t)
+
+	zipContent, err := os.ReadFile(publicRemotePack123)
+	assert.Nil(err)
+	otherContent, err := os.ReadFile(publicLocalPack123)
+	assert.Nil(err)
+
+	// setUp installs the remote pack, then replaces its file in .Download/
+	// with another pack, as a disk corrupting the cache would
+	setUp := func(t *testing.T, localTestingDir string) (string, string) {
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		installer.Installation.WebDir = filepath.Join(testDir, "public_index")
+
+		packServer := NewServer()
+		packServer.AddRoute("*", zipContent)
+		packURL := packServer.URL() + filepath.Base(publicRemotePack123)
+		assert.Nil(installer.AddPack(packURL, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		cachedPath := filepath.Join(installer.Installation.DownloadDir, filepath.Base(publicRemotePack123))
+		digest, err := utils.FileSHA256(cachedPath)
+		assert.Nil(err)
+		recorded, err := os.ReadFile(cachedPath + ".sha256")
+		assert.Nil(err)
+		assert.Equal(digest+"  "+filepath.Base(cachedPath)+"\n", string(recorded))
+
+		utils.UnsetReadOnly(cachedPath)
+		assert.Nil(os.WriteFile(cachedPath, otherContent, 0600))
+		return packURL, cachedPath
+	}
+
+	t.Run("test downloading a changed cached pack again", func(t *testing.T) {
+		localTestingDir := "test-downloading-a-changed-cached-pack-again"
+		defer removePackRoot(localTestingDir)
+		packURL, cachedPath := setUp(t, localTestingDir)
+
+		installer.SetVerifyCachedPacks(true)
+		defer installer.SetVerifyCachedPacks(false)
+		assert.Nil(installer.AddPack(packURL, !CheckEula, !ExtractEula, ForceReinstall, !NoRequirements, Timeout))
+
+		cached, err := os.ReadFile(cachedPath)
+		assert.Nil(err)
+		assert.Equal(zipContent, cached)
+
+		stats, err := installer.GetCacheStats()
+		assert.Nil(err)
+		assert.Equal(0, stats.Hits)
+		assert.Equal(2, stats.Misses)
+	})
+
+	t.Run("test installing a changed cached pack without verifying it", func(t *testing.T) {
+		localTestingDir := "test-installing-a-changed-cached-pack-without-verifying-it"
+		defer removePackRoot(localTestingDir)
+		packURL, cachedPath := setUp(t, localTestingDir)
+
+		assert.NotNil(installer.AddPack(packURL, !CheckEula, !ExtractEula, ForceReinstall, !NoRequirements, Timeout))
+
+		cached, err := os.ReadFile(cachedPath)
+		assert.Nil(err)
+		assert.Equal(otherContent, cached)
+	})
+}
@@ -0,0 +1,100 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeDevelopmentPdsc writes a PDSC file of TheVendor.DevPack whose latest release is version
+func writeDevelopmentPdsc(t *testing.T, pdscPath, version string) {
+	pdsc := `<package><vendor>TheVendor</vendor><name>DevPack</name>
+  <releases><release version="` + version + `"/><release version="1.0.0"/></releases>
+</package>`
+	assert.Nil(t, os.WriteFile(pdscPath, []byte(pdsc), 0600))
+}
+
+// localPdscVersions returns the versions of TheVendor.DevPack in .Local/local_repository.pidx
+func localPdscVersions(t *testing.T) []string {
+	localPidx := xml.NewPidxXML(filepath.Join(installer.Installation.LocalDir, "local_repository.pidx"))
+	assert.Nil(t, localPidx.Read())
+	versions := []string{}
+	for _, tag := range localPidx.FindPdscTags(xml.PdscTag{Vendor: "TheVendor", Name: "DevPack"}) {
+		versions = append(versions, tag.Version)
+	}
+	return versions
+}
+
+func TestWatchPdscs(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test refreshing a pdsc with a new release", func(t *testing.T) {
+		localTestingDir := "test-refreshing-a-pdsc-with-a-new-release"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		sourceDir := localTestingDir + "-source"
+		assert.Nil(os.MkdirAll(sourceDir, 0700))
+		defer os.RemoveAll(sourceDir)
+		pdscPath := filepath.Join(sourceDir, "TheVendor.DevPack.pdsc")
+
+		writeDevelopmentPdsc(t, pdscPath, "1.0.0")
+		assert.Nil(installer.AddPdsc(pdscPath))
+		assert.Equal([]string{"1.0.0"}, localPdscVersions(t))
+
+		writeDevelopmentPdsc(t, pdscPath, "1.1.0-dev")
+		assert.Nil(installer.RefreshPdsc(pdscPath))
+		assert.Equal([]string{"1.1.0-dev"}, localPdscVersions(t))
+		assert.True(utils.FileExists(installer.Installation.PackIdx))
+
+		// Refreshing without changes keeps the registration
+		assert.Nil(installer.RefreshPdsc(pdscPath))
+		assert.Equal([]string{"1.1.0-dev"}, localPdscVersions(t))
+	})
+
+	t.Run("test watching a pdsc", func(t *testing.T) {
+		localTestingDir := "test-watching-a-pdsc"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		sourceDir := localTestingDir + "-source"
+		assert.Nil(os.MkdirAll(filepath.Join(sourceDir, ".git"), 0700))
+		defer os.RemoveAll(sourceDir)
+		pdscPath := filepath.Join(sourceDir, "TheVendor.DevPack.pdsc")
+
+		writeDevelopmentPdsc(t, pdscPath, "1.0.0")
+		assert.Nil(installer.AddPdsc(pdscPath))
+
+		polls := 0
+		utils.ShouldAbortFunction = func() bool {
+			polls++
+			switch polls {
+			case 1:
+				// Hidden folders are not watched
+				assert.Nil(os.WriteFile(filepath.Join(sourceDir, ".git", "index"), []byte("changed"), 0600))
+			case 2:
+				assert.Equal([]string{"1.0.0"}, localPdscVersions(t))
+				writeDevelopmentPdsc(t, pdscPath, "1.1.0-dev")
+			}
+			return polls > 3
+		}
+		defer func() {
+			utils.ShouldAbortFunction = nil
+		}()
+
+		assert.Nil(installer.WatchPdscs([]string{pdscPath}, 10*time.Millisecond))
+		assert.Equal([]string{"1.1.0-dev"}, localPdscVersions(t))
+	})
+}
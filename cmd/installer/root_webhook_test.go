@@ -0,0 +1,82 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test notifying the webhook of added and removed packs", func(t *testing.T) {
+		localTestingDir := "test-notifying-the-webhook-of-added-and-removed-packs"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		notifications := []map[string]string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			notification := map[string]string{}
+			assert.Nil(json.NewDecoder(r.Body).Decode(&notification))
+			assert.Equal("application/json", r.Header.Get("Content-Type"))
+			notifications = append(notifications, notification)
+		}))
+		defer server.Close()
+
+		installer.SetWebhook(server.URL)
+		defer installer.SetWebhook("")
+
+		addPack(t, publicLocalPack123, ConfigType{})
+		removePack(t, publicLocalPack123, true, NotPublic, false)
+
+		hostname, _ := os.Hostname()
+		assert.Equal(2, len(notifications))
+		assert.Equal(map[string]string{
+			"operation": "add",
+			"pack":      "TheVendor::PublicLocalPack",
+			"version":   "1.2.3",
+			"host":      hostname,
+			"result":    "success",
+		}, notifications[0])
+		assert.Equal("remove", notifications[1]["operation"])
+		assert.Equal("success", notifications[1]["result"])
+	})
+
+	t.Run("test notifying the webhook of a declined license", func(t *testing.T) {
+		localTestingDir := "test-notifying-the-webhook-of-a-declined-license"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+
+		notifications := []map[string]string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			notification := map[string]string{}
+			assert.Nil(json.NewDecoder(r.Body).Decode(&notification))
+			notifications = append(notifications, notification)
+		}))
+		defer server.Close()
+
+		installer.SetWebhook(server.URL)
+		defer installer.SetWebhook("")
+
+		ui.LicenseAgreed = &ui.Disagreed
+		defer func() { ui.LicenseAgreed = nil }()
+
+		assert.Nil(installer.AddPack(packWithLicense, CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		assert.Equal(1, len(notifications))
+		assert.Equal("declined", notifications[0]["result"])
+		assert.Equal("EULA_DECLINED", notifications[0]["code"])
+	})
+}
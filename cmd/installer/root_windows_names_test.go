@@ -0,0 +1,113 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer_test
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var windowsNamesPackPdsc = `<?xml version="1.0" encoding="UTF-8"?>
+<package>
+  <vendor>TheVendor</vendor>
+  <name>WindowsNamesPack</name>
+  <url>http://vendor.com/packs/</url>
+  <releases>
+    <release version="1.2.3">Initial release.</release>
+  </releases>
+</package>
+`
+
+// writeWindowsNamesPack writes TheVendor.WindowsNamesPack.1.2.3.pack, some
+// files of which have names Windows refuses, to dir
+func writeWindowsNamesPack(t *testing.T, dir string) string {
+	assert := assert.New(t)
+
+	packPath := filepath.Join(dir, "TheVendor.WindowsNamesPack.1.2.3.pack")
+	out, err := os.Create(packPath)
+	assert.Nil(err)
+	w := zip.NewWriter(out)
+	for _, name := range []string{"TheVendor.WindowsNamesPack.pdsc", "Include/aux.h", "Docs/notes.", "Docs/a:b.txt"} {
+		writer, err := w.Create(name)
+		assert.Nil(err)
+		_, err = writer.Write([]byte(windowsNamesPackPdsc))
+		assert.Nil(err)
+	}
+	assert.Nil(w.Close())
+	assert.Nil(out.Close())
+	return packPath
+}
+
+func TestAddPackWindowsNames(t *testing.T) {
+
+	assert := assert.New(t)
+
+	t.Run("test escaping file names invalid on windows", func(t *testing.T) {
+		localTestingDir := "test-escaping-file-names-invalid-on-windows"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		packPath := writeWindowsNamesPack(t, localTestingDir)
+
+		defer utils.SetEscapeWindowsNames(utils.GetEscapeWindowsNames())
+		utils.SetEscapeWindowsNames(true)
+
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		packHomeDir := filepath.Join(localTestingDir, "TheVendor", "WindowsNamesPack", "1.2.3")
+		assert.FileExists(filepath.Join(packHomeDir, "Include", "au%78.h"))
+		assert.FileExists(filepath.Join(packHomeDir, "Docs", "notes%2E"))
+		assert.FileExists(filepath.Join(packHomeDir, "Docs", "a%3Ab.txt"))
+
+		b, err := os.ReadFile(filepath.Join(packHomeDir, cryptography.RenamedFilesName))
+		assert.Nil(err)
+		renamed := map[string]string{}
+		assert.Nil(json.Unmarshal(b, &renamed))
+		assert.Equal(map[string]string{
+			"Include/aux.h": "Include/au%78.h",
+			"Docs/notes.":   "Docs/notes%2E",
+			"Docs/a:b.txt":  "Docs/a%3Ab.txt",
+		}, renamed)
+	})
+
+	t.Run("test verifying the embedded checksum of escaped files", func(t *testing.T) {
+		localTestingDir := "test-verifying-the-embedded-checksum-of-escaped-files"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		packPath := writeWindowsNamesPack(t, localTestingDir)
+		assert.Nil(cryptography.EmbedChecksum(packPath, packPath, "sha256"))
+
+		defer utils.SetEscapeWindowsNames(utils.GetEscapeWindowsNames())
+		utils.SetEscapeWindowsNames(true)
+
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+		assert.FileExists(filepath.Join(localTestingDir, "TheVendor", "WindowsNamesPack", "1.2.3", "Include", "au%78.h"))
+	})
+
+	t.Run("test keeping file names when not escaping", func(t *testing.T) {
+		localTestingDir := "test-keeping-file-names-when-not-escaping"
+		assert.Nil(installer.SetPackRoot(localTestingDir, CreatePackRoot))
+		installer.UnlockPackRoot()
+		defer removePackRoot(localTestingDir)
+		packPath := writeWindowsNamesPack(t, localTestingDir)
+
+		defer utils.SetEscapeWindowsNames(utils.GetEscapeWindowsNames())
+		utils.SetEscapeWindowsNames(false)
+
+		assert.Nil(installer.AddPack(packPath, !CheckEula, !ExtractEula, !ForceReinstall, !NoRequirements, Timeout))
+
+		packHomeDir := filepath.Join(localTestingDir, "TheVendor", "WindowsNamesPack", "1.2.3")
+		assert.FileExists(filepath.Join(packHomeDir, "Include", "aux.h"))
+		assert.NoFileExists(filepath.Join(packHomeDir, cryptography.RenamedFilesName))
+	})
+}
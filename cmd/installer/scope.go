@@ -0,0 +1,59 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// Scopes packs are installed in: ScopeUser installs into the pack root of
+// the user, ScopeSystem into the machine-wide one set with SetSystemPackRoot
+const (
+	ScopeUser   = "user"
+	ScopeSystem = "system"
+)
+
+// systemPackRoot is the machine-wide pack root, managed by administrators
+var systemPackRoot string
+
+// SetSystemPackRoot sets the machine-wide pack root, "" for none. Packs
+// installed there count as installed in any other pack root, which never
+// installs into or removes from it, like the other pack roots of a search
+// path in CMSIS_PACK_ROOT.
+func SetSystemPackRoot(packRoot string) {
+	if packRoot != "" {
+		packRoot = filepath.Clean(packRoot)
+	}
+	systemPackRoot = packRoot
+}
+
+func GetSystemPackRoot() string {
+	return systemPackRoot
+}
+
+// withSystemPackRoot appends the machine-wide pack root, if any and it
+// exists, to extraPackRoots, the other pack roots looked into besides packRoot
+func withSystemPackRoot(packRoot string, extraPackRoots []string) []string {
+	if systemPackRoot == "" || systemPackRoot == packRoot || !utils.DirExists(systemPackRoot) {
+		return extraPackRoots
+	}
+	for _, extraPackRoot := range extraPackRoots {
+		if extraPackRoot == systemPackRoot {
+			return extraPackRoots
+		}
+	}
+	return append(extraPackRoots, systemPackRoot)
+}
+
+// isSystemPack tells whether pdscPath belongs to a pack installed in the
+// machine-wide pack root while installing into another one
+func isSystemPack(pdscPath string) bool {
+	if systemPackRoot == "" || Installation.PackRoot == systemPackRoot {
+		return false
+	}
+	return strings.HasPrefix(filepath.Clean(pdscPath), systemPackRoot+string(filepath.Separator))
+}
@@ -0,0 +1,290 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"golang.org/x/mod/semver"
+)
+
+// DefaultReleaseFeed is where the latest release of cpackget is looked up
+const DefaultReleaseFeed = "https://api.github.com/repos/Open-CMSIS-Pack/cpackget/releases/latest"
+
+// Release is a release of cpackget, as listed by the GitHub releases API
+type Release struct {
+	// Version is the tag of the release, e.g. v2.1.0
+	Version string `json:"tag_name"`
+
+	Assets []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file published with a release
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// LatestRelease reads the latest release of cpackget from feedURL, in the
+// format of the GitHub releases API
+func LatestRelease(feedURL string, timeout int) (*Release, error) {
+	dir, err := os.MkdirTemp("", "cpackget-release-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	feedPath := filepath.Join(dir, "release.json")
+	if err := utils.DownloadFileToContext(operationContext, feedURL, feedPath, timeout); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(feedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	release := &Release{}
+	if err := json.Unmarshal(b, release); err != nil || !semver.IsValid(release.version()) {
+		log.Errorf("\"%s\" does not list a release of cpackget", utils.RedactURL(feedURL))
+		return nil, errs.ErrReleaseNotFound
+	}
+	return release, nil
+}
+
+// IsNewerThan tells whether r is newer than version. Releases are always
+// newer than development builds, whose version is not a release's.
+func (r *Release) IsNewerThan(version string) bool {
+	current := "v" + strings.TrimPrefix(version, "v")
+	if !semver.IsValid(current) {
+		return true
+	}
+	return semver.Compare(r.version(), current) > 0
+}
+
+// version returns the semantic version of r, with its v prefix
+func (r *Release) version() string {
+	return "v" + strings.TrimPrefix(r.Version, "v")
+}
+
+// asset returns the asset of r named name
+func (r *Release) asset(name string) (ReleaseAsset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return ReleaseAsset{}, false
+}
+
+// archiveName returns the name of the archive of r holding the binary
+// for this platform, as named by goreleaser
+func (r *Release) archiveName() string {
+	extension := ".tar.gz"
+	if runtime.GOOS == "windows" {
+		extension = ".zip"
+	}
+	return fmt.Sprintf("cpackget_%s_%s_%s%s", strings.TrimPrefix(r.Version, "v"), runtime.GOOS, runtime.GOARCH, extension)
+}
+
+// SelfUpdate replaces the cpackget binary in executable with the one of
+// release for this platform. The archive holding it is verified against the
+// checksums file of the release, whose detached PGP signature, ".sig" next to
+// it, is verified with the public key in releaseKey, if given. The binary is
+// replaced atomically: either the new one is in place or the old one is kept.
+func SelfUpdate(release *Release, executable, releaseKey string, timeout int) error {
+	archive, ok := release.asset(release.archiveName())
+	if !ok {
+		log.Errorf("Release %s has no \"%s\"", release.Version, release.archiveName())
+		return errs.ErrReleaseNotFound
+	}
+	checksumsName := fmt.Sprintf("cpackget_%s_checksums.txt", strings.TrimPrefix(release.Version, "v"))
+	checksums, ok := release.asset(checksumsName)
+	if !ok {
+		log.Errorf("Release %s has no \"%s\" to verify \"%s\" with", release.Version, checksumsName, archive.Name)
+		return errs.ErrReleaseNotFound
+	}
+
+	dir, err := os.MkdirTemp("", "cpackget-release-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	checksumsPath := filepath.Join(dir, checksums.Name)
+	if err := utils.DownloadFileToContext(operationContext, checksums.URL, checksumsPath, timeout); err != nil {
+		return err
+	}
+	if releaseKey != "" {
+		signature, ok := release.asset(checksumsName + ".sig")
+		if !ok {
+			log.Errorf("Release %s has no \"%s.sig\"", release.Version, checksumsName)
+			return errs.ErrUnsignedRelease
+		}
+		signaturePath := filepath.Join(dir, signature.Name)
+		if err := utils.DownloadFileToContext(operationContext, signature.URL, signaturePath, timeout); err != nil {
+			return err
+		}
+		if err := cryptography.VerifyReleaseSignature(checksumsPath, signaturePath, releaseKey); err != nil {
+			return err
+		}
+		log.Info("Release signature verification success - release is authentic")
+	} else {
+		log.Warnf("The signature of release %s is not verified, only its checksum, see --release-key", release.Version)
+	}
+
+	expected, err := releaseChecksum(checksumsPath, archive.Name)
+	if err != nil {
+		return err
+	}
+	archivePath := filepath.Join(dir, archive.Name)
+	if err := utils.DownloadFileToContext(operationContext, archive.URL, archivePath, timeout); err != nil {
+		return err
+	}
+	digest, err := utils.FileSHA256(archivePath)
+	if err != nil {
+		return err
+	}
+	if digest != expected {
+		log.Errorf("\"%s\" does not match its checksum: expected %s, got %s", archive.Name, expected, digest)
+		return errs.ErrIntegrityCheckFailed
+	}
+
+	// The new binary is written next to the old one to be renamed over it
+	newPath := executable + ".new"
+	if err := extractBinary(archivePath, newPath); err != nil {
+		os.Remove(newPath)
+		return err
+	}
+	if err := replaceExecutable(executable, newPath); err != nil {
+		os.Remove(newPath)
+		return err
+	}
+	log.Infof("Updated \"%s\" to cpackget %s", executable, release.Version)
+	return nil
+}
+
+// releaseChecksum returns the SHA-256 digest of name listed in the checksums
+// file in checksumsPath, in the format of sha256sum
+func releaseChecksum(checksumsPath, name string) (string, error) {
+	file, err := os.Open(checksumsPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	log.Errorf("\"%s\" is not listed in the checksums of the release", name)
+	return "", errs.ErrIntegrityCheckFailed
+}
+
+// extractBinary extracts the cpackget binary of the release archive in
+// archivePath, a .zip or .tar.gz file, to binaryPath
+func extractBinary(archivePath, binaryPath string) error {
+	binaryName := "cpackget"
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+
+	out, err := os.OpenFile(binaryPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755) // #nosec
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if strings.HasSuffix(archivePath, ".zip") {
+		z, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return err
+		}
+		defer z.Close()
+		for _, file := range z.File {
+			if path.Base(file.Name) != binaryName || file.FileInfo().IsDir() {
+				continue
+			}
+			reader, err := file.Open()
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+			_, err = utils.SecureCopy(out, reader)
+			return err
+		}
+	} else {
+		in, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return err
+		}
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if path.Base(header.Name) == binaryName && header.Typeflag == tar.TypeReg {
+				_, err = utils.SecureCopy(out, tr)
+				return err
+			}
+		}
+	}
+
+	log.Errorf("\"%s\" holds no %s binary", filepath.Base(archivePath), binaryName)
+	return errs.ErrReleaseNotFound
+}
+
+// replaceExecutable renames newPath over executable. Windows does not allow
+// that while it runs, so the old binary is renamed out of the way first, and
+// left as ".old" until the next update.
+func replaceExecutable(executable, newPath string) error {
+	if err := os.Chmod(newPath, 0755); err != nil { // #nosec
+		return err
+	}
+	if runtime.GOOS != "windows" {
+		return os.Rename(newPath, executable)
+	}
+
+	oldPath := executable + ".old"
+	os.Remove(oldPath)
+	if err := os.Rename(executable, oldPath); err != nil {
+		return err
+	}
+	if err := os.Rename(newPath, executable); err != nil {
+		if restoreErr := os.Rename(oldPath, executable); restoreErr != nil {
+			log.Errorf("Can't restore \"%s\" from \"%s\": %s", executable, oldPath, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
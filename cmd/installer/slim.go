@@ -0,0 +1,248 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/spf13/afero"
+)
+
+// SlimCategories are the categories of component files slim installs skip
+var SlimCategories = []string{"doc"}
+
+// slimInstall makes install skip documentation and examples
+var slimInstall bool
+
+// SetSlim makes the following installations skip the files of packs
+// their PDSC file tells are documentation, i.e. component files of one of
+// SlimCategories and board books, and examples. Skipped files are listed
+// in cryptography.SkippedFilesName next to the PDSC file.
+func SetSlim(slim bool) {
+	slimInstall = slim
+}
+
+// includeComponents and excludeComponents select the components installations keep
+var includeComponents, excludeComponents []string
+
+// SetComponentFilters makes the following installations skip the files of
+// the components not matching any of the include patterns, if any, or
+// matching one of the exclude patterns. Patterns are Cclass[:Cgroup[:Csub]]
+// globs, case insensitive, e.g. "CMSIS:DSP" or "Device:*". Files other
+// components use as well are kept.
+func SetComponentFilters(include, exclude []string) {
+	includeComponents = include
+	excludeComponents = exclude
+}
+
+// SparseSelection tells what installations of a pack leave out
+type SparseSelection struct {
+	// Slim skips the pack's documentation and examples, see SetSlim
+	Slim bool `json:"slim,omitempty"`
+
+	// IncludeComponents and ExcludeComponents filter the pack's components, see SetComponentFilters
+	IncludeComponents []string `json:"includeComponents,omitempty"`
+	ExcludeComponents []string `json:"excludeComponents,omitempty"`
+
+	// Toolchain skips the files only other toolchains use, see SetToolchain
+	Toolchain string `json:"toolchain,omitempty"`
+}
+
+// sparseSelection returns the selection set with SetSlim, SetComponentFilters and SetToolchain
+func sparseSelection() SparseSelection {
+	return SparseSelection{Slim: slimInstall, IncludeComponents: includeComponents, ExcludeComponents: excludeComponents, Toolchain: installToolchain}
+}
+
+// IsFull tells whether the selection keeps every file of the pack
+func (s SparseSelection) IsFull() bool {
+	return !s.Slim && !s.filtersComponents() && s.Toolchain == ""
+}
+
+func (s SparseSelection) filtersComponents() bool {
+	return len(s.IncludeComponents) > 0 || len(s.ExcludeComponents) > 0
+}
+
+// keepsComponent tells whether the component of class, group and sub passes the component filters
+func (s SparseSelection) keepsComponent(class, group, sub string) (bool, error) {
+	matchesAny := func(patterns []string) (bool, error) {
+		for _, pattern := range patterns {
+			parts := strings.SplitN(pattern, ":", 3)
+			values := []string{class, group, sub}
+			matched := true
+			for i, part := range parts {
+				partMatches, err := matchPattern(part, values[i])
+				if err != nil {
+					log.Errorf("Bad component pattern \"%s\": %s", pattern, err)
+					return false, err
+				}
+				matched = matched && partMatches
+			}
+			if matched {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if len(s.IncludeComponents) > 0 {
+		included, err := matchesAny(s.IncludeComponents)
+		if err != nil || !included {
+			return false, err
+		}
+	}
+	excluded, err := matchesAny(s.ExcludeComponents)
+	return !excluded, err
+}
+
+// SparsePacksName is the file in .Local/ holding the SparseSelection of
+// the packs added with SetSlim, SetComponentFilters or SetToolchain, by Vendor.Pack, which
+// UpdatePack keeps applying
+const SparsePacksName = "sparse_packs.json"
+
+// sparsePacks returns the selections of the packs installed sparse by Vendor.Pack
+func sparsePacks() (map[string]SparseSelection, error) {
+	packs := map[string]SparseSelection{}
+	sparsePacksPath := filepath.Join(Installation.LocalDir, SparsePacksName)
+	if !utils.FileExists(sparsePacksPath) {
+		return packs, nil
+	}
+	b, err := afero.ReadFile(utils.GetFileSystem(), sparsePacksPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &packs); err != nil {
+		log.Errorf("Can't parse \"%s\": %s", sparsePacksPath, err)
+		return nil, err
+	}
+	return packs, nil
+}
+
+// rememberSparse records the selection the pack was installed with, for UpdatePack to apply it again
+func (p *PackType) rememberSparse() error {
+	packs, err := sparsePacks()
+	if err != nil {
+		return err
+	}
+	packID := p.Vendor + "." + p.Name
+	if _, ok := packs[packID]; !ok && p.sparse.IsFull() {
+		return nil
+	}
+	if p.sparse.IsFull() {
+		delete(packs, packID)
+	} else {
+		packs[packID] = p.sparse
+	}
+
+	b, err := json.MarshalIndent(packs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(filepath.Join(Installation.LocalDir, SparsePacksName), append(b, '\n'), utils.FileModeRW)
+}
+
+// sparseSkippedPaths returns the files and folders of the pack, relative to
+// its PDSC file with forward slashes, that its sparse selection skips
+func (p *PackType) sparseSkippedPaths() (map[string]bool, error) {
+	skipped := map[string]bool{}
+	kept := map[string]bool{}
+	add := func(paths map[string]bool, name string) {
+		name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+		if name == "." || name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return
+		}
+		paths[name] = true
+	}
+
+	var conditions *toolchainConditions
+	if p.sparse.Toolchain != "" {
+		conditions = newToolchainConditions(p.sparse.Toolchain, p.Pdsc)
+	}
+	addComponents := func(bundleClass string, components []xml.ComponentTag) error {
+		for _, component := range components {
+			keep := conditions == nil || !conditions.excludes(component.Condition)
+			if keep && p.sparse.filtersComponents() {
+				class := component.Class
+				if class == "" {
+					class = bundleClass
+				}
+				var err error
+				if keep, err = p.sparse.keepsComponent(class, component.Group, component.Sub); err != nil {
+					return err
+				}
+			}
+			for _, file := range component.Files {
+				if !keep || (p.sparse.Slim && slices.Contains(SlimCategories, file.Category)) ||
+					(conditions != nil && conditions.excludes(file.Condition)) {
+					add(skipped, file.Name)
+				} else {
+					add(kept, file.Name)
+				}
+			}
+		}
+		return nil
+	}
+	if err := addComponents("", p.Pdsc.ComponentsTag.Components); err != nil {
+		return nil, err
+	}
+	for _, bundle := range p.Pdsc.ComponentsTag.Bundles {
+		if err := addComponents(bundle.Class, bundle.Components); err != nil {
+			return nil, err
+		}
+	}
+	if p.sparse.Slim {
+		for _, board := range p.Pdsc.BoardsTag.Boards {
+			for _, book := range board.Books {
+				add(skipped, book.Name)
+			}
+		}
+		for _, example := range p.Pdsc.ExamplesTag.Examples {
+			add(skipped, example.Folder)
+		}
+	}
+
+	// Files shared with kept components stay
+	for name := range kept {
+		delete(skipped, name)
+	}
+	// Never skip what installing and verifying the pack needs
+	delete(skipped, p.PdscFileName())
+	delete(skipped, path.Clean(strings.ReplaceAll(p.Pdsc.License, "\\", "/")))
+	delete(skipped, cryptography.EmbeddedChecksumDir)
+	return skipped, nil
+}
+
+// entryName returns the name of the zip entry called fileName relative to
+// the folder of the PDSC file, with forward slashes
+func (p *PackType) entryName(fileName string) string {
+	name := strings.ReplaceAll(fileName, "\\", "/")
+	if p.Subfolder != "" && p.Subfolder != "." {
+		name = strings.TrimPrefix(name, strings.ReplaceAll(p.Subfolder, "\\", "/")+"/")
+	}
+	return path.Clean(name)
+}
+
+// isSkipped tells whether the pack file called name is, or lies in, one of skipped
+func isSkipped(skipped map[string]bool, name string) bool {
+	for ; name != "." && name != "/"; name = path.Dir(name) {
+		if skipped[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSkippedFiles lists the files sparse installs skipped in packHomeDir
+func writeSkippedFiles(packHomeDir string, names []string) error {
+	sort.Strings(names)
+	return afero.WriteFile(utils.GetFileSystem(), filepath.Join(packHomeDir, cryptography.SkippedFilesName), []byte(strings.Join(names, "\n")+"\n"), utils.FileModeRW)
+}
@@ -0,0 +1,140 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// ContentStoreDirName is the folder of the pack root that, if it exists, holds
+// the files of installed packs once, named by their SHA-256 hash. Identical
+// files of packs are hard links to the same file in it.
+const ContentStoreDirName = ".Store"
+
+// storeFiles replaces the files extracted to packHomeDir with hard links to
+// the same files in the content store, adding the ones it doesn't hold yet
+func storeFiles(packHomeDir string) error {
+	if !utils.DirExists(Installation.StoreDir) {
+		return nil
+	}
+	if !utils.CanHardLink() {
+		log.Debugf("Not adding the files of \"%s\" to the content store, the file system cannot link files", packHomeDir)
+		return nil
+	}
+
+	shared := 0
+	var sharedBytes uint64
+	err := afero.Walk(utils.GetFileSystem(), packHomeDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		// Files linked already are in the store
+		if links, err := utils.LinkCount(filePath); err != nil || links > 1 {
+			return err
+		}
+
+		digest, err := utils.FileSHA256(filePath)
+		if err != nil {
+			return err
+		}
+		storePath := filepath.Join(Installation.StoreDir, digest[:2], digest)
+		if !utils.FileExists(storePath) {
+			if err := utils.EnsureDir(filepath.Dir(storePath)); err != nil {
+				return err
+			}
+			return utils.HardLink(filePath, storePath)
+		}
+
+		if err := utils.GetFileSystem().Remove(filePath); err != nil {
+			return err
+		}
+		if err := utils.HardLink(storePath, filePath); err != nil {
+			return err
+		}
+		shared++
+		sharedBytes += uint64(info.Size())
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Can't add the files of \"%s\" to the content store: %s", packHomeDir, err)
+		return err
+	}
+
+	if shared > 0 {
+		log.Infof("Shared %d file(s) of %s with other packs in the content store", shared, utils.FormatBytes(sharedBytes))
+	}
+	return nil
+}
+
+// storedFiles returns the files of the content store that files in dir are
+// hard links to, for releaseStoredFiles to prune once dir is removed
+func storedFiles(dir string) ([]string, error) {
+	if !utils.DirExists(Installation.StoreDir) || !utils.CanHardLink() || !utils.DirExists(dir) {
+		return nil, nil
+	}
+
+	storePaths := []string{}
+	err := afero.Walk(utils.GetFileSystem(), dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if links, err := utils.LinkCount(filePath); err != nil || links < 2 {
+			return err
+		}
+
+		digest, err := utils.FileSHA256(filePath)
+		if err != nil {
+			return err
+		}
+		storePath := filepath.Join(Installation.StoreDir, digest[:2], digest)
+		if utils.FileExists(storePath) {
+			storePaths = append(storePaths, storePath)
+		}
+		return nil
+	})
+	return storePaths, err
+}
+
+// releaseStoredFiles removes the files of the content store in storePaths,
+// see storedFiles, that no pack links to anymore. The others are set
+// read-only again, as removing one of their links may have cleared it.
+func releaseStoredFiles(storePaths []string) error {
+	fsys := utils.GetFileSystem()
+	for _, storePath := range storePaths {
+		links, err := utils.LinkCount(storePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if links > 1 {
+			utils.SetReadOnly(storePath)
+			continue
+		}
+
+		utils.UnsetReadOnly(storePath)
+		log.Debugf("Removing \"%s\" from the content store", storePath)
+		if err := fsys.Remove(storePath); err != nil {
+			return err
+		}
+		if dir := filepath.Dir(storePath); utils.IsEmpty(dir) {
+			if err := fsys.Remove(dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
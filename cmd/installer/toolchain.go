@@ -0,0 +1,170 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"sort"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+)
+
+// Toolchains are the toolchains installations can be restricted to, see
+// SetToolchain, with the Tcompiler PDSC conditions name them by
+var Toolchains = map[string]string{
+	"AC6": "ARMCC",
+	"GCC": "GCC",
+	"IAR": "IAR",
+}
+
+// installToolchain is the toolchain installations keep the files of, if any
+var installToolchain string
+
+// SetToolchain makes the following installations skip the components and
+// files whose PDSC condition only holds with other toolchains than one of
+// Toolchains, e.g. startup files for other compilers. Conditions are only
+// evaluated for their Tcompiler and Toptions attributes, components and
+// files depending on anything else are kept. Empty keeps every toolchain.
+func SetToolchain(toolchain string) error {
+	if toolchain != "" {
+		if _, ok := Toolchains[strings.ToUpper(toolchain)]; !ok {
+			names := []string{}
+			for name := range Toolchains {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			log.Errorf("Unknown toolchain \"%s\", use one of %s", toolchain, strings.Join(names, ", "))
+			return errs.ErrIncorrectCmdArgs
+		}
+	}
+	installToolchain = strings.ToUpper(toolchain)
+	return nil
+}
+
+// tristate is the value of a condition evaluated for the toolchain only,
+// unknown when it depends on the device or other components
+type tristate int
+
+const (
+	conditionUnknown tristate = iota
+	conditionHolds
+	conditionFails
+)
+
+func (t tristate) and(other tristate) tristate {
+	if t == conditionFails || other == conditionFails {
+		return conditionFails
+	}
+	if t == conditionHolds && other == conditionHolds {
+		return conditionHolds
+	}
+	return conditionUnknown
+}
+
+func (t tristate) or(other tristate) tristate {
+	if t == conditionHolds || other == conditionHolds {
+		return conditionHolds
+	}
+	if t == conditionFails && other == conditionFails {
+		return conditionFails
+	}
+	return conditionUnknown
+}
+
+func (t tristate) not() tristate {
+	switch t {
+	case conditionHolds:
+		return conditionFails
+	case conditionFails:
+		return conditionHolds
+	}
+	return conditionUnknown
+}
+
+// toolchainConditions evaluates the conditions of a PDSC file for a toolchain
+type toolchainConditions struct {
+	toolchain  string
+	conditions map[string]xml.ConditionTag
+
+	// values memoizes the conditions evaluated so far, and breaks cycles
+	values map[string]tristate
+}
+
+func newToolchainConditions(toolchain string, pdsc *xml.PdscXML) *toolchainConditions {
+	c := &toolchainConditions{
+		toolchain:  toolchain,
+		conditions: map[string]xml.ConditionTag{},
+		values:     map[string]tristate{},
+	}
+	for _, condition := range pdsc.ConditionsTag.Conditions {
+		c.conditions[condition.ID] = condition
+	}
+	return c
+}
+
+// excludes tells whether the condition id only holds with other toolchains
+func (c *toolchainConditions) excludes(id string) bool {
+	return id != "" && c.evaluate(id) == conditionFails
+}
+
+func (c *toolchainConditions) evaluate(id string) tristate {
+	if value, ok := c.values[id]; ok {
+		return value
+	}
+	condition, ok := c.conditions[id]
+	if !ok {
+		return conditionUnknown
+	}
+	c.values[id] = conditionUnknown
+
+	value := conditionHolds
+	for _, expression := range condition.Require {
+		value = value.and(c.evaluateExpression(expression))
+	}
+	if len(condition.Accept) > 0 {
+		accepted := conditionFails
+		for _, expression := range condition.Accept {
+			accepted = accepted.or(c.evaluateExpression(expression))
+		}
+		value = value.and(accepted)
+	}
+	for _, expression := range condition.Deny {
+		value = value.and(c.evaluateExpression(expression).not())
+	}
+
+	c.values[id] = value
+	return value
+}
+
+func (c *toolchainConditions) evaluateExpression(expression xml.ConditionExprTag) tristate {
+	value := conditionHolds
+	for _, attr := range expression.Attrs {
+		switch attr.Name.Local {
+		case "Tcompiler":
+			if strings.EqualFold(attr.Value, Toolchains[c.toolchain]) {
+				value = value.and(conditionHolds)
+			} else {
+				value = value.and(conditionFails)
+			}
+		case "Toptions":
+			// Arm Compiler 5 and 6 share Tcompiler="ARMCC"
+			if c.toolchain == "AC6" {
+				if strings.HasPrefix(strings.ToUpper(attr.Value), "AC6") {
+					value = value.and(conditionHolds)
+				} else {
+					value = value.and(conditionFails)
+				}
+			} else {
+				value = value.and(conditionUnknown)
+			}
+		case "condition":
+			value = value.and(c.evaluate(attr.Value))
+		default:
+			value = value.and(conditionUnknown)
+		}
+	}
+	return value
+}
@@ -0,0 +1,187 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// PackUpdate is a newer public version of an installed pack
+type PackUpdate struct {
+	// Pack is the pack in Vendor::Name format
+	Pack string `json:"pack"`
+
+	// Version is the installed version of the pack
+	Version string `json:"version"`
+
+	// LatestVersion is the latest version of the pack in the public index
+	LatestVersion string `json:"latestVersion"`
+
+	// ReleaseNotes is the description of the latest release
+	ReleaseNotes string `json:"releaseNotes"`
+
+	// Releases are the releases after the installed version, newest first
+	Releases []PackRelease `json:"releases"`
+
+	// URL is where the latest version is downloaded from
+	URL string `json:"url"`
+
+	// DownloadSize is the size in bytes of the latest version's pack file,
+	// -1 if the server does not tell
+	DownloadSize int64 `json:"downloadSize"`
+
+	// Cached tells whether the latest version is in .Download/ already
+	Cached bool `json:"cached"`
+}
+
+// PackRelease is a release listed in the PDSC file of a pack
+type PackRelease struct {
+	Version     string `json:"version"`
+	Date        string `json:"date,omitempty"`
+	Description string `json:"description"`
+}
+
+// FindPackUpdates returns the installed public packs which have newer
+// versions in the public index, whose Vendor::Name match pattern, e.g.
+// "ARM::*". Their download size is asked for to the servers of the packs,
+// unless they are cached already.
+func FindPackUpdates(pattern string, timeout int) ([]PackUpdate, error) {
+	installedPacks, err := findInstalledPacks(false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := []PackUpdate{}
+	for _, installedPack := range installedPacks {
+		packID := installedPack.Vendor + "::" + installedPack.Name
+		matches, err := matchPattern(pattern, packID)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+
+		pack, err := preparePack(installedPack.Key(), false, true, true, timeout)
+		if err != nil {
+			log.Debugf("Could not look for updates of %s: %s", packID, err)
+			continue
+		}
+		if !pack.IsPublic || pack.isInstalled {
+			continue
+		}
+
+		packURL, err := FindPackURL(pack)
+		if err != nil {
+			log.Debugf("Could not find the latest version of %s: %s", packID, err)
+			continue
+		}
+
+		update := PackUpdate{
+			Pack:          packID,
+			Version:       installedPack.Version,
+			LatestVersion: pack.targetVersion,
+			Releases:      []PackRelease{},
+			URL:           packURL,
+			DownloadSize:  -1,
+		}
+
+		update.Releases = pack.releasesAfter(installedPack.Version)
+		if len(update.Releases) > 0 {
+			update.ReleaseNotes = update.Releases[0].Description
+		}
+
+		cachedPath := filepath.Join(Installation.DownloadDir, filepath.Base(packURL))
+		if info, err := utils.GetFileSystem().Stat(cachedPath); err == nil {
+			update.Cached = true
+			update.DownloadSize = info.Size()
+		} else if !strings.HasPrefix(packURL, "http") {
+			if info, err := utils.GetFileSystem().Stat(strings.TrimPrefix(packURL, "file://")); err == nil {
+				update.DownloadSize = info.Size()
+			}
+		} else if size, err := utils.RemoteFileSize(operationContext, packURL, timeout); err == nil {
+			update.DownloadSize = size
+		}
+
+		updates = append(updates, update)
+	}
+
+	sort.SliceStable(updates, func(i, j int) bool {
+		return strings.ToLower(updates[i].Pack) < strings.ToLower(updates[j].Pack)
+	})
+	return updates, nil
+}
+
+// releasesAfter returns the releases of the public pack p after version, up
+// to the version being installed, as listed by its PDSC file in .Web/
+func (p *PackType) releasesAfter(version string) []PackRelease {
+	releases := []PackRelease{}
+	pdscXML, err := readPdscReleases(filepath.Join(Installation.WebDir, p.PdscFileName()))
+	if err != nil {
+		log.Debugf("Could not read the releases of %s: %s", p.PackID(), err)
+		return releases
+	}
+
+	for _, release := range pdscXML.ReleasesAfter(version, p.GetVersion()) {
+		releases = append(releases, PackRelease{
+			Version:     release.Version,
+			Date:        release.Date,
+			Description: strings.TrimSpace(release.Description),
+		})
+	}
+	return releases
+}
+
+// latestInstalledVersion returns the latest version of vendor.name in the
+// pack roots, or "" if none is installed
+func latestInstalledVersion(vendor, name string) string {
+	latest := ""
+	for _, packRoot := range Installation.packRoots() {
+		installedDirs, err := utils.ListDir(filepath.Join(packRoot, vendor, name), "")
+		if err != nil {
+			continue
+		}
+		for _, installedDir := range installedDirs {
+			version := filepath.Base(installedDir)
+			if latest == "" || utils.SemverCompare(version, latest) > 0 {
+				latest = version
+			}
+		}
+	}
+	return latest
+}
+
+// showReleaseNotes logs the descriptions of the releases of p since its
+// latest installed version, for users to see what changed before agreeing
+// to the update. They are kept for the webhook notification too.
+func (p *PackType) showReleaseNotes() {
+	installedVersion := latestInstalledVersion(p.Vendor, p.Name)
+	if installedVersion == "" {
+		return
+	}
+
+	p.releaseNotes = p.releasesAfter(installedVersion)
+	if len(p.releaseNotes) == 0 {
+		return
+	}
+
+	log.Infof("Changes in %s since %s:", p.PackID(), installedVersion)
+	for _, release := range p.releaseNotes {
+		heading := "  " + release.Version
+		if release.Date != "" {
+			heading += " (" + release.Date + ")"
+		}
+		log.Info(heading)
+		for _, line := range strings.Split(release.Description, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				log.Info("    " + line)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/cmd/xml"
+	"github.com/spf13/afero"
+)
+
+// RefreshPdsc updates the registration of a pack added via PDSC file
+// after the PDSC file or the files next to it changed, e.g. to the new
+// latest version of the PDSC file, and touches pack.idx
+func RefreshPdsc(pdscPath string) error {
+	log.Debugf("Refreshing pdsc \"%v\"", pdscPath)
+
+	pdsc, err := preparePdsc(pdscPath)
+	if err != nil {
+		return err
+	}
+
+	tag, err := pdsc.toPdscTag()
+	if err != nil {
+		return err
+	}
+
+	// Entries of previous versions of the same PDSC file are replaced
+	for _, foundTag := range Installation.LocalPidx.FindPdscTags(xml.PdscTag{Vendor: tag.Vendor, Name: tag.Name}) {
+		if sameLocalURL(foundTag.URL, tag.URL) && foundTag.Version != tag.Version {
+			log.Infof("Updating %s from version %s to %s", tag.YamlPackID(), foundTag.Version, tag.Version)
+			if err := Installation.LocalPidx.RemovePdsc(foundTag); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := pdsc.install(Installation); err != nil && !errs.Is(err, errs.ErrPdscEntryExists) {
+		return err
+	}
+
+	if err := Installation.LocalPidx.Write(); err != nil {
+		return err
+	}
+
+	return Installation.touchPackIdx()
+}
+
+// sameLocalURL tells whether two file:// URLs of local_repository.pidx are
+// the same, ignoring case on Windows like PdscType.install does
+func sameLocalURL(url1, url2 string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(url1, url2)
+	}
+	return url1 == url2
+}
+
+// watchedFile is the state of a file WatchPdscs compares between polls
+type watchedFile struct {
+	size    int64
+	modTime int64
+}
+
+// snapshotDir returns the state of the files in dir and its subdirectories,
+// leaving out hidden ones like .git
+func snapshotDir(dir string) map[string]watchedFile {
+	snapshot := map[string]watchedFile{}
+	_ = afero.Walk(utils.GetFileSystem(), dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			snapshot[path] = watchedFile{info.Size(), info.ModTime().UnixNano()}
+		}
+		return nil
+	})
+	return snapshot
+}
+
+// WatchPdscs polls the folders of PDSC files added via AddPdsc every
+// interval, refreshing their registration with RefreshPdsc when something
+// in them changes, until utils.ShouldAbortFunction tells to stop.
+// Failing refreshes, e.g. of a PDSC file being edited, are only logged.
+func WatchPdscs(pdscPaths []string, interval time.Duration) error {
+	dirs := make([]string, len(pdscPaths))
+	snapshots := make([]map[string]watchedFile, len(pdscPaths))
+	for i, pdscPath := range pdscPaths {
+		dir, err := filepath.Abs(filepath.Dir(pdscPath))
+		if err != nil {
+			return err
+		}
+		dirs[i] = dir
+		snapshots[i] = snapshotDir(dir)
+		log.Infof("Watching \"%s\" for changes, press Ctrl+C to stop", dir)
+	}
+
+	for {
+		time.Sleep(interval)
+		if utils.ShouldAbortFunction != nil && utils.ShouldAbortFunction() {
+			log.Info("Stopping watching")
+			return nil
+		}
+
+		for i, pdscPath := range pdscPaths {
+			snapshot := snapshotDir(dirs[i])
+			if maps.Equal(snapshot, snapshots[i]) {
+				continue
+			}
+			snapshots[i] = snapshot
+
+			log.Infof("Changes detected in \"%s\", refreshing \"%s\"", dirs[i], pdscPath)
+			UnlockPackRoot()
+			err := RefreshPdsc(pdscPath)
+			LockPackRoot()
+			if err != nil {
+				log.Errorf("Could not refresh \"%s\": %s", pdscPath, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package installer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+)
+
+// webhookURL is posted to after packs are added, removed or updated
+var webhookURL string
+
+// WebhookTimeout bounds how long a webhook notification may take
+var WebhookTimeout = 10 * time.Second
+
+// SetWebhook makes adding, removing and updating packs post a JSON
+// notification to url. An empty url disables notifications.
+func SetWebhook(url string) {
+	webhookURL = url
+}
+
+// webhookPayload is the JSON body posted to the webhook
+type webhookPayload struct {
+	Operation string `json:"operation"`
+	Pack      string `json:"pack"`
+	Version   string `json:"version"`
+	Host      string `json:"host"`
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+	Code      string `json:"code,omitempty"`
+
+	// Releases are the releases an update brought, see PackType.showReleaseNotes
+	Releases []PackRelease `json:"releases,omitempty"`
+}
+
+// notifyWebhook reports the outcome of operation on pack to the webhook.
+// Notifications are best effort, failing to deliver one is only a warning.
+func notifyWebhook(operation string, pack *PackType, err error) {
+	if webhookURL == "" || pack == nil {
+		return
+	}
+
+	payload := webhookPayload{
+		Operation: operation,
+		Pack:      pack.Vendor + "::" + pack.Name,
+		Version:   pack.targetVersion,
+		Result:    "success",
+		Releases:  pack.releaseNotes,
+	}
+	if payload.Version == "" {
+		payload.Version = pack.GetVersionNoMeta()
+	}
+	payload.Host, _ = os.Hostname()
+	if err != nil {
+		payload.Result = "failure"
+		if errs.Is(err, errs.ErrEula) {
+			payload.Result = "declined"
+		}
+		payload.Error = err.Error()
+		payload.Code = errs.Code(err)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Warnf("Could not notify the webhook: %s", err)
+		return
+	}
+
+	client := &http.Client{
+		Transport: utils.DownloadTransport(webhookURL),
+		Timeout:   WebhookTimeout,
+	}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Warnf("Could not notify the webhook: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		log.Warnf("Could not notify the webhook: %s", resp.Status)
+		return
+	}
+	log.Debugf("Notified the webhook of %s %s", operation, payload.Pack)
+}
@@ -0,0 +1,90 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+// Package log is what the installer, utils, xml, ui and cryptography
+// packages log through. It forwards to the standard logrus logger unless
+// an application sets its own Logger.
+package log
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Logger receives cpackget's log messages. *logrus.Logger and *logrus.Entry
+// implement it, so an entry carrying fields keeps them on every message.
+type Logger interface {
+	Debug(args ...interface{})
+	Debugf(format string, args ...interface{})
+	Info(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warn(args ...interface{})
+	Warnf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Levels compared against GetLevel
+const (
+	ErrorLevel = logrus.ErrorLevel
+	InfoLevel  = logrus.InfoLevel
+	DebugLevel = logrus.DebugLevel
+)
+
+var gLogger Logger = logrus.StandardLogger()
+
+// SetLogger routes all log messages to logger. A nil logger
+// restores the standard logrus logger.
+func SetLogger(logger Logger) {
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	gLogger = logger
+}
+
+func GetLogger() Logger {
+	return gLogger
+}
+
+// GetLevel returns the level of the current logger when it is a logrus one.
+// Other loggers filter messages themselves, so InfoLevel is returned for them.
+func GetLevel() logrus.Level {
+	switch logger := gLogger.(type) {
+	case *logrus.Logger:
+		return logger.GetLevel()
+	case *logrus.Entry:
+		return logger.Logger.GetLevel()
+	}
+	return InfoLevel
+}
+
+func Debug(args ...interface{}) {
+	gLogger.Debug(args...)
+}
+
+func Debugf(format string, args ...interface{}) {
+	gLogger.Debugf(format, args...)
+}
+
+func Info(args ...interface{}) {
+	gLogger.Info(args...)
+}
+
+func Infof(format string, args ...interface{}) {
+	gLogger.Infof(format, args...)
+}
+
+func Warn(args ...interface{}) {
+	gLogger.Warn(args...)
+}
+
+func Warnf(format string, args ...interface{}) {
+	gLogger.Warnf(format, args...)
+}
+
+func Error(args ...interface{}) {
+	gLogger.Error(args...)
+}
+
+func Errorf(format string, args ...interface{}) {
+	gLogger.Errorf(format, args...)
+}
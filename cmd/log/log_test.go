@@ -0,0 +1,87 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package log_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingLogger keeps every message with the first letter of its level
+type recordingLogger struct {
+	messages []string
+}
+
+func (r *recordingLogger) record(level string, args ...interface{}) {
+	r.messages = append(r.messages, level+": "+fmt.Sprint(args...))
+}
+
+func (r *recordingLogger) Debug(args ...interface{}) { r.record("D", args...) }
+func (r *recordingLogger) Debugf(format string, args ...interface{}) {
+	r.record("D", fmt.Sprintf(format, args...))
+}
+func (r *recordingLogger) Info(args ...interface{}) { r.record("I", args...) }
+func (r *recordingLogger) Infof(format string, args ...interface{}) {
+	r.record("I", fmt.Sprintf(format, args...))
+}
+func (r *recordingLogger) Warn(args ...interface{}) { r.record("W", args...) }
+func (r *recordingLogger) Warnf(format string, args ...interface{}) {
+	r.record("W", fmt.Sprintf(format, args...))
+}
+func (r *recordingLogger) Error(args ...interface{}) { r.record("E", args...) }
+func (r *recordingLogger) Errorf(format string, args ...interface{}) {
+	r.record("E", fmt.Sprintf(format, args...))
+}
+
+func TestLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	defer log.SetLogger(nil)
+
+	t.Run("test logging through the standard logrus logger", func(t *testing.T) {
+		assert.Equal(logrus.StandardLogger(), log.GetLogger())
+		assert.Equal(logrus.GetLevel(), log.GetLevel())
+	})
+
+	t.Run("test logging through a custom logger", func(t *testing.T) {
+		logger := &recordingLogger{}
+		log.SetLogger(logger)
+
+		log.Debugf("pack %s", "TheVendor::PackName")
+		log.Info("installing")
+		log.Warnf("non-HTTPS url: %q", "http://vendor.com")
+		log.Error("failed")
+
+		assert.Equal([]string{
+			"D: pack TheVendor::PackName",
+			"I: installing",
+			"W: non-HTTPS url: \"http://vendor.com\"",
+			"E: failed",
+		}, logger.messages)
+		assert.Equal(log.InfoLevel, log.GetLevel())
+
+		log.SetLogger(nil)
+		assert.Equal(logrus.StandardLogger(), log.GetLogger())
+	})
+
+	t.Run("test logging through a logrus entry keeps its fields", func(t *testing.T) {
+		var output bytes.Buffer
+		logger := logrus.New()
+		logger.SetOutput(&output)
+		logger.SetLevel(logrus.ErrorLevel)
+		logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+		log.SetLogger(logger.WithField("tool", "my-ide"))
+
+		log.Info("not shown")
+		log.Errorf("pack %s not found", "TheVendor::PackName")
+
+		assert.Equal("level=error msg=\"pack TheVendor::PackName not found\" tool=my-ide\n", output.String())
+		assert.Equal(log.ErrorLevel, log.GetLevel())
+	})
+}
@@ -26,6 +26,7 @@ func main() {
 	cmd := commands.NewCli()
 	err := cmd.Execute()
 	if err != nil {
+		commands.ReportError(err)
 		if !errs.AlreadyLogged(err) {
 			log.Error(err)
 		}
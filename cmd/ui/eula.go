@@ -4,13 +4,17 @@
 package ui
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jroimartin/gocui"
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
-	log "github.com/sirupsen/logrus"
 )
 
 var Agreed = true
@@ -18,6 +22,13 @@ var Disagreed = false
 var LicenseAgreed *bool
 var Extract = false
 
+// PromptTimeout is the maximum amount of time to wait for the user to answer
+// the license prompt. Zero means waiting forever.
+var PromptTimeout time.Duration
+
+// LicensePrompt, if set, is asked to accept the license instead of the user
+var LicensePrompt func(licenseTitle, licenseContents string) (bool, error)
+
 // LicenseWindowType defines the struct to handle UI
 type LicenseWindowType struct {
 	// LayoutManager is a function that defines the elements in the ui
@@ -44,6 +55,10 @@ func DisplayAndWaitForEULA(licenseTitle, licenseContents string) (bool, error) {
 		return false, errs.ErrExtractEula
 	}
 
+	if LicensePrompt != nil {
+		return LicensePrompt(licenseTitle, licenseContents)
+	}
+
 	promptText := "License Agreement: [A]ccept [D]ecline [E]xtract"
 
 	if !utils.IsTerminalInteractive() {
@@ -63,8 +78,10 @@ func DisplayAndWaitForEULA(licenseTitle, licenseContents string) (bool, error) {
 			return false, errs.ErrExtractEula
 		}
 
-		var input string
-		_, _ = fmt.Scanln(&input)
+		input, err := readInput()
+		if err != nil {
+			return false, err
+		}
 
 		if input == "a" || input == "A" {
 			return true, nil
@@ -84,7 +101,81 @@ func DisplayAndWaitForEULA(licenseTitle, licenseContents string) (bool, error) {
 
 	defer licenseWindow.Gui.Close()
 
-	return licenseWindow.PromptUser()
+	timedOut := false
+	if PromptTimeout > 0 {
+		// done stops a late timer from queueing an update on a closed GUI,
+		// whose event loop would never consume it
+		var mutex sync.Mutex
+		done := make(chan struct{})
+		defer func() {
+			mutex.Lock()
+			close(done)
+			mutex.Unlock()
+		}()
+
+		timer := time.AfterFunc(PromptTimeout, func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			select {
+			case <-done:
+				return
+			default:
+			}
+			licenseWindow.Gui.Update(func(g *gocui.Gui) error {
+				timedOut = true
+				return gocui.ErrQuit
+			})
+		})
+		defer timer.Stop()
+	}
+
+	agreed, err := licenseWindow.PromptUser()
+	if timedOut && LicenseAgreed == nil && !Extract {
+		return false, errPromptTimeout()
+	}
+
+	return agreed, err
+}
+
+// stdinAnswers receives the lines read from the standard input by a single
+// reader, shared by all prompts so that a timed out prompt does not leave
+// a second reader behind
+var stdinAnswers chan string
+var startStdinReader sync.Once
+
+// readInput reads the user's answer from the standard input, giving up
+// after PromptTimeout if it is set
+func readInput() (string, error) {
+	startStdinReader.Do(func() {
+		stdinAnswers = make(chan string)
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				stdinAnswers <- strings.TrimSpace(scanner.Text())
+			}
+			close(stdinAnswers)
+		}()
+	})
+
+	var timeout <-chan time.Time
+	if PromptTimeout > 0 {
+		timeout = time.After(PromptTimeout)
+	}
+
+	select {
+	case input := <-stdinAnswers:
+		return input, nil
+	case <-timeout:
+		fmt.Println()
+		return "", errPromptTimeout()
+	}
+}
+
+// errPromptTimeout declines the license of a prompt left unanswered. Unlike an
+// explicit decline, it is reported as a failure to the caller.
+func errPromptTimeout() error {
+	log.Debugf("No answer to the license prompt after %v", PromptTimeout)
+	return errs.ErrEulaTimeout
 }
 
 func NewLicenseWindow(licenseTitle, licenseContents, promptText string) *LicenseWindowType {
@@ -248,7 +339,7 @@ func (l *LicenseWindowType) Setup() error {
 func (l *LicenseWindowType) PromptUser() (bool, error) {
 	log.Debug("Prompting user for license agreement")
 	err := l.Gui.MainLoop()
-	if err != nil && err != gocui.ErrQuit && err != errs.ErrExtractEula {
+	if err != nil && err != gocui.ErrQuit && !errs.Is(err, errs.ErrExtractEula) {
 		log.Error("Cannot obtain user response: ", err)
 		return false, err
 	}
@@ -0,0 +1,104 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package ui_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+// stdinWriter feeds answers to the license prompt, which reads from a pipe
+// set up before the first prompt starts reading the standard input
+var stdinWriter *os.File
+
+func TestDisplayAndWaitForEULA(t *testing.T) {
+	assert := assert.New(t)
+
+	defer func() { ui.PromptTimeout = 0 }()
+
+	t.Run("test accepting the license", func(t *testing.T) {
+		ui.PromptTimeout = 0
+		_, err := stdinWriter.WriteString("a\n")
+		assert.Nil(err)
+
+		agreed, err := ui.DisplayAndWaitForEULA("LICENSE.txt", "license contents")
+		assert.Nil(err)
+		assert.True(agreed)
+	})
+
+	t.Run("test declining the license", func(t *testing.T) {
+		ui.PromptTimeout = time.Minute
+		_, err := stdinWriter.WriteString("D\n")
+		assert.Nil(err)
+
+		agreed, err := ui.DisplayAndWaitForEULA("LICENSE.txt", "license contents")
+		assert.Nil(err)
+		assert.False(agreed)
+	})
+
+	t.Run("test extracting the license", func(t *testing.T) {
+		ui.PromptTimeout = time.Minute
+		_, err := stdinWriter.WriteString("e\n")
+		assert.Nil(err)
+
+		agreed, err := ui.DisplayAndWaitForEULA("LICENSE.txt", "license contents")
+		assert.Equal(errs.ErrExtractEula, err)
+		assert.False(agreed)
+		ui.Extract = false
+	})
+
+	t.Run("test license prompt timing out", func(t *testing.T) {
+		ui.PromptTimeout = 10 * time.Millisecond
+
+		agreed, err := ui.DisplayAndWaitForEULA("LICENSE.txt", "license contents")
+		assert.True(errors.Is(err, errs.ErrEulaTimeout))
+		assert.True(errors.Is(err, errs.ErrEula))
+		assert.False(agreed)
+	})
+
+	t.Run("test answering after a timed out prompt", func(t *testing.T) {
+		ui.PromptTimeout = 10 * time.Millisecond
+		_, err := ui.DisplayAndWaitForEULA("LICENSE.txt", "license contents")
+		assert.Equal(errs.ErrEulaTimeout, err)
+
+		// The next prompt gets the answer, rather than a reader left behind by the previous one
+		ui.PromptTimeout = time.Minute
+		_, err = stdinWriter.WriteString("a\n")
+		assert.Nil(err)
+
+		agreed, err := ui.DisplayAndWaitForEULA("LICENSE.txt", "license contents")
+		assert.Nil(err)
+		assert.True(agreed)
+	})
+}
+
+func TestMain(m *testing.M) {
+	stdinReader, writer, err := os.Pipe()
+	if err != nil {
+		panic(err)
+	}
+	stdinWriter = writer
+	os.Stdin = stdinReader
+
+	// A regular file keeps the prompt out of the full screen UI
+	stdout, err := os.CreateTemp("", "cpackget-eula-stdout")
+	if err != nil {
+		panic(err)
+	}
+	originalStdout := os.Stdout
+	os.Stdout = stdout
+
+	code := m.Run()
+
+	os.Stdout = originalStdout
+	stdout.Close()
+	os.Remove(stdout.Name())
+	os.Exit(code)
+}
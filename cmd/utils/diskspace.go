@@ -0,0 +1,94 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/spf13/afero"
+)
+
+// DiskSpaceMargin is how many bytes CheckDiskSpace leaves free on
+// top of what is required, so the volume does not fill up completely
+var DiskSpaceMargin uint64 = 64 * 1024 * 1024
+
+// CheckDiskSpace returns errs.ErrNotEnoughDiskSpace if the volume holding path
+// does not have size bytes free, plus DiskSpaceMargin. Nothing is checked when
+// the free space cannot be determined, e.g. on an in-memory file system.
+func CheckDiskSpace(path string, size uint64) error {
+	if _, ok := gFs.(*afero.OsFs); !ok {
+		return nil
+	}
+
+	free, err := freeDiskSpace(path)
+	if err != nil {
+		log.Debugf("Cannot determine free disk space of \"%s\": %s", path, err)
+		return nil
+	}
+
+	if size > free || free-size < DiskSpaceMargin {
+		log.Errorf("\"%s\" needs %s but only %s are free", path, FormatBytes(size+DiskSpaceMargin), FormatBytes(free))
+		return errs.WithPath(errs.ErrNotEnoughDiskSpace, path)
+	}
+
+	return nil
+}
+
+// FreeDiskSpace returns how many bytes can still be written to the volume holding path
+func FreeDiskSpace(path string) (uint64, error) {
+	return freeDiskSpace(path)
+}
+
+// FormatBytes formats size in the largest unit it has at least one of
+func FormatBytes(size uint64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// DirSize returns the size of all files under dir, counting files hard linked
+// to each other once. Directories whose name matches one of the skipDirs
+// patterns are left out.
+func DirSize(dir string, skipDirs ...string) (uint64, error) {
+	canHardLink := CanHardLink()
+	counted := map[FileID]bool{}
+
+	var size uint64
+	err := afero.Walk(gFs, dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			for _, pattern := range skipDirs {
+				if matched, _ := filepath.Match(pattern, info.Name()); matched && path != dir {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if canHardLink {
+			if id, links, err := fileIDOf(path, info); err == nil && links > 1 {
+				if counted[id] {
+					return nil
+				}
+				counted[id] = true
+			}
+		}
+		size += uint64(info.Size())
+		return nil
+	})
+	return size, err
+}
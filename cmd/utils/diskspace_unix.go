@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// freeDiskSpace returns how many bytes an unprivileged user can still write to the volume of path
+func freeDiskSpace(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -6,7 +6,7 @@ package utils
 import (
 	"sync"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 )
 
 type EncodedProgress struct {
@@ -18,6 +18,33 @@ type EncodedProgress struct {
 	name           string
 }
 
+// gProgressInstances numbers the files reporting encoded progress
+var gProgressInstances struct {
+	sync.Mutex
+	next      int
+	instances map[string]int
+}
+
+// ProgressInstance returns the instance number the encoded progress of the
+// file name is reported with. All operations on a file, e.g. downloading and
+// then extracting a pack, share its number, which frontends demultiplex the
+// progress of concurrent operations by, e.g. packs downloaded ahead while
+// another one is extracted.
+func ProgressInstance(name string) int {
+	gProgressInstances.Lock()
+	defer gProgressInstances.Unlock()
+	if instance, ok := gProgressInstances.instances[name]; ok {
+		return instance
+	}
+	if gProgressInstances.instances == nil {
+		gProgressInstances.instances = map[string]int{}
+	}
+	instance := gProgressInstances.next
+	gProgressInstances.instances[name] = instance
+	gProgressInstances.next++
+	return instance
+}
+
 func NewEncodedProgress(max int64, instNo int, filename string) *EncodedProgress {
 	return &EncodedProgress{
 		total:      max,
@@ -40,7 +67,8 @@ func (p *EncodedProgress) Write(bs []byte) (int, error) {
 }
 
 /* Encodes information to show progress when called by GUI or other tools
- * I: Instance number (always counts up), connected to the filename
+ * I: Instance number (always counts up), connected to the filename, see
+ *    ProgressInstance. Lines of concurrent instances interleave.
  * F: Filename currently processed
  * T: Total bytes of file or numbers of files
  * P: Currently processed percentage
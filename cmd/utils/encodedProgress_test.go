@@ -6,6 +6,8 @@ package utils_test
 import (
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
@@ -89,4 +91,45 @@ func TestEncodedProgres(t *testing.T) {
 		assert.Equal("I: [I0:F\"Testing\",T31,P100]\n", gText)
 	})
 
+	t.Run("test encoded progress of concurrent instances", func(t *testing.T) {
+		Log := CaptureLog(t)
+		defer Log.Release()
+
+		download := utils.ProgressInstance("Vendor.Pack.1.2.3.pack")
+		other := utils.ProgressInstance("Vendor.Other.1.0.0.pack")
+		assert.NotEqual(download, other)
+		assert.Equal(download, utils.ProgressInstance("Vendor.Pack.1.2.3.pack"))
+
+		var wg sync.WaitGroup
+		for _, instance := range []int{download, other} {
+			wg.Add(1)
+			go func(instance int) {
+				defer wg.Done()
+				progressWriter := utils.NewEncodedProgress(4, instance, "Testing")
+				for i := 0; i < 4; i++ {
+					progressWriter.Add(1)
+				}
+			}(instance)
+		}
+		wg.Wait()
+
+		lines := strings.Split(strings.TrimSuffix(gText, "\n"), "\n")
+		assert.Equal(8, len(lines))
+		for _, instance := range []int{download, other} {
+			prefix := fmt.Sprintf("I: [I%d:", instance)
+			instanceLines := []string{}
+			for _, line := range lines {
+				if strings.HasPrefix(line, prefix) {
+					instanceLines = append(instanceLines, line)
+				}
+			}
+			assert.Equal([]string{
+				prefix + "F\"Testing\",T4,P25]",
+				prefix + "P50,C2]",
+				prefix + "P75,C3]",
+				prefix + "P100,C4]",
+			}, instanceLines)
+		}
+	})
+
 }
@@ -0,0 +1,259 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/spf13/afero"
+)
+
+// gFs holds the pack root and the download cache. All file operations
+// on the installation tree go through it.
+var gFs afero.Fs = afero.NewOsFs()
+
+// SetFileSystem makes file operations on the installation tree use fs,
+// for instance an in-memory file system. A nil fs restores the
+// operating system's one.
+func SetFileSystem(fs afero.Fs) {
+	if fs == nil {
+		fs = afero.NewOsFs()
+	}
+	gFs = fs
+}
+
+func GetFileSystem() afero.Fs {
+	return gFs
+}
+
+// MmapThreshold is the size from which zip archives on the operating
+// system's file system are memory-mapped instead of read piecewise
+var MmapThreshold int64 = 64 * 1024 * 1024
+
+// ZipReadCloser is a zip archive opened from the current file system
+type ZipReadCloser struct {
+	*zip.Reader
+	file afero.File
+
+	// unmap releases the memory mapping of the archive, if it was mapped
+	unmap func() error
+}
+
+// Close closes the underlying archive file
+func (z *ZipReadCloser) Close() error {
+	if z.unmap != nil {
+		if err := z.unmap(); err != nil {
+			log.Debugf("Could not unmap \"%s\": %s", z.file.Name(), err)
+		}
+		z.unmap = nil
+	}
+	return z.file.Close()
+}
+
+// OpenZip opens the zip archive in path from the current file system.
+// Entries are read where they are in the archive, either with positioned
+// reads or, for large archives, through a memory mapping of the archive,
+// so finding the PDSC file or extracting a few entries never reads the
+// archive as a whole.
+func OpenZip(path string) (*ZipReadCloser, error) {
+	file, err := gFs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	archive := &ZipReadCloser{file: file}
+	var readerAt io.ReaderAt = file
+	if osFile, ok := file.(*os.File); ok && info.Size() >= MmapThreshold {
+		if data, unmap, err := mmapFile(osFile, info.Size()); err == nil {
+			log.Debugf("Memory-mapped \"%s\"", path)
+			readerAt = bytes.NewReader(data)
+			archive.unmap = unmap
+		} else {
+			log.Debugf("Could not memory-map \"%s\", reading it piecewise: %s", path, err)
+		}
+	}
+
+	archive.Reader, err = zip.NewReader(readerAt, info.Size())
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// zipHasEnd tells whether the file in path ends with the end of central
+// directory record of a zip archive, which truncated archives lack
+func zipHasEnd(path string) bool {
+	file, err := gFs.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	// The record is 22 bytes long, followed by a comment of up to 64 KiB
+	tailSize := min(info.Size(), 22+0xFFFF)
+	tail := make([]byte, tailSize)
+	if _, err := file.ReadAt(tail, info.Size()-tailSize); err != nil && err != io.EOF {
+		return false
+	}
+	end := bytes.LastIndex(tail, []byte("PK\x05\x06"))
+	return end >= 0 && len(tail)-end >= 22
+}
+
+// CorruptZipEntry decompresses every entry of reader, returning the name of
+// the first one failing to, e.g. because of a CRC-32 mismatch, and why.
+// It returns "" if all entries are fine.
+func CorruptZipEntry(reader *zip.Reader) (string, error) {
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		entry, err := file.Open()
+		if err == nil {
+			_, err = io.Copy(io.Discard, entry)
+			entry.Close()
+		}
+		if err != nil {
+			return file.Name, err
+		}
+	}
+	return "", nil
+}
+
+// FileID identifies a file regardless of its paths, of which hard links
+// give it several, see StatFileID
+type FileID struct {
+	Device uint64
+	Index  uint64
+}
+
+// CanHardLink tells whether the file system in use, see SetFileSystem,
+// supports hard links. Only the operating system's one does.
+func CanHardLink() bool {
+	_, isOsFs := gFs.(*afero.OsFs)
+	return isOsFs
+}
+
+// HardLink makes newname a hard link to the file oldname, or a copy of it
+// if the file system in use cannot link files, see CanHardLink
+func HardLink(oldname, newname string) error {
+	if !CanHardLink() {
+		return CopyFile(oldname, newname)
+	}
+	return os.Link(oldname, newname)
+}
+
+// LinkCount returns the number of hard links to the file in path, always 1
+// if the file system in use cannot link files, see CanHardLink
+func LinkCount(path string) (uint64, error) {
+	if !CanHardLink() {
+		if _, err := gFs.Stat(path); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+	_, links, err := StatFileID(path)
+	return links, err
+}
+
+// GlobIn returns the paths in dir matching pattern, like afero.Glob does for
+// filepath.Join(dir, pattern), but taking dir literally: glob metacharacters
+// in it, e.g. "[", are not part of the pattern. Elements of pattern are
+// separated by "/". Folders that cannot be read have no matches.
+func GlobIn(dir, pattern string) ([]string, error) {
+	matches := []string{dir}
+	for _, element := range strings.Split(pattern, "/") {
+		// Catch bad patterns even if there is nothing to match
+		if _, err := filepath.Match(element, ""); err != nil {
+			return nil, err
+		}
+
+		next := []string{}
+		for _, match := range matches {
+			file, err := gFs.Open(match)
+			if err != nil {
+				continue
+			}
+			names, err := file.Readdirnames(-1)
+			file.Close()
+			if err != nil {
+				continue
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if matched, _ := filepath.Match(element, name); matched {
+					next = append(next, filepath.Join(match, name))
+				}
+			}
+		}
+		matches = next
+	}
+	return matches, nil
+}
+
+// WriteFileAtomic writes data to path, see WriteAtomic
+func WriteFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	return WriteAtomic(path, perm, func(writer io.Writer) error {
+		_, err := writer.Write(data)
+		return err
+	})
+}
+
+// WriteAtomic replaces path with what write writes. It goes to a temporary
+// file in the same directory first, which is synced and then renamed over
+// path, so a crash never leaves path truncated. The directory is synced as
+// well, so that the rename itself survives a crash.
+func WriteAtomic(path string, perm fs.FileMode, write func(io.Writer) error) error {
+	if path == "" {
+		return &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+
+	file, err := afero.TempFile(gFs, filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = gFs.Chmod(file.Name(), perm)
+	}
+	if err == nil {
+		err = gFs.Rename(file.Name(), path)
+	}
+	if err != nil {
+		_ = gFs.Remove(file.Name())
+		return err
+	}
+
+	if _, ok := gFs.(*afero.OsFs); ok {
+		return syncDir(filepath.Dir(path))
+	}
+	return nil
+}
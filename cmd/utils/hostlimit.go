@@ -0,0 +1,102 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// DefaultMaxHostDownloads is how many downloads from the same host run at
+// once by default, whatever the number of concurrent downloads
+const DefaultMaxHostDownloads = 6
+
+// hostLimit caps the downloads running at once per host, not to overload
+// vendor servers with the connections of a batch
+var hostLimit = struct {
+	mu    sync.Mutex
+	max   int
+	slots map[string]*semaphore.Weighted
+}{max: DefaultMaxHostDownloads}
+
+// SetMaxHostDownloads sets how many downloads from the same host run at
+// once, the others waiting for one to finish. 0 sets no limit.
+func SetMaxHostDownloads(maxHostDownloads int) {
+	hostLimit.mu.Lock()
+	defer hostLimit.mu.Unlock()
+	hostLimit.max = maxHostDownloads
+	hostLimit.slots = nil
+}
+
+func GetMaxHostDownloads() int {
+	hostLimit.mu.Lock()
+	defer hostLimit.mu.Unlock()
+	return hostLimit.max
+}
+
+// URLHost returns the host of rawURL in lower case, or "" if it has none,
+// e.g. for local files
+func URLHost(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsedURL.Host)
+}
+
+// acquireHostSlot waits until a download from the host of URL may start, or
+// ctx is done. The returned function must be called once the download is
+// over.
+func acquireHostSlot(ctx context.Context, URL string) (func(), error) {
+	host := URLHost(URL)
+	hostLimit.mu.Lock()
+	if hostLimit.max <= 0 || host == "" {
+		hostLimit.mu.Unlock()
+		return func() {}, nil
+	}
+	if hostLimit.slots == nil {
+		hostLimit.slots = map[string]*semaphore.Weighted{}
+	}
+	slots, found := hostLimit.slots[host]
+	if !found {
+		slots = semaphore.NewWeighted(int64(hostLimit.max))
+		hostLimit.slots[host] = slots
+	}
+	hostLimit.mu.Unlock()
+
+	if err := slots.Acquire(ctx, 1); err != nil {
+		return nil, ContextError(ctx)
+	}
+	return func() { slots.Release(1) }, nil
+}
+
+// InterleaveByHost returns the positions of urls ordered round-robin by host,
+// the first URL of each host, then the second one and so on, keeping their
+// order within a host. Batches scheduled in this order make progress on all
+// hosts at once instead of queuing up behind the limit of a single one.
+func InterleaveByHost(urls []string) []int {
+	hosts := []string{}
+	byHost := map[string][]int{}
+	for i, rawURL := range urls {
+		host := URLHost(rawURL)
+		if _, found := byHost[host]; !found {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], i)
+	}
+
+	order := make([]int, 0, len(urls))
+	for round := 0; len(order) < len(urls); round++ {
+		for _, host := range hosts {
+			if round < len(byHost[host]) {
+				order = append(order, byHost[host][round])
+			}
+		}
+	}
+	return order
+}
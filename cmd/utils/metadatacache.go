@@ -0,0 +1,193 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+)
+
+// metadataCacheVersion is bumped whenever the layout of cached values changes
+const metadataCacheVersion = 2
+
+// metadataCacheMaxEntries bounds the files in the metadata cache. Pack roots
+// come and go, e.g. in CI or tests, so entries of files nobody reads anymore
+// are evicted, least recently used first.
+const metadataCacheMaxEntries = 4096
+
+// metadataCacheRacyWindow is how recently a file may have been modified to
+// still be cached. File systems storing coarse modification times would not
+// tell apart a change made right after caching a file of the same size.
+const metadataCacheRacyWindow = 2 * time.Second
+
+// gMetadataCacheDir keeps parsed PIDX and PDSC files, so they are not parsed
+// again until they change. It lives outside the pack root, which is read-only
+// most of the time.
+var gMetadataCacheDir = defaultMetadataCacheDir()
+
+func defaultMetadataCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cpackget", "metadata")
+}
+
+// SetMetadataCacheDir sets where parsed metadata is cached.
+// An empty dir disables the cache.
+func SetMetadataCacheDir(dir string) {
+	gMetadataCacheDir = dir
+}
+
+func GetMetadataCacheDir() string {
+	return gMetadataCacheDir
+}
+
+// metadataCacheHeader identifies the file a cache entry was made from
+type metadataCacheHeader struct {
+	Version int
+	Path    string
+	Size    int64
+	ModTime int64
+}
+
+// metadataCacheEntry returns the cache file and header of the file in path.
+// Entries of different kinds, e.g. the parsed file and its verification,
+// are kept apart.
+func metadataCacheEntry(kind, path string) (string, metadataCacheHeader, bool) {
+	if gMetadataCacheDir == "" {
+		return "", metadataCacheHeader{}, false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", metadataCacheHeader{}, false
+	}
+
+	info, err := gFs.Stat(absPath)
+	if err != nil {
+		return "", metadataCacheHeader{}, false
+	}
+
+	key := absPath
+	if kind != "" {
+		key = kind + "\x00" + absPath
+	}
+	sum := sha256.Sum256([]byte(key))
+	cachePath := filepath.Join(gMetadataCacheDir, hex.EncodeToString(sum[:16])+".gob")
+	header := metadataCacheHeader{
+		Version: metadataCacheVersion,
+		Path:    absPath,
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+	}
+	return cachePath, header, true
+}
+
+// LoadMetadataCache decodes into value what StoreMetadataCache saved for the
+// file in path. It returns false if nothing was saved or the file changed since,
+// in which case value should be discarded.
+func LoadMetadataCache(path string, value interface{}) bool {
+	return loadMetadataCache("", path, value)
+}
+
+func loadMetadataCache(kind, path string, value interface{}) bool {
+	cachePath, header, ok := metadataCacheEntry(kind, path)
+	if !ok {
+		return false
+	}
+
+	file, err := gFs.Open(cachePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	decoder := gob.NewDecoder(file)
+	var cachedHeader metadataCacheHeader
+	if err := decoder.Decode(&cachedHeader); err != nil || cachedHeader != header {
+		return false
+	}
+
+	if err := decoder.Decode(value); err != nil {
+		log.Debugf("Ignoring metadata cache of \"%s\": %s", path, err)
+		return false
+	}
+
+	// Recently used entries are the last to be evicted
+	now := time.Now()
+	_ = gFs.Chtimes(cachePath, now, now)
+
+	log.Debugf("Using metadata cache of \"%s\"", path)
+	return true
+}
+
+// StoreMetadataCache saves value as the parsed contents of the file in path.
+// Failing to do so only costs parsing the file again, so errors are just logged.
+func StoreMetadataCache(path string, value interface{}) {
+	storeMetadataCache("", path, value)
+}
+
+func storeMetadataCache(kind, path string, value interface{}) {
+	cachePath, header, ok := metadataCacheEntry(kind, path)
+	if !ok {
+		return
+	}
+
+	if time.Since(time.Unix(0, header.ModTime)) < metadataCacheRacyWindow {
+		log.Debugf("Not caching metadata of \"%s\", it has just been modified", path)
+		return
+	}
+
+	if err := gFs.MkdirAll(gMetadataCacheDir, 0755); err != nil {
+		log.Debugf("Could not create metadata cache directory: %s", err)
+		return
+	}
+
+	err := WriteAtomic(cachePath, FileModeRW, func(writer io.Writer) error {
+		encoder := gob.NewEncoder(writer)
+		if err := encoder.Encode(header); err != nil {
+			return err
+		}
+		return encoder.Encode(value)
+	})
+	if err != nil {
+		log.Debugf("Could not write metadata cache of \"%s\": %s", path, err)
+		return
+	}
+
+	evictMetadataCache()
+}
+
+// evictMetadataCache removes the least recently used entries of the metadata
+// cache once there are more than metadataCacheMaxEntries, down to 3/4 of it
+func evictMetadataCache() {
+	dir, err := gFs.Open(gMetadataCacheDir)
+	if err != nil {
+		return
+	}
+	infos, err := dir.Readdir(-1)
+	dir.Close()
+	if err != nil || len(infos) <= metadataCacheMaxEntries {
+		return
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	evicted := infos[:len(infos)-metadataCacheMaxEntries*3/4]
+	log.Debugf("Evicting %d entries of the metadata cache", len(evicted))
+	for _, info := range evicted {
+		_ = gFs.Remove(filepath.Join(gMetadataCacheDir, info.Name()))
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"strings"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
-	log "github.com/sirupsen/logrus"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 )
 
 // namePattern specifies a regular expression that matches Pack and Vendor names.
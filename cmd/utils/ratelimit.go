@@ -0,0 +1,76 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitRetries is how many times a download is retried when the server
+// rate-limits it, on top of the retries set with SetDownloadRetries
+var RateLimitRetries = 5
+
+// MaxRetryAfter caps how long a Retry-After header pauses downloads
+var MaxRetryAfter = 5 * time.Minute
+
+// rateLimit pauses all downloads until a rate-limiting server lets them resume
+var rateLimit struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// retryAfter tells how long the server asks to wait before downloading
+// again, or 0 if it did not rate-limit the download
+func retryAfter(resp *http.Response) time.Duration {
+	header := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if resp.StatusCode != http.StatusTooManyRequests && (resp.StatusCode != http.StatusServiceUnavailable || header == "") {
+		return 0
+	}
+
+	delay := DownloadRetryDelay
+	if seconds, err := strconv.Atoi(header); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		delay = time.Until(date)
+	}
+
+	if delay <= 0 {
+		delay = time.Millisecond
+	}
+	if delay > MaxRetryAfter {
+		delay = MaxRetryAfter
+	}
+	return delay
+}
+
+// pauseDownloads makes all downloads wait for delay before their next request
+func pauseDownloads(delay time.Duration) {
+	rateLimit.mu.Lock()
+	defer rateLimit.mu.Unlock()
+	if until := time.Now().Add(delay); until.After(rateLimit.until) {
+		rateLimit.until = until
+	}
+}
+
+// waitForRateLimit waits until downloads are no longer paused, or ctx is done
+func waitForRateLimit(ctx context.Context) error {
+	rateLimit.mu.Lock()
+	delay := time.Until(rateLimit.until)
+	rateLimit.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ContextError(ctx)
+	}
+}
@@ -0,0 +1,102 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+)
+
+// The hosts the credentials of a download are forwarded to on redirect,
+// see SetRedirectAuth
+const (
+	// RedirectAuthSameHost only forwards them to the host of the download URL
+	RedirectAuthSameHost = "same-host"
+
+	// RedirectAuthSameDomain also forwards them to its subdomains
+	RedirectAuthSameDomain = "same-domain"
+
+	// RedirectAuthAnyHost forwards them to any host, e.g. a CDN
+	RedirectAuthAnyHost = "any-host"
+)
+
+// DefaultMaxRedirects is how many redirects downloads follow by default
+const DefaultMaxRedirects = 10
+
+var gMaxRedirects = DefaultMaxRedirects
+var gRedirectAuth = RedirectAuthSameDomain
+
+// SetMaxRedirects sets how many redirects a download follows before
+// failing with errs.ErrTooManyRedirects, 0 makes any redirect fail
+func SetMaxRedirects(maxRedirects int) {
+	gMaxRedirects = maxRedirects
+}
+
+func GetMaxRedirects() int {
+	return gMaxRedirects
+}
+
+// SetRedirectAuth sets the hosts the Authorization header of a download,
+// or the credentials in the user info of its URL, are forwarded to when it
+// is redirected: RedirectAuthSameHost, RedirectAuthSameDomain or
+// RedirectAuthAnyHost
+func SetRedirectAuth(redirectAuth string) error {
+	switch redirectAuth {
+	case RedirectAuthSameHost, RedirectAuthSameDomain, RedirectAuthAnyHost:
+		gRedirectAuth = redirectAuth
+		return nil
+	}
+	log.Errorf("--redirect-auth must be either \"%s\", \"%s\" or \"%s\"", RedirectAuthSameHost, RedirectAuthSameDomain, RedirectAuthAnyHost)
+	return errs.ErrIncorrectCmdArgs
+}
+
+func GetRedirectAuth() string {
+	return gRedirectAuth
+}
+
+// checkRedirect is the CheckRedirect of download clients, applying the
+// redirect policy to req, redirected from the requests in via
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > gMaxRedirects {
+		log.Errorf("\"%s\" was redirected more than %d times", RedactURL(via[0].URL.String()), gMaxRedirects)
+		return errs.ErrTooManyRedirects
+	}
+
+	initial := via[0]
+	auth := initial.Header.Get("Authorization")
+	if auth == "" && initial.URL.User != nil {
+		password, _ := initial.URL.User.Password()
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(initial.URL.User.Username()+":"+password))
+	}
+	if auth == "" {
+		return nil
+	}
+
+	if forwardAuth(initial.URL, req.URL) {
+		log.Debugf("Forwarding the credentials of the download to \"%s\"", req.URL.Host)
+		req.Header.Set("Authorization", auth)
+	} else {
+		log.Debugf("Not forwarding the credentials of the download to \"%s\"", req.URL.Host)
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// forwardAuth tells whether credentials for the initial URL may be sent to
+// the redirected one according to the redirect policy
+func forwardAuth(initial, redirected *url.URL) bool {
+	switch gRedirectAuth {
+	case RedirectAuthAnyHost:
+		return true
+	case RedirectAuthSameDomain:
+		initialHost, host := strings.ToLower(initial.Hostname()), strings.ToLower(redirected.Hostname())
+		return host == initialHost || strings.HasSuffix(host, "."+initialHost)
+	}
+	return strings.EqualFold(initial.Host, redirected.Host)
+}
@@ -0,0 +1,41 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"crypto/sha1" // #nosec
+	"crypto/sha256"
+	"hash"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// nexusETag matches the ETag Nexus Repository sends along artifacts, e.g. "{SHA1{<digest>}}"
+var nexusETag = regexp.MustCompile(`^(?:W/)?"?\{SHA1\{([0-9a-fA-F]{40})\}\}"?$`)
+
+// RepositoryChecksum returns the checksum artifact repositories report for the
+// file being downloaded, as a hash algorithm ("sha256" or "sha1") and its hex
+// digest. Artifactory sends X-Checksum-* headers, Nexus the SHA-1 in the ETag.
+// Both are empty if the server reported none.
+func RepositoryChecksum(header http.Header) (string, string) {
+	if digest := header.Get("X-Checksum-Sha256"); digest != "" {
+		return "sha256", strings.ToLower(digest)
+	}
+	if digest := header.Get("X-Checksum-Sha1"); digest != "" {
+		return "sha1", strings.ToLower(digest)
+	}
+	if match := nexusETag.FindStringSubmatch(header.Get("ETag")); match != nil {
+		return "sha1", strings.ToLower(match[1])
+	}
+	return "", ""
+}
+
+// newRepositoryHash returns the hash computing digests of algorithm
+func newRepositoryHash(algorithm string) hash.Hash {
+	if algorithm == "sha1" {
+		return sha1.New() // #nosec
+	}
+	return sha256.New()
+}
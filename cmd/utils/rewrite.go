@@ -0,0 +1,72 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"bufio"
+	"strings"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+)
+
+// URLRewrite replaces the Prefix of URLs with Replacement, e.g. to download
+// vendor files from a mirror
+type URLRewrite struct {
+	Prefix      string
+	Replacement string
+}
+
+// gURLRewrites are applied to every URL downloaded
+var gURLRewrites []URLRewrite
+
+// SetURLRewrites makes downloads apply rewrites, the first matching one wins
+func SetURLRewrites(rewrites []URLRewrite) {
+	gURLRewrites = rewrites
+}
+
+func GetURLRewrites() []URLRewrite {
+	return gURLRewrites
+}
+
+// RewriteURL returns URL with the first matching rewrite applied
+func RewriteURL(URL string) string {
+	for _, rewrite := range gURLRewrites {
+		if rest, found := strings.CutPrefix(URL, rewrite.Prefix); found {
+			log.Debugf("Rewriting \"%s\" to \"%s\"", URL, rewrite.Replacement+rest)
+			return rewrite.Replacement + rest
+		}
+	}
+	return URL
+}
+
+// ReadURLRewrites reads the rewrite rules in fileName, one per line:
+//
+//	# Comments and empty lines are ignored
+//	https://www.keil.com/pack/ => https://mirror.corp/packs/
+func ReadURLRewrites(fileName string) ([]URLRewrite, error) {
+	file, err := gFs.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rewrites := []URLRewrite{}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		prefix, replacement, found := strings.Cut(line, "=>")
+		prefix, replacement = strings.TrimSpace(prefix), strings.TrimSpace(replacement)
+		if !found || prefix == "" || replacement == "" {
+			log.Errorf("%s:%d: \"%s\" is not a URL rewrite rule", fileName, lineNumber, line)
+			return nil, errs.ErrInvalidURLRewrite
+		}
+		rewrites = append(rewrites, URLRewrite{Prefix: prefix, Replacement: replacement})
+	}
+	return rewrites, scanner.Err()
+}
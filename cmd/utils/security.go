@@ -5,14 +5,19 @@ package utils
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
-	log "github.com/sirupsen/logrus"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/spf13/afero"
 )
 
 // MaxDownloadSize determines that the max file to be downloaded. Defaults to 20G
@@ -20,8 +25,8 @@ import (
 var MaxDownloadSize = int64(20 * 1024 * 1024 * 1024)
 
 // DownloadBufferSize is the number of bytes to transfer from the stream to the downloaded
-// file per iteration. It is 4kb
-const DownloadBufferSize = 4096
+// file per iteration. It is 32kb
+const DownloadBufferSize = 32 * 1024
 
 // ShouldAbortFunction is a function that determines whether early termination was requested
 // by the user
@@ -32,6 +37,23 @@ var ShouldAbortFunction func() bool
 // a file.
 // Ref: G110: Potential DoS vulnerability via decompression bomb (https://cwe.mitre.org/data/definitions/409.html)
 func SecureCopy(dst io.Writer, src io.Reader) (int64, error) {
+	return SecureCopyContext(context.Background(), dst, src)
+}
+
+// copyBuffers recycles the buffers of SecureCopy, so copying many files or
+// a multi-GB one allocates no more than a few of them
+var copyBuffers = sync.Pool{
+	New: func() interface{} {
+		buffer := make([]byte, DownloadBufferSize)
+		return &buffer
+	},
+}
+
+// SecureCopyContext is SecureCopy stopping once ctx is done
+func SecureCopyContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	buffer := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buffer)
+
 	bytesRead := int64(0)
 	for {
 		if ShouldAbortFunction != nil && ShouldAbortFunction() {
@@ -40,7 +62,11 @@ func SecureCopy(dst io.Writer, src io.Reader) (int64, error) {
 			return bytesRead, errs.ErrTerminatedByUser
 		}
 
-		partialRead, err := io.CopyN(dst, src, DownloadBufferSize)
+		if ctx.Err() != nil {
+			return bytesRead, ContextError(ctx)
+		}
+
+		partialRead, err := src.Read(*buffer)
 
 		// Check if copy limit has explode before checking for errors
 		bytesRead += int64(partialRead)
@@ -49,10 +75,21 @@ func SecureCopy(dst io.Writer, src io.Reader) (int64, error) {
 			return bytesRead, errs.ErrFileTooBig
 		}
 
+		if partialRead > 0 {
+			if _, writeErr := dst.Write((*buffer)[:partialRead]); writeErr != nil {
+				log.Error(writeErr)
+				return bytesRead, errs.ErrFailedWrittingToLocalFile
+			}
+		}
+
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
+			if ctx.Err() != nil {
+				// Reading a response body fails as soon as its request is canceled
+				return bytesRead, ContextError(ctx)
+			}
 			log.Error(err)
 			return bytesRead, errs.ErrFailedWrittingToLocalFile
 		}
@@ -65,6 +102,11 @@ func SecureCopy(dst io.Writer, src io.Reader) (int64, error) {
 // compressed files. It avoids extracting files with "../"
 // if stripPrefix is provided, use that to strip file.Name files
 func SecureInflateFile(file *zip.File, destinationDir, stripPrefix string) error {
+	return SecureInflateFileContext(context.Background(), file, destinationDir, stripPrefix)
+}
+
+// SecureInflateFileContext is SecureInflateFile stopping once ctx is done
+func SecureInflateFileContext(ctx context.Context, file *zip.File, destinationDir, stripPrefix string) error {
 	log.Debugf("Inflating \"%s\"", file.Name)
 
 	if strings.Contains(file.Name, "../") || strings.Contains(file.Name, "..\\") {
@@ -80,6 +122,10 @@ func SecureInflateFile(file *zip.File, destinationDir, stripPrefix string) error
 		}
 	}
 
+	if escapeWindowsNames {
+		fileName = WindowsSafeName(strings.ReplaceAll(fileName, "\\", "/"))
+	}
+
 	if strings.HasSuffix(fileName, "/") || strings.HasSuffix(fileName, "\\") {
 		return EnsureDir(filepath.Join(destinationDir, fileName)) // #nosec
 	}
@@ -96,19 +142,148 @@ func SecureInflateFile(file *zip.File, destinationDir, stripPrefix string) error
 		return err
 	}
 
-	reader, _ := file.Open()
+	reader, err := file.Open()
+	if err != nil {
+		log.Errorf("Can't decompress \"%s\": %s", file.Name, err)
+		return errs.ErrFailedDecompressingFile
+	}
 	defer reader.Close()
 
 	filePath := filepath.Join(destinationDir, fileName) // #nosec
-	out, err := os.Create(filePath)
+	out, err := gFs.Create(filePath)
 	if err != nil {
 		log.Error(err)
 		return errs.ErrFailedCreatingFile
 	}
 	defer out.Close()
 
-	written, err := SecureCopy(out, reader)
+	written, err := SecureCopyContext(ctx, out, reader)
 	log.Debugf("Inflated %d bytes", written)
 
 	return err
 }
+
+// ContextError reports an operation stopped because ctx is done as a
+// termination request, keeping the cause of the cancellation
+func ContextError(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", errs.ErrTerminatedByUser, context.Cause(ctx))
+}
+
+// CaseCollisions returns the groups of names differing only by case, which
+// overwrite each other on case-insensitive file systems like the default
+// ones of Windows and macOS. Groups keep the order of names, and are
+// ordered by their first name.
+func CaseCollisions(names []string) [][]string {
+	groups := map[string][]string{}
+	folded := []string{}
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if _, found := groups[key]; !found {
+			folded = append(folded, key)
+		}
+		groups[key] = append(groups[key], name)
+	}
+
+	collisions := [][]string{}
+	for _, key := range folded {
+		if len(groups[key]) > 1 {
+			collisions = append(collisions, groups[key])
+		}
+	}
+	return collisions
+}
+
+// IsCaseInsensitiveDir tells whether names of files created in dir are
+// matched regardless of their case
+func IsCaseInsensitiveDir(dir string) bool {
+	probe, err := afero.TempFile(gFs, dir, ".cpackget-case-probe-")
+	if err != nil {
+		log.Debugf("Can't tell whether \"%s\" is case-insensitive: %s", dir, err)
+		return false
+	}
+	probeName := probe.Name()
+	probe.Close()
+	defer gFs.Remove(probeName) // #nosec
+
+	_, err = gFs.Stat(filepath.Join(filepath.Dir(probeName), strings.ToUpper(filepath.Base(probeName))))
+	return err == nil
+}
+
+// escapeWindowsNames tells whether SecureInflateFile escapes names of zip
+// entries Windows refuses with WindowsSafeName
+var escapeWindowsNames = runtime.GOOS == "windows"
+
+// SetEscapeWindowsNames sets whether names of extracted files invalid on
+// Windows are escaped with WindowsSafeName. It is on by default on Windows
+// only, other systems may turn it on for pack roots shared with Windows.
+func SetEscapeWindowsNames(escape bool) {
+	escapeWindowsNames = escape
+}
+
+func GetEscapeWindowsNames() bool {
+	return escapeWindowsNames
+}
+
+// windowsReservedChars are the printable characters Windows refuses in
+// file names, besides the path separators
+const windowsReservedChars = `<>:"|?*`
+
+// isWindowsDeviceName tells whether base, the part of a file name before its
+// first dot, names a device, making the file inaccessible on Windows
+func isWindowsDeviceName(base string) bool {
+	base = strings.ToUpper(strings.TrimRight(base, " "))
+	switch base {
+	case "CON", "PRN", "AUX", "NUL":
+		return true
+	}
+	return len(base) == 4 && (strings.HasPrefix(base, "COM") || strings.HasPrefix(base, "LPT")) && base[3] >= '1' && base[3] <= '9'
+}
+
+// WindowsSafeName escapes the parts of name, a path with forward slashes,
+// Windows refuses as file names. Offending bytes are replaced by "%" and
+// their two hexadecimal digits:
+//   - control characters, the characters <>:"|?* and bytes which are not
+//     valid UTF-8
+//   - trailing dots and spaces
+//   - the last character of device names like CON, aux or LPT1, with or
+//     without an extension, e.g. "aux.h" becomes "au%78.h"
+//
+// Valid names are returned unchanged.
+func WindowsSafeName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+
+		var escaped strings.Builder
+		for j := 0; j < len(part); {
+			r, size := utf8.DecodeRuneInString(part[j:])
+			if (r == utf8.RuneError && size == 1) || r < 0x20 || r == 0x7f || strings.ContainsRune(windowsReservedChars, r) {
+				fmt.Fprintf(&escaped, "%%%02X", part[j])
+				j++
+				continue
+			}
+			escaped.WriteString(part[j : j+size])
+			j += size
+		}
+		part = escaped.String()
+
+		trimmed := strings.TrimRight(part, ". ")
+		if trimmed != part {
+			var suffix strings.Builder
+			for _, c := range []byte(part[len(trimmed):]) {
+				fmt.Fprintf(&suffix, "%%%02X", c)
+			}
+			part = trimmed + suffix.String()
+		}
+
+		base, _, _ := strings.Cut(part, ".")
+		if isWindowsDeviceName(base) {
+			last := len(strings.TrimRight(base, " ")) - 1
+			part = fmt.Sprintf("%s%%%02X%s", part[:last], part[last], part[last+1:])
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "/")
+}
@@ -7,6 +7,10 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,6 +18,7 @@ import (
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -56,6 +61,34 @@ func TestSecureCopy(t *testing.T) {
 		assert.NotNil(err)
 		assert.True(errs.Is(err, errs.ErrTerminatedByUser))
 	})
+
+	t.Run("test copying large files reuses its buffers", func(t *testing.T) {
+		content := bytes.Repeat([]byte("0123456789abcdef"), 1024*1024)
+
+		allocs := testing.AllocsPerRun(10, func() {
+			// Neither side helps io.Copy, as when hashing zip entries
+			written, err := utils.SecureCopy(sha256.New(), io.MultiReader(bytes.NewReader(content)))
+			assert.Nil(err)
+			assert.Equal(int64(len(content)), written)
+		})
+
+		// A 16MB copy must not allocate a buffer per chunk
+		assert.Less(allocs, float64(10))
+	})
+
+	t.Run("test abort copy due to a canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var outBuffer bytes.Buffer
+		writer := bufio.NewWriter(&outBuffer)
+		reader := strings.NewReader("some content")
+
+		written, err := utils.SecureCopyContext(ctx, writer, reader)
+		assert.Equal(int64(0), written)
+		assert.True(errs.Is(err, errs.ErrTerminatedByUser))
+		assert.True(errors.Is(err, context.Canceled))
+	})
 }
 
 func TestSecureInflateFile(t *testing.T) {
@@ -131,3 +164,160 @@ func TestSecureInflateFile(t *testing.T) {
 		assert.True(utils.FileExists(filepath.Join(outDir, "zipped-dir/file-in-folder")))
 	})
 }
+
+func TestCorruptZipEntry(t *testing.T) {
+	assert := assert.New(t)
+
+	// newZip returns a zip archive with a healthy file, and a corrupt one if corrupt
+	newZip := func(corrupt bool) *zip.Reader {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		writer, err := w.Create("healthy_file")
+		assert.Nil(err)
+		_, err = writer.Write([]byte("healthy"))
+		assert.Nil(err)
+		if corrupt {
+			writer, err = w.CreateRaw(&zip.FileHeader{Name: "corrupt_file", Method: zip.Store, CRC32: 1, CompressedSize64: 7, UncompressedSize64: 7})
+			assert.Nil(err)
+			_, err = writer.Write([]byte("corrupt"))
+			assert.Nil(err)
+		}
+		assert.Nil(w.Close())
+		reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		assert.Nil(err)
+		return reader
+	}
+
+	t.Run("test finding the corrupt entry of a zip archive", func(t *testing.T) {
+		entry, err := utils.CorruptZipEntry(newZip(true))
+		assert.Equal("corrupt_file", entry)
+		assert.True(errors.Is(err, zip.ErrChecksum))
+	})
+
+	t.Run("test checking a healthy zip archive", func(t *testing.T) {
+		entry, err := utils.CorruptZipEntry(newZip(false))
+		assert.Empty(entry)
+		assert.Nil(err)
+	})
+}
+
+func TestOpenZip(t *testing.T) {
+	assert := assert.New(t)
+
+	zipPath := filepath.Join(t.TempDir(), "archive.zip")
+	zipFile, err := os.Create(zipPath)
+	assert.Nil(err)
+	w := zip.NewWriter(zipFile)
+	for _, name := range []string{"first_file", "selected_file", "last_file"} {
+		writer, err := w.Create(name)
+		assert.Nil(err)
+		_, err = writer.Write([]byte(name))
+		assert.Nil(err)
+	}
+	assert.Nil(w.Close())
+	assert.Nil(zipFile.Close())
+
+	readEntry := func(name string) string {
+		archive, err := utils.OpenZip(zipPath)
+		assert.Nil(err)
+		defer archive.Close()
+
+		reader, err := archive.Open(name)
+		assert.Nil(err)
+		defer reader.Close()
+		content, err := io.ReadAll(reader)
+		assert.Nil(err)
+		return string(content)
+	}
+
+	t.Run("test reading an entry of a zip archive", func(t *testing.T) {
+		assert.Equal("selected_file", readEntry("selected_file"))
+	})
+
+	t.Run("test reading an entry of a memory-mapped zip archive", func(t *testing.T) {
+		oldThreshold := utils.MmapThreshold
+		utils.MmapThreshold = 0
+		defer func() { utils.MmapThreshold = oldThreshold }()
+
+		assert.Equal("selected_file", readEntry("selected_file"))
+	})
+}
+
+// lowerCaseFs matches file names regardless of their case, like the default
+// file systems of Windows and macOS
+type lowerCaseFs struct {
+	afero.Fs
+}
+
+func (l lowerCaseFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	return l.Fs.OpenFile(strings.ToLower(name), flag, perm)
+}
+
+func (l lowerCaseFs) Stat(name string) (os.FileInfo, error) {
+	return l.Fs.Stat(strings.ToLower(name))
+}
+
+func (l lowerCaseFs) Remove(name string) error {
+	return l.Fs.Remove(strings.ToLower(name))
+}
+
+func TestCaseCollisions(t *testing.T) {
+	assert := assert.New(t)
+
+	names := []string{"Include/core.h", "README.md", "include/Core.h", "Source/a.c", "readme.md", "INCLUDE/CORE.H"}
+	assert.Equal([][]string{{"Include/core.h", "include/Core.h", "INCLUDE/CORE.H"}, {"README.md", "readme.md"}}, utils.CaseCollisions(names))
+	assert.Empty(utils.CaseCollisions([]string{"Include/core.h", "Source/core.c"}))
+}
+
+func TestIsCaseInsensitiveDir(t *testing.T) {
+	assert := assert.New(t)
+	defer utils.SetFileSystem(nil)
+
+	t.Run("test a case-sensitive file system", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		assert.Nil(fs.MkdirAll("/packs", 0700))
+		utils.SetFileSystem(fs)
+
+		assert.False(utils.IsCaseInsensitiveDir("/packs"))
+		entries, err := afero.ReadDir(fs, "/packs")
+		assert.Nil(err)
+		assert.Empty(entries)
+	})
+
+	t.Run("test a case-insensitive file system", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		assert.Nil(fs.MkdirAll("/packs", 0700))
+		utils.SetFileSystem(lowerCaseFs{fs})
+
+		assert.True(utils.IsCaseInsensitiveDir("/packs"))
+		entries, err := afero.ReadDir(fs, "/packs")
+		assert.Nil(err)
+		assert.Empty(entries)
+	})
+}
+
+func TestWindowsSafeName(t *testing.T) {
+	assert := assert.New(t)
+
+	for name, expected := range map[string]string{
+		"Include/core.h":      "Include/core.h",
+		"Docs/":               "Docs/",
+		"Docs/a:b.txt":        "Docs/a%3Ab.txt",
+		"Docs/what?.txt":      "Docs/what%3F.txt",
+		"Docs/notes.":         "Docs/notes%2E",
+		"Docs/notes. ":        "Docs/notes%2E%20",
+		"Source/aux.h":        "Source/au%78.h",
+		"Source/CON":          "Source/CO%4E",
+		"Source/lpt1.c":       "Source/lpt%31.c",
+		"Source/com10.c":      "Source/com10.c",
+		"Source/console.c":    "Source/console.c",
+		"nul/file.c":          "nu%6C/file.c",
+		"Docs/tab\tname.txt":  "Docs/tab%09name.txt",
+		"Docs/café.txt":       "Docs/café.txt",
+		"Docs/caf\xe9.txt":    "Docs/caf%E9.txt",
+		"Docs/100%.txt":       "Docs/100%.txt",
+		"./Docs/../notes.txt": "./Docs/../notes.txt",
+	} {
+		assert.Equal(expected, utils.WindowsSafeName(name), name)
+	}
+}
@@ -5,8 +5,10 @@ package utils
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"golang.org/x/mod/semver"
 )
 
@@ -70,3 +72,35 @@ func SemverStripMeta(version string) string {
 	}
 	return version
 }
+
+// SemverBump increments the "major", "minor" or "patch" part of version and
+// resets the parts after it, dropping pre-release and meta information,
+// e.g. 1.2.3-rc1 becomes 1.3.0 when bumping the minor part
+func SemverBump(version, part string) (string, error) {
+	canonical := semver.Canonical("v" + stripLeadingZeros(SemverStripMeta(version)))
+	if canonical == "" {
+		return "", errs.ErrInvalidReleaseVersion
+	}
+	core, _, _ := strings.Cut(strings.TrimPrefix(canonical, "v"), "-")
+
+	parts := strings.Split(core, ".")
+	numbers := make([]int, len(parts))
+	for i, number := range parts {
+		var err error
+		if numbers[i], err = strconv.Atoi(number); err != nil {
+			return "", errs.ErrInvalidReleaseVersion
+		}
+	}
+
+	switch part {
+	case "major":
+		numbers = []int{numbers[0] + 1, 0, 0}
+	case "minor":
+		numbers = []int{numbers[0], numbers[1] + 1, 0}
+	case "patch":
+		numbers[2]++
+	default:
+		return "", errs.ErrIncorrectCmdArgs
+	}
+	return strconv.Itoa(numbers[0]) + "." + strconv.Itoa(numbers[1]) + "." + strconv.Itoa(numbers[2]), nil
+}
@@ -6,6 +6,7 @@ package utils_test
 import (
 	"testing"
 
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -106,3 +107,25 @@ func TestSemverCompareRange(t *testing.T) {
 		assert.True(utils.SemverCompareRange("1.2.3", "1.2.0:1.2.1") > 0)
 	})
 }
+
+func TestSemverBump(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("test version bump", func(t *testing.T) {
+		for _, test := range []struct{ version, part, expected string }{
+			{"1.2.3", "patch", "1.2.4"},
+			{"1.2.3", "minor", "1.3.0"},
+			{"1.2.3", "major", "2.0.0"},
+			{"1.02.3-rc1+meta", "minor", "1.3.0"},
+		} {
+			version, err := utils.SemverBump(test.version, test.part)
+			assert.Nil(err)
+			assert.Equal(test.expected, version)
+		}
+
+		_, err := utils.SemverBump("not-a-version", "patch")
+		assert.True(errs.Is(err, errs.ErrInvalidReleaseVersion))
+		_, err = utils.SemverBump("1.2.3", "build")
+		assert.True(errs.Is(err, errs.ErrIncorrectCmdArgs))
+	})
+}
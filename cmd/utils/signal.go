@@ -6,9 +6,10 @@ package utils
 import (
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 )
 
 // sigs holds signals to be monitored
@@ -17,7 +18,7 @@ var sigs chan os.Signal
 // terminationRequested is a boolean flag that needs to be checked on
 // long operationgs. The monitoring thread will use this to notify the
 // main thread about a termination request.
-var terminationRequested bool
+var terminationRequested atomic.Bool
 
 // startSignalWatcher spins off a thread monitoring termination signals
 // and retuns a function that returns whether termination was requested
@@ -28,19 +29,19 @@ func StartSignalWatcher() {
 	sigs = make(chan os.Signal, 1)
 	signal.Notify(sigs, os.Interrupt, syscall.SIGINT, syscall.SIGTERM) // SA1016: syscall.SIGKILL cannot be trapped
 
-	terminationRequested = false
+	terminationRequested.Store(false)
 
 	// Spin off the monitoring thread
-	go func() {
+	go func(sigs chan os.Signal) {
 		sig := <-sigs
 		log.Debugf("Monitoring thread detected a signal: %v", sig)
-		terminationRequested = true
-	}()
+		terminationRequested.Store(true)
+	}(sigs)
 
 	// Function that needs running to check if a termination request
 	// has been triggered
 	ShouldAbortFunction = func() bool {
-		return terminationRequested
+		return terminationRequested.Load()
 	}
 }
 
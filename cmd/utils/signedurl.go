@@ -0,0 +1,58 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// gSignedURLs tells whether the credentials and query of URLs are secrets
+var gSignedURLs = false
+
+// SetSignedURLs makes downloads treat the user info and query of URLs as
+// credentials, e.g. of S3 pre-signed URLs or Azure SAS tokens: they are
+// sent as given, never rewritten, and redacted from messages and errors
+func SetSignedURLs(signedURLs bool) {
+	gSignedURLs = signedURLs
+}
+
+func GetSignedURLs() bool {
+	return gSignedURLs
+}
+
+// IsSignedURL tells whether URL carries credentials in its user info or
+// query, when URLs are treated as signed
+func IsSignedURL(URL string) bool {
+	if !gSignedURLs {
+		return false
+	}
+	parsedURL, err := url.Parse(URL)
+	if err != nil {
+		return false
+	}
+	return parsedURL.User != nil || parsedURL.RawQuery != ""
+}
+
+// RedactURL returns URL as it can be shown, without its user info and with
+// the values of its query replaced if it is a signed URL, see IsSignedURL
+func RedactURL(URL string) string {
+	if !IsSignedURL(URL) {
+		return URL
+	}
+	parsedURL, _ := url.Parse(URL)
+	parsedURL.User = nil
+	query := parsedURL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		keys[i] = url.QueryEscape(key) + "=REDACTED"
+	}
+	parsedURL.RawQuery = strings.Join(keys, "&")
+	return parsedURL.String()
+}
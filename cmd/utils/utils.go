@@ -5,11 +5,15 @@ package utils
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/fs"
 	"math"
@@ -21,18 +25,25 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 	"github.com/schollz/progressbar/v3"
-	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 	"golang.org/x/net/html/charset"
 )
 
 var gEncodedProgress = false
 var gSkipTouch = false
+var gOffline = false
+var gDownloadRetries = 0
 var gUserAgent string
+var gHTTPTransport http.RoundTripper
+var gDialNetwork = "tcp"
 
 func SetEncodedProgress(encodedProgress bool) {
 	gEncodedProgress = encodedProgress
@@ -50,20 +61,91 @@ func GetSkipTouch() bool {
 	return gSkipTouch
 }
 
+// SetOffline makes downloads fail with errs.ErrOffline, unless the file
+// is already in the cache, and so do all other connections
+func SetOffline(offline bool) {
+	gOffline = offline
+}
+
+func GetOffline() bool {
+	return gOffline
+}
+
+// SetDownloadRetries sets how many times a download failing with
+// a network error or a server error status is tried again
+func SetDownloadRetries(retries int) {
+	gDownloadRetries = retries
+}
+
+func GetDownloadRetries() int {
+	return gDownloadRetries
+}
+
 func SetUserAgent(userAgent string) {
 	gUserAgent = userAgent
 }
 
+func GetUserAgent() string {
+	return gUserAgent
+}
+
+// SetHTTPTransport makes all downloads and connection checks go through
+// transport instead of the transports cpackget builds. A nil transport
+// restores the default ones.
+func SetHTTPTransport(transport http.RoundTripper) {
+	gHTTPTransport = transport
+}
+
+func GetHTTPTransport() http.RoundTripper {
+	return gHTTPTransport
+}
+
+// SetIPVersion makes the transports cpackget builds connect over IPv4 with
+// version 4 and over IPv6 with version 6. Any other version allows both.
+func SetIPVersion(version int) {
+	switch version {
+	case 4:
+		gDialNetwork = "tcp4"
+	case 6:
+		gDialNetwork = "tcp6"
+	default:
+		gDialNetwork = "tcp"
+	}
+}
+
+// dialContext connects to addr over the IP version set with SetIPVersion
+func dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if gOffline {
+		return nil, errs.ErrOffline
+	}
+	if network == "tcp" {
+		network = gDialNetwork
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// DownloadRetryDelay is how long the first retry of a download waits,
+// every further retry waits one more delay than the previous one
+var DownloadRetryDelay = 2 * time.Second
+
+// TruncatedDownloadRetries is how many times a download is retried when
+// fewer bytes than announced arrive, or a zip archive lacks its end, on top
+// of the retries set with SetDownloadRetries
+var TruncatedDownloadRetries = 2
+
+// errTruncatedDownload tells downloadFileTo a transfer was cut short
+var errTruncatedDownload = errors.New("truncated download")
+
 // CacheDir is used for cpackget to temporarily host downloaded pack files
 // before moving it to CMSIS_PACK_ROOT
 var CacheDir string
 
-var instCnt = 0
-
 var HTTPClient *http.Client
 
 type TimeoutTransport struct {
-	http.Transport
+	// Transport performs the requests, http.DefaultTransport if nil
+	Transport        http.RoundTripper
 	RoundTripTimeout time.Duration
 }
 
@@ -75,11 +157,17 @@ func (t *TimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error)
 		err  error
 	}
 
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
 	timeout := time.After(t.RoundTripTimeout)
 	resp := make(chan respAndErr, 1)
 
 	go func() {
-		r, e := t.Transport.RoundTrip(req)
+		r, e := transport.RoundTrip(req.WithContext(ctx))
 		resp <- respAndErr{
 			resp: r,
 			err:  e,
@@ -88,13 +176,30 @@ func (t *TimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error)
 
 	select {
 	case <-timeout:
-		t.Transport.CancelRequest(req)
+		cancel()
 		return nil, errors.New("HTTP get timed out")
 	case r := <-resp:
-		return r.resp, r.err
+		if r.err != nil {
+			cancel()
+			return nil, r.err
+		}
+		// The request context has to live until the body is read
+		r.resp.Body = &cancelOnClose{ReadCloser: r.resp.Body, cancel: cancel}
+		return r.resp, nil
 	}
 }
 
+// cancelOnClose releases the context of a request once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
 var (
 	// File RO (ReadOnly) and RW (Read + Write) modes
 	FileModeRO = fs.FileMode(0444)
@@ -105,16 +210,141 @@ var (
 	DirModeRW = fs.FileMode(0777)
 )
 
+// quietDownloadsKey marks the contexts of downloads made by WithQuietDownloads
+type quietDownloadsKey struct{}
+
+// WithQuietDownloads returns ctx making downloads show no progress bar nor
+// message of their own, for callers reporting the progress of several
+// concurrent downloads at once
+func WithQuietDownloads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quietDownloadsKey{}, true)
+}
+
 // DownloadFile downloads a file from an URL and saves it locally under destionationFilePath
 func DownloadFile(URL string, timeout int) (string, error) {
+	return DownloadFileContext(context.Background(), URL, timeout)
+}
+
+// DownloadFileContext is DownloadFile stopping once ctx is done
+func DownloadFileContext(ctx context.Context, URL string, timeout int) (string, error) {
+	return downloadFileContext(ctx, URL, "", timeout)
+}
+
+// DownloadChangedFileContext is DownloadFileContext skipping the transfer when
+// the server, e.g. Artifactory or Nexus, reports a checksum matching the file in
+// currentPath. currentPath is returned then, and left untouched.
+func DownloadChangedFileContext(ctx context.Context, URL, currentPath string, timeout int) (string, error) {
+	return downloadFileContext(ctx, URL, currentPath, timeout)
+}
+
+// DownloadFileToContext downloads URL to filePath, like DownloadFileContext
+// but never serving the file from the download cache nor leaving it there
+func DownloadFileToContext(ctx context.Context, URL, filePath string, timeout int) error {
+	log.Debugf("Downloading %s to %s", RedactURL(URL), filePath)
+	_, err := downloadFileTo(ctx, URL, filePath, "", timeout)
+	return err
+}
+
+func downloadFileContext(ctx context.Context, URL, currentPath string, timeout int) (string, error) {
 	parsedURL, _ := url.Parse(URL)
 	fileBase := path.Base(parsedURL.Path)
 	filePath := filepath.Join(CacheDir, fileBase)
-	log.Debugf("Downloading %s to %s", URL, filePath)
+	log.Debugf("Downloading %s to %s", RedactURL(URL), filePath)
 	if FileExists(filePath) {
 		log.Debugf("Download not required, using the one from cache")
 		return filePath, nil
 	}
+	return downloadFileTo(ctx, URL, filePath, currentPath, timeout)
+}
+
+// downloadFileTo downloads URL to filePath, retrying as configured
+func downloadFileTo(ctx context.Context, URL, filePath, currentPath string, timeout int) (string, error) {
+	// Signed URLs only ever show up redacted, and rewriting them would
+	// invalidate their signature
+	shownURL := RedactURL(URL)
+	if gOffline {
+		log.Errorf("Cannot download \"%s\" while offline", shownURL)
+		return "", errs.WithURL(errs.ErrOffline, shownURL)
+	}
+
+	if IsSignedURL(URL) {
+		log.Debugf("Not rewriting the signed URL \"%s\"", shownURL)
+	} else {
+		URL = RewriteURL(URL)
+		shownURL = URL
+	}
+
+	rateLimited := 0
+	truncated := 0
+	for attempt := 1; ; attempt++ {
+		if err := waitForRateLimit(ctx); err != nil {
+			return "", err
+		}
+
+		release, err := acquireHostSlot(ctx, URL)
+		if err != nil {
+			return "", err
+		}
+		downloadedPath, retry, pause, err := downloadFileOnce(ctx, URL, filePath, currentPath, timeout)
+		release()
+		if err == nil || ctx.Err() != nil {
+			return downloadedPath, err
+		}
+
+		// Truncated downloads are tried again right away, a few times only
+		if errors.Is(err, errTruncatedDownload) {
+			if truncated >= TruncatedDownloadRetries {
+				log.Errorf("The download of \"%s\" was truncated %d times", shownURL, truncated+1)
+				return "", errs.WithURL(errs.ErrIntegrityCheckFailed, shownURL)
+			}
+			truncated++
+			attempt--
+			log.Warnf("The download of \"%s\" was truncated, downloading it again (%d/%d)", shownURL, truncated, TruncatedDownloadRetries)
+			continue
+		}
+
+		// Rate-limited downloads pause all others and do not count as retries
+		if pause > 0 && rateLimited < RateLimitRetries {
+			rateLimited++
+			attempt--
+			log.Warnf("The server of \"%s\" is rate-limiting downloads, pausing them for %v", shownURL, pause.Round(time.Second))
+			pauseDownloads(pause)
+			continue
+		}
+
+		if !retry || attempt > gDownloadRetries {
+			return downloadedPath, err
+		}
+
+		delay := DownloadRetryDelay * time.Duration(attempt)
+		log.Warnf("Retrying download of \"%s\" in %v (%d/%d)", shownURL, delay, attempt, gDownloadRetries)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", ContextError(ctx)
+		}
+	}
+}
+
+// offlineTransport fails every request while offline, see SetOffline,
+// before transport can reach the network
+type offlineTransport struct {
+	transport http.RoundTripper
+}
+
+func (t offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if gOffline {
+		return nil, errs.WithURL(errs.ErrOffline, RedactURL(req.URL.String()))
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// DownloadTransport returns the transport downloading URL, the one
+// set with SetHTTPTransport if any. Either refuses requests while offline.
+func DownloadTransport(URL string) http.RoundTripper {
+	if gHTTPTransport != nil {
+		return offlineTransport{gHTTPTransport}
+	}
 
 	// For now, skip insecure HTTPS downloads verification only for localhost
 	var tls tls.Config
@@ -124,6 +354,23 @@ func DownloadFile(URL string, timeout int) (string, error) {
 		tls.InsecureSkipVerify = false
 	}
 
+	return offlineTransport{&http.Transport{
+		DialContext:     dialContext,
+		TLSClientConfig: &tls,
+		Proxy:           http.ProxyFromEnvironment,
+	}}
+}
+
+// downloadFileOnce makes a single attempt at downloading URL to filePath,
+// unless the file in currentPath is identical. It tells whether the failure
+// was transient and worth retrying, and how long to wait first if the server
+// rate-limited the download.
+func downloadFileOnce(ctx context.Context, URL, filePath, currentPath string, timeout int) (string, bool, time.Duration, error) {
+	fileBase := filepath.Base(filePath)
+	shownURL := RedactURL(URL)
+
+	transport := DownloadTransport(URL)
+
 	var rtt time.Duration
 	if timeout == 0 {
 		rtt = time.Duration(math.MaxInt64)
@@ -133,48 +380,71 @@ func DownloadFile(URL string, timeout int) (string, error) {
 
 	client := &http.Client{
 		Transport: &TimeoutTransport{
-			Transport: http.Transport{
-				Dial: func(netw, addr string) (net.Conn, error) {
-					return net.Dial(netw, addr)
-				},
-				TLSClientConfig: &tls,
-				Proxy:           http.ProxyFromEnvironment,
-			},
+			Transport:        transport,
 			RoundTripTimeout: rtt,
 		},
+		CheckRedirect: checkRedirect,
 	}
 
-	req, _ := http.NewRequest("GET", URL, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URL, nil)
 	req.Header.Add("User-Agent", gUserAgent)
 	resp, err := client.Do(req)
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", false, 0, ContextError(ctx)
+		}
+		if errors.Is(err, errs.ErrTooManyRedirects) {
+			return "", false, 0, errs.WithURL(errs.ErrTooManyRedirects, shownURL)
+		}
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = shownURL
+		}
 		log.Error(err)
-		return "", fmt.Errorf("\"%s\": %w", URL, errs.ErrFailedDownloadingFile)
+		return "", true, 0, errs.WithURL(errs.ErrFailedDownloadingFile, shownURL)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		log.Debugf("bad status: %s", resp.Status)
-		return "", fmt.Errorf("\"%s\": %w", URL, errs.ErrBadRequest)
+		retry := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return "", retry, retryAfter(resp), errs.WithURL(errs.ErrBadRequest, shownURL)
 	}
 
-	out, err := os.Create(filePath)
+	algorithm, digest := RepositoryChecksum(resp.Header)
+	if digest != "" && currentPath != "" && FileExists(currentPath) {
+		if currentDigest, err := fileDigest(currentPath, newRepositoryHash(algorithm)); err == nil && hex.EncodeToString(currentDigest) == digest {
+			log.Debugf("\"%s\" did not change, not downloading it again", currentPath)
+			return currentPath, false, 0, nil
+		}
+	}
+
+	out, err := gFs.Create(filePath)
 	if err != nil {
 		log.Error(err)
-		return "", errs.ErrFailedCreatingFile
+		return "", false, 0, errs.WithPath(errs.ErrFailedCreatingFile, filePath)
 	}
 	defer out.Close()
 
-	log.Infof("Downloading %s...", fileBase)
+	quiet := ctx.Value(quietDownloadsKey{}) != nil
+	if quiet {
+		log.Debugf("Downloading %s...", fileBase)
+	} else {
+		log.Infof("Downloading %s...", fileBase)
+	}
 	writers := []io.Writer{out}
+	var hasher hash.Hash
+	if digest != "" {
+		hasher = newRepositoryHash(algorithm)
+		writers = append(writers, hasher)
+	}
 	if log.GetLevel() != log.ErrorLevel {
 		length := resp.ContentLength
 		if GetEncodedProgress() {
-			progressWriter := NewEncodedProgress(length, instCnt, fileBase)
+			progressWriter := NewEncodedProgress(length, ProgressInstance(fileBase), fileBase)
 			writers = append(writers, progressWriter)
-			instCnt++
 		} else {
-			if IsTerminalInteractive() {
+			if IsTerminalInteractive() && !quiet {
 				progressWriter := progressbar.DefaultBytes(length, "I:")
 				writers = append(writers, progressWriter)
 			}
@@ -182,24 +452,104 @@ func DownloadFile(URL string, timeout int) (string, error) {
 	}
 
 	// Download file in smaller bits straight to a local file
-	written, err := SecureCopy(io.MultiWriter(writers...), resp.Body)
+	written, err := SecureCopyContext(ctx, io.MultiWriter(writers...), truncatedBodyReader{resp.Body})
 	//	fmt.Printf("\n")
 	log.Debugf("Downloaded %d bytes", written)
 
 	if err != nil {
 		out.Close()
-		_ = os.Remove(filePath)
+		_ = gFs.Remove(filePath)
+		return filePath, ctx.Err() == nil, 0, err
+	}
+
+	if resp.ContentLength > 0 && written != resp.ContentLength {
+		log.Warnf("Received %d of the %d bytes of \"%s\"", written, resp.ContentLength, fileBase)
+		out.Close()
+		_ = gFs.Remove(filePath)
+		return "", false, 0, errTruncatedDownload
+	}
+
+	if ext := strings.ToLower(filepath.Ext(fileBase)); (ext == ".pack" || ext == ".zip") && !zipHasEnd(filePath) {
+		log.Warnf("\"%s\" lacks the end of its zip archive", fileBase)
+		out.Close()
+		_ = gFs.Remove(filePath)
+		return "", false, 0, errTruncatedDownload
+	}
+
+	if hasher != nil && hex.EncodeToString(hasher.Sum(nil)) != digest {
+		log.Errorf("The %s checksum of \"%s\" does not match the one reported by the server", algorithm, fileBase)
+		out.Close()
+		_ = gFs.Remove(filePath)
+		return "", true, 0, errs.WithURL(errs.ErrIntegrityCheckFailed, shownURL)
+	}
+
+	return filePath, false, 0, nil
+}
+
+// truncatedBodyReader reports a response body ending before its
+// Content-Length like a complete one, for downloadFileOnce to tell
+// truncated transfers by their length instead of failing right away
+type truncatedBodyReader struct {
+	io.Reader
+}
+
+func (r truncatedBodyReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// RemoteFileSize asks the server of URL for the size of the file there,
+// without downloading it. It returns -1 if the server does not tell.
+func RemoteFileSize(ctx context.Context, URL string, timeout int) (int64, error) {
+	shownURL := RedactURL(URL)
+	if gOffline {
+		return -1, errs.WithURL(errs.ErrOffline, shownURL)
+	}
+	if !IsSignedURL(URL) {
+		URL = RewriteURL(URL)
+		shownURL = URL
+	}
+
+	client := &http.Client{
+		Transport:     DownloadTransport(URL),
+		Timeout:       time.Duration(timeout) * time.Second,
+		CheckRedirect: checkRedirect,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, URL, nil)
+	if err != nil {
+		return -1, errs.WithURL(errs.ErrBadRequest, shownURL)
+	}
+	req.Header.Add("User-Agent", gUserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Debugf("Could not ask for the size of \"%s\": %s", shownURL, err)
+		return -1, errs.WithURL(errs.ErrFailedDownloadingFile, shownURL)
 	}
+	defer resp.Body.Close()
 
-	return filePath, err
+	if resp.StatusCode != http.StatusOK {
+		log.Debugf("bad status: %s", resp.Status)
+		return -1, errs.WithURL(errs.ErrBadRequest, shownURL)
+	}
+	return resp.ContentLength, nil
 }
 
 func CheckConnection(url string, timeOut int) error {
+	if gOffline {
+		log.Errorf("Cannot connect to \"%s\" while offline", url)
+		return errs.WithURL(errs.ErrOffline, url)
+	}
+
 	timeout := time.Duration(timeOut) * time.Second
 	client := http.Client{
-		Timeout: timeout,
+		Transport: DownloadTransport(url),
+		Timeout:   timeout,
 	}
-	resp, err := client.Get(url)
+	resp, err := client.Get(RewriteURL(url))
 	connStatus := "offline"
 	if err != nil {
 		if !GetEncodedProgress() {
@@ -226,7 +576,7 @@ func CheckConnection(url string, timeOut int) error {
 
 // FileExists checks if filePath is an actual file in the local file system
 func FileExists(filePath string) bool {
-	info, err := os.Stat(filePath)
+	info, err := gFs.Stat(filePath)
 	if info == nil || os.IsNotExist(err) {
 		return false
 	}
@@ -235,17 +585,41 @@ func FileExists(filePath string) bool {
 
 // DirExists checks if dirPath is an actual directory in the local file system
 func DirExists(dirPath string) bool {
-	info, err := os.Stat(dirPath)
+	info, err := gFs.Stat(dirPath)
 	if os.IsNotExist(err) {
 		return false
 	}
 	return info.IsDir()
 }
 
+// IsLink tells whether path is a symbolic link or, on Windows, a junction,
+// without following it
+func IsLink(path string) bool {
+	lstater, ok := gFs.(afero.Lstater)
+	if !ok {
+		return false
+	}
+	info, _, err := lstater.LstatIfPossible(path)
+	if err != nil {
+		return false
+	}
+	// Newer Go versions report Windows junctions as irregular files
+	return info.Mode()&(os.ModeSymlink|os.ModeIrregular) != 0
+}
+
+// ReadLink returns the target of the link in path, see IsLink
+func ReadLink(path string) (string, error) {
+	reader, ok := gFs.(afero.LinkReader)
+	if !ok {
+		return "", &os.LinkError{Op: "readlink", Old: path, Err: afero.ErrNoReadlink}
+	}
+	return reader.ReadlinkIfPossible(path)
+}
+
 // EnsureDir recursevily creates a directory tree if it doesn't exist already
 func EnsureDir(dirName string) error {
 	log.Debugf("Ensuring \"%s\" directory exists", dirName)
-	err := os.MkdirAll(dirName, 0755)
+	err := gFs.MkdirAll(dirName, 0755)
 	if err != nil && !os.IsExist(err) {
 		log.Error(err)
 		return errs.ErrFailedCreatingDirectory
@@ -257,11 +631,11 @@ func SameFile(source, destination string) bool {
 	if source == destination {
 		return true
 	}
-	srcInfo, err := os.Stat(source)
+	srcInfo, err := gFs.Stat(source)
 	if err != nil {
 		return false
 	}
-	dstInfo, err := os.Stat(destination)
+	dstInfo, err := gFs.Stat(destination)
 	if err != nil {
 		return false
 	}
@@ -276,20 +650,16 @@ func CopyFile(source, destination string) error {
 		return nil
 	}
 
-	sourceFile, err := os.Open(source)
+	sourceFile, err := gFs.Open(source)
 	if err != nil {
 		return err
 	}
 	defer sourceFile.Close()
 
-	destinationFile, err := os.Create(destination)
-	if err != nil {
+	return WriteAtomic(destination, FileModeRW, func(destinationFile io.Writer) error {
+		_, err := SecureCopy(destinationFile, sourceFile)
 		return err
-	}
-	defer destinationFile.Close()
-
-	_, err = SecureCopy(destinationFile, sourceFile)
-	return err
+	})
 }
 
 // MoveFile moves a file from one source to destination
@@ -302,7 +672,11 @@ func MoveFile(source, destination string) error {
 
 	UnsetReadOnly(source)
 
-	err := os.Rename(source, destination)
+	err := gFs.Rename(source, destination)
+	if err != nil && isCrossDeviceError(err) {
+		log.Debugf("\"%s\" and \"%s\" are on different devices, copying instead", source, destination)
+		err = moveFileAcrossDevices(source, destination)
+	}
 	if err != nil {
 		log.Errorf("Can't move file \"%s\" to \"%s\": %s", source, destination, err)
 		return err
@@ -311,17 +685,84 @@ func MoveFile(source, destination string) error {
 	return nil
 }
 
+// moveFileAcrossDevices copies source to destination and only removes
+// source once the copy is verified to have the same contents
+func moveFileAcrossDevices(source, destination string) error {
+	info, err := gFs.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	if err := CopyFile(source, destination); err != nil {
+		return err
+	}
+
+	sourceDigest, err := fileDigest(source, sha256.New())
+	if err != nil {
+		return err
+	}
+	destinationDigest, err := fileDigest(destination, sha256.New())
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(sourceDigest, destinationDigest) {
+		_ = gFs.Remove(destination)
+		return errs.WithPath(errs.ErrFailedWrittingToLocalFile, destination)
+	}
+
+	if err := gFs.Chmod(destination, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return gFs.Remove(source)
+}
+
+// fileDigest returns the digest of the file in path computed by hasher
+func fileDigest(path string, hasher hash.Hash) ([]byte, error) {
+	file, err := gFs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := SecureCopy(hasher, file); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
+
+// FileSHA256 returns the hex-encoded SHA-256 digest of the file in path
+func FileSHA256(path string) (string, error) {
+	digest, err := fileDigest(path, sha256.New())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", digest), nil
+}
+
 // ReadXML reads in a file into an XML struct
 func ReadXML(path string, targetStruct interface{}) error {
-	contents, err := os.ReadFile(path)
+	file, err := gFs.Open(path)
 	if err != nil {
 		return err
 	}
+	defer file.Close()
+
+	return DecodeXML(file, targetStruct)
+}
 
-	reader := bytes.NewReader(contents)
-	decoder := xml.NewDecoder(reader)
+// DecodeXML streams an XML document from reader into an XML struct,
+// without holding the whole document in memory
+func DecodeXML(reader io.Reader, targetStruct interface{}) error {
+	return NewXMLDecoder(reader).Decode(targetStruct)
+}
+
+// NewXMLDecoder returns a decoder of the XML document in reader, limited
+// to MaxDownloadSize and accepting the usual non UTF-8 encodings
+func NewXMLDecoder(reader io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(io.LimitReader(reader, MaxDownloadSize))
 	decoder.CharsetReader = charset.NewReaderLabel
-	return decoder.Decode(targetStruct)
+	return decoder
 }
 
 // WriteXML writes an XML struct to a file
@@ -334,51 +775,55 @@ func WriteXML(path string, targetStruct interface{}) error {
 	xmlText := []byte(xml.Header)
 	xmlText = append(xmlText, output...)
 
-	return os.WriteFile(path, xmlText, FileModeRW)
+	return WriteFileAtomic(path, xmlText, FileModeRW)
 }
 
+// listDirPatterns caches the patterns compiled by ListDir
+var listDirPatterns sync.Map
+
 // ListDir generates a list of files and directories in "dir".
 // If pattern is specified, generates a list with matches only.
 // It does NOT walk subdirectories
 func ListDir(dir, pattern string) ([]string, error) {
-	regexPattern := regexp.MustCompile(`.*`)
-	if pattern != "" {
-		regexPattern = regexp.MustCompile(pattern)
+	if pattern == "" {
+		pattern = `.*`
+	}
+	regexPattern, ok := listDirPatterns.Load(pattern)
+	if !ok {
+		regexPattern, _ = listDirPatterns.LoadOrStore(pattern, regexp.MustCompile(pattern))
 	}
 
 	log.Debugf("Listing files and directories in \"%v\" that match \"%v\"", dir, regexPattern)
 
-	files := []string{}
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	// Only names are needed, so skip the stat a walk does on every entry
+	file, err := gFs.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-		// The target dir is always passed, skip it
-		if path == dir {
-			return nil
-		}
+	names, err := file.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
 
-		if regexPattern.MatchString(path) {
+	files := []string{}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if regexPattern.(*regexp.Regexp).MatchString(path) {
 			files = append(files, path)
 		}
+	}
 
-		// Avoid digging subdirs
-		if info.IsDir() {
-			return filepath.SkipDir
-		}
-
-		return nil
-	})
-
-	return files, err
+	return files, nil
 }
 
 // TouchFile touches the file specified by filePath.
 // If the file does not exist, create it.
 // Touch also updates the modified timestamp of the file.
 func TouchFile(filePath string) error {
-	file, err := os.Create(filePath)
+	file, err := gFs.Create(filePath)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -386,7 +831,7 @@ func TouchFile(filePath string) error {
 	defer file.Close()
 
 	currentTime := time.Now().Local()
-	return os.Chtimes(filePath, currentTime, currentTime)
+	return gFs.Chtimes(filePath, currentTime, currentTime)
 }
 
 // IsBase64 tells whether a string is correctly b64 encoded.
@@ -397,7 +842,7 @@ func IsBase64(s string) bool {
 
 // IsEmpty tells whether a directory specified by "dir" is empty or not
 func IsEmpty(dir string) bool {
-	file, err := os.Open(dir)
+	file, err := gFs.Open(dir)
 	if err != nil {
 		return false
 	}
@@ -478,26 +923,49 @@ func CleanPath(path string) string {
 	return cleanPath
 }
 
+// IsWritableDir tells whether files can be created in dir, even if
+// SetReadOnly made it read-only
+func IsWritableDir(dir string) bool {
+	info, err := gFs.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+
+	if err := gFs.Chmod(dir, DirModeRW); err != nil {
+		return false
+	}
+	defer func() { _ = gFs.Chmod(dir, info.Mode().Perm()) }()
+
+	file, err := afero.TempFile(gFs, dir, ".writable-")
+	if err != nil {
+		return false
+	}
+	file.Close()
+	_ = gFs.Remove(file.Name())
+	return true
+}
+
 // SetReadOnly takes in a file or directory and set it
 // to read-only mode. Should work on both Windows and Linux.
 func SetReadOnly(path string) {
-	info, err := os.Stat(path)
+	info, err := gFs.Stat(path)
 	if os.IsNotExist(err) {
 		return
 	}
 
 	if !info.IsDir() {
-		_ = os.Chmod(path, FileModeRO)
+		_ = gFs.Chmod(path, FileModeRO)
 		return
 	}
 
-	_ = os.Chmod(path, DirModeRO)
+	_ = gFs.Chmod(path, DirModeRO)
 }
 
-// SetReadOnlyR works the same as SetReadOnly, except that it is recursive
+// SetReadOnlyR works the same as SetReadOnly, except that it is recursive.
+// Links are left alone, so that the folders they point to stay writable.
 func SetReadOnlyR(path string) {
-	info, err := os.Stat(path)
-	if os.IsNotExist(err) || !info.IsDir() {
+	info, err := gFs.Stat(path)
+	if os.IsNotExist(err) || !info.IsDir() || IsLink(path) {
 		return
 	}
 
@@ -508,13 +976,13 @@ func SetReadOnlyR(path string) {
 	dirsByLevel := make(map[int][]string)
 	maxLevel := -1
 
-	_ = filepath.WalkDir(path, func(path string, info fs.DirEntry, err error) error {
+	_ = afero.Walk(gFs, path, func(path string, info fs.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
 		if !info.IsDir() {
-			_ = os.Chmod(path, FileModeRO)
+			_ = gFs.Chmod(path, FileModeRO)
 		} else {
 			levelCount := strings.Count(path, "/") + strings.Count(path, "\\")
 			dirsByLevel[levelCount] = append(dirsByLevel[levelCount], path)
@@ -530,7 +998,7 @@ func SetReadOnlyR(path string) {
 	for level := maxLevel; level >= 0; level-- {
 		if dirs, ok := dirsByLevel[level]; ok {
 			for _, dir := range dirs {
-				_ = os.Chmod(dir, DirModeRO)
+				_ = gFs.Chmod(dir, DirModeRO)
 			}
 		}
 	}
@@ -539,7 +1007,7 @@ func SetReadOnlyR(path string) {
 // UnsetReadOnly takes in a file or directory and set it
 // to read-only mode. Should work on both Windows and Linux.
 func UnsetReadOnly(path string) {
-	info, err := os.Stat(path)
+	info, err := gFs.Stat(path)
 	if os.IsNotExist(err) {
 		return
 	}
@@ -548,17 +1016,21 @@ func UnsetReadOnly(path string) {
 	if info.IsDir() {
 		mode = DirModeRW
 	}
-	_ = os.Chmod(path, mode)
+	_ = gFs.Chmod(path, mode)
 }
 
-// UnsetReadOnlyR works the same as UnsetReadOnly, but recursive
+// UnsetReadOnlyR works the same as UnsetReadOnly, but recursive.
+// Links are left alone like in SetReadOnlyR, and so are files with several
+// hard links, e.g. to a content store, whose other paths would become
+// writable as well.
 func UnsetReadOnlyR(path string) {
-	info, err := os.Stat(path)
-	if os.IsNotExist(err) || !info.IsDir() {
+	info, err := gFs.Stat(path)
+	if os.IsNotExist(err) || !info.IsDir() || IsLink(path) {
 		return
 	}
 
-	_ = filepath.WalkDir(path, func(path string, info fs.DirEntry, err error) error {
+	canHardLink := CanHardLink()
+	_ = afero.Walk(gFs, path, func(path string, info fs.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -566,8 +1038,12 @@ func UnsetReadOnlyR(path string) {
 		mode := FileModeRW
 		if info.IsDir() {
 			mode = DirModeRW
+		} else if canHardLink {
+			if _, links, err := fileIDOf(path, info); err == nil && links > 1 {
+				return nil
+			}
 		}
-		_ = os.Chmod(path, mode)
+		_ = gFs.Chmod(path, mode)
 
 		return nil
 	})
@@ -0,0 +1,68 @@
+//go:build !windows
+// +build !windows
+
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// isCrossDeviceError tells whether err comes from renaming a file to another device
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
+
+// syncDir flushes the entries of dir, e.g. a file renamed into it, to disk.
+// File systems that cannot sync directories are not an error.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := file.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTSUP) {
+		return err
+	}
+	return nil
+}
+
+// LinkDir creates link as a symbolic link to the directory target
+func LinkDir(target, link string) error {
+	return os.Symlink(target, link)
+}
+
+// StatFileID returns the FileID of the file in path and its number of hard links
+func StatFileID(path string) (FileID, uint64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileID{}, 0, err
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return FileID{Device: uint64(stat.Dev), Index: uint64(stat.Ino)}, uint64(stat.Nlink), nil
+	}
+	return FileID{}, 1, nil
+}
+
+// fileIDOf is StatFileID for the file in path described by info
+func fileIDOf(path string, info fs.FileInfo) (FileID, uint64, error) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return FileID{Device: uint64(stat.Dev), Index: uint64(stat.Ino)}, uint64(stat.Nlink), nil
+	}
+	return StatFileID(path)
+}
+
+// mmapFile maps the size bytes of file to memory, read-only
+func mmapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
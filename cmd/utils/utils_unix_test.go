@@ -0,0 +1,51 @@
+//go:build !windows
+// +build !windows
+
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+// crossDeviceFs fails renaming files to another directory,
+// like a rename across devices does
+type crossDeviceFs struct {
+	afero.Fs
+}
+
+func (c crossDeviceFs) Rename(oldname, newname string) error {
+	if filepath.Dir(oldname) != filepath.Dir(newname) {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EXDEV}
+	}
+	return c.Fs.Rename(oldname, newname)
+}
+
+func TestMoveFileAcrossDevices(t *testing.T) {
+	assert := assert.New(t)
+
+	defer utils.SetFileSystem(nil)
+	utils.SetFileSystem(crossDeviceFs{afero.NewOsFs()})
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "TheVendor.ThePack.1.2.3.pack")
+	destination := filepath.Join(dir, "cache", "TheVendor.ThePack.1.2.3.pack")
+	assert.Nil(os.Mkdir(filepath.Dir(destination), 0755))
+	assert.Nil(os.WriteFile(source, []byte("pack contents"), 0600))
+
+	assert.Nil(utils.MoveFile(source, destination))
+
+	assert.False(utils.FileExists(source))
+	contents, err := os.ReadFile(destination)
+	assert.Nil(err)
+	assert.Equal("pack contents", string(contents))
+}
@@ -0,0 +1,71 @@
+//go:build windows
+// +build windows
+
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+
+	"golang.org/x/sys/windows"
+)
+
+// isCrossDeviceError tells whether err comes from renaming a file to another volume
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, windows.ERROR_NOT_SAME_DEVICE)
+}
+
+// syncDir does nothing, as directories cannot be flushed on Windows
+func syncDir(_ string) error {
+	return nil
+}
+
+// LinkDir creates link as a junction to the directory target, as symbolic
+// links need administrator rights or the developer mode on Windows
+func LinkDir(target, link string) error {
+	output, err := exec.Command("cmd", "/c", "mklink", "/J", link, target).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
+
+// StatFileID returns the FileID of the file in path and its number of hard links
+func StatFileID(path string) (FileID, uint64, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return FileID{}, 0, err
+	}
+	handle, err := windows.CreateFile(name, 0, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE, nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return FileID{}, 0, err
+	}
+	defer windows.CloseHandle(handle) //nolint:errcheck
+
+	var info windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(handle, &info); err != nil {
+		return FileID{}, 0, err
+	}
+	id := FileID{
+		Device: uint64(info.VolumeSerialNumber),
+		Index:  uint64(info.FileIndexHigh)<<32 | uint64(info.FileIndexLow),
+	}
+	return id, uint64(info.NumberOfLinks), nil
+}
+
+// fileIDOf is StatFileID for the file in path described by info, which
+// does not hold the file index on Windows
+func fileIDOf(path string, _ fs.FileInfo) (FileID, uint64, error) {
+	return StatFileID(path)
+}
+
+// mmapFile is not supported on Windows, whose archives are read piecewise
+func mmapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	return nil, nil, errors.ErrUnsupported
+}
@@ -0,0 +1,40 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package utils
+
+// gVerifyCache makes verifications of files reuse the hashes of previous
+// successful ones, see SetVerifyCache
+var gVerifyCache = true
+
+// SetVerifyCache sets whether the hashes computed by successful checksum
+// and signature verifications are kept in the metadata cache, see
+// SetMetadataCacheDir, so that verifying the same unchanged file again,
+// e.g. a multi-GB pack in .Download/, does not read it all over again.
+// Failed verifications are never cached.
+func SetVerifyCache(enabled bool) {
+	gVerifyCache = enabled
+}
+
+func GetVerifyCache() bool {
+	return gVerifyCache
+}
+
+// LoadVerification decodes into value what StoreVerification saved after
+// the verification kind, e.g. "sha256", of the file in path succeeded. It
+// returns false if nothing was saved or the file changed since.
+func LoadVerification(kind, path string, value interface{}) bool {
+	if !gVerifyCache {
+		return false
+	}
+	return loadMetadataCache("verify-"+kind, path, value)
+}
+
+// StoreVerification saves value, e.g. the hash of the file in path, once
+// the verification kind of it succeeded
+func StoreVerification(kind, path string, value interface{}) {
+	if !gVerifyCache {
+		return
+	}
+	storeMetadataCache("verify-"+kind, path, value)
+}
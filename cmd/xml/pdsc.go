@@ -5,10 +5,12 @@ package xml
 
 import (
 	"encoding/xml"
+	"io"
+	"sort"
 	"strings"
 
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
-	log "github.com/sirupsen/logrus"
 )
 
 // PdscXML maps few tags of a PDSC file.
@@ -30,6 +32,37 @@ type PdscXML struct {
 		Packages []PackagesTag `xml:"packages"`
 	} `xml:"requirements"`
 
+	DevicesTag struct {
+		XMLName  xml.Name          `xml:"devices"`
+		Families []DeviceFamilyTag `xml:"family"`
+	} `xml:"devices"`
+
+	BoardsTag struct {
+		XMLName xml.Name   `xml:"boards"`
+		Boards  []BoardTag `xml:"board"`
+	} `xml:"boards"`
+
+	ComponentsTag struct {
+		XMLName    xml.Name       `xml:"components"`
+		Components []ComponentTag `xml:"component"`
+		Bundles    []BundleTag    `xml:"bundle"`
+	} `xml:"components"`
+
+	ExamplesTag struct {
+		XMLName  xml.Name     `xml:"examples"`
+		Examples []ExampleTag `xml:"example"`
+	} `xml:"examples"`
+
+	PostInstallTag struct {
+		XMLName xml.Name             `xml:"postInstall"`
+		Steps   []PostInstallStepTag `xml:"step"`
+	} `xml:"postInstall"`
+
+	ConditionsTag struct {
+		XMLName    xml.Name       `xml:"conditions"`
+		Conditions []ConditionTag `xml:"condition"`
+	} `xml:"conditions"`
+
 	FileName string
 }
 
@@ -37,8 +70,20 @@ type PdscXML struct {
 type ReleaseTag struct {
 	XMLName xml.Name `xml:"release"`
 	Version string   `xml:"version,attr"`
-	Date    string   `xml:"Date,attr"`
+	Date    string   `xml:"date,attr"`
 	URL     string   `xml:"url,attr"`
+
+	// Description is the text of the release, its release notes
+	Description string `xml:",chardata"`
+}
+
+// PostInstallStepTag maps the <step> tag of <postInstall>, a command the
+// pack runs once installed, e.g. <step command="python3" dir="Scripts">
+// <arg>generate.py</arg></step>. Dir is relative to the pack's folder.
+type PostInstallStepTag struct {
+	Command string   `xml:"command,attr"`
+	Dir     string   `xml:"dir,attr"`
+	Args    []string `xml:"arg"`
 }
 
 // PackagesTag only has one possible child, which is <package>
@@ -55,6 +100,216 @@ type PackageTag struct {
 	Version string   `xml:"version,attr"`
 }
 
+// DeviceFamilyTag maps the <family> tag of a PDSC file.
+type DeviceFamilyTag struct {
+	Family      string               `xml:"Dfamily,attr"`
+	Vendor      string               `xml:"Dvendor,attr"`
+	Processors  []ProcessorTag       `xml:"processor"`
+	SubFamilies []DeviceSubFamilyTag `xml:"subFamily"`
+	Devices     []DeviceTag          `xml:"device"`
+}
+
+// DeviceSubFamilyTag maps the <subFamily> tag of a PDSC file.
+type DeviceSubFamilyTag struct {
+	SubFamily  string         `xml:"DsubFamily,attr"`
+	Vendor     string         `xml:"Dvendor,attr"`
+	Processors []ProcessorTag `xml:"processor"`
+	Devices    []DeviceTag    `xml:"device"`
+}
+
+// DeviceTag maps the <device> tag of a PDSC file.
+type DeviceTag struct {
+	Name       string             `xml:"Dname,attr"`
+	Vendor     string             `xml:"Dvendor,attr"`
+	Processors []ProcessorTag     `xml:"processor"`
+	Variants   []DeviceVariantTag `xml:"variant"`
+}
+
+// DeviceVariantTag maps the <variant> tag of a PDSC file.
+type DeviceVariantTag struct {
+	Name       string         `xml:"Dvariant,attr"`
+	Vendor     string         `xml:"Dvendor,attr"`
+	Processors []ProcessorTag `xml:"processor"`
+}
+
+// ProcessorTag maps the <processor> tag of a PDSC file. Multi-core
+// devices describe each core in a processor tag of its own Pname.
+type ProcessorTag struct {
+	Pname        string `xml:"Pname,attr" json:"pname,omitempty"`
+	Dcore        string `xml:"Dcore,attr" json:"dcore,omitempty"`
+	DcoreVersion string `xml:"DcoreVersion,attr" json:"dcoreVersion,omitempty"`
+	Dfpu         string `xml:"Dfpu,attr" json:"dfpu,omitempty"`
+	Dmpu         string `xml:"Dmpu,attr" json:"dmpu,omitempty"`
+	Dtz          string `xml:"Dtz,attr" json:"dtz,omitempty"`
+	Ddsp         string `xml:"Ddsp,attr" json:"ddsp,omitempty"`
+	Dmve         string `xml:"Dmve,attr" json:"dmve,omitempty"`
+	Dendian      string `xml:"Dendian,attr" json:"dendian,omitempty"`
+	Dclock       string `xml:"Dclock,attr" json:"dclock,omitempty"`
+}
+
+// MergeProcessors returns the processors of a device level inheriting the
+// attributes of the levels above it in inherited: attributes of processors
+// of the same Pname are overridden and other processors are added.
+func MergeProcessors(inherited, processors []ProcessorTag) []ProcessorTag {
+	merged := append([]ProcessorTag{}, inherited...)
+	for _, processor := range processors {
+		index := -1
+		for i := range merged {
+			if merged[i].Pname == processor.Pname {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			merged = append(merged, processor)
+			continue
+		}
+
+		current := &merged[index]
+		current.Dcore = firstNonEmpty(processor.Dcore, current.Dcore)
+		current.DcoreVersion = firstNonEmpty(processor.DcoreVersion, current.DcoreVersion)
+		current.Dfpu = firstNonEmpty(processor.Dfpu, current.Dfpu)
+		current.Dmpu = firstNonEmpty(processor.Dmpu, current.Dmpu)
+		current.Dtz = firstNonEmpty(processor.Dtz, current.Dtz)
+		current.Ddsp = firstNonEmpty(processor.Ddsp, current.Ddsp)
+		current.Dmve = firstNonEmpty(processor.Dmve, current.Dmve)
+		current.Dendian = firstNonEmpty(processor.Dendian, current.Dendian)
+		current.Dclock = firstNonEmpty(processor.Dclock, current.Dclock)
+	}
+	return merged
+}
+
+// Device is a device described in a PDSC file, with the attributes it
+// inherits from its family and sub family.
+type Device struct {
+	Name      string `json:"name"`
+	Vendor    string `json:"vendor"`
+	Family    string `json:"family"`
+	SubFamily string `json:"subFamily,omitempty"`
+}
+
+// BoardTag maps the <board> tag of a PDSC file.
+type BoardTag struct {
+	Vendor         string             `xml:"vendor,attr"`
+	Name           string             `xml:"name,attr"`
+	Revision       string             `xml:"revision,attr"`
+	MountedDevices []MountedDeviceTag `xml:"mountedDevice"`
+	Books          []BookTag          `xml:"book"`
+}
+
+// BookTag maps the <book> tag of a board.
+type BookTag struct {
+	Category string `xml:"category,attr"`
+	Name     string `xml:"name,attr"`
+}
+
+// MountedDeviceTag maps the <mountedDevice> tag of a PDSC file.
+type MountedDeviceTag struct {
+	Vendor  string `xml:"Dvendor,attr"`
+	Name    string `xml:"Dname,attr"`
+	Variant string `xml:"Dvariant,attr"`
+}
+
+// Board is a board described in a PDSC file
+type Board struct {
+	Name           string   `json:"name"`
+	Vendor         string   `json:"vendor"`
+	Revision       string   `json:"revision,omitempty"`
+	MountedDevices []string `json:"mountedDevices"`
+}
+
+// ComponentTag maps the <component> tag of a PDSC file.
+type ComponentTag struct {
+	Vendor    string    `xml:"Cvendor,attr"`
+	Class     string    `xml:"Cclass,attr"`
+	Group     string    `xml:"Cgroup,attr"`
+	Sub       string    `xml:"Csub,attr"`
+	Variant   string    `xml:"Cvariant,attr"`
+	Version   string    `xml:"Cversion,attr"`
+	Condition string    `xml:"condition,attr"`
+	Files     []FileTag `xml:"files>file"`
+}
+
+// FileTag maps the <file> tag of a component.
+type FileTag struct {
+	Category  string `xml:"category,attr"`
+	Name      string `xml:"name,attr"`
+	Condition string `xml:"condition,attr"`
+}
+
+// ConditionTag maps the <condition> tag of a PDSC file, which components
+// and their files refer to by ID. It holds if all of its <require>, at
+// least one of its <accept>, if any, and none of its <deny> expressions do.
+type ConditionTag struct {
+	ID      string             `xml:"id,attr"`
+	Require []ConditionExprTag `xml:"require"`
+	Accept  []ConditionExprTag `xml:"accept"`
+	Deny    []ConditionExprTag `xml:"deny"`
+}
+
+// ConditionExprTag maps the <require>, <accept> and <deny> tags of a
+// condition, e.g. <require Tcompiler="GCC"/>. It holds if all of its
+// attributes do, "condition" referring to another condition.
+type ConditionExprTag struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// BundleTag maps the <bundle> tag of a PDSC file.
+type BundleTag struct {
+	Bundle     string         `xml:"Cbundle,attr"`
+	Vendor     string         `xml:"Cvendor,attr"`
+	Class      string         `xml:"Cclass,attr"`
+	Version    string         `xml:"Cversion,attr"`
+	Components []ComponentTag `xml:"component"`
+}
+
+// Component is a software component described in a PDSC file, with the
+// attributes it inherits from its bundle and pack
+type Component struct {
+	Vendor  string `json:"vendor"`
+	Class   string `json:"class"`
+	Bundle  string `json:"bundle,omitempty"`
+	Group   string `json:"group"`
+	Sub     string `json:"sub,omitempty"`
+	Variant string `json:"variant,omitempty"`
+	Version string `json:"version"`
+}
+
+// ID formats the component as Vendor::Class&Bundle:Group:Sub&Variant@Version, as in
+// https://github.com/Open-CMSIS-Pack/devtools/blob/main/tools/projmgr/docs/Manual/YML-Input-Format.md#component-name-conventions
+func (c *Component) ID() string {
+	id := c.Vendor + "::" + c.Class
+	if c.Bundle != "" {
+		id += "&" + c.Bundle
+	}
+	id += ":" + c.Group
+	if c.Sub != "" {
+		id += ":" + c.Sub
+	}
+	if c.Variant != "" {
+		id += "&" + c.Variant
+	}
+	if c.Version != "" {
+		id += "@" + c.Version
+	}
+	return id
+}
+
+// ExampleTag maps the <example> tag of a PDSC file.
+type ExampleTag struct {
+	Name        string            `xml:"name,attr"`
+	Folder      string            `xml:"folder,attr"`
+	Doc         string            `xml:"doc,attr"`
+	Description string            `xml:"description"`
+	Boards      []ExampleBoardTag `xml:"board"`
+}
+
+// ExampleBoardTag maps the <board> tag of an example.
+type ExampleBoardTag struct {
+	Vendor string `xml:"vendor,attr"`
+	Name   string `xml:"name,attr"`
+}
+
 // NewPdscXML receives a PDSC file name to be later read into the PdscXML struct
 func NewPdscXML(fileName string) *PdscXML {
 	log.Debugf("Initializing PdscXML object for \"%s\"", fileName)
@@ -85,6 +340,26 @@ func (p *PdscXML) AllReleases() []string {
 	return allReleases
 }
 
+// ReleasesAfter returns the releases newer than version, up to and
+// including latestVersion if not empty, newest first
+func (p *PdscXML) ReleasesAfter(version, latestVersion string) []ReleaseTag {
+	releases := []ReleaseTag{}
+	for _, releaseTag := range p.ReleasesTag.Releases {
+		if utils.SemverCompare(releaseTag.Version, version) <= 0 {
+			continue
+		}
+		if latestVersion != "" && utils.SemverCompare(releaseTag.Version, latestVersion) > 0 {
+			continue
+		}
+		releases = append(releases, releaseTag)
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return utils.SemverCompare(releases[i].Version, releases[j].Version) > 0
+	})
+	return releases
+}
+
 // FindReleaseTagByVersion iterates over the PDSC file's releases tag and returns
 // the release that matching version.
 func (p *PdscXML) FindReleaseTagByVersion(version string) *ReleaseTag {
@@ -113,10 +388,31 @@ func (p *PdscXML) Tag() PdscTag {
 	}
 }
 
-// Read reads the PDSC file specified in p.FileName into the PdscXML struct
+// Read reads the PDSC file specified in p.FileName into the PdscXML struct.
+// Files read before and not changed since come from the metadata cache.
 func (p *PdscXML) Read() error {
 	log.Debugf("Reading pdsc from file \"%s\"", p.FileName)
-	return utils.ReadXML(p.FileName, p)
+
+	var cached PdscXML
+	if utils.LoadMetadataCache(p.FileName, &cached) {
+		cached.FileName = p.FileName
+		*p = cached
+		return nil
+	}
+
+	if err := utils.ReadXML(p.FileName, p); err != nil {
+		return err
+	}
+
+	utils.StoreMetadataCache(p.FileName, p)
+	return nil
+}
+
+// Decode reads a PDSC file from reader into the PdscXML struct, for
+// instance straight out of a pack, keeping p.FileName
+func (p *PdscXML) Decode(reader io.Reader) error {
+	log.Debugf("Decoding pdsc \"%s\"", p.FileName)
+	return utils.DecodeXML(reader, p)
 }
 
 // PackURL returns a url for the Pack described in this PDSC file
@@ -167,3 +463,168 @@ func (p *PdscXML) Dependencies() [][]string {
 	}
 	return dependencies
 }
+
+// ListDevices returns the devices described in the <devices> section. Devices
+// with variants are listed once per variant, as these are the ones to select.
+func (p *PdscXML) ListDevices() []Device {
+	devices := []Device{}
+	addDevices := func(family DeviceFamilyTag, subFamily DeviceSubFamilyTag, deviceTags []DeviceTag) {
+		for _, deviceTag := range deviceTags {
+			vendor := firstNonEmpty(deviceTag.Vendor, subFamily.Vendor, family.Vendor)
+			if len(deviceTag.Variants) == 0 {
+				devices = append(devices, Device{
+					Name:      deviceTag.Name,
+					Vendor:    deviceVendorName(vendor),
+					Family:    family.Family,
+					SubFamily: subFamily.SubFamily,
+				})
+				continue
+			}
+			for _, variant := range deviceTag.Variants {
+				devices = append(devices, Device{
+					Name:      variant.Name,
+					Vendor:    deviceVendorName(firstNonEmpty(variant.Vendor, vendor)),
+					Family:    family.Family,
+					SubFamily: subFamily.SubFamily,
+				})
+			}
+		}
+	}
+
+	for _, family := range p.DevicesTag.Families {
+		addDevices(family, DeviceSubFamilyTag{}, family.Devices)
+		for _, subFamily := range family.SubFamilies {
+			addDevices(family, subFamily, subFamily.Devices)
+		}
+	}
+	return devices
+}
+
+// ListBoards returns the boards described in the <boards> section
+func (p *PdscXML) ListBoards() []Board {
+	boards := []Board{}
+	for _, boardTag := range p.BoardsTag.Boards {
+		board := Board{
+			Name:           boardTag.Name,
+			Vendor:         boardTag.Vendor,
+			Revision:       boardTag.Revision,
+			MountedDevices: []string{},
+		}
+		for _, mountedDevice := range boardTag.MountedDevices {
+			board.MountedDevices = append(board.MountedDevices, firstNonEmpty(mountedDevice.Variant, mountedDevice.Name))
+		}
+		boards = append(boards, board)
+	}
+	return boards
+}
+
+// ListComponents returns the components described in the <components>
+// section, including the ones in bundles
+func (p *PdscXML) ListComponents() []Component {
+	components := []Component{}
+	addComponents := func(bundle BundleTag, componentTags []ComponentTag) {
+		for _, componentTag := range componentTags {
+			components = append(components, Component{
+				Vendor:  firstNonEmpty(componentTag.Vendor, bundle.Vendor, p.Vendor),
+				Class:   firstNonEmpty(componentTag.Class, bundle.Class),
+				Bundle:  bundle.Bundle,
+				Group:   componentTag.Group,
+				Sub:     componentTag.Sub,
+				Variant: componentTag.Variant,
+				Version: firstNonEmpty(componentTag.Version, bundle.Version),
+			})
+		}
+	}
+
+	addComponents(BundleTag{}, p.ComponentsTag.Components)
+	for _, bundle := range p.ComponentsTag.Bundles {
+		addComponents(bundle, bundle.Components)
+	}
+	return components
+}
+
+// deviceVendorName strips the vendor ID off a Dvendor attribute, as in "ARM:82"
+func deviceVendorName(vendor string) string {
+	name, _, _ := strings.Cut(vendor, ":")
+	return name
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// DeviceTreeFamily is a device family of DeviceTree
+type DeviceTreeFamily struct {
+	Family      string                `json:"family"`
+	Vendor      string                `json:"vendor"`
+	Processors  []ProcessorTag        `json:"processors"`
+	SubFamilies []DeviceTreeSubFamily `json:"subFamilies,omitempty"`
+	Devices     []DeviceTreeDevice    `json:"devices,omitempty"`
+}
+
+// DeviceTreeSubFamily is a device sub family of DeviceTree
+type DeviceTreeSubFamily struct {
+	SubFamily  string             `json:"subFamily"`
+	Vendor     string             `json:"vendor"`
+	Processors []ProcessorTag     `json:"processors"`
+	Devices    []DeviceTreeDevice `json:"devices"`
+}
+
+// DeviceTreeDevice is a device, or a variant of it, of DeviceTree
+type DeviceTreeDevice struct {
+	Name       string             `json:"name"`
+	Vendor     string             `json:"vendor"`
+	Processors []ProcessorTag     `json:"processors"`
+	Variants   []DeviceTreeDevice `json:"variants,omitempty"`
+}
+
+// DeviceTree returns the hierarchy of the <devices> section. The vendor and
+// processors of each level include what they inherit from the levels above.
+func (p *PdscXML) DeviceTree() []DeviceTreeFamily {
+	treeDevices := func(vendor string, processors []ProcessorTag, deviceTags []DeviceTag) []DeviceTreeDevice {
+		devices := []DeviceTreeDevice{}
+		for _, deviceTag := range deviceTags {
+			device := DeviceTreeDevice{
+				Name:       deviceTag.Name,
+				Vendor:     deviceVendorName(firstNonEmpty(deviceTag.Vendor, vendor)),
+				Processors: MergeProcessors(processors, deviceTag.Processors),
+			}
+			for _, variant := range deviceTag.Variants {
+				device.Variants = append(device.Variants, DeviceTreeDevice{
+					Name:       variant.Name,
+					Vendor:     deviceVendorName(firstNonEmpty(variant.Vendor, deviceTag.Vendor, vendor)),
+					Processors: MergeProcessors(device.Processors, variant.Processors),
+				})
+			}
+			devices = append(devices, device)
+		}
+		return devices
+	}
+
+	families := []DeviceTreeFamily{}
+	for _, familyTag := range p.DevicesTag.Families {
+		family := DeviceTreeFamily{
+			Family:     familyTag.Family,
+			Vendor:     deviceVendorName(familyTag.Vendor),
+			Processors: MergeProcessors(nil, familyTag.Processors),
+		}
+		for _, subFamilyTag := range familyTag.SubFamilies {
+			vendor := firstNonEmpty(subFamilyTag.Vendor, familyTag.Vendor)
+			processors := MergeProcessors(family.Processors, subFamilyTag.Processors)
+			family.SubFamilies = append(family.SubFamilies, DeviceTreeSubFamily{
+				SubFamily:  subFamilyTag.SubFamily,
+				Vendor:     deviceVendorName(vendor),
+				Processors: processors,
+				Devices:    treeDevices(vendor, processors, subFamilyTag.Devices),
+			})
+		}
+		family.Devices = treeDevices(familyTag.Vendor, family.Processors, familyTag.Devices)
+		families = append(families, family)
+	}
+	return families
+}
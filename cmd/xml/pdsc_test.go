@@ -4,6 +4,7 @@
 package xml_test
 
 import (
+	"os"
 	"sort"
 	"testing"
 
@@ -71,6 +72,21 @@ func TestPdscXML(t *testing.T) {
 		assert.Equal(expected, allVersions)
 	})
 
+	t.Run("test listing the releases after a version", func(t *testing.T) {
+		pdscXML := xml.PdscXML{}
+		for _, version := range []string{"1.1.0", "1.3.0", "1.0.0", "1.2.0"} {
+			pdscXML.ReleasesTag.Releases = append(pdscXML.ReleasesTag.Releases, xml.ReleaseTag{Version: version})
+		}
+
+		versions := []string{}
+		for _, release := range pdscXML.ReleasesAfter("1.0.0", "1.2.0") {
+			versions = append(versions, release.Version)
+		}
+		assert.Equal([]string{"1.2.0", "1.1.0"}, versions)
+		assert.Len(pdscXML.ReleasesAfter("1.1.0", ""), 2)
+		assert.Empty(pdscXML.ReleasesAfter("1.3.0", ""))
+	})
+
 	t.Run("test pdscXML to pdscTag generation", func(t *testing.T) {
 		var url = "http://the.url/"
 		var name = "TheName"
@@ -103,6 +119,18 @@ func TestPdscXML(t *testing.T) {
 		assert.Equal("1.2.3+meta3", pdsc.LatestVersion())
 	})
 
+	t.Run("test decoding a PDSC file from a reader", func(t *testing.T) {
+		file, err := os.Open("../../testdata/devpack/1.2.3/TheVendor.DevPack.pdsc")
+		assert.Nil(err)
+		defer file.Close()
+
+		pdsc := xml.NewPdscXML("TheVendor.DevPack.pdsc")
+		assert.Nil(pdsc.Decode(file))
+		assert.Equal("TheVendor.DevPack.pdsc", pdsc.FileName)
+		assert.Equal("DevPack", pdsc.Name)
+		assert.Equal("1.2.3+meta3", pdsc.LatestVersion())
+	})
+
 	t.Run("test finding release tag", func(t *testing.T) {
 		pdsc := xml.NewPdscXML("../../testdata/devpack/1.2.3/TheVendor.DevPack.pdsc")
 		assert.Nil(pdsc.Read())
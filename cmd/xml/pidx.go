@@ -8,17 +8,18 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
-	log "github.com/sirupsen/logrus"
 )
 
 var (
 	PdscIndexNotFound = -1
 )
 
-// PidxXML maps the PIDX file format.
+// PidxXML maps the PIDX file format. Its methods are safe for concurrent use.
 // Ref: https://github.com/ARM-software/CMSIS_5/blob/develop/CMSIS/Utilities/PackIndex.xsd
 type PidxXML struct {
 	XMLName       xml.Name `xml:"index"`
@@ -33,6 +34,13 @@ type PidxXML struct {
 
 	pdscList map[string][]PdscTag
 	fileName string
+
+	// unread tells whether ReadLazily left the PDSC tags of fileName to be
+	// read by the first method needing them
+	unread bool
+
+	// mu guards pdscList, unread and Pindex, which Write fills in temporarily
+	mu sync.RWMutex
 }
 
 // PdscTag maps a <pdsc> tag that goes in PIDX files.
@@ -44,6 +52,14 @@ type PdscTag struct {
 	Version string   `xml:"version,attr"`
 }
 
+// pidxCache is what the metadata cache keeps of a PIDX file
+type pidxCache struct {
+	SchemaVersion string
+	Vendor        string
+	URL           string
+	Pdscs         []PdscTag
+}
+
 // NewPidxXML creates a new instance of the PidxXML struct.
 func NewPidxXML(fileName string) *PidxXML {
 	log.Debugf("Initializing PidxXML object for \"%s\"", fileName)
@@ -55,7 +71,15 @@ func NewPidxXML(fileName string) *PidxXML {
 // AddPdsc takes in a PdscTag and add it to the <pindex> tag.
 func (p *PidxXML) AddPdsc(pdsc PdscTag) error {
 	log.Debugf("Adding pdsc tag \"%s\" to \"%s\"", pdsc, p.fileName)
-	if p.HasPdsc(pdsc) != PdscIndexNotFound {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.readUnread(); err != nil {
+		return err
+	}
+
+	if p.hasPdsc(pdsc) != PdscIndexNotFound {
 		return errs.ErrPdscEntryExists
 	}
 
@@ -68,6 +92,13 @@ func (p *PidxXML) AddPdsc(pdsc PdscTag) error {
 func (p *PidxXML) RemovePdsc(pdsc PdscTag) error {
 	log.Debugf("Removing pdsc tag \"%s\" from \"%s\"", pdsc, p.fileName)
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.readUnread(); err != nil {
+		return err
+	}
+
 	// removeInfo serves as a helper to identify which pdsc tags need removal
 	// key is mandatory pdscTag.Key() formatted as Vendor.Pack[.x.y.z]
 	// index is the index of the pdsc tags available for Vendor.Pack.x.y.z,
@@ -79,7 +110,7 @@ func (p *PidxXML) RemovePdsc(pdsc PdscTag) error {
 	toRemove := []removeInfo{}
 
 	if pdsc.Version != "" {
-		if index := p.HasPdsc(pdsc); index != PdscIndexNotFound {
+		if index := p.hasPdsc(pdsc); index != PdscIndexNotFound {
 			toRemove = append(toRemove, removeInfo{
 				key:   pdsc.Key(),
 				index: index,
@@ -126,6 +157,14 @@ func (p *PidxXML) RemovePdsc(pdsc PdscTag) error {
 // HasPdsc tells whether of not pdsc is already present in this pidx file.
 // It returns the index of the matching pdsc tag, or -1 if not found
 func (p *PidxXML) HasPdsc(pdsc PdscTag) int {
+	p.ensureRead()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.hasPdsc(pdsc)
+}
+
+// hasPdsc is HasPdsc for callers already holding p.mu
+func (p *PidxXML) hasPdsc(pdsc PdscTag) int {
 	index := PdscIndexNotFound
 	if tags, found := p.pdscList[pdsc.Key()]; found {
 		for i, tag := range tags {
@@ -142,6 +181,10 @@ func (p *PidxXML) HasPdsc(pdsc PdscTag) int {
 
 // ListPdscTags returns a map of PdscTags in the pidx document
 func (p *PidxXML) ListPdscTags() []PdscTag {
+	p.ensureRead()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	tags := []PdscTag{}
 	for _, pdscTags := range p.pdscList {
 		tags = append(tags, pdscTags...)
@@ -152,8 +195,14 @@ func (p *PidxXML) ListPdscTags() []PdscTag {
 // FindPdscTags takes in a sample pdscTag and returns the actual PDSC tag inside this PidxXML.
 func (p *PidxXML) FindPdscTags(pdsc PdscTag) []PdscTag {
 	log.Debugf("Searching for pdsc \"%s\"", pdsc.Key())
+
+	p.ensureRead()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	if pdsc.Version != "" {
-		foundTags := p.pdscList[pdsc.Key()]
+		// Copy the tags, which AddPdsc and RemovePdsc change in place
+		foundTags := append([]PdscTag{}, p.pdscList[pdsc.Key()]...)
 		log.Debugf("\"%s\" contains %d pdsc tag(s) for \"%s\"", p.fileName, len(foundTags), pdsc.Key())
 		return foundTags
 	}
@@ -174,29 +223,146 @@ func (p *PidxXML) FindPdscTags(pdsc PdscTag) []PdscTag {
 }
 
 // Read reads FileName into this PidxXML struct and allocates memory for all PDSC tags.
+// Files read before and not changed since come from the metadata cache.
 func (p *PidxXML) Read() error {
 	log.Debugf("Reading pidx from file \"%s\"", p.fileName)
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	p.pdscList = make(map[string][]PdscTag)
+	p.unread = false
 
 	// Create a new empty l
 	if !utils.FileExists(p.fileName) {
-		log.Debugf("\"%v\" not found. Creating a new one.", p.fileName)
-		p.SchemaVersion = "1.1.0"
-		vendorName := ""
-		if p.URL == "" {
-			vendorName = "local_repository.pidx"
-		} else {
-			vendorName = path.Base(p.fileName)
+		return p.create()
+	}
+
+	var cached pidxCache
+	if utils.LoadMetadataCache(p.fileName, &cached) {
+		p.SchemaVersion = cached.SchemaVersion
+		p.Vendor = cached.Vendor
+		p.URL = cached.URL
+		p.Pindex.Pdscs = cached.Pdscs
+	} else {
+		if err := utils.ReadXML(p.fileName, p); err != nil {
+			return err
 		}
-		p.Vendor = strings.TrimSuffix(vendorName, filepath.Ext(vendorName))
-		return p.Write()
+		utils.StoreMetadataCache(p.fileName, pidxCache{
+			SchemaVersion: p.SchemaVersion,
+			Vendor:        p.Vendor,
+			URL:           p.URL,
+			Pdscs:         p.Pindex.Pdscs,
+		})
+	}
+
+	p.registerPdscs()
+	return nil
+}
+
+// ReadLazily reads the schema version, vendor and URL of FileName into this
+// PidxXML struct, leaving its PDSC tags to be read by the first method that
+// needs them. Commands not looking into the index then skip parsing it.
+func (p *PidxXML) ReadLazily() error {
+	log.Debugf("Reading the header of pidx file \"%s\"", p.fileName)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pdscList = make(map[string][]PdscTag)
+	p.unread = false
+
+	if !utils.FileExists(p.fileName) {
+		return p.create()
 	}
 
-	if err := utils.ReadXML(p.fileName, p); err != nil {
+	file, err := utils.GetFileSystem().Open(p.fileName)
+	if err != nil {
 		return err
 	}
+	defer file.Close()
 
+	decoder := utils.NewXMLDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch element.Name.Local {
+		case "index":
+			for _, attr := range element.Attr {
+				if attr.Name.Local == "schemaVersion" {
+					p.SchemaVersion = attr.Value
+				}
+			}
+		case "vendor":
+			err = decoder.DecodeElement(&p.Vendor, &element)
+		case "url":
+			err = decoder.DecodeElement(&p.URL, &element)
+		case "pindex":
+			p.unread = true
+			return nil
+		default:
+			err = decoder.Skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ensureRead reads the PDSC tags ReadLazily left unread, if any. Methods
+// not returning errors treat an unreadable file as an empty one.
+func (p *PidxXML) ensureRead() {
+	p.mu.RLock()
+	unread := p.unread
+	p.mu.RUnlock()
+	if !unread {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.readUnread(); err != nil {
+		log.Errorf("Could not read \"%s\": %s", p.fileName, err)
+	}
+}
+
+// readUnread is ensureRead for callers already holding p.mu. The header
+// fields are kept as they are, callers might have changed them since.
+func (p *PidxXML) readUnread() error {
+	if !p.unread {
+		return nil
+	}
+
+	var cached pidxCache
+	if utils.LoadMetadataCache(p.fileName, &cached) {
+		p.Pindex.Pdscs = cached.Pdscs
+	} else {
+		pidx := new(PidxXML)
+		if err := utils.ReadXML(p.fileName, pidx); err != nil {
+			return err
+		}
+		utils.StoreMetadataCache(p.fileName, pidxCache{
+			SchemaVersion: pidx.SchemaVersion,
+			Vendor:        pidx.Vendor,
+			URL:           pidx.URL,
+			Pdscs:         pidx.Pindex.Pdscs,
+		})
+		p.Pindex.Pdscs = pidx.Pindex.Pdscs
+	}
+
+	p.registerPdscs()
+	p.unread = false
+	return nil
+}
+
+// registerPdscs moves the tags of Pindex.Pdscs into pdscList
+func (p *PidxXML) registerPdscs() {
 	for _, pdsc := range p.Pindex.Pdscs {
 		key := pdsc.Key()
 		log.Debugf("Registring \"%s\"", key)
@@ -205,14 +371,38 @@ func (p *PidxXML) Read() error {
 
 	// truncate Pindex.Pdscs
 	p.Pindex.Pdscs = p.Pindex.Pdscs[:0]
+}
 
-	return nil
+// create writes a new empty pidx file to fileName
+func (p *PidxXML) create() error {
+	log.Debugf("\"%v\" not found. Creating a new one.", p.fileName)
+	p.SchemaVersion = "1.1.0"
+	vendorName := ""
+	if p.URL == "" {
+		vendorName = "local_repository.pidx"
+	} else {
+		vendorName = path.Base(p.fileName)
+	}
+	p.Vendor = strings.TrimSuffix(vendorName, filepath.Ext(vendorName))
+	return p.write()
 }
 
 // Save saves this PidxXML struct into its fileName.
 func (p *PidxXML) Write() error {
 	log.Debugf("Writing pidx file to \"%s\"", p.fileName)
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Do not drop the tags nobody asked for yet
+	if err := p.readUnread(); err != nil {
+		return err
+	}
+	return p.write()
+}
+
+// write is Write for callers already holding p.mu
+func (p *PidxXML) write() error {
 	// Use p.pdscList as the main source of pdsc tags
 	for _, pdscs := range p.pdscList {
 		p.Pindex.Pdscs = append(p.Pindex.Pdscs, pdscs...)
@@ -4,8 +4,12 @@
 package xml_test
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
 	"github.com/open-cmsis-pack/cpackget/cmd/utils"
@@ -204,6 +208,37 @@ func TestPidxXML(t *testing.T) {
 		assert.Greater(newPidx.HasPdsc(pdscTag2), xml.PdscIndexNotFound)
 	})
 
+	t.Run("test reading PIDX file from the metadata cache", func(t *testing.T) {
+		defer utils.SetMetadataCacheDir(utils.GetMetadataCacheDir())
+		utils.SetMetadataCacheDir(t.TempDir())
+
+		fileName := filepath.Join(t.TempDir(), "test-reading-from-metadata-cache.pidx")
+		pdscTag := xml.PdscTag{
+			Vendor:  "TheVendor",
+			URL:     "http://vendor.com/",
+			Name:    "ThePack",
+			Version: "0.0.1",
+		}
+
+		pidx := xml.NewPidxXML(fileName)
+		assert.Nil(pidx.Read())
+		assert.Nil(pidx.AddPdsc(pdscTag))
+		assert.Nil(pidx.Write())
+
+		// Files modified just now are not cached
+		anHourAgo := time.Now().Add(-time.Hour)
+		assert.Nil(os.Chtimes(fileName, anHourAgo, anHourAgo))
+
+		// The first read parses the file and the second one comes from the cache
+		for i := 0; i < 2; i++ {
+			newPidx := xml.NewPidxXML(fileName)
+			assert.Nil(newPidx.Read())
+			assert.Equal(pidx.Vendor, newPidx.Vendor)
+			assert.Greater(newPidx.HasPdsc(pdscTag), xml.PdscIndexNotFound)
+			assert.Len(newPidx.ListPdscTags(), 1)
+		}
+	})
+
 	t.Run("test reading PIDX file with malformed XML", func(t *testing.T) {
 		pidx := xml.NewPidxXML("../../testdata/MalformedPack.pidx")
 		err := pidx.Read()
@@ -246,4 +281,102 @@ func TestPidxXML(t *testing.T) {
 		assert.Equal(foundTags[0], pdscTag2)
 
 	})
+
+	t.Run("test changing a PIDX file concurrently", func(t *testing.T) {
+		fileName := "test-changing-pidx-concurrently.pidx"
+		defer os.Remove(fileName)
+
+		pidx := xml.NewPidxXML(fileName)
+		assert.Nil(pidx.Read())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				pdscTag := xml.PdscTag{
+					Vendor:  "TheVendor",
+					URL:     "http://vendor.com/",
+					Name:    "ThePack",
+					Version: fmt.Sprintf("0.0.%d", i),
+				}
+				assert.Nil(pidx.AddPdsc(pdscTag))
+				assert.NotEqual(xml.PdscIndexNotFound, pidx.HasPdsc(pdscTag))
+				assert.Len(pidx.FindPdscTags(pdscTag), 1)
+				pidx.ListPdscTags()
+				assert.Nil(pidx.Write())
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Len(pidx.ListPdscTags(), 50)
+
+		pidx = xml.NewPidxXML(fileName)
+		assert.Nil(pidx.Read())
+		assert.Len(pidx.ListPdscTags(), 50)
+	})
+
+	t.Run("test reading the pdsc tags of a PIDX file lazily", func(t *testing.T) {
+		fileName := "test-reading-pidx-lazily.pidx"
+		defer os.Remove(fileName)
+
+		pdscTag := xml.PdscTag{
+			Vendor:  "TheVendor",
+			URL:     "http://vendor.com/",
+			Name:    "ThePack",
+			Version: "0.0.1",
+		}
+
+		pidx := xml.NewPidxXML(fileName)
+		pidx.URL = "http://vendor.com/index/"
+		assert.Nil(pidx.Read())
+		assert.Nil(pidx.AddPdsc(pdscTag))
+		assert.Nil(pidx.Write())
+
+		pidx = xml.NewPidxXML(fileName)
+		assert.Nil(pidx.ReadLazily())
+		assert.Equal("http://vendor.com/index/", pidx.URL)
+		assert.Equal("1.1.0", pidx.SchemaVersion)
+
+		// Changes to the header made before the tags are read are kept
+		pidx.URL = "http://mirror.com/index/"
+		foundTags := pidx.FindPdscTags(pdscTag)
+		assert.Len(foundTags, 1)
+		assert.Equal(pdscTag.Key(), foundTags[0].Key())
+		assert.Equal("http://mirror.com/index/", pidx.URL)
+
+		// Writing an index whose tags were not read keeps them
+		pidx = xml.NewPidxXML(fileName)
+		assert.Nil(pidx.ReadLazily())
+		assert.Nil(pidx.Write())
+		assert.Nil(pidx.Read())
+		assert.Len(pidx.ListPdscTags(), 1)
+	})
+
+	t.Run("test reading a PIDX file with malformed pdsc tags lazily", func(t *testing.T) {
+		fileName := "test-reading-malformed-pidx-lazily.pidx"
+		defer os.Remove(fileName)
+		assert.Nil(os.WriteFile(fileName, []byte("<index><vendor>TheVendor</vendor><pindex><pdsc"), 0600))
+
+		pidx := xml.NewPidxXML(fileName)
+		assert.Nil(pidx.ReadLazily())
+		assert.Equal("TheVendor", pidx.Vendor)
+		assert.Empty(pidx.ListPdscTags())
+		assert.NotNil(pidx.AddPdsc(xml.PdscTag{Vendor: "TheVendor", Name: "ThePack", Version: "0.0.1"}))
+		assert.NotNil(pidx.Write())
+	})
+}
+
+// TestMain keeps parsed metadata of testing files out of the user's cache directory
+func TestMain(m *testing.M) {
+	metadataCacheDir, err := os.MkdirTemp("", "cpackget-metadata")
+	if err != nil {
+		panic(err)
+	}
+	utils.SetMetadataCacheDir(metadataCacheDir)
+
+	code := m.Run()
+
+	os.RemoveAll(metadataCacheDir)
+	os.Exit(code)
 }
@@ -0,0 +1,547 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+// Package cpackget exposes the pack installation, index and cryptography
+// operations of cpackget as a Go API, so other tools can manage a CMSIS
+// pack root without running the cpackget binary.
+//
+// The API follows semantic versioning: within a major version of the module,
+// it only changes in backward compatible ways. It does not expose the types
+// of the internal cmd/ packages, except for the errors of cmd/errors.
+//
+// The installer behind this package keeps its state in process-wide globals,
+// so only one operation runs at a time in a process, whichever Installer it
+// is called on. Each operation applies the Options of its Installer and
+// restores the defaults afterwards, so Installers do not affect each other.
+// Code that uses the cmd/installer package directly must not run alongside
+// operations of this package.
+package cpackget
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/installer"
+	"github.com/open-cmsis-pack/cpackget/cmd/log"
+	"github.com/open-cmsis-pack/cpackget/cmd/ui"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/spf13/afero"
+)
+
+// DefaultPublicIndex is the public index used when none is specified
+const DefaultPublicIndex = "https://www.keil.com/pack/index.pidx"
+
+// mu serializes all operations, as the underlying installer keeps
+// a single pack root installation at a time
+var mu sync.Mutex
+
+// URLRewrite replaces the Prefix of URLs with Replacement before downloading them
+type URLRewrite struct {
+	Prefix      string
+	Replacement string
+}
+
+// PackHashURL is where a vendor publishes the SHA-256 hashes of its packs
+type PackHashURL struct {
+	// Vendor is the vendor of the packs, or "*" for any vendor
+	Vendor string
+
+	// Pattern is the URL of the hash file of a pack, where {vendor}, {name},
+	// {version} and {file} are replaced with the pack's vendor, name,
+	// version and file name, e.g. https://vendor.com/hashes/{file}.sha256
+	Pattern string
+}
+
+// Logger receives log messages. *logrus.Logger and *logrus.Entry implement it.
+type Logger interface {
+	Debug(args ...interface{})
+	Debugf(format string, args ...interface{})
+	Info(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warn(args ...interface{})
+	Warnf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// LicenseFunc decides whether to accept the embedded license of a pack,
+// given the license file name and contents
+type LicenseFunc func(licenseTitle, licenseContents string) bool
+
+// Options configures an Installer
+type Options struct {
+	// PackRoot is the pack root folder to operate on. Defaults to the
+	// same location cpackget uses when CMSIS_PACK_ROOT is not set.
+	PackRoot string
+
+	// SystemPackRoot is the machine-wide pack root, whose packs count as
+	// installed in PackRoot without ever being changed from there. Set
+	// PackRoot to it to install packs machine-wide.
+	SystemPackRoot string
+
+	// CacheDir keeps downloaded packs instead of the .Download folder
+	// of the pack root, for instance on a larger volume.
+	CacheDir string
+
+	// MaxPackRootSize makes adding packs fail with errs.ErrPackRootQuotaExceeded
+	// when the pack root would hold more bytes afterwards. Zero disables it.
+	MaxPackRootSize uint64
+
+	// Webhook is posted a JSON notification after each pack is added,
+	// removed or updated. Empty disables notifications.
+	Webhook string
+
+	// PostInstallCommands are the commands the post-install steps declared
+	// by packs may run. Steps run sandboxed, without network access, and
+	// only on Linux. Empty disables post-install steps.
+	PostInstallCommands []string
+
+	// VerifyCachedPacks verifies packs installed from the cache against the
+	// SHA-256 hash recorded when they were downloaded, downloading them
+	// again if they changed, e.g. on disks silently corrupting files.
+	VerifyCachedPacks bool
+
+	// URLRewrites replace URL prefixes before downloading, e.g. to
+	// download vendor files from a mirror. The first matching one wins.
+	URLRewrites []URLRewrite
+
+	// IndexKey is the PGP public key updating the public index verifies
+	// its detached signature, index.pidx.sig, with. Empty disables it.
+	IndexKey string
+
+	// StrictIndex refuses indexes that are not signed
+	StrictIndex bool
+
+	// PackHashURLs are where vendors publish the SHA-256 hashes of their
+	// packs. Added packs of these vendors are verified against them.
+	PackHashURLs []PackHashURL
+
+	// Timeout is the maximum duration of a download. Zero disables it.
+	Timeout time.Duration
+
+	// Concurrency is the number of parallel downloads of batch operations.
+	// Zero disables concurrency.
+	Concurrency int
+
+	// MaxHostDownloads is the number of parallel downloads from the same
+	// host, the downloads from other hosts proceeding meanwhile. Zero
+	// defaults to 6, a negative number sets no limit.
+	MaxHostDownloads int
+
+	// SkipTouch does not touch pack.idx after changing the pack root
+	SkipTouch bool
+
+	// Transport performs all HTTP requests, for instance to go through a
+	// corporate proxy or a recording transport. Defaults to the transports
+	// cpackget builds, which skip certificate checks for 127.0.0.1 only.
+	Transport http.RoundTripper
+
+	// FileSystem holds the pack root, for instance an in-memory file
+	// system. Local pack, PDSC and index files are read from it too.
+	// Defaults to the operating system's file system.
+	FileSystem afero.Fs
+
+	// Logger receives the log messages of operations. Defaults to the
+	// standard logrus logger. Pass a *logrus.Entry to add fields to them.
+	Logger Logger
+}
+
+// Installer manages the packs of a single pack root
+type Installer struct {
+	options Options
+}
+
+// Pack describes a pack known to an Installer
+type Pack struct {
+	Vendor  string
+	Name    string
+	Version string
+
+	// PdscPath is the path of the pack's PDSC file, for installed packs only
+	PdscPath string
+
+	// File is the path of the pack file, for cached packs only
+	File string
+
+	// Local tells whether the pack was installed via PDSC file
+	Local bool
+
+	// Err is set when the pack could not be read. The other fields
+	// then hold whatever could be determined about it.
+	Err error
+}
+
+// ID returns the pack identifier formatted as Vendor::Name@Version
+func (p Pack) ID() string {
+	return p.Vendor + "::" + p.Name + "@" + p.Version
+}
+
+// InitOptions configures Installer.Init
+type InitOptions struct {
+	// IndexURL is the path or URL of the public index. Defaults to DefaultPublicIndex.
+	IndexURL string
+
+	// AllPdscFiles downloads all PDSC files listed in the index
+	AllPdscFiles bool
+}
+
+// AddOptions configures Installer.Add
+type AddOptions struct {
+	// AgreeLicense accepts the pack's embedded license without asking AcceptLicense
+	AgreeLicense bool
+
+	// AcceptLicense decides on the pack's embedded license unless AgreeLicense
+	// is set. Packs with a license are declined if it is nil.
+	AcceptLicense LicenseFunc
+
+	// ForceReinstall reinstalls the pack if it is already installed
+	ForceReinstall bool
+
+	// NoDependencies skips installing the pack's requirements
+	NoDependencies bool
+}
+
+// RemoveOptions configures Installer.Remove
+type RemoveOptions struct {
+	// Purge also removes the pack's cached files from .Download/
+	Purge bool
+
+	// Force removes the pack even if it is pinned
+	Force bool
+}
+
+// UpdateOptions configures Installer.Update
+type UpdateOptions struct {
+	// AgreeLicense accepts the packs' embedded licenses without asking AcceptLicense
+	AgreeLicense bool
+
+	// AcceptLicense decides on the packs' embedded licenses unless AgreeLicense
+	// is set. Packs with a license are declined if it is nil.
+	AcceptLicense LicenseFunc
+
+	// NoDependencies skips installing the pack's requirements
+	NoDependencies bool
+
+	// Force updates the pack even if it is pinned, and pinned packs along
+	// with all installed packs
+	Force bool
+}
+
+// UpdateIndexOptions configures Installer.UpdateIndex
+type UpdateIndexOptions struct {
+	// Sparse only updates index.pidx, leaving the PDSC files in .Web/ untouched
+	Sparse bool
+
+	// AllPdscFiles downloads all PDSC files listed in the index that are missing
+	AllPdscFiles bool
+
+	// RetryFailed only downloads again the PDSC files the last update could not download
+	RetryFailed bool
+}
+
+// New creates an Installer for the pack root in options.
+// The pack root is only accessed when an operation is performed.
+func New(options Options) (*Installer, error) {
+	if options.PackRoot == "" {
+		options.PackRoot = installer.GetDefaultCmsisPackRoot()
+	}
+	if options.PackRoot == "" {
+		return nil, errs.ErrPackRootNotFound
+	}
+	options.PackRoot = filepath.Clean(options.PackRoot)
+	return &Installer{options: options}, nil
+}
+
+// PackRoot returns the pack root folder managed by this Installer
+func (i *Installer) PackRoot() string {
+	return i.options.PackRoot
+}
+
+// run makes this Installer's pack root the active one and runs
+// operation with the pack root unlocked. Downloads and extractions
+// stop once ctx is done.
+func (i *Installer) run(ctx context.Context, create bool, operation func() error) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	installer.SetContext(ctx)
+	defer installer.SetContext(nil)
+
+	utils.SetSkipTouch(i.options.SkipTouch)
+	defer utils.SetSkipTouch(false)
+	utils.SetHTTPTransport(i.options.Transport)
+	defer utils.SetHTTPTransport(nil)
+	utils.SetFileSystem(i.options.FileSystem)
+	defer utils.SetFileSystem(nil)
+	log.SetLogger(i.logger())
+	defer log.SetLogger(nil)
+	installer.SetCacheDir(i.options.CacheDir)
+	defer installer.SetCacheDir("")
+	installer.SetSystemPackRoot(i.options.SystemPackRoot)
+	defer installer.SetSystemPackRoot("")
+	installer.SetMaxPackRootSize(i.options.MaxPackRootSize)
+	defer installer.SetMaxPackRootSize(0)
+	installer.SetWebhook(i.options.Webhook)
+	defer installer.SetWebhook("")
+	installer.SetPostInstallCommands(i.options.PostInstallCommands)
+	defer installer.SetPostInstallCommands(nil)
+	installer.SetVerifyCachedPacks(i.options.VerifyCachedPacks)
+	defer installer.SetVerifyCachedPacks(false)
+	utils.SetURLRewrites(i.urlRewrites())
+	defer utils.SetURLRewrites(nil)
+	utils.SetMaxHostDownloads(i.maxHostDownloads())
+	defer utils.SetMaxHostDownloads(utils.DefaultMaxHostDownloads)
+	installer.SetIndexVerification(i.options.IndexKey, i.options.StrictIndex)
+	defer installer.SetIndexVerification("", false)
+	installer.SetPackHashURLs(i.packHashURLs())
+	defer installer.SetPackHashURLs(nil)
+
+	if err := installer.SetPackRoot(i.options.PackRoot, create); err != nil {
+		return err
+	}
+
+	installer.UnlockPackRoot()
+	defer installer.LockPackRoot()
+
+	return operation()
+}
+
+// logger returns the logger of the options, nil for the default one
+func (i *Installer) logger() log.Logger {
+	if i.options.Logger == nil {
+		return nil
+	}
+	return i.options.Logger
+}
+
+// urlRewrites returns the URL rewrites of the options as the installer takes them
+func (i *Installer) urlRewrites() []utils.URLRewrite {
+	var rewrites []utils.URLRewrite
+	for _, rewrite := range i.options.URLRewrites {
+		rewrites = append(rewrites, utils.URLRewrite{Prefix: rewrite.Prefix, Replacement: rewrite.Replacement})
+	}
+	return rewrites
+}
+
+// packHashURLs returns the pack hash URLs of the options as the installer takes them
+func (i *Installer) packHashURLs() []installer.PackHashURL {
+	var hashURLs []installer.PackHashURL
+	for _, hashURL := range i.options.PackHashURLs {
+		hashURLs = append(hashURLs, installer.PackHashURL{Vendor: hashURL.Vendor, Pattern: hashURL.Pattern})
+	}
+	return hashURLs
+}
+
+// timeout returns the download timeout in whole seconds, as the installer
+// takes it, rounding up so that short timeouts are not disabled
+func (i *Installer) timeout() int {
+	return int((i.options.Timeout + time.Second - 1) / time.Second)
+}
+
+// maxHostDownloads returns the per-host download limit of the options as
+// utils takes it
+func (i *Installer) maxHostDownloads() int {
+	switch {
+	case i.options.MaxHostDownloads == 0:
+		return utils.DefaultMaxHostDownloads
+	case i.options.MaxHostDownloads < 0:
+		return 0
+	}
+	return i.options.MaxHostDownloads
+}
+
+// withLicense runs operation with acceptLicense answering the license
+// prompts, which never reach the user. It returns errs.ErrEula if any
+// license was declined, as the installer skips those packs silently.
+func withLicense(acceptLicense LicenseFunc, operation func() error) error {
+	declined := false
+	ui.LicensePrompt = func(licenseTitle, licenseContents string) (bool, error) {
+		if acceptLicense != nil && acceptLicense(licenseTitle, licenseContents) {
+			return true, nil
+		}
+		declined = true
+		return false, nil
+	}
+	defer func() { ui.LicensePrompt = nil }()
+
+	if err := operation(); err != nil {
+		return err
+	}
+	if declined {
+		return errs.ErrEula
+	}
+	return nil
+}
+
+// Init creates the pack root folder structure and downloads the public index
+func (i *Installer) Init(ctx context.Context, options InitOptions) error {
+	if options.IndexURL == "" {
+		options.IndexURL = DefaultPublicIndex
+	}
+	return i.run(ctx, true, func() error {
+		return installer.UpdatePublicIndex(options.IndexURL, true, true, options.AllPdscFiles, false, i.options.Concurrency, i.timeout())
+	})
+}
+
+// Add installs a pack given by a pack ID (Vendor::Pack@x.y.z), a path or URL to
+// a pack file, or a path to a PDSC file. It returns errs.ErrEula if the license
+// of the pack or of one of its dependencies is declined, and errs.ErrTerminatedByUser
+// if ctx is done first. Errors are returned as *errs.Error, carrying the pack
+// they happened on.
+func (i *Installer) Add(ctx context.Context, pack string, options AddOptions) error {
+	return i.run(ctx, false, func() error {
+		if filepath.Ext(pack) == ".pdsc" {
+			return packError(pack, installer.AddPdsc(pack))
+		}
+		return packError(pack, withLicense(options.AcceptLicense, func() error {
+			return installer.AddPack(pack, !options.AgreeLicense, false, options.ForceReinstall, options.NoDependencies, i.timeout())
+		}))
+	})
+}
+
+// Download fetches a pack given by a pack ID, or a path or URL to a pack
+// file, into .Download/ and verifies it without installing it, returning
+// the path of the pack file. Errors are returned as *errs.Error, carrying
+// the pack they happened on.
+func (i *Installer) Download(ctx context.Context, pack string) (string, error) {
+	downloadPath := ""
+	err := i.run(ctx, false, func() error {
+		var err error
+		downloadPath, err = installer.DownloadPack(pack, i.timeout())
+		return packError(pack, err)
+	})
+	return downloadPath, err
+}
+
+// Remove uninstalls a pack given by a pack ID or the path to the PDSC file it was added with.
+// Errors are returned as *errs.Error, carrying the pack they happened on.
+func (i *Installer) Remove(ctx context.Context, pack string, options RemoveOptions) error {
+	return i.run(ctx, false, func() error {
+		if filepath.Ext(pack) == ".pdsc" {
+			// Local packs are recorded with the absolute path of their PDSC file
+			pdscPath := pack
+			if absPath, err := filepath.Abs(pdscPath); err == nil {
+				pdscPath = absPath
+			}
+			return packError(pack, installer.RemovePdsc(pdscPath))
+		}
+		installer.SetForcePinned(options.Force)
+		defer installer.SetForcePinned(false)
+		return packError(pack, installer.RemovePack(pack, options.Purge, i.timeout()))
+	})
+}
+
+// Update updates a public pack to its latest version. An empty pack
+// updates all installed public packs. It returns errs.ErrEula if any
+// license was declined, after updating the other packs.
+func (i *Installer) Update(ctx context.Context, pack string, options UpdateOptions) error {
+	return i.run(ctx, false, func() error {
+		installer.SetForcePinned(options.Force)
+		defer installer.SetForcePinned(false)
+		return packError(pack, withLicense(options.AcceptLicense, func() error {
+			return installer.UpdatePack(pack, !options.AgreeLicense, options.NoDependencies, i.timeout())
+		}))
+	})
+}
+
+// packError adds the pack an error happened on to it
+func packError(pack string, err error) error {
+	if err == nil || pack == "" {
+		return err
+	}
+	return errs.WithPackID(err, pack)
+}
+
+// UpdateIndex refreshes the public index using the URL inside index.pidx
+func (i *Installer) UpdateIndex(ctx context.Context, options UpdateIndexOptions) error {
+	return i.run(ctx, false, func() error {
+		if options.RetryFailed {
+			return installer.RetryFailedPdscs(i.options.Concurrency, i.timeout())
+		}
+		return installer.UpdatePublicIndex("", true, options.Sparse, false, options.AllPdscFiles, i.options.Concurrency, i.timeout())
+	})
+}
+
+// ListInstalled returns all installed packs, including the ones installed via PDSC files.
+// Packs that could not be read are listed with Err set.
+func (i *Installer) ListInstalled() ([]Pack, error) {
+	var packs []Pack
+	err := i.run(context.Background(), false, func() error {
+		installedPacks, err := installer.GetInstalledPacks()
+		if err != nil {
+			return err
+		}
+		for _, installedPack := range installedPacks {
+			packs = append(packs, Pack{
+				Vendor:   installedPack.Vendor,
+				Name:     installedPack.Name,
+				Version:  installedPack.Version,
+				PdscPath: installedPack.PdscPath,
+				Local:    installedPack.IsPdscInstalled,
+				Err:      installedPack.Err,
+			})
+		}
+		return nil
+	})
+	sortPacks(packs)
+	return packs, err
+}
+
+// ListPublic returns all packs listed in the public index
+func (i *Installer) ListPublic() ([]Pack, error) {
+	var packs []Pack
+	err := i.run(context.Background(), false, func() error {
+		for _, pdscTag := range installer.Installation.PublicIndexXML.ListPdscTags() {
+			packs = append(packs, Pack{
+				Vendor:  pdscTag.Vendor,
+				Name:    pdscTag.Name,
+				Version: pdscTag.Version,
+			})
+		}
+		return nil
+	})
+	sortPacks(packs)
+	return packs, err
+}
+
+// ListCached returns all pack files present in .Download/. Files whose
+// name is not a valid pack file name are listed with Err set.
+func (i *Installer) ListCached() ([]Pack, error) {
+	var packs []Pack
+	err := i.run(context.Background(), false, func() error {
+		matches, err := utils.GlobIn(installer.Installation.DownloadDir, "*.pack")
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			info, err := utils.ExtractPackInfo(match)
+			if err == nil && info.IsPackID {
+				// Vendor.Pack.pack has no version, so it is no pack file name
+				err = errs.ErrBadPackName
+			}
+			packs = append(packs, Pack{
+				Vendor:  info.Vendor,
+				Name:    info.Pack,
+				Version: info.Version,
+				File:    match,
+				Err:     err,
+			})
+		}
+		return nil
+	})
+	sortPacks(packs)
+	return packs, err
+}
+
+// sortPacks orders packs by vendor, name and version, ignoring case
+func sortPacks(packs []Pack) {
+	sort.Slice(packs, func(i, j int) bool {
+		return strings.ToLower(packs[i].ID()) < strings.ToLower(packs[j].ID())
+	})
+}
@@ -0,0 +1,374 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package cpackget_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/pkg/cpackget"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctx                = context.Background()
+	testDir            = filepath.Join("..", "..", "testdata", "integration")
+	emptyPublicIndex   = filepath.Join(testDir, "EmptyPublicIndex.pidx")
+	publicLocalPack123 = filepath.Join(testDir, "1.2.3", "TheVendor.PublicLocalPack.1.2.3.pack")
+	publicLocalPack124 = filepath.Join(testDir, "1.2.4", "TheVendor.PublicLocalPack.1.2.4.pack")
+	packWithLicense    = filepath.Join(testDir, "TheVendor.PackWithLicense.1.2.3.pack")
+	pdscPackName       = filepath.Join(testDir, "1.2.3", "TheVendor.PackName.pdsc")
+)
+
+// newServer serves files over HTTPS on 127.0.0.1, for which cpackget skips
+// certificate verification
+func newServer(routes map[string][]byte) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := routes[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.Copy(w, bytes.NewReader(content))
+	}))
+}
+
+// roundTripperFunc answers HTTP requests without any network access
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newInstaller creates an Installer on a freshly initialized pack root
+func newInstaller(t *testing.T, packRoot string) *cpackget.Installer {
+	i, err := cpackget.New(cpackget.Options{PackRoot: packRoot})
+	assert.Nil(t, err)
+	assert.Nil(t, i.Init(ctx, cpackget.InitOptions{IndexURL: emptyPublicIndex}))
+	return i
+}
+
+// removePackRoot deletes a pack root left read-only by the installer
+func removePackRoot(packRoot string) {
+	utils.UnsetReadOnlyR(packRoot)
+	os.RemoveAll(packRoot)
+}
+
+func TestInstaller(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("test installer on missing pack root", func(t *testing.T) {
+		localTestingDir := "test-installer-on-missing-pack-root"
+		i, err := cpackget.New(cpackget.Options{PackRoot: localTestingDir})
+		assert.Nil(err)
+
+		_, err = i.ListInstalled()
+		assert.Equal(errs.ErrPackRootDoesNotExist, err)
+	})
+
+	t.Run("test installer init, add, list and remove", func(t *testing.T) {
+		localTestingDir := "test-installer-init-add-list-remove"
+		defer removePackRoot(localTestingDir)
+
+		i := newInstaller(t, localTestingDir)
+		assert.Equal(localTestingDir, i.PackRoot())
+		assert.True(utils.FileExists(filepath.Join(localTestingDir, ".Web", "index.pidx")))
+
+		assert.Nil(i.Add(ctx, publicLocalPack123, cpackget.AddOptions{NoDependencies: true}))
+
+		packs, err := i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 1)
+		assert.Equal("TheVendor::PublicLocalPack@1.2.3", packs[0].ID())
+		assert.Nil(packs[0].Err)
+
+		packs, err = i.ListCached()
+		assert.Nil(err)
+		assert.Len(packs, 1)
+		assert.Nil(packs[0].Err)
+
+		assert.Nil(i.Remove(ctx, "TheVendor.PublicLocalPack.1.2.3", cpackget.RemoveOptions{Purge: true}))
+
+		packs, err = i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 0)
+	})
+
+	t.Run("test installer download", func(t *testing.T) {
+		localTestingDir := "test-installer-download"
+		defer removePackRoot(localTestingDir)
+
+		i := newInstaller(t, localTestingDir)
+
+		downloadPath, err := i.Download(ctx, publicLocalPack123)
+		assert.Nil(err)
+		assert.Equal(filepath.Join(localTestingDir, ".Download", "TheVendor.PublicLocalPack.1.2.3.pack"), downloadPath)
+		assert.True(utils.FileExists(downloadPath))
+
+		packs, err := i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 0)
+	})
+
+	t.Run("test installer declines licenses without asking the user", func(t *testing.T) {
+		localTestingDir := "test-installer-declines-licenses"
+		defer removePackRoot(localTestingDir)
+
+		i := newInstaller(t, localTestingDir)
+
+		err := i.Add(ctx, packWithLicense, cpackget.AddOptions{})
+		assert.True(errors.Is(err, errs.ErrEula))
+		assert.Equal("EULA_DECLINED", errs.Code(err))
+
+		var packErr *errs.Error
+		assert.True(errors.As(err, &packErr))
+		assert.Equal(packWithLicense, packErr.PackID)
+
+		packs, err := i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 0)
+
+		declined := func(licenseTitle, licenseContents string) bool { return false }
+		assert.True(errors.Is(i.Add(ctx, packWithLicense, cpackget.AddOptions{AcceptLicense: declined}), errs.ErrEula))
+	})
+
+	t.Run("test installer accepts licenses through the callback", func(t *testing.T) {
+		localTestingDir := "test-installer-accepts-licenses"
+		defer removePackRoot(localTestingDir)
+
+		i := newInstaller(t, localTestingDir)
+
+		licenseContents := ""
+		accepted := func(title, contents string) bool {
+			licenseContents = contents
+			return true
+		}
+		assert.Nil(i.Add(ctx, packWithLicense, cpackget.AddOptions{AcceptLicense: accepted}))
+		assert.NotEqual("", licenseContents)
+
+		packs, err := i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 1)
+	})
+
+	t.Run("test installer add and remove pdsc", func(t *testing.T) {
+		localTestingDir := "test-installer-add-remove-pdsc"
+		defer removePackRoot(localTestingDir)
+
+		i := newInstaller(t, localTestingDir)
+
+		assert.Nil(i.Add(ctx, pdscPackName, cpackget.AddOptions{}))
+
+		packs, err := i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 1)
+		assert.True(packs[0].Local)
+		assert.Equal("TheVendor::PackName@1.2.3", packs[0].ID())
+
+		assert.Nil(i.Remove(ctx, pdscPackName, cpackget.RemoveOptions{}))
+
+		packs, err = i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 0)
+	})
+
+	t.Run("test installer downloads through the configured transport", func(t *testing.T) {
+		localTestingDir := "test-installer-downloads-through-transport"
+		defer removePackRoot(localTestingDir)
+
+		index, err := os.ReadFile(emptyPublicIndex)
+		assert.Nil(err)
+
+		var requestedURLs []string
+		transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			requestedURLs = append(requestedURLs, r.URL.String())
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(index)),
+				Request:    r,
+			}, nil
+		})
+
+		i, err := cpackget.New(cpackget.Options{PackRoot: localTestingDir, Transport: transport})
+		assert.Nil(err)
+		assert.Nil(i.Init(ctx, cpackget.InitOptions{IndexURL: "https://vendor.invalid/index.pidx"}))
+		assert.Equal([]string{"https://vendor.invalid/index.pidx"}, requestedURLs)
+	})
+
+	t.Run("test installer on an in-memory file system", func(t *testing.T) {
+		localTestingDir := "test-installer-in-memory"
+
+		index, err := os.ReadFile(emptyPublicIndex)
+		assert.Nil(err)
+		pack, err := os.ReadFile(publicLocalPack123)
+		assert.Nil(err)
+
+		memFs := afero.NewMemMapFs()
+		assert.Nil(afero.WriteFile(memFs, emptyPublicIndex, index, 0644))
+		assert.Nil(afero.WriteFile(memFs, publicLocalPack123, pack, 0644))
+
+		i, err := cpackget.New(cpackget.Options{PackRoot: localTestingDir, FileSystem: memFs})
+		assert.Nil(err)
+		assert.Nil(i.Init(ctx, cpackget.InitOptions{IndexURL: emptyPublicIndex}))
+		assert.Nil(i.Add(ctx, publicLocalPack123, cpackget.AddOptions{NoDependencies: true}))
+
+		packs, err := i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 1)
+
+		exists, err := afero.DirExists(memFs, filepath.Join(localTestingDir, "TheVendor", "PublicLocalPack", "1.2.3"))
+		assert.Nil(err)
+		assert.True(exists)
+		assert.False(utils.DirExists(localTestingDir))
+	})
+
+	t.Run("test installer logs through the configured logger", func(t *testing.T) {
+		localTestingDir := "test-installer-logs-through-logger"
+		defer removePackRoot(localTestingDir)
+
+		var output bytes.Buffer
+		logger := logrus.New()
+		logger.SetOutput(&output)
+		logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+		i, err := cpackget.New(cpackget.Options{PackRoot: localTestingDir, Logger: logger.WithField("tool", "test")})
+		assert.Nil(err)
+		assert.Nil(i.Init(ctx, cpackget.InitOptions{IndexURL: emptyPublicIndex}))
+		assert.Nil(i.Add(ctx, publicLocalPack123, cpackget.AddOptions{NoDependencies: true}))
+
+		assert.Contains(output.String(), "level=info msg=\"Extracting files to test-installer-logs-through-logger")
+		assert.Contains(output.String(), "\" tool=test\n")
+	})
+
+	t.Run("test installer stops once the context is canceled", func(t *testing.T) {
+		localTestingDir := "test-installer-stops-on-canceled-context"
+		defer removePackRoot(localTestingDir)
+
+		i := newInstaller(t, localTestingDir)
+
+		canceledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := i.Add(canceledCtx, publicLocalPack123, cpackget.AddOptions{})
+		assert.True(errors.Is(err, errs.ErrTerminatedByUser))
+		assert.True(errors.Is(err, context.Canceled))
+
+		packs, err := i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 0)
+
+		// Later operations are not affected by the canceled context
+		assert.Nil(i.Add(ctx, publicLocalPack123, cpackget.AddOptions{}))
+	})
+
+	t.Run("test installer lists broken packs", func(t *testing.T) {
+		localTestingDir := "test-installer-lists-broken-packs"
+		defer removePackRoot(localTestingDir)
+		pdscDir := "test-installer-lists-broken-packs-pdsc"
+		defer os.RemoveAll(pdscDir)
+
+		i := newInstaller(t, localTestingDir)
+
+		// Add a PDSC file that goes missing afterwards
+		assert.Nil(os.MkdirAll(pdscDir, 0700))
+		pdscPath := filepath.Join(pdscDir, filepath.Base(pdscPackName))
+		assert.Nil(utils.CopyFile(pdscPackName, pdscPath))
+		assert.Nil(i.Add(ctx, pdscPath, cpackget.AddOptions{}))
+		assert.Nil(os.Remove(pdscPath))
+
+		packs, err := i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 1)
+		assert.NotNil(packs[0].Err)
+
+		// Drop a file that is not named like a pack in .Download/
+		downloadDir := filepath.Join(localTestingDir, ".Download")
+		utils.UnsetReadOnly(downloadDir)
+		assert.Nil(os.WriteFile(filepath.Join(downloadDir, "NotAPack.pack"), []byte{}, 0600))
+
+		packs, err = i.ListCached()
+		assert.Nil(err)
+		assert.Len(packs, 1)
+		assert.NotNil(packs[0].Err)
+		assert.Equal(filepath.Join(downloadDir, "NotAPack.pack"), packs[0].File)
+	})
+
+	t.Run("test installer update index, list public and update", func(t *testing.T) {
+		localTestingDir := "test-installer-update-index-list-public-update"
+		defer removePackRoot(localTestingDir)
+
+		pack124, err := os.ReadFile(publicLocalPack124)
+		assert.Nil(err)
+
+		routes := map[string][]byte{"TheVendor.PublicLocalPack.1.2.4.pack": pack124}
+		server := newServer(routes)
+		defer server.Close()
+		serverURL := server.URL + "/"
+
+		routes["index.pidx"] = []byte(`<?xml version="1.0" encoding="UTF-8" ?>
+<index schemaVersion="1.1.0">
+<vendor>TheVendor</vendor>
+<url>` + serverURL + `</url>
+<pindex>
+  <pdsc url="` + serverURL + `" vendor="TheVendor" name="PublicLocalPack" version="1.2.4" />
+</pindex>
+</index>`)
+		routes["TheVendor.PublicLocalPack.pdsc"] = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<package>
+   <vendor>TheVendor</vendor>
+   <url>` + serverURL + `</url>
+   <name>PublicLocalPack</name>
+   <releases>
+      <release version="1.2.4">Newer release.</release>
+      <release version="1.2.3">New release.</release>
+   </releases>
+</package>`)
+
+		i, err := cpackget.New(cpackget.Options{PackRoot: localTestingDir})
+		assert.Nil(err)
+		assert.Nil(i.Init(ctx, cpackget.InitOptions{IndexURL: serverURL + "index.pidx"}))
+		assert.Nil(i.UpdateIndex(ctx, cpackget.UpdateIndexOptions{AllPdscFiles: true}))
+
+		packs, err := i.ListPublic()
+		assert.Nil(err)
+		assert.Len(packs, 1)
+		assert.Equal("TheVendor::PublicLocalPack@1.2.4", packs[0].ID())
+
+		assert.Nil(i.Add(ctx, publicLocalPack123, cpackget.AddOptions{}))
+		assert.Nil(i.Update(ctx, "TheVendor.PublicLocalPack", cpackget.UpdateOptions{}))
+
+		packs, err = i.ListInstalled()
+		assert.Nil(err)
+		assert.Len(packs, 2)
+		assert.Equal("TheVendor::PublicLocalPack@1.2.3", packs[0].ID())
+		assert.Equal("TheVendor::PublicLocalPack@1.2.4", packs[1].ID())
+	})
+}
+
+// TestMain keeps parsed metadata of the many throwaway pack roots
+// out of the user's cache directory
+func TestMain(m *testing.M) {
+	metadataCacheDir, err := os.MkdirTemp("", "cpackget-metadata")
+	if err != nil {
+		panic(err)
+	}
+	utils.SetMetadataCacheDir(metadataCacheDir)
+
+	code := m.Run()
+
+	os.RemoveAll(metadataCacheDir)
+	os.Exit(code)
+}
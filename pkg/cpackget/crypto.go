@@ -0,0 +1,133 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package cpackget
+
+import (
+	"runtime/debug"
+
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+)
+
+// modulePath is the path of the cpackget module in the build information
+const modulePath = "github.com/open-cmsis-pack/cpackget"
+
+// SignOptions configures Sign
+type SignOptions struct {
+	// CertPath is the path of the signer's X.509 certificate. Leave it
+	// empty to embed a PGP signature instead.
+	CertPath string
+
+	// KeyPath is the path of the signer's private key
+	KeyPath string
+
+	// OutputDir is where the signed pack is written to. Defaults to the current directory.
+	OutputDir string
+
+	// CertOnly embeds only the certificate, without signing the pack's contents
+	CertOnly bool
+
+	// EmbedChecksum embeds a checksum file into the pack before signing it, see EmbedChecksum
+	EmbedChecksum bool
+
+	// SkipCertValidation skips sanity checks on the certificate
+	SkipCertValidation bool
+
+	// Passphrase unlocks the PGP private key, the encrypted X.509 private key
+	// or PKCS#12 bundle. Leave it empty for unencrypted keys.
+	Passphrase []byte
+
+	// Version is the cpackget version recorded in the signature. Defaults
+	// to the version of the cpackget module the program was built with.
+	Version string
+}
+
+// VerifyOptions configures VerifySignature
+type VerifyOptions struct {
+	// PubKeyPath is the path of the publisher's PGP public key, needed for PGP signed packs only
+	PubKeyPath string
+
+	// SkipCertValidation skips sanity checks on the embedded certificate
+	SkipCertValidation bool
+
+	// KnownSigners is the JSON file the key the first verified pack of each
+	// vendor was signed with is recorded in. Packs of the vendor signed with
+	// another key are then refused. Empty disables trust on first use.
+	KnownSigners string
+
+	// Version is the cpackget version signatures are checked against. Defaults
+	// to the version of the cpackget module the program was built with.
+	Version string
+}
+
+// moduleVersion returns version, or the version of the cpackget module
+// found in the build information if it is empty
+func moduleVersion(version string) (string, error) {
+	if version != "" {
+		return version, nil
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", errs.ErrUnknownVersion
+	}
+
+	modules := append([]*debug.Module{&info.Main}, info.Deps...)
+	for _, module := range modules {
+		if module.Path != modulePath {
+			continue
+		}
+		if module.Replace != nil {
+			module = module.Replace
+		}
+		if module.Version != "" && module.Version != "(devel)" {
+			return module.Version, nil
+		}
+	}
+
+	return "", errs.ErrUnknownVersion
+}
+
+// CreateChecksum writes a .checksum file with the digests of all files in
+// the pack at packPath. The checksum file is written next to the pack
+// unless outputDir is given.
+func CreateChecksum(packPath, outputDir string) error {
+	return cryptography.GenerateChecksum(packPath, outputDir, cryptography.Hashes[0])
+}
+
+// EmbedChecksum embeds a checksum file of all files in the pack at packPath
+// into the pack itself. Adding the pack verifies the extracted files against it.
+func EmbedChecksum(packPath string) error {
+	return cryptography.EmbedChecksum(packPath, packPath, cryptography.Hashes[0])
+}
+
+// VerifyChecksum checks the pack at packPath against its .checksum file.
+// If checksumPath is empty, the checksum file is looked up next to the pack.
+func VerifyChecksum(packPath, checksumPath string) error {
+	return cryptography.VerifyChecksum(packPath, checksumPath)
+}
+
+// Sign embeds a signature into a copy of the pack at packPath.
+// It never prompts for the passphrase of keys, see SignOptions.Passphrase.
+func Sign(packPath string, options SignOptions) error {
+	version, err := moduleVersion(options.Version)
+	if err != nil {
+		return err
+	}
+	passphrase := options.Passphrase
+	if passphrase == nil {
+		passphrase = []byte{}
+	}
+	return cryptography.SignPack(packPath, options.CertPath, options.KeyPath, options.OutputDir, version, options.CertOnly, options.EmbedChecksum, options.SkipCertValidation, true, passphrase)
+}
+
+// VerifySignature checks the integrity and authenticity of a signed pack
+func VerifySignature(packPath string, options VerifyOptions) error {
+	version, err := moduleVersion(options.Version)
+	if err != nil {
+		return err
+	}
+	cryptography.SetKnownSigners(options.KnownSigners)
+	return cryptography.VerifyPackSignature(packPath, options.PubKeyPath, version, false, options.SkipCertValidation, true)
+}
@@ -0,0 +1,227 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+/* Copyright Contributors to the cpackget project. */
+
+package cpackget_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/open-cmsis-pack/cpackget/cmd/cryptography"
+	errs "github.com/open-cmsis-pack/cpackget/cmd/errors"
+	"github.com/open-cmsis-pack/cpackget/cmd/utils"
+	"github.com/open-cmsis-pack/cpackget/pkg/cpackget"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCertificate writes a self-signed certificate and its private key to dir
+func writeCertificate(t *testing.T, dir string) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "TheVendor", Organization: []string{"TheVendor"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	rawCert, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.Nil(t, err)
+
+	certPath := filepath.Join(dir, "TheVendor.pem")
+	keyPath := filepath.Join(dir, "TheVendor.key")
+	assert.Nil(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rawCert}), 0600))
+	assert.Nil(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	return certPath, keyPath
+}
+
+func TestChecksum(t *testing.T) {
+	assert := assert.New(t)
+
+	localTestingDir := "test-checksum"
+	assert.Nil(os.MkdirAll(localTestingDir, 0700))
+	defer os.RemoveAll(localTestingDir)
+
+	assert.Nil(cpackget.CreateChecksum(publicLocalPack123, localTestingDir))
+
+	checksumPath := filepath.Join(localTestingDir, "TheVendor.PublicLocalPack.1.2.3.sha256.checksum")
+	assert.True(utils.FileExists(checksumPath))
+	assert.Nil(cpackget.VerifyChecksum(publicLocalPack123, checksumPath))
+
+	assert.NotNil(cpackget.VerifyChecksum(publicLocalPack124, checksumPath))
+}
+
+func TestSignature(t *testing.T) {
+	assert := assert.New(t)
+
+	localTestingDir := "test-signature"
+	assert.Nil(os.MkdirAll(localTestingDir, 0700))
+	defer os.RemoveAll(localTestingDir)
+
+	certPath, keyPath := writeCertificate(t, localTestingDir)
+
+	options := cpackget.SignOptions{
+		CertPath:           certPath,
+		KeyPath:            keyPath,
+		OutputDir:          localTestingDir,
+		SkipCertValidation: true,
+		Version:            "v2.0.0",
+	}
+	assert.Nil(cpackget.Sign(publicLocalPack123, options))
+
+	signedPack := filepath.Join(localTestingDir, "TheVendor.PublicLocalPack.1.2.3.pack.signed")
+	assert.True(utils.FileExists(signedPack))
+
+	// Signing twice would overwrite the signed pack
+	assert.Equal(errs.ErrPathAlreadyExists, cpackget.Sign(publicLocalPack123, options))
+
+	assert.Nil(cpackget.VerifySignature(signedPack, cpackget.VerifyOptions{SkipCertValidation: true, Version: "v2.0.0"}))
+	assert.Equal(errs.ErrFileNotFound, cpackget.VerifySignature("DoesNotExist.pack", cpackget.VerifyOptions{Version: "v2.0.0"}))
+}
+
+func TestSignatureTrustOnFirstUse(t *testing.T) {
+	assert := assert.New(t)
+
+	localTestingDir := "test-signature-trust-on-first-use"
+	firstDir := filepath.Join(localTestingDir, "first")
+	otherDir := filepath.Join(localTestingDir, "other")
+	assert.Nil(os.MkdirAll(firstDir, 0700))
+	assert.Nil(os.MkdirAll(otherDir, 0700))
+	defer os.RemoveAll(localTestingDir)
+
+	sign := func(dir string) string {
+		certPath, keyPath := writeCertificate(t, dir)
+		assert.Nil(cpackget.Sign(publicLocalPack123, cpackget.SignOptions{
+			CertPath:           certPath,
+			KeyPath:            keyPath,
+			OutputDir:          dir,
+			SkipCertValidation: true,
+			Version:            "v2.0.0",
+		}))
+		return filepath.Join(dir, "TheVendor.PublicLocalPack.1.2.3.pack.signed")
+	}
+	firstPack := sign(firstDir)
+	otherPack := sign(otherDir)
+
+	options := cpackget.VerifyOptions{
+		KnownSigners:       filepath.Join(localTestingDir, "known_signers.json"),
+		SkipCertValidation: true,
+		Version:            "v2.0.0",
+	}
+	defer cryptography.SetKnownSigners("")
+
+	// The first signer is trusted and recorded
+	assert.Nil(cpackget.VerifySignature(firstPack, options))
+	assert.Nil(cpackget.VerifySignature(firstPack, options))
+	signers, err := cryptography.ReadKnownSigners(options.KnownSigners)
+	assert.Nil(err)
+	assert.Contains(signers["TheVendor"].Fingerprint, "x509:")
+	assert.Equal("TheVendor.PublicLocalPack.1.2.3.pack.signed", signers["TheVendor"].Pack)
+
+	// Packs signed with another key are refused
+	assert.Equal(errs.ErrSignerChanged, cpackget.VerifySignature(otherPack, options))
+
+	// Unless trust on first use is off
+	options.KnownSigners = ""
+	assert.Nil(cpackget.VerifySignature(otherPack, options))
+}
+
+func TestSignatureWithLockedKey(t *testing.T) {
+	assert := assert.New(t)
+
+	localTestingDir := "test-signature-with-locked-key"
+	assert.Nil(os.MkdirAll(localTestingDir, 0700))
+	defer os.RemoveAll(localTestingDir)
+
+	key, err := crypto.GenerateKey("TheVendor", "vendor@example.com", "x25519", 0)
+	assert.Nil(err)
+	lockedKey, err := key.Lock([]byte("secret"))
+	assert.Nil(err)
+	armoredKey, err := lockedKey.Armor()
+	assert.Nil(err)
+	keyPath := filepath.Join(localTestingDir, "TheVendor.asc")
+	assert.Nil(os.WriteFile(keyPath, []byte(armoredKey), 0600))
+
+	options := cpackget.SignOptions{
+		KeyPath:   keyPath,
+		OutputDir: localTestingDir,
+		Version:   "v2.0.0",
+	}
+
+	// The passphrase is never asked for
+	assert.NotNil(cpackget.Sign(publicLocalPack123, options))
+
+	options.Passphrase = []byte("secret")
+	assert.Nil(cpackget.Sign(publicLocalPack123, options))
+	assert.True(utils.FileExists(filepath.Join(localTestingDir, "TheVendor.PublicLocalPack.1.2.3.pack.signed")))
+}
+
+func TestSignatureKeyFormats(t *testing.T) {
+	assert := assert.New(t)
+
+	localTestingDir := "test-signature-key-formats"
+	assert.Nil(os.MkdirAll(localTestingDir, 0700))
+	defer os.RemoveAll(localTestingDir)
+
+	keysDir := filepath.Join("..", "..", "testdata", "keys")
+	signedPack := filepath.Join(localTestingDir, "TheVendor.PublicLocalPack.1.2.3.pack.signed")
+	sign := func(options cpackget.SignOptions) error {
+		os.Remove(signedPack)
+		options.OutputDir = localTestingDir
+		options.SkipCertValidation = true
+		options.Version = "v2.0.0"
+		if err := cpackget.Sign(publicLocalPack123, options); err != nil {
+			return err
+		}
+		return cpackget.VerifySignature(signedPack, cpackget.VerifyOptions{SkipCertValidation: true, Version: "v2.0.0"})
+	}
+
+	t.Run("test signing with a DER certificate and key", func(t *testing.T) {
+		certPath, keyPath := writeCertificate(t, localTestingDir)
+		for _, path := range []string{certPath, keyPath} {
+			raw, err := os.ReadFile(path)
+			assert.Nil(err)
+			block, _ := pem.Decode(raw)
+			der := block.Bytes
+			if block.Type == "RSA PRIVATE KEY" {
+				key, err := x509.ParsePKCS1PrivateKey(der)
+				assert.Nil(err)
+				der, err = x509.MarshalPKCS8PrivateKey(key)
+				assert.Nil(err)
+			}
+			assert.Nil(os.WriteFile(path+".der", der, 0600))
+		}
+		assert.Nil(sign(cpackget.SignOptions{CertPath: certPath + ".der", KeyPath: keyPath + ".der"}))
+	})
+
+	t.Run("test signing with a PKCS#12 bundle", func(t *testing.T) {
+		bundlePath := filepath.Join(keysDir, "TheVendor.p12")
+		options := cpackget.SignOptions{CertPath: bundlePath, KeyPath: bundlePath, Passphrase: []byte("secret")}
+		assert.Nil(sign(options))
+
+		options.Passphrase = []byte("wrong")
+		assert.NotNil(sign(options))
+	})
+
+	t.Run("test signing with an encrypted PKCS#8 key", func(t *testing.T) {
+		options := cpackget.SignOptions{
+			CertPath:   filepath.Join(keysDir, "TheVendor.pem"),
+			KeyPath:    filepath.Join(keysDir, "TheVendor.encrypted.key"),
+			Passphrase: []byte("secret"),
+		}
+		assert.Nil(sign(options))
+
+		// The passphrase is never asked for
+		options.Passphrase = nil
+		assert.NotNil(sign(options))
+	})
+}